@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
 )
 
 var (
@@ -46,18 +48,30 @@ Available features:
   scheduling      - Task scheduling
   storage         - Storage providers
 
+add only parses cmd/main.go and edits it, configs/config.yaml and, when go-micro-libs
+is not required yet, go.mod, so that it runs in milliseconds on any project size.
+
 Examples:
   microframework add ai --provider openai
   microframework add auth --provider jwt
   microframework add database --provider postgresql
   microframework add monitoring --provider prometheus`,
-	Args: cobra.ExactArgs(1),
-	RunE: runAdd,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFeatures,
+	RunE:              runAdd,
 }
 
 func init() {
 	addCmd.Flags().StringVarP(&addProvider, "provider", "p", "", "Specific provider to add (e.g., openai, jwt, postgresql)")
 	addCmd.Flags().StringVarP(&addConfig, "config", "c", "", "Configuration file path")
+
+	addCmd.RegisterFlagCompletionFunc("provider", completeAddProviders)
+}
+
+// addResult is the result of add in the JSON report
+type addResult struct {
+	Feature  string `json:"feature"`
+	Provider string `json:"provider,omitempty"`
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -65,7 +79,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	// Validate feature name
 	if err := validateFeatureName(feature); err != nil {
-		return fmt.Errorf("invalid feature name: %w", err)
+		return &UserError{fmt.Errorf("invalid feature name: %w", err)}
 	}
 
 	// Check if we're in a microservice directory
@@ -79,6 +93,8 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Provider: %s\n", addProvider)
 	}
 
+	reportResult(addResult{Feature: feature, Provider: addProvider})
+
 	// Add the feature based on type
 	switch feature {
 	case "api":
@@ -128,18 +144,13 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	case "storage":
 		return addStorageFeature(addProvider)
 	default:
-		return fmt.Errorf("unknown feature: %s", feature)
+		return &UserError{fmt.Errorf("unknown feature: %s", feature)}
 	}
 }
 
 // validateFeatureName validates the feature name
 func validateFeatureName(feature string) error {
-	validFeatures := []string{
-		"ai", "auth", "backup", "cache", "chaos", "circuitbreaker",
-		"communication", "config", "database", "discovery", "event",
-		"failover", "filegen", "logging", "messaging", "middleware",
-		"monitoring", "payment", "ratelimit", "scheduling", "storage", "api", "email",
-	}
+	validFeatures := addableFeatures()
 
 	for _, valid := range validFeatures {
 		if feature == valid {
@@ -154,14 +165,19 @@ func validateFeatureName(feature string) error {
 func checkMicroserviceDirectory() error {
 	// Check for go.mod file
 	if _, err := os.Stat("go.mod"); os.IsNotExist(err) {
-		return fmt.Errorf("not in a Go module directory. Please run this command from your microservice root directory")
+		return &UserError{fmt.Errorf("not in a Go module directory. Please run this command from your microservice root directory")}
+	}
+
+	// Projects adopted by microframework init keep their own structure
+	if _, err := os.Stat(generator.ManifestFile); err == nil {
+		return nil
 	}
 
 	// Check for typical microservice structure
 	requiredDirs := []string{"cmd", "internal", "configs"}
 	for _, dir := range requiredDirs {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			return fmt.Errorf("not in a microservice directory. Missing required directory: %s", dir)
+			return &UserError{fmt.Errorf("not in a microservice directory. Missing required directory: %s", dir)}
 		}
 	}
 
@@ -617,240 +633,192 @@ func addStorageFeature(provider string) error {
 
 // Helper functions for adding features
 func addDependency(dependency string) error {
-	// This would typically run `go get` command
-	fmt.Printf("Adding dependency: %s\n", dependency)
-	return nil
+	return requireModule(dependency)
 }
 
 func generateAPIConfig(provider string) error {
 	// Generate API configuration
-	fmt.Printf("Generating API configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("api", provider)
 }
 
 func generateAIConfig(provider string) error {
 	// Generate AI configuration
-	fmt.Printf("Generating AI configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("ai", provider)
 }
 
 func generateAuthConfig(provider string) error {
-	fmt.Printf("Generating authentication configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("auth", provider)
 }
 
 func generateBackupConfig(provider string) error {
-	fmt.Printf("Generating backup configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("backup", provider)
 }
 
 func generateCacheConfig(provider string) error {
-	fmt.Printf("Generating cache configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("cache", provider)
 }
 
 func generateChaosConfig(provider string) error {
-	fmt.Printf("Generating chaos engineering configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("chaos", provider)
 }
 
 func generateCircuitBreakerConfig(provider string) error {
-	fmt.Printf("Generating circuit breaker configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("circuitbreaker", provider)
 }
 
 func generateCommunicationConfig(provider string) error {
-	fmt.Printf("Generating communication configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("communication", provider)
 }
 
 func generateConfigConfig(provider string) error {
-	fmt.Printf("Generating configuration management for provider: %s\n", provider)
-	return nil
+	return addConfigSection("config", provider)
 }
 
 func generateDatabaseConfig(provider string) error {
-	fmt.Printf("Generating database configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("database", provider)
 }
 
 func generateDiscoveryConfig(provider string) error {
-	fmt.Printf("Generating service discovery configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("discovery", provider)
 }
 
 func generateEmailConfig(provider string) error {
-	fmt.Printf("Generating email configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("email", provider)
 }
 
 func generateEventConfig(provider string) error {
-	fmt.Printf("Generating event sourcing configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("event", provider)
 }
 
 func generateFailoverConfig(provider string) error {
-	fmt.Printf("Generating failover configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("failover", provider)
 }
 
 func generateFileGenConfig(provider string) error {
-	fmt.Printf("Generating file generation configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("filegen", provider)
 }
 
 func generateLoggingConfig(provider string) error {
-	fmt.Printf("Generating logging configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("logging", provider)
 }
 
 func generateMessagingConfig(provider string) error {
-	fmt.Printf("Generating messaging configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("messaging", provider)
 }
 
 func generateMiddlewareConfig(provider string) error {
-	fmt.Printf("Generating middleware configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("middleware", provider)
 }
 
 func generateMonitoringConfig(provider string) error {
-	fmt.Printf("Generating monitoring configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("monitoring", provider)
 }
 
 func generatePaymentConfig(provider string) error {
-	fmt.Printf("Generating payment processing configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("payment", provider)
 }
 
 func generateRateLimitConfig(provider string) error {
-	fmt.Printf("Generating rate limiting configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("ratelimit", provider)
 }
 
 func generateSchedulingConfig(provider string) error {
-	fmt.Printf("Generating task scheduling configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("scheduling", provider)
 }
 
 func generateStorageConfig(provider string) error {
-	fmt.Printf("Generating storage configuration for provider: %s\n", provider)
-	return nil
+	return addConfigSection("storage", provider)
 }
 
 // Functions to update main.go with new features
 func updateMainWithAPI() error {
-	fmt.Println("Updating main.go with API manager")
-	return nil
+	return addManagerToMain("api")
 }
 
 func updateMainWithAI() error {
-	fmt.Println("Updating main.go with AI manager")
-	return nil
+	return addManagerToMain("ai")
 }
 
 func updateMainWithAuth() error {
-	fmt.Println("Updating main.go with auth manager")
-	return nil
+	return addManagerToMain("auth")
 }
 
 func updateMainWithBackup() error {
-	fmt.Println("Updating main.go with backup manager")
-	return nil
+	return addManagerToMain("backup")
 }
 
 func updateMainWithCache() error {
-	fmt.Println("Updating main.go with cache manager")
-	return nil
+	return addManagerToMain("cache")
 }
 
 func updateMainWithChaos() error {
-	fmt.Println("Updating main.go with chaos manager")
-	return nil
+	return addManagerToMain("chaos")
 }
 
 func updateMainWithCircuitBreaker() error {
-	fmt.Println("Updating main.go with circuit breaker manager")
-	return nil
+	return addManagerToMain("circuitbreaker")
 }
 
 func updateMainWithCommunication() error {
-	fmt.Println("Updating main.go with communication manager")
-	return nil
+	return addManagerToMain("communication")
 }
 
 func updateMainWithConfig() error {
-	fmt.Println("Updating main.go with config manager")
-	return nil
+	return addManagerToMain("config")
 }
 
 func updateMainWithDatabase() error {
-	fmt.Println("Updating main.go with database manager")
-	return nil
+	return addManagerToMain("database")
 }
 
 func updateMainWithDiscovery() error {
-	fmt.Println("Updating main.go with discovery manager")
-	return nil
+	return addManagerToMain("discovery")
 }
 
 func updateMainWithEmail() error {
-	fmt.Println("Updating main.go with email manager")
-	return nil
+	return addManagerToMain("email")
 }
 
 func updateMainWithEvent() error {
-	fmt.Println("Updating main.go with event manager")
-	return nil
+	return addManagerToMain("event")
 }
 
 func updateMainWithFailover() error {
-	fmt.Println("Updating main.go with failover manager")
-	return nil
+	return addManagerToMain("failover")
 }
 
 func updateMainWithFileGen() error {
-	fmt.Println("Updating main.go with file generation manager")
-	return nil
+	return addManagerToMain("filegen")
 }
 
 func updateMainWithLogging() error {
-	fmt.Println("Updating main.go with logging manager")
-	return nil
+	return addManagerToMain("logging")
 }
 
 func updateMainWithMessaging() error {
-	fmt.Println("Updating main.go with messaging manager")
-	return nil
+	return addManagerToMain("messaging")
 }
 
 func updateMainWithMiddleware() error {
-	fmt.Println("Updating main.go with middleware manager")
-	return nil
+	return addManagerToMain("middleware")
 }
 
 func updateMainWithMonitoring() error {
-	fmt.Println("Updating main.go with monitoring manager")
-	return nil
+	return addManagerToMain("monitoring")
 }
 
 func updateMainWithPayment() error {
-	fmt.Println("Updating main.go with payment manager")
-	return nil
+	return addManagerToMain("payment")
 }
 
 func updateMainWithRateLimit() error {
-	fmt.Println("Updating main.go with rate limit manager")
-	return nil
+	return addManagerToMain("ratelimit")
 }
 
 func updateMainWithScheduling() error {
-	fmt.Println("Updating main.go with scheduling manager")
-	return nil
+	return addManagerToMain("scheduling")
 }
 
 func updateMainWithStorage() error {
-	fmt.Println("Updating main.go with storage manager")
-	return nil
+	return addManagerToMain("storage")
 }
@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// libsModule is the module of the go-micro-libs managers
+const libsModule = "github.com/anasamu/go-micro-libs"
+
+// featureManager is how main.go builds the go-micro-libs manager of a feature, as the
+// service template does
+type featureManager struct {
+	// Package is the package of the manager, under go-micro-libs
+	Package string
+	// Field is the field of the service struct holding the manager
+	Field string
+	// Type is the type of the manager, in Package
+	Type string
+	// Constructor builds the manager; with Fallible, it also returns an error
+	Constructor string
+	Fallible    bool
+	// Closes tells whether the manager is closed when the service stops
+	Closes bool
+}
+
+// featureManagers are the managers add wires into main.go, by feature. The managers of
+// config, logging, monitoring, middleware and communication are always there.
+var featureManagers = map[string]featureManager{
+	"ai":             {Package: "ai", Field: "ai", Type: "AIManager", Constructor: "ai.NewAIManager()"},
+	"api":            {Package: "api", Field: "api", Type: "APIManager", Constructor: "api.NewAPIManager(nil, logger)", Closes: true},
+	"auth":           {Package: "auth", Field: "auth", Type: "AuthManager", Constructor: "auth.NewAuthManager(nil, logger)", Closes: true},
+	"backup":         {Package: "backup", Field: "backup", Type: "BackupManager", Constructor: "backup.NewBackupManager()"},
+	"cache":          {Package: "cache", Field: "cache", Type: "CacheManager", Constructor: "cache.NewCacheManager(nil, logger)", Closes: true},
+	"chaos":          {Package: "chaos", Field: "chaos", Type: "Manager", Constructor: "chaos.NewManager()"},
+	"circuitbreaker": {Package: "circuitbreaker", Field: "circuitBreaker", Type: "CircuitBreakerManager", Constructor: "circuitbreaker.NewCircuitBreakerManager(nil, logger)", Closes: true},
+	"database":       {Package: "database", Field: "database", Type: "DatabaseManager", Constructor: "database.NewDatabaseManager(nil, logger)", Closes: true},
+	"discovery":      {Package: "discovery", Field: "discovery", Type: "DiscoveryManager", Constructor: "discovery.NewDiscoveryManager(nil, logger)", Closes: true},
+	"email":          {Package: "email", Field: "email", Type: "EmailManager", Constructor: "email.NewEmailManager(nil, logger)", Closes: true},
+	"event":          {Package: "event", Field: "event", Type: "EventSourcingManager", Constructor: "event.NewEventSourcingManager(nil, logger)", Closes: true},
+	"failover":       {Package: "failover", Field: "failover", Type: "FailoverManager", Constructor: "failover.NewFailoverManager(nil, logger)", Closes: true},
+	"filegen":        {Package: "filegen", Field: "fileGen", Type: "Manager", Constructor: "filegen.NewManager(nil)", Fallible: true, Closes: true},
+	"messaging":      {Package: "messaging", Field: "messaging", Type: "MessagingManager", Constructor: "messaging.NewMessagingManager(nil, logger)", Closes: true},
+	"payment":        {Package: "payment", Field: "payment", Type: "PaymentManager", Constructor: "payment.NewPaymentManager(nil, logger)"},
+	"ratelimit":      {Package: "ratelimit", Field: "rateLimit", Type: "RateLimitManager", Constructor: "ratelimit.NewRateLimitManager(nil, logger)", Closes: true},
+	"scheduling":     {Package: "scheduling", Field: "scheduling", Type: "SchedulingManager", Constructor: "scheduling.NewSchedulingManager(nil, logger)"},
+	"storage":        {Package: "storage", Field: "storage", Type: "StorageManager", Constructor: "storage.NewStorageManager(nil, logger)"},
+}
+
+// serviceMainFile returns the main.go of the service: cmd/main.go, or the only
+// cmd/<service>/main.go of projects upgraded to the layout of several services
+func serviceMainFile() (string, error) {
+	if _, err := os.Stat(filepath.Join("cmd", "main.go")); err == nil {
+		return filepath.Join("cmd", "main.go"), nil
+	}
+	matches, err := filepath.Glob(filepath.Join("cmd", "*", "main.go"))
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", &UserError{fmt.Errorf("no cmd/main.go or cmd/<service>/main.go found")}
+	case 1:
+		return matches[0], nil
+	}
+	return "", &UserError{fmt.Errorf("several services in cmd (%s); run add from a project with one main.go", strings.Join(matches, ", "))}
+}
+
+// addManagerToMain wires the manager of feature into main.go: its import, its field of the
+// service struct, its constructor and, when it has one, its Close. Only main.go is parsed,
+// never the packages of the project, so that add takes the same time on any project size.
+func addManagerToMain(feature string) error {
+	manager, ok := featureManagers[feature]
+	if !ok {
+		fmt.Printf("✓ main.go already builds the %s manager\n", feature)
+		return nil
+	}
+	path, err := serviceMainFile()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	edited, err := editMain(path, content, manager)
+	if err != nil {
+		return err
+	}
+	if edited == nil {
+		fmt.Printf("✓ %s already builds the %s manager\n", path, feature)
+		return nil
+	}
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	reportFiles(path)
+	fmt.Printf("Updated %s with the %s manager\n", path, feature)
+	return nil
+}
+
+// mainInsertion is text to insert into main.go at an offset
+type mainInsertion struct {
+	offset int
+	text   string
+}
+
+// editMain returns content, the main.go at path, building manager, or nil when it already
+// imports the package of the manager. The edits are insertions at offsets of the syntax
+// tree, so that the comments and layout of the file are kept, formatted with gofmt.
+func editMain(path string, content []byte, manager featureManager) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, &UserError{fmt.Errorf("failed to parse %s: %w", path, err)}
+	}
+	importPath := libsModule + "/" + manager.Package
+	for _, spec := range file.Imports {
+		if value, _ := strconv.Unquote(spec.Path.Value); value == importPath {
+			return nil, nil
+		}
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var insertions []mainInsertion
+	imports, ok := importBlock(file)
+	if !ok {
+		return nil, &UserError{fmt.Errorf("%s has no import block to add %s to", path, importPath)}
+	}
+	insertions = append(insertions, mainInsertion{offset(imports.Rparen), "\t" + strconv.Quote(importPath) + "\n"})
+
+	fields, ok := serviceStruct(file)
+	if !ok {
+		return nil, &UserError{fmt.Errorf("%s has no service struct; build the %s manager by hand", path, manager.Package)}
+	}
+	insertions = append(insertions, mainInsertion{offset(fields.Closing), fmt.Sprintf("\t%s *%s.%s\n", manager.Field, manager.Package, manager.Type)})
+
+	var built, closed bool
+	ast.Inspect(file, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		if literal := serviceLiteral(assign); literal != nil && !built {
+			// svc := &service{...}
+			built = true
+			if manager.Fallible {
+				variable := assign.Lhs[0].(*ast.Ident).Name
+				insertions = append(insertions, mainInsertion{offset(assign.End()), fmt.Sprintf(
+					"\n\t%[1]s, err := %[2]s\n\tif err != nil {\n\t\tlog.Fatal(\"Failed to initialize %[3]s:\", err)\n\t}\n\t%[4]s.%[1]s = %[1]s",
+					manager.Field, manager.Constructor, manager.Package, variable)})
+			} else {
+				insertions = append(insertions, mainInsertion{offset(literal.Rbrace), fmt.Sprintf("\t%s: %s,\n", manager.Field, manager.Constructor)})
+			}
+		}
+		if manager.Closes && !closed && isClosersDeclaration(assign) {
+			// The managers added last are closed first
+			closed = true
+			insertions = append(insertions, mainInsertion{offset(assign.End()), fmt.Sprintf("\n\tclosers = append(closers, s.%s)", manager.Field)})
+		}
+		return true
+	})
+	if !built {
+		return nil, &UserError{fmt.Errorf("%s does not build the service struct; build the %s manager by hand", path, manager.Package)}
+	}
+	if manager.Closes && !closed {
+		warnf("%s has no closers list; close the %s manager when the service stops", path, manager.Package)
+	}
+
+	sort.SliceStable(insertions, func(i, j int) bool { return insertions[i].offset < insertions[j].offset })
+	var edited bytes.Buffer
+	last := 0
+	for _, insertion := range insertions {
+		edited.Write(content[last:insertion.offset])
+		edited.WriteString(insertion.text)
+		last = insertion.offset
+	}
+	edited.Write(content[last:])
+
+	formatted, err := format.Source(edited.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	return formatted, nil
+}
+
+// importBlock returns the parenthesized import declaration of file
+func importBlock(file *ast.File) (*ast.GenDecl, bool) {
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && gen.Rparen.IsValid() {
+			return gen, true
+		}
+	}
+	return nil, false
+}
+
+// serviceStruct returns the fields of the service struct of file
+func serviceStruct(file *ast.File) (*ast.FieldList, bool) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.Name.Name == "service" {
+				return structType.Fields, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// serviceLiteral returns the &service{...} assign builds, if it builds the service struct
+func serviceLiteral(assign *ast.AssignStmt) *ast.CompositeLit {
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil
+	}
+	if _, ok := assign.Lhs[0].(*ast.Ident); !ok {
+		return nil
+	}
+	unary, ok := assign.Rhs[0].(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return nil
+	}
+	literal, ok := unary.X.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	if name, ok := literal.Type.(*ast.Ident); !ok || name.Name != "service" {
+		return nil
+	}
+	return literal
+}
+
+// isClosersDeclaration tells whether assign declares the closers list of the close method
+func isClosersDeclaration(assign *ast.AssignStmt) bool {
+	if assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+		return false
+	}
+	name, ok := assign.Lhs[0].(*ast.Ident)
+	return ok && name.Name == "closers"
+}
+
+// addConfigSection adds the section of feature, with provider, to configs/config.yaml when
+// the file has neither the section, at the top level or under optional, nor the provider in it
+func addConfigSection(feature, provider string) error {
+	path := filepath.Join("configs", "config.yaml")
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		warnf("no %s to add the %s configuration to", path, feature)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return &UserError{fmt.Errorf("failed to parse %s: %w", path, err)}
+	}
+	var root *yaml.Node
+	if len(document.Content) > 0 && document.Content[0].Kind == yaml.MappingNode {
+		root = document.Content[0]
+	}
+
+	section := mappingValue(root, feature)
+	if section == nil {
+		section = mappingValue(mappingValue(root, "optional"), feature)
+	}
+	var edited []byte
+	switch {
+	case section == nil:
+		providers := "  providers: {}\n"
+		if provider != "" {
+			providers = fmt.Sprintf("  providers:\n    %s: {}\n", provider)
+		}
+		edited = append(bytes.TrimRight(content, "\n"), fmt.Sprintf("\n\n# %s configuration, added by microframework add\n%s:\n%s", feature, feature, providers)...)
+	case provider == "" || mappingValue(mappingValue(section, "providers"), provider) != nil:
+		fmt.Printf("✓ %s already configures %s\n", path, feature)
+		return nil
+	default:
+		providers := mappingValue(section, "providers")
+		if providers == nil || providers.Style&yaml.FlowStyle != 0 || len(providers.Content) == 0 {
+			warnf("%s: add the %s provider to the %s section by hand", path, provider, feature)
+			return nil
+		}
+		// Insert the provider before the first one, at its indentation
+		first := providers.Content[0]
+		lines := strings.SplitAfter(string(content), "\n")
+		line := strings.Repeat(" ", first.Column-1) + provider + ": {}\n"
+		lines = append(lines[:first.Line-1], append([]string{line}, lines[first.Line-1:]...)...)
+		edited = []byte(strings.Join(lines, ""))
+	}
+
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	reportFiles(path)
+	fmt.Printf("Updated %s with the %s configuration\n", path, feature)
+	return nil
+}
+
+// mappingValue returns the value of key in the mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// requireModule makes go.mod require module. go.mod is only read when it requires the module
+// already, as generated projects do; else the module is added with go get.
+func requireModule(module string) error {
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return err
+	}
+	modFile, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return &UserError{fmt.Errorf("failed to parse go.mod: %w", err)}
+	}
+	for _, require := range modFile.Require {
+		if require.Mod.Path == module {
+			return nil
+		}
+	}
+
+	fmt.Printf("Adding dependency: %s\n", module)
+	if output, err := exec.Command("go", "get", module).CombinedOutput(); err != nil {
+		return fmt.Errorf("go get %s: %w\n%s", module, err, bytes.TrimSpace(output))
+	}
+	reportFiles("go.mod", "go.sum")
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+// BenchmarkAddFeature measures the edits of microframework add, of main.go, configs/config.yaml
+// and go.mod, on a generated service padded to 500 files: only those three files are read,
+// so the time does not depend on the size of the project.
+func BenchmarkAddFeature(b *testing.B) {
+	dir := b.TempDir()
+	config := &generator.GeneratorConfig{ServiceName: "orders", ServiceType: "rest"}
+	files, err := generator.NewServiceGenerator(config).RenderService()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := len(files); i < 500; i++ {
+		files[fmt.Sprintf("internal/generated/file%03d.go", i)] = []byte(fmt.Sprintf("package generated\n\n// Value%03d is padding\nconst Value%03d = %d\n", i, i, i))
+	}
+	for path, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.Chdir(dir)
+
+	mainGo, configYAML := files["cmd/main.go"], files["configs/config.yaml"]
+	stdout := os.Stdout
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if err := os.WriteFile(filepath.Join("cmd", "main.go"), mainGo, 0644); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join("configs", "config.yaml"), configYAML, 0644); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := addManagerToMain("cache"); err != nil {
+			b.Fatal(err)
+		}
+		if err := addConfigSection("cache", "redis"); err != nil {
+			b.Fatal(err)
+		}
+		if err := requireModule(libsModule); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.StopTimer()
+	edited, err := os.ReadFile(filepath.Join("cmd", "main.go"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if bytes.Equal(edited, mainGo) {
+		b.Fatal("main.go was not edited")
+	}
+}
@@ -0,0 +1,366 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	backstagePublish string
+	backstageHost    string
+	backstageOwner   string
+	backstageForce   bool
+)
+
+// backstageAction is the scaffolder action the exported templates generate services with
+const backstageAction = "microframework:new"
+
+// backstagePublishers are the hosts of the publish actions of --publish
+var backstagePublishers = map[string]string{
+	"github": "github.com",
+	"gitlab": "gitlab.com",
+}
+
+// backstageCmd represents the backstage command
+var backstageCmd = &cobra.Command{
+	Use:   "backstage",
+	Short: "Integrate the generators with a Backstage developer portal",
+	Long: `Integrate microframework with Backstage (backstage.io), so that platform teams front
+the CLI with their developer portal. The services it generates are registered in the software
+catalog by their catalog-info.yaml (see microframework new --owner and --system).`,
+}
+
+// backstageTemplatesCmd represents the backstage templates command
+var backstageTemplatesCmd = &cobra.Command{
+	Use:   "templates <dir>",
+	Short: "Export the generators as Backstage Software Templates",
+	Long: `Export the generators of microframework new as Backstage Software Templates in dir:
+
+  <type>/template.yaml       a template per service type, whose form asks for the name, owner,
+                             system and repository of the service and the providers of its
+                             features, and the template pack when packs are cached
+  catalog-info.yaml          the Location registering the templates in the catalog
+  actions/microframework.ts  the scaffolder action microframework:new the templates run,
+                             which runs microframework new in the workspace
+
+The templates generate the service with microframework:new, publish it with the publish
+action of --publish and register its catalog-info.yaml. Add the action to the scaffolder
+backend, with the CLI on the PATH of the backend, then register catalog-info.yaml.
+
+Examples:
+  microframework backstage templates ./backstage
+  microframework backstage templates ./backstage --publish gitlab --host gitlab.acme.com --owner group:platform`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackstageTemplates,
+}
+
+func init() {
+	backstageTemplatesCmd.Flags().StringVar(&backstagePublish, "publish", "github", "Publish action of the templates (github, gitlab)")
+	backstageTemplatesCmd.Flags().StringVar(&backstageHost, "host", "", "Host the repositories are created on (default github.com or gitlab.com)")
+	backstageTemplatesCmd.Flags().StringVar(&backstageOwner, "owner", "", "Owner of the templates in the catalog, as group:platform")
+	backstageTemplatesCmd.Flags().BoolVar(&backstageForce, "force", false, "Overwrite the templates in a directory that is not empty")
+	backstageTemplatesCmd.RegisterFlagCompletionFunc("publish", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return sortedKeys(backstagePublishers), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	backstageCmd.AddCommand(backstageTemplatesCmd)
+}
+
+// backstageEntity is an entity of the Backstage catalog
+type backstageEntity struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   backstageMetadata `yaml:"metadata"`
+	Spec       interface{}       `yaml:"spec"`
+}
+
+type backstageMetadata struct {
+	Name        string   `yaml:"name"`
+	Title       string   `yaml:"title,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+// backstageTemplateSpec is the spec of a Software Template
+type backstageTemplateSpec struct {
+	Owner      string                   `yaml:"owner,omitempty"`
+	Type       string                   `yaml:"type"`
+	Parameters []backstageParameters    `yaml:"parameters"`
+	Steps      []backstageStep          `yaml:"steps"`
+	Output     map[string][]interface{} `yaml:"output"`
+}
+
+// backstageParameters is a page of the form of a Software Template, a JSON schema
+type backstageParameters struct {
+	Title      string                 `yaml:"title"`
+	Required   []string               `yaml:"required,omitempty"`
+	Properties map[string]interface{} `yaml:"properties"`
+}
+
+// backstageStep is a step of a Software Template, running a scaffolder action
+type backstageStep struct {
+	ID     string                 `yaml:"id"`
+	Name   string                 `yaml:"name"`
+	Action string                 `yaml:"action"`
+	Input  map[string]interface{} `yaml:"input"`
+}
+
+func runBackstageTemplates(cmd *cobra.Command, args []string) error {
+	host, ok := backstagePublishers[backstagePublish]
+	if !ok {
+		return &UserError{fmt.Errorf("--publish must be one of: %s", strings.Join(sortedKeys(backstagePublishers), ", "))}
+	}
+	if backstageHost != "" {
+		host = backstageHost
+	}
+	if backstageOwner != "" && !catalogRefPattern.MatchString(backstageOwner) {
+		return &UserError{fmt.Errorf("invalid --owner %q: a Backstage entity name or reference, as group:default/platform", backstageOwner)}
+	}
+	dir := args[0]
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !backstageForce {
+		return &UserError{fmt.Errorf("%s is not empty; use --force to overwrite the templates in it", dir)}
+	}
+
+	// The template packs cached here are those the portal offers
+	var packs []string
+	if packsDir, err := templatePacksDir(); err == nil {
+		index, err := loadTemplatePackIndex(packsDir)
+		if err != nil {
+			return err
+		}
+		packs = sortedKeys(index)
+	}
+
+	files := make(map[string][]byte)
+	var targets []string
+	for _, serviceType := range serviceTypes {
+		template := backstageTemplate(serviceType, host, packs)
+		content, err := encodeBackstageEntities(template)
+		if err != nil {
+			return err
+		}
+		path := serviceType.Name + "/template.yaml"
+		files[path] = content
+		targets = append(targets, "./"+path)
+	}
+	location := backstageEntity{
+		APIVersion: "backstage.io/v1alpha1",
+		Kind:       "Location",
+		Metadata: backstageMetadata{
+			Name:        "microframework-templates",
+			Description: "Software Templates of the microframework generators",
+		},
+		Spec: map[string]interface{}{"targets": targets},
+	}
+	content, err := encodeBackstageEntities(location)
+	if err != nil {
+		return err
+	}
+	files["catalog-info.yaml"] = content
+	files["actions/microframework.ts"] = []byte(backstageActionSource)
+
+	paths := sortedKeys(files)
+	for _, path := range paths {
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, files[path], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		fmt.Printf("  %-7s %s\n", "create", filepath.ToSlash(target))
+		reportFiles(target)
+	}
+	reportResult(map[string]interface{}{"templates": len(serviceTypes), "publish": backstagePublish, "host": host, "template_packs": packs})
+
+	fmt.Printf("✓ Exported %d Software Templates to %s\n", len(serviceTypes), dir)
+	fmt.Println("\nNext steps:")
+	fmt.Printf("1. Add the %s action of %s to the scaffolder backend, with microframework on its PATH\n", backstageAction, filepath.Join(dir, "actions", "microframework.ts"))
+	fmt.Printf("2. Register %s in the catalog, from the catalog import page or the catalog.locations of app-config.yaml\n", filepath.Join(dir, "catalog-info.yaml"))
+	return nil
+}
+
+// backstageTemplate returns the Software Template generating the services of a type
+func backstageTemplate(serviceType catalogEntry, host string, packs []string) backstageEntity {
+	service := backstageParameters{
+		Title:    "Service",
+		Required: []string{"name", "owner"},
+		Properties: map[string]interface{}{
+			"name": map[string]interface{}{
+				"title":        "Name",
+				"type":         "string",
+				"description":  "Name of the service, 3 to 50 letters, digits and hyphens",
+				"pattern":      "^[a-zA-Z0-9][a-zA-Z0-9-]{1,48}[a-zA-Z0-9]$",
+				"ui:autofocus": true,
+			},
+			"owner": map[string]interface{}{
+				"title":       "Owner",
+				"type":        "string",
+				"description": "Owner of the service in the catalog",
+				"ui:field":    "OwnerPicker",
+				"ui:options":  map[string]interface{}{"catalogFilter": map[string]interface{}{"kind": []string{"Group", "User"}}},
+			},
+			"system": map[string]interface{}{
+				"title":       "System",
+				"type":        "string",
+				"description": "System the service is part of",
+				"ui:field":    "EntityPicker",
+				"ui:options":  map[string]interface{}{"catalogFilter": map[string]interface{}{"kind": "System"}},
+			},
+		},
+	}
+
+	// The features of new, a choice of their providers or a checkbox
+	featureProperties := make(map[string]interface{})
+	flags := make(map[string]interface{})
+	for _, feature := range features {
+		if feature.Flag == "" {
+			continue
+		}
+		property := map[string]interface{}{"title": feature.Description}
+		if len(feature.Providers) > 0 {
+			property["type"] = "string"
+			property["enum"] = append([]string{""}, feature.Providers...)
+			property["default"] = ""
+		} else {
+			property["type"] = "boolean"
+			property["default"] = false
+		}
+		featureProperties[feature.Name] = property
+		flags[feature.Flag] = "${{ parameters." + feature.Name + " }}"
+	}
+	input := map[string]interface{}{
+		"name":   "${{ parameters.name }}",
+		"type":   serviceType.Name,
+		"owner":  "${{ parameters.owner }}",
+		"system": "${{ parameters.system }}",
+		"flags":  flags,
+	}
+	if len(packs) > 0 {
+		featureProperties["templatePack"] = map[string]interface{}{
+			"title":   "Template pack",
+			"type":    "string",
+			"enum":    append([]string{""}, packs...),
+			"default": "",
+		}
+		input["templatePack"] = "${{ parameters.templatePack }}"
+	}
+
+	repository := backstageParameters{
+		Title:    "Repository",
+		Required: []string{"repoUrl"},
+		Properties: map[string]interface{}{
+			"repoUrl": map[string]interface{}{
+				"title":      "Repository",
+				"type":       "string",
+				"ui:field":   "RepoUrlPicker",
+				"ui:options": map[string]interface{}{"allowedHosts": []string{host}},
+			},
+		},
+	}
+
+	return backstageEntity{
+		APIVersion: "scaffolder.backstage.io/v1beta3",
+		Kind:       "Template",
+		Metadata: backstageMetadata{
+			Name:        "microframework-" + serviceType.Name,
+			Title:       serviceType.Description,
+			Description: serviceType.Description + ", generated with microframework new --type " + serviceType.Name,
+			Tags:        []string{"go", "microframework", serviceType.Name},
+		},
+		Spec: backstageTemplateSpec{
+			Owner:      backstageOwner,
+			Type:       "service",
+			Parameters: []backstageParameters{service, {Title: "Features", Properties: featureProperties}, repository},
+			Steps: []backstageStep{
+				{ID: "generate", Name: "Generate the service", Action: backstageAction, Input: input},
+				{ID: "publish", Name: "Publish", Action: "publish:" + backstagePublish, Input: map[string]interface{}{
+					"repoUrl":     "${{ parameters.repoUrl }}",
+					"sourcePath":  "./${{ parameters.name }}",
+					"description": serviceType.Description + " ${{ parameters.name }}",
+				}},
+				{ID: "register", Name: "Register", Action: "catalog:register", Input: map[string]interface{}{
+					"repoContentsUrl": "${{ steps.publish.output.repoContentsUrl }}",
+					"catalogInfoPath": "/catalog-info.yaml",
+				}},
+			},
+			Output: map[string][]interface{}{"links": {
+				map[string]string{"title": "Repository", "url": "${{ steps.publish.output.remoteUrl }}"},
+				map[string]string{"title": "Open in catalog", "icon": "catalog", "entityRef": "${{ steps.register.output.entityRef }}"},
+			}},
+		},
+	}
+}
+
+// encodeBackstageEntities renders an entity as YAML, with the two-space indentation of the
+// templates
+func encodeBackstageEntities(entity backstageEntity) ([]byte, error) {
+	var document yaml.Node
+	if err := document.Encode(entity); err != nil {
+		return nil, err
+	}
+	return encodeConfigDocument(&document)
+}
+
+// backstageActionSource is the scaffolder action the exported templates run
+const backstageActionSource = `// Scaffolder action microframework:new, generating a service with microframework new in the
+// workspace of the templates exported with microframework backstage templates. The
+// microframework CLI must be on the PATH of the scaffolder backend, which adds the action:
+//
+//   backend.add(createBackendModule({
+//     pluginId: 'scaffolder',
+//     moduleId: 'microframework',
+//     register(env) {
+//       env.registerInit({
+//         deps: { scaffolder: scaffolderActionsExtensionPoint },
+//         async init({ scaffolder }) {
+//           scaffolder.addActions(microframeworkNewAction());
+//         },
+//       });
+//     },
+//   }));
+import {
+  createTemplateAction,
+  executeShellCommand,
+} from '@backstage/plugin-scaffolder-node';
+
+export const microframeworkNewAction = () =>
+  createTemplateAction<{
+    name: string;
+    type: string;
+    owner?: string;
+    system?: string;
+    templatePack?: string;
+    flags?: Record<string, string | boolean>;
+  }>({
+    id: 'microframework:new',
+    description: 'Generates a service with microframework new',
+    async handler(ctx) {
+      const { name, type, owner, system, templatePack, flags = {} } = ctx.input;
+      const args = ['new', name, '--type', type, '--dir', ctx.workspacePath];
+      if (owner) {
+        args.push('--owner', owner);
+      }
+      if (system) {
+        args.push('--system', system);
+      }
+      if (templatePack) {
+        args.push('--template-pack', templatePack);
+      }
+      // Features are given a provider, or checked for those without providers
+      for (const [flag, value] of Object.entries(flags)) {
+        if (value === true) {
+          args.push(` + "`${flag}=true`" + `);
+        } else if (typeof value === 'string' && value !== '') {
+          args.push(` + "`${flag}=${value}`" + `);
+        }
+      }
+      await executeShellCommand({ command: 'microframework', args, logger: ctx.logger });
+    },
+  });
+`
@@ -0,0 +1,469 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchPattern   string
+	benchCount     int
+	benchTime      string
+	benchCPU       string
+	benchTags      string
+	benchBaseline  string
+	benchSave      bool
+	benchThreshold float64
+	benchAlpha     float64
+)
+
+// benchMinSamples is the number of runs of each side below which the comparison cannot be
+// significant, and a regression is decided by the delta alone
+const benchMinSamples = 4
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench [packages]",
+	Short: "Run the benchmarks and compare them with a baseline",
+	Long: `Run the benchmarks of the service with consistent flags and compare them with a baseline.
+
+The benchmarks run --count times each with -benchmem, so the comparison sees the noise of
+every benchmark. The results are compared with --baseline like benchstat does: the medians
+of each metric (sec/op, B/op, allocs/op and custom metrics) and a Mann-Whitney U test of
+the samples. A metric that got worse by more than --threshold percent, with a p-value
+below --alpha, is a regression and fails the run.
+
+With --save, or when there is no baseline yet, the run is saved as the baseline. The file
+is the output of go test -bench, so benchstat reads it too.
+
+Examples:
+  microframework bench
+  microframework bench --save
+  microframework bench ./internal/... --bench 'Handler' --threshold 5
+  microframework bench --count 10 --benchtime 2s`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBench(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchPattern, "bench", ".", "Run only the benchmarks matching the regular expression")
+	benchCmd.Flags().IntVar(&benchCount, "count", 6, "Runs of each benchmark")
+	benchCmd.Flags().StringVar(&benchTime, "benchtime", "1s", "Duration or iterations (e.g. 100x) of each run")
+	benchCmd.Flags().StringVar(&benchCPU, "cpu", "", "GOMAXPROCS values to run with, comma separated")
+	benchCmd.Flags().StringVar(&benchTags, "tags", "", "Build tags, comma separated")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", filepath.Join(".microframework", "bench-baseline.txt"), "Baseline file")
+	benchCmd.Flags().BoolVar(&benchSave, "save", false, "Save the run as the baseline")
+	benchCmd.Flags().Float64Var(&benchThreshold, "threshold", 10, "Change of a metric, in percent, above which it is a regression")
+	benchCmd.Flags().Float64Var(&benchAlpha, "alpha", 0.05, "Significance level of the comparison")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return &UserError{err}
+	}
+	if benchCount < 1 {
+		return &UserError{fmt.Errorf("--count must be at least 1")}
+	}
+	if benchThreshold < 0 || benchAlpha <= 0 || benchAlpha >= 1 {
+		return &UserError{fmt.Errorf("--threshold must be positive and --alpha between 0 and 1")}
+	}
+	packages := args
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output, err := runBenchmarks(ctx, packages)
+	if err != nil {
+		return err
+	}
+	current := parseBenchmarks(output)
+	if len(current.results) == 0 {
+		return fmt.Errorf("no benchmark matched %q", benchPattern)
+	}
+
+	baselineContent, err := os.ReadFile(benchBaseline)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the baseline: %w", err)
+	}
+	if benchSave || baselineContent == nil {
+		if err := os.MkdirAll(filepath.Dir(benchBaseline), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(benchBaseline, output, 0644); err != nil {
+			return fmt.Errorf("failed to save the baseline: %w", err)
+		}
+		if baselineContent == nil {
+			fmt.Printf("\nNo baseline yet: saved the run as %s\n", benchBaseline)
+			return nil
+		}
+		fmt.Printf("\nSaved the run as the baseline %s\n", benchBaseline)
+	}
+
+	baseline := parseBenchmarks(baselineContent)
+	for _, key := range []string{"goos", "goarch", "cpu"} {
+		if baseline.config[key] != "" && current.config[key] != "" && baseline.config[key] != current.config[key] {
+			fmt.Printf("\nWarning: the baseline ran with %s %s and this run with %s; the comparison may be meaningless\n",
+				key, baseline.config[key], current.config[key])
+		}
+	}
+
+	comparisons := compareBenchmarks(baseline, current)
+	printBenchComparisons(os.Stdout, comparisons)
+
+	var regressions []string
+	for _, comparison := range comparisons {
+		if comparison.regression {
+			regressions = append(regressions, fmt.Sprintf("%s %s %+.1f%%", comparison.name, comparison.unit, comparison.delta))
+		}
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("regressions beyond %.0f%%: %s", benchThreshold, strings.Join(regressions, ", "))
+	}
+	fmt.Printf("\nNo regression beyond %.0f%%\n", benchThreshold)
+	return nil
+}
+
+// runBenchmarks runs go test -bench, printing its output as it runs, and returns the output
+func runBenchmarks(ctx context.Context, packages []string) ([]byte, error) {
+	args := []string{"test", "-run", "^$", "-bench", benchPattern, "-benchmem",
+		"-count", strconv.Itoa(benchCount), "-benchtime", benchTime}
+	if benchCPU != "" {
+		args = append(args, "-cpu", benchCPU)
+	}
+	if benchTags != "" {
+		args = append(args, "-tags", benchTags)
+	}
+
+	var output bytes.Buffer
+	bench := exec.CommandContext(ctx, "go", append(args, packages...)...)
+	bench.Stdout = io.MultiWriter(os.Stdout, &output)
+	bench.Stderr = os.Stderr
+	if err := bench.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("interrupted")
+		}
+		return nil, fmt.Errorf("benchmarks failed: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// benchRun is the parsed output of go test -bench
+type benchRun struct {
+	// config holds the goos, goarch and cpu lines
+	config map[string]string
+	// results holds the samples of each metric of each benchmark, by "package.Benchmark"
+	// and unit
+	results map[string]map[string][]float64
+	order   []string
+}
+
+// benchLinePattern matches a result line: the name, the iterations and the metrics
+var benchLinePattern = regexp.MustCompile(`^(Benchmark\S+)\s+(\d+)\s+(.+)$`)
+
+// parseBenchmarks parses the output of go test -bench
+func parseBenchmarks(output []byte) benchRun {
+	run := benchRun{config: make(map[string]string), results: make(map[string]map[string][]float64)}
+	pkg := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if key, value, ok := strings.Cut(line, ": "); ok && !strings.Contains(key, " ") {
+			switch key {
+			case "pkg":
+				pkg = value
+				continue
+			case "goos", "goarch", "cpu":
+				run.config[key] = value
+				continue
+			}
+		}
+		match := benchLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name := match[1]
+		if pkg != "" {
+			name = shortPackage(pkg) + "." + name
+		}
+		fields := strings.Fields(match[3])
+		for i := 0; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			unit := fields[i+1]
+			if unit == "ns/op" {
+				// Compared in seconds, like benchstat, so the deltas read the same
+				value, unit = value/1e9, "sec/op"
+			}
+			if run.results[name] == nil {
+				run.results[name] = make(map[string][]float64)
+				run.order = append(run.order, name)
+			}
+			run.results[name][unit] = append(run.results[name][unit], value)
+		}
+	}
+	return run
+}
+
+// shortPackage returns the last element of an import path, which keeps the names short
+func shortPackage(pkg string) string {
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
+
+// benchComparison is the comparison of a metric of a benchmark
+type benchComparison struct {
+	name          string
+	unit          string
+	before, after float64
+	delta         float64
+	p             float64
+	samples       [2]int
+	regression    bool
+	// only is "old" or "new" when the benchmark is missing from the other run
+	only string
+}
+
+// compareBenchmarks compares the metrics of the benchmarks of both runs
+func compareBenchmarks(baseline, current benchRun) []benchComparison {
+	var comparisons []benchComparison
+	names := append([]string{}, current.order...)
+	for _, name := range baseline.order {
+		if current.results[name] == nil {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		units := make(map[string]bool)
+		for unit := range baseline.results[name] {
+			units[unit] = true
+		}
+		for unit := range current.results[name] {
+			units[unit] = true
+		}
+		sorted := make([]string, 0, len(units))
+		for unit := range units {
+			sorted = append(sorted, unit)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return unitOrder(sorted[i]) < unitOrder(sorted[j]) })
+
+		for _, unit := range sorted {
+			before, after := baseline.results[name][unit], current.results[name][unit]
+			comparison := benchComparison{name: name, unit: unit, samples: [2]int{len(before), len(after)}, p: 1}
+			switch {
+			case len(before) == 0:
+				comparison.only, comparison.after = "new", median(after)
+			case len(after) == 0:
+				comparison.only, comparison.before = "old", median(before)
+			default:
+				comparison.before, comparison.after = median(before), median(after)
+				if comparison.before != 0 {
+					comparison.delta = (comparison.after - comparison.before) / comparison.before * 100
+				}
+				comparison.p = mannWhitneyU(before, after)
+				significant := comparison.p <= benchAlpha || (len(before) < benchMinSamples && len(after) < benchMinSamples)
+				comparison.regression = significant && worse(unit, comparison.delta) > benchThreshold
+			}
+			comparisons = append(comparisons, comparison)
+		}
+	}
+	return comparisons
+}
+
+// unitOrder sorts the units like go test prints them, custom metrics last
+func unitOrder(unit string) string {
+	switch unit {
+	case "sec/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
+
+// worse returns how much worse a delta is, in percent: more is worse, except for throughput
+// metrics (MB/s and the other .../s)
+func worse(unit string, delta float64) float64 {
+	if strings.HasSuffix(unit, "/s") {
+		return -delta
+	}
+	return delta
+}
+
+func median(samples []float64) float64 {
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test of two samples: the
+// exact distribution without ties, the normal approximation with a tie correction otherwise
+func mannWhitneyU(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	type ranked struct {
+		value float64
+		first bool
+	}
+	all := make([]ranked, 0, n1+n2)
+	for _, value := range x {
+		all = append(all, ranked{value, true})
+	}
+	for _, value := range y {
+		all = append(all, ranked{value, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank sum of x, with the average rank for ties
+	var rankSum, tieCorrection float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorrection += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+
+	if !ties {
+		// Exact: ways[k] is the number of arrangements with U = k
+		ways := uDistribution(n1, n2)
+		var total, tail float64
+		low := math.Min(u, float64(n1*n2)-u)
+		for k, count := range ways {
+			total += count
+			if float64(k) <= low {
+				tail += count
+			}
+		}
+		return math.Min(1, 2*tail/total)
+	}
+
+	n := float64(n1 + n2)
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		return 1
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// uDistribution returns the number of arrangements of n1 and n2 values giving each U
+func uDistribution(n1, n2 int) []float64 {
+	// counts[i][j][u]: arrangements of i and j values with statistic u
+	counts := make([][][]float64, n1+1)
+	for i := range counts {
+		counts[i] = make([][]float64, n2+1)
+		for j := range counts[i] {
+			counts[i][j] = make([]float64, i*j+1)
+			switch {
+			case i == 0 || j == 0:
+				counts[i][j][0] = 1
+			default:
+				// The largest value is from the first sample, above the j others, or from
+				// the second one
+				for u := range counts[i][j] {
+					if u-j >= 0 && u-j < len(counts[i-1][j]) {
+						counts[i][j][u] += counts[i-1][j][u-j]
+					}
+					if u < len(counts[i][j-1]) {
+						counts[i][j][u] += counts[i][j-1][u]
+					}
+				}
+			}
+		}
+	}
+	return counts[n1][n2]
+}
+
+// printBenchComparisons prints the comparisons as a table
+func printBenchComparisons(out io.Writer, comparisons []benchComparison) {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out)
+	fmt.Fprintf(writer, "BENCHMARK\tUNIT\tBASELINE\tCURRENT\tDELTA\t\n")
+	for _, comparison := range comparisons {
+		before, after, delta := formatBenchValue(comparison.before), formatBenchValue(comparison.after), ""
+		switch {
+		case comparison.only == "new":
+			before, delta = "-", "new"
+		case comparison.only == "old":
+			after, delta = "-", "removed"
+		case comparison.p > benchAlpha && !(comparison.samples[0] < benchMinSamples && comparison.samples[1] < benchMinSamples):
+			delta = fmt.Sprintf("~ (p=%.3f n=%d+%d)", comparison.p, comparison.samples[0], comparison.samples[1])
+		default:
+			delta = fmt.Sprintf("%+.2f%% (p=%.3f n=%d+%d)", comparison.delta, comparison.p, comparison.samples[0], comparison.samples[1])
+		}
+		flag := ""
+		if comparison.regression {
+			flag = "REGRESSION"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", comparison.name, comparison.unit, before, after, delta, flag)
+	}
+	writer.Flush()
+}
+
+// formatBenchValue formats a metric with an SI prefix, like benchstat
+func formatBenchValue(value float64) string {
+	abs := math.Abs(value)
+	switch {
+	case abs == 0:
+		return "0"
+	case abs >= 1e9:
+		return strconv.FormatFloat(value/1e9, 'f', 2, 64) + "G"
+	case abs >= 1e6:
+		return strconv.FormatFloat(value/1e6, 'f', 2, 64) + "M"
+	case abs >= 1e3:
+		return strconv.FormatFloat(value/1e3, 'f', 2, 64) + "k"
+	case abs >= 1:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	case abs >= 1e-3:
+		return strconv.FormatFloat(value*1e3, 'f', 2, 64) + "m"
+	case abs >= 1e-6:
+		return strconv.FormatFloat(value*1e6, 'f', 2, 64) + "µ"
+	default:
+		return strconv.FormatFloat(value*1e9, 'f', 2, 64) + "n"
+	}
+}
@@ -0,0 +1,491 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	changelogFrom          string
+	changelogTagPrefix     string
+	changelogOutput        string
+	changelogWrite         bool
+	changelogUpdateVersion bool
+	changelogTag           bool
+)
+
+// The version bumps a release makes
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate the release notes of the service from its commits",
+	Long: `Generate the release notes of the service in the current directory from the conventional
+commits (feat: ..., fix(api)!: ...) made to it since its last release tag.
+
+The commits are grouped into breaking changes (a ! after the type, or a BREAKING CHANGE
+footer), features (feat) and fixes (fix, perf); other types are left out. The next version
+bumps the major version for breaking changes (the minor one before 1.0.0), the minor one
+for features and the patch one for fixes.
+
+Release tags are the semantic versions prefixed with the path of the service in the
+repository, as Go tags the modules of subdirectories: orders/v1.2.0 for a service in
+orders/, v1.2.0 for one at the root. Without a tag, the commits since the first are used
+and the version of configs/config.yaml is the current one.
+
+--write adds the release notes to the CHANGELOG.md of the service. --update-version sets
+the version in configs/config.yaml, the image tag of the Kubernetes deployment, and where
+the Makefile, Dockerfile or CI workflows of the project embed it: VERSION variables and
+-ldflags "-X ...version=" flags. --tag creates the release tag, after committing the files
+the other two changed.
+
+Examples:
+  microframework changelog
+  microframework changelog --write --update-version --tag
+  microframework changelog --from v1.0.0 --output json`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogFrom, "from", "", "Revision to start from (default: the last release tag)")
+	changelogCmd.Flags().StringVar(&changelogTagPrefix, "tag-prefix", "", "Prefix of the release tags (default: the path of the service in the repository)")
+	changelogCmd.Flags().StringVarP(&changelogOutput, "output", "o", "markdown", "Output format (markdown, json)")
+	changelogCmd.Flags().BoolVar(&changelogWrite, "write", false, "Add the release notes to CHANGELOG.md")
+	changelogCmd.Flags().BoolVar(&changelogUpdateVersion, "update-version", false, "Set the new version in the configuration and build files")
+	changelogCmd.Flags().BoolVar(&changelogTag, "tag", false, "Create the release tag")
+	changelogCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"markdown", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// conventionalCommit is a commit whose message follows the conventional commits format
+type conventionalCommit struct {
+	Hash    string `json:"hash"`
+	Type    string `json:"type"`
+	Scope   string `json:"scope,omitempty"`
+	Subject string `json:"subject"`
+	// Breaking is the description of the breaking change of the commit, empty for others
+	Breaking string `json:"breaking,omitempty"`
+}
+
+// serviceRelease is the release notes of a service and the version they make
+type serviceRelease struct {
+	Service  string               `json:"service"`
+	Previous string               `json:"previous"`
+	Version  string               `json:"version"`
+	Bump     string               `json:"bump"`
+	Tag      string               `json:"tag"`
+	Date     string               `json:"date"`
+	Breaking []conventionalCommit `json:"breaking"`
+	Features []conventionalCommit `json:"features"`
+	Fixes    []conventionalCommit `json:"fixes"`
+	// Skipped counts the commits left out: other types and unconventional messages
+	Skipped int `json:"skipped"`
+}
+
+// conventionalCommitPattern matches the header of a conventional commit: type(scope)!: subject
+var conventionalCommitPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// breakingFooterPattern matches the breaking change footer of a commit message
+var breakingFooterPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s*(.+)$`)
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if changelogOutput != "markdown" && changelogOutput != "json" {
+		return fmt.Errorf("invalid output format: %s (valid: markdown, json)", changelogOutput)
+	}
+
+	prefix := changelogTagPrefix
+	if !cmd.Flags().Changed("tag-prefix") {
+		output, err := exec.Command("git", "rev-parse", "--show-prefix").Output()
+		if err != nil {
+			return fmt.Errorf("the service is not in a git repository: %w", err)
+		}
+		prefix = strings.TrimSpace(string(output))
+	}
+
+	notes := &serviceRelease{Service: projectServiceName(), Date: time.Now().Format("2006-01-02")}
+	from := changelogFrom
+	lastTag, err := lastReleaseTag(prefix)
+	if err != nil {
+		return err
+	}
+	if lastTag != "" {
+		notes.Previous = strings.TrimPrefix(lastTag, prefix)
+		if from == "" {
+			from = lastTag
+		}
+	} else {
+		notes.Previous = "v0.0.0"
+		if version := configuredServiceVersion(); semver.IsValid("v" + version) {
+			notes.Previous = "v" + version
+		}
+	}
+
+	commits, skipped, err := conventionalCommitsSince(from)
+	if err != nil {
+		return err
+	}
+	notes.Skipped = skipped
+	for _, commit := range commits {
+		switch {
+		case commit.Breaking != "":
+			notes.Breaking = append(notes.Breaking, commit)
+		case commit.Type == "feat":
+			notes.Features = append(notes.Features, commit)
+		case commit.Type == "fix" || commit.Type == "perf":
+			notes.Fixes = append(notes.Fixes, commit)
+		default:
+			notes.Skipped++
+		}
+	}
+	notes.Bump, notes.Version = nextVersion(notes.Previous, notes)
+	notes.Tag = prefix + notes.Version
+
+	if changelogOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(notes); err != nil {
+			return err
+		}
+	} else if notes.Bump == BumpNone {
+		fmt.Printf("No feature, fix or breaking change since %s (%d commits left out)\n", orDefault(from, "the first commit"), notes.Skipped)
+	} else {
+		fmt.Print(notes.markdown())
+	}
+	if notes.Bump == BumpNone {
+		if changelogWrite || changelogUpdateVersion || changelogTag {
+			return errors.New("nothing to release")
+		}
+		return nil
+	}
+
+	var changed []string
+	if changelogWrite {
+		if err := writeChangelog("CHANGELOG.md", notes); err != nil {
+			return err
+		}
+		changed = append(changed, "CHANGELOG.md")
+		fmt.Fprintf(os.Stderr, "✓ Added %s to CHANGELOG.md\n", notes.Version)
+	}
+	if changelogUpdateVersion {
+		updated, err := updateServiceVersion(strings.TrimPrefix(notes.Version, "v"))
+		if err != nil {
+			return err
+		}
+		changed = append(changed, updated...)
+	}
+	if changelogTag {
+		// The tag is of the commit with the release notes and version, not of the one before
+		if len(changed) > 0 {
+			add := exec.Command("git", append([]string{"add", "--"}, changed...)...)
+			if output, err := add.CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to commit the release: %s", strings.TrimSpace(string(output)))
+			}
+			commit := exec.Command("git", append([]string{"commit", "-q", "-m", "chore(release): " + notes.Tag, "--"}, changed...)...)
+			if output, err := commit.CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to commit the release: %s", strings.TrimSpace(string(output)))
+			}
+			fmt.Fprintf(os.Stderr, "✓ Committed %s\n", strings.Join(changed, ", "))
+		}
+		tag := exec.Command("git", "tag", "-a", notes.Tag, "-m", "Release "+notes.Tag+"\n\n"+notes.markdown())
+		if output, err := tag.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to create the tag %s: %s", notes.Tag, strings.TrimSpace(string(output)))
+		}
+		fmt.Fprintf(os.Stderr, "✓ Tagged %s; push it with: git push origin %s\n", notes.Tag, notes.Tag)
+	}
+	return nil
+}
+
+// lastReleaseTag returns the highest release tag with a prefix, empty when there is none
+func lastReleaseTag(prefix string) (string, error) {
+	output, err := exec.Command("git", "tag", "--list", prefix+"v*").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list the tags: %w", err)
+	}
+	last := ""
+	for _, tag := range strings.Fields(string(output)) {
+		version := strings.TrimPrefix(tag, prefix)
+		if semver.IsValid(version) && (last == "" || semver.Compare(version, strings.TrimPrefix(last, prefix)) > 0) {
+			last = tag
+		}
+	}
+	return last, nil
+}
+
+// configuredServiceVersion returns the service.version of configs/config.yaml
+func configuredServiceVersion() string {
+	version, _ := asMap(loadServiceConfig(".")["service"])["version"].(string)
+	return version
+}
+
+// conventionalCommitsSince returns the conventional commits made to the current directory
+// since a revision, and how many commits are not conventional
+func conventionalCommitsSince(from string) ([]conventionalCommit, int, error) {
+	arguments := []string{"log", "--no-merges", "--format=%h%x1f%s%x1f%b%x1e"}
+	if from != "" {
+		arguments = append(arguments, from+"..HEAD")
+	}
+	var stderr bytes.Buffer
+	log := exec.Command("git", append(arguments, "--", ".")...)
+	log.Stderr = &stderr
+	output, err := log.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "does not have any commits") {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read the commits: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var commits []conventionalCommit
+	skipped := 0
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		match := conventionalCommitPattern.FindStringSubmatch(fields[1])
+		if match == nil {
+			skipped++
+			continue
+		}
+		commit := conventionalCommit{Hash: fields[0], Type: strings.ToLower(match[1]), Scope: match[2], Subject: match[4]}
+		if match[3] == "!" {
+			commit.Breaking = commit.Subject
+		}
+		if len(fields) == 3 {
+			if footer := breakingFooterPattern.FindStringSubmatch(fields[2]); footer != nil {
+				commit.Breaking = strings.TrimSpace(footer[1])
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, skipped, nil
+}
+
+// nextVersion returns the bump the changes of a release make, and the version it gives
+func nextVersion(previous string, notes *serviceRelease) (string, string) {
+	bump := BumpNone
+	switch {
+	case len(notes.Breaking) > 0:
+		bump = BumpMajor
+	case len(notes.Features) > 0:
+		bump = BumpMinor
+	case len(notes.Fixes) > 0:
+		bump = BumpPatch
+	}
+
+	var parts [3]int
+	for i, part := range strings.SplitN(strings.TrimPrefix(semver.Canonical(previous), "v"), ".", 3) {
+		// Prerelease and build suffixes are dropped: the release is the version they led to
+		part, _, _ = strings.Cut(part, "-")
+		parts[i], _ = strconv.Atoi(part)
+	}
+	if bump == BumpMajor && parts[0] == 0 {
+		// Before 1.0.0, breaking changes are released as minor versions
+		bump = BumpMinor
+	}
+	switch bump {
+	case BumpMajor:
+		parts = [3]int{parts[0] + 1, 0, 0}
+	case BumpMinor:
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	case BumpPatch:
+		parts[2]++
+	default:
+		return bump, previous
+	}
+	return bump, fmt.Sprintf("v%d.%d.%d", parts[0], parts[1], parts[2])
+}
+
+// markdown returns the release notes as a CHANGELOG.md section
+func (notes *serviceRelease) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## [%s] - %s\n", strings.TrimPrefix(notes.Version, "v"), notes.Date)
+	for _, section := range []struct {
+		title   string
+		commits []conventionalCommit
+	}{
+		{"Breaking Changes", notes.Breaking},
+		{"Features", notes.Features},
+		{"Fixes", notes.Fixes},
+	} {
+		if len(section.commits) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n", section.title)
+		for _, commit := range section.commits {
+			text := commit.Subject
+			if commit.Breaking != "" {
+				text = commit.Breaking
+			}
+			if commit.Scope != "" {
+				text = "**" + commit.Scope + "**: " + text
+			}
+			fmt.Fprintf(&b, "- %s (%s)\n", text, commit.Hash)
+		}
+	}
+	return b.String()
+}
+
+// writeChangelog adds the release notes to a changelog, above its latest release
+func writeChangelog(path string, notes *serviceRelease) error {
+	section := notes.markdown()
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		content = []byte("# Changelog\n\nAll notable changes to this service are documented in this file.\n")
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if bytes.Contains(content, []byte("## ["+strings.TrimPrefix(notes.Version, "v")+"]")) {
+		return fmt.Errorf("%s already has a %s section", path, notes.Version)
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") && !strings.HasPrefix(line, "## [Unreleased]") {
+			updated := strings.Join(lines[:i], "") + section + "\n" + strings.Join(lines[i:], "")
+			return os.WriteFile(path, []byte(updated), 0644)
+		}
+	}
+	updated := strings.TrimRight(string(content), "\n") + "\n\n" + section
+	return os.WriteFile(path, []byte(updated), 0644)
+}
+
+// versionVariablePattern matches the VERSION variable of a Makefile
+var versionVariablePattern = regexp.MustCompile(`(?m)^(VERSION\s*[?:]?=\s*)v?[0-9][^\s#]*`)
+
+// versionLdflagPattern matches an -ldflags setting of a version variable: -X main.version=1.0.0
+var versionLdflagPattern = regexp.MustCompile(`(-X[ =]?['"]?[\w./-]+\.[Vv]ersion=)(v?)[0-9][^\s'"]*`)
+
+// updateServiceVersion sets the version of the service in configs/config.yaml, the generation
+// manifest, the image of its Kubernetes deployment and the build files that embed it, and returns the files it changed
+func updateServiceVersion(version string) ([]string, error) {
+	var changed []string
+	config := filepath.Join("configs", "config.yaml")
+	updated, err := updateConfigVersion(config, version)
+	if err != nil {
+		return nil, err
+	}
+	if updated {
+		changed = append(changed, config)
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", "update", config)
+	}
+
+	// The tag of the image the Kubernetes deployment runs, and the version the project is
+	// regenerated with
+	if manifest, err := generator.LoadManifest("."); err == nil && manifest.Config.Version != version {
+		deployment := filepath.Join("deployments", "kubernetes", "deployment.yaml")
+		if content, err := os.ReadFile(deployment); err == nil {
+			image := regexp.MustCompile(`(?m)^(\s*-?\s*image:\s*["']?` + regexp.QuoteMeta(manifest.Config.ServiceName) + `:)v?[0-9][^\s"'#]*`)
+			if replaced := image.ReplaceAll(content, []byte("${1}"+version)); !bytes.Equal(replaced, content) {
+				if err := os.WriteFile(deployment, replaced, 0644); err != nil {
+					return nil, fmt.Errorf("failed to write %s: %w", deployment, err)
+				}
+				changed = append(changed, deployment)
+				fmt.Fprintf(os.Stderr, "  %-9s %s\n", "update", deployment)
+			}
+		}
+		manifest.Config.Version = version
+		if err := manifest.Save("."); err != nil {
+			return nil, fmt.Errorf("failed to update generation manifest: %w", err)
+		}
+		changed = append(changed, filepath.FromSlash(generator.ManifestFile))
+	}
+
+	files := []string{"Makefile", filepath.Join("deployments", "docker", "Dockerfile"), "Dockerfile", ".gitlab-ci.yml"}
+	workflows, _ := filepath.Glob(filepath.Join(".github", "workflows", "*.y*ml"))
+	embedded := false
+	for _, path := range append(files, workflows...) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		replaced := versionLdflagPattern.ReplaceAll(content, []byte("${1}${2}"+version))
+		if filepath.Base(path) == "Makefile" {
+			replaced = versionVariablePattern.ReplaceAll(replaced, []byte("${1}"+version))
+		}
+		if versionLdflagPattern.Match(content) || (filepath.Base(path) == "Makefile" && versionVariablePattern.Match(content)) {
+			embedded = true
+		}
+		if bytes.Equal(replaced, content) {
+			continue
+		}
+		if err := os.WriteFile(path, replaced, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		changed = append(changed, path)
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", "update", path)
+	}
+	if !embedded {
+		fmt.Fprintln(os.Stderr, `Warning: no Makefile, Dockerfile or CI workflow embeds the version; build with -ldflags "-X main.version=`+version+`" to embed it`)
+	}
+	return changed, nil
+}
+
+// updateConfigVersion sets service.version in a configuration file, keeping the rest of the
+// file as it is
+func updateConfigVersion(path, version string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return false, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return false, nil
+	}
+	node := yamlMapValue(yamlMapValue(document.Content[0], "service"), "version")
+	if node == nil || node.Value == version {
+		return false, nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	end := start + len(node.Value)
+	if node.Style == yaml.DoubleQuotedStyle || node.Style == yaml.SingleQuotedStyle {
+		end += 2
+	}
+	if end > len(line) {
+		return false, fmt.Errorf("failed to locate service.version in %s", path)
+	}
+	lines[node.Line-1] = line[:start] + strconv.Quote(version) + line[end:]
+	return true, os.WriteFile(path, []byte(strings.Join(lines, "")), 0644)
+}
+
+// yamlMapValue returns the value of a key of a YAML mapping, nil when it has none
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
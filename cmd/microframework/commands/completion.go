@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Shell completion of the arguments and flags that depend on the catalog or on the project in
+// the current directory. Completions carry their description after a tab, which zsh and fish
+// show next to them.
+
+// completeFeatures completes the feature argument of microframework add
+func completeFeatures(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, feature := range features {
+		if feature.Add && strings.HasPrefix(feature.Name, toComplete) {
+			completions = append(completions, feature.Name+"\t"+feature.Description)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAddProviders completes --provider of microframework add with the providers of the
+// feature given as argument
+func completeAddProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProviders(args[0], toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFeatureFlag returns the completion of a --with flag of microframework new, the
+// providers of its feature
+func completeFeatureFlag(feature string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeProviders(feature, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProviders returns the providers of a feature starting with toComplete
+func completeProviders(feature, toComplete string) []string {
+	var completions []string
+	for _, entry := range features {
+		if entry.Name != feature {
+			continue
+		}
+		for _, provider := range entry.Providers {
+			if strings.HasPrefix(provider, toComplete) {
+				completions = append(completions, provider)
+			}
+		}
+	}
+	return completions
+}
+
+// completeEnvironments completes the environments of deployments/environments.yaml, with the
+// target each runs on
+func completeEnvironments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	environments, err := loadEnvironments()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, environment := range environments {
+		if strings.HasPrefix(environment.Name, toComplete) {
+			completions = append(completions, environment.Name+"\t"+environment.Target)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCatalog returns the completion of a flag taking a name of a catalog section
+func completeCatalog(entries []catalogEntry) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var completions []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name, toComplete) {
+				completions = append(completions, entry.Name+"\t"+entry.Description)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeConfigOverlays completes the configuration environments, the overlays
+// configs/config.<env>.yaml of the project
+func completeConfigOverlays(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	matches, _ := filepath.Glob(filepath.Join("configs", "config.*.yaml"))
+	var completions []string
+	for _, match := range matches {
+		env := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "config."), ".yaml")
+		if strings.HasPrefix(env, toComplete) {
+			completions = append(completions, env)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigArgs completes the action of microframework config, then the key of get and set
+func completeConfigArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		var completions []string
+		for _, action := range configActions {
+			if strings.HasPrefix(action, toComplete) {
+				completions = append(completions, action)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && (args[0] == "get" || args[0] == "set"):
+		return completeConfigKeys(cmd, nil, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigKeys completes the dot-separated key paths of the service configuration, as
+// database.url
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path := configFile
+	if path == "" {
+		found, err := findConfigFile()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		path = found
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil || len(document.Content) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := prefix + node.Content[i].Value
+			if strings.HasPrefix(key, toComplete) {
+				completions = append(completions, key)
+			}
+			walk(node.Content[i+1], key+".")
+		}
+	}
+	walk(document.Content[0], "")
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -34,15 +34,18 @@ Examples:
   microframework config validate
   microframework config export --format yaml
   microframework config import --file config.yaml`,
-	RunE: runConfig,
+	ValidArgsFunction: completeConfigArgs,
+	RunE:              runConfig,
 }
 
 func init() {
 	configCmd.Flags().StringVarP(&configAction, "action", "a", "get", "Action to perform (get, set, validate, export, import)")
 	configCmd.Flags().StringVarP(&configKey, "key", "k", "", "Configuration key (e.g., database.url)")
-	configCmd.Flags().StringVarP(&configValue, "value", "v", "", "Configuration value to set")
+	configCmd.Flags().StringVar(&configValue, "value", "", "Configuration value to set")
 	configCmd.Flags().StringVarP(&configFile, "file", "f", "", "Configuration file path")
 	configCmd.Flags().StringVarP(&configFormat, "format", "", "yaml", "Configuration format (yaml, json, env)")
+
+	configCmd.RegisterFlagCompletionFunc("key", completeConfigKeys)
 }
 
 func runConfig(cmd *cobra.Command, args []string) error {
@@ -56,6 +59,13 @@ func runConfig(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		action = args[0]
 	}
+	// config get <key> and config set <key> <value> take the key and value as arguments too
+	if len(args) > 1 && configKey == "" {
+		configKey = args[1]
+	}
+	if len(args) > 2 && configValue == "" {
+		configValue = args[2]
+	}
 
 	// Validate action
 	if err := validateConfigAction(action); err != nil {
@@ -85,17 +95,18 @@ func runConfig(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// configActions are the actions of microframework config
+var configActions = []string{"get", "set", "validate", "export", "import", "list", "reset"}
+
 // validateConfigAction validates the configuration action
 func validateConfigAction(action string) error {
-	validActions := []string{"get", "set", "validate", "export", "import", "list", "reset"}
-
-	for _, valid := range validActions {
+	for _, valid := range configActions {
 		if action == valid {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("invalid action. Available actions: %v", validActions)
+	return fmt.Errorf("invalid action. Available actions: %v", configActions)
 }
 
 // Configuration action functions
@@ -156,20 +167,13 @@ func configSet(key, value string) error {
 func configValidate(configFile string) error {
 	fmt.Println("Validating configuration...")
 
-	// Load configuration
-	config, err := loadConfiguration()
+	// Validate the configuration files against their schemas
+	issues, err := validateConfigSchemas(configFile, false)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return fmt.Errorf("failed to validate configuration: %w", err)
 	}
-
-	// Validate configuration
-	errors := validateConfiguration(config)
-	if len(errors) > 0 {
-		fmt.Println("Configuration validation failed:")
-		for _, err := range errors {
-			fmt.Printf("  - %s\n", err)
-		}
-		return fmt.Errorf("configuration validation failed with %d errors", len(errors))
+	if err := reportIssues("configuration issues", issues); err != nil {
+		return err
 	}
 
 	fmt.Println("✓ Configuration is valid")
@@ -2,7 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"os/exec"
+	"strings"
 
+	"github.com/anasamu/go-micro-framework/pkg/progress"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +17,7 @@ var (
 	deployConfig  string
 	deployDryRun  bool
 	deployForce   bool
+	deploySBOM    bool
 )
 
 // deployCmd represents the deploy command
@@ -45,17 +49,30 @@ func init() {
 	deployCmd.Flags().StringVarP(&deployConfig, "config", "c", "", "Custom deployment configuration file")
 	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "Show what would be deployed without making changes")
 	deployCmd.Flags().BoolVar(&deployForce, "force", false, "Force deployment even if there are warnings")
+	deployCmd.Flags().BoolVar(&deploySBOM, "sbom", true, "Generate the SBOM of the service and its image, attached to the pushed image (microframework sbom)")
+
+	deployCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	deployCmd.RegisterFlagCompletionFunc("target", completeCatalog(deploymentTargets))
+}
+
+// deployResult is the result of deploy in the JSON report
+type deployResult struct {
+	Environment string `json:"environment"`
+	Target      string `json:"target"`
+	Image       string `json:"image,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	DryRun      bool   `json:"dry_run"`
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
 	// Validate environment
 	if err := validateEnvironment(deployEnv); err != nil {
-		return fmt.Errorf("invalid environment: %w", err)
+		return &UserError{fmt.Errorf("invalid environment: %w", err)}
 	}
 
 	// Validate deployment target
 	if err := validateDeploymentTarget(deployTarget); err != nil {
-		return fmt.Errorf("invalid deployment target: %w", err)
+		return &UserError{fmt.Errorf("invalid deployment target: %w", err)}
 	}
 
 	// Check if we're in a microservice directory
@@ -81,30 +98,45 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		fmt.Println("DRY RUN MODE - No changes will be made")
 	}
 
+	reportResult(deployResult{Environment: deployEnv, Target: deployTarget, Image: deployImage, Tag: deployTag, DryRun: deployDryRun})
+
 	// Deploy based on target
+	var err error
 	switch deployTarget {
 	case "docker":
-		return deployDocker(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployDocker(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "compose":
-		return deployDockerCompose(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployDockerCompose(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "kubernetes":
-		return deployKubernetes(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployKubernetes(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "aws":
-		return deployAWS(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployAWS(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "gcp":
-		return deployGCP(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployGCP(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "azure":
-		return deployAzure(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployAzure(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	case "lambda":
-		return deployLambda(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
+		err = deployLambda(deployEnv, deployImage, deployTag, deployConfig, deployDryRun)
 	default:
-		return fmt.Errorf("unknown deployment target: %s", deployTarget)
+		return &UserError{fmt.Errorf("unknown deployment target: %s", deployTarget)}
+	}
+	if err != nil {
+		return &DeploymentError{err}
 	}
+	return nil
 }
 
 // validateEnvironment validates the deployment environment
 func validateEnvironment(env string) error {
 	validEnvs := []string{"development", "staging", "production", "test"}
+	// The environments of deployments/environments.yaml are valid too
+	if environments, err := loadEnvironments(); err == nil {
+		for _, environment := range environments {
+			if !containsString(validEnvs, environment.Name) {
+				validEnvs = append(validEnvs, environment.Name)
+			}
+		}
+	}
 
 	for _, valid := range validEnvs {
 		if env == valid {
@@ -117,7 +149,7 @@ func validateEnvironment(env string) error {
 
 // validateDeploymentTarget validates the deployment target
 func validateDeploymentTarget(target string) error {
-	validTargets := []string{"docker", "compose", "kubernetes", "aws", "gcp", "azure", "lambda"}
+	validTargets := catalogNames(deploymentTargets)
 
 	for _, valid := range validTargets {
 		if target == valid {
@@ -134,19 +166,45 @@ func deployDocker(env, image, tag, config string, dryRun bool) error {
 
 	if dryRun {
 		fmt.Println("Would execute: docker build -t my-service:latest .")
+		if deploySBOM {
+			fmt.Println("Would execute: microframework sbom --image my-service:latest")
+		}
 		fmt.Println("Would execute: docker run -d --name my-service -p 8080:8080 my-service:latest")
 		return nil
 	}
 
+	total := 2
+	if deploySBOM {
+		total++
+	}
+	steps := progress.NewReporter(progressEvents, "deploy", total)
+
 	// Build Docker image
 	fmt.Println("Building Docker image...")
-	if err := buildDockerImage(image, tag); err != nil {
+	err := steps.Step("build image", func() error {
+		return buildDockerImage(image, tag)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to build Docker image: %w", err)
 	}
 
+	// Generate the SBOM of the service and the image
+	if deploySBOM {
+		fmt.Println("Generating the SBOM...")
+		err = steps.Step("sbom", func() error {
+			return deploySBOMStep(image, tag, false)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate the SBOM: %w", err)
+		}
+	}
+
 	// Run Docker container
 	fmt.Println("Starting Docker container...")
-	if err := runDockerContainer(image, tag, env); err != nil {
+	err = steps.Step("run container", func() error {
+		return runDockerContainer(image, tag, env)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to run Docker container: %w", err)
 	}
 
@@ -162,9 +220,14 @@ func deployDockerCompose(env, image, tag, config string, dryRun bool) error {
 		return nil
 	}
 
+	steps := progress.NewReporter(progressEvents, "deploy", 1)
+
 	// Start services with Docker Compose
 	fmt.Println("Starting services with Docker Compose...")
-	if err := startDockerCompose(env, config); err != nil {
+	err := steps.Step("start services", func() error {
+		return startDockerCompose(env, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to start Docker Compose: %w", err)
 	}
 
@@ -176,28 +239,57 @@ func deployKubernetes(env, image, tag, config string, dryRun bool) error {
 	fmt.Println("Deploying to Kubernetes...")
 
 	if dryRun {
+		if deploySBOM && image != "" {
+			fmt.Printf("Would execute: microframework sbom --image %s:%s --attest\n", image, tag)
+		}
 		fmt.Println("Would execute: kubectl apply -f deployments/kubernetes/")
 		fmt.Println("Would execute: kubectl set image deployment/my-service my-service=my-service:v1.0.0")
 		return nil
 	}
 
+	total := 2
+	if image != "" {
+		total++
+	}
+	if deploySBOM {
+		total++
+	}
+	steps := progress.NewReporter(progressEvents, "deploy", total)
+
+	// Generate the SBOM, attached to the image the cluster pulls
+	if deploySBOM {
+		fmt.Println("Generating the SBOM...")
+		err := steps.Step("sbom", func() error {
+			return deploySBOMStep(image, tag, image != "")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate the SBOM: %w", err)
+		}
+	}
+
 	// Apply Kubernetes manifests
 	fmt.Println("Applying Kubernetes manifests...")
-	if err := applyKubernetesManifests(env, config); err != nil {
+	err := steps.Step("apply manifests", func() error {
+		return applyKubernetesManifests(env, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to apply Kubernetes manifests: %w", err)
 	}
 
 	// Update image if specified
 	if image != "" {
 		fmt.Printf("Updating image to: %s:%s\n", image, tag)
-		if err := updateKubernetesImage(image, tag); err != nil {
+		err = steps.Step("update image", func() error {
+			return updateKubernetesImage(image, tag)
+		})
+		if err != nil {
 			return fmt.Errorf("failed to update Kubernetes image: %w", err)
 		}
 	}
 
 	// Wait for deployment
 	fmt.Println("Waiting for deployment to be ready...")
-	if err := waitForKubernetesDeployment(); err != nil {
+	if err := steps.Step("wait for rollout", waitForKubernetesDeployment); err != nil {
 		return fmt.Errorf("failed to wait for deployment: %w", err)
 	}
 
@@ -214,9 +306,14 @@ func deployAWS(env, image, tag, config string, dryRun bool) error {
 		return nil
 	}
 
+	steps := progress.NewReporter(progressEvents, "deploy", 1)
+
 	// Deploy to AWS ECS
 	fmt.Println("Deploying to AWS ECS...")
-	if err := deployToAWSECS(env, image, tag, config); err != nil {
+	err := steps.Step("deploy to ECS", func() error {
+		return deployToAWSECS(env, image, tag, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to deploy to AWS ECS: %w", err)
 	}
 
@@ -233,9 +330,14 @@ func deployGCP(env, image, tag, config string, dryRun bool) error {
 		return nil
 	}
 
+	steps := progress.NewReporter(progressEvents, "deploy", 1)
+
 	// Deploy to Google Cloud Run
 	fmt.Println("Deploying to Google Cloud Run...")
-	if err := deployToGCPCloudRun(env, image, tag, config); err != nil {
+	err := steps.Step("deploy to Cloud Run", func() error {
+		return deployToGCPCloudRun(env, image, tag, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to deploy to Google Cloud Run: %w", err)
 	}
 
@@ -252,9 +354,14 @@ func deployAzure(env, image, tag, config string, dryRun bool) error {
 		return nil
 	}
 
+	steps := progress.NewReporter(progressEvents, "deploy", 1)
+
 	// Deploy to Azure Container Instances
 	fmt.Println("Deploying to Azure Container Instances...")
-	if err := deployToAzureContainerInstances(env, image, tag, config); err != nil {
+	err := steps.Step("deploy to Container Instances", func() error {
+		return deployToAzureContainerInstances(env, image, tag, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to deploy to Azure Container Instances: %w", err)
 	}
 
@@ -271,9 +378,14 @@ func deployLambda(env, image, tag, config string, dryRun bool) error {
 		return nil
 	}
 
+	steps := progress.NewReporter(progressEvents, "deploy", 1)
+
 	// Deploy to AWS Lambda
 	fmt.Println("Deploying to AWS Lambda...")
-	if err := deployToAWSLambda(env, image, tag, config); err != nil {
+	err := steps.Step("deploy to Lambda", func() error {
+		return deployToAWSLambda(env, image, tag, config)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to deploy to AWS Lambda: %w", err)
 	}
 
@@ -282,6 +394,31 @@ func deployLambda(env, image, tag, config string, dryRun bool) error {
 }
 
 // Helper functions for deployment operations
+
+// deploySBOMStep generates the CycloneDX SBOM of the service and, with an image, of the image,
+// which attest attaches to it. Without syft or cosign, only what they are not needed for is
+// done, with a warning.
+func deploySBOMStep(image, tag string, attest bool) error {
+	ref := ""
+	if image != "" {
+		ref = image + ":" + tag
+	}
+	if _, err := exec.LookPath("syft"); ref != "" && err != nil {
+		warnf("the SBOM of %s needs syft (https://github.com/anchore/syft); only the SBOM of the service is generated", ref)
+		ref = ""
+	}
+	if _, err := exec.LookPath("cosign"); ref != "" && attest && err != nil {
+		warnf("attaching the SBOM to %s needs cosign (https://github.com/sigstore/cosign); it is not attached", ref)
+		attest = false
+	}
+	files, err := generateSBOM("cyclonedx", "sbom"+sbomFormats["cyclonedx"].extension, ref, attest && ref != "")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("SBOM written to %s\n", strings.Join(files, ", "))
+	return nil
+}
+
 func buildDockerImage(image, tag string) error {
 	fmt.Printf("Building Docker image: %s:%s\n", image, tag)
 	// Implementation would execute: docker build -t image:tag .
@@ -0,0 +1,657 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+var describeOutput string
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe the service in the current directory",
+	Long: `Describe the service in the current directory from its generation manifest, go.mod and
+configuration: the service type and layout, the features and providers, the versions of the
+framework, templates and go-micro-libs, how many generated files were modified since, and the
+HTTP routes, gRPC methods and GraphQL operations it exposes.
+
+Examples:
+  microframework describe
+  microframework describe --output json`,
+	Args: cobra.NoArgs,
+	RunE: runDescribe,
+}
+
+func init() {
+	describeCmd.Flags().StringVarP(&describeOutput, "output", "o", "text", "Output format (text, json)")
+}
+
+// projectDescription is what describe reports about a project
+type projectDescription struct {
+	Service   string             `json:"service"`
+	Type      string             `json:"type,omitempty"`
+	Layout    string             `json:"layout"`
+	Module    string             `json:"module"`
+	GoVersion string             `json:"go_version,omitempty"`
+	Generated bool               `json:"generated"`
+	Versions  describedVersions  `json:"versions"`
+	Features  []describedFeature `json:"features"`
+	// Providers are the providers of each section of configs/config.yaml
+	Providers map[string][]string `json:"providers,omitempty"`
+	Files     *describedFiles     `json:"files,omitempty"`
+	Endpoints []describedEndpoint `json:"endpoints"`
+}
+
+type describedVersions struct {
+	// Framework is the framework version the project was generated with
+	Framework   string `json:"framework,omitempty"`
+	CLI         string `json:"cli,omitempty"`
+	Templates   string `json:"templates,omitempty"`
+	GoMicroLibs string `json:"go_micro_libs,omitempty"`
+}
+
+type describedFeature struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// describedFiles counts the generated files by what became of them, and the other files
+type describedFiles struct {
+	Generated int `json:"generated"`
+	Unchanged int `json:"unchanged"`
+	Modified  int `json:"modified"`
+	Deleted   int `json:"deleted"`
+	Added     int `json:"added"`
+}
+
+// describedEndpoint is an HTTP route, a gRPC method or a GraphQL operation
+type describedEndpoint struct {
+	Kind    string `json:"kind"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler,omitempty"`
+	Source  string `json:"source"`
+}
+
+// Kinds of endpoints
+const (
+	EndpointHTTP    = "http"
+	EndpointGRPC    = "grpc"
+	EndpointGraphQL = "graphql"
+)
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if describeOutput != "text" && describeOutput != "json" {
+		return fmt.Errorf("invalid output format %q (text, json)", describeOutput)
+	}
+
+	description, err := describeProject()
+	if err != nil {
+		return err
+	}
+	if describeOutput == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(description)
+	}
+	return printDescription(cmd, description)
+}
+
+// describeProject describes the project in the current directory
+func describeProject() (*projectDescription, error) {
+	description := &projectDescription{
+		Service:  projectServiceName(),
+		Layout:   LayoutStandard,
+		Features: []describedFeature{},
+	}
+	if isDirectory(filepath.Join("internal", "core")) && isDirectory(filepath.Join("internal", "adapters")) {
+		description.Layout = LayoutHexagonal
+	}
+
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	modFile, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	if modFile.Module != nil {
+		description.Module = modFile.Module.Mod.Path
+	}
+	if modFile.Go != nil {
+		description.GoVersion = modFile.Go.Version
+	}
+	for _, require := range modFile.Require {
+		if require.Mod.Path == "github.com/anasamu/go-micro-libs" {
+			description.Versions.GoMicroLibs = require.Mod.Version
+		}
+	}
+
+	lock, err := loadProjectLock(".")
+	if err != nil {
+		return nil, err
+	}
+	description.Versions.CLI = lock.version(LockCLI)
+	description.Versions.Templates = lock.version(LockTemplates)
+
+	manifest, err := generator.LoadManifest(".")
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		description.Generated = true
+		description.Type = manifest.Config.ServiceType
+		description.Versions.Framework = manifest.FrameworkVersion
+		description.Features = generatedFeatures(&manifest.Config)
+		if description.Files, err = countProjectFiles(manifest); err != nil {
+			return nil, fmt.Errorf("failed to list the project files: %w", err)
+		}
+	}
+
+	if description.Providers, err = configProviders(filepath.Join("configs", "config.yaml")); err != nil {
+		return nil, err
+	}
+	if description.Endpoints, err = projectEndpoints(); err != nil {
+		return nil, err
+	}
+	if description.Type == "" {
+		description.Type = serviceTypeFromEndpoints(description.Endpoints)
+	}
+	return description, nil
+}
+
+// generatedFeatures returns the features a project was generated with, in the order of the
+// catalog
+func generatedFeatures(config *generator.GeneratorConfig) []describedFeature {
+	selected := []describedFeature{}
+	for _, feature := range features {
+		var enabled bool
+		var provider string
+		switch feature.Name {
+		case "auth":
+			enabled, provider = config.WithAuth, config.AuthProvider
+		case "database":
+			enabled, provider = config.WithDatabase, config.DatabaseProvider
+		case "messaging":
+			enabled, provider = config.WithMessaging, config.MessagingProvider
+		case "monitoring":
+			enabled, provider = config.WithMonitoring, config.MonitoringProvider
+		case "ai":
+			enabled, provider = config.WithAI, config.AIProvider
+		case "storage":
+			enabled, provider = config.WithStorage, config.StorageProvider
+		case "cache":
+			enabled, provider = config.WithCache, config.CacheProvider
+		case "discovery":
+			enabled, provider = config.WithDiscovery, config.DiscoveryProvider
+		case "circuitbreaker":
+			enabled = config.WithCircuitBreaker
+		case "ratelimit":
+			enabled = config.WithRateLimit
+		case "chaos":
+			enabled = config.WithChaos
+		case "failover":
+			enabled = config.WithFailover
+		case "event":
+			enabled = config.WithEvent
+		case "scheduling":
+			enabled = config.WithScheduling
+		case "backup":
+			enabled = config.WithBackup
+		case "payment":
+			enabled, provider = config.WithPayment, config.PaymentProvider
+		case "filegen":
+			enabled = config.WithFileGen
+		case "api":
+			enabled, provider = config.WithAPI, config.APIProvider
+		case "email":
+			enabled, provider = config.WithEmail, config.EmailProvider
+		case "featureflags":
+			enabled, provider = config.WithFeatureFlags, config.FeatureFlagsProvider
+			if config.OpenFeatureProvider != "" {
+				provider += " (" + config.OpenFeatureProvider + ")"
+			}
+		case "errortracking":
+			enabled, provider = config.WithErrorTracking, config.ErrorTrackingProvider
+		case "secrets":
+			enabled, provider = config.SecretsProvider != "", config.SecretsProvider
+		}
+		if enabled {
+			selected = append(selected, describedFeature{Name: feature.Name, Provider: provider})
+		}
+	}
+	return selected
+}
+
+// configProviders returns the providers configured in each section of a configuration file
+func configProviders(path string) (map[string][]string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var sections map[string]struct {
+		Providers map[string]yaml.Node `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal(content, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	providers := make(map[string][]string)
+	for section, value := range sections {
+		for name := range value.Providers {
+			providers[section] = append(providers[section], name)
+		}
+		sort.Strings(providers[section])
+	}
+	return providers, nil
+}
+
+// countProjectFiles compares the files of the project with the generated ones
+func countProjectFiles(manifest *generator.Manifest) (*describedFiles, error) {
+	files := &describedFiles{Generated: len(manifest.Files)}
+	for _, path := range manifest.Paths() {
+		content, err := os.ReadFile(filepath.FromSlash(path))
+		switch {
+		case os.IsNotExist(err):
+			files.Deleted++
+		case err != nil:
+			return nil, err
+		case generator.Checksum(content) == manifest.Files[path]:
+			files.Unchanged++
+		default:
+			files.Modified++
+		}
+	}
+
+	err := filepath.WalkDir(".", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && (strings.HasPrefix(d.Name(), ".") || containsString(runSkipDirs, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, generated := manifest.Files[filepath.ToSlash(path)]; !generated && d.Type().IsRegular() {
+			files.Added++
+		}
+		return nil
+	})
+	return files, err
+}
+
+// serviceTypeFromEndpoints guesses the type of a project without a manifest
+func serviceTypeFromEndpoints(endpoints []describedEndpoint) string {
+	kinds := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		kinds[endpoint.Kind] = true
+	}
+	switch {
+	case kinds[EndpointGRPC]:
+		return "grpc"
+	case kinds[EndpointGraphQL]:
+		return "graphql"
+	case kinds[EndpointHTTP]:
+		return "rest"
+	}
+	return ""
+}
+
+// projectEndpoints returns the HTTP routes registered in the Go files, the gRPC methods of the
+// proto files and the GraphQL operations of the schemas of the project
+func projectEndpoints() ([]describedEndpoint, error) {
+	project, err := loadGoProject("")
+	if err != nil {
+		return nil, err
+	}
+	endpoints := []describedEndpoint{}
+	for _, file := range project.filesUnder(nil) {
+		endpoints = append(endpoints, httpRoutes(project.Fset, file)...)
+	}
+
+	err = filepath.WalkDir(".", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && (strings.HasPrefix(d.Name(), ".") || containsString(runSkipDirs, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		var found []describedEndpoint
+		switch filepath.Ext(path) {
+		case ".proto":
+			found, err = grpcMethods(path)
+		case ".graphql", ".graphqls", ".gql":
+			found, err = graphqlOperations(path)
+		}
+		endpoints = append(endpoints, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return endpoints, nil
+}
+
+// httpRouteMethods are the router methods registering a route for an HTTP method, as in gin,
+// echo and chi
+var httpRouteMethods = map[string]string{
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"HEAD": "HEAD", "OPTIONS": "OPTIONS", "Any": "ANY",
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS",
+}
+
+// httpRoutes returns the routes registered in a Go file: router.GET("/path", handler) and the
+// other methods, Handle and HandleFunc (with a Go 1.22 "METHOD /path" pattern or a gorilla
+// Methods call), under the prefixes of the groups they are registered on
+func httpRoutes(fset *token.FileSet, file *goSourceFile) []describedEndpoint {
+	var routes []describedEndpoint
+	for _, decl := range file.AST.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Body == nil {
+			continue
+		}
+
+		// prefixes are the paths of the route groups assigned to variables
+		prefixes := make(map[string]string)
+		var prefixOf func(expr ast.Expr) string
+		prefixOf = func(expr ast.Expr) string {
+			switch expr := expr.(type) {
+			case *ast.Ident:
+				return prefixes[expr.Name]
+			case *ast.CallExpr:
+				if selector, ok := expr.Fun.(*ast.SelectorExpr); ok && (selector.Sel.Name == "Group" || selector.Sel.Name == "PathPrefix") {
+					if path, ok := stringArgument(expr, 0); ok {
+						return prefixOf(selector.X) + path
+					}
+				}
+				if selector, ok := expr.Fun.(*ast.SelectorExpr); ok && selector.Sel.Name == "Subrouter" {
+					return prefixOf(selector.X)
+				}
+			}
+			return ""
+		}
+		// methods are the gorilla Methods calls restricting the route registered by a call
+		methods := make(map[*ast.CallExpr]string)
+
+		ast.Inspect(function.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				if len(node.Lhs) == len(node.Rhs) {
+					for i, rhs := range node.Rhs {
+						ident, ok := node.Lhs[i].(*ast.Ident)
+						if !ok {
+							continue
+						}
+						if prefix := prefixOf(rhs); prefix != "" {
+							prefixes[ident.Name] = prefix
+						}
+					}
+				}
+			case *ast.CallExpr:
+				selector, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if selector.Sel.Name == "Methods" {
+					if inner, ok := selector.X.(*ast.CallExpr); ok {
+						var names []string
+						for i := range node.Args {
+							if name, ok := stringArgument(node, i); ok {
+								names = append(names, name)
+							}
+						}
+						methods[inner] = strings.Join(names, ",")
+					}
+					return true
+				}
+
+				method, path, handler := "", "", -1
+				if httpMethod, ok := httpRouteMethods[selector.Sel.Name]; ok {
+					// The route of a group itself has an empty path
+					if route, ok := stringArgument(node, 0); ok && (strings.HasPrefix(route, "/") || route == "" && prefixOf(selector.X) != "") {
+						method, path, handler = httpMethod, route, len(node.Args)-1
+					}
+				} else if selector.Sel.Name == "Handle" || selector.Sel.Name == "HandleFunc" {
+					first, ok := stringArgument(node, 0)
+					if !ok {
+						return true
+					}
+					if second, ok := stringArgument(node, 1); ok && strings.HasPrefix(second, "/") {
+						// gin's router.Handle("GET", "/path", handler)
+						method, path, handler = first, second, len(node.Args)-1
+					} else if pattern, route, ok := strings.Cut(first, " "); ok && strings.HasPrefix(strings.TrimSpace(route), "/") {
+						method, path, handler = pattern, strings.TrimSpace(route), 1
+					} else if strings.HasPrefix(first, "/") {
+						method, path, handler = "ANY", first, 1
+					}
+				}
+				if method == "" {
+					return true
+				}
+				if restricted, ok := methods[node]; ok && restricted != "" {
+					method = restricted
+				}
+
+				route := describedEndpoint{
+					Kind:   EndpointHTTP,
+					Method: method,
+					Path:   prefixOf(selector.X) + path,
+					Source: fmt.Sprintf("%s:%d", filepath.ToSlash(file.Path), fset.Position(node.Pos()).Line),
+				}
+				if handler > 0 && handler < len(node.Args) {
+					route.Handler = types.ExprString(node.Args[handler])
+				}
+				routes = append(routes, route)
+			}
+			return true
+		})
+	}
+	return routes
+}
+
+// stringArgument returns the argument i of call when it is a string literal
+func stringArgument(call *ast.CallExpr, i int) (string, bool) {
+	if i >= len(call.Args) {
+		return "", false
+	}
+	literal, ok := call.Args[i].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+var (
+	protoPackagePattern = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+	protoServicePattern = regexp.MustCompile(`^\s*service\s+(\w+)\s*\{`)
+	protoRPCPattern     = regexp.MustCompile(`^\s*rpc\s+(\w+)\s*\(\s*(stream\s+)?[\w.]+\s*\)\s*returns\s*\(\s*(stream\s+)?[\w.]+\s*\)`)
+)
+
+// grpcMethods returns the methods of the services of a proto file
+func grpcMethods(path string) ([]describedEndpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var methods []describedEndpoint
+	pkg, service := "", ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := protoPackagePattern.FindStringSubmatch(text); match != nil {
+			pkg = match[1] + "."
+		} else if match := protoServicePattern.FindStringSubmatch(text); match != nil {
+			service = match[1]
+		} else if match := protoRPCPattern.FindStringSubmatch(text); match != nil && service != "" {
+			kind := "unary"
+			switch {
+			case match[2] != "" && match[3] != "":
+				kind = "bidi-stream"
+			case match[2] != "":
+				kind = "client-stream"
+			case match[3] != "":
+				kind = "server-stream"
+			}
+			methods = append(methods, describedEndpoint{
+				Kind:   EndpointGRPC,
+				Method: kind,
+				Path:   fmt.Sprintf("/%s%s/%s", pkg, service, match[1]),
+				Source: fmt.Sprintf("%s:%d", filepath.ToSlash(path), line),
+			})
+		}
+	}
+	return methods, scanner.Err()
+}
+
+var (
+	graphqlRootPattern  = regexp.MustCompile(`^\s*(?:extend\s+)?type\s+(Query|Mutation|Subscription)\b[^{]*\{`)
+	graphqlFieldPattern = regexp.MustCompile(`^\s*(\w+)\s*[(:]`)
+)
+
+// graphqlOperations returns the fields of the Query, Mutation and Subscription types of a
+// GraphQL schema
+func graphqlOperations(path string) ([]describedEndpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var operations []describedEndpoint
+	root, depth := "", 0
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if comment := strings.IndexByte(text, '#'); comment >= 0 {
+			text = text[:comment]
+		}
+		if root == "" {
+			if match := graphqlRootPattern.FindStringSubmatch(text); match != nil {
+				root, depth = match[1], 0
+				text = text[strings.IndexByte(text, '{'):]
+			} else {
+				continue
+			}
+		}
+		// Fields are at the first level of the type, their arguments may span lines
+		if match := graphqlFieldPattern.FindStringSubmatch(text); match != nil && depth == 1 {
+			operations = append(operations, describedEndpoint{
+				Kind:   EndpointGraphQL,
+				Method: strings.ToLower(root),
+				Path:   match[1],
+				Source: fmt.Sprintf("%s:%d", filepath.ToSlash(path), line),
+			})
+		}
+		depth += strings.Count(text, "{") + strings.Count(text, "(") - strings.Count(text, "}") - strings.Count(text, ")")
+		if depth <= 0 {
+			root = ""
+		}
+	}
+	return operations, scanner.Err()
+}
+
+// printDescription prints a project description as text
+func printDescription(cmd *cobra.Command, description *projectDescription) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Service:\t%s\n", description.Service)
+	fmt.Fprintf(writer, "Type:\t%s\n", orDash(description.Type))
+	fmt.Fprintf(writer, "Layout:\t%s\n", description.Layout)
+	fmt.Fprintf(writer, "Module:\t%s\n", orDash(description.Module))
+	fmt.Fprintf(writer, "Go:\t%s\n", orDash(description.GoVersion))
+
+	fmt.Fprintln(writer, "\nVersions:")
+	fmt.Fprintf(writer, "  framework\t%s\n", orDash(description.Versions.Framework))
+	fmt.Fprintf(writer, "  cli\t%s\n", orDash(description.Versions.CLI))
+	fmt.Fprintf(writer, "  templates\t%s\n", orDash(description.Versions.Templates))
+	fmt.Fprintf(writer, "  go-micro-libs\t%s\n", orDash(description.Versions.GoMicroLibs))
+
+	fmt.Fprintln(writer, "\nFeatures:")
+	if !description.Generated {
+		fmt.Fprintf(writer, "  unknown, the project has no %s\n", generator.ManifestFile)
+	} else if len(description.Features) == 0 {
+		fmt.Fprintln(writer, "  none")
+	}
+	for _, feature := range description.Features {
+		fmt.Fprintf(writer, "  %s\t%s\n", feature.Name, orDash(feature.Provider))
+	}
+
+	if len(description.Providers) > 0 {
+		fmt.Fprintln(writer, "\nProviders (configs/config.yaml):")
+		sections := make([]string, 0, len(description.Providers))
+		for section := range description.Providers {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+		for _, section := range sections {
+			fmt.Fprintf(writer, "  %s\t%s\n", section, strings.Join(description.Providers[section], ", "))
+		}
+	}
+
+	if files := description.Files; files != nil {
+		fmt.Fprintln(writer, "\nFiles:")
+		fmt.Fprintf(writer, "  generated\t%d\n", files.Generated)
+		fmt.Fprintf(writer, "  unchanged\t%d\n", files.Unchanged)
+		fmt.Fprintf(writer, "  modified\t%d\n", files.Modified)
+		fmt.Fprintf(writer, "  deleted\t%d\n", files.Deleted)
+		fmt.Fprintf(writer, "  added\t%d\n", files.Added)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "\nEndpoints:")
+	if len(description.Endpoints) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "  none found")
+		return nil
+	}
+	writer = tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  KIND\tMETHOD\tPATH\tHANDLER\tSOURCE")
+	for _, endpoint := range description.Endpoints {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%s\n", endpoint.Kind, endpoint.Method, endpoint.Path, orDash(endpoint.Handler), endpoint.Source)
+	}
+	return writer.Flush()
+}
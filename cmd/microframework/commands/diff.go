@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed or '+' added
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// diffLines returns the edit script turning a into b, based on their longest common
+// subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
+
+// unifiedDiff renders the changes from before to after in unified diff format; it returns ""
+// when they are equal
+func unifiedDiff(before, after, fromName, toName string) string {
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].Kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk until a run of unchanged lines long enough to separate hunks
+		end := start
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContextLines {
+				break
+			}
+			end = run
+		}
+
+		from := start - diffContextLines
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContextLines
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.Kind != '+' {
+				oldStart++
+			}
+			if op.Kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", op.Kind, op.Line)
+		}
+
+		start = to
+	}
+	return out.String()
+}
+
+// mergeLines merges the changes from base to ours and from base to theirs. Where both sides
+// changed the same lines differently, the result has conflict markers labelled with the
+// names of the sides; the number of such conflicts is returned.
+func mergeLines(base, ours, theirs []string, oursName, theirsName string) ([]string, int) {
+	oursAt := baseMatches(base, ours)
+	theirsAt := baseMatches(base, theirs)
+
+	var merged []string
+	conflicts := 0
+	i, a, b := 0, 0, 0
+	for {
+		// Lines kept by both sides in the same place
+		for i < len(base) && oursAt[i] == a && theirsAt[i] == b {
+			merged = append(merged, base[i])
+			i, a, b = i+1, a+1, b+1
+		}
+		if i == len(base) && a == len(ours) && b == len(theirs) {
+			return merged, conflicts
+		}
+
+		// The changed chunk runs up to the next base line kept by both sides
+		j := i
+		for j < len(base) && (oursAt[j] < 0 || theirsAt[j] < 0) {
+			j++
+		}
+		oursEnd, theirsEnd := len(ours), len(theirs)
+		if j < len(base) {
+			oursEnd, theirsEnd = oursAt[j], theirsAt[j]
+		}
+		baseChunk, oursChunk, theirsChunk := base[i:j], ours[a:oursEnd], theirs[b:theirsEnd]
+
+		switch {
+		case equalLines(oursChunk, baseChunk):
+			merged = append(merged, theirsChunk...)
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			merged = append(merged, oursChunk...)
+		default:
+			conflicts++
+			merged = append(merged, "<<<<<<< "+oursName)
+			merged = append(merged, oursChunk...)
+			merged = append(merged, "=======")
+			merged = append(merged, theirsChunk...)
+			merged = append(merged, ">>>>>>> "+theirsName)
+		}
+		i, a, b = j, oursEnd, theirsEnd
+	}
+}
+
+// baseMatches maps each line of base to its index in other, or -1 when other removed it
+func baseMatches(base, other []string) []int {
+	matches := make([]int, len(base))
+	i, j := 0, 0
+	for _, op := range diffLines(base, other) {
+		switch op.Kind {
+		case ' ':
+			matches[i] = j
+			i, j = i+1, j+1
+		case '-':
+			matches[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,475 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	docsHost            string
+	docsPort            int
+	docsUI              string
+	docsGraphQLEndpoint string
+	docsPoll            time.Duration
+)
+
+// The kinds of API specification the documentation server shows
+const (
+	SpecOpenAPI  = "openapi"
+	SpecAsyncAPI = "asyncapi"
+	SpecGraphQL  = "graphql"
+)
+
+// docsSpecExtensions are the extensions of the files that may be API specifications
+var docsSpecExtensions = []string{".yaml", ".yml", ".json", ".graphql", ".graphqls", ".gql"}
+
+// docsCmd represents the docs command
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Browse the API documentation of the project",
+	Long:  `Browse the API documentation of the project: its OpenAPI, AsyncAPI and GraphQL contracts.`,
+}
+
+// docsServeCmd represents the docs serve command
+var docsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the OpenAPI, AsyncAPI and GraphQL documentation locally",
+	Long: `Serve the API documentation of the project locally.
+
+The specifications are found in the project: OpenAPI (and Swagger 2.0) and AsyncAPI
+documents in YAML or JSON, and GraphQL schemas. OpenAPI documents are shown with Swagger
+UI or Redoc, AsyncAPI documents with the AsyncAPI viewer, and the GraphQL schemas next to
+a playground sending its queries to --graphql-endpoint, the running service.
+
+The specifications are watched: the open pages reload when one is regenerated, added or
+removed. The viewers are loaded from a CDN.
+
+Examples:
+  microframework docs serve
+  microframework docs serve --ui redoc --port 9000
+  microframework docs serve --graphql-endpoint http://localhost:8080/query`,
+	Args: cobra.NoArgs,
+	RunE: runDocsServe,
+}
+
+func init() {
+	docsServeCmd.Flags().StringVar(&docsHost, "host", "localhost", "Address to listen on")
+	docsServeCmd.Flags().IntVar(&docsPort, "port", 8088, "Port to listen on")
+	docsServeCmd.Flags().StringVar(&docsUI, "ui", "swagger", "Viewer of the OpenAPI documents (swagger, redoc)")
+	docsServeCmd.Flags().StringVar(&docsGraphQLEndpoint, "graphql-endpoint", "http://localhost:8080/graphql", "GraphQL endpoint of the running service the playground queries")
+	docsServeCmd.Flags().DurationVar(&docsPoll, "poll", time.Second, "Interval between scans of the specifications")
+	docsServeCmd.RegisterFlagCompletionFunc("ui", cobra.FixedCompletions([]string{"swagger", "redoc"}, cobra.ShellCompDirectiveNoFileComp))
+
+	docsCmd.AddCommand(docsServeCmd)
+}
+
+// apiSpec is an API specification of the project
+type apiSpec struct {
+	Kind  string
+	Path  string
+	Title string
+}
+
+func runDocsServe(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if docsUI != "swagger" && docsUI != "redoc" {
+		return fmt.Errorf("invalid --ui %q (swagger, redoc)", docsUI)
+	}
+	endpoint, err := url.Parse(docsGraphQLEndpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return fmt.Errorf("invalid --graphql-endpoint %q: an absolute URL is needed", docsGraphQLEndpoint)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &docsServer{endpoint: endpoint, clients: make(map[chan struct{}]bool)}
+	watcher := &fileWatcher{roots: []string{"."}, extensions: docsSpecExtensions}
+	snapshot, err := watcher.scan()
+	if err != nil {
+		return err
+	}
+	server.specs = findAPISpecs(snapshot)
+	if len(server.specs) == 0 {
+		fmt.Println("No API specifications found yet; the pages reload when one is generated")
+	}
+	for _, spec := range server.specs {
+		fmt.Printf("  %-9s %s\n", spec.Kind, spec.Path)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(docsHost, strconv.Itoa(docsPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s:%d: %w", docsHost, docsPort, err)
+	}
+	httpServer := &http.Server{Handler: server.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Warning: %v\n", err)
+			stop()
+		}
+	}()
+	fmt.Printf("✓ Serving the API documentation on http://%s\n", listener.Addr())
+
+	ticker := time.NewTicker(docsPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopping...")
+			server.closeClients()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return httpServer.Shutdown(shutdown)
+		case <-ticker.C:
+		}
+
+		current, err := watcher.scan()
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		changed := snapshotChanges(snapshot, current)
+		snapshot = current
+		if len(changed) == 0 {
+			continue
+		}
+		specs := findAPISpecs(current)
+		if !server.update(specs, changed) {
+			continue
+		}
+		fmt.Printf("Changed: %s, reloading\n", summarizeChanges(changed))
+	}
+}
+
+// findAPISpecs returns the API specifications among the scanned files, sorted by kind and path
+func findAPISpecs(files map[string]fileState) []apiSpec {
+	var specs []apiSpec
+	for path := range files {
+		if spec, ok := readAPISpec(path); ok {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].Kind != specs[j].Kind {
+			return specs[i].Kind > specs[j].Kind
+		}
+		return specs[i].Path < specs[j].Path
+	})
+	return specs
+}
+
+// readAPISpec tells whether a file is an API specification, by its extension for GraphQL
+// schemas and by its openapi, swagger or asyncapi field for the others
+func readAPISpec(path string) (apiSpec, bool) {
+	spec := apiSpec{Path: filepath.ToSlash(path), Title: filepath.Base(path)}
+	switch filepath.Ext(path) {
+	case ".graphql", ".graphqls", ".gql":
+		spec.Kind = SpecGraphQL
+		return spec, true
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return spec, false
+	}
+	// JSON documents are YAML documents too
+	var document struct {
+		OpenAPI  string `yaml:"openapi"`
+		Swagger  string `yaml:"swagger"`
+		AsyncAPI string `yaml:"asyncapi"`
+		Info     struct {
+			Title string `yaml:"title"`
+		} `yaml:"info"`
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return spec, false
+	}
+	switch {
+	case document.OpenAPI != "" || document.Swagger != "":
+		spec.Kind = SpecOpenAPI
+	case document.AsyncAPI != "":
+		spec.Kind = SpecAsyncAPI
+	default:
+		return spec, false
+	}
+	if document.Info.Title != "" {
+		spec.Title = document.Info.Title
+	}
+	return spec, true
+}
+
+// docsServer serves the documentation pages, and notifies the open pages of the changes of the
+// specifications
+type docsServer struct {
+	endpoint *url.URL
+
+	mu      sync.Mutex
+	specs   []apiSpec
+	clients map[chan struct{}]bool
+}
+
+// routes returns the handler of the documentation server
+func (s *docsServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveIndex)
+	mux.HandleFunc("/specs/", s.serveSpec)
+	mux.HandleFunc("/view/", s.serveViewer)
+	mux.HandleFunc("/graphql", s.servePlayground)
+	mux.HandleFunc("/events", s.serveEvents)
+
+	// The playground queries the service through the server, which spares the service CORS
+	proxy := httputil.NewSingleHostReverseProxy(s.endpoint)
+	director := proxy.Director
+	proxy.Director = func(request *http.Request) {
+		director(request)
+		request.URL.Path, request.URL.RawPath = s.endpoint.Path, s.endpoint.RawPath
+		request.Host = s.endpoint.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, request *http.Request, err error) {
+		http.Error(w, fmt.Sprintf("the GraphQL endpoint %s is not reachable: %v", s.endpoint, err), http.StatusBadGateway)
+	}
+	mux.Handle("/graphql/query", proxy)
+	return mux
+}
+
+// update replaces the specifications, and reports whether a page is to be reloaded: a
+// specification changed, or the list of them did
+func (s *docsServer) update(specs []apiSpec, changed []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reload := len(specs) != len(s.specs)
+	for i := 0; !reload && i < len(specs); i++ {
+		reload = specs[i] != s.specs[i]
+	}
+	for _, spec := range specs {
+		reload = reload || containsString(changed, filepath.FromSlash(spec.Path))
+	}
+	s.specs = specs
+	if reload {
+		for client := range s.clients {
+			select {
+			case client <- struct{}{}:
+			default:
+			}
+		}
+	}
+	return reload
+}
+
+// closeClients ends the event streams of the open pages, which would hold the shutdown
+func (s *docsServer) closeClients() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		close(client)
+		delete(s.clients, client)
+	}
+}
+
+// spec returns the specification of a path
+func (s *docsServer) spec(path string) (apiSpec, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, spec := range s.specs {
+		if spec.Path == path {
+			return spec, true
+		}
+	}
+	return apiSpec{}, false
+}
+
+// specsOf returns the specifications of a kind
+func (s *docsServer) specsOf(kind string) []apiSpec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var specs []apiSpec
+	for _, spec := range s.specs {
+		if kind == "" || spec.Kind == kind {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+func (s *docsServer) serveIndex(w http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(w, request)
+		return
+	}
+	s.render(w, docsIndexPage, map[string]interface{}{
+		"Service":  projectServiceName(),
+		"Specs":    s.specsOf(""),
+		"GraphQL":  len(s.specsOf(SpecGraphQL)) > 0,
+		"Endpoint": s.endpoint.String(),
+	})
+}
+
+// serveSpec serves the file of a specification; only the specifications are served, not the
+// rest of the project
+func (s *docsServer) serveSpec(w http.ResponseWriter, request *http.Request) {
+	spec, ok := s.spec(strings.TrimPrefix(request.URL.Path, "/specs/"))
+	if !ok {
+		http.NotFound(w, request)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(w, request, filepath.FromSlash(spec.Path))
+}
+
+func (s *docsServer) serveViewer(w http.ResponseWriter, request *http.Request) {
+	spec, ok := s.spec(strings.TrimPrefix(request.URL.Path, "/view/"))
+	if !ok {
+		http.NotFound(w, request)
+		return
+	}
+	data := map[string]interface{}{"Spec": spec, "URL": "/specs/" + spec.Path}
+	switch {
+	case spec.Kind == SpecAsyncAPI:
+		s.render(w, docsAsyncAPIPage, data)
+	case spec.Kind == SpecGraphQL:
+		s.servePlayground(w, request)
+	case request.URL.Query().Get("ui") == "redoc" || (docsUI == "redoc" && request.URL.Query().Get("ui") != "swagger"):
+		s.render(w, docsRedocPage, data)
+	default:
+		s.render(w, docsSwaggerPage, data)
+	}
+}
+
+func (s *docsServer) servePlayground(w http.ResponseWriter, request *http.Request) {
+	s.render(w, docsPlaygroundPage, map[string]interface{}{
+		"Specs":    s.specsOf(SpecGraphQL),
+		"Endpoint": s.endpoint.String(),
+	})
+}
+
+// serveEvents streams an event to the page whenever the specifications change
+func (s *docsServer) serveEvents(w http.ResponseWriter, request *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	client := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[client] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case _, open := <-client:
+			if !open {
+				return
+			}
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *docsServer) render(w http.ResponseWriter, page *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := page.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// docsPage parses a page of the documentation server, which reloads when the specifications
+// change
+func docsPage(name, head, body string) *template.Template {
+	return template.Must(template.New(name).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API documentation</title>
+` + head + `
+<script>
+new EventSource("/events").onmessage = function () { location.reload(); };
+</script>
+</head>
+<body>
+` + body + `
+</body>
+</html>
+`))
+}
+
+var docsIndexPage = docsPage("index", `<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+td, th { padding: .3em 1em .3em 0; text-align: left; }
+</style>`, `<h1>{{.Service}} API documentation</h1>
+{{if .Specs}}
+<table>
+<tr><th>Kind</th><th>Specification</th><th>File</th></tr>
+{{range .Specs}}<tr>
+<td>{{.Kind}}</td>
+<td><a href="/view/{{.Path}}">{{.Title}}</a>{{if eq .Kind "openapi"}} (<a href="/view/{{.Path}}?ui=swagger">Swagger UI</a>, <a href="/view/{{.Path}}?ui=redoc">Redoc</a>){{end}}</td>
+<td><a href="/specs/{{.Path}}">{{.Path}}</a></td>
+</tr>{{end}}
+</table>
+{{else}}
+<p>No OpenAPI, AsyncAPI or GraphQL specification was found in the project. This page reloads when one is generated.</p>
+{{end}}
+{{if .GraphQL}}<p><a href="/graphql">GraphQL playground</a>, querying {{.Endpoint}}</p>{{end}}`)
+
+var docsSwaggerPage = docsPage("swagger", `<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>`, `<div id="swagger-ui"></div>
+<script>
+SwaggerUIBundle({ url: "{{.URL}}", dom_id: "#swagger-ui" });
+</script>`)
+
+var docsRedocPage = docsPage("redoc", `<script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>`,
+	`<redoc spec-url="{{.URL}}"></redoc>`)
+
+var docsAsyncAPIPage = docsPage("asyncapi", `<link rel="stylesheet" href="https://unpkg.com/@asyncapi/react-component@1/styles/default.min.css">
+<script src="https://unpkg.com/@asyncapi/react-component@1/browser/standalone/index.js"></script>`, `<div id="asyncapi"></div>
+<script>
+AsyncApiStandalone.render({ schema: { url: "{{.URL}}" }, config: { show: { sidebar: true } } }, document.getElementById("asyncapi"));
+</script>`)
+
+var docsPlaygroundPage = docsPage("playground", `<style>
+body { margin: 0; font-family: sans-serif; }
+#graphiql { height: 100vh; }
+nav { padding: .5em 1em; border-bottom: 1px solid #ddd; }
+</style>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+<script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+<script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+<script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>`, `<nav>Querying {{.Endpoint}}{{range .Specs}} · schema <a href="/specs/{{.Path}}">{{.Path}}</a>{{end}}</nav>
+<div id="graphiql"></div>
+<script>
+ReactDOM.createRoot(document.getElementById("graphiql")).render(
+  React.createElement(GraphiQL, { fetcher: GraphiQL.createFetcher({ url: "/graphql/query" }) })
+);
+</script>`)
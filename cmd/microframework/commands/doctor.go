@@ -0,0 +1,665 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+// Doctor check results
+const (
+	DoctorPass = "pass"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+	DoctorSkip = "skip"
+)
+
+// minimumGoVersion is the oldest Go the framework and the generated services build with
+const minimumGoVersion = "v1.21"
+
+var (
+	doctorOutput  string
+	doctorStrict  bool
+	doctorTimeout time.Duration
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the development environment",
+	Long: `Check that the tools and access the project needs are available.
+
+The checks are: the Go version (against go.mod), protoc or buf for gRPC services, the
+Docker daemon, kubectl and helm with access to the current cluster, the container
+registries the project pulls from and pushes to, and the environment variables the
+configuration and .env.example expect. Every problem comes with the step that fixes it.
+
+Outside a project only the tools are checked, and missing ones are warnings. Inside one,
+what the project uses must work. The command fails when a check fails, or with --strict
+when one warns.
+
+Examples:
+  microframework doctor
+  microframework doctor --strict
+  microframework doctor --output json
+
+Exit codes:
+  0  every check passed (or, without --strict, only warned)
+  2  invalid flags
+  3  a check failed`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDoctor(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "text", "Output format (text, json)")
+	doctorCmd.Flags().BoolVar(&doctorStrict, "strict", false, "Fail on warnings too")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "Timeout of each check that reaches a daemon, a cluster or a registry")
+}
+
+// doctorCheck is the result of one check
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Remedy is the step that fixes a warning or a failure
+	Remedy string `json:"remedy,omitempty"`
+}
+
+// doctorReport is the output of doctor
+type doctorReport struct {
+	Project string        `json:"project,omitempty"`
+	Checks  []doctorCheck `json:"checks"`
+	Passed  bool          `json:"passed"`
+}
+
+// doctorProject is what doctor knows of the project in the current directory
+type doctorProject struct {
+	name        string
+	goVersion   string
+	serviceType string
+	docker      bool
+	kubernetes  bool
+	helm        bool
+	proto       bool
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorOutput != "text" && doctorOutput != "json" {
+		return &UserError{fmt.Errorf("invalid output format %q (text, json)", doctorOutput)}
+	}
+
+	project := inspectDoctorProject()
+	checks := []doctorCheck{checkGoVersion(project)}
+	checks = append(checks, checkProtoTools(project))
+	checks = append(checks, checkDocker(project))
+	checks = append(checks, checkKubernetes(project)...)
+	checks = append(checks, checkRegistries(project)...)
+	checks = append(checks, checkEnvironment(project)...)
+
+	report := doctorReport{Checks: checks, Passed: true}
+	if project != nil {
+		report.Project = project.name
+	}
+	for _, check := range checks {
+		if check.Status == DoctorFail || (doctorStrict && check.Status == DoctorWarn) {
+			report.Passed = false
+		}
+	}
+
+	if doctorOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(report)
+	}
+	if !report.Passed {
+		return &EnvironmentError{fmt.Errorf("the environment is not ready (see the remedies of the failed checks)")}
+	}
+	return nil
+}
+
+// printDoctorReport prints the checks with their remedies, and the totals
+func printDoctorReport(report doctorReport) {
+	if report.Project != "" {
+		fmt.Printf("Checking the environment of %s\n\n", report.Project)
+	} else {
+		fmt.Printf("Checking the environment (not in a project: only the tools are checked)\n\n")
+	}
+
+	counts := make(map[string]int)
+	for _, check := range report.Checks {
+		counts[check.Status]++
+		fmt.Printf("[%s] %-14s %s\n", strings.ToUpper(check.Status), check.Name, check.Message)
+		if check.Remedy != "" && check.Status != DoctorPass && check.Status != DoctorSkip {
+			fmt.Printf("       %-14s -> %s\n", "", check.Remedy)
+		}
+	}
+
+	result := "PASSED"
+	if !report.Passed {
+		result = "FAILED"
+	}
+	fmt.Printf("\n%s: %d passed, %d warnings, %d failed, %d skipped\n",
+		result, counts[DoctorPass], counts[DoctorWarn], counts[DoctorFail], counts[DoctorSkip])
+}
+
+// inspectDoctorProject returns the project in the current directory, or nil outside one
+func inspectDoctorProject() *doctorProject {
+	if checkMicroserviceDirectory() != nil {
+		return nil
+	}
+	project := &doctorProject{name: filepath.Base(currentModulePath())}
+	if content, err := os.ReadFile("go.mod"); err == nil {
+		project.goVersion = goDirective(string(content))
+	}
+	if manifest, err := generator.LoadManifest("."); err == nil {
+		project.serviceType = manifest.Config.ServiceType
+		if manifest.Config.ServiceName != "" {
+			project.name = manifest.Config.ServiceName
+		}
+	}
+
+	filepath.WalkDir(".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != "." && (strings.HasPrefix(entry.Name(), ".") || containsString(runSkipDirs, entry.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(path, ".proto"):
+			project.proto = true
+		case entry.Name() == "Dockerfile" || strings.HasPrefix(entry.Name(), "Dockerfile."):
+			project.docker = true
+		}
+		return nil
+	})
+	project.kubernetes = isDirectory(filepath.Join("deployments", "kubernetes")) || isDirectory(filepath.Join("deployments", "k8s"))
+	project.helm = isDirectory(filepath.Join("deployments", "helm"))
+	if project.serviceType == "grpc" {
+		project.proto = true
+	}
+	return project
+}
+
+// goDirective returns the go directive of a go.mod
+func goDirective(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// probe runs a command bounded by the doctor timeout and returns its trimmed output
+func probe(name string, args ...string) (string, error) {
+	return probeWithin(doctorTimeout, name, args...)
+}
+
+// probeWithin runs a command bounded by timeout and returns its trimmed output, or the first
+// line of its output as the error
+func probeWithin(timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("no answer within %s", timeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return "", errors.New(firstLine(message))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// required returns the status of a missing tool: a failure when the project uses it, a
+// warning otherwise
+func required(used bool) string {
+	if used {
+		return DoctorFail
+	}
+	return DoctorWarn
+}
+
+// checkGoVersion checks that Go is installed, at least minimumGoVersion and the version of
+// the go directive of the project
+func checkGoVersion(project *doctorProject) doctorCheck {
+	check := doctorCheck{Name: "go"}
+	output, err := probe("go", "env", "GOVERSION")
+	if err != nil {
+		check.Status, check.Message = DoctorFail, "Go is not installed or not in PATH"
+		check.Remedy = "install Go from https://go.dev/dl and add it to PATH"
+		return check
+	}
+	installed := "v" + strings.TrimPrefix(output, "go")
+	// Development builds report versions such as go1.24-devel
+	installed, _, _ = strings.Cut(installed, "-")
+
+	wanted := minimumGoVersion
+	if project != nil && project.goVersion != "" && semver.Compare("v"+project.goVersion, wanted) > 0 {
+		wanted = "v" + project.goVersion
+	}
+	if semver.IsValid(installed) && semver.Compare(installed, wanted) < 0 {
+		check.Status = DoctorFail
+		check.Message = fmt.Sprintf("%s is older than the required go%s", output, strings.TrimPrefix(wanted, "v"))
+		check.Remedy = fmt.Sprintf("install go%s or newer from https://go.dev/dl", strings.TrimPrefix(wanted, "v"))
+		return check
+	}
+	check.Status, check.Message = DoctorPass, output
+	return check
+}
+
+// checkProtoTools checks that buf, or protoc with protoc-gen-go, can generate the code of
+// the protocol buffers of the project
+func checkProtoTools(project *doctorProject) doctorCheck {
+	check := doctorCheck{Name: "protobuf"}
+	used := project != nil && project.proto
+	if project != nil && !used {
+		check.Status, check.Message = DoctorSkip, "the project has no protocol buffers"
+		return check
+	}
+
+	if version, err := probe("buf", "--version"); err == nil {
+		check.Status, check.Message = DoctorPass, "buf "+version
+		return check
+	}
+	version, err := probe("protoc", "--version")
+	if err != nil {
+		check.Status, check.Message = required(used), "neither buf nor protoc is installed"
+		check.Remedy = "install buf (https://buf.build/docs/installation) or protoc (https://grpc.io/docs/protoc-installation)"
+		return check
+	}
+	var missing []string
+	for _, plugin := range []string{"protoc-gen-go", "protoc-gen-go-grpc"} {
+		if _, err := exec.LookPath(plugin); err != nil {
+			missing = append(missing, plugin)
+		}
+	}
+	if len(missing) > 0 {
+		check.Status = required(used)
+		check.Message = fmt.Sprintf("%s, but %s missing", version, strings.Join(missing, " and "))
+		check.Remedy = "go install google.golang.org/protobuf/cmd/protoc-gen-go@latest google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest"
+		return check
+	}
+	check.Status, check.Message = DoctorPass, version
+	return check
+}
+
+// checkDocker checks that the Docker daemon answers
+func checkDocker(project *doctorProject) doctorCheck {
+	check := doctorCheck{Name: "docker"}
+	used := project != nil && project.docker
+	if _, err := exec.LookPath("docker"); err != nil {
+		check.Status, check.Message = required(used), "docker is not installed"
+		check.Remedy = "install Docker from https://docs.docker.com/get-docker"
+		return check
+	}
+	version, err := probe("docker", "info", "--format", "{{.ServerVersion}}")
+	if err != nil {
+		check.Status, check.Message = required(used), "the Docker daemon does not answer: "+err.Error()
+		check.Remedy = "start Docker (Docker Desktop, or sudo systemctl start docker) and check that your user may use it"
+		return check
+	}
+	check.Status, check.Message = DoctorPass, "daemon "+version
+	return check
+}
+
+// checkKubernetes checks kubectl, helm and the access to the current cluster
+func checkKubernetes(project *doctorProject) []doctorCheck {
+	kubectl := doctorCheck{Name: "kubectl"}
+	cluster := doctorCheck{Name: "cluster"}
+	helm := doctorCheck{Name: "helm"}
+	usesCluster := project != nil && (project.kubernetes || project.helm)
+
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		kubectl.Status, kubectl.Message = required(usesCluster), "kubectl is not installed"
+		kubectl.Remedy = "install kubectl from https://kubernetes.io/docs/tasks/tools"
+		cluster.Status, cluster.Message = DoctorSkip, "needs kubectl"
+	} else {
+		version, _ := probe("kubectl", "version", "--client", "-o", "json")
+		var client struct {
+			ClientVersion struct {
+				GitVersion string `json:"gitVersion"`
+			} `json:"clientVersion"`
+		}
+		json.Unmarshal([]byte(version), &client)
+		kubectl.Status, kubectl.Message = DoctorPass, strings.TrimSpace("kubectl "+client.ClientVersion.GitVersion)
+
+		switch {
+		case project != nil && !usesCluster:
+			cluster.Status, cluster.Message = DoctorSkip, "the project has no Kubernetes deployment"
+		default:
+			cluster = checkCluster(usesCluster)
+		}
+	}
+
+	usesHelm := project != nil && project.helm
+	switch {
+	case project != nil && !usesHelm:
+		helm.Status, helm.Message = DoctorSkip, "the project has no Helm chart"
+	default:
+		if version, err := probe("helm", "version", "--short"); err != nil {
+			helm.Status, helm.Message = required(usesHelm), "helm is not installed"
+			helm.Remedy = "install Helm from https://helm.sh/docs/intro/install"
+		} else {
+			helm.Status, helm.Message = DoctorPass, "helm "+version
+		}
+	}
+	return []doctorCheck{kubectl, cluster, helm}
+}
+
+// checkCluster checks that the current kubectl context answers
+func checkCluster(used bool) doctorCheck {
+	check := doctorCheck{Name: "cluster"}
+	context, err := probe("kubectl", "config", "current-context")
+	if err != nil || context == "" {
+		check.Status, check.Message = required(used), "kubectl has no current context"
+		check.Remedy = "select a cluster with kubectl config use-context <name>, or get its credentials from your provider (aws eks update-kubeconfig, gcloud container clusters get-credentials...)"
+		return check
+	}
+	timeout := fmt.Sprintf("--request-timeout=%s", doctorTimeout)
+	if _, err := probe("kubectl", "version", timeout); err != nil {
+		check.Status, check.Message = required(used), fmt.Sprintf("context %s does not answer: %v", context, err)
+		check.Remedy = "check the VPN or network to the cluster and refresh its credentials"
+		return check
+	}
+	if answer, err := probe("kubectl", "auth", "can-i", "create", "deployments", timeout); err != nil || answer != "yes" {
+		check.Status, check.Message = DoctorWarn, fmt.Sprintf("context %s answers, but may not create deployments", context)
+		check.Remedy = "ask the cluster administrator for a role that can create deployments in the namespace"
+		return check
+	}
+	check.Status, check.Message = DoctorPass, "context "+context
+	return check
+}
+
+// imageReferencePattern matches the images of the Dockerfiles and manifests of a project
+var imageReferencePattern = regexp.MustCompile(`(?m)^\s*(?:-\s*)?(?:image:|FROM)\s+["']?([^\s"'#]+)`)
+
+// checkRegistries checks that the registries of the images the project uses answer, and
+// that there are credentials for the private ones
+func checkRegistries(project *doctorProject) []doctorCheck {
+	if project == nil {
+		return nil
+	}
+	registries := make(map[string]bool)
+	filepath.WalkDir(".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != "." && (strings.HasPrefix(entry.Name(), ".") || containsString(runSkipDirs, entry.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := entry.Name()
+		isManifest := strings.HasPrefix(filepath.ToSlash(path), "deployments/") && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml"))
+		if !isManifest && name != "Dockerfile" && !strings.HasPrefix(name, "Dockerfile.") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, match := range imageReferencePattern.FindAllStringSubmatch(string(content), -1) {
+			if registry := imageRegistry(match[1]); registry != "" {
+				registries[registry] = true
+			}
+		}
+		return nil
+	})
+	if len(registries) == 0 {
+		return []doctorCheck{{Name: "registry", Status: DoctorSkip, Message: "the project references no images"}}
+	}
+
+	names := make([]string, 0, len(registries))
+	for registry := range registries {
+		names = append(names, registry)
+	}
+	sort.Strings(names)
+	credentials := dockerCredentialHosts()
+	client := &http.Client{Timeout: doctorTimeout}
+
+	var checks []doctorCheck
+	for _, registry := range names {
+		check := doctorCheck{Name: "registry", Message: registry}
+		host := registry
+		if host == "docker.io" {
+			host = "registry-1.docker.io"
+		}
+		scheme := "https"
+		if strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+			scheme = "http"
+		}
+		response, err := client.Get(scheme + "://" + host + "/v2/")
+		switch {
+		case err != nil:
+			check.Status, check.Message = DoctorFail, fmt.Sprintf("%s does not answer: %v", registry, err)
+			check.Remedy = "check the network, proxy or VPN to the registry"
+		case response.StatusCode != http.StatusOK && response.StatusCode != http.StatusUnauthorized:
+			check.Status, check.Message = DoctorWarn, fmt.Sprintf("%s answers %s, which is not a registry API", registry, response.Status)
+			check.Remedy = "check the registry host of the images"
+		case registry != "docker.io" && !credentials[registry] && !credentials["*"]:
+			check.Status, check.Message = DoctorWarn, registry+" answers, but docker has no credentials for it"
+			check.Remedy = "docker login " + registry
+		default:
+			check.Status, check.Message = DoctorPass, registry+" answers"
+		}
+		if response != nil {
+			response.Body.Close()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// imageRegistry returns the registry host of an image reference, docker.io for images without
+// one and "" for references that are templated or build stages
+func imageRegistry(image string) string {
+	if strings.ContainsAny(image, "{}$") || image == "scratch" {
+		return ""
+	}
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	if !found && !strings.Contains(image, ":") && !strings.Contains(image, "@") {
+		// A bare name without a tag is usually a build stage (FROM builder)
+		return ""
+	}
+	return "docker.io"
+}
+
+// dockerCredentialHosts returns the registries docker has credentials for; "*" means a
+// credential store that may hold any of them
+func dockerCredentialHosts() map[string]bool {
+	hosts := make(map[string]bool)
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return hosts
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return hosts
+	}
+	var config struct {
+		Auths       map[string]json.RawMessage `json:"auths"`
+		CredHelpers map[string]string          `json:"credHelpers"`
+		CredsStore  string                     `json:"credsStore"`
+	}
+	if json.Unmarshal(content, &config) != nil {
+		return hosts
+	}
+	for host := range config.Auths {
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		host, _, _ = strings.Cut(host, "/")
+		hosts[host] = true
+	}
+	for host := range config.CredHelpers {
+		hosts[host] = true
+	}
+	if config.CredsStore != "" {
+		hosts["*"] = true
+	}
+	return hosts
+}
+
+// envReferencePattern matches the ${VAR}, ${VAR:-default} and ${VAR:?message} references of
+// the configuration files
+var envReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-|:\?)?[^}]*\}`)
+
+// checkEnvironment checks that the variables the configuration references without a default,
+// and the ones .env.example lists, are set in the environment or in .env
+func checkEnvironment(project *doctorProject) []doctorCheck {
+	if project == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		set[name] = true
+	}
+	dotenv, err := loadEnvFile(".env")
+	if err != nil {
+		return []doctorCheck{{Name: "env", Status: DoctorFail, Message: err.Error(), Remedy: "fix the line of .env"}}
+	}
+	for _, entry := range dotenv {
+		name, _, _ := strings.Cut(entry, "=")
+		set[name] = true
+	}
+
+	// Variables the configuration needs: without them it fails to load (:?) or reads ""
+	needed, optional := make(map[string][]string), make(map[string][]string)
+	files, _ := filepath.Glob(filepath.Join("configs", "*.y*ml"))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, match := range envReferencePattern.FindAllStringSubmatch(string(content), -1) {
+			switch match[2] {
+			case ":?":
+				needed[match[1]] = appendUnique(needed[match[1]], filepath.Base(file))
+			case "":
+				optional[match[1]] = appendUnique(optional[match[1]], filepath.Base(file))
+			}
+		}
+	}
+	var example []string
+	if file, err := os.Open(".env.example"); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if name, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "="); ok && !strings.HasPrefix(line, "#") {
+				example = append(example, strings.TrimSpace(name))
+			}
+		}
+		file.Close()
+	}
+
+	var checks []doctorCheck
+	if missing := unsetVariables(needed, set); len(missing) > 0 {
+		checks = append(checks, doctorCheck{
+			Name:    "env",
+			Status:  DoctorFail,
+			Message: "required by the configuration but unset: " + strings.Join(missing, ", "),
+			Remedy:  "set them in .env or the environment",
+		})
+	}
+	if missing := unsetVariables(optional, set); len(missing) > 0 {
+		checks = append(checks, doctorCheck{
+			Name:    "env",
+			Status:  DoctorWarn,
+			Message: "referenced by the configuration without a default, and read as empty: " + strings.Join(missing, ", "),
+			Remedy:  "set them in .env, or give the references a default with ${VAR:-default}",
+		})
+	}
+	var missingExample []string
+	for _, name := range example {
+		if !set[name] {
+			missingExample = append(missingExample, name)
+		}
+	}
+	if len(missingExample) > 0 {
+		message := fmt.Sprintf("%d of the %d variables of .env.example are unset", len(missingExample), len(example))
+		if len(missingExample) <= 5 {
+			message += ": " + strings.Join(missingExample, ", ")
+		}
+		checks = append(checks, doctorCheck{
+			Name:    "env",
+			Status:  DoctorWarn,
+			Message: message,
+			Remedy:  "cp .env.example .env and fill in the values",
+		})
+	}
+	if len(checks) == 0 {
+		message := "every referenced variable is set"
+		if len(needed)+len(optional)+len(example) == 0 {
+			message = "the project references no variables"
+		}
+		checks = append(checks, doctorCheck{Name: "env", Status: DoctorPass, Message: message})
+	}
+	return checks
+}
+
+// unsetVariables returns the unset variables of references, with the files referencing them
+func unsetVariables(references map[string][]string, set map[string]bool) []string {
+	var missing []string
+	for name, files := range references {
+		if !set[name] {
+			missing = append(missing, fmt.Sprintf("%s (%s)", name, strings.Join(files, ", ")))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// environmentsFile maps the environments of a project to where they run
+var environmentsFile = filepath.Join("deployments", "environments.yaml")
+
+// Targets an environment can run on
+const (
+	TargetDocker     = "docker"
+	TargetCompose    = "compose"
+	TargetKubernetes = "kubernetes"
+	TargetECS        = "ecs"
+	TargetCloudRun   = "cloudrun"
+)
+
+// environmentOrder is the order environments are listed in, the others following by name
+var environmentOrder = []string{"development", "test", "staging", "production"}
+
+// deploymentEnvironment is where an environment of the project runs
+type deploymentEnvironment struct {
+	Name   string `yaml:"-"`
+	Target string `yaml:"target"`
+	// Service is the container, compose service, deployment, ECS service or Cloud Run
+	// service; the name of the service by default
+	Service     string `yaml:"service,omitempty"`
+	ComposeFile string `yaml:"compose_file,omitempty"`
+	Context     string `yaml:"context,omitempty"`
+	Namespace   string `yaml:"namespace,omitempty"`
+	// Selector selects the pods of the service; app=<service> by default
+	Selector string `yaml:"selector,omitempty"`
+	Project  string `yaml:"project,omitempty"`
+	Region   string `yaml:"region,omitempty"`
+	Cluster  string `yaml:"cluster,omitempty"`
+	// LogGroup is the CloudWatch log group of an ECS service; /ecs/<service> by default
+	LogGroup string `yaml:"log_group,omitempty"`
+	// URL is where the service is reached from the developer's machine
+	URL string `yaml:"url,omitempty"`
+	// Config is the configuration overlay of the environment, configs/config.<config>.yaml;
+	// the environment's name when that file exists
+	Config string `yaml:"config,omitempty"`
+}
+
+// projectServiceName returns the name of the service in the current directory
+func projectServiceName() string {
+	if manifest, err := generator.LoadManifest("."); err == nil && manifest.Config.ServiceName != "" {
+		return manifest.Config.ServiceName
+	}
+	name := path.Base(currentModulePath())
+	if name == "." || name == "/" {
+		return "service"
+	}
+	return name
+}
+
+// loadEnvironments returns the environments of the project in the current directory. A
+// project without an environments file has a development environment on docker-compose,
+// or on docker without a compose file.
+func loadEnvironments() ([]*deploymentEnvironment, error) {
+	service := projectServiceName()
+	var file struct {
+		Environments map[string]*deploymentEnvironment `yaml:"environments"`
+	}
+
+	content, err := os.ReadFile(environmentsFile)
+	switch {
+	case os.IsNotExist(err):
+		development := &deploymentEnvironment{Target: TargetDocker}
+		if _, err := os.Stat(filepath.Join("deployments", "docker", "docker-compose.yml")); err == nil {
+			development.Target = TargetCompose
+		}
+		file.Environments = map[string]*deploymentEnvironment{"development": development}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", environmentsFile, err)
+	default:
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", environmentsFile, err)
+		}
+	}
+
+	environments := make([]*deploymentEnvironment, 0, len(file.Environments))
+	for name, environment := range file.Environments {
+		if environment == nil {
+			return nil, fmt.Errorf("%s: environment %s is empty", environmentsFile, name)
+		}
+		environment.Name = name
+		switch environment.Target {
+		case TargetDocker, TargetCompose, TargetKubernetes, TargetECS, TargetCloudRun:
+		default:
+			return nil, fmt.Errorf("%s: environment %s has the unknown target %q (docker, compose, kubernetes, ecs, cloudrun)", environmentsFile, name, environment.Target)
+		}
+		if environment.Service == "" {
+			environment.Service = service
+		}
+		if environment.Target == TargetCompose && environment.ComposeFile == "" {
+			environment.ComposeFile = filepath.Join("deployments", "docker", "docker-compose.yml")
+		}
+		if environment.Selector == "" {
+			environment.Selector = "app=" + environment.Service
+		}
+		if environment.Target == TargetECS && environment.LogGroup == "" {
+			environment.LogGroup = "/ecs/" + environment.Service
+		}
+		if environment.Config == "" {
+			if _, err := os.Stat(filepath.Join("configs", "config."+name+".yaml")); err == nil {
+				environment.Config = name
+			}
+		}
+		environments = append(environments, environment)
+	}
+
+	rank := func(name string) int {
+		for i, known := range environmentOrder {
+			if name == known {
+				return i
+			}
+		}
+		return len(environmentOrder)
+	}
+	sort.Slice(environments, func(i, j int) bool {
+		if ri, rj := rank(environments[i].Name), rank(environments[j].Name); ri != rj {
+			return ri < rj
+		}
+		return environments[i].Name < environments[j].Name
+	})
+	return environments, nil
+}
+
+// findEnvironment returns an environment of the project
+func findEnvironment(name string) (*deploymentEnvironment, error) {
+	environments, err := loadEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, environment := range environments {
+		if environment.Name == name {
+			return environment, nil
+		}
+		names = append(names, environment.Name)
+	}
+	return nil, fmt.Errorf("no environment %q in %s (environments: %v)", name, environmentsFile, names)
+}
+
+// kubectlArgs returns the context and namespace flags of a Kubernetes environment
+func (e *deploymentEnvironment) kubectlArgs() []string {
+	var args []string
+	if e.Context != "" {
+		args = append(args, "--context", e.Context)
+	}
+	if e.Namespace != "" {
+		args = append(args, "--namespace", e.Namespace)
+	}
+	return args
+}
+
+// gcloudArgs returns the project and region flags of a Cloud Run environment
+func (e *deploymentEnvironment) gcloudArgs() []string {
+	var args []string
+	if e.Project != "" {
+		args = append(args, "--project", e.Project)
+	}
+	if e.Region != "" {
+		args = append(args, "--region", e.Region)
+	}
+	return args
+}
+
+// awsArgs returns the cluster and region flags of an ECS environment
+func (e *deploymentEnvironment) awsArgs() []string {
+	var args []string
+	if e.Cluster != "" {
+		args = append(args, "--cluster", e.Cluster)
+	}
+	if e.Region != "" {
+		args = append(args, "--region", e.Region)
+	}
+	return args
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+// Exit codes of the CLI, one per category of error, so that automation can branch on why a
+// command failed
+const (
+	// ExitOK means the command succeeded
+	ExitOK = 0
+	// ExitError means the command failed for a reason of no category below
+	ExitError = 1
+	// ExitUser means the command was invoked incorrectly: flags, arguments, or the directory
+	// it ran in (UserError)
+	ExitUser = 2
+	// ExitEnvironment means a tool, service or credential the command needs is missing or
+	// unreachable (EnvironmentError)
+	ExitEnvironment = 3
+	// ExitGeneration means generating or rendering files failed (GenerationError)
+	ExitGeneration = 4
+	// ExitDeployment means deploying, scaling or rolling out failed (DeploymentError)
+	ExitDeployment = 5
+	// ExitValidation means the project failed a check: validation findings, migration
+	// checksums, post-update verification (ValidationFailure)
+	ExitValidation = 6
+)
+
+// Error categories, as reported in the JSON report
+const (
+	ErrorCategoryUser        = "user"
+	ErrorCategoryEnvironment = "environment"
+	ErrorCategoryGeneration  = "generation"
+	ErrorCategoryDeployment  = "deployment"
+	ErrorCategoryValidation  = "validation"
+	ErrorCategoryInternal    = "internal"
+)
+
+// UserError is an error caused by how a command was invoked rather than by the project or the
+// environment: invalid flags or arguments, or a command run outside a microservice
+type UserError struct {
+	Err error
+}
+
+func (e *UserError) Error() string { return e.Err.Error() }
+func (e *UserError) Unwrap() error { return e.Err }
+
+// EnvironmentError is an error caused by the environment: a tool that is not installed, a
+// database or cluster that cannot be reached, missing credentials
+type EnvironmentError struct {
+	Err error
+}
+
+func (e *EnvironmentError) Error() string { return e.Err.Error() }
+func (e *EnvironmentError) Unwrap() error { return e.Err }
+
+// GenerationError is an error generating or rendering the files of a project
+type GenerationError struct {
+	Err error
+}
+
+func (e *GenerationError) Error() string { return e.Err.Error() }
+func (e *GenerationError) Unwrap() error { return e.Err }
+
+// DeploymentError is an error deploying, scaling or rolling out a service
+type DeploymentError struct {
+	Err error
+}
+
+func (e *DeploymentError) Error() string { return e.Err.Error() }
+func (e *DeploymentError) Unwrap() error { return e.Err }
+
+// ValidationFailure reports a project that failed a check, as validation findings reaching
+// the --fail-on threshold
+type ValidationFailure struct {
+	Err error
+}
+
+func (e *ValidationFailure) Error() string { return e.Err.Error() }
+func (e *ValidationFailure) Unwrap() error { return e.Err }
+
+// errorCategory returns the category of an error and its exit code. The outermost
+// categorized error of the chain decides, so that a command can recategorize the errors
+// of what it calls.
+func errorCategory(err error) (string, int) {
+	// A tool that is not installed is the environment's fault, whatever the command
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrorCategoryEnvironment, ExitEnvironment
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch err.(type) {
+		case *UserError:
+			return ErrorCategoryUser, ExitUser
+		case *EnvironmentError:
+			return ErrorCategoryEnvironment, ExitEnvironment
+		case *GenerationError:
+			return ErrorCategoryGeneration, ExitGeneration
+		case *DeploymentError:
+			return ErrorCategoryDeployment, ExitDeployment
+		case *ValidationFailure:
+			return ErrorCategoryValidation, ExitValidation
+		case *generator.ConfigError:
+			return ErrorCategoryUser, ExitUser
+		}
+	}
+	return ErrorCategoryInternal, ExitError
+}
+
+// ExitCode returns the exit code of the CLI for the error a command failed with, ExitOK for
+// nil
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	_, code := errorCategory(err)
+	return code
+}
+
+// usageHint follows the message of a user error, naming the help of the command that failed
+var usageHint string
+
+// PrintError prints the error a command failed with on the standard error, followed by where
+// to find the usage of the command when it was invoked incorrectly
+func PrintError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if category, _ := errorCategory(err); category == ErrorCategoryUser && usageHint != "" {
+		fmt.Fprintln(os.Stderr, usageHint)
+	}
+}
+
+// exitWithError ends a command that exits on its own, rather than returning its error to
+// Execute: it prints the error, or the JSON report, and exits with the code of its category
+func exitWithError(err error) {
+	PrintError(err)
+	writeReport(err)
+	os.Exit(ExitCode(err))
+}
@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	eventsRegistry string
+	eventsUsername string
+	eventsPassword string
+	eventsCheck    bool
+	eventsTimeout  time.Duration
+)
+
+// eventsRegistryFile lists the subjects of the schemas generate from-asyncapi generates
+var eventsRegistryFile = filepath.Join("api", "events", "registry.yaml")
+
+// eventsCmd represents the events command
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Manage the schemas of the messages of the service",
+	Long: `Manage the schemas of the messages of the service, which generate from-asyncapi
+--serialization avro or protobuf generates into api/events with the subjects of the schema
+registry they are registered under, in api/events/registry.yaml.
+
+Examples:
+  microframework events register --registry http://localhost:8081
+  microframework events register --check`,
+}
+
+var eventsRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register the schemas of the messages in the schema registry",
+	Long: `Register the Avro or Protobuf schemas of api/events in a Confluent Schema Registry, or a
+compatible one, under the subjects of api/events/registry.yaml: the schema of the messages of
+a topic under <topic>-value, those of a topic of several message types under
+<namespace>.<name>. A schema registered already keeps its version.
+
+With --check, the schemas are only checked for compatibility with the latest versions of
+their subjects, by the compatibility level of the registry; the incompatible ones fail the
+command with the exit code of failed checks, so that it runs in CI before the messages change.
+
+The registry defaults to $SCHEMA_REGISTRY_URL, its basic auth to $SCHEMA_REGISTRY_USERNAME and
+$SCHEMA_REGISTRY_PASSWORD.`,
+	Args: cobra.NoArgs,
+	RunE: runEventsRegister,
+}
+
+func init() {
+	eventsRegisterCmd.Flags().StringVar(&eventsRegistry, "registry", "", "URL of the schema registry (default $SCHEMA_REGISTRY_URL, else http://localhost:8081)")
+	eventsRegisterCmd.Flags().StringVar(&eventsUsername, "username", "", "Basic auth username of the registry (default $SCHEMA_REGISTRY_USERNAME)")
+	eventsRegisterCmd.Flags().StringVar(&eventsPassword, "password", "", "Basic auth password of the registry (default $SCHEMA_REGISTRY_PASSWORD)")
+	eventsRegisterCmd.Flags().BoolVar(&eventsCheck, "check", false, "Only check the schemas are compatible with the registered ones")
+	eventsRegisterCmd.Flags().DurationVar(&eventsTimeout, "timeout", 30*time.Second, "Timeout of each request to the registry")
+
+	eventsCmd.AddCommand(eventsRegisterCmd)
+}
+
+// eventsRegistryManifest is api/events/registry.yaml
+type eventsRegistryManifest struct {
+	Format   string `yaml:"format"`
+	Subjects []struct {
+		Subject string `yaml:"subject"`
+		Type    string `yaml:"type"`
+		Schema  string `yaml:"schema"`
+	} `yaml:"subjects"`
+}
+
+// eventsSubjectResult is a subject of events register in the JSON report
+type eventsSubjectResult struct {
+	Subject string `json:"subject"`
+	Type    string `json:"type"`
+	// ID is the ID of the schema registered, Compatible whether it is compatible with --check
+	ID         int   `json:"id,omitempty"`
+	Compatible *bool `json:"compatible,omitempty"`
+}
+
+// eventsRegisterResult is the result of events register in the JSON report
+type eventsRegisterResult struct {
+	Registry string                `json:"registry"`
+	Format   string                `json:"format"`
+	Checked  bool                  `json:"checked"`
+	Subjects []eventsSubjectResult `json:"subjects"`
+}
+
+func runEventsRegister(cmd *cobra.Command, args []string) error {
+	content, err := os.ReadFile(eventsRegistryFile)
+	if os.IsNotExist(err) {
+		return &UserError{fmt.Errorf("%s not found: generate the schemas with microframework generate from-asyncapi --serialization avro or protobuf", eventsRegistryFile)}
+	} else if err != nil {
+		return err
+	}
+	var manifest eventsRegistryManifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return &UserError{fmt.Errorf("invalid %s: %w", eventsRegistryFile, err)}
+	}
+	if manifest.Format != "avro" && manifest.Format != "protobuf" {
+		return &UserError{fmt.Errorf("%s: unknown format %q", eventsRegistryFile, manifest.Format)}
+	}
+
+	registry := &schemaRegistry{
+		url:      firstNonEmpty(eventsRegistry, os.Getenv("SCHEMA_REGISTRY_URL"), "http://localhost:8081"),
+		username: firstNonEmpty(eventsUsername, os.Getenv("SCHEMA_REGISTRY_USERNAME")),
+		password: firstNonEmpty(eventsPassword, os.Getenv("SCHEMA_REGISTRY_PASSWORD")),
+		client:   &http.Client{Timeout: eventsTimeout},
+	}
+	result := eventsRegisterResult{Registry: registry.url, Format: manifest.Format, Checked: eventsCheck}
+	reportResult(&result)
+
+	var incompatible []string
+	for _, subject := range manifest.Subjects {
+		schema, err := os.ReadFile(filepath.Join(filepath.Dir(eventsRegistryFile), filepath.FromSlash(subject.Schema)))
+		if err != nil {
+			return &UserError{fmt.Errorf("schema of %s: %w", subject.Subject, err)}
+		}
+		request := map[string]string{"schema": string(schema)}
+		if manifest.Format == "protobuf" {
+			request["schemaType"] = "PROTOBUF"
+		}
+		subjectResult := eventsSubjectResult{Subject: subject.Subject, Type: subject.Type}
+
+		if eventsCheck {
+			var response struct {
+				IsCompatible bool `json:"is_compatible"`
+			}
+			status, err := registry.call(http.MethodPost, "/compatibility/subjects/"+url.PathEscape(subject.Subject)+"/versions/latest", request, &response)
+			switch {
+			case status == http.StatusNotFound:
+				// The subject is not registered yet; any schema is compatible
+				response.IsCompatible = true
+			case err != nil:
+				return &EnvironmentError{fmt.Errorf("failed to check %s: %w", subject.Subject, err)}
+			}
+			subjectResult.Compatible = &response.IsCompatible
+			if response.IsCompatible {
+				fmt.Printf("✓ %s (%s) is compatible\n", subject.Subject, subject.Type)
+			} else {
+				fmt.Printf("✗ %s (%s) is incompatible with its latest version\n", subject.Subject, subject.Type)
+				incompatible = append(incompatible, subject.Subject)
+			}
+		} else {
+			var response struct {
+				ID int `json:"id"`
+			}
+			if _, err := registry.call(http.MethodPost, "/subjects/"+url.PathEscape(subject.Subject)+"/versions", request, &response); err != nil {
+				return &EnvironmentError{fmt.Errorf("failed to register %s: %w", subject.Subject, err)}
+			}
+			subjectResult.ID = response.ID
+			fmt.Printf("✓ %s (%s): schema %d\n", subject.Subject, subject.Type, response.ID)
+		}
+		result.Subjects = append(result.Subjects, subjectResult)
+	}
+
+	if len(incompatible) > 0 {
+		return &ValidationFailure{fmt.Errorf("incompatible schemas: %s", strings.Join(incompatible, ", "))}
+	}
+	if eventsCheck {
+		fmt.Printf("✓ %d schemas are compatible with %s\n", len(result.Subjects), registry.url)
+	} else {
+		fmt.Printf("✓ %d schemas registered in %s\n", len(result.Subjects), registry.url)
+	}
+	return nil
+}
+
+// schemaRegistry is the REST API of a schema registry
+type schemaRegistry struct {
+	url                string
+	username, password string
+	client             *http.Client
+}
+
+// call sends a request to the registry and decodes its response, returning its status
+func (r *schemaRegistry) call(method, path string, request, response interface{}) (int, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(r.url, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, failure.Message)
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(response)
+}
+
+// firstNonEmpty returns the first of the values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	gatewayServices    []string
+	gatewayHost        string
+	gatewayPort        int
+	gatewayHeaders     []string
+	gatewayAuthToken   string
+	gatewayCORSOrigins []string
+	gatewayKeepPrefix  bool
+)
+
+// gatewayCmd represents the gateway command
+var gatewayCmd = &cobra.Command{
+	Use:   "gateway",
+	Short: "Run a local gateway in front of the services running on this machine",
+	Long: `Run a local reverse proxy in front of several services, so that a frontend reaches them all
+on one port during development.
+
+Requests are routed by the first segment of their path: /user/profile goes to the service
+named user as /profile (--keep-prefix forwards /user/profile). Services are given as
+name:port, for a service on localhost, or name:URL. Without --services, the services of the
+go.work of the current directory are routed, on the port of their configs/config.yaml.
+
+The headers of --header, and the bearer token of --auth-token, are added to every proxied
+request, so that the services see an authenticated caller. CORS is answered by the gateway
+for the origins of --cors-origin, preflight requests included.
+
+GET /_gateway lists the routes and whether each service answers its /health endpoint.
+
+Examples:
+  microframework gateway --services user:8081,order:8082
+  microframework gateway --services user:8081,payments:http://localhost:9000 --port 3001
+  microframework gateway --auth-token "$DEV_TOKEN" --cors-origin http://localhost:5173`,
+	Args: cobra.NoArgs,
+	RunE: runGateway,
+}
+
+func init() {
+	gatewayCmd.Flags().StringSliceVar(&gatewayServices, "services", nil, "Services to route to, name:port or name:URL (default: the services of go.work)")
+	gatewayCmd.Flags().StringVar(&gatewayHost, "host", "localhost", "Address to listen on")
+	gatewayCmd.Flags().IntVar(&gatewayPort, "port", 8000, "Port to listen on")
+	gatewayCmd.Flags().StringArrayVar(&gatewayHeaders, "header", nil, "Header added to the proxied requests, \"Name: value\" (repeatable)")
+	gatewayCmd.Flags().StringVar(&gatewayAuthToken, "auth-token", "", "Bearer token set as the Authorization header of the proxied requests")
+	gatewayCmd.Flags().StringSliceVar(&gatewayCORSOrigins, "cors-origin", []string{"*"}, "Origins allowed to call the gateway from a browser")
+	gatewayCmd.Flags().BoolVar(&gatewayKeepPrefix, "keep-prefix", false, "Forward the path with the service name in front")
+}
+
+// gatewayRoute routes the requests under /<Name> to a service
+type gatewayRoute struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	// Healthy is whether the service answered its health endpoint
+	Healthy bool `json:"healthy"`
+
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+}
+
+func runGateway(cmd *cobra.Command, args []string) error {
+	specs := gatewayServices
+	if len(specs) == 0 {
+		found, err := workspaceGatewayServices()
+		if err != nil {
+			return fmt.Errorf("%w; give the services with --services name:port", err)
+		}
+		specs = found
+	}
+	headers, err := parseGatewayHeaders(gatewayHeaders, gatewayAuthToken)
+	if err != nil {
+		return err
+	}
+
+	routes := make(map[string]*gatewayRoute)
+	for _, spec := range specs {
+		route, err := parseGatewayRoute(spec)
+		if err != nil {
+			return err
+		}
+		if _, ok := routes[route.Name]; ok {
+			return fmt.Errorf("service %s is given twice", route.Name)
+		}
+		route.proxy = newGatewayProxy(route, headers)
+		routes[route.Name] = route
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(gatewayHost, strconv.Itoa(gatewayPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s:%d: %w", gatewayHost, gatewayPort, err)
+	}
+	server := &http.Server{Handler: gatewayHandler(routes), ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("✓ Gateway listening on http://%s\n", listener.Addr())
+	for _, name := range sortedKeys(routes) {
+		fmt.Printf("  /%-12s → %s\n", name, routes[name].Target)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("Stopping...")
+	return nil
+}
+
+// parseGatewayRoute parses a service given as name:port or name:URL
+func parseGatewayRoute(spec string) (*gatewayRoute, error) {
+	name, address, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok || name == "" || address == "" {
+		return nil, fmt.Errorf("invalid service %q: expected name:port or name:URL", spec)
+	}
+	if strings.ContainsAny(name, "/ ") || name == "_gateway" {
+		return nil, fmt.Errorf("invalid service name %q", name)
+	}
+	if port, err := strconv.Atoi(address); err == nil {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %d of service %s", port, name)
+		}
+		address = fmt.Sprintf("http://localhost:%d", port)
+	}
+	target, err := url.Parse(address)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid address %q of service %s: expected a port or an http(s) URL", address, name)
+	}
+	return &gatewayRoute{Name: name, Target: target.String(), target: target}, nil
+}
+
+// parseGatewayHeaders parses the headers added to the proxied requests
+func parseGatewayHeaders(specs []string, token string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --header %q: expected \"Name: value\"", spec)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+	return headers, nil
+}
+
+// workspaceGatewayServices returns the services of the go.work of the current directory, on
+// the port of their configuration
+func workspaceGatewayServices() ([]string, error) {
+	dirs, err := workspaceServices()
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, errors.New("go.work lists no services")
+	}
+	var specs []string
+	for _, dir := range dirs {
+		port := 8080
+		if content, err := os.ReadFile(filepath.Join(dir, "configs", "config.yaml")); err == nil {
+			var config struct {
+				Service struct {
+					Port int `yaml:"port"`
+				} `yaml:"service"`
+			}
+			if yaml.Unmarshal(content, &config) == nil && config.Service.Port != 0 {
+				port = config.Service.Port
+			}
+		}
+		specs = append(specs, fmt.Sprintf("%s:%d", filepath.Base(dir), port))
+	}
+	return specs, nil
+}
+
+// newGatewayProxy returns the reverse proxy of a route, which forwards the path under the
+// service name and adds the shared headers
+func newGatewayProxy(route *gatewayRoute, headers http.Header) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(route.target)
+	director := proxy.Director
+	proxy.Director = func(request *http.Request) {
+		if !gatewayKeepPrefix {
+			request.URL.Path = strings.TrimPrefix(request.URL.Path, "/"+route.Name)
+			request.URL.RawPath = ""
+			if request.URL.Path == "" {
+				request.URL.Path = "/"
+			}
+		}
+		director(request)
+		request.Host = route.target.Host
+		for name, values := range headers {
+			request.Header[name] = values
+		}
+	}
+	// The gateway answers CORS; the headers of the services would repeat them
+	proxy.ModifyResponse = func(response *http.Response) error {
+		for name := range response.Header {
+			if strings.HasPrefix(name, "Access-Control-") {
+				response.Header.Del(name)
+			}
+		}
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, request *http.Request, err error) {
+		writeGatewayError(w, http.StatusBadGateway, fmt.Sprintf("service %s (%s) is not reachable: %v", route.Name, route.Target, err))
+	}
+	return proxy
+}
+
+// gatewayHandler routes the requests to the services, answering CORS and logging them
+func gatewayHandler(routes map[string]*gatewayRoute) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		started := time.Now()
+		if origin := request.Header.Get("Origin"); origin != "" && gatewayAllowsOrigin(origin) {
+			if containsString(gatewayCORSOrigins, "*") {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Add("Vary", "Origin")
+			}
+			if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+				if requested := request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					w.Header().Set("Access-Control-Allow-Headers", requested)
+				}
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		name := strings.SplitN(strings.TrimPrefix(request.URL.Path, "/"), "/", 2)[0]
+		if name == "_gateway" {
+			serveGatewayRoutes(w, routes)
+			return
+		}
+		route, ok := routes[name]
+		if !ok {
+			writeGatewayError(w, http.StatusNotFound, fmt.Sprintf("no service routed at /%s; the services are %s", name, strings.Join(sortedKeys(routes), ", ")))
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		route.proxy.ServeHTTP(recorder, request)
+		fmt.Printf("%s %s %s → %s %d %s\n", started.Format("15:04:05"), request.Method, request.URL.RequestURI(), route.Name,
+			recorder.status, time.Since(started).Round(time.Millisecond))
+	})
+}
+
+// gatewayAllowsOrigin reports whether a browser origin may call the gateway
+func gatewayAllowsOrigin(origin string) bool {
+	for _, allowed := range gatewayCORSOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveGatewayRoutes lists the routes, checking the health endpoint of each service
+func serveGatewayRoutes(w http.ResponseWriter, routes map[string]*gatewayRoute) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	var listed []gatewayRoute
+	for _, name := range sortedKeys(routes) {
+		route := *routes[name]
+		if response, err := client.Get(strings.TrimSuffix(route.Target, "/") + "/health"); err == nil {
+			response.Body.Close()
+			route.Healthy = response.StatusCode < 400
+		}
+		listed = append(listed, route)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"routes": listed})
+}
+
+// writeGatewayError answers with an error of the gateway itself
+func writeGatewayError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// statusRecorder records the status of a response, for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets the proxy flush streamed responses and take over upgraded connections, such as
+// WebSockets, through the recorder
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -3,9 +3,10 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/anasamu/go-micro-framework/internal/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator"
 	"github.com/spf13/cobra"
 )
 
@@ -21,34 +22,56 @@ var (
 	graphqlMutations     []string
 	graphqlSubscriptions []string
 	forceGenerate        bool
+	generateTemplatePack string
 )
 
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
 	Use:   "generate <type>",
-	Short: "Generate protobuf files or GraphQL schemas",
-	Long: `Generate protobuf files for gRPC services or GraphQL schemas for GraphQL services.
+	Short: "Generate protobuf files, GraphQL schemas, messaging code or infrastructure programs",
+	Long: `Generate protobuf files for gRPC services, GraphQL schemas for GraphQL services, the
+messaging code of an AsyncAPI document, or the Pulumi program of the infrastructure.
 
 This command supports:
 - protobuf: Generate .proto files for gRPC services
-- graphql: Generate GraphQL schema files
+- graphql: Generate GraphQL schema files, or with --from-sdl the Go types, server and
+  resolver stubs of an existing SDL schema
 - service: Generate both protobuf and GraphQL for a service
+- from-asyncapi: Generate with --spec the message types, producers and handler stubs of an
+  AsyncAPI 2 or 3 document into internal/events. With --serialization avro or protobuf, the
+  messages are encoded with their schemas, generated into api/events and registered in a
+  schema registry (microframework events register); the Kafka topics of the channels are
+  generated into deployments/kafka/topics.yaml from their kafka bindings
+- pulumi: Generate with --lang go the Pulumi program provisioning the infrastructure of the
+  project in deployments/pulumi: its deployment and service in the cluster, and the database,
+  cache and queues of its configuration, with a stack per Kubernetes environment
 
 Examples:
   microframework generate protobuf --service-name=user-service --grpc-services=UserService,AuthService
   microframework generate graphql --service-name=user-service --graphql-types=User,Profile --graphql-queries=getUser,getUsers
-  microframework generate service --service-name=user-service --grpc-services=UserService --graphql-types=User,Profile`,
-	Args: cobra.ExactArgs(1),
-	RunE: runGenerate,
+  microframework generate graphql --service-name=user-service --from-sdl schema.graphqls
+  microframework generate service --service-name=user-service --grpc-services=UserService --graphql-types=User,Profile
+  microframework generate from-asyncapi --service-name=user-service --spec asyncapi.yaml
+  microframework generate from-asyncapi --service-name=user-service --spec asyncapi.yaml --serialization avro
+  microframework generate pulumi --lang go`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runGenerate,
+	Annotations: map[string]string{outputDirectoryAnnotation: "true"},
+}
+
+// generateResult is the result of generate in the JSON report
+type generateResult struct {
+	Service string `json:"service"`
+	Type    string `json:"type"`
 }
 
 func init() {
 	// Generate type
-	generateCmd.Flags().StringVarP(&generateType, "type", "t", "", "Type to generate (protobuf, graphql, service)")
+	generateCmd.Flags().StringVarP(&generateType, "type", "t", "", "Type to generate (protobuf, graphql, service, from-asyncapi, pulumi)")
 
 	// Service configuration
 	generateCmd.Flags().StringVar(&serviceName, "service-name", "", "Name of the service")
-	generateCmd.Flags().StringVarP(&outputPath, "output", "o", ".", "Output directory for generated files")
+	generateCmd.Flags().StringVar(&outputPath, "dir", ".", "Directory the files are generated in (-o is a deprecated alias)")
 	generateCmd.Flags().StringVar(&protobufPackage, "protobuf-package", "", "Protobuf package name")
 	generateCmd.Flags().StringVar(&graphqlSchema, "graphql-schema", "", "GraphQL schema name")
 
@@ -60,9 +83,20 @@ func init() {
 	generateCmd.Flags().StringSliceVar(&graphqlQueries, "graphql-queries", []string{}, "GraphQL query names (comma-separated)")
 	generateCmd.Flags().StringSliceVar(&graphqlMutations, "graphql-mutations", []string{}, "GraphQL mutation names (comma-separated)")
 	generateCmd.Flags().StringSliceVar(&graphqlSubscriptions, "graphql-subscriptions", []string{}, "GraphQL subscription names (comma-separated)")
+	generateCmd.Flags().StringVar(&generateFromSDL, "from-sdl", "", "Generate the Go types, server and resolver stubs of an SDL schema file (graphql)")
+
+	// AsyncAPI configuration
+	generateCmd.Flags().StringVar(&generateSpec, "spec", "", "AsyncAPI document to generate the messaging code of (from-asyncapi)")
+	generateCmd.Flags().StringVar(&generateSerialization, "serialization", "json", "Encoding of the messages, avro and protobuf with a schema registry (from-asyncapi: json, avro, protobuf)")
+	generateCmd.RegisterFlagCompletionFunc("serialization", cobra.FixedCompletions(generator.Serializations, cobra.ShellCompDirectiveNoFileComp))
+
+	// Pulumi configuration
+	generateCmd.Flags().StringVar(&generateLang, "lang", "go", "Language of the program (pulumi: go)")
 
 	// Options
 	generateCmd.Flags().BoolVar(&forceGenerate, "force", false, "Overwrite existing files")
+	generateCmd.Flags().StringVar(&generateTemplatePack, "template-pack", "", "Template pack to generate from, <name>[@<version>] (microframework templates list)")
+	generateCmd.RegisterFlagCompletionFunc("template-pack", completeTemplatePacks)
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -70,12 +104,41 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 
 	// Validate generate type
 	if err := validateGenerateType(generateType); err != nil {
-		return fmt.Errorf("invalid generate type: %w", err)
+		return &UserError{fmt.Errorf("invalid generate type: %w", err)}
+	}
+
+	// The Pulumi program is that of the project in the current directory, named after it
+	if serviceName == "" && generateType == "pulumi" {
+		serviceName = projectServiceName()
 	}
 
 	// Validate service name
 	if serviceName == "" {
-		return fmt.Errorf("service name is required")
+		return &UserError{fmt.Errorf("service name is required")}
+	}
+
+	if generateFromSDL != "" {
+		if generateType != "graphql" {
+			return &UserError{fmt.Errorf("--from-sdl generates a graphql schema, not %s", generateType)}
+		}
+		if len(graphqlTypes)+len(graphqlQueries)+len(graphqlMutations)+len(graphqlSubscriptions) > 0 {
+			return &UserError{fmt.Errorf("--from-sdl cannot be combined with --graphql-types, --graphql-queries, --graphql-mutations or --graphql-subscriptions")}
+		}
+	}
+
+	switch {
+	case generateType == "from-asyncapi" && generateSpec == "":
+		return &UserError{fmt.Errorf("from-asyncapi requires the AsyncAPI document: --spec asyncapi.yaml")}
+	case generateType != "from-asyncapi" && generateSpec != "":
+		return &UserError{fmt.Errorf("--spec generates from-asyncapi, not %s", generateType)}
+	case generateType != "from-asyncapi" && cmd.Flags().Changed("serialization"):
+		return &UserError{fmt.Errorf("--serialization encodes the messages of from-asyncapi, not of %s", generateType)}
+	case !containsString(generator.Serializations, generateSerialization):
+		return &UserError{fmt.Errorf("invalid serialization %q: one of %s", generateSerialization, strings.Join(generator.Serializations, ", "))}
+	case generateType == "pulumi" && generateLang != "go":
+		return &UserError{fmt.Errorf("pulumi programs are generated in go, not %s", generateLang)}
+	case generateType != "pulumi" && cmd.Flags().Changed("lang"):
+		return &UserError{fmt.Errorf("--lang is the language of the pulumi program, not of %s", generateType)}
 	}
 
 	// Create output directory if it doesn't exist
@@ -83,6 +146,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	reportResult(generateResult{Service: serviceName, Type: generateType})
 	switch generateType {
 	case "protobuf":
 		return generateProtobuf()
@@ -90,14 +154,18 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return generateGraphQL()
 	case "service":
 		return generateService()
+	case "from-asyncapi":
+		return generateAsyncAPI()
+	case "pulumi":
+		return generatePulumi()
 	default:
-		return fmt.Errorf("unsupported generate type: %s", generateType)
+		return &UserError{fmt.Errorf("unsupported generate type: %s", generateType)}
 	}
 }
 
 // validateGenerateType validates the generate type
 func validateGenerateType(generateType string) error {
-	validTypes := []string{"protobuf", "graphql", "service"}
+	validTypes := []string{"protobuf", "graphql", "service", "from-asyncapi", "pulumi"}
 	for _, validType := range validTypes {
 		if generateType == validType {
 			return nil
@@ -125,11 +193,21 @@ func generateProtobuf() error {
 	}
 
 	// Create protobuf generator
-	protobufGenerator := generator.NewProtobufGenerator(config)
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, _, err := templatePackOptions(generateTemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: reportGeneratedFiles}))
+	protobufGenerator := generator.NewProtobufGenerator(config, opts...)
 
 	// Generate protobuf files
 	if err := protobufGenerator.GenerateProtobuf(); err != nil {
-		return fmt.Errorf("failed to generate protobuf files: %w", err)
+		return &GenerationError{fmt.Errorf("failed to generate protobuf files: %w", err)}
 	}
 
 	fmt.Printf("✓ Protobuf files generated successfully!\n")
@@ -161,19 +239,53 @@ func generateGraphQL() error {
 		OutputPath:    outputPath,
 		ForceGenerate: forceGenerate,
 	}
+	if generateFromSDL != "" {
+		sdl, err := importSDL(generateFromSDL)
+		if err != nil {
+			return err
+		}
+		config.SDL = sdl
+	}
+	// The resolvers are implemented in the generated stubs, which are kept once written
+	resolversPath := filepath.Join(outputPath, "graphql", graphqlSchema+"_resolvers.go")
+	_, statErr := os.Stat(resolversPath)
+	keptResolvers := config.SDL != nil && statErr == nil && !forceGenerate
 
 	// Create GraphQL generator
-	graphqlGenerator := generator.NewGraphQLGenerator(config)
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, _, err := templatePackOptions(generateTemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: reportGeneratedFiles}))
+	graphqlGenerator := generator.NewGraphQLGenerator(config, opts...)
 
 	// Generate GraphQL schema
 	if err := graphqlGenerator.GenerateGraphQL(); err != nil {
-		return fmt.Errorf("failed to generate GraphQL schema: %w", err)
+		return &GenerationError{fmt.Errorf("failed to generate GraphQL schema: %w", err)}
 	}
 
 	fmt.Printf("✓ GraphQL schema generated successfully!\n")
 	fmt.Printf("Generated files:\n")
 	fmt.Printf("  - %s.graphql\n", graphqlSchema)
-	fmt.Printf("  - %s_schema.go\n", graphqlSchema)
+	if config.SDL == nil {
+		fmt.Printf("  - %s_schema.go\n", graphqlSchema)
+		return nil
+	}
+	fmt.Printf("  - %s_types.go\n", graphqlSchema)
+	fmt.Printf("  - %s_server.go\n", graphqlSchema)
+	if keptResolvers {
+		fmt.Printf("Kept %s, whose resolvers are implemented; go build lists the methods the schema adds or changes\n", resolversPath)
+		return nil
+	}
+	fmt.Printf("  - %s_resolvers.go\n", graphqlSchema)
+	fmt.Println("\nNext steps:")
+	fmt.Printf("1. Implement the resolvers in %s\n", resolversPath)
+	fmt.Println("2. Serve the schema: handler, err := graphql.NewHandler(&graphql.Resolver{})")
 
 	return nil
 }
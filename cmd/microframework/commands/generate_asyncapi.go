@@ -0,0 +1,604 @@
+package commands
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// generateSpec is the AsyncAPI document generate from-asyncapi generates the messaging
+	// code of
+	generateSpec string
+	// generateSerialization is how the messages are encoded: json, avro or protobuf
+	generateSerialization string
+)
+
+// addressParameter matches the {parameters} of a channel address
+var addressParameter = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// asyncAPIImport reads the messages of an AsyncAPI document into an AsyncAPISpec
+type asyncAPIImport struct {
+	api  *mockAPI
+	path string
+	spec *generator.AsyncAPISpec
+	// types are the sources of the Go types, by name: the $ref of their schema, or the
+	// message or property whose inline schema they are
+	types map[string]string
+	// methods are the operations whose methods were declared, by producer or handler method
+	methods map[string]string
+}
+
+// asyncAPIOperation is an operation of an AsyncAPI 2 or 3 document: the service sends or
+// receives the messages on the channel at address
+type asyncAPIOperation struct {
+	id, description string
+	send            bool
+	address         string
+	// messages are the messages of the operation, by name
+	messages []asyncAPIMessage
+}
+
+// asyncAPIMessage is a message of an operation, and the name it is sent with
+type asyncAPIMessage struct {
+	name    string
+	message map[string]interface{}
+}
+
+// importAsyncAPI derives the messages the service sends and receives from the AsyncAPI 2 or
+// 3 document at path. Messages whose payload is no object are skipped with a warning, as are
+// the channels with parameters the service receives from. The Kafka topics of the channels
+// are derived for the documents of Kafka servers or bindings, and for all of them with kafka.
+func importAsyncAPI(path string, kafka bool) (*generator.AsyncAPISpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &UserError{err}
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, &UserError{fmt.Errorf("invalid %s: %w", path, err)}
+	}
+	version, _ := document["asyncapi"].(string)
+	var operations []asyncAPIOperation
+	imported := &asyncAPIImport{
+		api:     &mockAPI{document: document},
+		path:    path,
+		spec:    &generator.AsyncAPISpec{Source: filepath.ToSlash(path)},
+		types:   make(map[string]string),
+		methods: make(map[string]string),
+	}
+	switch {
+	case strings.HasPrefix(version, "2."):
+		operations = imported.operationsV2()
+	case strings.HasPrefix(version, "3."):
+		operations = imported.operationsV3()
+	default:
+		return nil, &UserError{fmt.Errorf("%s is no AsyncAPI 2 or 3 document: its asyncapi field is %q", path, version)}
+	}
+
+	channels := make(map[string]int)
+	for _, operation := range operations {
+		if err := imported.operation(operation, channels); err != nil {
+			return nil, &UserError{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+	if len(imported.spec.Producers)+len(imported.spec.Channels) == 0 {
+		return nil, &UserError{fmt.Errorf("%s: the document has no message to send or receive", path)}
+	}
+	if kafka || imported.kafka() {
+		if err := imported.topics(); err != nil {
+			return nil, &UserError{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+	return imported.spec, nil
+}
+
+// kafkaProtocols are the protocols of the servers of Kafka documents
+var kafkaProtocols = map[string]bool{"kafka": true, "kafka-secure": true}
+
+// defaultTopicPartitions and defaultTopicReplicas are those of the topics whose channels have
+// no kafka binding, and defaultTopicRetention the retention.ms of those without
+// topicConfiguration: 7 days, Kafka's default
+const (
+	defaultTopicPartitions = 3
+	defaultTopicReplicas   = 3
+	defaultTopicRetention  = "604800000"
+)
+
+// kafka reports whether the document is that of Kafka servers or channels
+func (i *asyncAPIImport) kafka() bool {
+	servers := asMap(i.api.document["servers"])
+	for _, name := range sortedKeys(servers) {
+		protocol, _ := i.api.resolve(asMap(servers[name]))["protocol"].(string)
+		if kafkaProtocols[strings.ToLower(protocol)] {
+			return true
+		}
+	}
+	for _, binding := range i.channelBindings() {
+		if binding != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// channelBindings returns the kafka bindings of the channels, by address; nil for the
+// channels without
+func (i *asyncAPIImport) channelBindings() map[string]map[string]interface{} {
+	bindings := make(map[string]map[string]interface{})
+	channels := asMap(i.api.document["channels"])
+	for _, key := range sortedKeys(channels) {
+		channel := i.api.resolve(asMap(channels[key]))
+		address := key
+		// The channels of AsyncAPI 3 are named, with their address in a field
+		if a, ok := channel["address"].(string); ok && a != "" && !strings.HasPrefix(i.version(), "2.") {
+			address = a
+		}
+		bindings[address] = i.api.resolve(asMap(i.api.resolve(asMap(channel["bindings"]))["kafka"]))
+	}
+	return bindings
+}
+
+// version returns the AsyncAPI version of the document
+func (i *asyncAPIImport) version() string {
+	version, _ := i.api.document["asyncapi"].(string)
+	return version
+}
+
+// topics adds the Kafka topics of the channels without parameters of the spec, with the
+// partitions, replicas and topicConfiguration of their kafka bindings; a binding's topic
+// names the topic of its channel
+func (i *asyncAPIImport) topics() error {
+	addresses := make(map[string]bool)
+	for _, producer := range i.spec.Producers {
+		addresses[producer.Address] = len(producer.Parameters) == 0
+	}
+	for _, channel := range i.spec.Channels {
+		addresses[channel.Address] = true
+	}
+	bindings := i.channelBindings()
+	resources := make(map[string]string)
+	for _, address := range sortedKeys(addresses) {
+		if !addresses[address] {
+			continue
+		}
+		binding := bindings[address]
+		topic := generator.KafkaTopic{Name: address, Partitions: defaultTopicPartitions, Replicas: defaultTopicReplicas}
+		if name, ok := binding["topic"].(string); ok && name != "" {
+			topic.Name = name
+		}
+		var ok bool
+		if topic.Partitions, ok = bindingCount(binding, "partitions", defaultTopicPartitions); !ok {
+			return fmt.Errorf("channel %s: the partitions of its kafka binding are no positive integer", address)
+		}
+		if topic.Replicas, ok = bindingCount(binding, "replicas", defaultTopicReplicas); !ok {
+			return fmt.Errorf("channel %s: the replicas of its kafka binding are no positive integer", address)
+		}
+		configuration := asMap(binding["topicConfiguration"])
+		for _, key := range sortedKeys(configuration) {
+			value := configuration[key]
+			if values, ok := value.([]interface{}); ok {
+				// cleanup.policy lists its policies
+				var items []string
+				for _, item := range values {
+					items = append(items, fmt.Sprint(item))
+				}
+				value = strings.Join(items, ",")
+			}
+			topic.Config = append(topic.Config, generator.TopicSetting{Key: key, Value: fmt.Sprint(value)})
+		}
+		if len(topic.Config) == 0 {
+			topic.Config = []generator.TopicSetting{{Key: "retention.ms", Value: defaultTopicRetention}}
+		}
+		topic.Resource = topicResourceName(topic.Name)
+		if topic.Resource == "" {
+			return fmt.Errorf("channel %s: topic %s has no Kubernetes resource name", address, topic.Name)
+		}
+		if other, ok := resources[topic.Resource]; ok && other != topic.Name {
+			return fmt.Errorf("topics %s and %s are both the resource %s", other, topic.Name, topic.Resource)
+		}
+		if _, ok := resources[topic.Resource]; ok {
+			continue
+		}
+		resources[topic.Resource] = topic.Name
+		i.spec.Topics = append(i.spec.Topics, topic)
+	}
+	sort.Slice(i.spec.Topics, func(a, b int) bool { return i.spec.Topics[a].Name < i.spec.Topics[b].Name })
+	return nil
+}
+
+// bindingCount returns a count of a kafka binding, or fallback when it has none; false when
+// it is no positive integer
+func bindingCount(binding map[string]interface{}, key string, fallback int) (int, bool) {
+	value, ok := binding[key]
+	if !ok {
+		return fallback, true
+	}
+	count, ok := value.(int)
+	return count, ok && count > 0
+}
+
+// invalidResourceRune matches what the names of Kubernetes resources cannot hold
+var invalidResourceRune = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// topicResourceName returns the name of the KafkaTopic resource of a topic: its name as a
+// DNS-1123 subdomain, the topic name itself being in spec.topicName
+func topicResourceName(topic string) string {
+	name := invalidResourceRune.ReplaceAllString(strings.ToLower(topic), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, ".-")
+}
+
+// operationsV2 returns the operations of the channels of an AsyncAPI 2 document, where
+// publish is what the service receives and subscribe what it sends
+func (i *asyncAPIImport) operationsV2() []asyncAPIOperation {
+	var operations []asyncAPIOperation
+	channels := asMap(i.api.document["channels"])
+	for _, address := range sortedKeys(channels) {
+		channel := i.api.resolve(asMap(channels[address]))
+		for _, action := range []string{"subscribe", "publish"} {
+			operation := i.api.resolve(asMap(channel[action]))
+			if operation == nil {
+				continue
+			}
+			id, _ := operation["operationId"].(string)
+			description, _ := operation["summary"].(string)
+			var messages []asyncAPIMessage
+			message := asMap(operation["message"])
+			if oneOf := mapSlice(i.api.resolve(message)["oneOf"]); len(oneOf) > 0 {
+				for _, message := range oneOf {
+					messages = append(messages, i.message("", message))
+				}
+			} else if message != nil {
+				messages = append(messages, i.message("", message))
+			}
+			operations = append(operations, asyncAPIOperation{
+				id:          id,
+				description: description,
+				send:        action == "subscribe",
+				address:     address,
+				messages:    messages,
+			})
+		}
+	}
+	return operations
+}
+
+// operationsV3 returns the send and receive operations of an AsyncAPI 3 document, with the
+// messages they list or else all the messages of their channel
+func (i *asyncAPIImport) operationsV3() []asyncAPIOperation {
+	var operations []asyncAPIOperation
+	all := asMap(i.api.document["operations"])
+	for _, id := range sortedKeys(all) {
+		operation := i.api.resolve(asMap(all[id]))
+		action, _ := operation["action"].(string)
+		if action != "send" && action != "receive" {
+			warnf("%s: operation %s: unknown action %q, skipped", i.path, id, action)
+			continue
+		}
+		channelRef := asMap(operation["channel"])
+		channel := i.api.resolve(channelRef)
+		address, _ := channel["address"].(string)
+		if address == "" {
+			// A channel without address is addressed by its name
+			ref, _ := channelRef["$ref"].(string)
+			address = path.Base(ref)
+		}
+		description, _ := operation["summary"].(string)
+		var messages []asyncAPIMessage
+		if refs := mapSlice(operation["messages"]); len(refs) > 0 {
+			for _, ref := range refs {
+				messages = append(messages, i.message("", ref))
+			}
+		} else {
+			channelMessages := asMap(channel["messages"])
+			for _, name := range sortedKeys(channelMessages) {
+				messages = append(messages, i.message(name, asMap(channelMessages[name])))
+			}
+		}
+		operations = append(operations, asyncAPIOperation{
+			id:          id,
+			description: description,
+			send:        action == "send",
+			address:     address,
+			messages:    messages,
+		})
+	}
+	return operations
+}
+
+// message resolves a message of an operation, named by its name field, else by key or by
+// the last segment of its $ref
+func (i *asyncAPIImport) message(key string, message map[string]interface{}) asyncAPIMessage {
+	if ref, ok := message["$ref"].(string); ok && key == "" {
+		key = path.Base(ref)
+	}
+	message = i.api.resolve(message)
+	if name, ok := message["name"].(string); ok && name != "" {
+		key = name
+	}
+	return asyncAPIMessage{name: key, message: message}
+}
+
+// operation adds the producers or handlers of the messages of an operation to the spec.
+// channels are the indexes of the channels of the spec, by address.
+func (i *asyncAPIImport) operation(operation asyncAPIOperation, channels map[string]int) error {
+	var parameters []generator.AsyncAPIParameter
+	for _, match := range addressParameter.FindAllStringSubmatch(operation.address, -1) {
+		goName := pascalName(match[1])
+		if goName == "" {
+			return fmt.Errorf("channel %s: parameter %s has no Go name", operation.address, match[1])
+		}
+		goName = strings.ToLower(goName[:1]) + goName[1:]
+		if token.IsKeyword(goName) || goName == "ctx" || goName == "message" || goName == "p" {
+			goName += "Param"
+		}
+		parameters = append(parameters, generator.AsyncAPIParameter{Name: match[1], GoName: goName})
+	}
+	if !operation.send && len(parameters) > 0 {
+		warnf("%s: channel %s has parameters; subscribe to it by hand, it is skipped", i.path, operation.address)
+		return nil
+	}
+
+	for _, message := range operation.messages {
+		if message.name == "" {
+			return fmt.Errorf("channel %s: a message has no name", operation.address)
+		}
+		payload := asMap(message.message["payload"])
+		key := "message " + message.name
+		name := pascalName(message.name)
+		if ref := schemaRef(payload); ref != "" {
+			key, name = ref, pascalName(path.Base(ref))
+		}
+		payload = i.api.resolve(payload)
+		if payload["properties"] == nil && schemaType(payload) != "object" {
+			warnf("%s: channel %s: the payload of message %s is no object, skipped", i.path, operation.address, message.name)
+			continue
+		}
+		goType, err := i.messageType(key, name, payload)
+		if err != nil {
+			return err
+		}
+
+		method := pascalName(operation.id)
+		switch {
+		case method == "" && operation.send:
+			method = "Publish" + pascalName(message.name)
+		case method == "":
+			method = "Handle" + pascalName(message.name)
+		case len(operation.messages) > 1:
+			method += pascalName(message.name)
+		}
+		if declared, ok := i.methods[method]; ok {
+			return fmt.Errorf("the methods of %s and %s are both named %s", declared, operation.address, method)
+		}
+		i.methods[method] = operation.address
+
+		produced := generator.AsyncAPIOperation{
+			Method:      method,
+			Description: oneLine(operation.description),
+			Address:     operation.address,
+			Message:     goType,
+			MessageName: message.name,
+			Parameters:  parameters,
+		}
+		if operation.send {
+			i.spec.Producers = append(i.spec.Producers, produced)
+			continue
+		}
+		index, ok := channels[operation.address]
+		if !ok {
+			index = len(i.spec.Channels)
+			channels[operation.address] = index
+			i.spec.Channels = append(i.spec.Channels, generator.AsyncAPIChannel{Address: operation.address})
+		}
+		channel := &i.spec.Channels[index]
+		for _, handler := range channel.Handlers {
+			if handler.MessageName == message.name {
+				return fmt.Errorf("channel %s: message %s is received by two operations", operation.address, message.name)
+			}
+		}
+		channel.Handlers = append(channel.Handlers, produced)
+	}
+	return nil
+}
+
+// messageType declares the struct of an object schema, and those of the objects of its
+// properties, and returns its name. key identifies the schema, for the types declared twice.
+func (i *asyncAPIImport) messageType(key, name string, schema map[string]interface{}) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%s has no Go name", key)
+	}
+	if declared, ok := i.types[name]; ok {
+		if declared != key {
+			return "", fmt.Errorf("%s and %s are both named %s", declared, key, name)
+		}
+		return name, nil
+	}
+	i.types[name] = key
+
+	description, _ := schema["description"].(string)
+	messageType := generator.MessageType{Name: name, Description: oneLine(description)}
+	required := make(map[string]bool)
+	for _, property := range stringList(schema["required"]) {
+		required[property] = true
+	}
+	properties := asMap(schema["properties"])
+	fields := make(map[string]string)
+	for _, property := range sortedKeys(properties) {
+		goName := pascalName(property)
+		if goName == "" {
+			return "", fmt.Errorf("%s: property %s has no Go name", key, property)
+		}
+		if other, ok := fields[goName]; ok {
+			return "", fmt.Errorf("%s: properties %s and %s are both named %s", key, other, property, goName)
+		}
+		fields[goName] = property
+
+		propertySchema := asMap(properties[property])
+		goType, err := i.fieldType(key+"/"+property, name+goName, propertySchema)
+		if err != nil {
+			return "", err
+		}
+		if !required[property] && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") &&
+			!strings.HasPrefix(goType, "*") && goType != "interface{}" {
+			goType = "*" + goType
+		}
+		// The description of a referenced schema is that of its type
+		description, _ := propertySchema["description"].(string)
+		messageType.Fields = append(messageType.Fields, generator.MessageField{
+			Name:        property,
+			GoName:      goName,
+			GoType:      goType,
+			Description: oneLine(description),
+			Required:    required[property],
+		})
+	}
+	i.spec.Types = append(i.spec.Types, messageType)
+	return name, nil
+}
+
+// fieldType returns the Go type of a property schema. The objects with properties are
+// structs, named after the schema they reference or else after the property.
+func (i *asyncAPIImport) fieldType(key, name string, schema map[string]interface{}) (string, error) {
+	if ref := schemaRef(schema); ref != "" {
+		key, name = ref, pascalName(path.Base(ref))
+	}
+	schema = i.api.resolve(schema)
+	format, _ := schema["format"].(string)
+	switch schemaType(schema) {
+	case "integer":
+		if format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "string":
+		if format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "array":
+		items, err := i.fieldType(key+"/items", name+"Item", asMap(schema["items"]))
+		return "[]" + strings.TrimPrefix(items, "*"), err
+	}
+	if schema["properties"] != nil {
+		goType, err := i.messageType(key, name, schema)
+		return "*" + goType, err
+	}
+	if schemaType(schema) == "object" {
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+// oneLine joins the lines of a description
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// generateAsyncAPI generates the message types, producers and handlers of an AsyncAPI
+// document
+func generateAsyncAPI() error {
+	fmt.Printf("Generating messaging code for service: %s\n", serviceName)
+
+	// The schemas of the registry are those of Kafka, whose topics are generated with them
+	spec, err := importAsyncAPI(generateSpec, generateSerialization != "json")
+	if err != nil {
+		return err
+	}
+	config := &generator.AsyncAPIConfig{
+		ServiceName:   serviceName,
+		Spec:          spec,
+		Serialization: generateSerialization,
+		OutputPath:    outputPath,
+		ForceGenerate: forceGenerate,
+	}
+	// The handlers are implemented in the generated stubs, which are kept once written
+	handlersPath := filepath.Join(outputPath, "internal", "events", "handlers.go")
+	_, statErr := os.Stat(handlersPath)
+	keptHandlers := len(spec.Channels) > 0 && statErr == nil && !forceGenerate
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, _, err := templatePackOptions(generateTemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: reportGeneratedFiles}))
+	if err := generator.NewAsyncAPIGenerator(config, opts...).GenerateAsyncAPI(); err != nil {
+		return &GenerationError{fmt.Errorf("failed to generate messaging code: %w", err)}
+	}
+
+	fmt.Printf("✓ Messaging code generated successfully!\n")
+	fmt.Printf("Generated files:\n")
+	fmt.Printf("  - internal/events/messages.go (%d types)\n", len(spec.Types))
+	fmt.Printf("  - internal/events/codec.go (%s)\n", generateSerialization)
+	if len(spec.Producers) > 0 {
+		fmt.Printf("  - internal/events/producers.go (%d producers)\n", len(spec.Producers))
+	}
+	if len(spec.Channels) > 0 {
+		fmt.Printf("  - internal/events/consumers.go (%d channels)\n", len(spec.Channels))
+		if keptHandlers {
+			fmt.Printf("Kept %s, whose handlers are implemented; go build lists the methods the document adds or changes\n", handlersPath)
+		} else {
+			fmt.Printf("  - internal/events/handlers.go\n")
+		}
+	}
+	if generateSerialization != "json" {
+		fmt.Printf("  - api/events (%s schemas, registry.yaml)\n", generateSerialization)
+	}
+	if len(spec.Topics) > 0 {
+		fmt.Printf("  - deployments/kafka/topics.yaml (%d topics)\n", len(spec.Topics))
+	}
+
+	// The codec is a parameter of the producer and of Subscribe with a schema registry
+	codec := ""
+	var steps []string
+	if generateSerialization != "json" {
+		codec = ", codec"
+		if generateSerialization == "avro" {
+			steps = append(steps, "Add the Avro library: go get github.com/hamba/avro/v2")
+		}
+		steps = append(steps,
+			"Create the codec: codec, err := events.NewRegistryCodec(events.RegistryConfigFrom(config[\"schema_registry\"]))",
+			"Register the schemas: microframework events register --registry $SCHEMA_REGISTRY_URL")
+	}
+	if len(spec.Channels) > 0 && !keptHandlers {
+		steps = append(steps,
+			fmt.Sprintf("Implement the handlers in %s", handlersPath),
+			fmt.Sprintf("Subscribe them: err := events.Subscribe(ctx, messagingManager, provider, &events.Handler{}%s)", codec))
+	}
+	if len(spec.Producers) > 0 {
+		steps = append(steps, fmt.Sprintf("Publish the messages: producer := events.NewProducer(messagingManager, provider%s)", codec))
+	}
+	if len(spec.Topics) > 0 {
+		steps = append(steps, "Create the topics: kubectl apply -f deployments/kafka/topics.yaml (Strimzi)")
+	}
+	if len(steps) > 0 {
+		fmt.Println("\nNext steps:")
+		for n, step := range steps {
+			fmt.Printf("%d. %s\n", n+1, step)
+		}
+	}
+	return nil
+}
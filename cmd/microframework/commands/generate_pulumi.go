@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// generateLang is the language of the program generate pulumi generates
+var generateLang string
+
+// pulumiServiceConfig is what generate pulumi maps from the configuration of an environment
+type pulumiServiceConfig struct {
+	version string
+	port    int
+}
+
+// generatePulumi generates the Pulumi program provisioning the infrastructure of the project
+// in the current directory, from its configuration and environments
+func generatePulumi() error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	fmt.Printf("Generating Pulumi program for service: %s\n", serviceName)
+
+	config := &generator.PulumiConfig{
+		ServiceName:   serviceName,
+		Language:      generateLang,
+		OutputPath:    outputPath,
+		ForceGenerate: forceGenerate,
+	}
+	if err := pulumiProviders(config); err != nil {
+		return err
+	}
+	stacks, err := pulumiStacks()
+	if err != nil {
+		return err
+	}
+	config.Stacks = stacks
+
+	// The stack files hold the secrets of the stacks, and are kept once written
+	pulumiDir := filepath.Join(outputPath, "deployments", "pulumi")
+	var kept []string
+	for _, stack := range stacks {
+		file := "Pulumi." + stack.Name + ".yaml"
+		if _, err := os.Stat(filepath.Join(pulumiDir, file)); err == nil && !forceGenerate {
+			kept = append(kept, file)
+		}
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, _, err := templatePackOptions(generateTemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: reportGeneratedFiles}))
+	if err := generator.NewPulumiGenerator(config, opts...).GeneratePulumi(); err != nil {
+		return &GenerationError{fmt.Errorf("failed to generate Pulumi program: %w", err)}
+	}
+
+	fmt.Printf("✓ Pulumi program generated successfully!\n")
+	fmt.Printf("Generated files in %s:\n", pulumiDir)
+	fmt.Printf("  - Pulumi.yaml, go.mod\n")
+	if releases := config.Releases(); len(releases) > 0 {
+		fmt.Printf("  - main.go (deployment, service, %s)\n", strings.Join(releases, ", "))
+	} else {
+		fmt.Printf("  - main.go (deployment, service)\n")
+	}
+	for _, stack := range stacks {
+		file := "Pulumi." + stack.Name + ".yaml"
+		if !containsString(kept, file) {
+			fmt.Printf("  - %s (%s, %d replicas, port %d)\n", file, stack.Image, stack.Replicas, stack.Port)
+		}
+	}
+	if len(kept) > 0 {
+		fmt.Printf("Kept %s, which hold the configuration and secrets of the stacks; --force writes them again\n", strings.Join(kept, ", "))
+	}
+
+	fmt.Println("\nNext steps:")
+	fmt.Printf("1. Download the dependencies: cd %s && go mod tidy\n", pulumiDir)
+	step := 2
+	if secrets := config.Secrets(); len(secrets) > 0 {
+		fmt.Printf("2. Set %s in every stack: pulumi config set --secret %s <value> --stack %s\n", strings.Join(secrets, ", "), secrets[0], stacks[0].Name)
+		step++
+	}
+	fmt.Printf("%d. Provision the stack: pulumi up --stack %s\n", step, stacks[0].Name)
+	return nil
+}
+
+// pulumiProviders sets the database, cache and queues of the program from the providers of
+// configs/config.yaml
+func pulumiProviders(config *generator.PulumiConfig) error {
+	content, err := os.ReadFile(projectConfigFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return &UserError{fmt.Errorf("invalid %s: %w", projectConfigFile, err)}
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	root := document.Content[0]
+
+	for _, provider := range mappingKeys(mappingValue(mappingValue(root, "database"), "providers")) {
+		switch provider {
+		case "postgresql", "mysql", "mongodb":
+			if config.Database != "" {
+				warnf("the program provisions one database, %s; %s is left out", config.Database, provider)
+				continue
+			}
+			config.Database = provider
+		case "redis":
+			config.Redis = true
+		default:
+			warnf("the program provisions no %s database", provider)
+		}
+	}
+
+	// The cache is at the top level of the configuration, or under optional
+	cache := mappingValue(root, "cache")
+	if cache == nil {
+		cache = mappingValue(mappingValue(root, "optional"), "cache")
+	}
+	config.Cache = mappingValue(mappingValue(cache, "providers"), "redis") != nil
+
+	for _, provider := range mappingKeys(mappingValue(mappingValue(root, "messaging"), "providers")) {
+		switch provider {
+		case "kafka", "rabbitmq", "nats":
+			config.Messaging = append(config.Messaging, provider)
+		default:
+			warnf("the program provisions no %s queues", provider)
+		}
+	}
+	return nil
+}
+
+// pulumiStacks returns the stacks of the program: one per kubernetes environment of
+// deployments/environments.yaml, or a dev stack of configs/config.yaml when it has none
+func pulumiStacks() ([]generator.PulumiStack, error) {
+	environments, err := loadEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	replicas := pulumiReplicas()
+
+	var stacks []generator.PulumiStack
+	for _, environment := range environments {
+		if environment.Target != TargetKubernetes {
+			continue
+		}
+		service, err := pulumiServiceSettings(environment.Config)
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, generator.PulumiStack{
+			Name:      environment.Name,
+			Context:   environment.Context,
+			Namespace: environment.Namespace,
+			Image:     pulumiImage(environment.Service, service.version),
+			Replicas:  replicas,
+			Port:      service.port,
+		})
+	}
+	if len(stacks) == 0 {
+		service, err := pulumiServiceSettings("")
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, generator.PulumiStack{
+			Name:     "dev",
+			Image:    pulumiImage(serviceName, service.version),
+			Replicas: replicas,
+			Port:     service.port,
+		})
+	}
+	return stacks, nil
+}
+
+// pulumiServiceSettings returns the version and port of the service in configs/config.yaml
+// and the overlay of an environment
+func pulumiServiceSettings(overlay string) (*pulumiServiceConfig, error) {
+	settings := &pulumiServiceConfig{version: "1.0.0", port: 8080}
+	paths, err := projectConfigPaths("", overlay)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var file struct {
+			Service struct {
+				Version string `yaml:"version"`
+				Port    string `yaml:"port"`
+			} `yaml:"service"`
+		}
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			return nil, &UserError{fmt.Errorf("invalid %s: %w", path, err)}
+		}
+		if file.Service.Version != "" {
+			settings.version = file.Service.Version
+		}
+		if port, err := strconv.Atoi(expandConfigValue(file.Service.Port).(string)); err == nil {
+			settings.port = port
+		}
+	}
+	return settings, nil
+}
+
+// pulumiReplicas returns the replicas of deployments/kubernetes/deployment.yaml, 1 without it
+func pulumiReplicas() int {
+	content, err := os.ReadFile(filepath.Join("deployments", "kubernetes", "deployment.yaml"))
+	if err != nil {
+		return 1
+	}
+	var deployment struct {
+		Spec struct {
+			Replicas int `yaml:"replicas"`
+		} `yaml:"spec"`
+	}
+	if yaml.Unmarshal(content, &deployment) != nil || deployment.Spec.Replicas < 1 {
+		return 1
+	}
+	return deployment.Spec.Replicas
+}
+
+// pulumiImage returns the image of the service at version, tagged as the Kubernetes
+// deployment of the project is
+func pulumiImage(service, version string) string {
+	return service + ":v" + strings.TrimPrefix(version, "v")
+}
+
+// mappingKeys returns the keys of the mapping node, in order
+func mappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// generateFromSDL is the SDL file generate graphql generates the service from
+var generateFromSDL string
+
+// graphQLBuiltinScalars are the scalars every GraphQL schema has
+var graphQLBuiltinScalars = map[string]bool{"String": true, "ID": true, "Int": true, "Float": true, "Boolean": true}
+
+// importSDL reads the types of a GraphQL schema written in SDL, checking that they reference
+// declared types of the right kinds. Type extensions are merged into their types; the
+// subscription type is left out, as the generated server serves queries and mutations.
+func importSDL(path string) (*generator.GraphQLSchema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &UserError{err}
+	}
+	document, err := parser.Parse(parser.ParseParams{Source: string(content)})
+	if err != nil {
+		return nil, &UserError{fmt.Errorf("failed to parse %s: %w", path, err)}
+	}
+
+	schema := &generator.GraphQLSchema{Source: path, SDL: content, Query: "Query", Mutation: "Mutation"}
+	subscription := "Subscription"
+	kinds := make(map[string]string)
+	declare := func(name *ast.Name, kind string) error {
+		if graphQLBuiltinScalars[name.Value] || strings.HasPrefix(name.Value, "__") {
+			return fmt.Errorf("%s: type %s is reserved", path, name.Value)
+		}
+		if _, ok := kinds[name.Value]; ok {
+			return fmt.Errorf("%s: type %s is declared twice", path, name.Value)
+		}
+		kinds[name.Value] = kind
+		return nil
+	}
+	objects := make(map[string]*ast.ObjectDefinition)
+	var extensions []*ast.ObjectDefinition
+	for _, definition := range document.Definitions {
+		var err error
+		switch definition := definition.(type) {
+		case *ast.SchemaDefinition:
+			schema.Mutation = ""
+			for _, operation := range definition.OperationTypes {
+				switch operation.Operation {
+				case "query":
+					schema.Query = operation.Type.Name.Value
+				case "mutation":
+					schema.Mutation = operation.Type.Name.Value
+				case "subscription":
+					subscription = operation.Type.Name.Value
+				}
+			}
+		case *ast.ScalarDefinition:
+			err = declare(definition.Name, "scalar")
+		case *ast.EnumDefinition:
+			err = declare(definition.Name, "enum")
+		case *ast.InterfaceDefinition:
+			err = declare(definition.Name, "interface")
+		case *ast.UnionDefinition:
+			err = declare(definition.Name, "union")
+		case *ast.InputObjectDefinition:
+			err = declare(definition.Name, "input")
+		case *ast.ObjectDefinition:
+			err = declare(definition.Name, "object")
+			objects[definition.Name.Value] = definition
+		case *ast.TypeExtensionDefinition:
+			extensions = append(extensions, definition.Definition)
+		case *ast.DirectiveDefinition:
+			// Directives are not executed by the generated server
+		default:
+			err = fmt.Errorf("%s: only type system definitions are allowed, found %s", path, definition.GetKind())
+		}
+		if err != nil {
+			return nil, &UserError{err}
+		}
+	}
+	for _, extension := range extensions {
+		object, ok := objects[extension.Name.Value]
+		if !ok {
+			return nil, &UserError{fmt.Errorf("%s: extend type %s: no type %s", path, extension.Name.Value, extension.Name.Value)}
+		}
+		object.Interfaces = append(object.Interfaces, extension.Interfaces...)
+		object.Fields = append(object.Fields, extension.Fields...)
+	}
+	if kinds[schema.Query] != "object" {
+		return nil, &UserError{fmt.Errorf("%s: no query type %s", path, schema.Query)}
+	}
+	if schema.Mutation != "" && kinds[schema.Mutation] != "object" {
+		if _, ok := kinds[schema.Mutation]; ok || schema.Mutation != "Mutation" {
+			return nil, &UserError{fmt.Errorf("%s: no mutation type %s", path, schema.Mutation)}
+		}
+		schema.Mutation = ""
+	}
+	if kinds[subscription] == "object" {
+		warnf("%s: the subscriptions of %s are not served; the generated server serves queries and mutations", path, subscription)
+	}
+
+	// typeRef converts a type, checking that it is declared and of a kind allowed in input
+	// or output positions
+	var typeRef func(t ast.Type, input bool, where string) (generator.GraphQLTypeRef, error)
+	typeRef = func(t ast.Type, input bool, where string) (generator.GraphQLTypeRef, error) {
+		switch t := t.(type) {
+		case *ast.NonNull:
+			ref, err := typeRef(t.Type, input, where)
+			ref.NonNull = true
+			return ref, err
+		case *ast.List:
+			of, err := typeRef(t.Type, input, where)
+			return generator.GraphQLTypeRef{Of: &of}, err
+		case *ast.Named:
+			name := t.Name.Value
+			kind, ok := kinds[name]
+			switch {
+			case graphQLBuiltinScalars[name]:
+			case !ok:
+				return generator.GraphQLTypeRef{}, fmt.Errorf("%s: unknown type %s", where, name)
+			case name == subscription:
+				return generator.GraphQLTypeRef{}, fmt.Errorf("%s: the subscription type %s is not a type of fields", where, name)
+			case input && (kind == "object" || kind == "interface" || kind == "union"):
+				return generator.GraphQLTypeRef{}, fmt.Errorf("%s: %s is an output type", where, name)
+			case !input && kind == "input":
+				return generator.GraphQLTypeRef{}, fmt.Errorf("%s: %s is an input type", where, name)
+			}
+			return generator.GraphQLTypeRef{Name: name}, nil
+		}
+		return generator.GraphQLTypeRef{}, fmt.Errorf("%s: unsupported type", where)
+	}
+	inputValues := func(values []*ast.InputValueDefinition, where string) ([]generator.GraphQLField, error) {
+		var fields []generator.GraphQLField
+		for _, value := range values {
+			ref, err := typeRef(value.Type, true, where+"."+value.Name.Value)
+			if err != nil {
+				return nil, err
+			}
+			field := generator.GraphQLField{Name: value.Name.Value, Description: description(value.Description), Type: ref}
+			if value.DefaultValue != nil {
+				field.Default = goLiteral(value.DefaultValue)
+			}
+			fields = append(fields, field)
+		}
+		return fields, nil
+	}
+	outputFields := func(definitions []*ast.FieldDefinition, where string) ([]generator.GraphQLField, error) {
+		var fields []generator.GraphQLField
+		for _, definition := range definitions {
+			name := where + "." + definition.Name.Value
+			ref, err := typeRef(definition.Type, false, name)
+			if err != nil {
+				return nil, err
+			}
+			args, err := inputValues(definition.Arguments, name)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, generator.GraphQLField{
+				Name:        definition.Name.Value,
+				Description: description(definition.Description),
+				Type:        ref,
+				Args:        args,
+				Deprecated:  deprecationReason(definition.Directives),
+			})
+		}
+		return fields, nil
+	}
+	named := func(types []*ast.Named, kind, where string) ([]string, error) {
+		var names []string
+		for _, t := range types {
+			if kinds[t.Name.Value] != kind {
+				return nil, fmt.Errorf("%s: %s is not an %s", where, t.Name.Value, kind)
+			}
+			names = append(names, t.Name.Value)
+		}
+		return names, nil
+	}
+
+	for _, definition := range document.Definitions {
+		var err error
+		switch definition := definition.(type) {
+		case *ast.ScalarDefinition:
+			schema.Scalars = append(schema.Scalars, generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)})
+		case *ast.EnumDefinition:
+			enum := generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)}
+			for _, value := range definition.Values {
+				enum.Values = append(enum.Values, value.Name.Value)
+			}
+			schema.Enums = append(schema.Enums, enum)
+		case *ast.InterfaceDefinition:
+			t := generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)}
+			if t.Fields, err = outputFields(definition.Fields, t.Name); err == nil {
+				schema.Interfaces = append(schema.Interfaces, t)
+			}
+		case *ast.UnionDefinition:
+			t := generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)}
+			if t.Types, err = named(definition.Types, "object", t.Name); err == nil {
+				schema.Unions = append(schema.Unions, t)
+			}
+		case *ast.InputObjectDefinition:
+			t := generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)}
+			if t.Fields, err = inputValues(definition.Fields, t.Name); err == nil {
+				schema.Inputs = append(schema.Inputs, t)
+			}
+		case *ast.ObjectDefinition:
+			if definition.Name.Value == subscription {
+				continue
+			}
+			t := generator.GraphQLType{Name: definition.Name.Value, Description: description(definition.Description)}
+			if t.Fields, err = outputFields(definition.Fields, t.Name); err != nil {
+				break
+			}
+			if t.Types, err = named(definition.Interfaces, "interface", t.Name); err == nil {
+				schema.Objects = append(schema.Objects, t)
+			}
+		}
+		if err != nil {
+			return nil, &UserError{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+	for _, t := range append(append([]generator.GraphQLType{}, schema.Objects...), schema.Interfaces...) {
+		if len(t.Fields) == 0 {
+			return nil, &UserError{fmt.Errorf("%s: type %s has no fields", path, t.Name)}
+		}
+	}
+	return schema, nil
+}
+
+// description returns the text of the description of a definition
+func description(value *ast.StringValue) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(value.Value)
+}
+
+// deprecationReason returns the reason of the @deprecated directive of a field, "" when the
+// field is not deprecated
+func deprecationReason(directives []*ast.Directive) string {
+	for _, directive := range directives {
+		if directive.Name.Value != "deprecated" {
+			continue
+		}
+		for _, argument := range directive.Arguments {
+			if reason, ok := argument.Value.(*ast.StringValue); argument.Name.Value == "reason" && ok {
+				return reason.Value
+			}
+		}
+		return "No longer supported"
+	}
+	return ""
+}
+
+// goLiteral returns the Go expression of a default value of the schema, as graphql-go takes
+// it: enum values are their names
+func goLiteral(value ast.Value) string {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		return value.Value
+	case *ast.FloatValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return strconv.FormatBool(value.Value)
+	case *ast.StringValue:
+		return strconv.Quote(value.Value)
+	case *ast.EnumValue:
+		return strconv.Quote(value.Value)
+	case *ast.ListValue:
+		var items []string
+		for _, item := range value.Values {
+			items = append(items, goLiteral(item))
+		}
+		return "[]interface{}{" + strings.Join(items, ", ") + "}"
+	case *ast.ObjectValue:
+		var fields []string
+		for _, field := range value.Fields {
+			fields = append(fields, strconv.Quote(field.Name.Value)+": "+goLiteral(field.Value))
+		}
+		return "map[string]interface{}{" + strings.Join(fields, ", ") + "}"
+	}
+	return "nil"
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// cliConfigFile is the configuration of the CLI, read from the current directory or from
+// the home directory, unless --config names another
+const cliConfigFile = ".microframework.yaml"
+
+// cliConfig is the content of .microframework.yaml
+type cliConfig struct {
+	Hooks generationHooks `yaml:"hooks"`
+	// Workers is how many steps of a generation render their files at once; 0 for the
+	// number of CPUs, 1 to render them one step after the other
+	Workers int `yaml:"workers"`
+	// MaxFileSize is the largest file, in bytes, a generation writes; 0 for no limit
+	MaxFileSize int64 `yaml:"max_file_size"`
+}
+
+// generationHooks are the shell commands run around a generation, through sh -c in the
+// current directory, or PowerShell on Windows without sh (see shellCommand):
+//
+//	hooks:
+//	  before:
+//	    - ./scripts/check-name.sh "$MICROFRAMEWORK_SERVICE"
+//	  file:
+//	    - case "$MICROFRAMEWORK_PATH" in *.go) ./scripts/stamp-header.sh "$MICROFRAMEWORK_FILE";; esac
+//	  after:
+//	    - ./scripts/register-service.sh "$MICROFRAMEWORK_SERVICE" "$MICROFRAMEWORK_DIR"
+//
+// Every command gets the generation in MICROFRAMEWORK_GENERATOR, MICROFRAMEWORK_SERVICE and
+// MICROFRAMEWORK_DIR. A file command runs on each generated file, whose path in the project
+// is MICROFRAMEWORK_PATH; it edits the copy at MICROFRAMEWORK_FILE in place, which is what
+// gets written. The after commands get the generated files, one per line, in
+// MICROFRAMEWORK_FILES.
+type generationHooks struct {
+	Before []string `yaml:"before"`
+	File   []string `yaml:"file"`
+	After  []string `yaml:"after"`
+}
+
+// loadCLIConfig reads the configuration of the CLI: the file of --config, else
+// .microframework.yaml in the current directory, else in the home directory. It returns an
+// empty configuration when there is none.
+func loadCLIConfig() (*cliConfig, error) {
+	path, _ := rootCmd.PersistentFlags().GetString("config")
+	explicit := path != ""
+	if !explicit {
+		path = cliConfigFile
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, cliConfigFile)
+			}
+		}
+	}
+
+	config := &cliConfig{}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, &UserError{fmt.Errorf("invalid %s: %w", path, err)}
+	}
+	return config, nil
+}
+
+// generatorOptions returns the generator options of the CLI configuration, its hooks,
+// workers and file size limit, and of --progress
+func generatorOptions() ([]generator.Option, error) {
+	config, err := loadCLIConfig()
+	if err != nil {
+		return nil, err
+	}
+	var opts []generator.Option
+	if hooks := config.Hooks; len(hooks.Before)+len(hooks.File)+len(hooks.After) > 0 {
+		opts = append(opts, generator.WithHooks(hooks.generatorHooks()))
+	}
+	if config.Workers > 0 {
+		opts = append(opts, generator.WithWorkers(config.Workers))
+	}
+	if config.MaxFileSize > 0 {
+		opts = append(opts, generator.WithMaxFileSize(config.MaxFileSize))
+	}
+	if streamingProgress() {
+		opts = append(opts, generator.WithProgress(progressEvents))
+	}
+	return opts, nil
+}
+
+// generatorHooks runs the shell commands as generator hooks
+func (h generationHooks) generatorHooks() generator.Hooks {
+	var hooks generator.Hooks
+	if len(h.Before) > 0 {
+		hooks.Before = func(ctx *generator.HookContext) error {
+			return runHookCommands(h.Before, hookEnv(ctx))
+		}
+	}
+	if len(h.File) > 0 {
+		hooks.File = func(ctx *generator.HookContext, path string, content []byte) ([]byte, error) {
+			return runFileHookCommands(h.File, ctx, path, content)
+		}
+	}
+	if len(h.After) > 0 {
+		hooks.After = func(ctx *generator.HookContext) error {
+			return runHookCommands(h.After, append(hookEnv(ctx), "MICROFRAMEWORK_FILES="+strings.Join(ctx.Files, "\n")))
+		}
+	}
+	return hooks
+}
+
+// hookEnv returns the environment of the hook commands of a generation
+func hookEnv(ctx *generator.HookContext) []string {
+	dir, err := filepath.Abs(ctx.Dir)
+	if err != nil {
+		dir = ctx.Dir
+	}
+	return append(os.Environ(),
+		"MICROFRAMEWORK_GENERATOR="+ctx.Generator,
+		"MICROFRAMEWORK_SERVICE="+ctx.Name,
+		"MICROFRAMEWORK_DIR="+dir,
+	)
+}
+
+// runFileHookCommands runs the file commands on a copy of a generated file, keeping its base
+// name so that the tools the commands run tell its kind, and returns the copy once edited
+func runFileHookCommands(commands []string, ctx *generator.HookContext, path string, content []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "microframework-hook-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return nil, err
+	}
+	env := append(hookEnv(ctx), "MICROFRAMEWORK_PATH="+path, "MICROFRAMEWORK_FILE="+file)
+	if err := runHookCommands(commands, env); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(file)
+}
+
+// runHookCommands runs commands in order, stopping at the first that fails
+func runHookCommands(commands []string, env []string) error {
+	for _, command := range commands {
+		hook := shellCommand(command)
+		hook.Env = env
+		hook.Stdout = os.Stdout
+		hook.Stderr = os.Stderr
+		if err := hook.Run(); err != nil {
+			return fmt.Errorf("hook %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,924 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+var (
+	importProjectFrom  string
+	importProjectForce bool
+)
+
+// portingReport is the report of import project, in the root of the service
+const portingReport = "PORTING.md"
+
+// portingFrameworks are the frameworks import project ports from, and the packages of their
+// routes and transports: the files importing none of them register no routes
+var portingFrameworks = map[string][]string{
+	"echo":   {"github.com/labstack/echo/v4", "github.com/labstack/echo"},
+	"go-kit": {"github.com/go-kit/kit/transport/http", "github.com/go-kit/kit/transport/grpc", "github.com/go-kit/kit/endpoint", "github.com/gorilla/mux"},
+	"kratos": {"github.com/go-kratos/kratos/v2/transport/http", "github.com/go-kratos/kratos/v2/transport/grpc"},
+}
+
+// routeMethods are the route methods of echo and kratos routers, by name
+var routeMethods = map[string]string{
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"HEAD": "HEAD", "OPTIONS": "OPTIONS", "CONNECT": "CONNECT", "TRACE": "TRACE", "Any": "Any",
+}
+
+// majorVersion matches the major version suffix of an import path
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// kratosHandler matches the handlers protoc-gen-go-http generates: _Greeter_SayHello0_HTTP_Handler
+var kratosHandler = regexp.MustCompile(`^_(\w+?)_(\w+?)\d+_HTTP_Handler$`)
+
+// importProjectCmd represents the import project command
+var importProjectCmd = &cobra.Command{
+	Use:   "project <dir>",
+	Short: "Port the endpoints and services of an echo, go-kit or kratos project",
+	Long: `Analyze the Go sources of a project of another framework and port its endpoints and services
+onto the layout of the service:
+
+  internal/handlers/ported.go  PortedHandler, with a gin route and a handler stub per endpoint
+                               the project registers
+  internal/services/ported.go  a stub implementing each service interface of the project
+  PORTING.md                   the endpoints and where they were registered, and the
+                               constructs left to port by hand: middlewares, transports,
+                               request decoders and response encoders, gRPC services, routes
+                               gin cannot serve
+
+--from selects the framework:
+
+  echo    the routes of echo routers and groups (e.GET, g.POST, e.Match...)
+  go-kit  the gorilla/mux and net/http routes of the HTTP transports, and the interfaces
+          named ...Service whose methods take a context.Context
+  kratos  the routes and the ...HTTPServer interfaces of the _http.pb.go files
+
+The project is recorded in the generation manifest, so that new and update --type templates
+regenerate the stubs. Importing a project again replaces it; files changed since they were
+generated are kept unless --force.
+
+Examples:
+  microframework import project ../legacy-api --from echo
+  microframework import project ../profilesvc --from go-kit
+  microframework import project ../helloworld --from kratos --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportProject,
+}
+
+// importProjectResult is the result of import project in the JSON report
+type importProjectResult struct {
+	Framework string   `json:"framework"`
+	Endpoints int      `json:"endpoints"`
+	Services  []string `json:"services,omitempty"`
+	// Manual are the constructs left to port by hand, as PORTING.md lists them
+	Manual []string `json:"manual,omitempty"`
+}
+
+func init() {
+	importProjectCmd.Flags().StringVar(&importProjectFrom, "from", "", "Framework of the project (echo, go-kit, kratos)")
+	importProjectCmd.Flags().BoolVar(&importProjectForce, "force", false, "Overwrite files changed since they were generated")
+	importProjectCmd.MarkFlagRequired("from")
+	importProjectCmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return sortedKeys(portingFrameworks), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	importCmd.AddCommand(importProjectCmd)
+}
+
+func runImportProject(cmd *cobra.Command, args []string) error {
+	if _, ok := portingFrameworks[importProjectFrom]; !ok {
+		return &UserError{fmt.Errorf("--from must be one of: %s", strings.Join(sortedKeys(portingFrameworks), ", "))}
+	}
+	manifest, err := loadImportManifest()
+	if err != nil {
+		return err
+	}
+
+	analysis, err := analyzeProject(args[0], importProjectFrom)
+	if err != nil {
+		return err
+	}
+	ported := analysis.ported
+	if len(ported.Endpoints)+len(ported.Services) == 0 {
+		return &UserError{fmt.Errorf("found no routes or services in %s", args[0])}
+	}
+	if err := generator.ValidatePorted(ported); err != nil {
+		return &UserError{err}
+	}
+
+	config := manifest.Config
+	config.OutputDir = ""
+	config.FrameworkVersion = version
+	config.Ported = ported
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	// The stubs are generated from the templates the project was generated from
+	packOpts, _, err := templatePackOptions(config.TemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	gen := generator.NewServiceGenerator(&config, opts...)
+	rendered, err := gen.RenderPorted()
+	if err != nil {
+		return &GenerationError{fmt.Errorf("failed to render the ported project: %w", err)}
+	}
+	paths, err := writeImportedFiles(manifest, gen, rendered, importProjectForce)
+	if err != nil {
+		return err
+	}
+	manifest.Config.Ported = ported
+	if err := manifest.Save("."); err != nil {
+		return fmt.Errorf("failed to update generation manifest: %w", err)
+	}
+	if err := os.WriteFile(portingReport, analysis.report(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", portingReport, err)
+	}
+
+	result := importProjectResult{Framework: ported.Framework, Endpoints: len(ported.Endpoints)}
+	for _, service := range ported.Services {
+		result.Services = append(result.Services, service.Name)
+	}
+	for _, note := range analysis.notes {
+		result.Manual = append(result.Manual, note.origin+": "+note.construct)
+	}
+	reportFiles(append(paths, portingReport)...)
+	reportResult(result)
+
+	fmt.Printf("✓ Ported %d endpoints and %d services from the %s project %s\n", len(ported.Endpoints), len(ported.Services), ported.Framework, ported.Source)
+	fmt.Printf("✓ Porting report written to %s: %d constructs to port by hand\n", portingReport, len(analysis.notes))
+	fmt.Println("\nNext steps:")
+	step := 1
+	if len(ported.Endpoints) > 0 {
+		fmt.Printf("%d. Register the routes: handlers.NewPortedHandler().RegisterRoutes(router)\n", step)
+		step++
+	}
+	fmt.Printf("%d. Port the implementation of the stubs in %s\n", step, strings.Join(paths, " and "))
+	fmt.Printf("%d. Work through the constructs %s lists\n", step+1, portingReport)
+	return nil
+}
+
+// portingNote is a construct of a ported project that is left to port by hand
+type portingNote struct {
+	// origin is where the construct is, as file:line
+	origin, construct, advice string
+}
+
+// projectAnalysis is what import project found in the sources of a project
+type projectAnalysis struct {
+	dir    string
+	fset   *token.FileSet
+	ported *generator.PortedProject
+	notes  []portingNote
+	// names are the names of the endpoint handlers, routes the endpoints by method and
+	// normalized route, and wildcards the names of the wildcards of the routes as ginRoute
+	// names them
+	names     map[string]bool
+	routes    map[string]string
+	wildcards map[string]string
+	imports   map[string]bool
+}
+
+// analyzeProject finds the endpoints and services of the Go sources of a project of the
+// framework in dir, tests, vendored packages and testdata aside
+func analyzeProject(dir, framework string) (*projectAnalysis, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, &UserError{err}
+	}
+	if !info.IsDir() {
+		return nil, &UserError{fmt.Errorf("%s is not a directory", dir)}
+	}
+	analysis := &projectAnalysis{
+		dir:       dir,
+		fset:      token.NewFileSet(),
+		ported:    &generator.PortedProject{Framework: framework, Source: filepath.ToSlash(dir)},
+		names:     map[string]bool{"RegisterRoutes": true},
+		routes:    make(map[string]string),
+		wildcards: make(map[string]string),
+		imports:   make(map[string]bool),
+	}
+
+	usesFramework := false
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(analysis.fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			warnf("%v; skipped", err)
+			return nil
+		}
+		imports := fileImports(file)
+		routed := false
+		for _, imported := range imports {
+			for _, frameworkPackage := range portingFrameworks[framework] {
+				if imported == frameworkPackage {
+					routed = true
+				}
+			}
+		}
+		usesFramework = usesFramework || routed
+		analysis.services(file, imports, framework)
+		if routed {
+			analysis.file(file, imports, framework)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !usesFramework {
+		return nil, &UserError{fmt.Errorf("%s imports none of %s: is it a %s project?", dir, strings.Join(portingFrameworks[framework], ", "), framework)}
+	}
+	analysis.ported.Imports = sortedKeys(analysis.imports)
+	sort.SliceStable(analysis.notes, func(i, j int) bool { return originLess(analysis.notes[i].origin, analysis.notes[j].origin) })
+	return analysis, nil
+}
+
+// originLess orders the origins file:line by file, then line
+func originLess(a, b string) bool {
+	i, j := strings.LastIndex(a, ":"), strings.LastIndex(b, ":")
+	if a[:i] != b[:j] {
+		return a[:i] < b[:j]
+	}
+	lineA, _ := strconv.Atoi(a[i+1:])
+	lineB, _ := strconv.Atoi(b[j+1:])
+	return lineA < lineB
+}
+
+// fileImports returns the paths of the imports of a file by the name they are used with
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if majorVersion.MatchString(name) && path.Dir(importPath) != "." {
+			// A major version suffix is not the name of the package: echo/v4 is echo
+			name = path.Base(path.Dir(importPath))
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// origin returns where a node is, as file:line relative to the project
+func (a *projectAnalysis) origin(node ast.Node) string {
+	position := a.fset.Position(node.Pos())
+	file, err := filepath.Rel(a.dir, position.Filename)
+	if err != nil {
+		file = position.Filename
+	}
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(file), position.Line)
+}
+
+// note records a construct left to port by hand
+func (a *projectAnalysis) note(node ast.Node, construct, advice string) {
+	a.notes = append(a.notes, portingNote{origin: a.origin(node), construct: construct, advice: advice})
+}
+
+// source returns the source of an expression on one line, shortened
+func (a *projectAnalysis) source(expr ast.Node) string {
+	var buf bytes.Buffer
+	format.Node(&buf, a.fset, expr)
+	text := strings.Join(strings.Fields(buf.String()), " ")
+	if len(text) > 80 {
+		text = text[:77] + "..."
+	}
+	return text
+}
+
+// chainCall is a call of a chain of method calls, as Methods("GET") in
+// r.Methods("GET").Path("/users").Handler(h)
+type chainCall struct {
+	name string
+	call *ast.CallExpr
+}
+
+// routeChain returns the calls of a chain of method calls, the innermost first, and the
+// variable the chain starts from
+func routeChain(expr ast.Expr) (root string, calls []chainCall) {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		calls = append([]chainCall{{selector.Sel.Name, call}}, calls...)
+		expr = selector.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		root = ident.Name
+	}
+	return root, calls
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, bool) {
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+// file finds the routes and the constructs to port of a file registering routes. The
+// prefixes of the groups and subrouters are followed within each function.
+func (a *projectAnalysis) file(file *ast.File, imports map[string]string, framework string) {
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if framework == "go-kit" && function.Type.Results != nil {
+			for _, result := range function.Type.Results.List {
+				if strings.HasSuffix(a.source(result.Type), "endpoint.Middleware") {
+					a.note(function, "endpoint middleware "+function.Name.Name, "port it into a gin middleware of the routes it wraps")
+				}
+			}
+		}
+		if function.Body == nil {
+			continue
+		}
+		prefixes := make(map[string]string)
+		ast.Inspect(function.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				a.assignment(node, prefixes, framework)
+			case *ast.ExprStmt:
+				if framework == "go-kit" {
+					a.muxRoute(node.X, prefixes, imports)
+				}
+			case *ast.CallExpr:
+				switch framework {
+				case "echo":
+					a.echoCall(node, prefixes)
+				case "kratos":
+					a.kratosCall(node, prefixes, imports)
+				case "go-kit":
+					a.kitCall(node, imports)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// assignment follows the prefixes of the groups, routes and subrouters assigned to
+// variables, and the handlers of the echo routers
+func (a *projectAnalysis) assignment(assign *ast.AssignStmt, prefixes map[string]string, framework string) {
+	for i, lhs := range assign.Lhs {
+		if i >= len(assign.Rhs) {
+			break
+		}
+		if selector, ok := lhs.(*ast.SelectorExpr); ok && framework == "echo" {
+			switch selector.Sel.Name {
+			case "HTTPErrorHandler", "Validator", "Binder", "Renderer", "JSONSerializer", "IPExtractor":
+				a.note(assign, "echo "+selector.Sel.Name, "port it into the gin engine or the handlers")
+			}
+			continue
+		}
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		root, calls := routeChain(assign.Rhs[i])
+		if len(calls) == 0 {
+			continue
+		}
+		prefix, known := prefixes[root], false
+		for _, call := range calls {
+			switch {
+			case call.name == "Group" && framework == "echo",
+				call.name == "Route" && framework == "kratos",
+				call.name == "PathPrefix" && framework == "go-kit":
+				if len(call.call.Args) == 0 {
+					continue
+				}
+				value, ok := stringLiteral(call.call.Args[0])
+				if !ok {
+					a.note(call.call, "group with a computed prefix", "register its routes by hand")
+					continue
+				}
+				prefix, known = strings.TrimSuffix(prefix, "/")+value, true
+				if framework == "echo" && len(call.call.Args) > 1 {
+					a.note(call.call, "middlewares of group "+value, "port them into gin middlewares of the routes of the group")
+				}
+			case call.name == "Subrouter":
+				known = true
+			}
+		}
+		if known {
+			prefixes[ident.Name] = prefix
+		}
+	}
+}
+
+// echoCall finds the routes, middlewares and static files of an echo router or group
+func (a *projectAnalysis) echoCall(call *ast.CallExpr, prefixes map[string]string) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	receiver, _ := selector.X.(*ast.Ident)
+	prefix := ""
+	if receiver != nil {
+		prefix = prefixes[receiver.Name]
+	}
+	name := selector.Sel.Name
+	switch {
+	case routeMethods[name] != "" && len(call.Args) >= 2:
+		if len(call.Args) > 2 {
+			a.note(call, "middlewares of route "+a.source(call.Args[0]), "port them into gin middlewares of the route")
+		}
+		a.route(call, routeMethods[name], prefix, call.Args[0], call.Args[1], echoPath)
+	case name == "Match" && len(call.Args) >= 3:
+		methods, ok := call.Args[0].(*ast.CompositeLit)
+		if !ok {
+			a.note(call, "route of computed methods", "register it by hand")
+			return
+		}
+		for _, element := range methods.Elts {
+			method, ok := stringLiteral(element)
+			if !ok {
+				a.note(call, "route of computed methods", "register it by hand")
+				continue
+			}
+			a.route(call, strings.ToUpper(method), prefix, call.Args[1], call.Args[2], echoPath)
+		}
+	case name == "Use" || name == "Pre":
+		for _, middleware := range call.Args {
+			a.note(middleware, "middleware "+a.source(middleware), "port it into a gin middleware: router.Use")
+		}
+	case name == "Static" || name == "File" || name == "StaticFS" || name == "FileFS":
+		a.note(call, "static files "+a.source(call), "serve them with router.Static or router.StaticFile")
+	}
+}
+
+// echoPath converts an echo path to a path template: /users/:id is /users/{id}
+func echoPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// kratosCall finds the routes of the HTTP servers, and the middlewares and gRPC services of
+// a kratos project
+func (a *projectAnalysis) kratosCall(call *ast.CallExpr, prefixes map[string]string, imports map[string]string) {
+	var name string
+	var receiver *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+		receiver, _ = fun.X.(*ast.Ident)
+	case *ast.Ident:
+		name = fun.Name
+	}
+	switch {
+	case routeMethods[name] != "" && len(call.Args) == 2:
+		prefix := ""
+		if receiver != nil {
+			prefix = prefixes[receiver.Name]
+		}
+		a.route(call, routeMethods[name], prefix, call.Args[0], call.Args[1], kratosPath)
+	case name == "Middleware" && receiver != nil && strings.HasPrefix(imports[receiver.Name], "github.com/go-kratos/kratos/v2/transport/"):
+		for _, middleware := range call.Args {
+			a.note(middleware, "middleware "+a.source(middleware), "port it into a gin middleware: router.Use")
+		}
+	case (name == "Handle" || name == "HandleFunc" || name == "HandlePrefix") && receiver != nil && len(call.Args) == 2:
+		a.note(call, "net/http handler "+a.source(call.Args[0]), "register it with gin.WrapH")
+	case strings.HasPrefix(name, "Register") && strings.HasSuffix(name, "Server") && !strings.HasSuffix(name, "HTTPServer"):
+		a.note(call, "gRPC service "+strings.TrimSuffix(strings.TrimPrefix(name, "Register"), "Server"), "serve it from its .proto files with microframework new --from-proto")
+	}
+}
+
+// kratosPath converts a kratos path to a path template, which it is already; route leaves
+// out the paths whose variables match several segments, as {name=messages/*}
+func kratosPath(route string) string {
+	return route
+}
+
+// kitCall finds the transports of a go-kit project, whose decoders, encoders and options are
+// left to port
+func (a *projectAnalysis) kitCall(call *ast.CallExpr, imports map[string]string) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "NewServer" {
+		return
+	}
+	pkg, _ := selector.X.(*ast.Ident)
+	if pkg == nil {
+		return
+	}
+	switch imports[pkg.Name] {
+	case "github.com/go-kit/kit/transport/http":
+		if len(call.Args) < 3 {
+			return
+		}
+		a.note(call, fmt.Sprintf("HTTP transport of %s, decoding with %s and encoding with %s", a.source(call.Args[0]), a.source(call.Args[1]), a.source(call.Args[2])),
+			"port the decoding of the request and the encoding of the response into the handler of its route")
+		for _, option := range call.Args[3:] {
+			a.note(option, "server option "+a.source(option), "port it into a gin middleware or the handler")
+		}
+	case "github.com/go-kit/kit/transport/grpc":
+		a.note(call, "gRPC transport of "+a.source(call.Args[0]), "serve it from its .proto files with microframework new --from-proto")
+	}
+}
+
+// muxRoute finds the route of a statement of a go-kit project registering a handler with
+// gorilla/mux or net/http: r.Methods("GET").Path("/users").Handler(h), r.Handle("/", h)
+func (a *projectAnalysis) muxRoute(expr ast.Expr, prefixes map[string]string, imports map[string]string) {
+	root, calls := routeChain(expr)
+	if len(calls) == 0 {
+		return
+	}
+	prefix := prefixes[root]
+	var methods []string
+	var routePath, handler ast.Expr
+	for _, call := range calls {
+		switch {
+		case call.name == "Methods":
+			for _, arg := range call.call.Args {
+				method, ok := stringLiteral(arg)
+				if !ok {
+					a.note(call.call, "route of computed methods", "register it by hand")
+					return
+				}
+				methods = append(methods, strings.ToUpper(method))
+			}
+		case call.name == "Path" && len(call.call.Args) == 1:
+			routePath = call.call.Args[0]
+		case call.name == "PathPrefix" && len(call.call.Args) == 1:
+			value, ok := stringLiteral(call.call.Args[0])
+			if !ok {
+				a.note(call.call, "route with a computed prefix", "register it by hand")
+				return
+			}
+			prefix = strings.TrimSuffix(prefix, "/") + value
+		case (call.name == "Handle" || call.name == "HandleFunc") && len(call.call.Args) == 2:
+			routePath, handler = call.call.Args[0], call.call.Args[1]
+		case (call.name == "Handler" || call.name == "HandlerFunc") && len(call.call.Args) == 1:
+			handler = call.call.Args[0]
+		}
+	}
+	if handler == nil {
+		return
+	}
+	if root != "" && imports[root] == "net/http" {
+		prefix = ""
+	}
+	if routePath == nil {
+		a.note(expr, "handler of prefix "+prefix, "register it with gin.WrapH on the routes of the prefix")
+		return
+	}
+	if len(methods) == 0 {
+		methods = []string{"Any"}
+	}
+	for _, method := range methods {
+		a.route(expr, method, prefix, routePath, handler, a.muxPath(expr))
+	}
+}
+
+// muxPath returns the conversion of the gorilla/mux paths of the route at node to path
+// templates, without the regular expressions of their variables: /users/{id:[0-9]+} is
+// /users/{id}
+func (a *projectAnalysis) muxPath(node ast.Node) func(string) string {
+	return func(route string) string {
+		segments := strings.Split(route, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && strings.Contains(segment, ":") {
+				segments[i] = segment[:strings.Index(segment, ":")] + "}"
+				a.note(node, "pattern of variable "+segment, "validate it in the handler")
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+}
+
+// route adds the endpoint of a route registered at node, whose path template convert
+// returns from the path of the framework
+func (a *projectAnalysis) route(node ast.Node, method, prefix string, pathExpr, handler ast.Expr, convert func(string) string) {
+	value, ok := stringLiteral(pathExpr)
+	if !ok {
+		a.note(node, "route with the computed path "+a.source(pathExpr), "register it by hand")
+		return
+	}
+	fullPath := value
+	if prefix != "" {
+		fullPath = strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(value, "/")
+	}
+	if !strings.HasPrefix(fullPath, "/") {
+		fullPath = "/" + fullPath
+	}
+	switch method {
+	case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "Any":
+	default:
+		a.note(node, method+" "+fullPath, "gin has no route method for "+method+"; register it with router.Handle")
+		return
+	}
+	template := convert(fullPath)
+	normalized, ginPath, valid := ginRoute(template, a.wildcards)
+	if !valid || strings.Contains(template, "=") {
+		a.note(node, method+" "+fullPath, "gin cannot match the path; register it by hand")
+		return
+	}
+	key := method + " " + normalized
+	if registered, ok := a.routes[key]; ok {
+		a.note(node, method+" "+fullPath, "already registered at "+registered)
+		return
+	}
+	a.routes[key] = a.origin(node)
+
+	endpoint := generator.PortedEndpoint{
+		Operation: generator.Operation{Method: method, Path: fullPath, Route: ginPath},
+		Origin:    a.origin(node),
+		Handler:   a.source(handler),
+	}
+	endpoint.Name = handlerName(handler)
+	if endpoint.Name == "" {
+		endpoint.Name = pascalName(strings.ToLower(method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(template))
+	}
+	for base, n := endpoint.Name, 2; a.names[endpoint.Name]; n++ {
+		endpoint.Name = base + strconv.Itoa(n)
+	}
+	a.names[endpoint.Name] = true
+	a.ported.Endpoints = append(a.ported.Endpoints, endpoint)
+}
+
+// handlerName returns the Go name of the handler of a route, after the function, method or
+// endpoint it is made from: GetUser for h.GetUser, GetProfile for
+// httptransport.NewServer(e.GetProfileEndpoint, ...), SayHello for
+// _Greeter_SayHello0_HTTP_Handler(srv)
+func handlerName(handler ast.Expr) string {
+	var name string
+	switch handler := handler.(type) {
+	case *ast.Ident:
+		name = handler.Name
+	case *ast.SelectorExpr:
+		name = handler.Sel.Name
+	case *ast.CallExpr:
+		called := handlerName(handler.Fun)
+		if match := kratosHandler.FindStringSubmatch(called); match != nil {
+			return match[2]
+		}
+		if called == "NewServer" && len(handler.Args) > 0 {
+			return handlerName(handler.Args[0])
+		}
+		name = called
+	default:
+		return ""
+	}
+	if match := kratosHandler.FindStringSubmatch(name); match != nil {
+		return match[2]
+	}
+	for _, prefix := range []string{"make", "Make"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	for _, suffix := range []string{"Handler", "Endpoint"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	name = pascalName(name)
+	if name == "" || !unicode.IsUpper(rune(name[0])) {
+		return ""
+	}
+	return name
+}
+
+// services finds the service interfaces of a file: the ...HTTPServer interfaces of kratos,
+// and the interfaces named ...Service of go-kit whose methods take a context.Context
+func (a *projectAnalysis) services(file *ast.File, imports map[string]string, framework string) {
+	for _, decl := range file.Decls {
+		general, ok := decl.(*ast.GenDecl)
+		if !ok || general.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range general.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if framework == "go-kit" {
+				if function, ok := typeSpec.Type.(*ast.FuncType); ok && function.Params.NumFields() == 1 && function.Results.NumFields() == 1 &&
+					a.source(function.Params.List[0].Type) == a.source(function.Results.List[0].Type) {
+					a.note(typeSpec, "service middleware "+typeSpec.Name.Name, "port it into the ported service or a gin middleware")
+				}
+			}
+			iface, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || !typeSpec.Name.IsExported() || len(iface.Methods.List) == 0 {
+				continue
+			}
+			name := typeSpec.Name.Name
+			switch {
+			case framework == "kratos" && strings.HasSuffix(name, "HTTPServer"):
+				name = strings.TrimSuffix(name, "HTTPServer") + "Service"
+			case framework == "go-kit" && strings.HasSuffix(name, "Service"):
+			default:
+				continue
+			}
+			service, ok := a.service(name, typeSpec, iface, imports)
+			if !ok {
+				continue
+			}
+			a.ported.Services = append(a.ported.Services, service)
+		}
+	}
+}
+
+// service returns the ported service of an interface whose methods all take a
+// context.Context first. The types of the project in the signatures are interface{}.
+func (a *projectAnalysis) service(name string, typeSpec *ast.TypeSpec, iface *ast.InterfaceType, imports map[string]string) (generator.PortedService, bool) {
+	service := generator.PortedService{Name: name, Origin: a.origin(typeSpec)}
+	unported := make(map[string]bool)
+	for _, field := range iface.Methods.List {
+		function, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			// Embedded interfaces are not followed
+			return service, false
+		}
+		if function.Params.NumFields() == 0 || a.source(function.Params.List[0].Type) != "context.Context" {
+			return service, false
+		}
+		method := generator.PortedMethod{Name: field.Names[0].Name}
+		used := map[string]bool{"s": true}
+		method.Params = a.params(function.Params, "p", imports, used, unported)
+		method.Results = a.params(function.Results, "r", imports, used, unported)
+		service.Methods = append(service.Methods, method)
+	}
+	if len(unported) > 0 {
+		a.note(typeSpec, fmt.Sprintf("types %s of service %s", strings.Join(sortedKeys(unported), ", "), typeSpec.Name.Name),
+			"port them into internal/models; they are interface{} in internal/services/ported.go")
+	}
+	return service, true
+}
+
+// params returns the parameters or results of a method, named after theirs or else after
+// prefix and their index; an error result is err
+func (a *projectAnalysis) params(fields *ast.FieldList, prefix string, imports map[string]string, used, unported map[string]bool) []generator.PortedParam {
+	if fields == nil {
+		return nil
+	}
+	var params []generator.PortedParam
+	for _, field := range fields.List {
+		goType := a.portedType(field.Type, imports, unported)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, ident := range names {
+			name := ""
+			if ident != nil && ident.Name != "_" {
+				name = ident.Name
+			}
+			switch {
+			case name == "" && goType == "error":
+				name = "err"
+			case name == "" && goType == "context.Context":
+				name = "ctx"
+			}
+			if name == "" {
+				name = prefix + strconv.Itoa(len(params))
+			}
+			for base, n := name, 2; used[name]; n++ {
+				name = base + strconv.Itoa(n)
+			}
+			used[name] = true
+			params = append(params, generator.PortedParam{Name: name, Type: goType})
+		}
+	}
+	return params
+}
+
+// portedType returns the Go type of a parameter in the ported service: its own when it is
+// made of predeclared types and types of the standard library, whose packages the service
+// imports, and else interface{}, the types of the project it uses being unported
+func (a *projectAnalysis) portedType(expr ast.Expr, imports map[string]string, unported map[string]bool) string {
+	portable := true
+	stdlib := make(map[string]bool)
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.SelectorExpr:
+			pkg, ok := node.X.(*ast.Ident)
+			importPath := ""
+			if ok {
+				importPath = imports[pkg.Name]
+			}
+			if importPath == "" || strings.Contains(strings.Split(importPath, "/")[0], ".") || path.Base(importPath) != pkg.Name {
+				portable = false
+				unported[a.source(node)] = true
+			} else {
+				stdlib[importPath] = true
+			}
+			return false
+		case *ast.Ident:
+			if !predeclaredTypes[node.Name] {
+				portable = false
+				unported[node.Name] = true
+			}
+		case *ast.FuncType, *ast.StructType:
+			portable = false
+			unported[a.source(node)] = true
+			return false
+		}
+		return true
+	})
+	if !portable {
+		if _, ok := expr.(*ast.Ellipsis); ok {
+			return "...interface{}"
+		}
+		return "interface{}"
+	}
+	for importPath := range stdlib {
+		a.imports[importPath] = true
+	}
+	return a.source(expr)
+}
+
+// predeclaredTypes are the types of the universe scope
+var predeclaredTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// report returns the porting report of the analysis: what was ported where, and what is
+// left to port by hand
+func (a *projectAnalysis) report() []byte {
+	var report strings.Builder
+	ported := a.ported
+	fmt.Fprintf(&report, "# Porting from %s\n\n", ported.Framework)
+	fmt.Fprintf(&report, "`microframework import project %s --from %s` ported the endpoints and services of the project onto the layout of the service. The stubs are generated again by `new` and `update --type templates`; this report is written by each import.\n", ported.Source, ported.Framework)
+
+	fmt.Fprintf(&report, "\n## Endpoints\n\n")
+	if len(ported.Endpoints) == 0 {
+		report.WriteString("The project registers no routes that could be ported.\n")
+	} else {
+		report.WriteString("`internal/handlers/ported.go` has a stub per endpoint; register its routes with `handlers.NewPortedHandler().RegisterRoutes(router)`.\n\n")
+		report.WriteString("| Method | Path | Gin route | Stub | Registered at | Handler |\n")
+		report.WriteString("|--------|------|-----------|------|---------------|---------|\n")
+		for _, endpoint := range ported.Endpoints {
+			fmt.Fprintf(&report, "| %s | `%s` | `%s` | `PortedHandler.%s` | %s | `%s` |\n", endpoint.Method, endpoint.Path, endpoint.Route, endpoint.Name, endpoint.Origin, strings.ReplaceAll(endpoint.Handler, "|", "\\|"))
+		}
+	}
+
+	fmt.Fprintf(&report, "\n## Services\n\n")
+	if len(ported.Services) == 0 {
+		report.WriteString("The project declares no service interfaces that could be ported.\n")
+	} else {
+		report.WriteString("`internal/services/ported.go` has a stub implementing each service interface.\n\n")
+		for _, service := range ported.Services {
+			var methods []string
+			for _, method := range service.Methods {
+				methods = append(methods, method.Name)
+			}
+			fmt.Fprintf(&report, "- `Ported%s` (%s): %s\n", service.Name, service.Origin, strings.Join(methods, ", "))
+		}
+	}
+
+	fmt.Fprintf(&report, "\n## Needs manual porting\n\n")
+	if len(a.notes) == 0 {
+		report.WriteString("Nothing: the project has no constructs the analysis left to port by hand.\n")
+	}
+	for _, note := range a.notes {
+		fmt.Fprintf(&report, "- %s: %s — %s\n", note.origin, strings.ReplaceAll(note.construct, "\n", " "), note.advice)
+	}
+	return []byte(report.String())
+}
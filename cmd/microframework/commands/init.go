@@ -0,0 +1,494 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+)
+
+var (
+	initName         string
+	initType         string
+	initMain         string
+	initWire         bool
+	initYes          bool
+	initDryRun       bool
+	initForce        bool
+	initTemplatePack string
+)
+
+// initScaffoldDirs are the directories init adds to a project that does not have them
+var initScaffoldDirs = []string{"configs", "deployments", "tests"}
+
+// initFrameworkFile is the file of the main package --wire starts the framework in
+const initFrameworkFile = "microframework.go"
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Adopt an existing Go service",
+	Long: `Adopt the existing Go HTTP or gRPC service in the current directory, so that the other
+commands work on it:
+
+  1. Inspect the project: its module, main package and the servers it imports, which give
+     the service type (grpc, graphql, websocket or rest)
+  2. Scaffold configs/, deployments/ and tests/ with the templates of this CLI, for each of
+     them the project does not have; tests/ gets health checks of the running service
+  3. Write the generation manifest and the lock file, which update --type templates uses
+     to keep the scaffolded files up to date
+
+With --wire, init also starts the go-micro-libs configuration, logging and monitoring
+managers in the main package: it adds microframework.go next to the main and two lines at
+the start of main(), shows the changes as a diff and asks before applying them (--yes
+applies them without asking). The framework's own Bootstrap is internal to it and cannot
+be imported from another module.
+
+Examples:
+  microframework init
+  microframework init --name orders --main ./cmd/server
+  microframework init --wire --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runInit(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initName, "name", "n", "", "Service name (default: the last element of the module path)")
+	initCmd.Flags().StringVarP(&initType, "type", "t", "", "Service type (default: detected from the imports)")
+	initCmd.Flags().StringVar(&initMain, "main", "", "Main package of the service, when the project has several")
+	initCmd.Flags().BoolVar(&initWire, "wire", false, "Start the go-micro-libs managers in the main package")
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Apply the changes to the main package without asking")
+	initCmd.Flags().BoolVar(&initDryRun, "dry-run", false, "Show the changes without writing them")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Adopt a project that has a generation manifest already")
+	initCmd.Flags().StringVar(&initTemplatePack, "template-pack", "", "Template pack to scaffold from, <name>[@<version>] (microframework templates list)")
+	initCmd.RegisterFlagCompletionFunc("template-pack", completeTemplatePacks)
+}
+
+// existingProject is what init found in the project it adopts
+type existingProject struct {
+	Module  string
+	Name    string
+	Type    string
+	Servers []string
+	// Mains maps the directory of each main package to the file declaring func main
+	Mains map[string]string
+	Main  string
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat("go.mod"); err != nil {
+		return fmt.Errorf("no go.mod; run microframework init from the root of a Go module")
+	}
+	previous, err := generator.LoadManifest(".")
+	switch {
+	case err == nil && !initForce:
+		return &UserError{fmt.Errorf("%s exists, the project is set up already; use --force to adopt it again", generator.ManifestFile)}
+	case err != nil && !os.IsNotExist(err):
+		return err
+	}
+
+	project, err := inspectProject()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Module:       %s\n", project.Module)
+	fmt.Printf("Service:      %s\n", project.Name)
+	fmt.Printf("Type:         %s\n", project.Type)
+	fmt.Printf("Servers:      %s\n", orDefault(strings.Join(project.Servers, ", "), "none found"))
+	fmt.Printf("Main package: %s\n", project.Main)
+
+	config := generator.GeneratorConfig{
+		ServiceName:      project.Name,
+		ServiceType:      project.Type,
+		MainPackage:      project.Main,
+		Adopted:          true,
+		FrameworkVersion: version,
+	}
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, pack, err := templatePackOptions(initTemplatePack)
+	if err != nil {
+		return err
+	}
+	config.TemplatePack = pack
+	opts = append(opts, packOpts...)
+	rendered, err := generator.NewServiceGenerator(&config, opts...).RenderService()
+	if err != nil {
+		return &GenerationError{fmt.Errorf("failed to render templates: %w", err)}
+	}
+
+	fmt.Println("\nScaffold:")
+	scaffold := make(map[string][]byte)
+	for _, dir := range initScaffoldDirs {
+		if isDirectory(dir) {
+			fmt.Printf("  %-7s %s/ (exists)\n", "keep", dir)
+			continue
+		}
+		var files []string
+		for path, content := range rendered {
+			if strings.HasPrefix(path, dir+"/") {
+				scaffold[path] = content
+				files = append(files, path)
+			}
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			fmt.Printf("  %-7s %s\n", "create", file)
+		}
+	}
+
+	var wiring map[string][]byte
+	if initWire {
+		if wiring, err = planWiring(project, config); err != nil {
+			return err
+		}
+		if len(wiring) == 0 {
+			fmt.Printf("\n%s already starts the framework\n", project.Mains[project.Main])
+		}
+		for _, path := range sortedKeys(wiring) {
+			before, _ := os.ReadFile(path)
+			from := path
+			if before == nil {
+				from = "/dev/null"
+			}
+			fmt.Printf("\n%s", unifiedDiff(string(before), string(wiring[path]), from, path))
+		}
+	}
+
+	if initDryRun {
+		fmt.Println("\nDry run: nothing was written")
+		return nil
+	}
+
+	applyWiring := len(wiring) > 0
+	if applyWiring && !initYes {
+		if !isInteractive() {
+			fmt.Println("\nNot applying the changes to the main package without a terminal; run again with --force --wire --yes")
+			applyWiring = false
+		} else {
+			applyWiring = confirm("\nApply the changes to the main package?")
+		}
+	}
+
+	if err := writeAdoption(previous, config, scaffold); err != nil {
+		return err
+	}
+	if applyWiring {
+		for _, path := range sortedKeys(wiring) {
+			if err := os.WriteFile(path, wiring[path], 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+	}
+
+	fmt.Printf("\n✓ Adopted %s (%d files scaffolded)\n", project.Name, len(scaffold))
+	fmt.Println("\nNext steps:")
+	step := 1
+	if applyWiring {
+		fmt.Printf("%d. go get github.com/anasamu/go-micro-libs@v1.0.0 && go mod tidy\n", step)
+		step++
+	}
+	fmt.Printf("%d. Check the port (8080) and health endpoint (/health) the scaffold assumes\n", step)
+	fmt.Printf("%d. microframework doctor\n", step+1)
+	return nil
+}
+
+// inspectProject finds the module, main package and servers of the project in the current
+// directory
+func inspectProject() (*existingProject, error) {
+	project := &existingProject{Module: currentModulePath(), Mains: make(map[string]string)}
+	if project.Module == "" {
+		return nil, fmt.Errorf("go.mod declares no module")
+	}
+
+	files, err := goSourceFiles("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files: %w", err)
+	}
+	imported := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		for _, spec := range parsed.Imports {
+			imported[strings.Trim(spec.Path.Value, `"`)] = true
+		}
+		if parsed.Name.Name == "main" && mainFunc(parsed) != nil {
+			project.Mains[packagePath(filepath.Dir(file))] = file
+		}
+	}
+
+	project.Type, project.Servers = detectServers(imported)
+	if initType != "" {
+		project.Type = initType
+	}
+
+	project.Name = initName
+	if project.Name == "" {
+		project.Name = strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(path.Base(project.Module)))
+	}
+	if err := validateServiceName(project.Name); err != nil {
+		return nil, &UserError{fmt.Errorf("%w; give the service name with --name", err)}
+	}
+
+	mains := sortedKeys(project.Mains)
+	switch {
+	case initMain != "":
+		project.Main = packagePath(filepath.Clean(initMain))
+		if _, ok := project.Mains[project.Main]; !ok {
+			return nil, &UserError{fmt.Errorf("%s is not a main package; main packages: %s", initMain, strings.Join(mains, ", "))}
+		}
+	case len(mains) == 0:
+		return nil, fmt.Errorf("no main package found; init adopts services, which have one")
+	case len(mains) == 1:
+		project.Main = mains[0]
+	default:
+		if _, ok := project.Mains["./cmd/"+project.Name]; ok {
+			project.Main = "./cmd/" + project.Name
+			break
+		}
+		return nil, &UserError{fmt.Errorf("several main packages (%s); choose the service with --main", strings.Join(mains, ", "))}
+	}
+	return project, nil
+}
+
+// serverImports are the imports that tell the type of a service and the servers it runs
+var serverImports = []struct {
+	Prefix string
+	Type   string
+	Server string
+}{
+	{"google.golang.org/grpc", "grpc", "gRPC"},
+	{"github.com/99designs/gqlgen", "graphql", "gqlgen"},
+	{"github.com/graph-gophers/graphql-go", "graphql", "graphql-go"},
+	{"github.com/graphql-go/graphql", "graphql", "graphql-go"},
+	{"github.com/gorilla/websocket", "websocket", "gorilla/websocket"},
+	{"github.com/coder/websocket", "websocket", "websocket"},
+	{"nhooyr.io/websocket", "websocket", "websocket"},
+	{"github.com/gin-gonic/gin", "rest", "gin"},
+	{"github.com/labstack/echo", "rest", "echo"},
+	{"github.com/go-chi/chi", "rest", "chi"},
+	{"github.com/gofiber/fiber", "rest", "fiber"},
+	{"github.com/gorilla/mux", "rest", "gorilla/mux"},
+	{"net/http", "rest", "net/http"},
+}
+
+// detectServers returns the service type and the servers the imports of a project give; the
+// type is the first in serverImports found, rest when none is
+func detectServers(imported map[string]bool) (string, []string) {
+	serviceType := ""
+	var servers []string
+	for _, server := range serverImports {
+		for path := range imported {
+			if path != server.Prefix && !strings.HasPrefix(path, server.Prefix+"/") {
+				continue
+			}
+			if serviceType == "" {
+				serviceType = server.Type
+			}
+			if !containsString(servers, server.Server) {
+				servers = append(servers, server.Server)
+			}
+			break
+		}
+	}
+	if serviceType == "" {
+		serviceType = "rest"
+	}
+	return serviceType, servers
+}
+
+// planWiring returns the files --wire writes to start the framework in the main package, none
+// when it starts it already
+func planWiring(project *existingProject, config generator.GeneratorConfig) (map[string][]byte, error) {
+	mainFile := project.Mains[project.Main]
+	src, err := os.ReadFile(mainFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", mainFile, err)
+	}
+	if bytes.Contains(src, []byte("mustStartFramework()")) {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(mainFile)
+	frameworkFile := filepath.Join(dir, initFrameworkFile)
+	if _, err := os.Stat(frameworkFile); err == nil {
+		return nil, fmt.Errorf("%s exists; --wire adds its own", frameworkFile)
+	}
+	if err := checkWiringNames(dir, mainFile); err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, mainFile, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", mainFile, err)
+	}
+	offset := fset.Position(mainFunc(parsed).Body.Lbrace).Offset + 1
+	var wired bytes.Buffer
+	wired.Write(src[:offset])
+	wired.WriteString("\n\tframework := mustStartFramework()\n\tdefer framework.close()\n")
+	wired.Write(src[offset:])
+	main, err := format.Source(wired.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", mainFile, err)
+	}
+
+	tmpl, err := template.New(initFrameworkFile).Parse(templates.FrameworkTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse framework template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, config); err != nil {
+		return nil, err
+	}
+	framework, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", initFrameworkFile, err)
+	}
+
+	return map[string][]byte{mainFile: main, frameworkFile: framework}, nil
+}
+
+// checkWiringNames fails when the main package declares the names --wire adds, or main()
+// uses the framework variable
+func checkWiringNames(dir, mainFile string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file := filepath.Join(dir, name)
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		for _, decl := range parsed.Decls {
+			var names []string
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					names = append(names, decl.Name.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						names = append(names, spec.Name.Name)
+					case *ast.ValueSpec:
+						for _, ident := range spec.Names {
+							names = append(names, ident.Name)
+						}
+					}
+				}
+			}
+			for _, declared := range names {
+				if declared == "frameworkManagers" || declared == "startFramework" || declared == "mustStartFramework" {
+					return fmt.Errorf("%s declares %s, which --wire adds", file, declared)
+				}
+			}
+		}
+		if file != mainFile {
+			continue
+		}
+		used := false
+		ast.Inspect(mainFunc(parsed).Body, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "framework" {
+				used = true
+			}
+			return !used
+		})
+		if used {
+			return fmt.Errorf("main() in %s uses the name framework, which --wire declares", file)
+		}
+	}
+	return nil
+}
+
+// writeAdoption writes the scaffolded files, the generation manifest recording them and the
+// lock file. A project adopted again keeps the files it was scaffolded with before.
+func writeAdoption(previous *generator.Manifest, config generator.GeneratorConfig, scaffold map[string][]byte) error {
+	manifest := &generator.Manifest{FrameworkVersion: version, Config: config, Files: make(map[string]string)}
+	if previous != nil {
+		for path, checksum := range previous.Files {
+			manifest.Files[path] = checksum
+		}
+	}
+
+	for _, path := range sortedKeys(scaffold) {
+		content := scaffold[path]
+		if err := os.MkdirAll(filepath.Dir(filepath.FromSlash(path)), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(filepath.FromSlash(path), content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := generator.WriteBase(".", path, content); err != nil {
+			return err
+		}
+		manifest.Files[path] = generator.Checksum(content)
+	}
+
+	if err := manifest.Save("."); err != nil {
+		return fmt.Errorf("failed to write %s: %w", generator.ManifestFile, err)
+	}
+	return writeProjectLock(".")
+}
+
+// mainFunc returns the func main of a file, or nil
+func mainFunc(file *ast.File) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// packagePath returns the go build path of a directory of the module, as ./cmd/api
+func packagePath(dir string) string {
+	dir = filepath.ToSlash(dir)
+	if dir == "." {
+		return "."
+	}
+	return "./" + strings.TrimPrefix(dir, "./")
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,566 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+var inspectDepsOutput string
+
+// The kinds of dependency between the services of a workspace
+const (
+	// DependencyClient is an import of the packages of another service, as its generated client
+	DependencyClient = "client"
+	// DependencyCall is a URL of another service in the configuration
+	DependencyCall = "call"
+	// DependencyTopic is a subscription to a topic another service publishes
+	DependencyTopic = "topic"
+	// DependencyDiscovery is another service looked up through service discovery
+	DependencyDiscovery = "discovery"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Inspect the services of the workspace",
+	Long:  `Inspect the services of the workspace and how they relate to each other.`,
+}
+
+// inspectDepsCmd represents the inspect deps command
+var inspectDepsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Show the dependency graph of the services of the workspace",
+	Long: `Show which service of the workspace depends on which, for architecture reviews.
+
+The services are those of the go.work of the current directory. A service depends on
+another when:
+- client: it imports packages of the other's module, as its generated client
+- call: its configs or .env.example hold a URL, or host:port, whose host is the other
+- discovery: the discovery section of its configuration names the other
+- topic: it subscribes to a topic the other publishes. Topics are read from the
+  Publish and Subscribe calls of the code, and from the topics of the configuration,
+  where a topic prefixed with a service name ("user-events") belongs to that service
+
+The graph is printed as text, Graphviz DOT, a Mermaid flowchart, or JSON.
+
+Examples:
+  microframework inspect deps
+  microframework inspect deps --output mermaid
+  microframework inspect deps -o dot | dot -Tsvg > deps.svg`,
+	Args: cobra.NoArgs,
+	RunE: runInspectDeps,
+}
+
+func init() {
+	inspectDepsCmd.Flags().StringVarP(&inspectDepsOutput, "output", "o", "text", "Output format (text, dot, mermaid, json)")
+	inspectDepsCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"text", "dot", "mermaid", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	inspectCmd.AddCommand(inspectDepsCmd)
+}
+
+// inspectedService is a service of the dependency graph
+type inspectedService struct {
+	Name   string `json:"name"`
+	Dir    string `json:"dir"`
+	Module string `json:"module"`
+	// Publishes and Subscribes are the topics the service produces and consumes
+	Publishes  []string `json:"publishes,omitempty"`
+	Subscribes []string `json:"subscribes,omitempty"`
+
+	// aliases are the names the other services may address the service by
+	aliases []string
+}
+
+// serviceDependency is an edge of the dependency graph
+type serviceDependency struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Via are what the dependency was found from: import paths, configuration keys, topics
+	Via []string `json:"via"`
+}
+
+// dependencyGraph is the dependency graph of the services of a workspace
+type dependencyGraph struct {
+	Services     []*inspectedService  `json:"services"`
+	Dependencies []*serviceDependency `json:"dependencies"`
+}
+
+func runInspectDeps(cmd *cobra.Command, args []string) error {
+	if !containsString([]string{"text", "dot", "mermaid", "json"}, inspectDepsOutput) {
+		return fmt.Errorf("invalid output format: %s (valid: text, dot, mermaid, json)", inspectDepsOutput)
+	}
+	dirs, err := workspaceServices()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("go.work lists no services")
+	}
+
+	graph, err := buildDependencyGraph(dirs)
+	if err != nil {
+		return err
+	}
+	switch inspectDepsOutput {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(graph)
+	case "dot":
+		fmt.Print(graph.dot())
+	case "mermaid":
+		fmt.Print(graph.mermaid())
+	default:
+		graph.print()
+	}
+	return nil
+}
+
+// buildDependencyGraph analyzes the services of a workspace
+func buildDependencyGraph(dirs []string) (*dependencyGraph, error) {
+	graph := &dependencyGraph{Dependencies: []*serviceDependency{}}
+	for _, dir := range dirs {
+		service, err := inspectService(dir)
+		if err != nil {
+			return nil, err
+		}
+		graph.Services = append(graph.Services, service)
+	}
+
+	edges := make(map[[3]string]*serviceDependency)
+	add := func(from, to *inspectedService, kind, via string) {
+		if from == to {
+			return
+		}
+		key := [3]string{from.Name, to.Name, kind}
+		if edges[key] == nil {
+			edges[key] = &serviceDependency{From: from.Name, To: to.Name, Kind: kind}
+		}
+		if !containsString(edges[key].Via, via) {
+			edges[key].Via = append(edges[key].Via, via)
+		}
+	}
+
+	topicOwners := make(map[string][]*inspectedService)
+	for _, service := range graph.Services {
+		imports, err := serviceImports(service.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range imports {
+			for _, other := range graph.Services {
+				if path == other.Module || strings.HasPrefix(path, other.Module+"/") {
+					add(service, other, DependencyClient, path)
+				}
+			}
+		}
+
+		for _, reference := range serviceReferences(service.Dir) {
+			if other := graph.service(reference.host); other != nil {
+				add(service, other, reference.kind, reference.key)
+			}
+		}
+
+		for _, topic := range service.Publishes {
+			topicOwners[topic] = append(topicOwners[topic], service)
+		}
+	}
+
+	for _, service := range graph.Services {
+		for _, topic := range service.Subscribes {
+			owners := topicOwners[topic]
+			if owner := graph.topicService(topic); owner != nil && !containsService(owners, owner) {
+				owners = append(owners, owner)
+			}
+			for _, owner := range owners {
+				add(service, owner, DependencyTopic, topic)
+			}
+		}
+	}
+
+	for _, edge := range edges {
+		sort.Strings(edge.Via)
+		graph.Dependencies = append(graph.Dependencies, edge)
+	}
+	sort.Slice(graph.Dependencies, func(i, j int) bool {
+		a, b := graph.Dependencies[i], graph.Dependencies[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return graph, nil
+}
+
+// inspectService reads the name, module and topics of the service in dir
+func inspectService(dir string) (*inspectedService, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the go.mod of %s: %w", dir, err)
+	}
+	module := modfile.ModulePath(content)
+	service := &inspectedService{Name: filepath.Base(dir), Dir: dir, Module: module}
+	service.aliases = []string{service.Name, filepath.Base(module)}
+	if manifest, err := generator.LoadManifest(dir); err == nil && manifest.Config.ServiceName != "" {
+		service.aliases = append(service.aliases, manifest.Config.ServiceName)
+	}
+
+	publishes, subscribes, err := codeTopics(dir)
+	if err != nil {
+		return nil, err
+	}
+	config := loadServiceConfig(dir)
+	if name, ok := asMap(config["service"])["name"].(string); ok && name != "" {
+		service.aliases = append(service.aliases, name)
+	}
+	for _, topic := range configTopics(config) {
+		if topicPrefixed(topic, service.aliases) {
+			publishes = append(publishes, topic)
+		} else {
+			subscribes = append(subscribes, topic)
+		}
+	}
+	service.Publishes = uniqueSorted(publishes)
+	service.Subscribes = uniqueSorted(subscribes)
+	return service, nil
+}
+
+// loadServiceConfig returns the configs/config.yaml of a service, nil when it has none
+func loadServiceConfig(dir string) map[string]interface{} {
+	var config map[string]interface{}
+	if content, err := os.ReadFile(filepath.Join(dir, "configs", "config.yaml")); err == nil {
+		yaml.Unmarshal(content, &config)
+	}
+	return config
+}
+
+// service returns the service a host addresses, nil when none does
+func (graph *dependencyGraph) service(host string) *inspectedService {
+	host = strings.ToLower(host)
+	for _, service := range graph.Services {
+		for _, alias := range service.aliases {
+			// Hosts of a Kubernetes service, as user.default.svc.cluster.local
+			if host == strings.ToLower(alias) || strings.HasPrefix(host, strings.ToLower(alias)+".") {
+				return service
+			}
+		}
+	}
+	return nil
+}
+
+// topicService returns the service whose name prefixes a topic, nil when none does
+func (graph *dependencyGraph) topicService(topic string) *inspectedService {
+	for _, service := range graph.Services {
+		if topicPrefixed(topic, service.aliases) {
+			return service
+		}
+	}
+	return nil
+}
+
+// topicPrefixed reports whether a topic is prefixed with one of the names of a service
+func topicPrefixed(topic string, aliases []string) bool {
+	for _, alias := range aliases {
+		for _, separator := range []string{"-", ".", "_", ":", "/"} {
+			if strings.HasPrefix(topic, alias+separator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsService(services []*inspectedService, service *inspectedService) bool {
+	for _, candidate := range services {
+		if candidate == service {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueSorted returns the distinct values of a list, sorted
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool)
+	for _, value := range values {
+		seen[value] = true
+	}
+	return sortedKeys(seen)
+}
+
+// walkServiceGoFiles calls fn with the Go files of a service, tests and generated copies aside
+func walkServiceGoFiles(dir string, fn func(path string) error) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || containsString(runSkipDirs, entry.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// serviceImports returns the import paths of the Go files of a service
+func serviceImports(dir string) ([]string, error) {
+	var imports []string
+	err := walkServiceGoFiles(dir, func(path string) error {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			// Files that do not parse are reported by the build, not here
+			return nil
+		}
+		for _, spec := range file.Imports {
+			if value, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports = append(imports, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the code of %s: %w", dir, err)
+	}
+	return uniqueSorted(imports), nil
+}
+
+// codeTopics returns the topics the code of a service publishes and subscribes to: the
+// string literals passed to the Publish and Subscribe methods of its messaging clients
+func codeTopics(dir string) ([]string, []string, error) {
+	var publishes, subscribes []string
+	err := walkServiceGoFiles(dir, func(path string) error {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			var topics *[]string
+			switch name := selector.Sel.Name; {
+			case strings.HasPrefix(name, "Publish"), strings.HasPrefix(name, "Produce"):
+				topics = &publishes
+			case strings.HasPrefix(name, "Subscribe"), strings.HasPrefix(name, "Consume"):
+				topics = &subscribes
+			default:
+				return true
+			}
+			for _, arg := range call.Args {
+				if literal, ok := arg.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+					if topic, err := strconv.Unquote(literal.Value); err == nil && topic != "" {
+						*topics = append(*topics, topic)
+					}
+					break
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the code of %s: %w", dir, err)
+	}
+	return publishes, subscribes, nil
+}
+
+// configTopics returns the topics of the messaging section of a configuration
+func configTopics(config map[string]interface{}) []string {
+	var topics []string
+	var walk func(key string, value interface{})
+	walk = func(key string, value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for name, child := range value {
+				walk(name, child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(key, child)
+			}
+		case string:
+			if key == "topic" || key == "topics" {
+				topics = append(topics, value)
+			}
+		}
+	}
+	walk("", config["messaging"])
+	return topics
+}
+
+// serviceReference is a host a service refers to in its configuration
+type serviceReference struct {
+	host string
+	kind string
+	// key is where the reference was found, as configs/config.yaml:clients.user.url
+	key string
+}
+
+// hostPortPattern matches host:port values, whose host may name a service
+var hostPortPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9.-]*):[0-9]+$`)
+
+// serviceReferences returns the hosts the configs and the .env.example of a service refer
+// to, and the names its discovery configuration looks up
+func serviceReferences(dir string) []serviceReference {
+	var references []serviceReference
+	configs, _ := filepath.Glob(filepath.Join(dir, "configs", "*.yaml"))
+	for _, path := range configs {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var config interface{}
+		if yaml.Unmarshal(content, &config) != nil {
+			continue
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+		var walk func(key string, value interface{}, discovery bool)
+		walk = func(key string, value interface{}, discovery bool) {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				for _, child := range sortedKeys(value) {
+					walk(strings.TrimPrefix(key+"."+child, "."), value[child], discovery || child == "discovery")
+				}
+			case []interface{}:
+				for _, child := range value {
+					walk(key, child, discovery)
+				}
+			case string:
+				if host := referencedHost(value); host != "" {
+					references = append(references, serviceReference{host: host, kind: DependencyCall, key: name + ":" + key})
+				} else if discovery {
+					references = append(references, serviceReference{host: value, kind: DependencyDiscovery, key: name + ":" + key})
+				}
+			}
+		}
+		walk("", config, false)
+	}
+
+	if file, err := os.Open(filepath.Join(dir, ".env.example")); err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+			if !ok || strings.HasPrefix(key, "#") {
+				continue
+			}
+			if host := referencedHost(strings.Trim(value, `"'`)); host != "" {
+				references = append(references, serviceReference{host: host, kind: DependencyCall, key: ".env.example:" + key})
+			}
+		}
+	}
+	return references
+}
+
+// referencedHost returns the host of a URL or host:port value, empty for other values
+func referencedHost(value string) string {
+	if strings.Contains(value, "://") {
+		if parsed, err := url.Parse(value); err == nil {
+			return parsed.Hostname()
+		}
+		return ""
+	}
+	if match := hostPortPattern.FindStringSubmatch(value); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// print writes the graph as a list of dependencies per service
+func (graph *dependencyGraph) print() {
+	fmt.Printf("%d services, %d dependencies\n\n", len(graph.Services), len(graph.Dependencies))
+	if len(graph.Dependencies) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FROM\tTO\tKIND\tVIA")
+		for _, dependency := range graph.Dependencies {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", dependency.From, dependency.To, dependency.Kind, strings.Join(dependency.Via, ", "))
+		}
+		w.Flush()
+	}
+
+	var isolated []string
+	for _, service := range graph.Services {
+		connected := false
+		for _, dependency := range graph.Dependencies {
+			if dependency.From == service.Name || dependency.To == service.Name {
+				connected = true
+				break
+			}
+		}
+		if !connected {
+			isolated = append(isolated, service.Name)
+		}
+	}
+	if len(isolated) > 0 {
+		fmt.Printf("\nWithout dependencies: %s\n", strings.Join(isolated, ", "))
+	}
+}
+
+// dot returns the graph in the Graphviz DOT language
+func (graph *dependencyGraph) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, service := range graph.Services {
+		fmt.Fprintf(&b, "  %q;\n", service.Name)
+	}
+	for _, dependency := range graph.Dependencies {
+		style := ""
+		if dependency.Kind == DependencyTopic {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q%s];\n", dependency.From, dependency.To, dependency.Kind, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidID returns a Mermaid node identifier of a service name
+var mermaidID = strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace
+
+// mermaid returns the graph as a Mermaid flowchart
+func (graph *dependencyGraph) mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, service := range graph.Services {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(service.Name), service.Name)
+	}
+	for _, dependency := range graph.Dependencies {
+		arrow := "-->"
+		if dependency.Kind == DependencyTopic {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", mermaidID(dependency.From), arrow, dependency.Kind, mermaidID(dependency.To))
+	}
+	return b.String()
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"github.com/spf13/cobra"
+)
+
+var listOutput string
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list [service-types|features|templates|targets]",
+	Short: "List what the CLI can generate",
+	Long: `List the service types, features and their providers, template packs and deployment
+targets the CLI knows. Without a subcommand everything is listed.
+
+Examples:
+  microframework list
+  microframework list features
+  microframework list targets --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printCatalog(cmd, "")
+	},
+}
+
+func init() {
+	listCmd.PersistentFlags().StringVarP(&listOutput, "output", "o", "text", "Output format (text, json)")
+
+	for _, section := range []struct{ use, alias, short string }{
+		{"service-types", "types", "List the service types of microframework new --type"},
+		{"features", "", "List the features and their providers"},
+		{"templates", "", "List the template packs"},
+		{"targets", "", "List the deployment targets of microframework deploy --target"},
+	} {
+		subcommand := &cobra.Command{
+			Use:   section.use,
+			Short: section.short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printCatalog(cmd, section.use)
+			},
+		}
+		if section.alias != "" {
+			subcommand.Aliases = []string{section.alias}
+		}
+		listCmd.AddCommand(subcommand)
+	}
+}
+
+// catalogEntry is a service type or a deployment target
+type catalogEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// catalogFeature is a feature a service can be generated with or have added
+type catalogFeature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Flag is the flag of microframework new that generates the service with the feature
+	Flag string `json:"flag,omitempty"`
+	// Add reports whether microframework add can add the feature to an existing service
+	Add       bool     `json:"add"`
+	Providers []string `json:"providers,omitempty"`
+}
+
+// catalogTemplatePack is a set of templates a service is generated from
+type catalogTemplatePack struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// catalog is everything the CLI can generate
+type catalog struct {
+	ServiceTypes  []catalogEntry        `json:"service_types,omitempty"`
+	Features      []catalogFeature      `json:"features,omitempty"`
+	TemplatePacks []catalogTemplatePack `json:"templates,omitempty"`
+	Targets       []catalogEntry        `json:"targets,omitempty"`
+}
+
+// serviceTypes are the values of microframework new --type
+var serviceTypes = []catalogEntry{
+	{"rest", "HTTP service with a REST API"},
+	{"graphql", "HTTP service with a GraphQL API"},
+	{"grpc", "gRPC service generated from protocol buffers"},
+	{"websocket", "Service holding WebSocket connections"},
+	{"event", "Service consuming and publishing events"},
+	{"scheduled", "Service running scheduled tasks"},
+	{"worker", "Background worker processing jobs"},
+	{"temporal-worker", "Temporal worker running workflows and activities"},
+	{"gateway", "API gateway in front of other services"},
+	{"proxy", "Reverse proxy to other services"},
+}
+
+// features are the features of microframework new and microframework add, in the order of
+// the flags of new
+var features = []catalogFeature{
+	{Name: "auth", Description: "Authentication", Flag: "--with-auth", Add: true, Providers: []string{"jwt", "oauth", "oidc", "ldap", "saml"}},
+	{Name: "database", Description: "Database integration and migrations", Flag: "--with-database", Add: true, Providers: []string{"postgres", "mysql", "redis", "mongodb"}},
+	{Name: "messaging", Description: "Message queues", Flag: "--with-messaging", Add: true, Providers: []string{"kafka", "rabbitmq", "nats"}},
+	{Name: "monitoring", Description: "Monitoring and observability", Flag: "--with-monitoring", Add: true, Providers: []string{"prometheus", "jaeger", "grafana"}},
+	{Name: "ai", Description: "AI services", Flag: "--with-ai", Add: true, Providers: []string{"openai", "anthropic", "google"}},
+	{Name: "storage", Description: "Object storage", Flag: "--with-storage", Add: true, Providers: []string{"s3", "gcs", "azure"}},
+	{Name: "cache", Description: "Caching", Flag: "--with-cache", Add: true, Providers: []string{"redis", "memcached", "memory"}},
+	{Name: "discovery", Description: "Service discovery", Flag: "--with-discovery", Add: true, Providers: []string{"consul", "kubernetes"}},
+	{Name: "circuitbreaker", Description: "Circuit breaker patterns", Flag: "--with-circuit-breaker", Add: true},
+	{Name: "ratelimit", Description: "Rate limiting", Flag: "--with-rate-limit", Add: true},
+	{Name: "chaos", Description: "Chaos engineering", Flag: "--with-chaos", Add: true},
+	{Name: "failover", Description: "Failover mechanisms", Flag: "--with-failover", Add: true},
+	{Name: "event", Description: "Event sourcing", Flag: "--with-event", Add: true},
+	{Name: "scheduling", Description: "Task scheduling", Flag: "--with-scheduling", Add: true},
+	{Name: "backup", Description: "Backup services", Flag: "--with-backup", Add: true, Providers: []string{"s3", "gcs", "azure"}},
+	{Name: "payment", Description: "Payment processing", Flag: "--with-payment", Add: true},
+	{Name: "filegen", Description: "File generation", Flag: "--with-filegen", Add: true},
+	{Name: "api", Description: "Third-party API integration", Flag: "--with-api", Add: true, Providers: []string{"http", "grpc", "graphql", "websocket"}},
+	{Name: "email", Description: "Email services", Flag: "--with-email", Add: true, Providers: []string{"smtp", "sendgrid", "mailgun"}},
+	{Name: "featureflags", Description: "Feature flags", Flag: "--with-featureflags", Providers: []string{"file", "env", "remote", "openfeature"}},
+	{Name: "errortracking", Description: "Error and panic reporting", Flag: "--with-errortracking", Providers: []string{"sentry", "bugsnag"}},
+	{Name: "secrets", Description: "Production secrets read from a secrets backend", Flag: "--with-secrets", Providers: []string{"vault", "ssm", "gsm"}},
+	{Name: "communication", Description: "Communication protocols", Add: true},
+	{Name: "config", Description: "Configuration management", Add: true},
+	{Name: "logging", Description: "Logging providers", Add: true},
+	{Name: "middleware", Description: "Middleware components", Add: true},
+}
+
+// deploymentTargets are the values of microframework deploy --target
+var deploymentTargets = []catalogEntry{
+	{"docker", "Docker image run on the local daemon"},
+	{"compose", "docker-compose stack with the service's dependencies"},
+	{"kubernetes", "Kubernetes manifests applied with kubectl"},
+	{"aws", "Amazon Web Services"},
+	{"gcp", "Google Cloud Platform"},
+	{"azure", "Microsoft Azure"},
+	{"lambda", "AWS Lambda function"},
+}
+
+// templatePacks are the template packs services are generated from
+var templatePacks = []catalogTemplatePack{
+	{templates.Pack, templates.Version, "Service layout: main, configuration, handlers, models, repositories, Docker, Kubernetes, tests and documentation"},
+}
+
+// addableFeatures returns the names of the features microframework add accepts
+func addableFeatures() []string {
+	var names []string
+	for _, feature := range features {
+		if feature.Add {
+			names = append(names, feature.Name)
+		}
+	}
+	return names
+}
+
+func catalogNames(entries []catalogEntry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return names
+}
+
+// printCatalog prints a section of the catalog, or all of it for ""
+func printCatalog(cmd *cobra.Command, section string) error {
+	if listOutput != "text" && listOutput != "json" {
+		return fmt.Errorf("invalid output format %q (text, json)", listOutput)
+	}
+
+	var selected catalog
+	all := section == ""
+	if all || section == "service-types" {
+		selected.ServiceTypes = serviceTypes
+	}
+	if all || section == "features" {
+		selected.Features = features
+	}
+	if all || section == "templates" {
+		selected.TemplatePacks = templatePacks
+	}
+	if all || section == "targets" {
+		selected.Targets = deploymentTargets
+	}
+
+	if listOutput == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(selected)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	heading := func(title string) {
+		if !all {
+			return
+		}
+		fmt.Fprintf(writer, "%s\n", title)
+	}
+	if selected.ServiceTypes != nil {
+		heading("Service types (microframework new --type):")
+		for _, entry := range selected.ServiceTypes {
+			fmt.Fprintf(writer, "  %s\t%s\n", entry.Name, entry.Description)
+		}
+	}
+	if selected.Features != nil {
+		if all {
+			fmt.Fprintln(writer)
+		}
+		heading("Features (microframework new --with-<feature>, microframework add <feature>):")
+		fmt.Fprintf(writer, "  FEATURE\tNEW FLAG\tADD\tPROVIDERS\tDESCRIPTION\n")
+		for _, feature := range selected.Features {
+			flag, add, providers := "-", "no", "-"
+			if feature.Flag != "" {
+				flag = feature.Flag
+			}
+			if feature.Add {
+				add = "yes"
+			}
+			if len(feature.Providers) > 0 {
+				providers = strings.Join(feature.Providers, ", ")
+			}
+			fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%s\n", feature.Name, flag, add, providers, feature.Description)
+		}
+	}
+	if selected.TemplatePacks != nil {
+		if all {
+			fmt.Fprintln(writer)
+		}
+		heading("Template packs:")
+		for _, pack := range selected.TemplatePacks {
+			fmt.Fprintf(writer, "  %s\t%s\t%s\n", pack.Name, pack.Version, pack.Description)
+		}
+	}
+	if selected.Targets != nil {
+		if all {
+			fmt.Fprintln(writer)
+		}
+		heading("Deployment targets (microframework deploy --target):")
+		for _, entry := range selected.Targets {
+			fmt.Fprintf(writer, "  %s\t%s\n", entry.Name, entry.Description)
+		}
+	}
+	return writer.Flush()
+}
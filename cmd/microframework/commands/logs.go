@@ -0,0 +1,373 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsEnv     string
+	logsService string
+	logsFollow  bool
+	logsSince   string
+	logsTail    int
+	logsLevel   string
+	logsRaw     bool
+)
+
+// logLevels are the levels of the generated logging, from the least to the most severe
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the logs of the deployed service",
+	Long: `Show the logs of the service where an environment of the project runs.
+
+The environments are read from deployments/environments.yaml: a docker container, a
+docker-compose service, the pods of a Kubernetes deployment (selected by label, every
+container, prefixed with the pod), the CloudWatch log group of an ECS service or a Cloud Run
+service. Without the file, the
+development environment is the docker-compose service of the project.
+
+The JSON lines of the generated logging are printed as time, level, message and fields;
+other lines are printed as they are. --raw prints every line as it is.
+
+Examples:
+  microframework logs
+  microframework logs --env staging --follow
+  microframework logs --env production --since 1h --level error
+  microframework logs --tail 50 --raw`,
+	Args: cobra.NoArgs,
+	RunE: runLogs,
+}
+
+func init() {
+	logsCmd.Flags().StringVarP(&logsEnv, "env", "e", "development", "Environment of deployments/environments.yaml")
+	logsCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	logsCmd.Flags().StringVar(&logsService, "service", "", "Container, compose service, deployment or Cloud Run service (default: the environment's)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow the logs")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only the logs of the last duration (e.g. 30m, 1h, 2d)")
+	logsCmd.Flags().IntVar(&logsTail, "tail", 100, "Number of lines of each container shown first")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "Only the lines of this level or more severe (debug, info, warn, error)")
+	logsCmd.Flags().BoolVar(&logsRaw, "raw", false, "Print the lines as they are")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if logsLevel != "" && logLevelRank(logsLevel) < 0 {
+		return fmt.Errorf("invalid level %q (%s)", logsLevel, strings.Join(logLevels, ", "))
+	}
+	var since time.Duration
+	if logsSince != "" {
+		var err error
+		if since, err = parseSince(logsSince); err != nil {
+			return err
+		}
+	}
+
+	environment, err := findEnvironment(logsEnv)
+	if err != nil {
+		return err
+	}
+	if logsService != "" {
+		environment.Service = logsService
+		environment.Selector = "app=" + logsService
+	}
+	name, logArgs, err := logsCommand(environment, since)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("the %s environment runs on %s, which needs %s: %w", environment.Name, environment.Target, name, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reader, writer := io.Pipe()
+	logs := exec.CommandContext(ctx, name, logArgs...)
+	logs.Stdout, logs.Stderr = writer, writer
+	if err := logs.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	go func() {
+		writer.CloseWithError(logs.Wait())
+	}()
+
+	printer := &logPrinter{out: os.Stdout, raw: logsRaw, minLevel: logLevelRank(logsLevel), color: colorOutput()}
+	err = printer.copy(reader)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", name, strings.Join(logArgs, " "), err)
+	}
+	return nil
+}
+
+// logsCommand returns the command showing the logs of an environment
+func logsCommand(environment *deploymentEnvironment, since time.Duration) (string, []string, error) {
+	tail := strconv.Itoa(logsTail)
+	// docker and kubectl take a Go duration, which has no days
+	sinceFlag := since.String()
+
+	switch environment.Target {
+	case TargetDocker:
+		args := []string{"logs", "--tail", tail}
+		if since > 0 {
+			args = append(args, "--since", sinceFlag)
+		}
+		if logsFollow {
+			args = append(args, "--follow")
+		}
+		return "docker", append(args, environment.Service), nil
+
+	case TargetCompose:
+		compose, err := composeCommand()
+		if err != nil {
+			return "", nil, fmt.Errorf("the %s environment needs %w", environment.Name, err)
+		}
+		args := append(append([]string{}, compose[1:]...), "-f", environment.ComposeFile, "logs", "--no-log-prefix", "--tail", tail)
+		if since > 0 {
+			args = append(args, "--since", sinceFlag)
+		}
+		if logsFollow {
+			args = append(args, "--follow")
+		}
+		return compose[0], append(args, environment.Service), nil
+
+	case TargetKubernetes:
+		args := append(environment.kubectlArgs(), "logs", "--selector", environment.Selector,
+			"--all-containers", "--prefix", "--max-log-requests", "20", "--tail", tail)
+		if since > 0 {
+			args = append(args, "--since", sinceFlag)
+		}
+		if logsFollow {
+			args = append(args, "--follow")
+		}
+		return "kubectl", args, nil
+
+	case TargetECS:
+		// aws logs tail shows the last 10 minutes by default and has no line count
+		args := []string{"logs", "tail", environment.LogGroup, "--format", "short"}
+		if since > 0 {
+			args = append(args, "--since", logsSince)
+		}
+		if logsFollow {
+			args = append(args, "--follow")
+		}
+		if environment.Region != "" {
+			args = append(args, "--region", environment.Region)
+		}
+		return "aws", args, nil
+
+	case TargetCloudRun:
+		if logsFollow {
+			return "gcloud", append([]string{"beta", "run", "services", "logs", "tail", environment.Service}, environment.gcloudArgs()...), nil
+		}
+		args := append([]string{"run", "services", "logs", "read", environment.Service, "--limit", tail}, environment.gcloudArgs()...)
+		if since > 0 {
+			args = append(args, "--log-filter", fmt.Sprintf(`timestamp>="%s"`, time.Now().Add(-since).UTC().Format(time.RFC3339)))
+		}
+		return "gcloud", args, nil
+	}
+	return "", nil, fmt.Errorf("unknown target %q", environment.Target)
+}
+
+// parseSince parses a duration, which may also be a number of days (2d)
+func parseSince(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil && count > 0 {
+			return time.Duration(count) * 24 * time.Hour, nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid --since %q (e.g. 30m, 1h, 2d)", value)
+	}
+	return duration, nil
+}
+
+// logLevelRank returns the severity of a level, -1 for an unknown one
+func logLevelRank(level string) int {
+	level = strings.ToLower(level)
+	switch level {
+	case "warning":
+		level = "warn"
+	case "critical", "alert", "emergency":
+		level = "fatal"
+	case "default", "notice":
+		level = "info"
+	}
+	for i, known := range logLevels {
+		if level == known {
+			return i
+		}
+	}
+	return -1
+}
+
+// colorOutput reports whether the output is a terminal that accepts colors
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// logPrinter prints log lines, formatting the JSON ones
+type logPrinter struct {
+	out      io.Writer
+	raw      bool
+	minLevel int
+	color    bool
+}
+
+// textLevelPattern matches the level of the text format of the generated logging
+var textLevelPattern = regexp.MustCompile(`\blevel=(\w+)`)
+
+// copy prints the lines of reader until it ends
+func (p *logPrinter) copy(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		p.printLine(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func (p *logPrinter) printLine(line string) {
+	// Lines may be prefixed, by kubectl --prefix with the pod for example
+	prefix, entry := "", map[string]any(nil)
+	if start := strings.IndexByte(line, '{'); start >= 0 && strings.HasSuffix(strings.TrimSpace(line), "}") {
+		if json.Unmarshal([]byte(line[start:]), &entry) == nil {
+			prefix = line[:start]
+		} else {
+			entry = nil
+		}
+	}
+
+	if entry == nil {
+		level := ""
+		if match := textLevelPattern.FindStringSubmatch(line); match != nil {
+			level = match[1]
+		}
+		if p.filtered(level) {
+			return
+		}
+		fmt.Fprintln(p.out, line)
+		return
+	}
+
+	level := takeString(entry, "level", "severity", "lvl")
+	if p.filtered(level) {
+		return
+	}
+	if p.raw {
+		fmt.Fprintln(p.out, line)
+		return
+	}
+	timestamp := takeString(entry, "timestamp", "time", "ts")
+	message := takeString(entry, "message", "msg")
+
+	var formatted strings.Builder
+	formatted.WriteString(prefix)
+	if parsed, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		formatted.WriteString(parsed.Local().Format("2006-01-02 15:04:05.000"))
+	} else {
+		formatted.WriteString(timestamp)
+	}
+	formatted.WriteString(" " + p.formatLevel(level) + " " + message)
+
+	keys := make([]string, 0, len(entry))
+	for key := range entry {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&formatted, " %s=%s", key, formatLogValue(entry[key]))
+	}
+	fmt.Fprintln(p.out, formatted.String())
+}
+
+// filtered reports whether a line of a level is below --level. Lines without a level, such
+// as stack traces, are kept.
+func (p *logPrinter) filtered(level string) bool {
+	if p.minLevel <= 0 || level == "" {
+		return false
+	}
+	rank := logLevelRank(level)
+	return rank >= 0 && rank < p.minLevel
+}
+
+func (p *logPrinter) formatLevel(level string) string {
+	text := fmt.Sprintf("%-5s", strings.ToUpper(level))
+	if !p.color {
+		return text
+	}
+	color := ""
+	switch rank := logLevelRank(level); {
+	case rank >= logLevelRank("error"):
+		color = "31"
+	case rank == logLevelRank("warn"):
+		color = "33"
+	case rank == logLevelRank("info"):
+		color = "36"
+	default:
+		color = "90"
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
+
+// takeString removes the first of keys from entry and returns it as a string
+func takeString(entry map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := entry[key]; ok {
+			delete(entry, key)
+			if text, ok := value.(string); ok {
+				return text
+			}
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// formatLogValue formats the value of a field, quoting strings with spaces
+func formatLogValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			return strconv.Quote(value)
+		}
+		return value
+	case nil:
+		return "null"
+	case float64, bool:
+		return fmt.Sprint(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(encoded)
+	}
+}
@@ -5,17 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/anasamu/go-micro-libs/database"
 	"github.com/anasamu/go-micro-libs/database/migrations"
-	"github.com/anasamu/go-micro-libs/database/providers/cassandra"
-	"github.com/anasamu/go-micro-libs/database/providers/cockroachdb"
-	"github.com/anasamu/go-micro-libs/database/providers/influxdb"
-	"github.com/anasamu/go-micro-libs/database/providers/mongodb"
-	"github.com/anasamu/go-micro-libs/database/providers/mysql"
-	"github.com/anasamu/go-micro-libs/database/providers/postgresql"
-	"github.com/anasamu/go-micro-libs/database/providers/redis"
-	"github.com/anasamu/go-micro-libs/database/providers/sqlite"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -30,26 +23,89 @@ This command integrates with the go-micro-libs database migration system to prov
 - Create new migration files
 - Apply pending migrations (up)
 - Rollback migrations (down)
+- Migrate to a specific version (to)
 - Check migration status
 - Reset database
 - Validate migration files
+- Generate migrations from GORM model changes (diff)
+- Seed environment-specific data (seed)
+- Adopt migrations on an existing database (baseline)
+- Detect edited migrations that were already applied (verify)
 
 Examples:
   microframework migrate create add_users_table
+  microframework migrate create backfill_slugs --go
   microframework migrate up
+  microframework migrate up --steps 2
   microframework migrate down
+  microframework migrate down --steps 3
+  microframework migrate to 20240101120000
   microframework migrate status
+  microframework migrate status --output json
   microframework migrate reset
-  microframework migrate validate`,
+  microframework migrate validate
+  microframework migrate verify
+  microframework migrate verify --repair
+  microframework migrate diff add_orders
+  microframework migrate diff sync_schema --live
+  microframework migrate seed --env staging
+  microframework migrate up --seed
+  microframework migrate baseline --version 20240101120000
+  microframework migrate up --env prod
+  microframework migrate up --dry-run
+  microframework migrate up --db analytics
+
+Connection settings are read from database.providers in configs/config.yaml, overlaid
+with configs/config.<env>.yaml when --env is given. ${VAR} and ${VAR:-default} references
+are expanded, and settings missing from the configuration fall back to the POSTGRES_*,
+MYSQL_*, ... environment variables.
+
+Projects with several databases declare them by name under databases, each with a
+provider, its connection settings and a migrations directory (migrations/<name> by
+default). --db selects the database to migrate; without it the first one is used.
+
+Commands that change the schema take a migration lock first (a PostgreSQL advisory lock,
+or a row in <table>_lock on other databases), so concurrent runs from several instances
+or CI jobs wait for each other for up to --lock-timeout.
+
+Standard builds of the CLI include the postgresql, mysql and sqlite providers. The others
+are compiled in with the microframework_migrate_<provider> build tags, or all of them with
+microframework_migrate_all (make build-full).`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupOutput(cmd, args); err != nil {
+			return err
+		}
+		return loadMigrateConfig(cmd, args)
+	},
 }
 
 var (
-	migrateProvider string
-	migrateDir      string
-	migrateName     string
-	migrateConfig   string
-	migrateVerbose  bool
-	migrateTable    string
+	migrateProvider        string
+	migrateDir             string
+	migrateName            string
+	migrateConfig          string
+	migrateVerbose         bool
+	migrateTable           string
+	migrateEnv             string
+	migrateUpSteps         int
+	migrateDownSteps       int
+	migrateUpSeed          bool
+	migrateDryRun          bool
+	migrateDB              string
+	migrateLockTimeout     time.Duration
+	migrateStatusOutput    string
+	migrateBaselineVersion string
+	migrateCreateGo        bool
+	migrateVerifyRepair    bool
+	migrateVerifyYes       bool
+
+	migrateDiffModels string
+	migrateDiffLive   bool
+	migrateDiffYes    bool
+	migrateDiffName   string
+
+	// migrateProjectConfig holds the database settings of the service configuration
+	migrateProjectConfig = &projectDatabaseConfig{Providers: map[string]map[string]interface{}{}}
 )
 
 func init() {
@@ -58,30 +114,62 @@ func init() {
 	// Global flags for migrate command
 	migrateCmd.PersistentFlags().StringVar(&migrateProvider, "provider", "postgresql", "Database provider (postgresql, mysql, sqlite, cassandra, cockroachdb, influxdb, mongodb, redis)")
 	migrateCmd.PersistentFlags().StringVar(&migrateDir, "dir", "./migrations", "Migrations directory")
-	migrateCmd.PersistentFlags().StringVar(&migrateConfig, "config", "", "Configuration file path")
+	migrateCmd.PersistentFlags().StringVar(&migrateConfig, "config", "", "Configuration file path (default configs/config.yaml)")
+	migrateCmd.PersistentFlags().StringVar(&migrateEnv, "env", "", "Configuration environment; overlays configs/config.<env>.yaml")
+	migrateCmd.RegisterFlagCompletionFunc("env", completeConfigOverlays)
 	migrateCmd.PersistentFlags().BoolVar(&migrateVerbose, "verbose", false, "Enable verbose logging")
 	migrateCmd.PersistentFlags().StringVar(&migrateTable, "table", "schema_migrations", "Migration table name")
+	migrateCmd.PersistentFlags().StringVar(&migrateDB, "db", "", "Named database from the databases section of the configuration (default the first declared)")
+
+	migrateUpCmd.Flags().IntVar(&migrateUpSteps, "steps", 0, "Number of pending migrations to apply (0 applies all)")
+	migrateUpCmd.Flags().BoolVar(&migrateUpSeed, "seed", false, "Apply the seeds of the environment after migrating")
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "Number of applied migrations to roll back")
+	for _, cmd := range []*cobra.Command{migrateUpCmd, migrateDownCmd, migrateToCmd, migrateResetCmd} {
+		cmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Print the SQL that would be executed without changing the database")
+	}
+	migrateVerifyCmd.Flags().BoolVar(&migrateVerifyRepair, "repair", false, "Record the current contents of changed migration files as applied")
+	migrateVerifyCmd.Flags().BoolVarP(&migrateVerifyYes, "yes", "y", false, "Repair without asking for confirmation")
+	migrateCreateCmd.Flags().BoolVar(&migrateCreateGo, "go", false, "Create a Go migration instead of a SQL one")
+	migrateBaselineCmd.Flags().StringVar(&migrateBaselineVersion, "version", "", "Latest migration version already present in the database")
+	migrateBaselineCmd.MarkFlagRequired("version")
+	migrateStatusCmd.Flags().StringVar(&migrateStatusOutput, "output", "table", "Output format (table, json)")
+	for _, cmd := range []*cobra.Command{migrateUpCmd, migrateDownCmd, migrateToCmd, migrateResetCmd, migrateSeedCmd, migrateBaselineCmd, migrateVerifyCmd} {
+		cmd.Flags().DurationVar(&migrateLockTimeout, "lock-timeout", time.Minute, "How long to wait for a concurrent migration run to release the migration lock")
+	}
+
+	migrateDiffCmd.Flags().StringVar(&migrateDiffModels, "models", "internal/models", "Directory containing the GORM models")
+	migrateDiffCmd.Flags().BoolVar(&migrateDiffLive, "live", false, "Compare against the live database instead of the last schema snapshot")
+	migrateDiffCmd.Flags().BoolVarP(&migrateDiffYes, "yes", "y", false, "Write the migration without asking for review")
 
 	// Subcommands
 	migrateCmd.AddCommand(migrateCreateCmd)
 	migrateCmd.AddCommand(migrateUpCmd)
 	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateToCmd)
 	migrateCmd.AddCommand(migrateStatusCmd)
 	migrateCmd.AddCommand(migrateResetCmd)
 	migrateCmd.AddCommand(migrateValidateCmd)
+	migrateCmd.AddCommand(migrateDiffCmd)
+	migrateCmd.AddCommand(migrateSeedCmd)
+	migrateCmd.AddCommand(migrateBaselineCmd)
+	migrateCmd.AddCommand(migrateVerifyCmd)
 }
 
 // migrateCreateCmd creates a new migration file
 var migrateCreateCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new migration file",
-	Long:  `Create a new migration file with the specified name.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new migration file with the specified name.
+
+With --go a Go migration is created instead: a file in the migrations directory that
+registers up and down functions with github.com/anasamu/go-micro-framework/pkg/migrate.
+Go migrations run in version order together with the SQL migrations; commands that
+run them build the project's migrations package with go run.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
 		if err := runMigrateCreate(name); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating migration: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -90,11 +178,10 @@ var migrateCreateCmd = &cobra.Command{
 var migrateUpCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Apply all pending migrations",
-	Long:  `Apply all pending migrations to the database.`,
+	Long:  `Apply all pending migrations to the database, or only the next N with --steps.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runMigrateUp(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error applying migrations: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -103,11 +190,24 @@ var migrateUpCmd = &cobra.Command{
 var migrateDownCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Rollback the last migration",
-	Long:  `Rollback the last applied migration.`,
+	Long:  `Rollback the last applied migration, or the last N with --steps.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runMigrateDown(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error rolling back migration: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
+		}
+	},
+}
+
+// migrateToCmd migrates the database up or down to a specific version
+var migrateToCmd = &cobra.Command{
+	Use:   "to [version]",
+	Short: "Migrate up or down to a specific version",
+	Long: `Apply pending migrations up to and including the given version, or roll back
+every applied migration newer than it. Use version 0 to roll back all migrations.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMigrateTo(args[0]); err != nil {
+			exitWithError(err)
 		}
 	},
 }
@@ -116,11 +216,12 @@ var migrateDownCmd = &cobra.Command{
 var migrateStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
-	Long:  `Show the status of all migrations (applied and pending).`,
+	Long: `Show the version, name, state, application time and checksum of every migration.
+A migration is applied, pending, dirty (its file changed after it was applied) or
+missing (applied, but its file is gone). Use --output json for tooling.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runMigrateStatus(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting migration status: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -132,8 +233,7 @@ var migrateResetCmd = &cobra.Command{
 	Long:  `Reset the database by rolling back all migrations and then reapplying them.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runMigrateReset(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error resetting database: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -142,17 +242,135 @@ var migrateResetCmd = &cobra.Command{
 var migrateValidateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate migration files",
-	Long:  `Validate all migration files for correctness.`,
+	Long:  `Validate all migration files for correctness and check their SQL against the dialect of the database provider declared in configs/config.yaml (or --provider).`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runMigrateValidate(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error validating migrations: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
+		}
+	},
+}
+
+// migrateDiffCmd generates a migration from changes to the GORM models
+var migrateDiffCmd = &cobra.Command{
+	Use:   "diff [name]",
+	Short: "Generate a migration from GORM model changes",
+	Long: `Compare the GORM models in internal/models with the schema recorded by the previous
+diff (migrations/schema.snapshot), or with the live database when --live is given, and
+generate up and down SQL for new and dropped tables, columns, indexes and constraints.
+
+The SQL is printed for review before the migration is written; destructive changes are
+marked with "!". Against the live database only tables and columns are compared.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		migrateDiffName = "schema_changes"
+		if len(args) == 1 {
+			migrateDiffName = args[0]
+		}
+		if err := runMigrateDiff(); err != nil {
+			exitWithError(err)
 		}
 	},
 }
 
+// migrateSeedCmd applies the seed files of an environment
+var migrateSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Apply environment-specific seed data",
+	Long: `Apply the SQL files in seeds/<env>/ (seeds/development/ without --env) in name order.
+Seeds run only once all migrations are applied, and each seed is recorded in the
+schema_seeds table so running the command again only applies new seed files. A seed
+that changed after it was applied is reported but not reapplied.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMigrateSeed(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// migrateBaselineCmd marks existing migrations as applied
+var migrateBaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Mark migrations up to a version as applied without running them",
+	Long: `Record every migration up to and including --version as applied without executing
+it. Use this to adopt migrations on a database whose schema already exists; later
+migrations are applied by migrate up as usual.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMigrateBaseline(migrateBaselineVersion); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// migrateVerifyCmd checks applied migrations against their files
+var migrateVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify applied migrations against their files",
+	Long: `Recompute the checksum of every migration file and compare it with the checksum recorded
+when the migration was applied. Fails when an applied migration was edited or its file
+removed. After reviewing the changes, --repair records the current file contents.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMigrateVerify(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// loadMigrateConfig reads the service configuration and, unless --provider is given,
+// selects the provider it declares. Commands that need the Go migrations of the project
+// are handed over to a runner that has them compiled in.
+func loadMigrateConfig(cmd *cobra.Command, args []string) error {
+	if err := loadDatabaseConfig(cmd); err != nil {
+		return err
+	}
+	return delegateGoMigrations(cmd)
+}
+
+// loadDatabaseConfig reads the database settings of the configuration and, unless the flags
+// set them, selects the provider and migrations directory of the --db database
+func loadDatabaseConfig(cmd *cobra.Command) error {
+	paths, err := projectConfigPaths(migrateConfig, migrateEnv)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadProjectDatabaseConfig(paths)
+	if err != nil {
+		return &UserError{fmt.Errorf("failed to load configuration: %w", err)}
+	}
+	migrateProjectConfig = config
+
+	target, err := config.target(migrateDB)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		config.useTarget(target)
+		if !cmd.Flags().Changed("provider") {
+			migrateProvider = target.Provider
+		}
+		if !cmd.Flags().Changed("dir") {
+			migrateDir = target.Dir
+		}
+	} else if !cmd.Flags().Changed("provider") {
+		if provider := config.defaultProvider(); provider != "" {
+			migrateProvider = provider
+		}
+	}
+	return nil
+}
+
 // runMigrateCreate creates a new migration file
 func runMigrateCreate(name string) error {
+	if migrateCreateGo {
+		path, err := createGoMigration(name)
+		if err != nil {
+			return err
+		}
+		reportFiles(path)
+		fmt.Printf("Go migration '%s' created in %s\n", name, path)
+		return nil
+	}
+
 	// Setup logger
 	logger := setupLogger()
 
@@ -186,202 +404,115 @@ func runMigrateCreate(name string) error {
 	return nil
 }
 
-// runMigrateUp applies all pending migrations
+// runMigrateUp applies pending migrations, all of them unless --steps limits the count
 func runMigrateUp() error {
-	// Setup logger
-	logger := setupLogger()
-
-	// Create database manager
-	config := database.DefaultManagerConfig()
-	databaseManager := database.NewDatabaseManager(config, logger)
-
-	// Create and register provider
-	provider, err := createProvider(migrateProvider, logger)
+	plan, err := runMigratePlan(func(available, applied []migrations.Migration) (migrationPlan, error) {
+		return planUp(available, applied, migrateUpSteps, ""), nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create provider: %w", err)
-	}
-
-	if err := databaseManager.RegisterProvider(provider); err != nil {
-		return fmt.Errorf("failed to register provider: %w", err)
-	}
-
-	// Connect to database
-	ctx := context.Background()
-	if err := databaseManager.Connect(ctx, migrateProvider); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer databaseManager.Close()
-
-	// Get provider and create CLI manager
-	dbProvider, err := databaseManager.GetProvider(migrateProvider)
-	if err != nil {
-		return fmt.Errorf("failed to get provider: %w", err)
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
-
-	cliManager := migrations.NewCLIManager(dbProvider, migrateDir, logger)
-
-	// Set custom migration table name if provided
-	if migrateTable != "schema_migrations" {
-		if err := cliManager.SetMigrationTableName(migrateTable); err != nil {
-			return fmt.Errorf("failed to set migration table name: %w", err)
+	if migrateDryRun {
+		if migrateUpSeed {
+			fmt.Println("-- Seeds are not previewed by --dry-run")
 		}
+		return nil
 	}
 
-	// Apply migrations
-	if err := cliManager.Up(ctx); err != nil {
-		return fmt.Errorf("failed to apply migrations: %w", err)
+	if len(plan.Migrations) == 0 {
+		fmt.Println("No pending migrations")
+	} else {
+		fmt.Printf("%d migrations applied successfully\n", len(plan.Migrations))
 	}
 
-	fmt.Println("Migrations applied successfully")
+	if migrateUpSeed {
+		return runMigrateSeed()
+	}
 	return nil
 }
 
-// runMigrateDown rolls back the last migration
+// runMigrateDown rolls back the last applied migrations, one unless --steps says otherwise
 func runMigrateDown() error {
-	// Setup logger
-	logger := setupLogger()
-
-	// Create database manager
-	config := database.DefaultManagerConfig()
-	databaseManager := database.NewDatabaseManager(config, logger)
-
-	// Create and register provider
-	provider, err := createProvider(migrateProvider, logger)
-	if err != nil {
-		return fmt.Errorf("failed to create provider: %w", err)
+	steps := migrateDownSteps
+	if steps <= 0 {
+		steps = 1
 	}
 
-	if err := databaseManager.RegisterProvider(provider); err != nil {
-		return fmt.Errorf("failed to register provider: %w", err)
-	}
-
-	// Connect to database
-	ctx := context.Background()
-	if err := databaseManager.Connect(ctx, migrateProvider); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer databaseManager.Close()
-
-	// Get provider and create CLI manager
-	dbProvider, err := databaseManager.GetProvider(migrateProvider)
+	plan, err := runMigratePlan(func(available, applied []migrations.Migration) (migrationPlan, error) {
+		return planDown(applied, steps, ""), nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get provider: %w", err)
+		return fmt.Errorf("failed to rollback migrations: %w", err)
 	}
-
-	cliManager := migrations.NewCLIManager(dbProvider, migrateDir, logger)
-
-	// Set custom migration table name if provided
-	if migrateTable != "schema_migrations" {
-		if err := cliManager.SetMigrationTableName(migrateTable); err != nil {
-			return fmt.Errorf("failed to set migration table name: %w", err)
-		}
+	if migrateDryRun {
+		return nil
 	}
 
-	// Rollback migration
-	if err := cliManager.Down(ctx); err != nil {
-		return fmt.Errorf("failed to rollback migration: %w", err)
+	if len(plan.Migrations) == 0 {
+		fmt.Println("No migrations to roll back")
+		return nil
 	}
-
-	fmt.Println("Migration rolled back successfully")
+	fmt.Printf("%d migrations rolled back successfully\n", len(plan.Migrations))
 	return nil
 }
 
-// runMigrateStatus shows the status of all migrations
-func runMigrateStatus() error {
-	// Setup logger
-	logger := setupLogger()
-
-	// Create database manager
-	config := database.DefaultManagerConfig()
-	databaseManager := database.NewDatabaseManager(config, logger)
-
-	// Create and register provider
-	provider, err := createProvider(migrateProvider, logger)
+// runMigrateTo applies or rolls back migrations until target is the latest applied version
+func runMigrateTo(target string) error {
+	plan, err := runMigratePlan(func(available, applied []migrations.Migration) (migrationPlan, error) {
+		return planTo(available, applied, target)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create provider: %w", err)
-	}
-
-	if err := databaseManager.RegisterProvider(provider); err != nil {
-		return fmt.Errorf("failed to register provider: %w", err)
-	}
-
-	// Connect to database
-	ctx := context.Background()
-	if err := databaseManager.Connect(ctx, migrateProvider); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return fmt.Errorf("failed to migrate to %s: %w", target, err)
 	}
-	defer databaseManager.Close()
-
-	// Get provider and create CLI manager
-	dbProvider, err := databaseManager.GetProvider(migrateProvider)
-	if err != nil {
-		return fmt.Errorf("failed to get provider: %w", err)
-	}
-
-	cliManager := migrations.NewCLIManager(dbProvider, migrateDir, logger)
-
-	// Set custom migration table name if provided
-	if migrateTable != "schema_migrations" {
-		if err := cliManager.SetMigrationTableName(migrateTable); err != nil {
-			return fmt.Errorf("failed to set migration table name: %w", err)
-		}
+	if migrateDryRun {
+		return nil
 	}
 
-	// Show status
-	if err := cliManager.Status(ctx); err != nil {
-		return fmt.Errorf("failed to get migration status: %w", err)
+	if len(plan.Migrations) == 0 {
+		fmt.Printf("Database is already at version %s\n", target)
+		return nil
 	}
-
+	fmt.Printf("Database migrated %s to version %s (%d migrations)\n", plan.Direction, target, len(plan.Migrations))
 	return nil
 }
 
-// runMigrateReset resets the database and reapplies all migrations
+// runMigrateReset rolls back every applied migration and applies all migrations again
 func runMigrateReset() error {
-	// Setup logger
 	logger := setupLogger()
+	ctx := context.Background()
 
-	// Create database manager
-	config := database.DefaultManagerConfig()
-	databaseManager := database.NewDatabaseManager(config, logger)
-
-	// Create and register provider
-	provider, err := createProvider(migrateProvider, logger)
+	session, err := openMigrationSession(ctx, logger)
 	if err != nil {
-		return fmt.Errorf("failed to create provider: %w", err)
-	}
-
-	if err := databaseManager.RegisterProvider(provider); err != nil {
-		return fmt.Errorf("failed to register provider: %w", err)
+		return err
 	}
+	defer session.Close()
 
-	// Connect to database
-	ctx := context.Background()
-	if err := databaseManager.Connect(ctx, migrateProvider); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if !migrateDryRun {
+		unlock, err := lockMigrations(ctx, session)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
-	defer databaseManager.Close()
 
-	// Get provider and create CLI manager
-	dbProvider, err := databaseManager.GetProvider(migrateProvider)
+	available, applied, err := loadMigrationState(ctx, session)
 	if err != nil {
-		return fmt.Errorf("failed to get provider: %w", err)
+		return err
 	}
 
-	cliManager := migrations.NewCLIManager(dbProvider, migrateDir, logger)
-
-	// Set custom migration table name if provided
-	if migrateTable != "schema_migrations" {
-		if err := cliManager.SetMigrationTableName(migrateTable); err != nil {
-			return fmt.Errorf("failed to set migration table name: %w", err)
-		}
+	if !migrateDryRun {
+		logger.Warn("Resetting database - this will drop all data!")
 	}
-
-	// Reset database
-	if err := cliManager.Reset(ctx); err != nil {
+	if err := executePlan(ctx, session, planDown(applied, 0, "")); err != nil {
+		return fmt.Errorf("failed to reset database: %w", err)
+	}
+	if err := executePlan(ctx, session, planUp(available, nil, 0, "")); err != nil {
 		return fmt.Errorf("failed to reset database: %w", err)
 	}
 
-	fmt.Println("Database reset successfully")
+	if !migrateDryRun {
+		fmt.Println("Database reset successfully")
+	}
 	return nil
 }
 
@@ -408,7 +539,19 @@ func runMigrateValidate() error {
 
 	// Validate migrations
 	if err := cliManager.Validate(); err != nil {
-		return fmt.Errorf("migration validation failed: %w", err)
+		return &ValidationFailure{fmt.Errorf("migration validation failed: %w", err)}
+	}
+
+	// Check the SQL against the dialect of the provider
+	issues, err := checkMigrations(migrateDir, migrateProvider)
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		printValidationIssues(issues)
+		if errorCount := countIssuesAtLeast(issues, SeverityError); errorCount > 0 {
+			return &ValidationFailure{fmt.Errorf("%d migration errors for provider %s", errorCount, migrateProvider)}
+		}
 	}
 
 	fmt.Println("All migrations are valid")
@@ -426,117 +569,30 @@ func setupLogger() *logrus.Logger {
 	return logger
 }
 
-// createProvider creates a database provider based on the provider name
-func createProvider(providerName string, logger *logrus.Logger) (database.DatabaseProvider, error) {
-	switch providerName {
-	case "postgresql":
-		provider := postgresql.NewProvider(logger)
-		config := map[string]interface{}{
-			"host":     getEnv("POSTGRES_HOST", "localhost"),
-			"port":     getEnvInt("POSTGRES_PORT", 5432),
-			"user":     getEnv("POSTGRES_USER", "postgres"),
-			"password": getEnv("POSTGRES_PASSWORD", "password"),
-			"database": getEnv("POSTGRES_DATABASE", "testdb"),
-			"ssl_mode": getEnv("POSTGRES_SSL_MODE", "disable"),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
-
-	case "mysql":
-		provider := mysql.NewProvider(logger)
-		config := map[string]interface{}{
-			"host":     getEnv("MYSQL_HOST", "localhost"),
-			"port":     getEnvInt("MYSQL_PORT", 3306),
-			"user":     getEnv("MYSQL_USER", "root"),
-			"password": getEnv("MYSQL_PASSWORD", "password"),
-			"database": getEnv("MYSQL_DATABASE", "testdb"),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
+// migrateProviderFactory builds a database provider, with the settings of the service
+// configuration over the environment variable defaults
+type migrateProviderFactory func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error)
 
-	case "sqlite":
-		provider := sqlite.NewProvider(logger)
-		config := map[string]interface{}{
-			"file": getEnv("SQLITE_FILE", "./test.db"),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
-
-	case "cassandra":
-		provider := cassandra.NewProvider(logger)
-		config := map[string]interface{}{
-			"hosts":       []string{getEnv("CASSANDRA_HOST", "localhost")},
-			"keyspace":    getEnv("CASSANDRA_KEYSPACE", "test_keyspace"),
-			"username":    getEnv("CASSANDRA_USERNAME", ""),
-			"password":    getEnv("CASSANDRA_PASSWORD", ""),
-			"consistency": getEnv("CASSANDRA_CONSISTENCY", "quorum"),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
-
-	case "cockroachdb":
-		provider := cockroachdb.NewProvider(logger)
-		config := map[string]interface{}{
-			"host":     getEnv("COCKROACHDB_HOST", "localhost"),
-			"port":     getEnvInt("COCKROACHDB_PORT", 26257),
-			"user":     getEnv("COCKROACHDB_USER", "root"),
-			"password": getEnv("COCKROACHDB_PASSWORD", ""),
-			"database": getEnv("COCKROACHDB_DATABASE", "defaultdb"),
-			"ssl_mode": getEnv("COCKROACHDB_SSL_MODE", "require"),
-			"cluster":  getEnv("COCKROACHDB_CLUSTER", ""),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
-
-	case "influxdb":
-		provider := influxdb.NewProvider(logger)
-		config := map[string]interface{}{
-			"url":    getEnv("INFLUXDB_URL", "http://localhost:8086"),
-			"token":  getEnv("INFLUXDB_TOKEN", ""),
-			"org":    getEnv("INFLUXDB_ORG", ""),
-			"bucket": getEnv("INFLUXDB_BUCKET", ""),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
+// migrateProviders are the providers compiled into this build, registered by the
+// migrate_provider_*.go files according to the build tags
+var migrateProviders = map[string]migrateProviderFactory{}
 
-	case "mongodb":
-		provider := mongodb.NewProvider(logger)
-		config := map[string]interface{}{
-			"uri":      getEnv("MONGO_URI", "mongodb://localhost:27017"),
-			"database": getEnv("MONGO_DATABASE", "testdb"),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
-
-	case "redis":
-		provider := redis.NewProvider(logger)
-		config := map[string]interface{}{
-			"host": getEnv("REDIS_HOST", "localhost"),
-			"port": getEnvInt("REDIS_PORT", 6379),
-			"db":   getEnvInt("REDIS_DB", 0),
-		}
-		if err := provider.Configure(config); err != nil {
-			return nil, err
-		}
-		return provider, nil
+// registerMigrateProvider makes a provider available to migrate
+func registerMigrateProvider(name string, factory migrateProviderFactory) {
+	migrateProviders[name] = factory
+}
 
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", providerName)
-	}
+// createProvider creates a database provider based on the provider name. Settings from the
+// service configuration take precedence over the environment variable defaults.
+func createProvider(providerName string, logger *logrus.Logger) (database.DatabaseProvider, error) {
+	factory, ok := migrateProviders[providerName]
+	switch {
+	case ok:
+		return factory(logger, migrateProjectConfig.connectionSettings(providerName))
+	case containsString(migrateProviderNames, providerName):
+		return nil, &EnvironmentError{fmt.Errorf("the %s provider is not compiled into this build of microframework (build it with -tags microframework_migrate_%s)", providerName, providerName)}
+	}
+	return nil, &UserError{fmt.Errorf("unsupported provider: %s", providerName)}
 }
 
 // getEnv gets an environment variable with a default value
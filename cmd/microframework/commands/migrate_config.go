@@ -0,0 +1,368 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migrateProviderNames are the providers createProvider can build, in order of preference
+// when the project configuration declares several
+var migrateProviderNames = []string{"postgresql", "cockroachdb", "mysql", "sqlite", "cassandra", "mongodb", "influxdb", "redis"}
+
+// mysqlDSNPattern matches go-sql-driver DSNs such as user:pass@tcp(host:3306)/db
+var mysqlDSNPattern = regexp.MustCompile(`^(?:([^:@]*)(?::([^@]*))?@)?(?:tcp\()?([^:/()]*)(?::(\d+))?\)?/([^?]*)`)
+
+// projectDatabaseConfig is the database section of the service configuration
+type projectDatabaseConfig struct {
+	// Providers holds the settings of each provider under database.providers
+	Providers map[string]map[string]interface{}
+	// Order lists the provider names in the order they are declared
+	Order []string
+	// Targets holds the named databases declared under databases
+	Targets map[string]*databaseTarget
+	// TargetOrder lists the target names in the order they are declared
+	TargetOrder []string
+}
+
+// databaseTarget is a named database with its own provider, settings and migrations, e.g.
+//
+//	databases:
+//	  primary:
+//	    provider: postgresql
+//	    url: ${DATABASE_URL}
+//	  analytics:
+//	    provider: influxdb
+//	    migrations: migrations/analytics
+type databaseTarget struct {
+	Name     string
+	Provider string
+	// Dir is the migrations directory of the target, migrations/<name> by default
+	Dir string
+	// Settings are the connection settings, overriding database.providers.<provider>
+	Settings map[string]interface{}
+}
+
+// projectConfigPaths returns the configuration files to read: configs/config.yaml overlaid
+// with configs/config.<env>.yaml, or only the explicitly given file
+func projectConfigPaths(explicit, env string) ([]string, error) {
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", explicit, err)
+		}
+		return []string{explicit}, nil
+	}
+
+	var paths []string
+	if _, err := os.Stat(projectConfigFile); err == nil {
+		paths = append(paths, projectConfigFile)
+	}
+	if env != "" {
+		envFile := filepath.Join(filepath.Dir(projectConfigFile), fmt.Sprintf("config.%s.yaml", env))
+		if _, err := os.Stat(envFile); err != nil {
+			return nil, fmt.Errorf("no configuration for environment %q: %s not found", env, envFile)
+		}
+		paths = append(paths, envFile)
+	}
+	return paths, nil
+}
+
+// loadProjectDatabaseConfig reads database.providers from the given files, later files
+// overriding earlier ones key by key, with ${VAR} and ${VAR:-default} expanded
+func loadProjectDatabaseConfig(paths []string) (*projectDatabaseConfig, error) {
+	config := &projectDatabaseConfig{
+		Providers: map[string]map[string]interface{}{},
+		Targets:   map[string]*databaseTarget{},
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		if err := config.loadTargets(yamlMappingValue(document.Content[0], "databases"), path); err != nil {
+			return nil, err
+		}
+
+		providers := yamlMappingValue(yamlMappingValue(document.Content[0], "database"), "providers")
+		if providers == nil || providers.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(providers.Content); i += 2 {
+			name := providers.Content[i].Value
+			var settings map[string]interface{}
+			if err := providers.Content[i+1].Decode(&settings); err != nil {
+				return nil, fmt.Errorf("invalid database.providers.%s in %s: %w", name, path, err)
+			}
+
+			if _, ok := config.Providers[name]; !ok {
+				config.Providers[name] = map[string]interface{}{}
+				config.Order = append(config.Order, name)
+			}
+			for key, value := range settings {
+				config.Providers[name][key] = expandConfigValue(value)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// loadTargets merges the named databases of one configuration file into c
+func (c *projectDatabaseConfig) loadTargets(databases *yaml.Node, path string) error {
+	if databases == nil || databases.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(databases.Content); i += 2 {
+		name := databases.Content[i].Value
+		var settings map[string]interface{}
+		if err := databases.Content[i+1].Decode(&settings); err != nil {
+			return fmt.Errorf("invalid databases.%s in %s: %w", name, path, err)
+		}
+
+		target, ok := c.Targets[name]
+		if !ok {
+			target = &databaseTarget{Name: name, Dir: filepath.Join("migrations", name), Settings: map[string]interface{}{}}
+			c.Targets[name] = target
+			c.TargetOrder = append(c.TargetOrder, name)
+		}
+		for key, value := range settings {
+			value = expandConfigValue(value)
+			switch key {
+			case "provider":
+				target.Provider = fmt.Sprint(value)
+			case "migrations":
+				target.Dir = fmt.Sprint(value)
+			default:
+				target.Settings[key] = value
+			}
+		}
+	}
+
+	for _, name := range c.TargetOrder {
+		if c.Targets[name].Provider == "" {
+			return fmt.Errorf("databases.%s in %s has no provider", name, path)
+		}
+	}
+	return nil
+}
+
+// target returns the named database; without a name it returns the first declared one,
+// or nil when the configuration declares no databases
+func (c *projectDatabaseConfig) target(name string) (*databaseTarget, error) {
+	if name == "" {
+		if len(c.TargetOrder) == 0 {
+			return nil, nil
+		}
+		name = c.TargetOrder[0]
+	}
+
+	target, ok := c.Targets[name]
+	if !ok {
+		if len(c.TargetOrder) == 0 {
+			return nil, &UserError{fmt.Errorf("unknown database %q: no databases are declared in the configuration", name)}
+		}
+		return nil, &UserError{fmt.Errorf("unknown database %q. Declared databases: %s", name, strings.Join(c.TargetOrder, ", "))}
+	}
+	return target, nil
+}
+
+// useTarget makes the connection settings of target those of its provider
+func (c *projectDatabaseConfig) useTarget(target *databaseTarget) {
+	settings := map[string]interface{}{}
+	for key, value := range c.Providers[target.Provider] {
+		settings[key] = value
+	}
+	// A url given for the target replaces the one of the provider
+	if _, ok := target.Settings["url"]; ok {
+		for _, key := range []string{"host", "port", "user", "password", "database", "db", "uri", "file"} {
+			delete(settings, key)
+		}
+	}
+	for key, value := range target.Settings {
+		settings[key] = value
+	}
+
+	if _, ok := c.Providers[target.Provider]; !ok {
+		c.Order = append(c.Order, target.Provider)
+	}
+	c.Providers[target.Provider] = settings
+}
+
+// defaultProvider returns the provider migrations should target: the first declared SQL
+// provider, otherwise the first declared provider the CLI supports
+func (c *projectDatabaseConfig) defaultProvider() string {
+	for _, name := range c.Order {
+		if _, ok := providerDialects[name]; ok {
+			return name
+		}
+	}
+	for _, name := range c.Order {
+		if containsString(migrateProviderNames, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// connectionSettings returns the provider settings in the form its Configure method expects;
+// a url entry is split into host, port, user, password and database
+func (c *projectDatabaseConfig) connectionSettings(provider string) map[string]interface{} {
+	settings := map[string]interface{}{}
+	declared, ok := c.Providers[provider]
+	if !ok {
+		return settings
+	}
+
+	if raw, ok := declared["url"].(string); ok && raw != "" {
+		for key, value := range databaseURLSettings(provider, raw) {
+			settings[key] = value
+		}
+	}
+	for key, value := range declared {
+		if key == "url" {
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// mergeDatabaseConfig overlays project settings on the environment defaults. Empty values,
+// such as unset ${VAR} references, keep the environment value.
+func mergeDatabaseConfig(defaults, project map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range project {
+		if s, ok := value.(string); ok && s == "" {
+			continue
+		}
+		merged[key] = coerceConfigValue(key, value, defaults[key])
+	}
+	return merged
+}
+
+// coerceConfigValue converts YAML and URL values to the Go types the providers assert on
+func coerceConfigValue(key string, value, current interface{}) interface{} {
+	s, isString := value.(string)
+	switch current.(type) {
+	case int:
+		if isString {
+			if n, err := strconv.Atoi(s); err == nil {
+				return n
+			}
+		}
+	case []string:
+		if isString {
+			return strings.Split(s, ",")
+		}
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		values := make([]string, 0, len(list))
+		for _, v := range list {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	}
+	if isString && (strings.HasSuffix(key, "timeout") || strings.HasSuffix(key, "lifetime") || strings.HasSuffix(key, "idle_time")) {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	return value
+}
+
+// databaseURLSettings splits a connection URL into provider settings
+func databaseURLSettings(provider, raw string) map[string]interface{} {
+	switch provider {
+	case "mongodb":
+		return map[string]interface{}{"uri": raw}
+	case "influxdb":
+		return map[string]interface{}{"url": raw}
+	case "sqlite":
+		return map[string]interface{}{"file": strings.TrimPrefix(strings.TrimPrefix(raw, "sqlite://"), "file:")}
+	}
+
+	settings := map[string]interface{}{}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		// MySQL drivers use user:pass@tcp(host:port)/db rather than a URL
+		if match := mysqlDSNPattern.FindStringSubmatch(raw); match != nil {
+			settings["user"], settings["password"], settings["host"] = match[1], match[2], match[3]
+			if match[4] != "" {
+				settings["port"] = match[4]
+			}
+			settings["database"] = match[5]
+		}
+		return settings
+	}
+
+	settings["host"] = parsed.Hostname()
+	if port := parsed.Port(); port != "" {
+		settings["port"] = port
+	}
+	if parsed.User != nil {
+		settings["user"] = parsed.User.Username()
+		if password, ok := parsed.User.Password(); ok {
+			settings["password"] = password
+		}
+	}
+
+	path := strings.TrimPrefix(parsed.Path, "/")
+	if provider == "redis" {
+		if path != "" {
+			settings["db"] = path
+		}
+		return settings
+	}
+	if path != "" {
+		settings["database"] = path
+	}
+	if sslMode := parsed.Query().Get("sslmode"); sslMode != "" {
+		settings["ssl_mode"] = sslMode
+	}
+	return settings
+}
+
+// expandConfigValue expands ${VAR} and ${VAR:-default} references in string values
+func expandConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return os.Expand(v, func(name string) string {
+			if i := strings.Index(name, ":-"); i >= 0 {
+				if env := os.Getenv(name[:i]); env != "" {
+					return env
+				}
+				return name[i+2:]
+			}
+			return os.Getenv(name)
+		})
+	case []interface{}:
+		for i := range v {
+			v[i] = expandConfigValue(v[i])
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,801 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anasamu/go-micro-libs/database/migrations"
+)
+
+// schemaSnapshotFile records the schema the models had when the last diff was written.
+// It deliberately does not use the .json extension so it is not loaded as a migration.
+const schemaSnapshotFile = "schema.snapshot"
+
+// schemaSnapshot is the database schema derived from the GORM models
+type schemaSnapshot struct {
+	Tables map[string]*schemaTable `json:"tables"`
+}
+
+// schemaTable is a table with its columns in declaration order
+type schemaTable struct {
+	Name    string         `json:"name"`
+	Columns []schemaColumn `json:"columns"`
+	Indexes []schemaIndex  `json:"indexes,omitempty"`
+}
+
+// schemaColumn is a single table column
+type schemaColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	NotNull    bool   `json:"not_null,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+	Default    string `json:"default,omitempty"`
+}
+
+// schemaIndex is an index over one or more columns
+type schemaIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// schemaChange is one difference between two schemas with the SQL applying and reverting it
+type schemaChange struct {
+	Summary     string
+	Up          string
+	Down        string
+	Destructive bool
+}
+
+func (t *schemaTable) column(name string) *schemaColumn {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *schemaTable) index(name string) *schemaIndex {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// runMigrateDiff compares the models with the last snapshot or the live database and
+// writes a migration for the differences after review
+func runMigrateDiff() error {
+	dialect, ok := providerDialects[migrateProvider]
+	if !ok {
+		return fmt.Errorf("migrate diff needs a SQL provider, got %s", migrateProvider)
+	}
+
+	desired, err := loadModelSchema(migrateDiffModels, dialect)
+	if err != nil {
+		return fmt.Errorf("failed to read models: %w", err)
+	}
+	if len(desired.Tables) == 0 {
+		return fmt.Errorf("no GORM models found in %s", migrateDiffModels)
+	}
+
+	var current *schemaSnapshot
+	if migrateDiffLive {
+		current, err = loadLiveSchema()
+	} else {
+		current, err = loadSchemaSnapshot(filepath.Join(migrateDir, schemaSnapshotFile))
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := diffSchemas(current, desired, dialect, migrateDiffLive)
+	if len(changes) == 0 {
+		fmt.Println("Models and schema are in sync; no migration needed")
+		return nil
+	}
+
+	var up, down []string
+	fmt.Printf("%d schema changes detected:\n", len(changes))
+	for _, change := range changes {
+		marker := " "
+		if change.Destructive {
+			marker = "!"
+		}
+		fmt.Printf(" %s %s\n", marker, change.Summary)
+		up = append(up, change.Up)
+	}
+	// Down statements revert the changes in reverse order
+	for i := len(changes) - 1; i >= 0; i-- {
+		down = append(down, changes[i].Down)
+	}
+
+	upSQL, downSQL := strings.Join(up, "\n"), strings.Join(down, "\n")
+	fmt.Printf("\n-- up\n%s\n\n-- down\n%s\n\n", upSQL, downSQL)
+
+	if !migrateDiffYes && !confirm("Write this migration?") {
+		fmt.Println("Migration discarded")
+		return nil
+	}
+
+	path, err := writeMigrationFile(migrateDir, migrateDiffName, upSQL, downSQL)
+	if err != nil {
+		return err
+	}
+	if err := writeSchemaSnapshot(filepath.Join(migrateDir, schemaSnapshotFile), desired); err != nil {
+		return fmt.Errorf("failed to write schema snapshot: %w", err)
+	}
+
+	reportFiles(path)
+	fmt.Printf("Migration written to %s\n", path)
+	return nil
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// writeMigrationFile writes a migration in the format created by `migrate create`
+func writeMigrationFile(dir, name, upSQL, downSQL string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	now := time.Now()
+	version := now.Format("20060102150405")
+	cleanName := strings.ReplaceAll(strings.ToLower(name), " ", "_")
+	cleanName = strings.ReplaceAll(cleanName, "-", "_")
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.json", version, cleanName))
+
+	data, err := json.MarshalIndent(migrations.Migration{
+		Version:     version,
+		Description: name,
+		UpSQL:       upSQL,
+		DownSQL:     downSQL,
+		CreatedAt:   now,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal migration: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return path, nil
+}
+
+func loadSchemaSnapshot(path string) (*schemaSnapshot, error) {
+	snapshot := &schemaSnapshot{Tables: map[string]*schemaTable{}}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshot, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid schema snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+func writeSchemaSnapshot(path string, snapshot *schemaSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// loadLiveSchema reads the tables and columns of the connected database. Column types are
+// not compared against the live schema because each database spells them differently.
+func loadLiveSchema() (*schemaSnapshot, error) {
+	ctx := context.Background()
+	session, err := openMigrationSession(ctx, setupLogger())
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var query string
+	switch providerDialects[migrateProvider] {
+	case DialectPostgres, DialectCockroach:
+		query = "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position"
+	case DialectMySQL:
+		query = "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position"
+	case DialectSQLite:
+		query = "SELECT m.name, p.name FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' ORDER BY m.name, p.cid"
+	}
+
+	rows, err := session.Provider.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database schema: %w", err)
+	}
+	defer rows.Close()
+
+	snapshot := &schemaSnapshot{Tables: map[string]*schemaTable{}}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("failed to read database schema: %w", err)
+		}
+		if table == migrateTable {
+			continue
+		}
+		if snapshot.Tables[table] == nil {
+			snapshot.Tables[table] = &schemaTable{Name: table}
+		}
+		snapshot.Tables[table].Columns = append(snapshot.Tables[table].Columns, schemaColumn{Name: column})
+	}
+	return snapshot, rows.Err()
+}
+
+// diffSchemas returns the changes that turn current into desired. With columnsOnly, column
+// types and indexes of existing tables are not compared.
+func diffSchemas(current, desired *schemaSnapshot, dialect string, columnsOnly bool) []schemaChange {
+	var changes []schemaChange
+
+	for _, name := range sortedTableNames(desired) {
+		want := desired.Tables[name]
+		have, exists := current.Tables[name]
+		if !exists {
+			changes = append(changes, schemaChange{
+				Summary: fmt.Sprintf("create table %s", name),
+				Up:      createTableSQL(want, dialect),
+				Down:    fmt.Sprintf("DROP TABLE %s;", name),
+			})
+			continue
+		}
+
+		for _, column := range want.Columns {
+			existing := have.column(column.Name)
+			switch {
+			case existing == nil:
+				changes = append(changes, schemaChange{
+					Summary: fmt.Sprintf("add column %s.%s", name, column.Name),
+					Up:      fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", name, columnDefinition(column, dialect)),
+					Down:    fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", name, column.Name),
+				})
+			case !columnsOnly && !strings.EqualFold(existing.Type, column.Type):
+				changes = append(changes, schemaChange{
+					Summary:     fmt.Sprintf("change column %s.%s from %s to %s", name, column.Name, existing.Type, column.Type),
+					Up:          alterColumnTypeSQL(name, column, dialect),
+					Down:        alterColumnTypeSQL(name, *existing, dialect),
+					Destructive: true,
+				})
+			}
+		}
+		for _, column := range have.Columns {
+			if want.column(column.Name) == nil {
+				changes = append(changes, schemaChange{
+					Summary:     fmt.Sprintf("drop column %s.%s", name, column.Name),
+					Up:          fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", name, column.Name),
+					Down:        fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", name, columnDefinition(column, dialect)),
+					Destructive: true,
+				})
+			}
+		}
+
+		if columnsOnly {
+			continue
+		}
+		for _, index := range want.Indexes {
+			if existing := have.index(index.Name); existing == nil || !reflect.DeepEqual(existing, &index) {
+				change := schemaChange{
+					Summary: fmt.Sprintf("create index %s on %s", index.Name, name),
+					Up:      createIndexSQL(name, index),
+					Down:    dropIndexSQL(name, index.Name, dialect),
+				}
+				if existing != nil {
+					change.Summary = fmt.Sprintf("recreate index %s on %s", index.Name, name)
+					change.Up = dropIndexSQL(name, index.Name, dialect) + "\n" + change.Up
+					change.Down = change.Down + "\n" + createIndexSQL(name, *existing)
+				}
+				changes = append(changes, change)
+			}
+		}
+		for _, index := range have.Indexes {
+			if want.index(index.Name) == nil {
+				changes = append(changes, schemaChange{
+					Summary: fmt.Sprintf("drop index %s on %s", index.Name, name),
+					Up:      dropIndexSQL(name, index.Name, dialect),
+					Down:    createIndexSQL(name, index),
+				})
+			}
+		}
+	}
+
+	for _, name := range sortedTableNames(current) {
+		if _, ok := desired.Tables[name]; !ok {
+			have := current.Tables[name]
+			down := fmt.Sprintf("-- table %s was dropped; restore it from a backup", name)
+			if len(have.Columns) > 0 && have.Columns[0].Type != "" {
+				down = createTableSQL(have, dialect)
+			}
+			changes = append(changes, schemaChange{
+				Summary:     fmt.Sprintf("drop table %s", name),
+				Up:          fmt.Sprintf("DROP TABLE %s;", name),
+				Down:        down,
+				Destructive: true,
+			})
+		}
+	}
+
+	return changes
+}
+
+func sortedTableNames(snapshot *schemaSnapshot) []string {
+	var names []string
+	for name := range snapshot.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func createTableSQL(table *schemaTable, dialect string) string {
+	var keys []string
+	for _, column := range table.Columns {
+		if column.PrimaryKey {
+			keys = append(keys, column.Name)
+		}
+	}
+	var definitions []string
+	for _, column := range table.Columns {
+		// A primary key of several columns is declared as a constraint of the table
+		if len(keys) > 1 && column.PrimaryKey {
+			column.PrimaryKey, column.NotNull = false, true
+		}
+		definitions = append(definitions, "  "+columnDefinition(column, dialect))
+	}
+	if len(keys) > 1 {
+		definitions = append(definitions, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(keys, ", ")))
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table.Name, strings.Join(definitions, ",\n"))}
+	for _, index := range table.Indexes {
+		statements = append(statements, createIndexSQL(table.Name, index))
+	}
+	return strings.Join(statements, "\n")
+}
+
+func columnDefinition(column schemaColumn, dialect string) string {
+	parts := []string{column.Name, column.Type}
+	if column.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+		if dialect == DialectSQLite && column.Type == "INTEGER" {
+			parts = append(parts, "AUTOINCREMENT")
+		}
+	}
+	if column.NotNull && !column.PrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+	if column.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if column.Default != "" {
+		parts = append(parts, "DEFAULT "+column.Default)
+	}
+	return strings.Join(parts, " ")
+}
+
+func alterColumnTypeSQL(table string, column schemaColumn, dialect string) string {
+	switch dialect {
+	case DialectMySQL:
+		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, columnDefinition(column, dialect))
+	case DialectSQLite:
+		return fmt.Sprintf("-- SQLite cannot change the type of %s.%s to %s; recreate the table", table, column.Name, column.Type)
+	default:
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, column.Name, column.Type)
+	}
+}
+
+func createIndexSQL(table string, index schemaIndex) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, index.Name, table, strings.Join(index.Columns, ", "))
+}
+
+func dropIndexSQL(table, index, dialect string) string {
+	if dialect == DialectMySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", index, table)
+	}
+	return fmt.Sprintf("DROP INDEX %s;", index)
+}
+
+// loadModelSchema derives the schema GORM would create for the models in dir
+func loadModelSchema(dir, dialect string) (*schemaSnapshot, error) {
+	fset := token.NewFileSet()
+	packages, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]*ast.StructType{}
+	tableNames := map[string]string{}
+	var order []string
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						typeSpec, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						if st, ok := typeSpec.Type.(*ast.StructType); ok {
+							structs[typeSpec.Name.Name] = st
+							order = append(order, typeSpec.Name.Name)
+						}
+					}
+				case *ast.FuncDecl:
+					if receiver, name, ok := tableNameMethod(d); ok {
+						tableNames[receiver] = name
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(order)
+
+	snapshot := &schemaSnapshot{Tables: map[string]*schemaTable{}}
+	for _, name := range order {
+		st := structs[name]
+		_, hasTableName := tableNames[name]
+		if !hasTableName && !isGormModel(st) {
+			continue
+		}
+
+		table := &schemaTable{Name: tableNames[name]}
+		if table.Name == "" {
+			table.Name = pluralize(toSnakeCase(name))
+		}
+		addModelFields(table, st, structs, dialect)
+		snapshot.Tables[table.Name] = table
+	}
+	return snapshot, nil
+}
+
+// tableNameMethod recognizes func (T) TableName() string { return "literal" }
+func tableNameMethod(fn *ast.FuncDecl) (string, string, bool) {
+	if fn.Name.Name != "TableName" || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+		return "", "", false
+	}
+	receiver := fn.Recv.List[0].Type
+	if star, ok := receiver.(*ast.StarExpr); ok {
+		receiver = star.X
+	}
+	ident, ok := receiver.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", "", false
+	}
+	literal, ok := ret.Results[0].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", "", false
+	}
+	name, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return "", "", false
+	}
+	return ident.Name, name, true
+}
+
+// isGormModel reports whether a struct embeds gorm.Model or carries gorm tags
+func isGormModel(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 && exprString(field.Type) == "gorm.Model" {
+			return true
+		}
+		if field.Tag != nil && strings.Contains(field.Tag.Value, "gorm:") {
+			return true
+		}
+	}
+	return false
+}
+
+// addModelFields appends the columns and indexes of a struct's fields to table
+func addModelFields(table *schemaTable, st *ast.StructType, structs map[string]*ast.StructType, dialect string) {
+	for _, field := range st.Fields.List {
+		typeName := exprString(field.Type)
+		tags := gormTagSettings(field.Tag)
+		if _, ignored := tags["-"]; ignored {
+			continue
+		}
+
+		// Embedded structs contribute their fields
+		if len(field.Names) == 0 {
+			if typeName == "gorm.Model" {
+				addGormModelFields(table, dialect)
+			} else if embedded, ok := structs[strings.TrimPrefix(typeName, "*")]; ok {
+				addModelFields(table, embedded, structs, dialect)
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			column, ok := modelColumn(ident.Name, typeName, tags, dialect)
+			if !ok {
+				continue
+			}
+			table.Columns = append(table.Columns, column)
+			addTagIndexes(table, column.Name, tags)
+		}
+	}
+}
+
+func addGormModelFields(table *schemaTable, dialect string) {
+	for _, field := range []struct{ name, typ, tag string }{
+		{"ID", "uint", "primaryKey"},
+		{"CreatedAt", "time.Time", ""},
+		{"UpdatedAt", "time.Time", ""},
+		{"DeletedAt", "gorm.DeletedAt", "index"},
+	} {
+		tags := parseGormTag(field.tag)
+		column, _ := modelColumn(field.name, field.typ, tags, dialect)
+		table.Columns = append(table.Columns, column)
+		addTagIndexes(table, column.Name, tags)
+	}
+}
+
+// modelColumn maps a struct field to a column; associations and unsupported types are skipped
+func modelColumn(field, typeName string, tags map[string]string, dialect string) (schemaColumn, bool) {
+	column := schemaColumn{Name: toSnakeCase(field)}
+	if name := tags["column"]; name != "" {
+		column.Name = name
+	}
+
+	_, column.PrimaryKey = tags["primarykey"]
+	if !column.PrimaryKey && field == "ID" {
+		column.PrimaryKey = true
+	}
+	_, column.NotNull = tags["not null"]
+	_, column.Unique = tags["unique"]
+	column.Default = tags["default"]
+	if column.Default != "" && !isSQLLiteral(column.Default) {
+		column.Default = "'" + strings.ReplaceAll(column.Default, "'", "''") + "'"
+	}
+
+	if explicit := tags["type"]; explicit != "" {
+		column.Type = strings.ToUpper(explicit)
+		return column, true
+	}
+
+	column.Type = sqlColumnType(typeName, tags["size"], column.PrimaryKey, dialect)
+	return column, column.Type != ""
+}
+
+// sqlColumnType maps a Go type to the column type GORM uses for it in a dialect
+func sqlColumnType(typeName, size string, primaryKey bool, dialect string) string {
+	base := strings.TrimPrefix(typeName, "*")
+	if idx := strings.LastIndex(base, "."); idx >= 0 && base != "time.Time" && base != "gorm.DeletedAt" {
+		// Qualified names other than the known ones are matched on the type name
+		base = strings.ToLower(base[idx+1:])
+	}
+
+	postgres := dialect == DialectPostgres || dialect == DialectCockroach
+	switch base {
+	case "uint", "uint64", "int64", "int", "uint32", "int32", "uint16", "int16", "uint8", "int8":
+		if primaryKey {
+			switch dialect {
+			case DialectMySQL:
+				return "BIGINT UNSIGNED AUTO_INCREMENT"
+			case DialectSQLite:
+				return "INTEGER"
+			default:
+				return "BIGSERIAL"
+			}
+		}
+		if base == "int32" || base == "uint32" || base == "int16" || base == "uint16" || base == "int8" || base == "uint8" {
+			return "INTEGER"
+		}
+		if dialect == DialectSQLite {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case "string":
+		if size != "" {
+			return "VARCHAR(" + size + ")"
+		}
+		if dialect == DialectMySQL {
+			return "VARCHAR(255)"
+		}
+		return "TEXT"
+	case "bool":
+		if dialect == DialectSQLite {
+			return "NUMERIC"
+		}
+		return "BOOLEAN"
+	case "float32":
+		return "REAL"
+	case "float64":
+		if postgres {
+			return "DOUBLE PRECISION"
+		}
+		if dialect == DialectMySQL {
+			return "DOUBLE"
+		}
+		return "REAL"
+	case "time.Time", "gorm.DeletedAt", "nulltime":
+		switch dialect {
+		case DialectMySQL:
+			return "DATETIME(3)"
+		case DialectSQLite:
+			return "DATETIME"
+		default:
+			return "TIMESTAMPTZ"
+		}
+	case "[]byte":
+		if postgres {
+			return "BYTEA"
+		}
+		return "BLOB"
+	case "uuid":
+		if postgres {
+			return "UUID"
+		}
+		if dialect == DialectMySQL {
+			return "CHAR(36)"
+		}
+		return "TEXT"
+	case "json", "jsonmap", "rawmessage":
+		if postgres {
+			return "JSONB"
+		}
+		if dialect == DialectMySQL {
+			return "JSON"
+		}
+		return "TEXT"
+	case "nullstring":
+		return sqlColumnType("string", size, false, dialect)
+	case "nullint64", "nullint32":
+		return sqlColumnType("int64", "", false, dialect)
+	case "nullbool":
+		return sqlColumnType("bool", "", false, dialect)
+	case "nullfloat64":
+		return sqlColumnType("float64", "", false, dialect)
+	}
+
+	// Slices, maps and other structs are associations, not columns
+	return ""
+}
+
+// addTagIndexes records the indexes declared by index and uniqueIndex tags
+func addTagIndexes(table *schemaTable, column string, tags map[string]string) {
+	for _, kind := range []string{"index", "uniqueindex"} {
+		value, ok := tags[kind]
+		if !ok {
+			continue
+		}
+		name := strings.SplitN(value, ",", 2)[0]
+		if name == "" {
+			name = fmt.Sprintf("idx_%s_%s", table.Name, column)
+		}
+		if existing := table.index(name); existing != nil {
+			existing.Columns = append(existing.Columns, column)
+			continue
+		}
+		table.Indexes = append(table.Indexes, schemaIndex{Name: name, Columns: []string{column}, Unique: kind == "uniqueindex"})
+	}
+}
+
+// gormTagSettings parses the gorm struct tag of a field into lower-cased keys
+func gormTagSettings(tag *ast.BasicLit) map[string]string {
+	if tag == nil {
+		return map[string]string{}
+	}
+	value, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return map[string]string{}
+	}
+	return parseGormTag(reflect.StructTag(value).Get("gorm"))
+}
+
+func parseGormTag(tag string) map[string]string {
+	settings := map[string]string{}
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value := part, ""
+		if i := strings.Index(part, ":"); i >= 0 {
+			key, value = part[:i], part[i+1:]
+		}
+		settings[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return settings
+}
+
+// isSQLLiteral reports whether a default value can be used in SQL without quoting
+func isSQLLiteral(value string) bool {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "null", "current_timestamp", "now()":
+		return true
+	}
+	return strings.HasPrefix(value, "'") || strings.HasSuffix(value, ")")
+}
+
+// exprString renders a type expression such as *time.Time or []byte
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	case *ast.MapType:
+		return "map[" + exprString(e.Key) + "]" + exprString(e.Value)
+	default:
+		return ""
+	}
+}
+
+// toSnakeCase converts a Go identifier to GORM's column naming, keeping initialisms together
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pluralize applies the English plural rules GORM's naming strategy uses for common cases
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anasamu/go-micro-framework/pkg/migrate"
+	"github.com/anasamu/go-micro-libs/database/migrations"
+	"github.com/anasamu/go-micro-libs/database/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// goMigrationMarker is stored as the SQL of Go migrations to tell them apart from SQL ones
+	goMigrationMarker = "-- go migration"
+	// goMigrationRunnerEnv is set in the runner process that has the Go migrations compiled in
+	goMigrationRunnerEnv = "MICROFRAMEWORK_GO_MIGRATIONS"
+	// goMigrationRunnerDir holds the generated runner while it runs
+	goMigrationRunnerDir = ".microframework/migrate"
+)
+
+// goMigrationRunnerTemplate is the program that runs the CLI with the Go migrations of the
+// project registered
+const goMigrationRunnerTemplate = `// Code generated by microframework migrate. DO NOT EDIT.
+
+package main
+
+import (
+	"os"
+
+	"github.com/anasamu/go-micro-framework/cmd/microframework/commands"
+
+	_ %q
+)
+
+func main() {
+	if err := commands.Execute(); err != nil {
+		commands.PrintError(err)
+		os.Exit(commands.ExitCode(err))
+	}
+}
+`
+
+// goMigrationTemplate is the file written by migrate create --go
+const goMigrationTemplate = `package migrations
+
+import (
+	"context"
+
+	"github.com/anasamu/go-micro-framework/pkg/migrate"
+	"github.com/anasamu/go-micro-libs/database/types"
+)
+
+func init() {
+	migrate.Register(%q, %q, up%[3]s, down%[3]s)
+}
+
+func up%[3]s(ctx context.Context, tx types.Transaction) error {
+	// TODO: apply the migration
+	return nil
+}
+
+func down%[3]s(ctx context.Context, tx types.Transaction) error {
+	// TODO: revert the migration
+	return nil
+}
+`
+
+// needsGoMigrations reports whether cmd plans or runs migrations, and so must see the Go
+// migrations of the project
+func needsGoMigrations(cmd *cobra.Command) bool {
+	switch cmd {
+	case migrateUpCmd, migrateDownCmd, migrateToCmd, migrateResetCmd, migrateStatusCmd, migrateBaselineCmd, migrateSeedCmd, migrateVerifyCmd:
+		return true
+	}
+	return false
+}
+
+// hasGoMigrationFiles reports whether dir contains Go migrations
+func hasGoMigrationFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && !strings.HasSuffix(entry.Name(), "_test.go") {
+			return true
+		}
+	}
+	return false
+}
+
+// delegateGoMigrations runs cmd in the Go migration runner when the migrations directory
+// has Go migrations, exiting with the runner's exit code. In the runner itself, or when there
+// are no Go migrations, it does nothing.
+func delegateGoMigrations(cmd *cobra.Command) error {
+	if os.Getenv(goMigrationRunnerEnv) != "" || !needsGoMigrations(cmd) || !hasGoMigrationFiles(migrateDir) {
+		return nil
+	}
+
+	code, err := runGoMigrationRunner()
+	if err != nil {
+		return err
+	}
+	os.Exit(code)
+	return nil
+}
+
+// runGoMigrationRunner reruns the current command in a program built from the project that
+// imports its migrations package, and returns the exit code of that run
+func runGoMigrationRunner() (int, error) {
+	modulePath := currentModulePath()
+	if modulePath == "" {
+		return 0, fmt.Errorf("Go migrations in %s need a go.mod in the current directory", migrateDir)
+	}
+	dir := filepath.ToSlash(filepath.Clean(migrateDir))
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, "..") {
+		return 0, fmt.Errorf("Go migrations must be inside the module, %s is not", migrateDir)
+	}
+
+	if err := os.MkdirAll(goMigrationRunnerDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create migration runner: %w", err)
+	}
+	defer func() {
+		os.RemoveAll(goMigrationRunnerDir)
+		// Leave the parent only when something else uses it
+		os.Remove(filepath.Dir(goMigrationRunnerDir))
+	}()
+
+	source := fmt.Sprintf(goMigrationRunnerTemplate, modulePath+"/"+dir)
+	if err := os.WriteFile(filepath.Join(goMigrationRunnerDir, "main.go"), []byte(source), 0644); err != nil {
+		return 0, fmt.Errorf("failed to create migration runner: %w", err)
+	}
+
+	// Built rather than run with go run, which exits with 1 whatever the exit code of the runner
+	runner := filepath.Join(goMigrationRunnerDir, "migrate")
+	if runtime.GOOS == "windows" {
+		runner += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", runner, "./"+goMigrationRunnerDir)
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		return 0, fmt.Errorf("failed to build Go migrations (does go.mod require github.com/anasamu/go-micro-framework?): %w", err)
+	}
+
+	run := exec.Command(runner, os.Args[1:]...)
+	run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+	run.Env = append(os.Environ(), goMigrationRunnerEnv+"=1")
+
+	if err := run.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("failed to run Go migrations: %w", err)
+	}
+	return 0, nil
+}
+
+// goMigrations returns the registered Go migrations in the form of SQL migrations, so that
+// they are planned together with them
+func goMigrations() []migrations.Migration {
+	var list []migrations.Migration
+	for _, migration := range migrate.Registered() {
+		list = append(list, migrations.Migration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			UpSQL:       goMigrationMarker,
+			DownSQL:     goMigrationMarker,
+		})
+	}
+	return list
+}
+
+// isGoMigration reports whether a migration file or record is a Go migration
+func isGoMigration(migration migrations.Migration) bool {
+	return migration.UpSQL == goMigrationMarker || migration.DownSQL == goMigrationMarker
+}
+
+// applyGoMigration runs the up function of a Go migration and records it in one transaction
+func applyGoMigration(ctx context.Context, session *migrationSession, migration migrations.Migration) error {
+	registered, ok := migrate.Lookup(migration.Version)
+	if !ok {
+		return fmt.Errorf("Go migration %s is not registered", migration.Version)
+	}
+	dialect := providerDialects[migrateProvider]
+
+	err := session.Provider.WithTransaction(ctx, func(tx types.Transaction) error {
+		if err := registered.Up(ctx, tx); err != nil {
+			return err
+		}
+
+		placeholders := make([]string, 7)
+		for i := range placeholders {
+			placeholders[i] = sqlPlaceholder(dialect, i+1)
+		}
+		now := time.Now()
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf("INSERT INTO %s (version, description, up_sql, down_sql, created_at, applied_at, checksum) VALUES (%s)",
+				migrateTable, strings.Join(placeholders, ", ")),
+			migration.Version, migration.Description, goMigrationMarker, goMigrationMarker, now, now, migrationChecksum(migration))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// rollbackGoMigration runs the down function of a Go migration and removes its record in
+// one transaction
+func rollbackGoMigration(ctx context.Context, session *migrationSession, migration migrations.Migration) error {
+	registered, ok := migrate.Lookup(migration.Version)
+	if !ok {
+		return fmt.Errorf("Go migration %s is not registered", migration.Version)
+	}
+	if registered.Down == nil {
+		return fmt.Errorf("Go migration %s has no down function and cannot be rolled back", migration.Version)
+	}
+
+	err := session.Provider.WithTransaction(ctx, func(tx types.Transaction) error {
+		if err := registered.Down(ctx, tx); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE version = %s", migrateTable, sqlPlaceholder(providerDialects[migrateProvider], 1)),
+			migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rollback migration %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// createGoMigration writes a Go migration skeleton named after name
+func createGoMigration(name string) (string, error) {
+	if err := os.MkdirAll(migrateDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	version := time.Now().Format("20060102150405")
+	cleanName := strings.ReplaceAll(strings.ReplaceAll(strings.ToLower(name), " ", "_"), "-", "_")
+	path := filepath.Join(migrateDir, fmt.Sprintf("%s_%s.go", version, cleanName))
+
+	var identifier strings.Builder
+	for _, part := range strings.Split(cleanName, "_") {
+		for i, r := range part {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				continue
+			}
+			if i == 0 {
+				r = unicode.ToUpper(r)
+			}
+			identifier.WriteRune(r)
+		}
+	}
+
+	source := fmt.Sprintf(goMigrationTemplate, version, name, identifier.String())
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return "", fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return path, nil
+}
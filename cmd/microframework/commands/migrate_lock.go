@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anasamu/go-micro-framework/pkg/migrate"
+)
+
+// lockMigrations takes the migration lock of the database, the one the services take to
+// apply their migrations at startup, waiting up to --lock-timeout for another run to release
+// it, and returns the function that releases it
+func lockMigrations(ctx context.Context, session *migrationSession) (func(), error) {
+	return migrate.Lock(ctx, session.Provider, migrate.LockOptions{
+		Table:   migrateTable,
+		Timeout: migrateLockTimeout,
+		Waiting: func(holder string) {
+			if holder == "" {
+				fmt.Println("Waiting for another migration to finish...")
+				return
+			}
+			fmt.Printf("Waiting for the migration lock held by %s...\n", holder)
+		},
+	})
+}
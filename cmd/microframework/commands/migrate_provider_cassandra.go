@@ -0,0 +1,28 @@
+//go:build microframework_migrate_all || microframework_migrate_cassandra
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/cassandra"
+	"github.com/sirupsen/logrus"
+)
+
+// Cassandra is only compiled into migrate with the microframework_migrate_all or
+// microframework_migrate_cassandra build tag
+func init() {
+	registerMigrateProvider("cassandra", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := cassandra.NewProvider(logger)
+		config := map[string]interface{}{
+			"hosts":       []string{getEnv("CASSANDRA_HOST", "localhost")},
+			"keyspace":    getEnv("CASSANDRA_KEYSPACE", "test_keyspace"),
+			"username":    getEnv("CASSANDRA_USERNAME", ""),
+			"password":    getEnv("CASSANDRA_PASSWORD", ""),
+			"consistency": getEnv("CASSANDRA_CONSISTENCY", "quorum"),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
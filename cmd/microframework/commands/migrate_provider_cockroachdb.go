@@ -0,0 +1,30 @@
+//go:build microframework_migrate_all || microframework_migrate_cockroachdb
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/cockroachdb"
+	"github.com/sirupsen/logrus"
+)
+
+// CockroachDB is only compiled into migrate with the microframework_migrate_all or
+// microframework_migrate_cockroachdb build tag
+func init() {
+	registerMigrateProvider("cockroachdb", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := cockroachdb.NewProvider(logger)
+		config := map[string]interface{}{
+			"host":     getEnv("COCKROACHDB_HOST", "localhost"),
+			"port":     getEnvInt("COCKROACHDB_PORT", 26257),
+			"user":     getEnv("COCKROACHDB_USER", "root"),
+			"password": getEnv("COCKROACHDB_PASSWORD", ""),
+			"database": getEnv("COCKROACHDB_DATABASE", "defaultdb"),
+			"ssl_mode": getEnv("COCKROACHDB_SSL_MODE", "require"),
+			"cluster":  getEnv("COCKROACHDB_CLUSTER", ""),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
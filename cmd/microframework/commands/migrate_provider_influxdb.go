@@ -0,0 +1,27 @@
+//go:build microframework_migrate_all || microframework_migrate_influxdb
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/influxdb"
+	"github.com/sirupsen/logrus"
+)
+
+// InfluxDB is only compiled into migrate with the microframework_migrate_all or
+// microframework_migrate_influxdb build tag
+func init() {
+	registerMigrateProvider("influxdb", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := influxdb.NewProvider(logger)
+		config := map[string]interface{}{
+			"url":    getEnv("INFLUXDB_URL", "http://localhost:8086"),
+			"token":  getEnv("INFLUXDB_TOKEN", ""),
+			"org":    getEnv("INFLUXDB_ORG", ""),
+			"bucket": getEnv("INFLUXDB_BUCKET", ""),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
@@ -0,0 +1,25 @@
+//go:build microframework_migrate_all || microframework_migrate_mongodb
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/mongodb"
+	"github.com/sirupsen/logrus"
+)
+
+// MongoDB is only compiled into migrate with the microframework_migrate_all or
+// microframework_migrate_mongodb build tag
+func init() {
+	registerMigrateProvider("mongodb", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := mongodb.NewProvider(logger)
+		config := map[string]interface{}{
+			"uri":      getEnv("MONGO_URI", "mongodb://localhost:27017"),
+			"database": getEnv("MONGO_DATABASE", "testdb"),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
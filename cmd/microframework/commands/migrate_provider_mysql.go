@@ -0,0 +1,28 @@
+//go:build !microframework_migrate_minimal || microframework_migrate_mysql
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+// MySQL is one of the common providers of migrate, left out of builds tagged
+// microframework_migrate_minimal
+func init() {
+	registerMigrateProvider("mysql", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := mysql.NewProvider(logger)
+		config := map[string]interface{}{
+			"host":     getEnv("MYSQL_HOST", "localhost"),
+			"port":     getEnvInt("MYSQL_PORT", 3306),
+			"user":     getEnv("MYSQL_USER", "root"),
+			"password": getEnv("MYSQL_PASSWORD", "password"),
+			"database": getEnv("MYSQL_DATABASE", "testdb"),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
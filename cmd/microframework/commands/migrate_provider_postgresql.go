@@ -0,0 +1,29 @@
+//go:build !microframework_migrate_minimal || microframework_migrate_postgresql
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/postgresql"
+	"github.com/sirupsen/logrus"
+)
+
+// PostgreSQL is one of the common providers of migrate, left out of builds tagged
+// microframework_migrate_minimal
+func init() {
+	registerMigrateProvider("postgresql", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := postgresql.NewProvider(logger)
+		config := map[string]interface{}{
+			"host":     getEnv("POSTGRES_HOST", "localhost"),
+			"port":     getEnvInt("POSTGRES_PORT", 5432),
+			"user":     getEnv("POSTGRES_USER", "postgres"),
+			"password": getEnv("POSTGRES_PASSWORD", "password"),
+			"database": getEnv("POSTGRES_DATABASE", "testdb"),
+			"ssl_mode": getEnv("POSTGRES_SSL_MODE", "disable"),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
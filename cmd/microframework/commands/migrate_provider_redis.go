@@ -0,0 +1,26 @@
+//go:build microframework_migrate_all || microframework_migrate_redis
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// Redis is only compiled into migrate with the microframework_migrate_all or
+// microframework_migrate_redis build tag
+func init() {
+	registerMigrateProvider("redis", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := redis.NewProvider(logger)
+		config := map[string]interface{}{
+			"host": getEnv("REDIS_HOST", "localhost"),
+			"port": getEnvInt("REDIS_PORT", 6379),
+			"db":   getEnvInt("REDIS_DB", 0),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
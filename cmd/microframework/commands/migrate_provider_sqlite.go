@@ -0,0 +1,24 @@
+//go:build !microframework_migrate_minimal || microframework_migrate_sqlite
+
+package commands
+
+import (
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/providers/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// SQLite is one of the common providers of migrate, left out of builds tagged
+// microframework_migrate_minimal
+func init() {
+	registerMigrateProvider("sqlite", func(logger *logrus.Logger, settings map[string]interface{}) (database.DatabaseProvider, error) {
+		provider := sqlite.NewProvider(logger)
+		config := map[string]interface{}{
+			"file": getEnv("SQLITE_FILE", "./test.db"),
+		}
+		if err := provider.Configure(mergeDatabaseConfig(config, settings)); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	})
+}
@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-libs/database/types"
+)
+
+const (
+	// seedsDir holds one directory of seed files per environment
+	seedsDir = "seeds"
+	// defaultSeedEnv is the environment seeded when --env is not given
+	defaultSeedEnv = "development"
+	// seedTable records the seeds applied to the database
+	seedTable = "schema_seeds"
+)
+
+// seedFile is a SQL file under seeds/<env>/
+type seedFile struct {
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// seedEnvironment returns the environment whose seeds are applied
+func seedEnvironment() string {
+	if migrateEnv != "" {
+		return migrateEnv
+	}
+	return defaultSeedEnv
+}
+
+// runMigrateSeed applies the seeds of the current environment once all migrations are applied
+func runMigrateSeed() error {
+	logger := setupLogger()
+	ctx := context.Background()
+
+	session, err := openMigrationSession(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	unlock, err := lockMigrations(ctx, session)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	available, err := session.CLIManager.LoadMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	applied, err := session.Manager.GetAppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	if pending := planUp(available, applied, 0, "").Migrations; len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations; run migrate up before seeding", len(pending))
+	}
+
+	return applySeeds(ctx, session)
+}
+
+// applySeeds runs the seed files of the current environment that have not been applied yet.
+// A seed is applied in a transaction together with its tracking record, so it runs at most once.
+func applySeeds(ctx context.Context, session *migrationSession) error {
+	dialect, ok := providerDialects[migrateProvider]
+	if !ok {
+		return fmt.Errorf("seeding needs a SQL provider, got %s", migrateProvider)
+	}
+
+	env := seedEnvironment()
+	seeds, err := loadSeedFiles(filepath.Join(seedsDir, env))
+	if err != nil {
+		return err
+	}
+	if len(seeds) == 0 {
+		fmt.Printf("No seeds found in %s\n", filepath.Join(seedsDir, env))
+		return nil
+	}
+
+	if err := initializeSeedTable(ctx, session, dialect); err != nil {
+		return err
+	}
+	appliedSeeds, err := appliedSeedChecksums(ctx, session, dialect, env)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, seed := range seeds {
+		checksum, done := appliedSeeds[seed.Name]
+		if done {
+			if checksum != seed.Checksum {
+				warnf("seed %s changed since it was applied and is not reapplied", seed.Name)
+			}
+			continue
+		}
+
+		fmt.Printf("Seeding %s/%s\n", env, seed.Name)
+		err := session.Provider.WithTransaction(ctx, func(tx types.Transaction) error {
+			if _, err := tx.Exec(ctx, seed.SQL); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx,
+				fmt.Sprintf("INSERT INTO %s (env, name, checksum, applied_at) VALUES (%s, %s, %s, %s)",
+					seedTable, sqlPlaceholder(dialect, 1), sqlPlaceholder(dialect, 2), sqlPlaceholder(dialect, 3), sqlPlaceholder(dialect, 4)),
+				env, seed.Name, seed.Checksum, time.Now())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply seed %s: %w", seed.Name, err)
+		}
+		count++
+	}
+
+	fmt.Printf("Applied %d seeds for %s\n", count, env)
+	return nil
+}
+
+// loadSeedFiles reads the .sql files of dir in name order; a missing directory has no seeds
+func loadSeedFiles(dir string) ([]seedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read seeds directory: %w", err)
+	}
+
+	var seeds []seedFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed %s: %w", path, err)
+		}
+		sum := sha256.Sum256(content)
+		seeds = append(seeds, seedFile{
+			Name:     entry.Name(),
+			SQL:      string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].Name < seeds[j].Name })
+	return seeds, nil
+}
+
+func initializeSeedTable(ctx context.Context, session *migrationSession, dialect string) error {
+	timestamp := "TIMESTAMP"
+	if dialect == DialectSQLite {
+		timestamp = "DATETIME"
+	}
+
+	_, err := session.Provider.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	env VARCHAR(64) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at %s NOT NULL,
+	PRIMARY KEY (env, name)
+)`, seedTable, timestamp))
+	if err != nil {
+		return fmt.Errorf("failed to create seed table: %w", err)
+	}
+	return nil
+}
+
+// appliedSeedChecksums returns the checksum of each seed applied for env, by file name
+func appliedSeedChecksums(ctx context.Context, session *migrationSession, dialect, env string) (map[string]string, error) {
+	rows, err := session.Provider.Query(ctx,
+		fmt.Sprintf("SELECT name, checksum FROM %s WHERE env = %s", seedTable, sqlPlaceholder(dialect, 1)), env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied seeds: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]string{}
+	for rows.Next() {
+		var name, checksum string
+		if err := rows.Scan(&name, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to read applied seeds: %w", err)
+		}
+		applied[name] = checksum
+	}
+	return applied, rows.Err()
+}
+
+// sqlPlaceholder returns the n-th bind parameter in the syntax of dialect
+func sqlPlaceholder(dialect string, n int) string {
+	if dialect == DialectPostgres || dialect == DialectCockroach {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
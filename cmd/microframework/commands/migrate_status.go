@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/anasamu/go-micro-libs/database/migrations"
+)
+
+// Migration states reported by migrate status
+const (
+	MigrationStateApplied = "applied"
+	MigrationStatePending = "pending"
+	// MigrationStateDirty is an applied migration whose file changed since it was applied
+	MigrationStateDirty = "dirty"
+	// MigrationStateMissing is an applied migration without a migration file
+	MigrationStateMissing = "missing"
+)
+
+// migrationStatusEntry is the state of one migration as printed by migrate status
+type migrationStatusEntry struct {
+	Version          string     `json:"version"`
+	Name             string     `json:"name"`
+	State            string     `json:"state"`
+	AppliedAt        *time.Time `json:"applied_at,omitempty"`
+	Checksum         string     `json:"checksum,omitempty"`
+	RecordedChecksum string     `json:"recorded_checksum,omitempty"`
+}
+
+// migrationChecksum identifies the SQL of a migration; it is recorded when the migration is
+// applied so later edits to the file can be detected
+func migrationChecksum(migration migrations.Migration) string {
+	sum := sha256.Sum256([]byte(migration.UpSQL + "\x00" + migration.DownSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordedChecksum returns the checksum stored for an applied migration. Migrations applied
+// before checksums were recorded are checked against the SQL stored with them.
+func recordedChecksum(migration migrations.Migration) string {
+	if migration.Checksum != "" {
+		return migration.Checksum
+	}
+	return migrationChecksum(migration)
+}
+
+// migrationStatuses combines the migration files and the applied migrations, by version
+func migrationStatuses(available, applied []migrations.Migration) []migrationStatusEntry {
+	appliedByVersion := map[string]migrations.Migration{}
+	for _, migration := range applied {
+		appliedByVersion[migration.Version] = migration
+	}
+
+	var entries []migrationStatusEntry
+	seen := map[string]bool{}
+	for _, migration := range available {
+		seen[migration.Version] = true
+		entry := migrationStatusEntry{
+			Version:  migration.Version,
+			Name:     migration.Description,
+			State:    MigrationStatePending,
+			Checksum: migrationChecksum(migration),
+		}
+		if record, ok := appliedByVersion[migration.Version]; ok {
+			entry.State = MigrationStateApplied
+			entry.AppliedAt = record.AppliedAt
+			entry.RecordedChecksum = recordedChecksum(record)
+			if entry.RecordedChecksum != entry.Checksum {
+				entry.State = MigrationStateDirty
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, record := range applied {
+		if seen[record.Version] {
+			continue
+		}
+		entries = append(entries, migrationStatusEntry{
+			Version:          record.Version,
+			Name:             record.Description,
+			State:            MigrationStateMissing,
+			AppliedAt:        record.AppliedAt,
+			RecordedChecksum: recordedChecksum(record),
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+	return entries
+}
+
+// runMigrateStatus prints the state of every migration as a table or as JSON
+func runMigrateStatus() error {
+	if migrateStatusOutput != "table" && migrateStatusOutput != "json" {
+		return &UserError{fmt.Errorf("invalid --output %q. Available formats: table, json", migrateStatusOutput)}
+	}
+
+	ctx := context.Background()
+	session, err := openMigrationSession(ctx, setupLogger())
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	available, applied, err := loadMigrationState(ctx, session)
+	if err != nil {
+		return err
+	}
+	entries := migrationStatuses(available, applied)
+
+	if migrateStatusOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No migrations found in %s\n", migrateDir)
+		return nil
+	}
+
+	counts := map[string]int{}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED AT\tCHECKSUM")
+	for _, entry := range entries {
+		counts[entry.State]++
+		appliedAt := "-"
+		if entry.AppliedAt != nil {
+			appliedAt = entry.AppliedAt.Format(time.RFC3339)
+		}
+		checksum := entry.Checksum
+		if checksum == "" {
+			checksum = entry.RecordedChecksum
+		}
+		if len(checksum) > 12 {
+			checksum = checksum[:12]
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Version, entry.Name, entry.State, appliedAt, checksum)
+	}
+	writer.Flush()
+
+	fmt.Printf("\n%d applied, %d pending, %d dirty, %d missing\n",
+		counts[MigrationStateApplied], counts[MigrationStatePending], counts[MigrationStateDirty], counts[MigrationStateMissing])
+	return nil
+}
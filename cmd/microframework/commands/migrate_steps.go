@@ -0,0 +1,344 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/migrations"
+	"github.com/sirupsen/logrus"
+)
+
+// Migration plan directions
+const (
+	MigrationDirectionUp   = "up"
+	MigrationDirectionDown = "down"
+)
+
+// migrationSession is an open connection to the database being migrated
+type migrationSession struct {
+	Manager    *migrations.MigrationManager
+	CLIManager *migrations.CLIManager
+	Provider   database.DatabaseProvider
+	close      func()
+}
+
+// Close disconnects from the database
+func (s *migrationSession) Close() {
+	s.close()
+}
+
+// migrationPlan is the ordered list of migrations a command will apply or roll back
+type migrationPlan struct {
+	Direction  string
+	Migrations []migrations.Migration
+	// Target is the version the database is at once the plan is executed, "0" for none
+	Target string
+}
+
+// migrateResult is the result of the migrate commands that apply or roll back migrations in
+// the JSON report
+type migrateResult struct {
+	Direction string `json:"direction"`
+	// Target is the version the database is at afterwards, "0" for none
+	Target string `json:"target"`
+	DryRun bool   `json:"dry_run"`
+	// Migrations are the versions applied or rolled back, in order
+	Migrations []string `json:"migrations"`
+}
+
+// openMigrationSession connects to the configured provider and prepares the migration table.
+// With --dry-run the migration table is not created.
+func openMigrationSession(ctx context.Context, logger *logrus.Logger) (*migrationSession, error) {
+	dbProvider, closeDatabase, err := connectDatabase(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &migrationSession{
+		Manager:    migrations.NewMigrationManager(dbProvider, logger),
+		CLIManager: migrations.NewCLIManager(dbProvider, migrateDir, logger),
+		Provider:   dbProvider,
+		close:      closeDatabase,
+	}
+
+	if migrateTable != "schema_migrations" {
+		if err := session.Manager.SetTableName(migrateTable); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to set migration table name: %w", err)
+		}
+		if err := session.CLIManager.SetMigrationTableName(migrateTable); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to set migration table name: %w", err)
+		}
+	}
+
+	if migrateDryRun {
+		return session, nil
+	}
+	if err := session.Manager.Initialize(ctx); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to initialize migration table: %w", err)
+	}
+
+	return session, nil
+}
+
+// connectDatabase connects to the configured provider and returns it with the function
+// disconnecting from it
+func connectDatabase(ctx context.Context, logger *logrus.Logger) (database.DatabaseProvider, func(), error) {
+	databaseManager := database.NewDatabaseManager(database.DefaultManagerConfig(), logger)
+
+	provider, err := createProvider(migrateProvider, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	if err := databaseManager.RegisterProvider(provider); err != nil {
+		return nil, nil, fmt.Errorf("failed to register provider: %w", err)
+	}
+	if err := databaseManager.Connect(ctx, migrateProvider); err != nil {
+		return nil, nil, &EnvironmentError{fmt.Errorf("failed to connect to database: %w", err)}
+	}
+
+	dbProvider, err := databaseManager.GetProvider(migrateProvider)
+	if err != nil {
+		databaseManager.Close()
+		return nil, nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+	return dbProvider, func() { databaseManager.Close() }, nil
+}
+
+// planUp returns the pending migrations to apply: all of them, the first steps, or those up
+// to and including target
+func planUp(available, applied []migrations.Migration, steps int, target string) migrationPlan {
+	appliedVersions := map[string]bool{}
+	for _, migration := range applied {
+		appliedVersions[migration.Version] = true
+	}
+
+	plan := migrationPlan{Direction: MigrationDirectionUp}
+	for _, migration := range available {
+		if appliedVersions[migration.Version] {
+			continue
+		}
+		if target != "" && migration.Version > target {
+			break
+		}
+		if steps > 0 && len(plan.Migrations) == steps {
+			break
+		}
+		plan.Migrations = append(plan.Migrations, migration)
+	}
+
+	plan.Target = "0"
+	if len(plan.Migrations) > 0 {
+		plan.Target = plan.Migrations[len(plan.Migrations)-1].Version
+	} else if len(applied) > 0 {
+		plan.Target = applied[len(applied)-1].Version
+	}
+	return plan
+}
+
+// planDown returns the applied migrations to roll back, newest first: the last steps, or
+// all of those newer than target
+func planDown(applied []migrations.Migration, steps int, target string) migrationPlan {
+	plan := migrationPlan{Direction: MigrationDirectionDown, Target: "0"}
+	for i := len(applied) - 1; i >= 0; i-- {
+		if (target != "" && applied[i].Version <= target) || (steps > 0 && len(plan.Migrations) == steps) {
+			plan.Target = applied[i].Version
+			break
+		}
+		plan.Migrations = append(plan.Migrations, applied[i])
+	}
+	return plan
+}
+
+// planTo returns the plan that moves the schema to target; "0" rolls back every migration
+func planTo(available, applied []migrations.Migration, target string) (migrationPlan, error) {
+	if target != "0" && !hasMigrationVersion(available, target) && !hasMigrationVersion(applied, target) {
+		return migrationPlan{}, &UserError{fmt.Errorf("unknown migration version %s", target)}
+	}
+
+	if len(applied) > 0 && applied[len(applied)-1].Version > target {
+		return planDown(applied, 0, target), nil
+	}
+	return planUp(available, applied, 0, target), nil
+}
+
+func hasMigrationVersion(list []migrations.Migration, version string) bool {
+	for _, migration := range list {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// executePlan applies or rolls back the migrations of a plan in order, stopping at the first
+// failure. With --dry-run the SQL is printed instead.
+func executePlan(ctx context.Context, session *migrationSession, plan migrationPlan) error {
+	if migrateDryRun {
+		printPlanSQL(plan)
+		return nil
+	}
+
+	for _, migration := range plan.Migrations {
+		var err error
+		switch {
+		case plan.Direction == MigrationDirectionUp:
+			fmt.Printf("Applying %s %s\n", migration.Version, migration.Description)
+			if isGoMigration(migration) {
+				err = applyGoMigration(ctx, session, migration)
+				break
+			}
+			migration.Checksum = migrationChecksum(migration)
+			err = session.Manager.ApplyMigration(ctx, migration)
+		case isGoMigration(migration):
+			fmt.Printf("Rolling back %s %s\n", migration.Version, migration.Description)
+			err = rollbackGoMigration(ctx, session, migration)
+		default:
+			fmt.Printf("Rolling back %s %s\n", migration.Version, migration.Description)
+			err = session.Manager.RollbackMigration(ctx, migration)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlanSQL prints the statements a plan would execute, in execution order
+func printPlanSQL(plan migrationPlan) {
+	if len(plan.Migrations) == 0 {
+		fmt.Printf("-- Nothing to migrate %s; the database stays at version %s\n", plan.Direction, plan.Target)
+		return
+	}
+
+	fmt.Printf("-- Migrating %s to version %s (%d migrations)\n", plan.Direction, plan.Target, len(plan.Migrations))
+	for _, migration := range plan.Migrations {
+		sql := migration.UpSQL
+		if plan.Direction == MigrationDirectionDown {
+			sql = migration.DownSQL
+		}
+		fmt.Printf("\n-- %s %s (%s)\n", migration.Version, migration.Description, plan.Direction)
+		if isGoMigration(migration) {
+			fmt.Printf("-- (Go migration; its statements are not previewed)\n")
+			continue
+		}
+		if strings.TrimSpace(sql) == "" {
+			fmt.Println("-- (no SQL)")
+			continue
+		}
+		fmt.Println(strings.TrimRight(sql, "\n"))
+	}
+	fmt.Println()
+}
+
+// loadMigrationState returns the migration files, including registered Go migrations, and the
+// migrations applied to the database.
+// A dry run against a database without a migration table treats every migration as pending.
+func loadMigrationState(ctx context.Context, session *migrationSession) ([]migrations.Migration, []migrations.Migration, error) {
+	available, err := session.CLIManager.LoadMigrations()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if registered := goMigrations(); len(registered) > 0 {
+		available = append(available, registered...)
+		sort.SliceStable(available, func(i, j int) bool { return available[i].Version < available[j].Version })
+	}
+	applied, err := session.Manager.GetAppliedMigrations(ctx)
+	if err != nil {
+		if !migrateDryRun {
+			return nil, nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		fmt.Printf("-- Could not read %s (%v); assuming no migrations are applied\n", migrateTable, err)
+		applied = nil
+	}
+	return available, applied, nil
+}
+
+// runMigratePlan connects, builds a plan with planner and executes it
+func runMigratePlan(planner func(available, applied []migrations.Migration) (migrationPlan, error)) (migrationPlan, error) {
+	logger := setupLogger()
+	ctx := context.Background()
+
+	session, err := openMigrationSession(ctx, logger)
+	if err != nil {
+		return migrationPlan{}, err
+	}
+	defer session.Close()
+
+	if !migrateDryRun {
+		unlock, err := lockMigrations(ctx, session)
+		if err != nil {
+			return migrationPlan{}, err
+		}
+		defer unlock()
+	}
+
+	available, applied, err := loadMigrationState(ctx, session)
+	if err != nil {
+		return migrationPlan{}, err
+	}
+
+	plan, err := planner(available, applied)
+	if err != nil {
+		return plan, err
+	}
+	if err := executePlan(ctx, session, plan); err != nil {
+		return plan, err
+	}
+
+	result := migrateResult{Direction: plan.Direction, Target: plan.Target, DryRun: migrateDryRun, Migrations: []string{}}
+	for _, migration := range plan.Migrations {
+		result.Migrations = append(result.Migrations, migration.Version)
+	}
+	reportResult(result)
+	return plan, nil
+}
+
+// runMigrateBaseline records the migrations up to and including version as applied without
+// running them, for databases whose schema predates the migrations
+func runMigrateBaseline(version string) error {
+	ctx := context.Background()
+	session, err := openMigrationSession(ctx, setupLogger())
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	unlock, err := lockMigrations(ctx, session)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	available, applied, err := loadMigrationState(ctx, session)
+	if err != nil {
+		return err
+	}
+	if !hasMigrationVersion(available, version) {
+		return &UserError{fmt.Errorf("unknown migration version %s", version)}
+	}
+
+	plan := planUp(available, applied, 0, version)
+	for _, migration := range plan.Migrations {
+		fmt.Printf("Baselining %s %s\n", migration.Version, migration.Description)
+		// The manager records the migration without executing anything when UpSQL is empty;
+		// the checksum still covers the file so status and verify see it as unchanged
+		migration.Checksum = migrationChecksum(migration)
+		migration.UpSQL = ""
+		if err := session.Manager.ApplyMigration(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	if len(plan.Migrations) == 0 {
+		fmt.Printf("Migrations up to %s are already applied\n", version)
+		return nil
+	}
+	fmt.Printf("%d migrations marked as applied; the database is at version %s\n", len(plan.Migrations), version)
+	return nil
+}
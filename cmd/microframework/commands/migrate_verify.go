@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anasamu/go-micro-libs/database/migrations"
+)
+
+// migrationDrift is an applied migration whose file no longer matches what was applied
+type migrationDrift struct {
+	Entry   migrationStatusEntry
+	File    *migrations.Migration
+	Applied migrations.Migration
+}
+
+// describe explains what changed
+func (d migrationDrift) describe() string {
+	if d.File == nil {
+		return "applied, but its migration file is gone"
+	}
+
+	var changed []string
+	if d.File.UpSQL != d.Applied.UpSQL && d.Applied.UpSQL != "" {
+		changed = append(changed, "up SQL")
+	}
+	if d.File.DownSQL != d.Applied.DownSQL {
+		changed = append(changed, "down SQL")
+	}
+	detail := ""
+	if len(changed) > 0 {
+		detail = " (" + strings.Join(changed, " and ") + ")"
+	}
+	return fmt.Sprintf("file changed since it was applied%s: recorded checksum %s, file %s",
+		detail, shortChecksum(d.Entry.RecordedChecksum), shortChecksum(d.Entry.Checksum))
+}
+
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
+
+// findMigrationDrift returns the applied migrations that are dirty or missing
+func findMigrationDrift(available, applied []migrations.Migration) []migrationDrift {
+	files := map[string]*migrations.Migration{}
+	for i := range available {
+		files[available[i].Version] = &available[i]
+	}
+	records := map[string]migrations.Migration{}
+	for _, migration := range applied {
+		records[migration.Version] = migration
+	}
+
+	var drift []migrationDrift
+	for _, entry := range migrationStatuses(available, applied) {
+		if entry.State != MigrationStateDirty && entry.State != MigrationStateMissing {
+			continue
+		}
+		drift = append(drift, migrationDrift{Entry: entry, File: files[entry.Version], Applied: records[entry.Version]})
+	}
+	return drift
+}
+
+// runMigrateVerify compares the checksums of the migration files with those recorded when
+// they were applied. With --repair the records of changed files are updated after review.
+func runMigrateVerify() error {
+	ctx := context.Background()
+	session, err := openMigrationSession(ctx, setupLogger())
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if migrateVerifyRepair {
+		unlock, err := lockMigrations(ctx, session)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	available, applied, err := loadMigrationState(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	drift := findMigrationDrift(available, applied)
+	if len(drift) == 0 {
+		fmt.Printf("All %d applied migrations match their files\n", len(applied))
+		return nil
+	}
+
+	fmt.Printf("%d applied migrations no longer match their files:\n", len(drift))
+	for _, d := range drift {
+		fmt.Printf("  %s %s: %s\n", d.Entry.Version, d.Entry.Name, d.describe())
+	}
+
+	if !migrateVerifyRepair {
+		return &ValidationFailure{fmt.Errorf("migration drift detected; restore the original files, or review the changes and run migrate verify --repair")}
+	}
+	return repairMigrationRecords(ctx, session, drift)
+}
+
+// repairMigrationRecords re-records the changed files as applied. Missing files cannot be
+// repaired, since there is nothing to record.
+func repairMigrationRecords(ctx context.Context, session *migrationSession, drift []migrationDrift) error {
+	var repairable []migrationDrift
+	for _, d := range drift {
+		if d.File != nil {
+			repairable = append(repairable, d)
+		}
+	}
+	if len(repairable) == 0 {
+		return fmt.Errorf("no changed files to repair; migrations whose files are gone must be restored")
+	}
+
+	if !migrateVerifyYes && !confirm(fmt.Sprintf("Record the current contents of %d migration files as applied?", len(repairable))) {
+		return fmt.Errorf("repair cancelled")
+	}
+
+	dialect := providerDialects[migrateProvider]
+	query := fmt.Sprintf("UPDATE %s SET description = %s, up_sql = %s, down_sql = %s, checksum = %s WHERE version = %s",
+		migrateTable, sqlPlaceholder(dialect, 1), sqlPlaceholder(dialect, 2), sqlPlaceholder(dialect, 3),
+		sqlPlaceholder(dialect, 4), sqlPlaceholder(dialect, 5))
+	for _, d := range repairable {
+		file := *d.File
+		if _, err := session.Provider.Exec(ctx, query,
+			file.Description, file.UpSQL, file.DownSQL, migrationChecksum(file), file.Version); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", file.Version, err)
+		}
+		fmt.Printf("Repaired %s %s\n", file.Version, file.Description)
+	}
+
+	if len(repairable) < len(drift) {
+		return &ValidationFailure{fmt.Errorf("%d migrations whose files are gone were not repaired", len(drift)-len(repairable))}
+	}
+	return nil
+}
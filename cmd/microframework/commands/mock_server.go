@@ -0,0 +1,641 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	mockSpec      string
+	mockHost      string
+	mockPort      int
+	mockLatency   string
+	mockErrorRate float64
+)
+
+// mockSchemaDepth bounds the nesting of the generated examples and of the $ref chains
+const mockSchemaDepth = 16
+
+// mockServerCmd represents the mock-server command
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Serve example responses generated from the OpenAPI spec",
+	Long: `Start a mock HTTP server answering the operations of the OpenAPI 3 spec of the service
+with example responses, so that its consumers can develop against it before it is built.
+
+Each operation answers with its first success response. The example of the response is
+used when the spec has one; otherwise one is generated from its schema, following $ref,
+allOf, oneOf and anyOf, with the enums, formats, defaults and bounds of the schema. A
+request chooses another of the declared responses with the Prefer: code=<status> header,
+or the __status query parameter.
+
+--latency delays every response, by a fixed duration or a random one within a range
+(100ms-1s). --error-rate answers that share of the requests with the 5xx or default
+response of the operation, 500 when it declares none. The spec is read again when it
+changes.
+
+Examples:
+  microframework mock-server
+  microframework mock-server --spec api/openapi.yaml --port 4010
+  microframework mock-server --latency 100ms-800ms --error-rate 0.1
+  curl -H "Prefer: code=404" localhost:4010/users/1`,
+	Args: cobra.NoArgs,
+	RunE: runMockServer,
+}
+
+func init() {
+	mockServerCmd.Flags().StringVar(&mockSpec, "spec", "api/openapi.yaml", "OpenAPI 3 spec, YAML or JSON")
+	mockServerCmd.Flags().StringVar(&mockHost, "host", "localhost", "Address to listen on")
+	mockServerCmd.Flags().IntVar(&mockPort, "port", 4010, "Port to listen on")
+	mockServerCmd.Flags().StringVar(&mockLatency, "latency", "", "Delay of the responses, a duration or a range (100ms-1s)")
+	mockServerCmd.Flags().Float64Var(&mockErrorRate, "error-rate", 0, "Share of the requests answered with an error, from 0 to 1")
+}
+
+// mockOperation is an operation of the spec the mock server answers
+type mockOperation struct {
+	Method string
+	// Path is the path template of the operation, as /users/{id}
+	Path      string
+	segments  []string
+	responses map[string]interface{}
+}
+
+// mockAPI is the operations of a spec, with what resolving their schemas needs
+type mockAPI struct {
+	document   map[string]interface{}
+	operations []*mockOperation
+	// basePaths are the paths of the servers of the spec, which prefix the operations
+	basePaths []string
+}
+
+func runMockServer(cmd *cobra.Command, args []string) error {
+	minLatency, maxLatency, err := parseLatency(mockLatency)
+	if err != nil {
+		return err
+	}
+	if mockErrorRate < 0 || mockErrorRate > 1 {
+		return fmt.Errorf("invalid --error-rate %v: expected a share from 0 to 1", mockErrorRate)
+	}
+
+	server := &mockServer{spec: mockSpec, minLatency: minLatency, maxLatency: maxLatency, errorRate: mockErrorRate}
+	api, err := server.load()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(mockHost, strconv.Itoa(mockPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s:%d: %w", mockHost, mockPort, err)
+	}
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("✓ Mock server of %s listening on http://%s\n", mockSpec, listener.Addr())
+	for _, operation := range api.operations {
+		fmt.Printf("  %-7s %s\n", operation.Method, operation.Path)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("Stopping...")
+	return nil
+}
+
+// parseLatency parses --latency, a duration or a range of durations
+func parseLatency(latency string) (time.Duration, time.Duration, error) {
+	if latency == "" {
+		return 0, 0, nil
+	}
+	low, high, isRange := strings.Cut(latency, "-")
+	minimum, err := time.ParseDuration(strings.TrimSpace(low))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --latency %q: %w", latency, err)
+	}
+	maximum := minimum
+	if isRange {
+		if maximum, err = time.ParseDuration(strings.TrimSpace(high)); err != nil {
+			return 0, 0, fmt.Errorf("invalid --latency %q: %w", latency, err)
+		}
+	}
+	if minimum < 0 || maximum < minimum {
+		return 0, 0, fmt.Errorf("invalid --latency %q: expected a duration or a range from low to high", latency)
+	}
+	return minimum, maximum, nil
+}
+
+// mockServer answers the requests with the examples of the spec, reloading it when it changes
+type mockServer struct {
+	spec                   string
+	minLatency, maxLatency time.Duration
+	errorRate              float64
+
+	mu       sync.Mutex
+	api      *mockAPI
+	modified time.Time
+}
+
+// load returns the operations of the spec, parsing it again when it changed since last read
+func (s *mockServer) load() (*mockAPI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.spec)
+	if err != nil {
+		if s.api != nil {
+			return s.api, nil
+		}
+		return nil, fmt.Errorf("failed to read the OpenAPI spec: %w", err)
+	}
+	if s.api != nil && info.ModTime().Equal(s.modified) {
+		return s.api, nil
+	}
+
+	api, err := parseMockAPI(s.spec)
+	if err != nil {
+		if s.api != nil {
+			fmt.Printf("Warning: keeping the previous spec: %v\n", err)
+			return s.api, nil
+		}
+		return nil, err
+	}
+	if s.api != nil {
+		fmt.Printf("Reloaded %s: %d operations\n", s.spec, len(api.operations))
+	}
+	s.api, s.modified = api, info.ModTime()
+	return api, nil
+}
+
+// parseMockAPI reads the operations of an OpenAPI 3 spec
+func parseMockAPI(path string) (*mockAPI, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI spec: %w", err)
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if _, ok := document["swagger"]; ok {
+		return nil, fmt.Errorf("%s is a Swagger 2.0 spec; only OpenAPI 3 specs are read", path)
+	}
+	if _, ok := document["openapi"]; !ok {
+		return nil, fmt.Errorf("%s is no OpenAPI spec: it has no openapi field", path)
+	}
+
+	api := &mockAPI{document: document}
+	for _, server := range mapSlice(document["servers"]) {
+		if address, ok := server["url"].(string); ok {
+			if parsed, err := url.Parse(address); err == nil && strings.Trim(parsed.Path, "/") != "" {
+				api.basePaths = append(api.basePaths, "/"+strings.Trim(parsed.Path, "/"))
+			}
+		}
+	}
+
+	paths, _ := document["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			operation, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			responses, _ := operation["responses"].(map[string]interface{})
+			api.operations = append(api.operations, &mockOperation{
+				Method:    strings.ToUpper(method),
+				Path:      path,
+				segments:  strings.Split(strings.Trim(path, "/"), "/"),
+				responses: responses,
+			})
+		}
+	}
+	// Literal segments win over parameters: /users/me before /users/{id}
+	sort.SliceStable(api.operations, func(i, j int) bool {
+		return literalSegments(api.operations[i].segments) > literalSegments(api.operations[j].segments)
+	})
+	return api, nil
+}
+
+// literalSegments counts the segments of a path template that are no parameter
+func literalSegments(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			count++
+		}
+	}
+	return count
+}
+
+// match returns the operation of a request, and whether its path exists for another method
+func (api *mockAPI) match(method, path string) (*mockOperation, bool) {
+	candidates := []string{path}
+	for _, base := range api.basePaths {
+		if strings.HasPrefix(path, base) {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	pathFound := false
+	for _, candidate := range candidates {
+		segments := strings.Split(strings.Trim(candidate, "/"), "/")
+		for _, operation := range api.operations {
+			if !matchSegments(operation.segments, segments) {
+				continue
+			}
+			if operation.Method == method || (method == http.MethodHead && operation.Method == http.MethodGet) {
+				return operation, true
+			}
+			pathFound = true
+		}
+	}
+	return nil, pathFound
+}
+
+// matchSegments reports whether the segments of a path match those of a path template
+func matchSegments(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, segment := range template {
+		if !(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segments[i] != "") && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	started := time.Now()
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		if headers := request.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	api, err := s.load()
+	if err != nil {
+		writeGatewayError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	operation, pathFound := api.match(request.Method, request.URL.Path)
+	if operation == nil {
+		status := http.StatusNotFound
+		if pathFound {
+			status = http.StatusMethodNotAllowed
+		}
+		writeGatewayError(w, status, fmt.Sprintf("no operation %s %s in %s", request.Method, request.URL.Path, s.spec))
+		fmt.Printf("%s %s %s → %d (not in the spec)\n", started.Format("15:04:05"), request.Method, request.URL.RequestURI(), status)
+		return
+	}
+
+	if s.maxLatency > 0 {
+		time.Sleep(s.minLatency + time.Duration(rand.Int63n(int64(s.maxLatency-s.minLatency)+1)))
+	}
+	status, response := s.chooseResponse(operation, request)
+	s.writeResponse(w, request, api, status, response)
+	fmt.Printf("%s %s %s → %s %d %s\n", started.Format("15:04:05"), request.Method, request.URL.RequestURI(), operation.Path,
+		status, time.Since(started).Round(time.Millisecond))
+}
+
+// chooseResponse returns the status and response an operation answers a request with: the
+// one the request prefers, an injected error, or the first success
+func (s *mockServer) chooseResponse(operation *mockOperation, request *http.Request) (int, map[string]interface{}) {
+	codes := sortedKeys(operation.responses)
+	response := func(code string) map[string]interface{} {
+		value, _ := operation.responses[code].(map[string]interface{})
+		return value
+	}
+
+	preferred := request.URL.Query().Get("__status")
+	if prefer := request.Header.Get("Prefer"); preferred == "" && strings.HasPrefix(prefer, "code=") {
+		preferred = strings.TrimPrefix(prefer, "code=")
+	}
+	if preferred != "" {
+		if status, err := strconv.Atoi(preferred); err == nil {
+			if _, ok := operation.responses[preferred]; ok {
+				return status, response(preferred)
+			}
+			// A status of a range (4XX) or the default response
+			for _, code := range []string{preferred[:1] + "XX", "default"} {
+				if _, ok := operation.responses[code]; ok {
+					return status, response(code)
+				}
+			}
+		}
+	}
+
+	if s.errorRate > 0 && rand.Float64() < s.errorRate {
+		for _, code := range codes {
+			if strings.HasPrefix(code, "5") {
+				status, _ := strconv.Atoi(strings.ReplaceAll(code, "XX", "00"))
+				return status, response(code)
+			}
+		}
+		return http.StatusInternalServerError, response("default")
+	}
+
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			status, _ := strconv.Atoi(strings.ReplaceAll(code, "XX", "00"))
+			return status, response(code)
+		}
+	}
+	if len(codes) > 0 && codes[0] != "default" {
+		status, _ := strconv.Atoi(strings.ReplaceAll(codes[0], "XX", "00"))
+		return status, response(codes[0])
+	}
+	return http.StatusOK, response("default")
+}
+
+// writeResponse writes the example of a response, in the media type the request accepts
+func (s *mockServer) writeResponse(w http.ResponseWriter, request *http.Request, api *mockAPI, status int, response map[string]interface{}) {
+	response = api.resolve(response)
+	if headers, ok := response["headers"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(headers) {
+			header := api.resolve(asMap(headers[name]))
+			if value := api.example(header, asMap(header["schema"])); value != nil {
+				w.Header().Set(name, fmt.Sprint(value))
+			}
+		}
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	mediaType := chooseMediaType(content, request.Header.Get("Accept"))
+	if mediaType == "" {
+		w.WriteHeader(status)
+		return
+	}
+	media := asMap(content[mediaType])
+	body := api.example(media, asMap(media["schema"]))
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	if request.Method == http.MethodHead {
+		return
+	}
+	if strings.Contains(mediaType, "json") {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(body)
+		return
+	}
+	if body != nil {
+		fmt.Fprint(w, body)
+	}
+}
+
+// chooseMediaType returns the media type of a response content the Accept header takes, JSON
+// when it takes any
+func chooseMediaType(content map[string]interface{}, accept string) string {
+	if len(content) == 0 {
+		return ""
+	}
+	types := sortedKeys(content)
+	for _, accepted := range strings.Split(accept, ",") {
+		accepted = strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		if _, ok := content[accepted]; ok {
+			return accepted
+		}
+	}
+	for _, mediaType := range types {
+		if strings.Contains(mediaType, "json") {
+			return mediaType
+		}
+	}
+	return types[0]
+}
+
+// resolve follows the $ref of an object of the spec, within the document
+func (api *mockAPI) resolve(object map[string]interface{}) map[string]interface{} {
+	for range mockSchemaDepth {
+		ref, ok := object["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return object
+		}
+		var node interface{} = api.document
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+			node = asMap(node)[name]
+		}
+		object = asMap(node)
+	}
+	return object
+}
+
+// example returns the example of a media type, parameter or header: its own example, the
+// first of its examples, or one generated from its schema
+func (api *mockAPI) example(holder, schema map[string]interface{}) interface{} {
+	if value, ok := holder["example"]; ok {
+		return value
+	}
+	if examples, ok := holder["examples"].(map[string]interface{}); ok && len(examples) > 0 {
+		first := api.resolve(asMap(examples[sortedKeys(examples)[0]]))
+		if value, ok := first["value"]; ok {
+			return value
+		}
+	}
+	if schema == nil {
+		return nil
+	}
+	return api.generate(schema, nil)
+}
+
+// generate returns a value of a schema, nil for the schemas of refs, the $ref chain that
+// leads to it, already expands: a recursive schema stops at its first repetition
+func (api *mockAPI) generate(schema map[string]interface{}, refs []string) interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		if containsString(refs, ref) {
+			return nil
+		}
+		refs = append(refs[:len(refs):len(refs)], ref)
+	}
+	schema = api.resolve(schema)
+	if len(refs) > mockSchemaDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if variants := mapSlice(schema[key]); len(variants) > 0 {
+			return api.generate(variants[0], refs)
+		}
+	}
+	if parts := mapSlice(schema["allOf"]); len(parts) > 0 {
+		merged := make(map[string]interface{})
+		for _, part := range parts {
+			if value, ok := api.generate(part, refs).(map[string]interface{}); ok {
+				for key, field := range value {
+					merged[key] = field
+				}
+			}
+		}
+		return merged
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]interface{}); ok && len(types) > 0 {
+		// OpenAPI 3.1 type lists, as [string, "null"]
+		schemaType = fmt.Sprint(types[0])
+	}
+	if schemaType == "" {
+		if _, ok := schema["properties"]; ok {
+			schemaType = "object"
+		} else if _, ok := schema["items"]; ok {
+			schemaType = "array"
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		object := make(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(properties) {
+			property := asMap(properties[name])
+			if api.resolve(property)["writeOnly"] == true {
+				continue
+			}
+			if value := api.generate(property, refs); value != nil {
+				object[name] = value
+			}
+		}
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok && len(object) == 0 {
+			if value := api.generate(additional, refs); value != nil {
+				object["key"] = value
+			}
+		}
+		return object
+	case "array":
+		count := 1
+		if minimum, ok := schema["minItems"].(int); ok && minimum > count {
+			count = minimum
+		}
+		items := make([]interface{}, 0, count)
+		for range count {
+			if item := api.generate(asMap(schema["items"]), refs); item != nil {
+				items = append(items, item)
+			}
+		}
+		return items
+	case "integer":
+		return int64(boundedNumber(schema, 1))
+	case "number":
+		return boundedNumber(schema, 1.5)
+	case "boolean":
+		return true
+	case "string":
+		return exampleString(schema)
+	}
+	return nil
+}
+
+// boundedNumber returns fallback, or the closest value the bounds of a schema allow
+func boundedNumber(schema map[string]interface{}, fallback float64) float64 {
+	if minimum, ok := numberOf(schema["minimum"]); ok && fallback < minimum {
+		fallback = minimum
+	}
+	if minimum, ok := numberOf(schema["exclusiveMinimum"]); ok && fallback <= minimum {
+		fallback = minimum + 1
+	}
+	if maximum, ok := numberOf(schema["maximum"]); ok && fallback > maximum {
+		fallback = maximum
+	}
+	return fallback
+}
+
+// numberOf returns the number of a YAML or JSON value
+func numberOf(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+// exampleStrings are the examples of the string formats
+var exampleStrings = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "12:00:00Z",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "ZXhhbXBsZQ==",
+	"password":  "********",
+}
+
+// exampleString returns a string of the format and length of a schema
+func exampleString(schema map[string]interface{}) string {
+	format, _ := schema["format"].(string)
+	value, ok := exampleStrings[format]
+	if !ok {
+		value = "string"
+	}
+	if minimum, ok := schema["minLength"].(int); ok && len(value) < minimum {
+		value += strings.Repeat("x", minimum-len(value))
+	}
+	if maximum, ok := schema["maxLength"].(int); ok && len(value) > maximum {
+		value = value[:maximum]
+	}
+	return value
+}
+
+// asMap returns a value of the spec as an object, nil when it is none
+func asMap(value interface{}) map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	return object
+}
+
+// mapSlice returns a list of objects of the spec
+func mapSlice(value interface{}) []map[string]interface{} {
+	list, _ := value.([]interface{})
+	var objects []map[string]interface{}
+	for _, item := range list {
+		if object, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
-	"github.com/anasamu/go-micro-framework/internal/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator"
 	"github.com/spf13/cobra"
 )
 
@@ -30,10 +32,25 @@ var (
 	withFileGen        string
 	withAPI            string
 	withEmail          string
+	withFeatureFlags   string
+	withErrorTracking  string
+	openFeatureBackend string
+	withSecrets        string
 	outputDir          string
 	force              bool
+	templatePack       string
+	newVet             bool
+	newOwner           string
+	newSystem          string
+	oidcRedirectURLs   []string
+	oidcScopes         []string
+	oidcRoles          []string
 )
 
+// catalogRefPattern is what the owner and system of a service in the Backstage catalog may be
+// made of: an entity name, or a reference such as group:default/payments
+var catalogRefPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9:/._-]*$`)
+
 // newCmd represents the new command
 var newCmd = &cobra.Command{
 	Use:   "new <service-name>",
@@ -55,21 +72,73 @@ CORE LIBRARIES (Always Integrated):
 - Communication (go-micro-libs/communication)
 - Utils (internal/utils)
 
+Run again on a project it generated, new regenerates it incrementally from its generation
+manifest: the files whose template and inputs did not change are skipped, the files with the
+same contents are not written, and the files changed since they were generated are kept and
+reported, unless --force is given. The entities designed with scaffold entity are kept.
+
+The service is registered in the Backstage software catalog by its catalog-info.yaml: its
+component, owned by --owner in the system of --system, and the APIs of its OpenAPI spec and
+.proto files. Regenerating the project keeps the owner and system unless they are given.
+
+With --from-openapi, the service is generated around an OpenAPI 3 spec, copied to
+api/openapi.yaml: its schemas with an id property become entities, with their CRUD layers, the
+operations of its paths that the entity routes do not serve get a stub each in
+internal/handlers/api.go, and its bearer, OAuth or OpenID Connect security schemes set
+--with-auth to jwt, oauth or oidc unless it is given.
+
+With --with-auth oidc, deployments/oidc has the Keycloak realm export of the service, which
+the Keycloak of its docker-compose.yml imports, and its client registration for the other
+OpenID Connect providers: its redirect URLs, scopes and roles are those of --oidc-redirect-url,
+--oidc-scopes and --oidc-roles, or those the security requirements of --from-openapi require.
+
+With --from-proto, the service is a gRPC service implementing the services of the .proto files
+of a directory, copied to api/proto. They are compiled in-process, without protoc, and their
+Go code is generated with protoc-gen-go and protoc-gen-go-grpc, which must be on the PATH, in
+internal/pb; each service gets a server in internal/handlers whose methods return
+Unimplemented, and main.go serves them on the gRPC port. Regenerating the project compiles
+api/proto again.
+
+The project is generated in a staging directory next to it and only moved into place once
+its Go files parse, and with --vet once go vet passes, so that a failed generation leaves
+the directory as it was.
+
 Examples:
   microframework new user-service
   microframework new order-service --with-auth=jwt --with-database=postgres
   microframework new notification-service --with-messaging=kafka --with-ai=openai
-  microframework new payment-service --with-payment=stripe --with-database=postgres --with-monitoring=prometheus`,
-	Args: cobra.ExactArgs(1),
-	RunE: runNew,
+  microframework new payment-service --with-payment=stripe --with-database=postgres --with-monitoring=prometheus
+  microframework new billing-service --template-pack acme@1.2.0
+  microframework new pet-service --from-openapi petstore.yaml --with-database=postgres
+  microframework new user-service --from-proto ./protos
+  microframework new ledger-service --owner group:payments --system billing
+  microframework new account-service --with-auth=oidc --oidc-roles viewer,editor`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runNew,
+	Annotations: map[string]string{outputDirectoryAnnotation: "true"},
+}
+
+// newResult is the result of new in the JSON report
+type newResult struct {
+	Service   string `json:"service"`
+	Type      string `json:"type"`
+	Directory string `json:"directory"`
+	// Features are the providers of the features enabled, by feature
+	Features map[string]string `json:"features,omitempty"`
+	// Imported is what was imported from the OpenAPI spec of --from-openapi and the .proto
+	// files of --from-proto
+	Imported *importResult `json:"imported,omitempty"`
 }
 
 func init() {
 	// Service type
-	newCmd.Flags().StringVarP(&serviceType, "type", "t", "rest", "Service type (rest, graphql, grpc, websocket, event, scheduled, worker, gateway, proxy)")
+	newCmd.Flags().StringVarP(&serviceType, "type", "t", "rest", "Service type (rest, graphql, grpc, websocket, event, scheduled, worker, temporal-worker, gateway, proxy)")
 
 	// Core features
-	newCmd.Flags().StringVar(&withAuth, "with-auth", "", "Include authentication (jwt, oauth, ldap, saml)")
+	newCmd.Flags().StringVar(&withAuth, "with-auth", "", "Include authentication (jwt, oauth, oidc, ldap, saml)")
+	newCmd.Flags().StringSliceVar(&oidcRedirectURLs, "oidc-redirect-url", nil, "Redirect URL of the OIDC client of --with-auth oidc (default http://localhost:8080/auth/callback)")
+	newCmd.Flags().StringSliceVar(&oidcScopes, "oidc-scopes", nil, "Scopes the OIDC client of --with-auth oidc requests besides openid (default profile,email)")
+	newCmd.Flags().StringSliceVar(&oidcRoles, "oidc-roles", nil, "Realm roles of the OIDC client of --with-auth oidc (default user,admin)")
 	newCmd.Flags().StringVar(&withDatabase, "with-database", "", "Include database (postgres, mysql, redis, mongodb)")
 	newCmd.Flags().StringVar(&withMessaging, "with-messaging", "", "Include messaging (kafka, rabbitmq, nats)")
 	newCmd.Flags().StringVar(&withMonitoring, "with-monitoring", "", "Include monitoring (prometheus, jaeger, grafana)")
@@ -90,10 +159,29 @@ func init() {
 	newCmd.Flags().StringVar(&withFileGen, "with-filegen", "", "Include file generation")
 	newCmd.Flags().StringVar(&withAPI, "with-api", "", "Include API thirdparty integration (http, grpc, graphql, websocket)")
 	newCmd.Flags().StringVar(&withEmail, "with-email", "", "Include email services (smtp, sendgrid, mailgun)")
+	newCmd.Flags().StringVar(&withFeatureFlags, "with-featureflags", "", "Include feature flags (file, env, remote, openfeature)")
+	newCmd.Flags().StringVar(&openFeatureBackend, "openfeature-provider", "file", "Provider of --with-featureflags openfeature (env, file, flagd, launchdarkly)")
+	newCmd.Flags().StringVar(&withErrorTracking, "with-errortracking", "", "Report errors and panics to an error tracking service (sentry, bugsnag)")
+	newCmd.Flags().StringVar(&withSecrets, "with-secrets", "", "Read production secrets from a secrets backend (vault, ssm, gsm)")
 
 	// Output options
-	newCmd.Flags().StringVarP(&outputDir, "output", "o", ".", "Output directory for the generated service")
-	newCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+	newCmd.Flags().StringVar(&outputDir, "dir", ".", "Directory the service is generated in (-o is a deprecated alias)")
+	newCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files, and the files changed since they were generated when regenerating")
+	newCmd.Flags().BoolVar(&newVet, "vet", false, "Run go mod tidy and go vet on the generated project before moving it into place")
+	newCmd.Flags().StringVar(&templatePack, "template-pack", "", "Template pack to generate from, <name>[@<version>] (microframework templates list)")
+	newCmd.Flags().StringVar(&newFromOpenAPI, "from-openapi", "", "OpenAPI 3 spec, YAML or JSON, to derive the entities, handlers and auth of the service from")
+	newCmd.Flags().StringVar(&newOwner, "owner", "", "Owner of the service in the Backstage catalog (catalog-info.yaml)")
+	newCmd.Flags().StringVar(&newSystem, "system", "", "System of the service in the Backstage catalog (catalog-info.yaml)")
+	newCmd.Flags().StringVar(&newFromProto, "from-proto", "", "Directory of .proto files whose gRPC services the service implements (implies --type grpc)")
+
+	newCmd.RegisterFlagCompletionFunc("type", completeCatalog(serviceTypes))
+	newCmd.RegisterFlagCompletionFunc("template-pack", completeTemplatePacks)
+	newCmd.RegisterFlagCompletionFunc("openfeature-provider", cobra.FixedCompletions(openFeatureProviders, cobra.ShellCompDirectiveNoFileComp))
+	for _, feature := range features {
+		if feature.Flag != "" && len(feature.Providers) > 0 {
+			newCmd.RegisterFlagCompletionFunc(strings.TrimPrefix(feature.Flag, "--"), completeFeatureFlag(feature.Name))
+		}
+	}
 }
 
 func runNew(cmd *cobra.Command, args []string) error {
@@ -101,13 +189,60 @@ func runNew(cmd *cobra.Command, args []string) error {
 
 	// Validate service name
 	if err := validateServiceName(serviceName); err != nil {
-		return fmt.Errorf("invalid service name: %w", err)
+		return &UserError{fmt.Errorf("invalid service name: %w", err)}
 	}
 
 	// Check if output directory exists and is not empty
 	fullOutputDir := filepath.Join(outputDir, serviceName)
-	if !force {
+	previous, err := generator.LoadManifest(fullOutputDir)
+	switch {
+	case err == nil && previous.Config.Adopted:
+		return &UserError{fmt.Errorf("'%s' was adopted with microframework init; update its templates with microframework update --type templates", fullOutputDir)}
+	case err == nil:
+		// A project generated before is regenerated incrementally
+	case !os.IsNotExist(err):
+		return err
+	case !force:
+		previous = nil
 		if err := checkOutputDirectory(fullOutputDir); err != nil {
+			return &UserError{err}
+		}
+	default:
+		previous = nil
+	}
+
+	for _, ref := range []struct{ flag, value string }{{"--owner", newOwner}, {"--system", newSystem}} {
+		if ref.value != "" && !catalogRefPattern.MatchString(ref.value) {
+			return &UserError{fmt.Errorf("invalid %s %q: a Backstage entity name or reference, as group:default/payments", ref.flag, ref.value)}
+		}
+	}
+
+	if withErrorTracking != "" && withErrorTracking != "sentry" && withErrorTracking != "bugsnag" {
+		return &UserError{fmt.Errorf("invalid --with-errortracking %q: sentry or bugsnag", withErrorTracking)}
+	}
+
+	var imported *openAPIImport
+	if newFromOpenAPI != "" {
+		if imported, err = importOpenAPI(newFromOpenAPI); err != nil {
+			return err
+		}
+		if withAuth == "" {
+			withAuth = imported.authProvider
+		}
+	}
+
+	var protos *protoImport
+	protoSource := newFromProto
+	if protoSource != "" && cmd.Flags().Changed("type") && serviceType != "grpc" {
+		return &UserError{fmt.Errorf("--from-proto generates a grpc service, not a %s one", serviceType)}
+	}
+	if protoSource == "" && previous != nil && len(previous.Config.GRPCServices) > 0 {
+		// The services imported before are compiled again from the .proto files of the project
+		protoSource = filepath.Join(fullOutputDir, filepath.FromSlash(protoDir))
+	}
+	if protoSource != "" {
+		serviceType = "grpc"
+		if protos, err = importProto(protoSource, serviceName); err != nil {
 			return err
 		}
 	}
@@ -135,23 +270,94 @@ func runNew(cmd *cobra.Command, args []string) error {
 		WithFileGen:        withFileGen != "",
 		WithAPI:            withAPI != "",
 		WithEmail:          withEmail != "",
+		WithFeatureFlags:   withFeatureFlags != "",
+		WithErrorTracking:  withErrorTracking != "",
 		OutputDir:          outputDir,
 		// Provider specifications
-		AuthProvider:       withAuth,
-		DatabaseProvider:   withDatabase,
-		MessagingProvider:  withMessaging,
-		MonitoringProvider: withMonitoring,
-		AIProvider:         withAI,
-		StorageProvider:    withStorage,
-		CacheProvider:      withCache,
-		DiscoveryProvider:  withDiscovery,
-		PaymentProvider:    withPayment,
-		APIProvider:        withAPI,
-		EmailProvider:      withEmail,
+		AuthProvider:          withAuth,
+		DatabaseProvider:      withDatabase,
+		MessagingProvider:     withMessaging,
+		MonitoringProvider:    withMonitoring,
+		AIProvider:            withAI,
+		StorageProvider:       withStorage,
+		CacheProvider:         withCache,
+		DiscoveryProvider:     withDiscovery,
+		PaymentProvider:       withPayment,
+		APIProvider:           withAPI,
+		EmailProvider:         withEmail,
+		FeatureFlagsProvider:  withFeatureFlags,
+		ErrorTrackingProvider: withErrorTracking,
+		SecretsProvider:       withSecrets,
+		Owner:                 newOwner,
+		System:                newSystem,
+		FrameworkVersion:      version,
+	}
+	switch {
+	case imported != nil:
+		config.Entities, config.Operations, config.OpenAPISpec = imported.entities, imported.operations, imported.spec
+	case previous != nil:
+		config.Entities = previous.Config.Entities
+		config.Operations, config.OpenAPISpec = previous.Config.Operations, previous.Config.OpenAPISpec
+	}
+	if protos != nil {
+		config.GRPCServices = protos.services
+	}
+	// Imported tables and ported projects are kept whatever the project is regenerated from
+	if previous != nil {
+		config.Tables, config.Ported = previous.Config.Tables, previous.Config.Ported
+		if config.Owner == "" {
+			config.Owner = previous.Config.Owner
+		}
+		if config.System == "" {
+			config.System = previous.Config.System
+		}
+	}
+	for _, flag := range []string{"oidc-redirect-url", "oidc-scopes", "oidc-roles"} {
+		if cmd.Flags().Changed(flag) && withAuth != "oidc" {
+			return &UserError{fmt.Errorf("--%s configures the client of --with-auth oidc", flag)}
+		}
+	}
+	if withAuth == "oidc" {
+		config.OIDC = newOIDCClient(cmd, previous, imported)
+		if err := config.OIDC.Validate(); err != nil {
+			return &UserError{err}
+		}
+	}
+	if cmd.Flags().Changed("openfeature-provider") && withFeatureFlags != "openfeature" {
+		return &UserError{fmt.Errorf("--openfeature-provider configures the provider of --with-featureflags openfeature")}
+	}
+	if withFeatureFlags == "openfeature" {
+		config.OpenFeatureProvider = openFeatureBackend
+		if previous != nil && previous.Config.OpenFeatureProvider != "" && !cmd.Flags().Changed("openfeature-provider") {
+			config.OpenFeatureProvider = previous.Config.OpenFeatureProvider
+		}
+		if !containsString(openFeatureProviders, config.OpenFeatureProvider) {
+			return &UserError{fmt.Errorf("invalid --openfeature-provider %q: %s", config.OpenFeatureProvider, strings.Join(openFeatureProviders, ", "))}
+		}
 	}
 
 	// Create service generator
-	generator := generator.NewServiceGenerator(config)
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	packOpts, pack, err := templatePackOptions(templatePack)
+	if err != nil {
+		return err
+	}
+	config.TemplatePack = pack
+	opts = append(opts, packOpts...)
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: reportGeneratedFiles}))
+	if previous != nil {
+		opts = append(opts, generator.WithIncremental(force))
+	}
+	if newVet {
+		opts = append(opts, generator.WithValidators(generator.GoVet{}))
+	}
+	if protos != nil {
+		opts = append(opts, generator.WithFiles(protos.files))
+	}
+	generator := generator.NewServiceGenerator(config, opts...)
 
 	// Generate the service
 	fmt.Printf("Generating microservice: %s\n", serviceName)
@@ -161,6 +367,9 @@ func runNew(cmd *cobra.Command, args []string) error {
 	if withAuth != "" {
 		fmt.Printf("✓ Authentication enabled (%s)\n", withAuth)
 	}
+	if config.OIDC != nil {
+		fmt.Println("✓ Keycloak realm and OIDC client registration generated (deployments/oidc)")
+	}
 	if withDatabase != "" {
 		fmt.Printf("✓ Database integration enabled (%s)\n", withDatabase)
 	}
@@ -215,12 +424,59 @@ func runNew(cmd *cobra.Command, args []string) error {
 	if withEmail != "" {
 		fmt.Printf("✓ Email services enabled (%s)\n", withEmail)
 	}
+	if withFeatureFlags == "openfeature" {
+		fmt.Printf("✓ Feature flags enabled (OpenFeature, %s)\n", config.OpenFeatureProvider)
+	} else if withFeatureFlags != "" {
+		fmt.Printf("✓ Feature flags enabled (%s)\n", withFeatureFlags)
+	}
+	if withErrorTracking != "" {
+		fmt.Printf("✓ Error tracking enabled (%s)\n", withErrorTracking)
+	}
+	if withSecrets != "" {
+		fmt.Printf("✓ Secrets read from %s\n", withSecrets)
+	}
+
+	if imported != nil {
+		fmt.Printf("✓ Imported %s: %d entities, %d operations\n", newFromOpenAPI, len(imported.entities), len(imported.operations))
+	}
+	if protos != nil {
+		fmt.Printf("✓ Imported %s: %d .proto files, %d gRPC services\n", protoSource, len(protos.protos), len(protos.services))
+	}
 
 	fmt.Println("\nGenerating service structure...")
 
 	if err := generator.GenerateService(); err != nil {
-		return fmt.Errorf("failed to generate service: %w", err)
+		return &GenerationError{fmt.Errorf("failed to generate service: %w", err)}
+	}
+	if previous != nil {
+		reportRegeneration(generator.Changes())
+	}
+
+	if err := writeProjectLock(fullOutputDir); err != nil {
+		return &GenerationError{fmt.Errorf("failed to write %s: %w", projectLockFile, err)}
+	}
+	if imported != nil {
+		if err := imported.writeOpenAPISpec(fullOutputDir); err != nil {
+			return &GenerationError{fmt.Errorf("failed to write %s: %w", imported.spec, err)}
+		}
+	}
+
+	result := newResult{Service: serviceName, Type: serviceType, Directory: fullOutputDir, Features: map[string]string{}}
+	for _, feature := range features {
+		if flag := cmd.Flags().Lookup(strings.TrimPrefix(feature.Flag, "--")); flag != nil && flag.Value.String() != "" {
+			result.Features[feature.Name] = flag.Value.String()
+		}
+	}
+	if imported != nil {
+		result.Imported = imported.result()
+	}
+	if protos != nil {
+		if result.Imported == nil {
+			result.Imported = &importResult{}
+		}
+		protos.report(result.Imported)
 	}
+	reportResult(result)
 
 	fmt.Printf("\n✓ Service '%s' generated successfully!\n", serviceName)
 	fmt.Printf("\n✓ Core libraries automatically integrated:\n")
@@ -236,11 +492,64 @@ func runNew(cmd *cobra.Command, args []string) error {
 	fmt.Printf("3. cp .env.example .env\n")
 	fmt.Printf("4. Edit .env with your configuration\n")
 	fmt.Printf("5. go run cmd/main.go\n")
+	step := 6
+	if len(config.Operations) > 0 {
+		fmt.Printf("%d. Implement the operations of %s in internal/handlers/api.go and register them: handlers.NewAPIHandler().RegisterRoutes(router)\n", step, config.OpenAPISpec)
+		step++
+	}
+	if len(config.GRPCServices) > 0 {
+		fmt.Printf("%d. Implement the methods of the gRPC services in internal/handlers/*_server.go\n", step)
+	}
 	fmt.Printf("\nFor more information, see the README.md file.\n")
 
 	return nil
 }
 
+// reportRegeneration prints what an incremental generation did to the files that it did not
+// leave unchanged, and warns about the local changes it kept
+func reportRegeneration(changes []generator.FileChange) {
+	fmt.Println("\nRegenerated from the generation manifest:")
+	unchanged := 0
+	for _, change := range changes {
+		switch change.Action {
+		case generator.FileUnchanged:
+			unchanged++
+			continue
+		case generator.FileModified:
+			warnf("%s changed since it was generated and was kept; --force overwrites it", change.Path)
+		case generator.FileDeleted:
+			warnf("%s was deleted since it was generated and was not created again; --force creates it", change.Path)
+		}
+		fmt.Printf("  %-11s %s\n", change.Action, change.Path)
+	}
+	fmt.Printf("✓ %d files unchanged\n", unchanged)
+}
+
+// openFeatureProviders are the providers of --openfeature-provider
+var openFeatureProviders = []string{"env", "file", "flagd", "launchdarkly"}
+
+// newOIDCClient returns the OIDC client of a service of --with-auth oidc: the redirect URLs,
+// scopes and roles of the flags, else those the project was generated with, the scopes of the
+// security requirements of an imported spec besides profile and email, or the defaults
+func newOIDCClient(cmd *cobra.Command, previous *generator.Manifest, imported *openAPIImport) *generator.OIDCClient {
+	redirectURLs, scopes, roles := oidcRedirectURLs, oidcScopes, oidcRoles
+	if previous != nil && previous.Config.OIDC != nil {
+		if !cmd.Flags().Changed("oidc-redirect-url") {
+			redirectURLs = previous.Config.OIDC.RedirectURLs
+		}
+		if !cmd.Flags().Changed("oidc-scopes") {
+			scopes = previous.Config.OIDC.Scopes
+		}
+		if !cmd.Flags().Changed("oidc-roles") {
+			roles = previous.Config.OIDC.Roles
+		}
+	}
+	if imported != nil && len(imported.scopes) > 0 && !cmd.Flags().Changed("oidc-scopes") {
+		scopes = append([]string{"profile", "email"}, imported.scopes...)
+	}
+	return generator.NewOIDCClient(redirectURLs, scopes, roles)
+}
+
 // validateServiceName validates the service name
 func validateServiceName(name string) error {
 	if name == "" {
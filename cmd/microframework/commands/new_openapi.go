@@ -0,0 +1,468 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+// newFromOpenAPI is the OpenAPI spec new imports the service from
+var newFromOpenAPI string
+
+// openAPIFieldPattern is the pattern of the entity field names
+var openAPIFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// openAPIMethods are the methods of the operations of a path item
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIImport is what new --from-openapi derives from an OpenAPI spec
+type openAPIImport struct {
+	// spec is where the spec is copied in the project, as api/openapi.yaml
+	spec    string
+	content []byte
+	// entities are derived from the schemas with an id property
+	entities []generator.Entity
+	// operations are the operations of the paths the entities do not serve
+	operations []generator.Operation
+	// authProvider is the provider of the auth feature the security schemes map to
+	authProvider string
+	// scopes are the scopes the security requirements of the OAuth 2 and OpenID Connect
+	// schemes require, which the OIDC client of --with-auth oidc requests
+	scopes []string
+}
+
+// importResult is what new imported from an OpenAPI spec or .proto files, in the JSON report
+type importResult struct {
+	Spec       string   `json:"spec,omitempty"`
+	Entities   []string `json:"entities,omitempty"`
+	Operations []string `json:"operations,omitempty"`
+	Auth       string   `json:"auth,omitempty"`
+	// Protos are the .proto files and Services the gRPC services they declare
+	Protos   []string `json:"protos,omitempty"`
+	Services []string `json:"services,omitempty"`
+}
+
+// importOpenAPI derives the entities, operations and auth provider of a service from the
+// OpenAPI 3 spec at path. What the spec has that a service cannot be generated from is
+// skipped with a warning.
+func importOpenAPI(path string) (*openAPIImport, error) {
+	api, err := parseMockAPI(path)
+	if err != nil {
+		return nil, &UserError{err}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := &openAPIImport{spec: "api/openapi.yaml", content: content}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		imported.spec = "api/openapi.json"
+	}
+	components := asMap(api.document["components"])
+	imported.entities = api.entities(asMap(components["schemas"]))
+	if err := generator.ValidateEntities(imported.entities); err != nil {
+		return nil, &UserError{fmt.Errorf("%s: %w", path, err)}
+	}
+	imported.operations = api.handlerOperations(imported.entities)
+	imported.authProvider = api.authProvider(asMap(components["securitySchemes"]))
+	imported.scopes = api.scopes(asMap(components["securitySchemes"]))
+	return imported, nil
+}
+
+// result returns what was imported, for the JSON report
+func (i *openAPIImport) result() *importResult {
+	result := &importResult{Spec: i.spec, Auth: i.authProvider}
+	for _, entity := range i.entities {
+		result.Entities = append(result.Entities, entity.Name)
+	}
+	for _, operation := range i.operations {
+		result.Operations = append(result.Operations, operation.Method+" "+operation.Path)
+	}
+	return result
+}
+
+// entities returns the entities of the schemas of a spec: the object schemas with an id
+// property, the resources of the API, rather than its requests, lists or errors
+func (api *mockAPI) entities(schemas map[string]interface{}) []generator.Entity {
+	names := make(map[string]string)
+	for _, name := range sortedKeys(schemas) {
+		if _, ok := asMap(api.resolve(asMap(schemas[name]))["properties"])["id"]; !ok {
+			continue
+		}
+		entityName := pascalName(name)
+		if entityName == "" || !unicode.IsUpper(rune(entityName[0])) {
+			warnf("schema %s has no name an entity can take; skipped", name)
+			continue
+		}
+		names["#/components/schemas/"+name] = entityName
+	}
+
+	var entities []generator.Entity
+	for _, name := range sortedKeys(schemas) {
+		entityName, ok := names["#/components/schemas/"+name]
+		if !ok {
+			continue
+		}
+		schema := api.resolve(asMap(schemas[name]))
+		properties := asMap(schema["properties"])
+		required := make(map[string]bool)
+		for _, property := range stringList(schema["required"]) {
+			required[property] = true
+		}
+
+		entity := generator.Entity{Name: entityName}
+		for _, property := range sortedKeys(properties) {
+			fieldName := strings.ReplaceAll(toSnakeCase(property), "-", "_")
+			if containsString([]string{"id", "created_at", "updated_at", "deleted_at"}, fieldName) {
+				continue
+			}
+			if !openAPIFieldPattern.MatchString(fieldName) {
+				warnf("schema %s: property %s has no name a field can take; skipped", name, property)
+				continue
+			}
+
+			schema := asMap(properties[property])
+			if related, ok := names[schemaRef(schema)]; ok {
+				entity.Relations = append(entity.Relations, generator.EntityRelation{Name: fieldName, Kind: generator.RelationBelongsTo, Entity: related})
+				continue
+			}
+			if items := asMap(api.resolve(schema)["items"]); items != nil {
+				if related, ok := names[schemaRef(items)]; ok {
+					entity.Relations = append(entity.Relations, generator.EntityRelation{Name: fieldName, Kind: generator.RelationManyToMany, Entity: related})
+					continue
+				}
+			}
+			entity.Fields = append(entity.Fields, api.entityField(fieldName, schema, required[property]))
+		}
+
+		// The foreign keys of the belongs_to relations are generated with them
+		fields := entity.Fields[:0]
+		for _, field := range entity.Fields {
+			foreignKey := false
+			for _, relation := range entity.Relations {
+				foreignKey = foreignKey || relation.Kind == generator.RelationBelongsTo && field.Name == relation.Name+"_id"
+			}
+			if !foreignKey {
+				fields = append(fields, field)
+			}
+		}
+		entity.Fields = fields
+		if len(entity.Fields) == 0 {
+			warnf("schema %s has no property besides its id and relations; skipped", name)
+			continue
+		}
+		entities = append(entities, entity)
+	}
+
+	// Relations to the entities skipped are dropped
+	kept := make(map[string]bool)
+	for _, entity := range entities {
+		kept[entity.Name] = true
+	}
+	for i := range entities {
+		relations := entities[i].Relations[:0]
+		for _, relation := range entities[i].Relations {
+			if kept[relation.Entity] {
+				relations = append(relations, relation)
+			}
+		}
+		entities[i].Relations = relations
+	}
+	return entities
+}
+
+// entityField returns the entity field of a property schema, its type and validations
+func (api *mockAPI) entityField(name string, schema map[string]interface{}, required bool) generator.EntityField {
+	schema = api.resolve(schema)
+	field := generator.EntityField{Name: name, Type: "json", Required: required}
+	format, _ := schema["format"].(string)
+	switch schemaType(schema) {
+	case "integer":
+		field.Type = "int"
+		field.Validate = numberRules(schema)
+	case "number":
+		field.Type = "float"
+		field.Validate = numberRules(schema)
+	case "boolean":
+		field.Type = "bool"
+	case "string":
+		field.Type = "string"
+		switch format {
+		case "date-time", "date":
+			field.Type = "time"
+		case "uuid":
+			field.Type = "uuid"
+		case "email":
+			field.Validate = append(field.Validate, "email")
+		case "uri", "url":
+			field.Validate = append(field.Validate, "url")
+		}
+		if maxLength, ok := numberOf(schema["maxLength"]); ok && maxLength > 255 && field.Type == "string" {
+			field.Type = "text"
+		}
+		if minLength, ok := numberOf(schema["minLength"]); ok && field.Type != "time" {
+			field.Validate = append(field.Validate, "min="+formatNumber(minLength))
+		}
+		if maxLength, ok := numberOf(schema["maxLength"]); ok && field.Type != "time" {
+			field.Validate = append(field.Validate, "max="+formatNumber(maxLength))
+		}
+		if values := stringList(schema["enum"]); len(values) > 0 {
+			rule := "oneof=" + strings.Join(values, " ")
+			if len(strings.Fields(rule[len("oneof="):])) == len(values) && !strings.ContainsAny(rule, ",`\"") {
+				field.Validate = append(field.Validate, rule)
+			}
+		}
+	}
+	return field
+}
+
+// handlerOperations returns the operations of the paths of a spec the API handler serves,
+// those the CRUD routes of the entities do not, by path and method
+func (api *mockAPI) handlerOperations(entities []generator.Entity) []generator.Operation {
+	// Gin names the wildcards at the same place of every route alike, as the entity routes do
+	served := make(map[string]bool)
+	wildcards := make(map[string]string)
+	for _, entity := range entities {
+		route := entity.Route()
+		for _, key := range []string{"POST " + route, "GET " + route, "GET " + route + "/{}", "PATCH " + route + "/{}", "DELETE " + route + "/{}"} {
+			served[key] = true
+		}
+		wildcards[route+"/{}"] = "id"
+	}
+
+	paths := asMap(api.document["paths"])
+	names := map[string]bool{"RegisterRoutes": true}
+	var operations []generator.Operation
+	for _, path := range sortedKeys(paths) {
+		item := api.resolve(asMap(paths[path]))
+		for _, method := range openAPIMethods {
+			spec, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			operation := generator.Operation{Method: strings.ToUpper(method), Path: path}
+			if method == "trace" {
+				warnf("%s %s: gin has no route method for %s; skipped", operation.Method, path, operation.Method)
+				continue
+			}
+
+			normalized, route, valid := ginRoute(path, wildcards)
+			if !valid {
+				warnf("%s %s: the path has no gin route; skipped", operation.Method, path)
+				continue
+			}
+			if served[operation.Method+" "+normalized] {
+				continue
+			}
+			operation.Route = route
+
+			operationID, _ := spec["operationId"].(string)
+			operation.Name = pascalName(operationID)
+			if operation.Name == "" || !unicode.IsUpper(rune(operation.Name[0])) {
+				operation.Name = pascalName(method + " " + strings.NewReplacer("{", "by ", "}", "").Replace(path))
+			}
+			for base, n := operation.Name, 2; names[operation.Name]; n++ {
+				operation.Name = base + strconv.Itoa(n)
+			}
+			names[operation.Name] = true
+
+			summary, _ := spec["summary"].(string)
+			operation.Summary = strings.Join(strings.Fields(summary), " ")
+			requirements, ok := spec["security"]
+			if !ok {
+				requirements = api.document["security"]
+			}
+			for _, requirement := range mapSlice(requirements) {
+				operation.Security = append(operation.Security, sortedKeys(requirement)...)
+			}
+			operation.Security = uniqueSorted(operation.Security)
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// ginRoute returns the gin route of a path template, as /pets/:id for /pets/{petId}, and its
+// normalized form, as /pets/{}. wildcards are the names of the wildcards of the routes by
+// normalized prefix: gin names the wildcards at the same place of every route alike. A path
+// with segments gin cannot match is not valid.
+func ginRoute(path string, wildcards map[string]string) (normalized, route string, valid bool) {
+	var normalizedPath, routePath strings.Builder
+	valid = !strings.ContainsAny(path, "\"\\`")
+	named := make(map[string]bool)
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "":
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			normalizedPath.WriteString("/{}")
+			name, ok := wildcards[normalizedPath.String()]
+			if !ok {
+				name = strings.Map(func(r rune) rune {
+					if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+						return r
+					}
+					return '_'
+				}, segment[1:len(segment)-1])
+				// The wildcards of a route are named apart
+				for base, n := name, 2; named[name]; n++ {
+					name = base + strconv.Itoa(n)
+				}
+				wildcards[normalizedPath.String()] = name
+			}
+			named[name] = true
+			routePath.WriteString("/:" + name)
+		case strings.ContainsAny(segment, "{}:*"):
+			valid = false
+		default:
+			normalizedPath.WriteString("/" + segment)
+			routePath.WriteString("/" + segment)
+		}
+	}
+	route = routePath.String()
+	if route == "" {
+		route = "/"
+	}
+	return normalizedPath.String(), route, valid
+}
+
+// authProvider returns the provider of the auth feature the security schemes of a spec map
+// to: jwt for bearer tokens, oauth for OAuth 2 and oidc for OpenID Connect
+func (api *mockAPI) authProvider(schemes map[string]interface{}) string {
+	var providers []string
+	for _, name := range sortedKeys(schemes) {
+		scheme := api.resolve(asMap(schemes[name]))
+		kind, _ := scheme["type"].(string)
+		httpScheme, _ := scheme["scheme"].(string)
+		switch {
+		case kind == "http" && strings.EqualFold(httpScheme, "bearer"):
+			providers = append(providers, "jwt")
+		case kind == "oauth2":
+			providers = append(providers, "oauth")
+		case kind == "openIdConnect":
+			providers = append(providers, "oidc")
+		default:
+			warnf("security scheme %s (%s) has no auth provider; check it in the middleware", name, strings.TrimSpace(kind+" "+httpScheme))
+		}
+	}
+	providers = uniqueSorted(providers)
+	if len(providers) > 1 {
+		warnf("the security schemes use %s; the service is generated with %s", strings.Join(providers, " and "), providers[0])
+	}
+	if len(providers) == 0 {
+		return ""
+	}
+	return providers[0]
+}
+
+// scopes returns the scopes the security requirements of the spec and its operations require
+// of its OAuth 2 and OpenID Connect schemes, sorted
+func (api *mockAPI) scopes(schemes map[string]interface{}) []string {
+	requirements := mapSlice(api.document["security"])
+	paths := asMap(api.document["paths"])
+	for _, path := range sortedKeys(paths) {
+		item := api.resolve(asMap(paths[path]))
+		for _, method := range openAPIMethods {
+			if spec, ok := item[method].(map[string]interface{}); ok {
+				requirements = append(requirements, mapSlice(spec["security"])...)
+			}
+		}
+	}
+	var scopes []string
+	for _, requirement := range requirements {
+		for name, required := range requirement {
+			kind, _ := api.resolve(asMap(schemes[name]))["type"].(string)
+			if kind != "oauth2" && kind != "openIdConnect" {
+				continue
+			}
+			list, _ := required.([]interface{})
+			for _, scope := range list {
+				if s, ok := scope.(string); ok && s != "" {
+					scopes = append(scopes, s)
+				}
+			}
+		}
+	}
+	return uniqueSorted(scopes)
+}
+
+// schemaRef returns the $ref of a schema, also when it is the only schema of an allOf
+func schemaRef(schema map[string]interface{}) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return ref
+	}
+	if all := mapSlice(schema["allOf"]); len(all) == 1 {
+		ref, _ := all[0]["$ref"].(string)
+		return ref
+	}
+	return ""
+}
+
+// schemaType returns the type of a schema, the first that is not null of an OpenAPI 3.1
+// type list
+func schemaType(schema map[string]interface{}) string {
+	switch kind := schema["type"].(type) {
+	case string:
+		return kind
+	case []interface{}:
+		for _, item := range kind {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// numberRules returns the validations of the bounds of a number schema
+func numberRules(schema map[string]interface{}) []string {
+	var rules []string
+	if minimum, ok := numberOf(schema["minimum"]); ok {
+		rules = append(rules, "gte="+formatNumber(minimum))
+	}
+	if maximum, ok := numberOf(schema["maximum"]); ok {
+		rules = append(rules, "lte="+formatNumber(maximum))
+	}
+	return rules
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// stringList returns the strings of a list of the spec
+func stringList(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var values []string
+	for _, item := range list {
+		if text, ok := item.(string); ok {
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+// pascalName returns the PascalCase Go name of a name of the spec, from its ASCII letters and
+// digits: pet_store, pet-store and petStore all give PetStore
+func pascalName(name string) string {
+	var builder strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		builder.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return builder.String()
+}
+
+// writeOpenAPISpec copies the imported spec into the project in dir
+func (i *openAPIImport) writeOpenAPISpec(dir string) error {
+	path := filepath.Join(dir, filepath.FromSlash(i.spec))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, i.content, 0644)
+}
@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// newFromProto is the directory of the .proto files new imports the service from
+var newFromProto string
+
+// protoDir is where the .proto files are copied in the project, and compiled from again when
+// the project is regenerated
+const protoDir = "api/proto"
+
+// protocPlugins are the protoc plugins the .proto files are compiled with, by the package
+// installing them
+var protocPlugins = [][2]string{
+	{"protoc-gen-go", "google.golang.org/protobuf/cmd/protoc-gen-go@latest"},
+	{"protoc-gen-go-grpc", "google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest"},
+}
+
+// protoImport is what new --from-proto derives from a directory of .proto files
+type protoImport struct {
+	// protos are the .proto files, relative to the directory
+	protos   []string
+	services []generator.GRPCService
+	// files are the .proto files copied to api/proto and the Go code compiled from them in
+	// internal/pb, by path relative to the project root
+	files map[string][]byte
+}
+
+// importProto compiles the .proto files of dir, and their imports found in dir, into the Go
+// packages of a service under internal/pb with protoc-gen-go and protoc-gen-go-grpc, which
+// are run from the PATH; protoc is not needed
+func importProto(dir, serviceName string) (*protoImport, error) {
+	imported := &protoImport{files: make(map[string][]byte)}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".proto" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		imported.protos = append(imported.protos, rel)
+		imported.files[protoDir+"/"+rel] = content
+		return nil
+	})
+	if err != nil {
+		return nil, &UserError{err}
+	}
+	if len(imported.protos) == 0 {
+		return nil, &UserError{fmt.Errorf("%s has no .proto files", dir)}
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{dir}}),
+	}
+	compiled, err := compiler.Compile(context.Background(), imported.protos...)
+	if err != nil {
+		return nil, &UserError{fmt.Errorf("failed to compile %s: %w", dir, err)}
+	}
+
+	// The Go package of each .proto file is the directory of the file under internal/pb,
+	// whatever its go_package option says
+	parameters := []string{"module=" + serviceName}
+	for _, name := range imported.protos {
+		importPath := serviceName + "/internal/pb"
+		if dir := path.Dir(name); dir != "." {
+			importPath += "/" + dir
+		}
+		parameters = append(parameters, "M"+name+"="+importPath)
+	}
+	request := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: imported.protos,
+		Parameter:      proto.String(strings.Join(parameters, ",")),
+	}
+	added := make(map[string]bool)
+	var addFile func(file protoreflect.FileDescriptor)
+	addFile = func(file protoreflect.FileDescriptor) {
+		if added[file.Path()] {
+			return
+		}
+		added[file.Path()] = true
+		// The files are listed after the files they import, as protoc lists them
+		for i := 0; i < file.Imports().Len(); i++ {
+			addFile(file.Imports().Get(i).FileDescriptor)
+		}
+		request.ProtoFile = append(request.ProtoFile, protodesc.ToFileDescriptorProto(file))
+	}
+	for _, file := range compiled {
+		addFile(file)
+	}
+
+	for _, plugin := range protocPlugins {
+		files, err := runProtocPlugin(plugin[0], plugin[1], request)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			imported.files[file.GetName()] = []byte(file.GetContent())
+		}
+	}
+
+	gen, err := protogen.Options{}.New(request)
+	if err != nil {
+		return nil, &UserError{fmt.Errorf("%s: %w", dir, err)}
+	}
+	imported.services = protoServices(gen)
+	if len(imported.services) == 0 {
+		return nil, &UserError{fmt.Errorf("the .proto files of %s declare no service", dir)}
+	}
+	// The servers of the services are named after them in a single package
+	declared := make(map[string]string)
+	for _, service := range imported.services {
+		if other, ok := declared[service.Name]; ok {
+			return nil, &UserError{fmt.Errorf("%s and %s both declare a service %s", other, service.Proto, service.Name)}
+		}
+		declared[service.Name] = service.Proto
+	}
+	return imported, nil
+}
+
+// runProtocPlugin runs a protoc plugin on a request, as protoc does, and returns the files it
+// generated
+func runProtocPlugin(name, pkg string, request *pluginpb.CodeGeneratorRequest) ([]*pluginpb.CodeGeneratorResponse_File, error) {
+	plugin, err := exec.LookPath(name)
+	if err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("%s is not installed; install it with go install %s", name, pkg)}
+	}
+	input, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(plugin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, &GenerationError{fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))}
+	}
+	var response pluginpb.CodeGeneratorResponse
+	if err := proto.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, &GenerationError{fmt.Errorf("%s: %w", name, err)}
+	}
+	if response.Error != nil {
+		return nil, &UserError{fmt.Errorf("%s: %s", name, response.GetError())}
+	}
+	return response.File, nil
+}
+
+// protoServices returns the services of the files to generate of a request, their Go packages
+// aliased after their names, numbered when the name is taken
+func protoServices(gen *protogen.Plugin) []generator.GRPCService {
+	names := make(map[protogen.GoImportPath]protogen.GoPackageName)
+	for _, file := range gen.Files {
+		names[file.GoImportPath] = file.GoPackageName
+	}
+	aliases := make(map[protogen.GoImportPath]string)
+	// The packages the handlers import already are not taken as aliases
+	taken := map[string]bool{"context": true, "grpc": true, "codes": true, "status": true, "handlers": true}
+	alias := func(importPath protogen.GoImportPath) string {
+		if name, ok := aliases[importPath]; ok {
+			return name
+		}
+		name := string(names[importPath])
+		for n := 2; taken[name]; n++ {
+			name = string(names[importPath]) + strconv.Itoa(n)
+		}
+		aliases[importPath], taken[name] = name, true
+		return name
+	}
+
+	var services []generator.GRPCService
+	for _, file := range gen.Files {
+		if !file.Generate {
+			continue
+		}
+		for _, service := range file.Services {
+			grpcService := generator.GRPCService{
+				Name:    service.GoName,
+				Proto:   protoDir + "/" + file.Desc.Path(),
+				Package: alias(file.GoImportPath),
+			}
+			imports := map[protogen.GoImportPath]bool{file.GoImportPath: true}
+			goType := func(ident protogen.GoIdent) string {
+				imports[ident.GoImportPath] = true
+				return alias(ident.GoImportPath) + "." + ident.GoName
+			}
+			for _, method := range service.Methods {
+				grpcMethod := generator.GRPCMethod{
+					Name:            method.GoName,
+					ClientStreaming: method.Desc.IsStreamingClient(),
+					ServerStreaming: method.Desc.IsStreamingServer(),
+				}
+				// Only the messages in the signature of the server method are imported
+				if !grpcMethod.ClientStreaming {
+					grpcMethod.Input = goType(method.Input.GoIdent)
+				}
+				if grpcMethod.Unary() {
+					grpcMethod.Output = goType(method.Output.GoIdent)
+				}
+				grpcService.Methods = append(grpcService.Methods, grpcMethod)
+			}
+			for importPath := range imports {
+				grpcService.Imports = append(grpcService.Imports, generator.GoImport{Alias: alias(importPath), Path: string(importPath)})
+			}
+			sort.Slice(grpcService.Imports, func(i, j int) bool { return grpcService.Imports[i].Path < grpcService.Imports[j].Path })
+			services = append(services, grpcService)
+		}
+	}
+	return services
+}
+
+// report adds what was imported to the JSON report
+func (i *protoImport) report(result *importResult) {
+	result.Protos = i.protos
+	for _, service := range i.services {
+		result.Services = append(result.Services, service.Name)
+	}
+}
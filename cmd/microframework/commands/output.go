@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the global --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// outputEnv selects the output format when --output is not given
+const outputEnv = "MICROFRAMEWORK_OUTPUT"
+
+// outputDirectoryAnnotation marks the commands generating in the directory of their --dir
+// flag, whose -o named that directory before it was the output format; -o is still taken as
+// --dir there, with a deprecation warning
+const outputDirectoryAnnotation = "output-directory"
+
+// commandReport is what a command prints on the standard output in JSON output mode, once it
+// is done; everything it would print in text mode goes to the standard error instead
+type commandReport struct {
+	Command  string       `json:"command"`
+	Success  bool         `json:"success"`
+	Result   interface{}  `json:"result,omitempty"`
+	Files    []string     `json:"files,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+	Error    *reportError `json:"error,omitempty"`
+}
+
+// reportError is the error of a failed command in its report
+type reportError struct {
+	Message string `json:"message"`
+	// Category is one of the ErrorCategory constants
+	Category string `json:"category"`
+	ExitCode int    `json:"exit_code"`
+}
+
+var (
+	// dirShorthandUsed is set when -o named the output directory of the command, as --dir
+	dirShorthandUsed bool
+	// jsonReport collects the results of the running command in JSON output mode; nil in text
+	// mode
+	jsonReport *commandReport
+	// reportOutput is the standard output the report is printed on
+	reportOutput io.Writer = os.Stdout
+)
+
+// setupOutput selects the output format of the command about to run, and starts streaming
+// its progress with --progress
+func setupOutput(cmd *cobra.Command, args []string) error {
+	usageHint = fmt.Sprintf("Run '%s --help' for usage.", cmd.CommandPath())
+
+	if err := setupOutputFormat(cmd); err != nil {
+		return err
+	}
+	if dirShorthandUsed {
+		warnf("-o is a deprecated alias of --dir for %s; use --dir", cmd.Name())
+	}
+	return setupProgress(cmd)
+}
+
+// setupOutputFormat selects the output format of the command. In JSON output mode the
+// standard output is redirected to the standard error, so that the report is the only thing
+// on it.
+func setupOutputFormat(cmd *cobra.Command) error {
+	format := os.Getenv(outputEnv)
+	if flag := cmd.Flags().Lookup("output"); flag != nil && flag == cmd.Root().PersistentFlags().Lookup("output") {
+		if flag.Changed || format == "" {
+			format = flag.Value.String()
+		}
+	} else {
+		// The command has an --output flag of its own
+		return nil
+	}
+
+	switch strings.ToLower(format) {
+	case "", OutputText:
+		return nil
+	case OutputJSON:
+	default:
+		return &UserError{fmt.Errorf("invalid output format %q (text, json)", format)}
+	}
+
+	jsonReport = &commandReport{Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")}
+	reportOutput = os.Stdout
+	os.Stdout = os.Stderr
+	return nil
+}
+
+// rewriteDirShorthand returns the arguments of the CLI with -o replaced by --dir when they
+// run a command with outputDirectoryAnnotation, as -o named its directory before
+func rewriteDirShorthand(root *cobra.Command, args []string) []string {
+	cmd, _, err := root.Find(args)
+	if err != nil || cmd.Annotations[outputDirectoryAnnotation] == "" {
+		return args
+	}
+	rewritten := make([]string, 0, len(args))
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(rewritten, args[i:]...)
+		case arg == "-o":
+			arg = "--dir"
+		case strings.HasPrefix(arg, "-o="):
+			arg = "--dir=" + strings.TrimPrefix(arg, "-o=")
+		case strings.HasPrefix(arg, "-o"):
+			arg = "--dir=" + strings.TrimPrefix(arg, "-o")
+		default:
+			rewritten = append(rewritten, arg)
+			continue
+		}
+		dirShorthandUsed = true
+		rewritten = append(rewritten, arg)
+	}
+	return rewritten
+}
+
+// jsonOutput reports whether the running command prints a JSON report
+func jsonOutput() bool {
+	return jsonReport != nil
+}
+
+// reportResult sets the result of the running command in its JSON report
+func reportResult(result interface{}) {
+	if jsonReport != nil {
+		jsonReport.Result = result
+	}
+}
+
+// reportFiles adds files the running command wrote to its JSON report
+func reportFiles(paths ...string) {
+	if jsonReport == nil {
+		return
+	}
+	for _, path := range paths {
+		jsonReport.Files = append(jsonReport.Files, filepath.ToSlash(path))
+	}
+}
+
+// reportGeneratedFiles is a generator hook adding the generated files to the JSON report
+func reportGeneratedFiles(ctx *generator.HookContext) error {
+	for _, path := range ctx.Files {
+		reportFiles(filepath.Join(ctx.Dir, path))
+	}
+	return nil
+}
+
+// warnf prints a warning, which the JSON report lists too
+func warnf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	fmt.Printf("Warning: %s\n", message)
+	if jsonReport != nil {
+		jsonReport.Warnings = append(jsonReport.Warnings, message)
+	}
+}
+
+// writeReport ends the stream of progress events and prints the JSON report of the command,
+// with the error it failed with, in JSON output mode. It is called once the command is done,
+// including by the commands that exit on their own.
+func writeReport(err error) {
+	// After progress events, the report is one more line of the stream
+	indent := !streamingProgress() || progressVerbose
+	finishProgress()
+	if jsonReport == nil {
+		return
+	}
+	jsonReport.Success = err == nil
+	if err != nil {
+		category, code := errorCategory(err)
+		jsonReport.Error = &reportError{Message: err.Error(), Category: category, ExitCode: code}
+	}
+
+	encoder := json.NewEncoder(reportOutput)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	if encodeErr := encoder.Encode(jsonReport); encodeErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write the JSON report: %v\n", encodeErr)
+	}
+	jsonReport = nil
+}
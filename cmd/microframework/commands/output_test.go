@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteDirShorthand(t *testing.T) {
+	tests := []struct {
+		args     string
+		want     string
+		wantUsed bool
+	}{
+		{args: "new orders -o services", want: "new orders --dir services", wantUsed: true},
+		{args: "new orders -o=services --output json", want: "new orders --dir=services --output json", wantUsed: true},
+		{args: "generate service -oservices", want: "generate service --dir=services", wantUsed: true},
+		{args: "new orders --dir services --output json", want: "new orders --dir services --output json"},
+		{args: "new orders -- -o", want: "new orders -- -o"},
+		{args: "validate -o json", want: "validate -o json"},
+		{args: "describe -o json", want: "describe -o json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			dirShorthandUsed = false
+			defer func() { dirShorthandUsed = false }()
+			got := strings.Join(rewriteDirShorthand(rootCmd, strings.Fields(tt.args)), " ")
+			if got != tt.want || dirShorthandUsed != tt.wantUsed {
+				t.Errorf("rewriteDirShorthand(%q) = %q, used %v, want %q, used %v", tt.args, got, dirShorthandUsed, tt.want, tt.wantUsed)
+			}
+		})
+	}
+}
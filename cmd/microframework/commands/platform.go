@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+)
+
+// shellCommand returns the command running a shell command line: through sh -c, also on
+// Windows when sh is on the PATH, as it is with Git for Windows; else through PowerShell,
+// so that the hooks run in PowerShell-based CI
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS != "windows" {
+		return exec.Command("sh", "-c", command)
+	}
+	if sh, err := exec.LookPath("sh"); err == nil {
+		return exec.Command(sh, "-c", command)
+	}
+	powershell := "powershell"
+	if pwsh, err := exec.LookPath("pwsh"); err == nil {
+		powershell = pwsh
+	}
+	return exec.Command(powershell, "-NoProfile", "-NonInteractive", "-Command", command)
+}
+
+// withLineEndings returns content, written with \n line endings, with those of like: with \r\n
+// when like uses them, as files checked out on Windows do
+func withLineEndings(content, like []byte) []byte {
+	if !bytes.Contains(like, []byte("\r\n")) {
+		return content
+	}
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+}
@@ -0,0 +1,594 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pluginName    string
+	pluginVersion string
+	pluginRelease bool
+	pluginForce   bool
+	pluginOutput  string
+)
+
+// pluginPrefix starts the name of the executables of plugins: microframework-<plugin>
+const pluginPrefix = "microframework-"
+
+// pluginIndexFile records where the installed plugins come from, in the plugins directory
+const pluginIndexFile = "plugins.json"
+
+// pluginNamePattern is what a plugin name may be made of; dashes separate the words of
+// nested commands, microframework-nomad-deploy running as microframework nomad-deploy or
+// microframework nomad deploy
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// pluginCmd represents the plugin command
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage the plugins extending the CLI",
+	Long: `Manage the plugins extending the CLI with commands of their own.
+
+A plugin is an executable named microframework-<plugin>: microframework <plugin> [args] runs
+it with the arguments, when <plugin> is not a command of the CLI. Plugins are looked up in
+the plugins directory (~/.microframework/plugins, or $MICROFRAMEWORK_PLUGINS_DIR), then on
+PATH. They run with MICROFRAMEWORK_BIN, the path of the CLI, and MICROFRAMEWORK_VERSION set.
+
+Examples:
+  microframework plugin install github.com/acme/microframework-nomad
+  microframework plugin install acme/microframework-nomad --release --version v1.2.0
+  microframework plugin list
+  microframework plugin remove nomad`,
+}
+
+// pluginInstallCmd represents the plugin install command
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <source>",
+	Short: "Install a plugin from git, a GitHub release or a local path",
+	Long: `Install a plugin into the plugins directory. The source is:
+
+  a git repository     github.com/acme/microframework-nomad, or any URL git clones; the plugin
+                       is built with go build from ./cmd/microframework-<plugin>, or the root
+                       of the repository. --version checks out a branch or tag.
+  a GitHub release     owner/repo with --release: the asset
+                       microframework-<plugin>-<os>-<arch> of the latest release, or of
+                       --version, verified against the checksums.txt of the release
+  a local path         an executable, copied; or the directory of a Go module, built
+
+The plugin is named after the repository or file without the microframework- prefix, or
+--name. It cannot replace a command of the CLI.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginInstall,
+}
+
+// pluginListCmd represents the plugin list command
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins found in the plugins directory and on PATH",
+	Args:  cobra.NoArgs,
+	RunE:  runPluginList,
+}
+
+// pluginRemoveCmd represents the plugin remove command
+var pluginRemoveCmd = &cobra.Command{
+	Use:   "remove <plugin>",
+	Short: "Remove an installed plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPluginRemove,
+}
+
+func init() {
+	pluginInstallCmd.Flags().StringVar(&pluginName, "name", "", "Name of the plugin (default: from the source)")
+	pluginInstallCmd.Flags().StringVar(&pluginVersion, "version", "", "Branch or tag of a git source, or tag of a release")
+	pluginInstallCmd.Flags().BoolVar(&pluginRelease, "release", false, "Install the binary of a GitHub release of the owner/repo source")
+	pluginInstallCmd.Flags().BoolVar(&pluginForce, "force", false, "Replace an installed plugin of the same name")
+	pluginListCmd.Flags().StringVarP(&pluginOutput, "output", "o", "text", "Output format (text, json)")
+
+	pluginRemoveCmd.ValidArgsFunction = completePlugins
+
+	pluginCmd.AddCommand(pluginInstallCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginRemoveCmd)
+}
+
+// installedPlugin is the record of an installed plugin in the plugin index
+type installedPlugin struct {
+	Source string `json:"source"`
+	// Kind is git, release or local
+	Kind string `json:"kind"`
+	// Version is the tag of a release, or the commit a git source was built from
+	Version   string    `json:"version,omitempty"`
+	Installed time.Time `json:"installed"`
+}
+
+// foundPlugin is a plugin executable found in the plugins directory or on PATH
+type foundPlugin struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Warning tells why the plugin does not run
+	Warning string `json:"warning,omitempty"`
+}
+
+// pluginsDir returns the directory plugins are installed in
+func pluginsDir() (string, error) {
+	if dir := os.Getenv("MICROFRAMEWORK_PLUGINS_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the home directory: %w", err)
+	}
+	return filepath.Join(home, ".microframework", "plugins"), nil
+}
+
+// pluginExecutable returns the file name of the executable of a plugin
+func pluginExecutable(name string) string {
+	if runtime.GOOS == "windows" {
+		return pluginPrefix + name + ".exe"
+	}
+	return pluginPrefix + name
+}
+
+// isBuiltinCommand reports whether name is a command of the CLI, which plugins cannot replace
+func isBuiltinCommand(name string) bool {
+	switch name {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	for _, command := range rootCmd.Commands() {
+		if command.Name() == name || command.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookPlugin returns the executable of a plugin, from the plugins directory or PATH
+func lookPlugin(name string) (string, error) {
+	if dir, err := pluginsDir(); err == nil {
+		path := filepath.Join(dir, pluginExecutable(name))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return exec.LookPath(pluginPrefix + name)
+}
+
+// dispatchPlugin runs the plugin args name, when they do not name a command of the CLI. The
+// longest run of arguments naming a plugin wins: microframework nomad deploy up runs
+// microframework-nomad-deploy with up, over microframework-nomad with deploy up. It reports whether a plugin ran; the CLI exits with the
+// status of the plugin.
+func dispatchPlugin(args []string) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args[0]) {
+		return false, nil
+	}
+
+	var words []string
+	for _, arg := range args {
+		if !pluginNamePattern.MatchString(arg) {
+			break
+		}
+		words = append(words, arg)
+	}
+	for n := len(words); n > 0; n-- {
+		path, err := lookPlugin(strings.Join(words[:n], "-"))
+		if err != nil {
+			continue
+		}
+
+		plugin := exec.Command(path, args[n:]...)
+		plugin.Stdin, plugin.Stdout, plugin.Stderr = os.Stdin, os.Stdout, os.Stderr
+		plugin.Env = append(os.Environ(), "MICROFRAMEWORK_VERSION="+version)
+		if executable, err := os.Executable(); err == nil {
+			plugin.Env = append(plugin.Env, "MICROFRAMEWORK_BIN="+executable)
+		}
+		if err := plugin.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			return true, fmt.Errorf("failed to run %s: %w", path, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func runPluginInstall(cmd *cobra.Command, args []string) error {
+	source := args[0]
+	name := pluginName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(strings.TrimSuffix(source, "/")), ".git")
+		name = strings.TrimSuffix(strings.TrimPrefix(name, pluginPrefix), ".exe")
+	}
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid plugin name %q; give one with --name (lowercase letters, digits and dashes)", name)
+	}
+	if isBuiltinCommand(name) {
+		return fmt.Errorf("%s is a command of the CLI; give the plugin another name with --name", name)
+	}
+
+	dir, err := pluginsDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadPluginIndex(dir)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, pluginExecutable(name))
+	if _, err := os.Stat(target); err == nil && !pluginForce {
+		return fmt.Errorf("plugin %s is installed already; use --force to replace it", name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var record *installedPlugin
+	switch {
+	case pluginRelease:
+		record, err = installReleasePlugin(source, name, target)
+	case isLocalPath(source):
+		record, err = installLocalPlugin(source, target)
+	default:
+		record, err = installGitPlugin(source, name, target)
+	}
+	if err != nil {
+		return err
+	}
+
+	record.Installed = time.Now().UTC()
+	index[name] = record
+	if err := savePluginIndex(dir, index); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Installed plugin %s (%s)\n", name, target)
+	fmt.Printf("Run it with: microframework %s\n", name)
+	return nil
+}
+
+// isLocalPath reports whether a plugin source is a file or directory of this machine
+func isLocalPath(source string) bool {
+	if strings.Contains(source, "://") || strings.HasPrefix(source, "git@") {
+		return false
+	}
+	_, err := os.Stat(source)
+	return err == nil
+}
+
+// installReleasePlugin installs the binary of a GitHub release of repository for this platform
+func installReleasePlugin(repository, name, target string) (*installedPlugin, error) {
+	repository = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(repository, "https://"), "github.com/"), ".git")
+	if strings.Count(repository, "/") != 1 {
+		return nil, fmt.Errorf("a release source is a GitHub repository, owner/repo")
+	}
+	release, err := fetchGitHubRelease(repository, pluginVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errReleaseUnavailable, repository, err)
+	}
+
+	asset := fmt.Sprintf("%s%s-%s-%s", pluginPrefix, name, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	binaryURL := release.asset(asset)
+	if binaryURL == "" {
+		return nil, fmt.Errorf("%w (%s in release %s of %s)", errNoReleaseBinary, asset, release.TagName, repository)
+	}
+	checksumsURL := release.asset(releaseChecksumsAsset)
+	if checksumsURL == "" {
+		return nil, fmt.Errorf("release %s of %s has no %s; refusing to install an unverified binary", release.TagName, repository, releaseChecksumsAsset)
+	}
+	checksums, err := downloadReleaseAsset(checksumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", releaseChecksumsAsset, err)
+	}
+
+	fmt.Printf("Downloading %s %s...\n", asset, release.TagName)
+	binary, err := downloadReleaseAsset(binaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	if err := verifyReleaseChecksum(checksums, asset, binary); err != nil {
+		return nil, err
+	}
+	fmt.Println("✓ Checksum verified")
+
+	if err := writePluginExecutable(target, binary); err != nil {
+		return nil, err
+	}
+	return &installedPlugin{Source: "github.com/" + repository, Kind: "release", Version: release.TagName}, nil
+}
+
+// installLocalPlugin copies a local executable, or builds the Go module of a local directory
+func installLocalPlugin(source, target string) (*installedPlugin, error) {
+	absolute, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+	if isDirectory(source) {
+		if err := buildPlugin(source, filepath.Base(target), target); err != nil {
+			return nil, err
+		}
+		return &installedPlugin{Source: absolute, Kind: "local"}, nil
+	}
+
+	binary, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if err := writePluginExecutable(target, binary); err != nil {
+		return nil, err
+	}
+	return &installedPlugin{Source: absolute, Kind: "local"}, nil
+}
+
+// installGitPlugin clones a git repository and builds the plugin from it
+func installGitPlugin(source, name, target string) (*installedPlugin, error) {
+	url := source
+	if !strings.Contains(url, "://") && !strings.HasPrefix(url, "git@") {
+		url = "https://" + url
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("installing from git needs git: %w", err)
+	}
+
+	checkout, err := os.MkdirTemp("", "microframework-plugin-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the checkout directory: %w", err)
+	}
+	defer os.RemoveAll(checkout)
+
+	clone := []string{"clone", "--quiet", "--depth", "1"}
+	if pluginVersion != "" {
+		clone = append(clone, "--branch", pluginVersion)
+	}
+	fmt.Printf("Cloning %s...\n", url)
+	run := exec.Command("git", append(clone, url, checkout)...)
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Run(); err != nil {
+		return nil, fmt.Errorf("git clone %s failed: %w", url, err)
+	}
+	commit, _ := exec.Command("git", "-C", checkout, "rev-parse", "--short", "HEAD").Output()
+
+	if err := buildPlugin(checkout, pluginPrefix+name, target); err != nil {
+		return nil, err
+	}
+	return &installedPlugin{Source: source, Kind: "git", Version: strings.TrimSpace(string(commit))}, nil
+}
+
+// buildPlugin builds the plugin of the Go module in dir: its cmd/<command> package when there
+// is one, the root of the module otherwise
+func buildPlugin(dir, command, target string) error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return fmt.Errorf("building the plugin needs Go: %w", err)
+	}
+	pkg := "."
+	if isDirectory(filepath.Join(dir, "cmd", strings.TrimSuffix(command, ".exe"))) {
+		pkg = "./cmd/" + strings.TrimSuffix(command, ".exe")
+	}
+	absolute, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Building the plugin (%s)...\n", pkg)
+	build := exec.Command("go", "build", "-o", absolute, pkg)
+	build.Dir = dir
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("go build %s failed: %w", pkg, err)
+	}
+	return nil
+}
+
+// writePluginExecutable writes the executable of a plugin through a temporary file, so that a
+// failed install leaves the installed plugin alone
+func writePluginExecutable(target string, binary []byte) error {
+	next, err := os.CreateTemp(filepath.Dir(target), ".plugin-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the plugin: %w", err)
+	}
+	defer os.Remove(next.Name())
+	if _, err := next.Write(binary); err != nil {
+		next.Close()
+		return fmt.Errorf("failed to write the plugin: %w", err)
+	}
+	if err := next.Close(); err != nil {
+		return fmt.Errorf("failed to write the plugin: %w", err)
+	}
+	if err := os.Chmod(next.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to make the plugin executable: %w", err)
+	}
+	return os.Rename(next.Name(), target)
+}
+
+func runPluginList(cmd *cobra.Command, args []string) error {
+	if pluginOutput != "text" && pluginOutput != "json" {
+		return fmt.Errorf("invalid output format %q (text, json)", pluginOutput)
+	}
+	plugins, err := findPlugins()
+	if err != nil {
+		return err
+	}
+
+	if pluginOutput == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plugins)
+	}
+	if len(plugins) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No plugins found; install one with microframework plugin install")
+		return nil
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "PLUGIN\tVERSION\tSOURCE\tPATH\n")
+	for _, plugin := range plugins {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", plugin.Name, orDash(plugin.Version), orDash(plugin.Source), plugin.Path)
+	}
+	writer.Flush()
+	for _, plugin := range plugins {
+		if plugin.Warning != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Warning: %s: %s\n", plugin.Path, plugin.Warning)
+		}
+	}
+	return nil
+}
+
+// findPlugins returns the plugin executables of the plugins directory and PATH, in the order
+// they are looked up in
+func findPlugins() ([]foundPlugin, error) {
+	dir, err := pluginsDir()
+	if err != nil {
+		return nil, err
+	}
+	index, err := loadPluginIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []foundPlugin
+	seen := make(map[string]string)
+	for _, searched := range append([]string{dir}, filepath.SplitList(os.Getenv("PATH"))...) {
+		entries, err := os.ReadDir(searched)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginNameOf(searched, entry)
+			if !ok {
+				continue
+			}
+			plugin := foundPlugin{Name: name, Path: filepath.Join(searched, entry.Name())}
+			if record, ok := index[name]; ok && searched == dir {
+				plugin.Source, plugin.Version = record.Source, record.Version
+			}
+			switch shadowing, shadowed := seen[name]; {
+			case isBuiltinCommand(name):
+				plugin.Warning = "ignored, " + name + " is a command of the CLI"
+			case shadowed && shadowing != plugin.Path:
+				plugin.Warning = "shadowed by " + shadowing
+			case shadowed:
+				continue
+			default:
+				seen[name] = plugin.Path
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins, nil
+}
+
+// pluginNameOf returns the name of the plugin a directory entry is the executable of
+func pluginNameOf(dir string, entry os.DirEntry) (string, bool) {
+	file := entry.Name()
+	if entry.IsDir() || !strings.HasPrefix(file, pluginPrefix) {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(file), ".exe") {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	} else {
+		info, err := os.Stat(filepath.Join(dir, file))
+		if err != nil || info.Mode()&0111 == 0 {
+			return "", false
+		}
+	}
+	name := strings.TrimPrefix(file, pluginPrefix)
+	return name, pluginNamePattern.MatchString(name)
+}
+
+func runPluginRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	dir, err := pluginsDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadPluginIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(dir, pluginExecutable(name))
+	if err := os.Remove(target); err != nil {
+		if os.IsNotExist(err) {
+			if path, err := exec.LookPath(pluginPrefix + name); err == nil {
+				return fmt.Errorf("plugin %s is not installed in %s; remove %s yourself", name, dir, path)
+			}
+			return fmt.Errorf("plugin %s is not installed", name)
+		}
+		return fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+	delete(index, name)
+	if err := savePluginIndex(dir, index); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Removed plugin %s\n", name)
+	return nil
+}
+
+// loadPluginIndex reads the records of the installed plugins
+func loadPluginIndex(dir string) (map[string]*installedPlugin, error) {
+	index := make(map[string]*installedPlugin)
+	content, err := os.ReadFile(filepath.Join(dir, pluginIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pluginIndexFile, err)
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, pluginIndexFile), err)
+	}
+	return index, nil
+}
+
+// savePluginIndex writes the records of the installed plugins
+func savePluginIndex(dir string, index map[string]*installedPlugin) error {
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, pluginIndexFile), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pluginIndexFile, err)
+	}
+	return nil
+}
+
+// completePlugins completes the names of the installed plugins
+func completePlugins(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	dir, err := pluginsDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	index, err := loadPluginIndex(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, name := range sortedKeys(index) {
+		if strings.HasPrefix(name, toComplete) {
+			completions = append(completions, name+"\t"+index[name].Source)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
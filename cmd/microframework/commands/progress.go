@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// progressEvents receives the progress events of the running command with --progress
+	// json; nil otherwise
+	progressEvents chan progress.Event
+	// progressDone is closed once every progress event is written
+	progressDone chan struct{}
+	// progressVerbose is set when the progress events are printed as text for --verbose
+	progressVerbose bool
+)
+
+// setupProgress starts streaming the progress events of the command about to run, as one
+// JSON object per line on the standard output, when --progress json is given. The standard
+// output is redirected to the standard error, as in JSON output mode, so that the events are
+// the only thing on it, followed by the report in JSON output mode.
+func setupProgress(cmd *cobra.Command) error {
+	format, _ := cmd.Root().PersistentFlags().GetString("progress")
+	switch strings.ToLower(format) {
+	case "", "none":
+		if verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose"); verbose {
+			setupVerboseProgress()
+		}
+		return nil
+	case OutputJSON:
+	default:
+		return &UserError{fmt.Errorf("invalid progress format %q (json, none)", format)}
+	}
+
+	if jsonReport == nil {
+		reportOutput = os.Stdout
+		os.Stdout = os.Stderr
+	}
+	events, done := make(chan progress.Event), make(chan struct{})
+	go func(encoder *json.Encoder) {
+		defer close(done)
+		for event := range events {
+			if err := encoder.Encode(event); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to write a progress event: %v\n", err)
+			}
+		}
+	}(json.NewEncoder(reportOutput))
+	progressEvents, progressDone = events, done
+	return nil
+}
+
+// setupVerboseProgress prints each file the running command writes, with its size and how
+// long it took to write, for --verbose
+func setupVerboseProgress() {
+	events, done := make(chan progress.Event), make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range events {
+			if event.Type == progress.FileWritten {
+				fmt.Printf("  wrote %s (%d bytes in %s)\n", event.Path, event.Size, event.Duration.Round(time.Microsecond))
+			}
+		}
+	}()
+	progressEvents, progressDone, progressVerbose = events, done, true
+}
+
+// streamingProgress reports whether the running command streams its progress events
+func streamingProgress() bool {
+	return progressEvents != nil
+}
+
+// finishProgress ends the stream of progress events, once they are all written
+func finishProgress() {
+	if progressEvents == nil {
+		return
+	}
+	close(progressEvents)
+	<-progressDone
+	progressEvents, progressDone, progressVerbose = nil, nil, false
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// projectLockFile pins the versions of the tooling a project was generated with
+const projectLockFile = ".microframework.lock"
+
+// Locked components
+const (
+	LockCLI         = "cli"
+	LockGoMicroLibs = "go-micro-libs"
+	LockTemplates   = "templates"
+)
+
+const projectLockHeader = "# Generated by microframework. update keeps the project on these versions;\n" +
+	"# run update --pin to move them.\n"
+
+// projectLock is the content of .microframework.lock
+type projectLock struct {
+	CLI         string            `yaml:"cli"`
+	GoMicroLibs string            `yaml:"go-micro-libs"`
+	Templates   map[string]string `yaml:"templates"`
+	// Layout is the framework version whose project layout the project follows, once
+	// upgrade-project upgraded it
+	Layout string `yaml:"layout,omitempty"`
+}
+
+// loadProjectLock reads the lock file of the project in dir; it returns nil when there is none
+func loadProjectLock(dir string) (*projectLock, error) {
+	content, err := os.ReadFile(filepath.Join(dir, projectLockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", projectLockFile, err)
+	}
+
+	var lock projectLock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", projectLockFile, err)
+	}
+	return &lock, nil
+}
+
+// save writes the lock file of the project in dir
+func (l *projectLock) save(dir string) error {
+	var buf bytes.Buffer
+	buf.WriteString(projectLockHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, projectLockFile), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", projectLockFile, err)
+	}
+	return nil
+}
+
+// version returns the locked version of component, or "" when it is not locked
+func (l *projectLock) version(component string) string {
+	if l == nil {
+		return ""
+	}
+	switch component {
+	case LockCLI:
+		return l.CLI
+	case LockGoMicroLibs:
+		return l.GoMicroLibs
+	case LockTemplates:
+		return l.Templates[templates.Pack]
+	}
+	return ""
+}
+
+// set locks component to version
+func (l *projectLock) set(component, version string) {
+	switch component {
+	case LockCLI:
+		l.CLI = version
+	case LockGoMicroLibs:
+		l.GoMicroLibs = version
+	case LockTemplates:
+		if l.Templates == nil {
+			l.Templates = make(map[string]string)
+		}
+		l.Templates[templates.Pack] = version
+	}
+}
+
+// writeProjectLock locks a newly generated project to this CLI, its templates and the
+// go-micro-libs version its go.mod requires
+func writeProjectLock(dir string) error {
+	lock := &projectLock{CLI: version}
+	lock.set(LockTemplates, templates.Version)
+
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	file, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	for _, require := range file.Require {
+		if require.Mod.Path == "github.com/anasamu/go-micro-libs" {
+			lock.GoMicroLibs = require.Mod.Version
+		}
+	}
+
+	return lock.save(dir)
+}
+
+// sameVersion compares versions with or without their v prefix
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// lockedTarget returns the version update moves component to. A locked component stays on
+// its locked version; --pin moves it to the requested or latest version, and --locked
+// requires the lock to name it.
+func lockedTarget(lock *projectLock, component, requested string, latest func() (string, error)) (string, error) {
+	locked := lock.version(component)
+
+	if updateLocked {
+		if locked == "" {
+			return "", fmt.Errorf("--locked needs %s in %s", component, projectLockFile)
+		}
+		if requested != "" && !sameVersion(requested, locked) {
+			return "", fmt.Errorf("%s is locked to %s, not %s", component, locked, requested)
+		}
+		return locked, nil
+	}
+
+	if locked != "" && !updatePin {
+		if requested != "" && !sameVersion(requested, locked) {
+			return "", fmt.Errorf("%s is locked to %s in %s; pass --pin to move it to %s", component, locked, projectLockFile, requested)
+		}
+		return locked, nil
+	}
+
+	if requested != "" {
+		return requested, nil
+	}
+	return latest()
+}
+
+// pinVersion records the version component was updated to, with --pin
+func pinVersion(lock *projectLock, component, version string) error {
+	if !updatePin {
+		return nil
+	}
+	if lock == nil {
+		lock = &projectLock{}
+	}
+	if lock.version(component) == version {
+		return nil
+	}
+
+	lock.set(component, version)
+	if err := lock.save("."); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Pinned %s to %s in %s\n", component, version, projectLockFile)
+	return nil
+}
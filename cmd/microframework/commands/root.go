@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -36,7 +37,41 @@ Examples:
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	// Commands the CLI does not know run as plugins, when one of that name is found
+	if ran, err := dispatchPlugin(os.Args[1:]); ran || err != nil {
+		return err
+	}
+	// Invalid flags and arguments are user errors, which print a usage hint; every error is
+	// printed once, by main
+	rootCmd.SilenceErrors, rootCmd.SilenceUsage = true, true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &UserError{err}
+	})
+	wrapArgs(rootCmd)
+	rootCmd.SetArgs(rewriteDirShorthand(rootCmd, os.Args[1:]))
+
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		usageHint = fmt.Sprintf("Run '%s --help' for usage.", cmd.CommandPath())
+	}
+	writeReport(err)
+	return err
+}
+
+// wrapArgs makes the argument validation of a command and its subcommands fail with a
+// UserError
+func wrapArgs(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &UserError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		wrapArgs(sub)
+	}
 }
 
 func init() {
@@ -44,16 +79,45 @@ func init() {
 	rootCmd.AddCommand(newCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(benchCmd)
 	rootCmd.AddCommand(deployCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(scaleCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(describeCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(upgradeProjectCmd)
+	rootCmd.AddCommand(pluginCmd)
+	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(scaffoldCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(gatewayCmd)
+	rootCmd.AddCommand(mockServerCmd)
+	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(changelogCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(backstageCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(sbomCmd)
+	rootCmd.AddCommand(eventsCmd)
 
 	// Global flags
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file (default is $HOME/.microframework.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolP("dry-run", "", false, "show what would be done without making changes")
+	rootCmd.PersistentFlags().StringP("output", "o", OutputText, "output format (text, json); json prints a report on stdout and the logs on stderr")
+	rootCmd.PersistentFlags().String("progress", "none", "progress events of generate, deploy and update (json, none); json streams them on stdout, one per line, and the logs on stderr")
+	rootCmd.PersistentPreRunE = setupOutput
 }
 
 // GetRootCmd returns the root command for use in main.go
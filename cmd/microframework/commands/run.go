@@ -0,0 +1,437 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+var (
+	runMain        string
+	runTags        string
+	runEnvFile     string
+	runWatch       []string
+	runExtensions  []string
+	runDebounce    time.Duration
+	runPoll        time.Duration
+	runGrace       time.Duration
+	runDeps        bool
+	runComposeFile string
+)
+
+// runSkipDirs are the directories the dev server never watches
+var runSkipDirs = []string{".git", "vendor", "node_modules", "bin", "build", "dist", "tmp"}
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:     "run [-- service arguments]",
+	Aliases: []string{"dev"},
+	Short:   "Build and run the service, rebuilding and restarting it on change",
+	Long: `Build and run the microservice for local development.
+
+The service is built from --main and started with the variables of .env added to the
+environment (variables already set are kept). The source and configuration files are
+watched: once they stop changing for --debounce, the service is rebuilt and, when the
+build succeeds, restarted. A failed build keeps the running service.
+
+With --deps the dependencies of the docker-compose file (every service but the
+microservice itself) are started first, and left running on exit.
+
+Examples:
+  microframework run
+  microframework dev --deps
+  microframework run --tags microframework_minimal,microframework_cache
+  microframework run -- --port 9090`,
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().StringVar(&runMain, "main", "./cmd", "Package of the service's main")
+	runCmd.Flags().StringVar(&runTags, "tags", "", "Build tags, comma separated")
+	runCmd.Flags().StringVar(&runEnvFile, "env-file", ".env", "Environment file loaded into the service's environment")
+	runCmd.Flags().StringSliceVar(&runWatch, "watch", []string{"."}, "Directories to watch")
+	runCmd.Flags().StringSliceVar(&runExtensions, "ext", []string{".go", ".yaml", ".yml", ".json", ".sql", ".env", ".tmpl"}, "Extensions of the watched files")
+	runCmd.Flags().DurationVar(&runDebounce, "debounce", 500*time.Millisecond, "Quiet period after a change before rebuilding")
+	runCmd.Flags().DurationVar(&runPoll, "poll", 300*time.Millisecond, "Interval between scans of the watched files")
+	runCmd.Flags().DurationVar(&runGrace, "grace", 5*time.Second, "Time the service is given to stop before it is killed")
+	runCmd.Flags().BoolVar(&runDeps, "deps", false, "Start the docker-compose dependencies first")
+	runCmd.Flags().StringVar(&runComposeFile, "compose-file", "deployments/docker/docker-compose.yml", "docker-compose file of the dependencies")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("main") {
+		// An adopted project records where its main is
+		if manifest, err := generator.LoadManifest("."); err == nil && manifest.Config.MainPackage != "" {
+			runMain = manifest.Config.MainPackage
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name := path.Base(currentModulePath())
+	if name == "." || name == "/" {
+		name = "service"
+	}
+	if runDeps {
+		if err := startComposeDependencies(ctx, runComposeFile, name); err != nil {
+			return err
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "microframework-run-")
+	if err != nil {
+		return fmt.Errorf("failed to create the build directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	binary := filepath.Join(tmpDir, name)
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+
+	server := &devServer{binary: binary, args: args, grace: runGrace}
+	defer server.stop()
+	rebuild := func() {
+		fmt.Printf("Building %s...\n", runMain)
+		started := time.Now()
+		if err := buildService(ctx, runMain, runTags, binary+".next"); err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("Build failed, waiting for changes: %v\n", err)
+			}
+			return
+		}
+		fmt.Printf("Built in %s\n", time.Since(started).Round(time.Millisecond))
+
+		env, err := loadEnvFile(runEnvFile)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if err := server.restart(binary+".next", env); err != nil {
+			fmt.Printf("Failed to start the service: %v\n", err)
+		}
+	}
+
+	watcher := &fileWatcher{roots: runWatch, extensions: runExtensions}
+	snapshot, err := watcher.scan()
+	if err != nil {
+		return err
+	}
+	rebuild()
+
+	ticker := time.NewTicker(runPoll)
+	defer ticker.Stop()
+	var changed []string
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopping...")
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := watcher.scan()
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		if diff := snapshotChanges(snapshot, current); len(diff) > 0 {
+			changed = append(changed, diff...)
+			lastChange = time.Now()
+		}
+		snapshot = current
+		if len(changed) == 0 || time.Since(lastChange) < runDebounce {
+			continue
+		}
+		fmt.Printf("Changed: %s\n", summarizeChanges(changed))
+		changed = nil
+		rebuild()
+	}
+}
+
+// buildService builds the main package into binary
+func buildService(ctx context.Context, main, tags, binary string) error {
+	args := []string{"build", "-o", binary}
+	if tags != "" {
+		args = append(args, "-tags", tags)
+	}
+	build := exec.CommandContext(ctx, "go", append(args, main)...)
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	return build.Run()
+}
+
+// startComposeDependencies starts the services of a docker-compose file, except the service
+// itself, and waits for them to be up
+func startComposeDependencies(ctx context.Context, file, service string) error {
+	if _, err := os.Stat(file); err != nil {
+		return fmt.Errorf("no docker-compose file for --deps: %w", err)
+	}
+	compose, err := composeCommand()
+	if err != nil {
+		return fmt.Errorf("--deps needs %w", err)
+	}
+	up := []string{"up", "-d", "--wait"}
+	if compose[0] == "docker-compose" {
+		// docker-compose v1 cannot wait for the services to be healthy
+		up = []string{"up", "-d"}
+	}
+
+	output, err := exec.CommandContext(ctx, compose[0], append(compose[1:], "-f", file, "config", "--services")...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to read the services of %s: %w", file, err)
+	}
+	var dependencies []string
+	for _, name := range strings.Fields(string(output)) {
+		if name != service {
+			dependencies = append(dependencies, name)
+		}
+	}
+	if len(dependencies) == 0 {
+		fmt.Printf("No dependencies in %s\n", file)
+		return nil
+	}
+
+	fmt.Printf("Starting dependencies: %s\n", strings.Join(dependencies, ", "))
+	start := exec.CommandContext(ctx, compose[0], append(append(append(compose[1:], "-f", file), up...), dependencies...)...)
+	start.Stdout, start.Stderr = os.Stdout, os.Stderr
+	if err := start.Run(); err != nil {
+		return fmt.Errorf("failed to start the dependencies: %w", err)
+	}
+	fmt.Printf("Dependencies are left running; stop them with: %s -f %s down\n", strings.Join(compose, " "), file)
+	return nil
+}
+
+// composeCommand returns the docker compose command, or docker-compose where the compose
+// plugin is missing
+func composeCommand() ([]string, error) {
+	if exec.Command("docker", "compose", "version").Run() == nil {
+		return []string{"docker", "compose"}, nil
+	}
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("docker compose or docker-compose")}
+	}
+	return []string{"docker-compose"}, nil
+}
+
+// devServer is the running service
+type devServer struct {
+	binary string
+	args   []string
+	grace  time.Duration
+
+	process *exec.Cmd
+	exited  chan struct{}
+}
+
+// restart stops the running service, replaces its binary with build and starts it with env
+// added to the environment. The build is kept apart until then, since a running binary
+// cannot be replaced on every system.
+func (s *devServer) restart(build string, env []string) error {
+	s.stop()
+	if err := os.Rename(build, s.binary); err != nil {
+		return err
+	}
+
+	process := exec.Command(s.binary, s.args...)
+	process.Stdin, process.Stdout, process.Stderr = os.Stdin, os.Stdout, os.Stderr
+	process.Env = append(env, os.Environ()...)
+	if err := process.Start(); err != nil {
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		err := process.Wait()
+		close(exited)
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			fmt.Printf("Service exited: %v\n", exitErr)
+		case err == nil:
+			fmt.Println("Service exited")
+		}
+	}()
+	s.process, s.exited = process, exited
+	fmt.Printf("Service started (pid %d)\n", process.Process.Pid)
+	return nil
+}
+
+// stop interrupts the running service, killing it when it is still running after the grace
+// period
+func (s *devServer) stop() {
+	if s.process == nil {
+		return
+	}
+	process, exited := s.process, s.exited
+	s.process, s.exited = nil, nil
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+	if err := process.Process.Signal(os.Interrupt); err != nil {
+		process.Process.Kill()
+	}
+	select {
+	case <-exited:
+	case <-time.After(s.grace):
+		fmt.Printf("Service did not stop within %s, killing it\n", s.grace)
+		process.Process.Kill()
+		<-exited
+	}
+}
+
+// fileWatcher finds the changes of the watched files by comparing scans
+type fileWatcher struct {
+	roots      []string
+	extensions []string
+}
+
+// fileState identifies a version of a file
+type fileState struct {
+	modified time.Time
+	size     int64
+}
+
+// scan returns the state of the watched files
+func (w *fileWatcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	for _, root := range w.roots {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// A file removed during the scan is a change the next scan sees
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			name := entry.Name()
+			if entry.IsDir() {
+				if path != root && (strings.HasPrefix(name, ".") || containsString(runSkipDirs, name)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !w.watched(name) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			files[path] = fileState{modified: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return files, nil
+}
+
+// watched reports whether a file has a watched extension; .env files count whatever follows
+// the name
+func (w *fileWatcher) watched(name string) bool {
+	if name == ".env" || strings.HasPrefix(name, ".env.") {
+		return true
+	}
+	return containsString(w.extensions, filepath.Ext(name))
+}
+
+// snapshotChanges returns the files added, changed or removed between two scans
+func snapshotChanges(previous, current map[string]fileState) []string {
+	var changed []string
+	for path, state := range current {
+		if old, ok := previous[path]; !ok || old != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// summarizeChanges lists the changed files, a few of them when there are many
+func summarizeChanges(changed []string) string {
+	unique := make(map[string]bool)
+	var files []string
+	for _, file := range changed {
+		if !unique[file] {
+			unique[file] = true
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	if len(files) > 3 {
+		return fmt.Sprintf("%s and %d more", strings.Join(files[:3], ", "), len(files)-3)
+	}
+	return strings.Join(files, ", ")
+}
+
+// loadEnvFile reads KEY=VALUE lines of an environment file: blank lines and # comments are
+// skipped, an export prefix is allowed, and values may be single or double quoted. A missing
+// file is no error.
+func loadEnvFile(file string) ([]string, error) {
+	content, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(content)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return env, fmt.Errorf("%s:%d: expected KEY=VALUE", file, number)
+		}
+		value = strings.TrimSpace(value)
+		quote := byte(0)
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			quote = value[0]
+		}
+		switch end := strings.LastIndexByte(value, quote); {
+		case quote == '"' && end > 0:
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1:end])
+		case quote == '\'' && end > 0:
+			value = value[1:end]
+		default:
+			// An unquoted value ends at a comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		env = append(env, key+"="+value)
+	}
+	return env, scanner.Err()
+}
@@ -0,0 +1,365 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+)
+
+var (
+	sbomFormatName string
+	sbomOutput     string
+	sbomImage      string
+	sbomAttest     bool
+)
+
+// sbomFormat is a format of the SBOMs: the extension of its files, and its names for syft and
+// cosign attest
+type sbomFormat struct {
+	extension, syft, cosign string
+}
+
+// sbomFormats are the formats of microframework sbom --format
+var sbomFormats = map[string]sbomFormat{
+	"cyclonedx": {extension: ".cdx.json", syft: "cyclonedx-json", cosign: "cyclonedx"},
+	"spdx":      {extension: ".spdx.json", syft: "spdx-json", cosign: "spdxjson"},
+}
+
+// sbomCmd represents the sbom command
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Generate the SBOM of the service and its image",
+	Long: `Generate the software bill of materials (SBOM) of the service in the current directory,
+in CycloneDX or SPDX JSON.
+
+The SBOM of the service lists the modules of its build list, from go.mod, with their
+version, package URL and the license detected in their sources; the direct requirements of
+go.mod are the dependencies of the service. With --image, the SBOM of the image, its OS
+packages and binaries, is generated next to it with syft (https://github.com/anchore/syft),
+sbom-image.cdx.json for sbom.cdx.json. --attest attaches the SBOM of the image to the image
+in its registry as a signed attestation with cosign attest, which cosign verify-attestation
+checks.
+
+microframework deploy runs this as its sbom step, unless --sbom=false.
+
+Examples:
+  microframework sbom
+  microframework sbom --format spdx --output dist/sbom.spdx.json
+  microframework sbom --image registry.example.com/orders:v1.2.0 --attest`,
+	Args: cobra.NoArgs,
+	RunE: runSBOM,
+}
+
+func init() {
+	sbomCmd.Flags().StringVar(&sbomFormatName, "format", "cyclonedx", "Format of the SBOM (cyclonedx, spdx)")
+	sbomCmd.Flags().StringVarP(&sbomOutput, "output", "o", "", "File of the SBOM of the service (default sbom.cdx.json or sbom.spdx.json)")
+	sbomCmd.Flags().StringVar(&sbomImage, "image", "", "Image of the service to generate the SBOM of too, with syft")
+	sbomCmd.Flags().BoolVar(&sbomAttest, "attest", false, "Attach the SBOM of the image to the image as an attestation, with cosign")
+
+	sbomCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"cyclonedx", "spdx"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// sbomResult is the result of sbom in the JSON report
+type sbomResult struct {
+	Format   string   `json:"format"`
+	Files    []string `json:"files"`
+	Image    string   `json:"image,omitempty"`
+	Attested bool     `json:"attested"`
+}
+
+func runSBOM(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	format, ok := sbomFormats[sbomFormatName]
+	if !ok {
+		return &UserError{fmt.Errorf("unknown SBOM format %q: cyclonedx or spdx", sbomFormatName)}
+	}
+	if sbomAttest && sbomImage == "" {
+		return &UserError{fmt.Errorf("--attest attaches the SBOM of the image, given with --image")}
+	}
+	output := sbomOutput
+	if output == "" {
+		output = "sbom" + format.extension
+	}
+
+	files, err := generateSBOM(sbomFormatName, output, sbomImage, sbomAttest)
+	if err != nil {
+		return err
+	}
+	reportResult(sbomResult{Format: sbomFormatName, Files: files, Image: sbomImage, Attested: sbomAttest})
+	fmt.Printf("✓ SBOM of the service written to %s\n", files[0])
+	if len(files) > 1 {
+		fmt.Printf("✓ SBOM of %s written to %s\n", sbomImage, files[1])
+	}
+	if sbomAttest {
+		fmt.Printf("✓ SBOM attached to %s as a %s attestation\n", sbomImage, format.cosign)
+	}
+	return nil
+}
+
+// generateSBOM writes the SBOM of the modules of the service to output and, with an image,
+// the SBOM of the image next to it, which attest attaches to the image. It returns the files
+// written.
+func generateSBOM(formatName, output, image string, attest bool) ([]string, error) {
+	format := sbomFormats[formatName]
+	modules, err := resolveModuleGraph()
+	if err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("failed to resolve the modules of the service: %w", err)}
+	}
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return nil, err
+	}
+	goMod, err := modfile.Parse("go.mod", content, nil)
+	if err != nil {
+		return nil, &UserError{fmt.Errorf("invalid go.mod: %w", err)}
+	}
+
+	var document interface{}
+	if formatName == "spdx" {
+		document = spdxDocument(goMod, modules)
+	} else {
+		document = cycloneDXDocument(goMod, modules)
+	}
+	if err := writeSBOM(output, document); err != nil {
+		return nil, err
+	}
+	files := []string{output}
+	reportFiles(output)
+	if image == "" {
+		return files, nil
+	}
+
+	imageOutput := sbomImagePath(output, format)
+	if _, err := exec.LookPath("syft"); err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("the SBOM of an image needs syft (https://github.com/anchore/syft): %w", err)}
+	}
+	fmt.Printf("Generating the SBOM of %s...\n", image)
+	syft := exec.Command("syft", "scan", image, "--output", format.syft+"="+imageOutput)
+	syft.Stdout, syft.Stderr = os.Stdout, os.Stderr
+	if err := syft.Run(); err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("syft scan %s failed: %w", image, err)}
+	}
+	files = append(files, imageOutput)
+	reportFiles(imageOutput)
+	if !attest {
+		return files, nil
+	}
+
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return nil, &EnvironmentError{fmt.Errorf("attaching the SBOM to the image needs cosign (https://github.com/sigstore/cosign): %w", err)}
+	}
+	fmt.Printf("Attaching the SBOM to %s...\n", image)
+	cosign := exec.Command("cosign", "attest", "--yes", "--type", format.cosign, "--predicate", imageOutput, image)
+	cosign.Stdout, cosign.Stderr = os.Stdout, os.Stderr
+	if err := cosign.Run(); err != nil {
+		return nil, &DeploymentError{fmt.Errorf("cosign attest %s failed: %w", image, err)}
+	}
+	return files, nil
+}
+
+// sbomImagePath returns the file of the SBOM of the image next to the SBOM of the service,
+// sbom-image.cdx.json for sbom.cdx.json
+func sbomImagePath(output string, format sbomFormat) string {
+	extension := format.extension
+	if !strings.HasSuffix(output, extension) {
+		extension = filepath.Ext(output)
+	}
+	return strings.TrimSuffix(output, extension) + "-image" + extension
+}
+
+// writeSBOM writes an SBOM document as indented JSON
+func writeSBOM(path string, document interface{}) error {
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// sbomModule is a module of the SBOM of the service
+type sbomModule struct {
+	path, version, purl string
+	// license is the SPDX identifier of the license of the module, empty when unknown
+	license string
+}
+
+// sbomModules returns the main module of go.mod and the modules of its build list, with
+// their licenses
+func sbomModules(goMod *modfile.File, modules []goModuleDownload) (sbomModule, []sbomModule) {
+	main := sbomModule{path: goMod.Module.Mod.Path, version: "v0.0.0"}
+	if manifestVersion := projectVersion(); manifestVersion != "" {
+		main.version = manifestVersion
+	}
+	main.purl = modulePURL(main.path, main.version)
+
+	var dependencies []sbomModule
+	for _, module := range modules {
+		if module.Path == main.path || module.Version == "" {
+			continue
+		}
+		dependency := sbomModule{path: module.Path, version: module.Version, purl: modulePURL(module.Path, module.Version)}
+		if module.Dir != "" {
+			if license, _ := detectModuleLicense(module.Dir); license != "Unknown" {
+				dependency.license = license
+			}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return main, dependencies
+}
+
+// directDependencies returns the package URLs of the modules of the build list go.mod
+// requires directly, the dependencies of the service itself
+func directDependencies(goMod *modfile.File, dependencies []sbomModule) []string {
+	direct := make(map[string]bool)
+	for _, require := range goMod.Require {
+		if !require.Indirect {
+			direct[require.Mod.Path] = true
+		}
+	}
+	purls := []string{}
+	for _, dependency := range dependencies {
+		if direct[dependency.path] {
+			purls = append(purls, dependency.purl)
+		}
+	}
+	sort.Strings(purls)
+	return purls
+}
+
+// modulePURL returns the package URL of a Go module
+func modulePURL(path, version string) string {
+	return "pkg:golang/" + path + "@" + version
+}
+
+// projectVersion returns the version of the service in configs/config.yaml, as a module
+// version, empty without it
+func projectVersion() string {
+	settings, err := pulumiServiceSettings("")
+	if err != nil || settings.version == "" {
+		return ""
+	}
+	return "v" + strings.TrimPrefix(settings.version, "v")
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// cycloneDXDocument returns the CycloneDX 1.5 SBOM of the modules of the service
+func cycloneDXDocument(goMod *modfile.File, modules []goModuleDownload) map[string]interface{} {
+	main, dependencies := sbomModules(goMod, modules)
+	component := func(module sbomModule, kind string) map[string]interface{} {
+		c := map[string]interface{}{
+			"type":    kind,
+			"bom-ref": module.purl,
+			"name":    module.path,
+			"version": module.version,
+			"purl":    module.purl,
+		}
+		if module.license != "" {
+			c["licenses"] = []interface{}{map[string]interface{}{"license": map[string]string{"id": module.license}}}
+		}
+		return c
+	}
+
+	components := make([]interface{}, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		components = append(components, component(dependency, "library"))
+	}
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []interface{}{map[string]string{"type": "application", "name": "microframework", "version": version}},
+			},
+			"component": component(main, "application"),
+		},
+		"components": components,
+		"dependencies": []interface{}{
+			map[string]interface{}{"ref": main.purl, "dependsOn": directDependencies(goMod, dependencies)},
+		},
+	}
+}
+
+// spdxDocument returns the SPDX 2.3 SBOM of the modules of the service
+func spdxDocument(goMod *modfile.File, modules []goModuleDownload) map[string]interface{} {
+	main, dependencies := sbomModules(goMod, modules)
+	ids := make(map[string]string)
+	pkg := func(module sbomModule, id string) map[string]interface{} {
+		ids[module.purl] = id
+		license := module.license
+		if license == "" {
+			license = "NOASSERTION"
+		}
+		return map[string]interface{}{
+			"name":             module.path,
+			"SPDXID":           id,
+			"versionInfo":      module.version,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared":  license,
+			"externalRefs": []interface{}{map[string]string{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  module.purl,
+			}},
+		}
+	}
+
+	packages := []interface{}{pkg(main, "SPDXRef-Package-main")}
+	for i, dependency := range dependencies {
+		packages = append(packages, pkg(dependency, fmt.Sprintf("SPDXRef-Package-%d", i+1)))
+	}
+	relationships := []interface{}{map[string]string{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-Package-main",
+	}}
+	for _, purl := range directDependencies(goMod, dependencies) {
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-Package-main",
+			"relationshipType":   "DEPENDS_ON",
+			"relatedSpdxElement": ids[purl],
+		})
+	}
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              main.path,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + filepath.Base(main.path) + "-" + newUUID(),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: microframework-" + version},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+var (
+	scaffoldSpec      string
+	scaffoldFields    []string
+	scaffoldRelations []string
+	scaffoldDryRun    bool
+	scaffoldForce     bool
+)
+
+// scaffoldCmd represents the scaffold command
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Short: "Design the domain of the service and generate its code",
+	Long:  `Design the domain of the service, its entities, and generate their code.`,
+}
+
+// scaffoldEntityCmd represents the scaffold entity command
+var scaffoldEntityCmd = &cobra.Command{
+	Use:   "entity [name]",
+	Short: "Design an entity and generate its CRUD layers, protobuf messages and GraphQL types",
+	Long: `Design an entity of the service: its fields, their types and validations, and its relations
+to the other entities. The entity is recorded in the generation manifest, the single source
+the code of the entity is generated from:
+
+  internal/models/<entity>.go              the GORM model and the create and update requests,
+                                           validated by their binding tags
+  internal/repositories/<entity>_repository.go
+  internal/services/<entity>_service.go
+  internal/handlers/<entity>_handler.go    the REST routes of the entity, registered with
+                                           RegisterRoutes
+  protobuf/<entity>.proto                  the messages and CRUD service of a grpc service
+  graphql/<entity>.graphql                 the types, queries and mutations of a graphql service
+
+The entity is given by a spec file (--spec), by --field and --relation, or through prompts on
+a terminal. Scaffolding an entity again replaces its design and regenerates its files; files
+changed since they were generated are kept unless --force. update --type templates
+regenerates the files of the entities with newer templates.
+
+Fields are name:type[:rule,...], where the type is one of bool, float, int, json, string,
+text, time and uuid, and the rules are required, unique and the validations of the binding
+tags (email, min=3, max=100, oneof=draft published). Relations are name:kind:Entity, where
+the kind is belongs_to, has_one, has_many or many_to_many, and the entity is already designed.
+
+Examples:
+  microframework scaffold entity Customer --field name:string:required,min=2 --field email:string:required,unique,email
+  microframework scaffold entity Order --field total:float:required,gte=0 --relation customer:belongs_to:Customer
+  microframework scaffold entity --spec entities/order.yaml
+  microframework scaffold entity Product`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScaffoldEntity,
+}
+
+func init() {
+	scaffoldEntityCmd.Flags().StringVar(&scaffoldSpec, "spec", "", "YAML file designing the entity (name, fields, relations)")
+	scaffoldEntityCmd.Flags().StringArrayVar(&scaffoldFields, "field", nil, "Field of the entity, name:type[:rule,...] (repeatable)")
+	scaffoldEntityCmd.Flags().StringArrayVar(&scaffoldRelations, "relation", nil, "Relation of the entity, name:kind:Entity (repeatable)")
+	scaffoldEntityCmd.Flags().BoolVar(&scaffoldDryRun, "dry-run", false, "Show the files that would be written, without writing them")
+	scaffoldEntityCmd.Flags().BoolVar(&scaffoldForce, "force", false, "Overwrite files changed since they were generated")
+	scaffoldEntityCmd.ValidArgsFunction = completeEntities
+
+	scaffoldCmd.AddCommand(scaffoldEntityCmd)
+}
+
+func runScaffoldEntity(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	manifest, err := generator.LoadManifest(".")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UserError{fmt.Errorf("no generation manifest (%s); generate the project with microframework new, or adopt it with microframework init", generator.ManifestFile)}
+		}
+		return err
+	}
+
+	entity, err := designEntity(args)
+	if err != nil {
+		return err
+	}
+
+	config := manifest.Config
+	config.OutputDir = ""
+	config.FrameworkVersion = version
+	config.Entities = append([]generator.Entity{}, manifest.Config.Entities...)
+	replaced := false
+	for i := range config.Entities {
+		if config.Entities[i].Name == entity.Name {
+			config.Entities[i] = *entity
+			replaced = true
+		}
+	}
+	if !replaced {
+		config.Entities = append(config.Entities, *entity)
+		sort.Slice(config.Entities, func(i, j int) bool { return config.Entities[i].Name < config.Entities[j].Name })
+	}
+	if err := generator.ValidateEntities(config.Entities); err != nil {
+		return err
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	// Entities are scaffolded from the templates the project was generated from
+	packOpts, _, err := templatePackOptions(config.TemplatePack)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, packOpts...)
+	gen := generator.NewServiceGenerator(&config, opts...)
+	rendered, err := gen.RenderEntity(entity.Name)
+	if err != nil {
+		return &GenerationError{fmt.Errorf("failed to render entity %s: %w", entity.Name, err)}
+	}
+
+	paths := make([]string, 0, len(rendered))
+	for path := range rendered {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var conflicts []string
+	changed := 0
+	for _, path := range paths {
+		action, existing := scaffoldAction(manifest, path, rendered[path])
+		switch action {
+		case "conflict":
+			conflicts = append(conflicts, path)
+			if scaffoldForce {
+				action = "overwrite"
+			}
+		case "unchanged":
+			fmt.Printf("  %-9s %s\n", action, path)
+			continue
+		}
+		changed++
+		fmt.Printf("  %-9s %s\n", action, path)
+		if scaffoldDryRun && existing != nil {
+			fmt.Print(unifiedDiff(string(existing), string(rendered[path]), path, path+" (generated)"))
+		}
+	}
+	if len(conflicts) > 0 && !scaffoldForce {
+		return &UserError{fmt.Errorf("%d file(s) changed since they were generated: %s; use --force to overwrite them", len(conflicts), strings.Join(conflicts, ", "))}
+	}
+	if scaffoldDryRun {
+		fmt.Printf("Dry run: %d file(s) would be written\n", changed)
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, rendered[path], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := generator.WriteBase(".", path, rendered[path]); err != nil {
+			return fmt.Errorf("failed to record %s: %w", path, err)
+		}
+		manifest.Files[path] = generator.Checksum(rendered[path])
+		if inputs := gen.Inputs()[path]; inputs != "" {
+			manifest.Inputs[path] = inputs
+		}
+	}
+	manifest.Config.Entities = config.Entities
+	if err := manifest.Save("."); err != nil {
+		return fmt.Errorf("failed to update generation manifest: %w", err)
+	}
+
+	fmt.Printf("✓ Entity %s scaffolded\n", entity.Name)
+	fmt.Println("\nNext steps:")
+	fmt.Printf("1. Migrate its table: db.AutoMigrate(&models.%s{})\n", entity.Name)
+	fmt.Printf("2. Register its routes: handlers.New%[1]sHandler(services.New%[1]sService(repositories.New%[1]sRepository(db))).RegisterRoutes(router)\n", entity.Name)
+	return nil
+}
+
+// scaffoldAction tells what writing a generated file of an entity does, and returns the file
+// on disk when there is one
+func scaffoldAction(manifest *generator.Manifest, path string, content []byte) (string, []byte) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return "create", nil
+	}
+	if bytes.Equal(existing, content) {
+		return "unchanged", existing
+	}
+	if checksum, ok := manifest.Files[path]; ok && checksum == generator.Checksum(existing) {
+		return "update", existing
+	}
+	return "conflict", existing
+}
+
+// designEntity returns the entity designed by the spec file, the flags or the prompts
+func designEntity(args []string) (*generator.Entity, error) {
+	entity := &generator.Entity{}
+	if scaffoldSpec != "" {
+		content, err := os.ReadFile(scaffoldSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", scaffoldSpec, err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(entity); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", scaffoldSpec, err)
+		}
+	}
+	if len(args) > 0 {
+		if entity.Name != "" && entity.Name != args[0] {
+			return nil, fmt.Errorf("%s designs entity %s, not %s", scaffoldSpec, entity.Name, args[0])
+		}
+		entity.Name = args[0]
+	}
+
+	for _, flag := range scaffoldFields {
+		field, err := parseEntityField(flag)
+		if err != nil {
+			return nil, err
+		}
+		entity.Fields = append(entity.Fields, field)
+	}
+	for _, flag := range scaffoldRelations {
+		parts := strings.Split(flag, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid --relation %q: expected name:kind:Entity", flag)
+		}
+		entity.Relations = append(entity.Relations, generator.EntityRelation{Name: parts[0], Kind: parts[1], Entity: parts[2]})
+	}
+
+	if scaffoldSpec == "" && len(scaffoldFields) == 0 && len(scaffoldRelations) == 0 {
+		if !isInteractive() {
+			return nil, errors.New("no design given; use --spec, or --field and --relation, or run on a terminal to be prompted")
+		}
+		if err := promptEntity(entity); err != nil {
+			return nil, err
+		}
+	}
+	if entity.Name == "" {
+		return nil, errors.New("the entity has no name; give it as argument or in the spec")
+	}
+	return entity, nil
+}
+
+// parseEntityField parses a field given as name:type[:rule,...]; required and unique are
+// rules of their own, the others validations
+func parseEntityField(flag string) (generator.EntityField, error) {
+	parts := strings.SplitN(flag, ":", 3)
+	if len(parts) < 2 {
+		return generator.EntityField{}, &UserError{fmt.Errorf("invalid --field %q: expected name:type[:rule,...]", flag)}
+	}
+	field := generator.EntityField{Name: parts[0], Type: parts[1]}
+	if len(parts) == 3 {
+		applyFieldRules(&field, parts[2])
+	}
+	return field, nil
+}
+
+// applyFieldRules sets the comma-separated rules of a field
+func applyFieldRules(field *generator.EntityField, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		switch rule = strings.TrimSpace(rule); rule {
+		case "":
+		case "required":
+			field.Required = true
+		case "unique":
+			field.Unique = true
+		default:
+			field.Validate = append(field.Validate, rule)
+		}
+	}
+}
+
+// promptEntity asks for the name, fields and relations of an entity
+func promptEntity(entity *generator.Entity) error {
+	reader := bufio.NewReader(os.Stdin)
+	ask := func(question, fallback string) (string, error) {
+		if fallback != "" {
+			fmt.Printf("%s [%s]: ", question, fallback)
+		} else {
+			fmt.Printf("%s: ", question)
+		}
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return "", fmt.Errorf("no answer: %w", err)
+		}
+		if answer = strings.TrimSpace(answer); answer == "" {
+			return fallback, nil
+		}
+		return answer, nil
+	}
+
+	if entity.Name == "" {
+		name, err := ask("Entity name (PascalCase)", "")
+		if err != nil {
+			return err
+		}
+		entity.Name = name
+	}
+
+	fmt.Printf("Fields of %s; types: %s\n", entity.Name, strings.Join(generator.EntityFieldTypes, ", "))
+	for {
+		name, err := ask("Field name (empty to finish)", "")
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			break
+		}
+		fieldType, err := ask("  Type", "string")
+		if err != nil {
+			return err
+		}
+		rules, err := ask("  Rules, comma separated (required, unique, email, min=3...)", "")
+		if err != nil {
+			return err
+		}
+		field := generator.EntityField{Name: name, Type: fieldType}
+		applyFieldRules(&field, rules)
+		entity.Fields = append(entity.Fields, field)
+	}
+
+	fmt.Printf("Relations of %s; kinds: %s\n", entity.Name, strings.Join(generator.EntityRelationKinds, ", "))
+	for {
+		name, err := ask("Relation name (empty to finish)", "")
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			break
+		}
+		kind, err := ask("  Kind", generator.RelationBelongsTo)
+		if err != nil {
+			return err
+		}
+		target, err := ask("  Entity", goTypeName(name))
+		if err != nil {
+			return err
+		}
+		entity.Relations = append(entity.Relations, generator.EntityRelation{Name: name, Kind: kind, Entity: target})
+	}
+	return nil
+}
+
+// goTypeName returns the PascalCase name of a snake_case name, the entity a relation named
+// after it likely points to
+func goTypeName(name string) string {
+	var builder strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word != "" {
+			builder.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "s")
+}
+
+// completeEntities completes the entities designed in the project
+func completeEntities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	manifest, err := generator.LoadManifest(".")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, entity := range manifest.Config.Entities {
+		if strings.HasPrefix(entity.Name, toComplete) {
+			completions = append(completions, entity.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
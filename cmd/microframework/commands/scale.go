@@ -0,0 +1,318 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	scaleEnv    string
+	scaleMin    int
+	scaleMax    int
+	scaleHPA    string
+	scaleReason string
+	scaleDryRun bool
+)
+
+// historyFile records the changes made to the environments of a project
+var historyFile = filepath.Join(".microframework", "history.jsonl")
+
+// scaleCmd represents the scale command
+var scaleCmd = &cobra.Command{
+	Use:   "scale [replicas] --env <environment>",
+	Short: "Change the replicas of the service in an environment",
+	Long: `Change the number of replicas of the service in an environment of
+deployments/environments.yaml, and the bounds of its autoscaling with --min and --max:
+
+  kubernetes  kubectl scale of the deployment; --min and --max patch its HorizontalPodAutoscaler
+  ecs         aws ecs update-service --desired-count; --min and --max update the Application
+              Auto Scaling target of the service
+  cloudrun    --min and --max set the minimum and maximum instances (Cloud Run scales on
+              requests, there is no replica count)
+  compose     docker compose up --scale
+
+Each change is appended to .microframework/history.jsonl with the previous replicas, the user
+and --reason; on Kubernetes the deployment is annotated with the change cause as well.
+
+Examples:
+  microframework scale 5 --env production --reason "traffic spike"
+  microframework scale --env production --min 3 --max 20
+  microframework scale 0 --env staging --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScale(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	scaleCmd.Flags().StringVarP(&scaleEnv, "env", "e", "", "Environment of deployments/environments.yaml (required)")
+	scaleCmd.Flags().IntVar(&scaleMin, "min", 0, "Minimum replicas of the autoscaling")
+	scaleCmd.Flags().IntVar(&scaleMax, "max", 0, "Maximum replicas of the autoscaling")
+	scaleCmd.Flags().StringVar(&scaleHPA, "hpa", "", "HorizontalPodAutoscaler of a Kubernetes environment (default: the service)")
+	scaleCmd.Flags().StringVar(&scaleReason, "reason", "", "Reason of the change, recorded with it")
+	scaleCmd.Flags().BoolVar(&scaleDryRun, "dry-run", false, "Show the commands without running them")
+
+	scaleCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+}
+
+// scaleChange is a change of the replicas of an environment, as recorded in the history
+type scaleChange struct {
+	Time        time.Time `json:"time"`
+	User        string    `json:"user,omitempty"`
+	Command     string    `json:"command"`
+	Environment string    `json:"environment"`
+	Target      string    `json:"target"`
+	Service     string    `json:"service"`
+	Replicas    *int      `json:"replicas,omitempty"`
+	Previous    *int      `json:"previous_replicas,omitempty"`
+	Min         *int      `json:"min,omitempty"`
+	Max         *int      `json:"max,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if scaleEnv == "" {
+		return &UserError{fmt.Errorf("--env is required")}
+	}
+
+	change := scaleChange{Command: "scale", Reason: scaleReason}
+	if len(args) == 1 {
+		replicas, err := strconv.Atoi(args[0])
+		if err != nil || replicas < 0 {
+			return &UserError{fmt.Errorf("invalid replicas %q", args[0])}
+		}
+		change.Replicas = &replicas
+	}
+	if cmd.Flags().Changed("min") {
+		change.Min = &scaleMin
+	}
+	if cmd.Flags().Changed("max") {
+		change.Max = &scaleMax
+	}
+	switch {
+	case change.Replicas == nil && change.Min == nil && change.Max == nil:
+		return &UserError{fmt.Errorf("give the replicas, --min or --max")}
+	case change.Min != nil && *change.Min < 0, change.Max != nil && *change.Max < 1:
+		return &UserError{fmt.Errorf("--min must be at least 0 and --max at least 1")}
+	case change.Min != nil && change.Max != nil && *change.Min > *change.Max:
+		return &UserError{fmt.Errorf("--min %d is above --max %d", *change.Min, *change.Max)}
+	}
+
+	environment, err := findEnvironment(scaleEnv)
+	if err != nil {
+		return &UserError{err}
+	}
+	change.Environment, change.Target, change.Service = environment.Name, environment.Target, environment.Service
+
+	steps, err := scaleCommands(environment, change)
+	if err != nil {
+		return &UserError{err}
+	}
+	if scaleDryRun {
+		for _, step := range steps {
+			fmt.Printf("Would execute: %s\n", strings.Join(step, " "))
+		}
+		return nil
+	}
+	if _, err := exec.LookPath(steps[0][0]); err != nil {
+		return &EnvironmentError{fmt.Errorf("the %s environment runs on %s, which needs %s: %w", environment.Name, environment.Target, steps[0][0], err)}
+	}
+
+	change.Previous = currentReplicas(environment)
+	for _, step := range steps {
+		run := exec.Command(step[0], step[1:]...)
+		run.Stdout, run.Stderr = os.Stdout, os.Stderr
+		if err := run.Run(); err != nil {
+			return &DeploymentError{fmt.Errorf("%s failed: %w", strings.Join(step, " "), err)}
+		}
+	}
+
+	change.Time = time.Now().UTC()
+	if current, err := user.Current(); err == nil {
+		change.User = current.Username
+	}
+	if err := recordChange(change); err != nil {
+		return err
+	}
+	if change.Target == TargetKubernetes {
+		annotateChangeCause(environment, change)
+	}
+
+	fmt.Printf("✓ Scaled %s in %s: %s\n", environment.Service, environment.Name, describeScaleChange(change))
+	return nil
+}
+
+// scaleCommands returns the commands making a change to an environment
+func scaleCommands(environment *deploymentEnvironment, change scaleChange) ([][]string, error) {
+	var steps [][]string
+	autoscaling := change.Min != nil || change.Max != nil
+
+	switch environment.Target {
+	case TargetKubernetes:
+		if change.Replicas != nil {
+			steps = append(steps, append(append([]string{"kubectl"}, environment.kubectlArgs()...),
+				"scale", "deployment/"+environment.Service, "--replicas", strconv.Itoa(*change.Replicas)))
+		}
+		if autoscaling {
+			spec := make(map[string]int)
+			if change.Min != nil {
+				spec["minReplicas"] = *change.Min
+			}
+			if change.Max != nil {
+				spec["maxReplicas"] = *change.Max
+			}
+			patch, _ := json.Marshal(map[string]any{"spec": spec})
+			hpa := scaleHPA
+			if hpa == "" {
+				hpa = environment.Service
+			}
+			steps = append(steps, append(append([]string{"kubectl"}, environment.kubectlArgs()...),
+				"patch", "hpa", hpa, "--type", "merge", "--patch", string(patch)))
+		}
+
+	case TargetECS:
+		if change.Replicas != nil {
+			steps = append(steps, append([]string{"aws", "ecs", "update-service", "--service", environment.Service,
+				"--desired-count", strconv.Itoa(*change.Replicas)}, environment.awsArgs()...))
+		}
+		if autoscaling {
+			cluster := environment.Cluster
+			if cluster == "" {
+				cluster = "default"
+			}
+			step := []string{"aws", "application-autoscaling", "register-scalable-target", "--service-namespace", "ecs",
+				"--scalable-dimension", "ecs:service:DesiredCount", "--resource-id", "service/" + cluster + "/" + environment.Service}
+			if change.Min != nil {
+				step = append(step, "--min-capacity", strconv.Itoa(*change.Min))
+			}
+			if change.Max != nil {
+				step = append(step, "--max-capacity", strconv.Itoa(*change.Max))
+			}
+			if environment.Region != "" {
+				step = append(step, "--region", environment.Region)
+			}
+			steps = append(steps, step)
+		}
+
+	case TargetCloudRun:
+		if change.Replicas != nil {
+			return nil, &UserError{fmt.Errorf("Cloud Run scales on requests; set the bounds of the %s environment with --min and --max", environment.Name)}
+		}
+		step := []string{"gcloud", "run", "services", "update", environment.Service}
+		if change.Min != nil {
+			step = append(step, "--min-instances", strconv.Itoa(*change.Min))
+		}
+		if change.Max != nil {
+			step = append(step, "--max-instances", strconv.Itoa(*change.Max))
+		}
+		steps = append(steps, append(step, environment.gcloudArgs()...))
+
+	case TargetCompose:
+		if autoscaling {
+			return nil, &UserError{fmt.Errorf("the %s environment runs on docker-compose, which has no autoscaling", environment.Name)}
+		}
+		compose, err := composeCommand()
+		if err != nil {
+			return nil, fmt.Errorf("the %s environment needs %w", environment.Name, err)
+		}
+		steps = append(steps, append(append([]string{}, compose...), "-f", environment.ComposeFile, "up", "--detach",
+			"--no-recreate", "--scale", fmt.Sprintf("%s=%d", environment.Service, *change.Replicas), environment.Service))
+
+	default:
+		return nil, &UserError{fmt.Errorf("the %s environment runs on %s, which cannot be scaled", environment.Name, environment.Target)}
+	}
+	return steps, nil
+}
+
+// currentReplicas returns the replicas of an environment before a change, or nil when they
+// cannot be read
+func currentReplicas(environment *deploymentEnvironment) *int {
+	var output string
+	var err error
+	switch environment.Target {
+	case TargetKubernetes:
+		args := append(environment.kubectlArgs(), "get", "deployment", environment.Service, "--output", "jsonpath={.spec.replicas}")
+		output, err = probe("kubectl", args...)
+	case TargetECS:
+		args := append([]string{"ecs", "describe-services", "--services", environment.Service,
+			"--query", "services[0].desiredCount", "--output", "text"}, environment.awsArgs()...)
+		output, err = probe("aws", args...)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	replicas, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return nil
+	}
+	return &replicas
+}
+
+// recordChange appends a change to the history of the project
+func recordChange(change any) error {
+	line, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(historyFile), err)
+	}
+	file, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", historyFile, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", historyFile, err)
+	}
+	return nil
+}
+
+// annotateChangeCause records a change on the deployment, where kubectl rollout history shows
+// it. The change is made already, so a failure is only reported.
+func annotateChangeCause(environment *deploymentEnvironment, change scaleChange) {
+	cause := "microframework scale: " + describeScaleChange(change)
+	if change.Reason != "" {
+		cause += " (" + change.Reason + ")"
+	}
+	args := append(environment.kubectlArgs(), "annotate", "deployment/"+environment.Service, "--overwrite",
+		"kubernetes.io/change-cause="+cause)
+	if _, err := probe("kubectl", args...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to annotate deployment/%s: %v\n", environment.Service, err)
+	}
+}
+
+// describeScaleChange summarizes a change, as "replicas 2 -> 5, min 3"
+func describeScaleChange(change scaleChange) string {
+	var parts []string
+	if change.Replicas != nil {
+		if change.Previous != nil {
+			parts = append(parts, fmt.Sprintf("replicas %d -> %d", *change.Previous, *change.Replicas))
+		} else {
+			parts = append(parts, fmt.Sprintf("replicas %d", *change.Replicas))
+		}
+	}
+	if change.Min != nil {
+		parts = append(parts, fmt.Sprintf("min %d", *change.Min))
+	}
+	if change.Max != nil {
+		parts = append(parts, fmt.Sprintf("max %d", *change.Max))
+	}
+	return strings.Join(parts, ", ")
+}
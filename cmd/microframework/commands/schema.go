@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anasamu/go-micro-framework/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaWrite  string
+	schemaOutput string
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema [name]",
+	Short: "Print the JSON Schemas of the project files",
+	Long: `Print the JSON Schemas of the files of a project:
+
+  microframework  .microframework.yaml, the configuration of the CLI
+  config          configs/config.yaml and its overlays, the configuration of the service
+  environments    deployments/environments.yaml, where each environment runs
+
+Without a name, the schemas are listed with the files they describe and the URL they are
+published at. The generated files start with a yaml-language-server comment pointing editors
+(VS Code with the YAML extension, JetBrains IDEs, Neovim) at their schema, so that editors
+check them as they are edited; validate --type config --fix adds the comment to the files
+without it. validate --type config checks the files against the schemas.
+
+Examples:
+  microframework schema
+  microframework schema config > config.schema.json
+  microframework schema --write schemas/`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSchemas,
+	RunE:              runSchema,
+}
+
+// listedSchema is a schema of schema -o json
+type listedSchema struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+	URL   string   `json:"url"`
+}
+
+func init() {
+	schemaCmd.Flags().StringVar(&schemaWrite, "write", "", "Write every schema to a directory, as <name>.schema.json")
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "text", "Output format of the list (text, json)")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	if schemaWrite != "" {
+		return writeSchemas(schemaWrite)
+	}
+	if len(args) == 1 {
+		content, ok := schema.Get(args[0])
+		if !ok {
+			return &UserError{fmt.Errorf("no schema %q (%s)", args[0], strings.Join(schema.Names(), ", "))}
+		}
+		_, err := cmd.OutOrStdout().Write(content)
+		return err
+	}
+
+	if schemaOutput != "text" && schemaOutput != "json" {
+		return &UserError{fmt.Errorf("invalid output format %q (text, json)", schemaOutput)}
+	}
+	schemas := []listedSchema{}
+	for _, name := range schema.Names() {
+		schemas = append(schemas, listedSchema{Name: name, Files: schema.Files(name), URL: schema.URL(name)})
+	}
+	if schemaOutput == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schemas)
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "SCHEMA\tFILES\tURL\n")
+	for _, listed := range schemas {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", listed.Name, strings.Join(listed.Files, ", "), listed.URL)
+	}
+	return writer.Flush()
+}
+
+// writeSchemas writes every schema to dir, to be served or committed next to the projects
+func writeSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	var written []string
+	for _, name := range schema.Names() {
+		content, _ := schema.Get(name)
+		path := filepath.Join(dir, name+".schema.json")
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("✓ Wrote %s\n", path)
+		written = append(written, path)
+	}
+	reportFiles(written...)
+	return nil
+}
+
+// completeSchemas completes the names of the schemas
+func completeSchemas(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return schema.Names(), cobra.ShellCompDirectiveNoFileComp
+}
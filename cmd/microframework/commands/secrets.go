@@ -0,0 +1,460 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/anasamu/go-micro-framework/pkg/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsBackendName   string
+	secretsEnv           string
+	secretsTimeout       time.Duration
+	secretsVaultMount    string
+	secretsK8sSecret     string
+	secretsOutput        string
+	secretsReveal        bool
+	secretsFromFile      string
+	secretsSetRestart    bool
+	secretsRotateRestart bool
+	secretsReason        string
+)
+
+// secretKeyPattern matches the keys the backends accept alike
+var secretKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// previousSecretSuffix names the key keeping the value a rotation replaced
+const previousSecretSuffix = "-previous"
+
+// wellKnownSecrets generate the secrets rotate can replace by itself
+var wellKnownSecrets = map[string]func() (string, error){
+	// The JWT signing key: 512 random bits, the size of the HS512 hash
+	"jwt-secret": func() (string, error) {
+		key := make([]byte, 64)
+		if _, err := rand.Read(key); err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(key), nil
+	},
+}
+
+// secretsCmd represents the secrets command
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the secret values of the service",
+	Long: `Manage the secret values of the service in its secrets backend:
+
+  k8s    the keys of the Kubernetes secret <service>-secrets of an environment (--env), which
+         the generated deployment reads its environment from
+  vault  the keys of the Vault KV secret secret/<service> (vault://secret/<service>#<key>)
+  ssm    the SecureString parameters /<service>/<key> (ssm:///<service>/<key>)
+  gsm    the Secret Manager secrets <service>-<key> (gsm://<service>-<key>)
+
+The backend defaults to the one the project was generated with (--with-secrets), k8s
+otherwise. The backends are reached with their CLIs (kubectl, vault, aws, gcloud) and their
+credentials. Values are redacted unless get --reveal asks for them, and are handed to the
+CLIs in files only the user can read rather than in their arguments.
+
+Examples:
+  microframework secrets list --env production
+  microframework secrets get database-url --env production
+  microframework secrets set database-url --env production < database-url.txt
+  microframework secrets rotate jwt-secret --env production`,
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys of the secrets",
+	Args:  cobra.NoArgs,
+	Run:   runSecretsCommandLine(runSecretsList),
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show a secret, redacted unless --reveal",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSecretsCommandLine(runSecretsGet),
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <key>",
+	Short: "Set a secret from the standard input or --from-file",
+	Long: `Set a secret to the standard input, without its final line break, or to the content of
+--from-file. The value is never taken from the arguments, which the shell history and the
+other users of the machine can see.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretsCommandLine(runSecretsSet),
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate <key>",
+	Short: "Replace a well-known secret with a newly generated value",
+	Long: `Replace a well-known secret with a newly generated value: jwt-secret, the JWT signing key.
+
+The replaced value is kept in <key>-previous, written in the same update as the new value on
+k8s and vault (one patch of the secret, one version of the KV secret); ssm and gsm write the
+previous value first. The service reads its secrets at startup, so with --env the environment
+is restarted to load the new value (--restart=false to restart it yourself): a rollout
+restart on Kubernetes, a new deployment on ECS, a new revision on Cloud Run.
+
+The rotation is recorded in .microframework/history.jsonl, without the values.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretsCommandLine(runSecretsRotate),
+}
+
+func init() {
+	secretsCmd.PersistentFlags().StringVar(&secretsBackendName, "backend", "", "Secrets backend (k8s, vault, ssm, gsm; default: the project's)")
+	secretsCmd.PersistentFlags().StringVarP(&secretsEnv, "env", "e", "", "Environment of deployments/environments.yaml (required for k8s)")
+	secretsCmd.PersistentFlags().DurationVar(&secretsTimeout, "timeout", 30*time.Second, "Timeout of each backend command")
+	secretsCmd.PersistentFlags().StringVar(&secretsVaultMount, "vault-mount", "secret", "Mount of the Vault KV secrets engine")
+	secretsCmd.PersistentFlags().StringVar(&secretsK8sSecret, "k8s-secret", "", "Kubernetes secret (default: <service>-secrets)")
+	secretsCmd.RegisterFlagCompletionFunc("backend", cobra.FixedCompletions([]string{"k8s", "vault", "ssm", "gsm"}, cobra.ShellCompDirectiveNoFileComp))
+	secretsCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+
+	secretsListCmd.Flags().StringVarP(&secretsOutput, "output", "o", "text", "Output format (text, json)")
+	secretsGetCmd.Flags().BoolVar(&secretsReveal, "reveal", false, "Print the value itself")
+	secretsSetCmd.Flags().StringVar(&secretsFromFile, "from-file", "", "File holding the value")
+	secretsSetCmd.Flags().BoolVar(&secretsSetRestart, "restart", false, "Restart the environment to load the value")
+	secretsRotateCmd.Flags().BoolVar(&secretsRotateRestart, "restart", true, "Restart the environment to load the value (with --env)")
+	for _, command := range []*cobra.Command{secretsSetCmd, secretsRotateCmd} {
+		command.Flags().StringVar(&secretsReason, "reason", "", "Reason of the change, recorded with it")
+	}
+
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsGetCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsRotateCmd)
+}
+
+// secretChange is a change of secrets, as recorded in the history
+type secretChange struct {
+	Time        time.Time `json:"time"`
+	User        string    `json:"user,omitempty"`
+	Command     string    `json:"command"`
+	Environment string    `json:"environment,omitempty"`
+	Backend     string    `json:"backend"`
+	Location    string    `json:"location"`
+	Keys        []string  `json:"keys"`
+	Restarted   bool      `json:"restarted,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// runSecretsCommandLine exits with 2 on usage errors and 1 on other errors, without the usage
+func runSecretsCommandLine(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if err := run(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	}
+}
+
+// openSecretsBackend returns the selected backend and environment
+func openSecretsBackend() (string, secretsBackend, *deploymentEnvironment, error) {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return "", nil, nil, err
+	}
+	name := secretsBackendName
+	if name == "" {
+		if manifest, err := generator.LoadManifest("."); err == nil {
+			name = manifest.Config.SecretsProvider
+		}
+	}
+	if name == "" {
+		name = SecretsKubernetes
+	}
+
+	var environment *deploymentEnvironment
+	if secretsEnv != "" {
+		var err error
+		if environment, err = findEnvironment(secretsEnv); err != nil {
+			return "", nil, nil, &UserError{err}
+		}
+	}
+	service := projectServiceName()
+
+	switch name {
+	case SecretsKubernetes, "kubernetes":
+		if environment == nil || environment.Target != TargetKubernetes {
+			return "", nil, nil, &UserError{fmt.Errorf("the k8s backend needs --env with a Kubernetes environment")}
+		}
+		secret := secretsK8sSecret
+		if secret == "" {
+			secret = environment.Service + "-secrets"
+		}
+		return SecretsKubernetes, &kubernetesSecrets{environment: environment, name: secret}, environment, nil
+	case SecretsVault:
+		return name, &vaultSecrets{mount: secretsVaultMount, path: service}, environment, nil
+	case SecretsSSM:
+		backend := &ssmSecrets{prefix: "/" + service + "/"}
+		if environment != nil {
+			backend.region = environment.Region
+		}
+		return name, backend, environment, nil
+	case SecretsGSM:
+		backend := &gsmSecrets{prefix: service + "-"}
+		if environment != nil {
+			backend.project = environment.Project
+		}
+		return name, backend, environment, nil
+	}
+	return "", nil, nil, &UserError{fmt.Errorf("unknown secrets backend %q (k8s, vault, ssm, gsm)", name)}
+}
+
+func checkSecretKey(key string) error {
+	if !secretKeyPattern.MatchString(key) {
+		return &UserError{fmt.Errorf("invalid key %q: letters, digits, '.', '_' and '-'", key)}
+	}
+	return nil
+}
+
+// redactSecret describes a value without showing it: its length and the start of its SHA-256,
+// to compare values between environments
+func redactSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("******** (%d chars, sha256 %s)", len(value), hex.EncodeToString(sum[:])[:8])
+}
+
+func runSecretsList(cmd *cobra.Command, args []string) error {
+	if secretsOutput != "text" && secretsOutput != "json" {
+		return &UserError{fmt.Errorf("invalid output format %q (text, json)", secretsOutput)}
+	}
+	_, backend, _, err := openSecretsBackend()
+	if err != nil {
+		return err
+	}
+	keys, err := backend.list()
+	if err != nil {
+		return err
+	}
+
+	if secretsOutput == "json" {
+		if keys == nil {
+			keys = []secretKey{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(keys)
+	}
+	if len(keys) == 0 {
+		fmt.Printf("No secrets in the %s\n", backend.location())
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tUPDATED")
+	for _, key := range keys {
+		fmt.Fprintf(writer, "%s\t%s\n", key.Key, orDash(key.Updated))
+	}
+	return writer.Flush()
+}
+
+func runSecretsGet(cmd *cobra.Command, args []string) error {
+	if err := checkSecretKey(args[0]); err != nil {
+		return err
+	}
+	_, backend, _, err := openSecretsBackend()
+	if err != nil {
+		return err
+	}
+	value, err := backend.get(args[0])
+	if errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("no %s in the %s", args[0], backend.location())
+	}
+	if err != nil {
+		return err
+	}
+	if secretsReveal {
+		fmt.Println(value)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", args[0], redactSecret(value))
+	return nil
+}
+
+func runSecretsSet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if err := checkSecretKey(key); err != nil {
+		return err
+	}
+	name, backend, environment, err := openSecretsBackend()
+	if err != nil {
+		return err
+	}
+	if secretsSetRestart && environment == nil {
+		return &UserError{fmt.Errorf("--restart needs --env")}
+	}
+
+	value, err := readSecretValue(key)
+	if err != nil {
+		return err
+	}
+	if err := backend.set([]secretValue{{key, value}}); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	fmt.Printf("✓ Set %s in the %s: %s\n", key, backend.location(), redactSecret(value))
+
+	return finishSecretChange(secretChange{Command: "secrets set", Backend: name, Location: backend.location(), Keys: []string{key}}, environment, secretsSetRestart)
+}
+
+// readSecretValue reads the value of set from --from-file or the standard input
+func readSecretValue(key string) (string, error) {
+	if secretsFromFile != "" {
+		content, err := os.ReadFile(secretsFromFile)
+		if err != nil {
+			return "", err
+		}
+		if len(content) == 0 {
+			return "", fmt.Errorf("%s is empty", secretsFromFile)
+		}
+		return string(content), nil
+	}
+
+	var content []byte
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "Value of %s (shown as typed): ", key)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		content = []byte(line)
+	} else {
+		var err error
+		if content, err = io.ReadAll(os.Stdin); err != nil {
+			return "", err
+		}
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	if value == "" {
+		return "", &UserError{fmt.Errorf("empty value: give it on the standard input or with --from-file")}
+	}
+	return value, nil
+}
+
+func runSecretsRotate(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	generate, ok := wellKnownSecrets[key]
+	if !ok {
+		return &UserError{fmt.Errorf("rotate generates %s; set the new value of %s with secrets set", strings.Join(wellKnownSecretNames(), ", "), key)}
+	}
+	name, backend, environment, err := openSecretsBackend()
+	if err != nil {
+		return err
+	}
+
+	previous, err := backend.get(key)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	value, err := generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", key, err)
+	}
+
+	// The previous value is written first, for the backends writing the keys one by one
+	values := []secretValue{{key, value}}
+	if previous != "" {
+		values = []secretValue{{key + previousSecretSuffix, previous}, {key, value}}
+	}
+	if err := backend.set(values); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", key, err)
+	}
+	fmt.Printf("✓ Rotated %s in the %s: %s\n", key, backend.location(), redactSecret(value))
+	if previous != "" {
+		fmt.Printf("  The previous value is kept in %s%s\n", key, previousSecretSuffix)
+	}
+
+	keys := make([]string, len(values))
+	for i, value := range values {
+		keys[i] = value.Key
+	}
+	return finishSecretChange(secretChange{Command: "secrets rotate", Backend: name, Location: backend.location(), Keys: keys}, environment, secretsRotateRestart)
+}
+
+func wellKnownSecretNames() []string {
+	var names []string
+	for name := range wellKnownSecrets {
+		names = append(names, name)
+	}
+	return names
+}
+
+// finishSecretChange restarts the environment when asked to, and records the change
+func finishSecretChange(change secretChange, environment *deploymentEnvironment, restart bool) error {
+	change.Time = time.Now().UTC()
+	change.Reason = secretsReason
+	if current, err := user.Current(); err == nil {
+		change.User = current.Username
+	}
+	if environment != nil {
+		change.Environment = environment.Name
+	}
+
+	var restartErr error
+	switch {
+	case restart && environment != nil:
+		if restartErr = restartEnvironment(environment); restartErr == nil {
+			change.Restarted = true
+			fmt.Printf("✓ Restarted %s in %s\n", environment.Service, environment.Name)
+		}
+	default:
+		fmt.Println("  The service reads its secrets at startup: restart it to load the new value")
+	}
+
+	if err := recordChange(change); err != nil {
+		return err
+	}
+	if restartErr != nil {
+		return fmt.Errorf("the secret is changed, but restarting %s failed: %w", environment.Name, restartErr)
+	}
+	return nil
+}
+
+// restartEnvironment restarts the service of an environment, so that it reads its secrets
+// again
+func restartEnvironment(environment *deploymentEnvironment) error {
+	var command []string
+	switch environment.Target {
+	case TargetKubernetes:
+		command = append(append([]string{"kubectl"}, environment.kubectlArgs()...), "rollout", "restart", "deployment/"+environment.Service)
+	case TargetECS:
+		command = append([]string{"aws", "ecs", "update-service", "--service", environment.Service, "--force-new-deployment"}, environment.awsArgs()...)
+	case TargetCloudRun:
+		// A changed environment variable makes a new revision, whose instances start anew
+		command = append([]string{"gcloud", "run", "services", "update", environment.Service,
+			"--update-env-vars", "SECRETS_ROTATED_AT=" + strconv.FormatInt(time.Now().Unix(), 10)}, environment.gcloudArgs()...)
+	case TargetCompose:
+		compose, err := composeCommand()
+		if err != nil {
+			return err
+		}
+		command = append(append([]string{}, compose...), "-f", environment.ComposeFile, "restart", environment.Service)
+	default:
+		command = []string{"docker", "restart", environment.Service}
+	}
+
+	output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%s: %s", strings.Join(command[:min(3, len(command))], " "), firstLine(message))
+		}
+		return err
+	}
+	return nil
+}
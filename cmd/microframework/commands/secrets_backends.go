@@ -0,0 +1,443 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/secrets"
+)
+
+// Secrets backends of microframework secrets
+const (
+	SecretsKubernetes = "k8s"
+	SecretsVault      = "vault"
+	SecretsSSM        = "ssm"
+	SecretsGSM        = "gsm"
+)
+
+// secretsBackend holds the secret values of a service
+type secretsBackend interface {
+	// location names where the secrets are kept, for messages
+	location() string
+	list() ([]secretKey, error)
+	// get returns the value of a key, or secrets.ErrNotFound
+	get(key string) (string, error)
+	// set writes values in one update when the backend allows it, in order otherwise
+	set(values []secretValue) error
+}
+
+// secretKey is a key of the secrets of a service
+type secretKey struct {
+	Key     string `json:"key"`
+	Updated string `json:"updated,omitempty"`
+}
+
+type secretValue struct {
+	Key   string
+	Value string
+}
+
+// runSecretsCommand runs a backend CLI within --timeout and returns its standard output; the
+// error carries the first line of its standard error
+func runSecretsCommand(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, name, args...)
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s: no answer within %s", name, secretsTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %s", name, firstLine(message))
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// withSecretFile writes content to a file only the user can read, for the CLIs that read
+// values from files instead of their arguments, where other users could see them
+func withSecretFile(content []byte, use func(path string) error) error {
+	file, err := os.CreateTemp("", "microframework-secret-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return use(file.Name())
+}
+
+// kubernetesSecrets keeps the secrets in the keys of a Kubernetes secret, <service>-secrets by
+// default, which the generated deployment reads its environment from
+type kubernetesSecrets struct {
+	environment *deploymentEnvironment
+	name        string
+}
+
+func (b *kubernetesSecrets) location() string {
+	return fmt.Sprintf("secret %s of the %s environment", b.name, b.environment.Name)
+}
+
+func (b *kubernetesSecrets) kubectl(args ...string) ([]byte, error) {
+	return runSecretsCommand("kubectl", append(b.environment.kubectlArgs(), args...)...)
+}
+
+// read returns the decoded keys of the secret
+func (b *kubernetesSecrets) read() (map[string]string, error) {
+	output, err := b.kubectl("get", "secret", b.name, "--output", "json")
+	if err != nil {
+		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "not found") {
+			return nil, secrets.ErrNotFound
+		}
+		return nil, err
+	}
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(output, &secret); err != nil {
+		return nil, fmt.Errorf("kubectl: %w", err)
+	}
+	values := make(map[string]string, len(secret.Data))
+	for key, encoded := range secret.Data {
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s of secret %s: %w", key, b.name, err)
+		}
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+func (b *kubernetesSecrets) list() ([]secretKey, error) {
+	values, err := b.read()
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]secretKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, secretKey{Key: key})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys, nil
+}
+
+func (b *kubernetesSecrets) get(key string) (string, error) {
+	values, err := b.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+// set patches the keys of the secret in one request, creating it when it does not exist
+func (b *kubernetesSecrets) set(values []secretValue) error {
+	data := make(map[string]string, len(values))
+	for _, value := range values {
+		data[value.Key] = base64.StdEncoding.EncodeToString([]byte(value.Value))
+	}
+
+	_, err := b.read()
+	if errors.Is(err, secrets.ErrNotFound) {
+		manifest, _ := json.Marshal(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "Opaque",
+			"metadata":   map[string]any{"name": b.name, "labels": map[string]string{"app": b.environment.Service}},
+			"data":       data,
+		})
+		return withSecretFile(manifest, func(path string) error {
+			_, err := b.kubectl("create", "--filename", path)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+	patch, _ := json.Marshal(map[string]any{"data": data})
+	return withSecretFile(patch, func(path string) error {
+		_, err := b.kubectl("patch", "secret", b.name, "--type", "merge", "--patch-file", path)
+		return err
+	})
+}
+
+// vaultSecrets keeps the secrets in the keys of a KV secret, secret/<service> by default,
+// which the generated configuration references as vault://secret/<service>#<key>
+type vaultSecrets struct {
+	mount string
+	path  string
+}
+
+func (b *vaultSecrets) location() string {
+	return fmt.Sprintf("Vault secret %s/%s", b.mount, b.path)
+}
+
+// read returns the keys of the latest version of the secret and its creation time
+func (b *vaultSecrets) read() (map[string]string, string, error) {
+	output, err := runSecretsCommand("vault", "kv", "get", "-format=json", "-mount="+b.mount, b.path)
+	if err != nil {
+		if strings.Contains(err.Error(), "No value found") {
+			return nil, "", secrets.ErrNotFound
+		}
+		return nil, "", err
+	}
+	var secret struct {
+		Data struct {
+			Data     map[string]any `json:"data"`
+			Metadata struct {
+				CreatedTime string `json:"created_time"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &secret); err != nil {
+		return nil, "", fmt.Errorf("vault: %w", err)
+	}
+	values := make(map[string]string, len(secret.Data.Data))
+	for key, value := range secret.Data.Data {
+		if text, ok := value.(string); ok {
+			values[key] = text
+		} else {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, secret.Data.Metadata.CreatedTime, nil
+}
+
+func (b *vaultSecrets) list() ([]secretKey, error) {
+	values, updated, err := b.read()
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]secretKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, secretKey{Key: key, Updated: updated})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys, nil
+}
+
+func (b *vaultSecrets) get(key string) (string, error) {
+	values, _, err := b.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+// set writes the keys in one new version of the secret, keeping its other keys
+func (b *vaultSecrets) set(values []secretValue) error {
+	operation := "patch"
+	if _, _, err := b.read(); errors.Is(err, secrets.ErrNotFound) {
+		operation = "put"
+	} else if err != nil {
+		return err
+	}
+
+	// key=@file reads a value from a file; the files are removed once vault has run
+	args := []string{"kv", operation, "-mount=" + b.mount, b.path}
+	var files []string
+	defer func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}()
+	for _, value := range values {
+		file, err := os.CreateTemp("", "microframework-secret-*")
+		if err != nil {
+			return err
+		}
+		files = append(files, file.Name())
+		_, err = file.WriteString(value.Value)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		args = append(args, value.Key+"=@"+file.Name())
+	}
+	_, err := runSecretsCommand("vault", args...)
+	return err
+}
+
+// ssmSecrets keeps the secrets in SecureString parameters /<service>/<key>, which the generated
+// configuration references as ssm:///<service>/<key>
+type ssmSecrets struct {
+	prefix string
+	region string
+}
+
+func (b *ssmSecrets) location() string {
+	return "SSM parameters " + b.prefix + "*"
+}
+
+func (b *ssmSecrets) aws(args ...string) ([]byte, error) {
+	if b.region != "" {
+		args = append(args, "--region", b.region)
+	}
+	return runSecretsCommand("aws", append([]string{"ssm"}, args...)...)
+}
+
+func (b *ssmSecrets) list() ([]secretKey, error) {
+	output, err := b.aws("get-parameters-by-path", "--path", b.prefix, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Parameters []struct {
+			Name string `json:"Name"`
+			// LastModifiedDate is a timestamp in version 1 of the CLI, a date in version 2
+			LastModifiedDate json.RawMessage `json:"LastModifiedDate"`
+		} `json:"Parameters"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	var keys []secretKey
+	for _, parameter := range result.Parameters {
+		key := secretKey{Key: strings.TrimPrefix(parameter.Name, b.prefix)}
+		var seconds float64
+		if json.Unmarshal(parameter.LastModifiedDate, &seconds) == nil {
+			key.Updated = time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+		} else {
+			_ = json.Unmarshal(parameter.LastModifiedDate, &key.Updated)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys, nil
+}
+
+func (b *ssmSecrets) get(key string) (string, error) {
+	output, err := b.aws("get-parameter", "--name", b.prefix+key, "--with-decryption", "--output", "json")
+	if err != nil {
+		if strings.Contains(err.Error(), "ParameterNotFound") {
+			return "", secrets.ErrNotFound
+		}
+		return "", err
+	}
+	var result struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", fmt.Errorf("aws: %w", err)
+	}
+	return result.Parameter.Value, nil
+}
+
+// set writes the parameters one after the other, in the order of values: SSM has no
+// transaction
+func (b *ssmSecrets) set(values []secretValue) error {
+	for _, value := range values {
+		err := withSecretFile([]byte(value.Value), func(path string) error {
+			_, err := b.aws("put-parameter", "--name", b.prefix+value.Key, "--type", "SecureString",
+				"--overwrite", "--value", "file://"+path)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", value.Key, err)
+		}
+	}
+	return nil
+}
+
+// gsmSecrets keeps the secrets in Google Secret Manager secrets <service>-<key>, which the
+// generated configuration references as gsm://<service>-<key>
+type gsmSecrets struct {
+	prefix  string
+	project string
+}
+
+func (b *gsmSecrets) location() string {
+	return "Secret Manager secrets " + b.prefix + "*"
+}
+
+func (b *gsmSecrets) gcloud(args ...string) ([]byte, error) {
+	if b.project != "" {
+		args = append(args, "--project", b.project)
+	}
+	return runSecretsCommand("gcloud", append([]string{"secrets"}, args...)...)
+}
+
+func (b *gsmSecrets) list() ([]secretKey, error) {
+	output, err := b.gcloud("list", "--filter", "name:"+b.prefix, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result []struct {
+		Name       string `json:"name"`
+		CreateTime string `json:"createTime"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("gcloud: %w", err)
+	}
+	var keys []secretKey
+	for _, secret := range result {
+		name := secret.Name[strings.LastIndex(secret.Name, "/")+1:]
+		if key, ok := strings.CutPrefix(name, b.prefix); ok {
+			keys = append(keys, secretKey{Key: key, Updated: secret.CreateTime})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys, nil
+}
+
+func (b *gsmSecrets) get(key string) (string, error) {
+	output, err := b.gcloud("versions", "access", "latest", "--secret", b.prefix+key)
+	if err != nil {
+		if strings.Contains(err.Error(), "NOT_FOUND") {
+			return "", secrets.ErrNotFound
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// set adds a version to each secret, creating the secrets that do not exist
+func (b *gsmSecrets) set(values []secretValue) error {
+	for _, value := range values {
+		err := withSecretFile([]byte(value.Value), func(path string) error {
+			_, err := b.gcloud("versions", "add", b.prefix+value.Key, "--data-file", path)
+			if err != nil && strings.Contains(err.Error(), "NOT_FOUND") {
+				_, err = b.gcloud("create", b.prefix+value.Key, "--data-file", path, "--replication-policy", "automatic")
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", value.Key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+package commands
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveHost  string
+	servePort  int
+	serveRoot  string
+	serveToken string
+)
+
+// serveTokenEnv holds the token of the API when --token is not given
+const serveTokenEnv = "MICROFRAMEWORK_SERVE_TOKEN"
+
+// serveMaxBody bounds the size of the request bodies
+const serveMaxBody = 1 << 20
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the generator, validation and project manifests over HTTP",
+	Long: `Start an HTTP server exposing the generator, validation and the generation manifests
+of the projects under --root as a JSON API, so that internal developer portals (a
+self-service "create microservice" page, for example) drive the framework without shelling
+out to the CLI.
+
+Endpoints:
+  GET  /v1/health                    the version of the server
+  GET  /v1/templates                 the names of the built-in templates
+  POST /v1/services/validate         check a generator configuration without generating
+  POST /v1/services                  generate a service under --root (?render=true returns
+                                     the files instead of writing them)
+  GET  /v1/projects                  the generated projects under --root
+  GET  /v1/projects/{name}/manifest  the generation manifest of a project
+  POST /v1/projects/{name}/validate  validate a project: {"type": "all", "fail_on": "error"}
+
+The configurations are those of the generation manifests, as {"ServiceName": "orders",
+"ServiceType": "rest", "WithDatabase": true, "DatabaseProvider": "postgres"}, and
+"TemplatePack": "acme@1.2.0" generates from a cached template pack. A generation
+requested with Accept: application/x-ndjson streams its progress events, one per line,
+followed by the result. Errors answer {"error": {"message", "category"}} with a status of
+their category: 400 user, 422 validation, 503 environment, 500 otherwise.
+
+Every request needs an Authorization: Bearer header with the token of --token, or
+MICROFRAMEWORK_SERVE_TOKEN; the server does not start without one. Requests run one at a
+time.
+
+Examples:
+  microframework serve --token "$TOKEN"
+  microframework serve --root /srv/services --host 0.0.0.0 --port 8585 --token "$TOKEN"
+  curl -X POST localhost:8585/v1/services -H "Authorization: Bearer $TOKEN" -d '{"ServiceName": "orders", "ServiceType": "rest"}'`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveHost, "host", "localhost", "Address to listen on")
+	serveCmd.Flags().IntVar(&servePort, "port", 8585, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveRoot, "root", ".", "Directory the services are generated in and the projects are read from")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token the requests must present (default $"+serveTokenEnv+")")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	root, err := filepath.Abs(serveRoot)
+	if err != nil {
+		return &UserError{fmt.Errorf("invalid --root: %w", err)}
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return &UserError{fmt.Errorf("--root %s is not a directory", serveRoot)}
+	}
+	token := serveToken
+	if token == "" {
+		token = os.Getenv(serveTokenEnv)
+	}
+	if token == "" {
+		return &UserError{fmt.Errorf("no --token or $%s: the API generates files on this machine and needs a token", serveTokenEnv)}
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(serveHost, strconv.Itoa(servePort)))
+	if err != nil {
+		return &EnvironmentError{fmt.Errorf("failed to listen on %s:%d: %w", serveHost, servePort, err)}
+	}
+	server := &apiServer{root: root, token: token}
+	httpServer := &http.Server{Handler: server.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("✓ Serving the projects of %s on http://%s\n", root, listener.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("Stopping...")
+	return nil
+}
+
+// apiServer answers the requests of the API on the projects under root
+type apiServer struct {
+	root  string
+	token string
+	// mu runs the requests one at a time: the generator options and the validation keep
+	// state in the process, and validation runs in the directory of the project
+	mu sync.Mutex
+}
+
+// serveValidateRequest is the body of POST /v1/projects/{name}/validate
+type serveValidateRequest struct {
+	Type   string `json:"type"`
+	FailOn string `json:"fail_on"`
+}
+
+// serveProject is a project of GET /v1/projects
+type serveProject struct {
+	Name             string `json:"name"`
+	ServiceType      string `json:"service_type"`
+	FrameworkVersion string `json:"framework_version"`
+}
+
+// serveGenerateResult is the answer of POST /v1/services
+type serveGenerateResult struct {
+	Service   string `json:"service"`
+	Directory string `json:"directory,omitempty"`
+	// Files are the generated files, relative to the project; with their contents when
+	// rendered
+	Files    []string          `json:"files"`
+	Contents map[string]string `json:"contents,omitempty"`
+}
+
+// serveValidateResult is the answer of POST /v1/projects/{name}/validate
+type serveValidateResult struct {
+	Passed bool `json:"passed"`
+	validateResult
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.serveHealth)
+	mux.HandleFunc("GET /v1/templates", s.serveTemplates)
+	mux.HandleFunc("POST /v1/services/validate", s.serveValidateConfig)
+	mux.HandleFunc("POST /v1/services", s.serveGenerate)
+	mux.HandleFunc("GET /v1/projects", s.serveProjects)
+	mux.HandleFunc("GET /v1/projects/{name}/manifest", s.serveManifest)
+	mux.HandleFunc("POST /v1/projects/{name}/validate", s.serveValidateProject)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if !s.authorized(request) {
+			writeServeError(w, &UserError{errors.New("missing or invalid bearer token")}, http.StatusUnauthorized)
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		s.mu.Lock()
+		mux.ServeHTTP(recorder, request)
+		s.mu.Unlock()
+		fmt.Printf("%s %s %d %s\n", request.Method, request.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// authorized reports whether a request presents the token of the server
+func (s *apiServer) authorized(request *http.Request) bool {
+	token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *apiServer) serveHealth(w http.ResponseWriter, request *http.Request) {
+	writeServeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": version, "templates": templates.Version})
+}
+
+func (s *apiServer) serveTemplates(w http.ResponseWriter, request *http.Request) {
+	writeServeJSON(w, http.StatusOK, map[string]interface{}{"templates": templates.NewRegistry().Names()})
+}
+
+func (s *apiServer) serveValidateConfig(w http.ResponseWriter, request *http.Request) {
+	config, err := readServeConfig(w, request)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	if err := config.Validate(); err != nil {
+		var configErr *generator.ConfigError
+		if errors.As(err, &configErr) {
+			writeServeJSON(w, http.StatusOK, map[string]interface{}{"valid": false, "field": configErr.Field, "reason": configErr.Reason})
+			return
+		}
+		writeServeError(w, err, 0)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, map[string]interface{}{"valid": true})
+}
+
+func (s *apiServer) serveGenerate(w http.ResponseWriter, request *http.Request) {
+	config, err := readServeConfig(w, request)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	if err := config.Validate(); err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	config.OutputDir = s.root
+	config.FrameworkVersion = version
+
+	opts, err := generatorOptions()
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	packOpts, pack, err := templatePackOptions(config.TemplatePack)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	config.TemplatePack = pack
+	opts = append(opts, packOpts...)
+
+	if render, _ := strconv.ParseBool(request.URL.Query().Get("render")); render {
+		files, err := generator.NewServiceGenerator(config, opts...).RenderService()
+		if err != nil {
+			writeServeError(w, &GenerationError{err}, 0)
+			return
+		}
+		result := serveGenerateResult{Service: config.ServiceName, Contents: map[string]string{}}
+		for path, content := range files {
+			result.Files = append(result.Files, path)
+			result.Contents[path] = string(content)
+		}
+		sort.Strings(result.Files)
+		writeServeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	dir := filepath.Join(s.root, config.ServiceName)
+	if _, err := os.Stat(dir); err == nil {
+		writeServeError(w, &UserError{fmt.Errorf("%s exists already", config.ServiceName)}, http.StatusConflict)
+		return
+	}
+
+	result := serveGenerateResult{Service: config.ServiceName, Directory: dir}
+	opts = append(opts, generator.WithHooks(generator.Hooks{After: func(ctx *generator.HookContext) error {
+		result.Files = append(result.Files, ctx.Files...)
+		return nil
+	}}))
+	generate := func(opts ...generator.Option) error {
+		if err := generator.NewServiceGenerator(config, opts...).GenerateService(); err != nil {
+			os.RemoveAll(dir)
+			return &GenerationError{fmt.Errorf("failed to generate service: %w", err)}
+		}
+		if err := writeProjectLock(dir); err != nil {
+			return &GenerationError{fmt.Errorf("failed to write %s: %w", projectLockFile, err)}
+		}
+		return nil
+	}
+
+	if !strings.Contains(request.Header.Get("Accept"), "application/x-ndjson") {
+		if err := generate(opts...); err != nil {
+			writeServeError(w, err, 0)
+			return
+		}
+		writeServeJSON(w, http.StatusCreated, result)
+		return
+	}
+
+	// Stream the progress events, then the result or the error, one per line
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher := http.NewResponseController(w)
+	events := make(chan progress.Event)
+	done := make(chan error, 1)
+	go func(opts []generator.Option) {
+		done <- generate(opts...)
+		close(events)
+	}(append(opts, generator.WithProgress(events)))
+	for event := range events {
+		encoder.Encode(event)
+		flusher.Flush()
+	}
+	if err := <-done; err != nil {
+		category, _ := errorCategory(err)
+		encoder.Encode(map[string]interface{}{"error": map[string]string{"message": err.Error(), "category": category}})
+		return
+	}
+	encoder.Encode(result)
+}
+
+func (s *apiServer) serveProjects(w http.ResponseWriter, request *http.Request) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	projects := []serveProject{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifest, err := generator.LoadManifest(filepath.Join(s.root, entry.Name()))
+		if err != nil {
+			continue
+		}
+		projects = append(projects, serveProject{
+			Name:             entry.Name(),
+			ServiceType:      manifest.Config.ServiceType,
+			FrameworkVersion: manifest.FrameworkVersion,
+		})
+	}
+	writeServeJSON(w, http.StatusOK, map[string]interface{}{"projects": projects})
+}
+
+func (s *apiServer) serveManifest(w http.ResponseWriter, request *http.Request) {
+	dir, err := s.projectDir(request)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	manifest, err := generator.LoadManifest(dir)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, manifest)
+}
+
+func (s *apiServer) serveValidateProject(w http.ResponseWriter, request *http.Request) {
+	dir, err := s.projectDir(request)
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	body := serveValidateRequest{Type: "all", FailOn: SeverityError}
+	if request.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, request.Body, serveMaxBody)).Decode(&body); err != nil {
+			writeServeError(w, &UserError{fmt.Errorf("invalid request: %w", err)}, 0)
+			return
+		}
+	}
+	if err := validateValidationType(body.Type); err != nil {
+		writeServeError(w, &UserError{err}, 0)
+		return
+	}
+	failOn, err := parseFailOn(body.FailOn)
+	if err != nil {
+		writeServeError(w, &UserError{err}, 0)
+		return
+	}
+
+	// Validation runs in the directory of the project, with the state of a validate run
+	previous, err := os.Getwd()
+	if err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	if err := os.Chdir(dir); err != nil {
+		writeServeError(w, err, 0)
+		return
+	}
+	defer os.Chdir(previous)
+	resetValidation()
+	validateType, validateFailOn = body.Type, failOn
+
+	err = validateByType(body.Type, "", false)
+	var failure *ValidationFailure
+	if err != nil && !errors.As(err, &failure) {
+		writeServeError(w, err, 0)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, serveValidateResult{Passed: err == nil, validateResult: newValidateResult()})
+}
+
+// projectDir returns the directory of the project named in the path of a request
+func (s *apiServer) projectDir(request *http.Request) (string, error) {
+	name := request.PathValue("name")
+	if err := validateServiceName(name); err != nil {
+		return "", &UserError{fmt.Errorf("invalid project %q: %w", name, err)}
+	}
+	dir := filepath.Join(s.root, name)
+	if _, err := os.Stat(filepath.Join(dir, generator.ManifestFile)); err != nil {
+		return "", &UserError{fmt.Errorf("no generated project %s", name)}
+	}
+	return dir, nil
+}
+
+// readServeConfig reads the generator configuration in the body of a request
+func readServeConfig(w http.ResponseWriter, request *http.Request) (*generator.GeneratorConfig, error) {
+	var config generator.GeneratorConfig
+	decoder := json.NewDecoder(http.MaxBytesReader(w, request.Body, serveMaxBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, &UserError{fmt.Errorf("invalid configuration: %w", err)}
+	}
+	if err := validateServiceName(config.ServiceName); err != nil {
+		return nil, &UserError{fmt.Errorf("invalid ServiceName: %w", err)}
+	}
+	return &config, nil
+}
+
+// writeServeJSON answers with a JSON document
+func writeServeJSON(w http.ResponseWriter, status int, document interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(document)
+}
+
+// writeServeError answers with an error, with the status of its category unless status is
+// given
+func writeServeError(w http.ResponseWriter, err error, status int) {
+	category, _ := errorCategory(err)
+	if status == 0 {
+		switch category {
+		case ErrorCategoryUser:
+			status = http.StatusBadRequest
+		case ErrorCategoryValidation:
+			status = http.StatusUnprocessableEntity
+		case ErrorCategoryEnvironment:
+			status = http.StatusServiceUnavailable
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+	writeServeJSON(w, status, map[string]interface{}{"error": map[string]string{"message": err.Error(), "category": category}})
+}
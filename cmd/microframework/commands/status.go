@@ -0,0 +1,527 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusEnvs       []string
+	statusOutput     string
+	statusHealthPath string
+	statusTimeout    time.Duration
+	statusMigrations bool
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the service in every environment",
+	Long: `Show, for every environment of deployments/environments.yaml, the deployed version, the
+replicas, the result of the health endpoint and the pending migrations.
+
+The version is the tag of the deployed image. The health endpoint is requested at the url of
+the environment, through the Kubernetes API for a Kubernetes environment without one, or at
+the URL of the Cloud Run service. The migrations are those microframework migrate status
+reports as pending or dirty with the environment's configuration overlay, so they need the
+database of the environment to be reachable from here.
+
+Examples:
+  microframework status
+  microframework status --env staging --env production
+  microframework status --output json --no-migrations`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().StringSliceVarP(&statusEnvs, "env", "e", nil, "Environments to show (default: every environment)")
+	statusCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", "table", "Output format (table, json)")
+	statusCmd.Flags().StringVar(&statusHealthPath, "health-path", "/health", "Path of the health endpoint")
+	statusCmd.Flags().DurationVar(&statusTimeout, "timeout", 10*time.Second, "Timeout of each query")
+	statusCmd.Flags().BoolVar(&statusMigrations, "migrations", true, "Check the pending migrations (--migrations=false to skip)")
+}
+
+// environmentStatus is the state of the service in an environment
+type environmentStatus struct {
+	Environment string `json:"environment"`
+	Target      string `json:"target"`
+	Image       string `json:"image,omitempty"`
+	Version     string `json:"version,omitempty"`
+	// Replicas is ready/desired, or what the target reports instead
+	Replicas   string `json:"replicas,omitempty"`
+	Health     string `json:"health,omitempty"`
+	Healthy    *bool  `json:"healthy,omitempty"`
+	Migrations string `json:"migrations,omitempty"`
+	Pending    *int   `json:"pending_migrations,omitempty"`
+	// Errors holds what could not be queried
+	Errors []string `json:"errors,omitempty"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	if err := checkMicroserviceDirectory(); err != nil {
+		return err
+	}
+	if statusOutput != "table" && statusOutput != "json" {
+		return fmt.Errorf("invalid output format %q (table, json)", statusOutput)
+	}
+	environments, err := loadEnvironments()
+	if err != nil {
+		return err
+	}
+	if len(statusEnvs) > 0 {
+		var selected []*deploymentEnvironment
+		for _, name := range statusEnvs {
+			environment, err := findEnvironment(name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, environment)
+		}
+		environments = selected
+	}
+
+	// The environments are queried in parallel: each may wait for a timeout
+	statuses := make([]environmentStatus, len(environments))
+	var wg sync.WaitGroup
+	for i, environment := range environments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = queryEnvironmentStatus(environment)
+		}()
+	}
+	wg.Wait()
+
+	if statusOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ENVIRONMENT\tTARGET\tVERSION\tREPLICAS\tHEALTH\tMIGRATIONS")
+	for _, status := range statuses {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", status.Environment, status.Target,
+			orDash(status.Version), orDash(status.Replicas), orDash(status.Health), orDash(status.Migrations))
+	}
+	writer.Flush()
+
+	for _, status := range statuses {
+		for _, problem := range status.Errors {
+			fmt.Printf("\n%s: %s", status.Environment, problem)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// queryEnvironmentStatus queries the deployment, the health and the migrations of an
+// environment
+func queryEnvironmentStatus(environment *deploymentEnvironment) environmentStatus {
+	status := environmentStatus{Environment: environment.Name, Target: environment.Target}
+	healthURL := ""
+	if environment.URL != "" {
+		healthURL = strings.TrimSuffix(environment.URL, "/") + statusHealthPath
+	}
+
+	var err error
+	switch environment.Target {
+	case TargetDocker:
+		err = dockerStatus(environment, &status)
+	case TargetCompose:
+		err = composeStatus(environment, &status)
+	case TargetKubernetes:
+		err = kubernetesStatus(environment, &status)
+		if err == nil && healthURL == "" {
+			kubernetesHealth(environment, &status)
+		}
+	case TargetECS:
+		err = ecsStatus(environment, &status)
+	case TargetCloudRun:
+		var serviceURL string
+		serviceURL, err = cloudRunStatus(environment, &status)
+		if healthURL == "" && serviceURL != "" {
+			healthURL = strings.TrimSuffix(serviceURL, "/") + statusHealthPath
+		}
+	}
+	if err != nil {
+		status.Errors = append(status.Errors, err.Error())
+	}
+	if status.Image != "" && status.Version == "" {
+		status.Version = imageVersion(status.Image)
+	}
+	if healthURL != "" {
+		httpHealth(healthURL, &status)
+	}
+	if statusMigrations {
+		migrationStatus(environment, &status)
+	}
+	return status
+}
+
+// imageVersion returns the tag of an image, or the start of its digest
+func imageVersion(image string) string {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		if tag := imageVersion(name); tag != "latest" {
+			return tag
+		}
+		digest = strings.TrimPrefix(digest, "sha256:")
+		return "@" + digest[:min(12, len(digest))]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); ok {
+		return tag
+	}
+	return "latest"
+}
+
+func setHealth(status *environmentStatus, healthy bool, health string) {
+	status.Healthy, status.Health = &healthy, health
+}
+
+// httpHealth requests the health endpoint
+func httpHealth(url string, status *environmentStatus) {
+	client := &http.Client{Timeout: statusTimeout}
+	started := time.Now()
+	response, err := client.Get(url)
+	if err != nil {
+		setHealth(status, false, "unreachable")
+		status.Errors = append(status.Errors, fmt.Sprintf("health: %v", err))
+		return
+	}
+	response.Body.Close()
+	elapsed := time.Since(started).Round(time.Millisecond)
+	healthy := response.StatusCode >= 200 && response.StatusCode < 300
+	result := "ok"
+	if !healthy {
+		result = "failing"
+	}
+	setHealth(status, healthy, fmt.Sprintf("%s (%d, %s)", result, response.StatusCode, elapsed))
+}
+
+// kubernetesHealth requests the health endpoint through the API server proxy to the service
+// the generated manifests define, <service>-service
+func kubernetesHealth(environment *deploymentEnvironment, status *environmentStatus) {
+	namespace := environment.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/services/%s-service:80/proxy%s", namespace, environment.Service, statusHealthPath)
+	started := time.Now()
+	args := append(environment.kubectlArgs(), "get", "--raw", path)
+	if _, err := probeWithin(statusTimeout, "kubectl", args...); err != nil {
+		setHealth(status, false, "failing")
+		status.Errors = append(status.Errors, fmt.Sprintf("health: %v", err))
+		return
+	}
+	setHealth(status, true, fmt.Sprintf("ok (%s)", time.Since(started).Round(time.Millisecond)))
+}
+
+// kubernetesStatus reads the image and the replicas of the deployment
+func kubernetesStatus(environment *deploymentEnvironment, status *environmentStatus) error {
+	args := append(environment.kubectlArgs(), "get", "deployment", environment.Service, "--output", "json")
+	output, err := probeWithin(statusTimeout, "kubectl", args...)
+	if err != nil {
+		return fmt.Errorf("kubectl: %w", err)
+	}
+	var deployment struct {
+		Spec struct {
+			Replicas *int `json:"replicas"`
+			Template struct {
+				Spec struct {
+					Containers []struct {
+						Name  string `json:"name"`
+						Image string `json:"image"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+		Status struct {
+			ReadyReplicas   int `json:"readyReplicas"`
+			UpdatedReplicas int `json:"updatedReplicas"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &deployment); err != nil {
+		return fmt.Errorf("kubectl: %w", err)
+	}
+	for i, container := range deployment.Spec.Template.Spec.Containers {
+		if i == 0 || container.Name == environment.Service {
+			status.Image = container.Image
+		}
+	}
+	desired := 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	status.Replicas = fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, desired)
+	if deployment.Status.UpdatedReplicas < desired {
+		status.Replicas += fmt.Sprintf(" (%d updated)", deployment.Status.UpdatedReplicas)
+	}
+	return nil
+}
+
+// ecsStatus reads the task definition and the task counts of the ECS service
+func ecsStatus(environment *deploymentEnvironment, status *environmentStatus) error {
+	args := append([]string{"ecs", "describe-services", "--services", environment.Service, "--output", "json"}, environment.awsArgs()...)
+	output, err := probeWithin(statusTimeout, "aws", args...)
+	if err != nil {
+		return fmt.Errorf("aws: %w", err)
+	}
+	var described struct {
+		Services []struct {
+			DesiredCount   int    `json:"desiredCount"`
+			RunningCount   int    `json:"runningCount"`
+			PendingCount   int    `json:"pendingCount"`
+			TaskDefinition string `json:"taskDefinition"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal([]byte(output), &described); err != nil {
+		return fmt.Errorf("aws: %w", err)
+	}
+	if len(described.Services) == 0 {
+		return fmt.Errorf("aws: no ECS service %s", environment.Service)
+	}
+	service := described.Services[0]
+	status.Replicas = fmt.Sprintf("%d/%d", service.RunningCount, service.DesiredCount)
+	if service.PendingCount > 0 {
+		status.Replicas += fmt.Sprintf(" (%d pending)", service.PendingCount)
+	}
+
+	// The task definition is family:revision; the image is in its containers
+	revision := service.TaskDefinition[strings.LastIndex(service.TaskDefinition, "/")+1:]
+	args = []string{"ecs", "describe-task-definition", "--task-definition", service.TaskDefinition, "--output", "json"}
+	if environment.Region != "" {
+		args = append(args, "--region", environment.Region)
+	}
+	output, err = probeWithin(statusTimeout, "aws", args...)
+	if err != nil {
+		status.Version = revision
+		return fmt.Errorf("aws: %w", err)
+	}
+	var definition struct {
+		TaskDefinition struct {
+			ContainerDefinitions []struct {
+				Name  string `json:"name"`
+				Image string `json:"image"`
+			} `json:"containerDefinitions"`
+		} `json:"taskDefinition"`
+	}
+	if err := json.Unmarshal([]byte(output), &definition); err != nil {
+		return fmt.Errorf("aws: %w", err)
+	}
+	for i, container := range definition.TaskDefinition.ContainerDefinitions {
+		if i == 0 || container.Name == environment.Service {
+			status.Image = container.Image
+		}
+	}
+	if status.Image != "" {
+		status.Version = fmt.Sprintf("%s (%s)", imageVersion(status.Image), revision)
+	}
+	return nil
+}
+
+// dockerContainer is the part of docker inspect and docker compose ps status reads
+type dockerContainer struct {
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	State struct {
+		Status string `json:"Status"`
+		Health *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// dockerStatus reads the image and the state of the container
+func dockerStatus(environment *deploymentEnvironment, status *environmentStatus) error {
+	output, err := probeWithin(statusTimeout, "docker", "inspect", "--type", "container", environment.Service)
+	if err != nil {
+		return fmt.Errorf("docker: %w", err)
+	}
+	var containers []dockerContainer
+	if err := json.Unmarshal([]byte(output), &containers); err != nil || len(containers) == 0 {
+		return fmt.Errorf("docker: no container %s", environment.Service)
+	}
+	container := containers[0]
+	status.Image = container.Config.Image
+	running := 0
+	if container.State.Status == "running" {
+		running = 1
+	}
+	status.Replicas = fmt.Sprintf("%d/1 (%s)", running, container.State.Status)
+	if container.State.Health != nil && environment.URL == "" {
+		setHealth(status, container.State.Health.Status == "healthy", container.State.Health.Status)
+	}
+	return nil
+}
+
+// composeStatus reads the image and the state of the containers of the compose service
+func composeStatus(environment *deploymentEnvironment, status *environmentStatus) error {
+	compose, err := composeCommand()
+	if err != nil {
+		return fmt.Errorf("needs %w", err)
+	}
+	args := append(append([]string{}, compose[1:]...), "-f", environment.ComposeFile, "ps", "--all", "--format", "json", environment.Service)
+	output, err := probeWithin(statusTimeout, compose[0], args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(compose, " "), err)
+	}
+
+	type composeContainer struct {
+		Image  string `json:"Image"`
+		State  string `json:"State"`
+		Health string `json:"Health"`
+	}
+	// Older versions print an array, newer ones an object per line
+	var containers []composeContainer
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &containers); err != nil {
+			return fmt.Errorf("%s ps: %w", strings.Join(compose, " "), err)
+		}
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			var container composeContainer
+			if json.Unmarshal([]byte(line), &container) == nil {
+				containers = append(containers, container)
+			}
+		}
+	}
+	if len(containers) == 0 {
+		status.Replicas = "0/0 (not created)"
+		return nil
+	}
+
+	running, healthy := 0, 0
+	for _, container := range containers {
+		status.Image = container.Image
+		if container.State == "running" {
+			running++
+		}
+		if container.Health == "healthy" {
+			healthy++
+		}
+	}
+	status.Replicas = fmt.Sprintf("%d/%d", running, len(containers))
+	if containers[0].Health != "" && environment.URL == "" {
+		setHealth(status, healthy == len(containers), fmt.Sprintf("%d/%d healthy", healthy, len(containers)))
+	}
+	return nil
+}
+
+// cloudRunStatus reads the image, the revision and the scaling of the Cloud Run service, and
+// returns its URL
+func cloudRunStatus(environment *deploymentEnvironment, status *environmentStatus) (string, error) {
+	args := append([]string{"run", "services", "describe", environment.Service, "--format", "json"}, environment.gcloudArgs()...)
+	output, err := probeWithin(statusTimeout, "gcloud", args...)
+	if err != nil {
+		return "", fmt.Errorf("gcloud: %w", err)
+	}
+	var service struct {
+		Spec struct {
+			Template struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+				Spec struct {
+					Containers []struct {
+						Image string `json:"image"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+		Status struct {
+			URL                     string `json:"url"`
+			LatestReadyRevisionName string `json:"latestReadyRevisionName"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &service); err != nil {
+		return "", fmt.Errorf("gcloud: %w", err)
+	}
+	if containers := service.Spec.Template.Spec.Containers; len(containers) > 0 {
+		status.Image = containers[0].Image
+		status.Version = fmt.Sprintf("%s (%s)", imageVersion(status.Image), service.Status.LatestReadyRevisionName)
+	}
+	annotations := service.Spec.Template.Metadata.Annotations
+	minScale, maxScale := annotations["autoscaling.knative.dev/minScale"], annotations["autoscaling.knative.dev/maxScale"]
+	status.Replicas = fmt.Sprintf("auto %s-%s", orDefault(minScale, "0"), orDefault(maxScale, "100"))
+	return service.Status.URL, nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// migrationStatus counts the pending and dirty migrations, with microframework migrate status
+// run on the environment's configuration
+func migrationStatus(environment *deploymentEnvironment, status *environmentStatus) {
+	if entries, err := os.ReadDir("migrations"); err != nil || len(entries) == 0 {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	args := []string{"migrate", "status", "--output", "json"}
+	if environment.Config != "" {
+		args = append(args, "--env", environment.Config)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, self, args...).Output()
+	if err != nil {
+		status.Migrations = "unknown"
+		message := err.Error()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			message = firstLine(string(exitErr.Stderr))
+		}
+		if ctx.Err() != nil {
+			message = fmt.Sprintf("no answer within %s", statusTimeout)
+		}
+		status.Errors = append(status.Errors, "migrations: "+message)
+		return
+	}
+
+	var entries []migrationStatusEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		status.Migrations = "unknown"
+		status.Errors = append(status.Errors, "migrations: "+err.Error())
+		return
+	}
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.State]++
+	}
+	pending := counts[MigrationStatePending]
+	status.Pending = &pending
+	status.Migrations = fmt.Sprintf("%d pending", pending)
+	if pending == 0 {
+		status.Migrations = "up to date"
+	}
+	if dirty := counts[MigrationStateDirty] + counts[MigrationStateMissing]; dirty > 0 {
+		status.Migrations += fmt.Sprintf(", %d dirty or missing", dirty)
+	}
+}
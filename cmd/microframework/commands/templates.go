@@ -0,0 +1,781 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	templatesVersion  string
+	templatesChecksum string
+	templatesForce    bool
+	templatesOutput   string
+)
+
+// templatePackFile describes a template pack, at its root
+const templatePackFile = "pack.yaml"
+
+// templatePackIndexFile records the cached template packs, in the template packs directory
+const templatePackIndexFile = "packs.json"
+
+// templatePackTemplatesDir is the directory of the templates of a pack, unless its pack.yaml
+// names another
+const templatePackTemplatesDir = "templates"
+
+// ociPrefix marks the sources of template packs that are OCI artifacts
+const ociPrefix = "oci://"
+
+// templatePackNamePattern is what the name and the version of a pack may be made of
+var templatePackNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// templatesCmd represents the templates command
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage the template packs services are generated from",
+	Long: `Manage template packs: sets of templates replacing the built-in ones, fetched from
+git repositories or OCI registries, so that platform teams ship and update the scaffolds of
+their organization centrally.
+
+A pack is a directory with a pack.yaml:
+
+  name: acme
+  version: 1.2.0
+  description: Acme scaffolds
+  templates: templates        # directory of the templates, default templates
+  ports:                      # ports the templates render with {{port "http"}}
+    http: 8080
+
+and templates named after the built-in ones (microframework list templates), with an
+optional .tmpl suffix: templates/deployments/docker/Dockerfile.tmpl replaces the Dockerfile.
+The built-in templates fill in those a pack does not have.
+
+Packs are cached in ~/.microframework/templates (or $MICROFRAMEWORK_TEMPLATES_DIR), one
+directory per version, with the digest of their files, which is checked every time a pack
+is used. new, init and generate use a pack with --template-pack <name>[@<version>]; the
+pack is recorded in the generation manifest, so that scaffold and update --type templates
+keep using it.
+
+Examples:
+  microframework templates add git@github.com:acme/templates.git --version v1.2.0
+  microframework templates add oci://ghcr.io/acme/templates:1.2.0
+  microframework templates add ./templates --checksum sha256:4f2a...
+  microframework templates list
+  microframework templates update acme
+  microframework templates export ../acme-templates --name acme
+  microframework new orders --template-pack acme`,
+}
+
+// templatesAddCmd represents the templates add command
+var templatesAddCmd = &cobra.Command{
+	Use:   "add <source>",
+	Short: "Fetch, verify and cache a template pack from git, an OCI registry or a local path",
+	Long: `Fetch a template pack, verify it and cache its version. The source is:
+
+  a git repository   git@github.com:acme/templates.git, github.com/acme/templates, or any
+                     URL git clones; --version checks out a branch or tag
+  an OCI artifact    oci://ghcr.io/acme/templates:1.2.0, or pinned by digest
+                     (oci://...@sha256:...), pulled with oras
+  a local path       the directory of the pack
+
+Every template must be named after a built-in one and parse. --checksum pins the digest of
+the files of the pack, which add prints; a pack that does not match is refused.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplatesAdd,
+}
+
+// templatesListCmd represents the templates list command
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached template packs",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplatesList,
+}
+
+// templatesUpdateCmd represents the templates update command
+var templatesUpdateCmd = &cobra.Command{
+	Use:   "update [pack...]",
+	Short: "Fetch the latest version of template packs from their sources",
+	Long: `Fetch template packs again from their sources, at the branch or tag they were added
+with, and make the version fetched the one --template-pack <name> uses. The versions cached
+before are kept for the projects generated from them. Without arguments, every pack is
+updated.`,
+	RunE: runTemplatesUpdate,
+}
+
+// templatesRemoveCmd represents the templates remove command
+var templatesRemoveCmd = &cobra.Command{
+	Use:   "remove <pack>[@<version>]",
+	Short: "Remove a template pack, or one of its versions, from the cache",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplatesRemove,
+}
+
+// templatesVerifyCmd represents the templates verify command
+var templatesVerifyCmd = &cobra.Command{
+	Use:   "verify [pack[@version]...]",
+	Short: "Check that cached template packs match their digests and parse",
+	RunE:  runTemplatesVerify,
+}
+
+func init() {
+	templatesAddCmd.Flags().StringVar(&templatesVersion, "version", "", "Branch or tag of a git source")
+	templatesAddCmd.Flags().StringVar(&templatesChecksum, "checksum", "", "Expected digest of the pack (sha256:<hex>)")
+	templatesAddCmd.Flags().BoolVar(&templatesForce, "force", false, "Replace a cached version of the pack whose files differ")
+	templatesListCmd.Flags().StringVarP(&templatesOutput, "output", "o", "text", "Output format (text, json)")
+
+	templatesRemoveCmd.ValidArgsFunction = completeTemplatePacks
+	templatesUpdateCmd.ValidArgsFunction = completeTemplatePacks
+	templatesVerifyCmd.ValidArgsFunction = completeTemplatePacks
+
+	templatesCmd.AddCommand(templatesAddCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesUpdateCmd)
+	templatesCmd.AddCommand(templatesRemoveCmd)
+	templatesCmd.AddCommand(templatesVerifyCmd)
+}
+
+// templatePackSpec is the content of pack.yaml
+type templatePackSpec struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+	Templates   string `yaml:"templates"`
+	// Ports are the ports the templates render with {{port "<name>"}}, by name
+	Ports map[string]int `yaml:"ports,omitempty"`
+}
+
+// renderer returns the renderer of the templates of the pack, with its port function
+func (spec *templatePackSpec) renderer() generator.TemplateRenderer {
+	return generator.NewTemplateRenderer(template.FuncMap{
+		"port": func(name string) (int, error) {
+			port, ok := spec.Ports[name]
+			if !ok {
+				return 0, fmt.Errorf("no port %s in the %s of pack %s", name, templatePackFile, spec.Name)
+			}
+			return port, nil
+		},
+	})
+}
+
+// cachedTemplatePack is the record of a cached pack in the pack index
+type cachedTemplatePack struct {
+	Source string `json:"source"`
+	// Kind is git, oci or local
+	Kind string `json:"kind"`
+	// Ref is the branch or tag of a git source, which update fetches again
+	Ref string `json:"ref,omitempty"`
+	// Current is the version --template-pack <name> uses: the last one fetched
+	Current  string                        `json:"current"`
+	Versions map[string]*cachedPackVersion `json:"versions"`
+}
+
+// cachedPackVersion is a cached version of a pack
+type cachedPackVersion struct {
+	// Revision is the commit of a git source, or the manifest digest of an OCI artifact
+	Revision string `json:"revision,omitempty"`
+	// Digest is the digest of the files of the pack
+	Digest    string    `json:"digest"`
+	Templates []string  `json:"templates"`
+	Fetched   time.Time `json:"fetched"`
+}
+
+// listedTemplatePack is a pack of templates list
+type listedTemplatePack struct {
+	Name     string   `json:"name"`
+	Current  string   `json:"current"`
+	Versions []string `json:"versions"`
+	Source   string   `json:"source"`
+	Kind     string   `json:"kind"`
+}
+
+// templatePacksDir returns the directory template packs are cached in
+func templatePacksDir() (string, error) {
+	if dir := os.Getenv("MICROFRAMEWORK_TEMPLATES_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the home directory: %w", err)
+	}
+	return filepath.Join(home, ".microframework", "templates"), nil
+}
+
+func runTemplatesAdd(cmd *cobra.Command, args []string) error {
+	if templatesChecksum != "" && !strings.HasPrefix(templatesChecksum, "sha256:") {
+		return &UserError{fmt.Errorf("invalid --checksum %q: expected sha256:<hex>", templatesChecksum)}
+	}
+	name, version, err := addTemplatePack(args[0], templatesVersion, templatesChecksum, templatesForce)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Use it with: microframework new <service> --template-pack %s\n", name)
+	reportResult(map[string]string{"pack": name, "version": version})
+	return nil
+}
+
+// addTemplatePack fetches the pack of a source, verifies it and caches its version, which
+// becomes the current one. It returns the name and version of the pack.
+func addTemplatePack(source, ref, checksum string, force bool) (string, string, error) {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return "", "", err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	fetched, err := os.MkdirTemp("", "microframework-templates-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create the fetch directory: %w", err)
+	}
+	defer os.RemoveAll(fetched)
+
+	var kind, revision, root string
+	switch {
+	case strings.HasPrefix(source, ociPrefix):
+		kind = "oci"
+		revision, err = pullOCITemplatePack(strings.TrimPrefix(source, ociPrefix), fetched)
+	case isLocalPath(source):
+		kind = "local"
+		if source, err = filepath.Abs(source); err == nil {
+			root = source
+		}
+	default:
+		kind = "git"
+		revision, err = cloneTemplatePack(source, ref, fetched)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if root == "" {
+		if root, err = findTemplatePackRoot(fetched); err != nil {
+			return "", "", fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	spec, names, err := readTemplatePack(root)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", source, err)
+	}
+	digest, err := templatePackDigest(root, spec)
+	if err != nil {
+		return "", "", err
+	}
+	fmt.Printf("Pack %s %s: %d templates, %s\n", spec.Name, spec.Version, len(names), digest)
+	if checksum != "" && checksum != digest {
+		return "", "", &ValidationFailure{fmt.Errorf("pack %s %s has digest %s, not the expected %s; refusing to cache it", spec.Name, spec.Version, digest, checksum)}
+	}
+
+	pack := index[spec.Name]
+	if pack == nil {
+		pack = &cachedTemplatePack{Versions: map[string]*cachedPackVersion{}}
+		index[spec.Name] = pack
+	}
+	if cached := pack.Versions[spec.Version]; cached != nil && cached.Digest != digest && !force {
+		return "", "", &UserError{fmt.Errorf("version %s of pack %s is cached already with other files (%s); publish a new version, or use --force to replace it", spec.Version, spec.Name, cached.Digest)}
+	}
+
+	target := filepath.Join(dir, spec.Name, spec.Version)
+	if err := os.RemoveAll(target); err != nil {
+		return "", "", fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	if err := copyTemplatePack(root, spec, target); err != nil {
+		return "", "", err
+	}
+
+	pack.Source, pack.Kind, pack.Ref, pack.Current = source, kind, ref, spec.Version
+	pack.Versions[spec.Version] = &cachedPackVersion{Revision: revision, Digest: digest, Templates: names, Fetched: time.Now().UTC()}
+	if err := saveTemplatePackIndex(dir, index); err != nil {
+		return "", "", err
+	}
+	fmt.Printf("✓ Cached template pack %s %s (%s)\n", spec.Name, spec.Version, target)
+	return spec.Name, spec.Version, nil
+}
+
+// cloneTemplatePack clones the git repository of a pack into dir, at ref when given, and
+// returns the commit cloned
+func cloneTemplatePack(source, ref, dir string) (string, error) {
+	url := source
+	if !strings.Contains(url, "://") && !strings.HasPrefix(url, "git@") {
+		url = "https://" + url
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("fetching a pack from git needs git: %w", err)
+	}
+
+	clone := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		clone = append(clone, "--branch", ref)
+	}
+	fmt.Printf("Cloning %s...\n", url)
+	run := exec.Command("git", append(clone, url, dir)...)
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Run(); err != nil {
+		return "", &EnvironmentError{fmt.Errorf("git clone %s failed: %w", url, err)}
+	}
+	commit, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// pullOCITemplatePack pulls the OCI artifact of a pack into dir with oras, and returns the
+// digest of its manifest
+func pullOCITemplatePack(reference, dir string) (string, error) {
+	if _, err := exec.LookPath("oras"); err != nil {
+		return "", fmt.Errorf("fetching a pack from an OCI registry needs oras (https://oras.land): %w", err)
+	}
+	resolved, err := exec.Command("oras", "resolve", reference).Output()
+	if err != nil {
+		return "", &EnvironmentError{fmt.Errorf("failed to resolve %s: %w", reference, err)}
+	}
+	digest := strings.TrimSpace(string(resolved))
+
+	// Pulling by digest makes the artifact verified the one resolved
+	repository := reference
+	if at := strings.Index(reference, "@"); at >= 0 {
+		repository = reference[:at]
+	} else if colon := strings.LastIndex(reference, ":"); colon > strings.LastIndex(reference, "/") {
+		repository = reference[:colon]
+	}
+	fmt.Printf("Pulling %s@%s...\n", repository, digest)
+	run := exec.Command("oras", "pull", repository+"@"+digest, "--output", dir)
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Run(); err != nil {
+		return "", &EnvironmentError{fmt.Errorf("oras pull %s failed: %w", reference, err)}
+	}
+	return digest, nil
+}
+
+// findTemplatePackRoot returns the directory of the pack.yaml of a fetched pack: dir, or its
+// only subdirectory, as an OCI artifact of a pushed directory unpacks into
+func findTemplatePackRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, templatePackFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var found []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			if _, err := os.Stat(filepath.Join(dir, entry.Name(), templatePackFile)); err == nil {
+				found = append(found, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	if len(found) != 1 {
+		return "", &UserError{fmt.Errorf("not a template pack: no %s", templatePackFile)}
+	}
+	return found[0], nil
+}
+
+// readTemplatePack reads the pack.yaml of the pack in root and checks its templates: each is
+// named after a built-in template and parses. It returns the names of the templates.
+func readTemplatePack(root string) (*templatePackSpec, []string, error) {
+	content, err := os.ReadFile(filepath.Join(root, templatePackFile))
+	if err != nil {
+		return nil, nil, &UserError{fmt.Errorf("not a template pack: %w", err)}
+	}
+	spec := &templatePackSpec{}
+	if err := yaml.Unmarshal(content, spec); err != nil {
+		return nil, nil, &UserError{fmt.Errorf("invalid %s: %w", templatePackFile, err)}
+	}
+	if !templatePackNamePattern.MatchString(spec.Name) || !templatePackNamePattern.MatchString(spec.Version) {
+		return nil, nil, &UserError{fmt.Errorf("%s needs a name and a version of letters, digits, dots, dashes and underscores", templatePackFile)}
+	}
+	if spec.Templates == "" {
+		spec.Templates = templatePackTemplatesDir
+	}
+	if !filepath.IsLocal(spec.Templates) {
+		return nil, nil, &UserError{fmt.Errorf("invalid templates directory %q in %s", spec.Templates, templatePackFile)}
+	}
+
+	files, err := templatePackFiles(filepath.Join(root, spec.Templates))
+	if err != nil {
+		return nil, nil, err
+	}
+	builtin := templates.NewRegistry()
+	renderer := spec.renderer()
+	var names []string
+	var problems []string
+	for name, path := range files {
+		if _, ok := builtin.Lookup(name); !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a template name (microframework list templates)", name))
+			continue
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := renderer.Parse(name, string(text)); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, nil, &ValidationFailure{fmt.Errorf("invalid templates in pack %s %s:\n  %s", spec.Name, spec.Version, strings.Join(problems, "\n  "))}
+	}
+	if len(names) == 0 {
+		return nil, nil, &UserError{fmt.Errorf("pack %s %s has no templates in %s", spec.Name, spec.Version, spec.Templates)}
+	}
+	sort.Strings(names)
+	return spec, names, nil
+}
+
+// templatePackFiles returns the template files under dir by template name: their path
+// relative to dir, without the .tmpl suffix
+func templatePackFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Hidden files are not templates, unless they are those of hidden files, as
+		// .env.example.tmpl
+		if entry.IsDir() || (strings.HasPrefix(entry.Name(), ".") && !strings.HasSuffix(entry.Name(), ".tmpl")) {
+			return nil
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(filepath.ToSlash(relative), ".tmpl")] = path
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the templates: %w", err)
+	}
+	return files, nil
+}
+
+// templatePackDigest returns the digest of the files of the pack in root: its pack.yaml and
+// its templates, by path and content, as sha256:<hex>
+func templatePackDigest(root string, spec *templatePackSpec) (string, error) {
+	files, err := templatePackFiles(filepath.Join(root, spec.Templates))
+	if err != nil {
+		return "", err
+	}
+	paths := []string{filepath.Join(root, templatePackFile)}
+	for _, path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		relative, _ := filepath.Rel(root, path)
+		fmt.Fprintf(hash, "%s %s\n", generator.Checksum(content), filepath.ToSlash(relative))
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyTemplatePack copies the pack.yaml and the templates of the pack in root to target
+func copyTemplatePack(root string, spec *templatePackSpec, target string) error {
+	files, err := templatePackFiles(filepath.Join(root, spec.Templates))
+	if err != nil {
+		return err
+	}
+	paths := []string{filepath.Join(root, templatePackFile)}
+	for _, path := range files {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, _ := filepath.Rel(root, path)
+		destination := filepath.Join(target, relative)
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return fmt.Errorf("failed to cache the pack: %w", err)
+		}
+		if err := os.WriteFile(destination, content, 0644); err != nil {
+			return fmt.Errorf("failed to cache the pack: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadTemplatePack returns the templates of a cached pack, name or name@version, over the
+// built-in ones, and its pack.yaml, after checking the pack against its digest. It returns
+// the pack as name@version, as recorded in the generation manifest.
+func loadTemplatePack(reference string) (*templates.Registry, *templatePackSpec, string, error) {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	name, version, _ := strings.Cut(reference, "@")
+	pack := index[name]
+	if pack == nil {
+		return nil, nil, "", &UserError{fmt.Errorf("no template pack %s; add it with microframework templates add", name)}
+	}
+	if version == "" {
+		version = pack.Current
+	}
+	cached := pack.Versions[version]
+	if cached == nil {
+		return nil, nil, "", &UserError{fmt.Errorf("version %s of template pack %s is not cached; add it with microframework templates add %s", version, name, pack.Source)}
+	}
+
+	root := filepath.Join(dir, name, version)
+	spec, _, err := readTemplatePack(root)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	digest, err := templatePackDigest(root, spec)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if digest != cached.Digest {
+		return nil, nil, "", &ValidationFailure{fmt.Errorf("template pack %s@%s was modified in the cache (%s, expected %s); add it again", name, version, digest, cached.Digest)}
+	}
+
+	files, err := templatePackFiles(filepath.Join(root, spec.Templates))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	registry := templates.NewRegistry()
+	for templateName, path := range files {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		registry.Register(templateName, string(text))
+	}
+	return registry, spec, name + "@" + version, nil
+}
+
+// templatePackOptions returns the generator options rendering the templates of a pack, and
+// the pack as name@version; none for an empty reference
+func templatePackOptions(reference string) ([]generator.Option, string, error) {
+	if reference == "" {
+		return nil, "", nil
+	}
+	registry, spec, resolved, err := loadTemplatePack(reference)
+	if err != nil {
+		return nil, "", err
+	}
+	fmt.Printf("Templates: pack %s\n", resolved)
+	return []generator.Option{generator.WithTemplates(registry), generator.WithRenderer(spec.renderer())}, resolved, nil
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	if templatesOutput != "text" && templatesOutput != "json" {
+		return &UserError{fmt.Errorf("invalid output format %q (text, json)", templatesOutput)}
+	}
+	dir, err := templatePacksDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	packs := []listedTemplatePack{}
+	for _, name := range sortedKeys(index) {
+		pack := index[name]
+		packs = append(packs, listedTemplatePack{Name: name, Current: pack.Current, Versions: sortedKeys(pack.Versions), Source: pack.Source, Kind: pack.Kind})
+	}
+	if templatesOutput == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(packs)
+	}
+	if len(packs) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No template packs cached; add one with microframework templates add")
+		return nil
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "PACK\tCURRENT\tVERSIONS\tSOURCE\n")
+	for _, pack := range packs {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", pack.Name, pack.Current, strings.Join(pack.Versions, ", "), pack.Source)
+	}
+	return writer.Flush()
+}
+
+func runTemplatesUpdate(cmd *cobra.Command, args []string) error {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		names = sortedKeys(index)
+	}
+
+	updated := 0
+	for _, name := range names {
+		pack := index[name]
+		if pack == nil {
+			return &UserError{fmt.Errorf("no template pack %s", name)}
+		}
+		if pack.Kind == "oci" && strings.Contains(pack.Source, "@") {
+			fmt.Printf("%s is pinned by digest (%s); add another version to update it\n", name, pack.Source)
+			continue
+		}
+		fmt.Printf("Updating %s from %s...\n", name, pack.Source)
+		_, version, err := addTemplatePack(pack.Source, pack.Ref, "", false)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", name, err)
+		}
+		if version != pack.Current {
+			updated++
+		}
+	}
+	if updated == 0 {
+		fmt.Println("✓ Template packs are up to date")
+	}
+	return nil
+}
+
+func runTemplatesRemove(cmd *cobra.Command, args []string) error {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return err
+	}
+	name, version, _ := strings.Cut(args[0], "@")
+	pack := index[name]
+	if pack == nil || (version != "" && pack.Versions[version] == nil) {
+		return &UserError{fmt.Errorf("no template pack %s", args[0])}
+	}
+
+	if version == "" || len(pack.Versions) == 1 {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		delete(index, name)
+	} else {
+		if err := os.RemoveAll(filepath.Join(dir, name, version)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", args[0], err)
+		}
+		delete(pack.Versions, version)
+		if pack.Current == version {
+			// The last fetched of the remaining versions becomes the current one
+			pack.Current = ""
+			for remaining, cached := range pack.Versions {
+				if pack.Current == "" || cached.Fetched.After(pack.Versions[pack.Current].Fetched) {
+					pack.Current = remaining
+				}
+			}
+		}
+	}
+	if err := saveTemplatePackIndex(dir, index); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Removed template pack %s\n", args[0])
+	return nil
+}
+
+func runTemplatesVerify(cmd *cobra.Command, args []string) error {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return err
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return err
+	}
+	references := args
+	if len(references) == 0 {
+		for _, name := range sortedKeys(index) {
+			for _, version := range sortedKeys(index[name].Versions) {
+				references = append(references, name+"@"+version)
+			}
+		}
+	}
+
+	failed := 0
+	for _, reference := range references {
+		if _, _, resolved, err := loadTemplatePack(reference); err != nil {
+			fmt.Printf("✗ %s: %v\n", reference, err)
+			failed++
+		} else {
+			fmt.Printf("✓ %s\n", resolved)
+		}
+	}
+	if failed > 0 {
+		return &ValidationFailure{fmt.Errorf("%d template packs failed verification", failed)}
+	}
+	return nil
+}
+
+// loadTemplatePackIndex reads the records of the cached packs
+func loadTemplatePackIndex(dir string) (map[string]*cachedTemplatePack, error) {
+	index := make(map[string]*cachedTemplatePack)
+	content, err := os.ReadFile(filepath.Join(dir, templatePackIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", templatePackIndexFile, err)
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, templatePackIndexFile), err)
+	}
+	return index, nil
+}
+
+// saveTemplatePackIndex writes the records of the cached packs
+func saveTemplatePackIndex(dir string, index map[string]*cachedTemplatePack) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, templatePackIndexFile), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", templatePackIndexFile, err)
+	}
+	return nil
+}
+
+// completeTemplatePacks completes the names of the cached template packs
+func completeTemplatePacks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := templatePacksDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	index, err := loadTemplatePackIndex(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return sortedKeys(index), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,298 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	templatesExportName        string
+	templatesExportVersion     string
+	templatesExportDescription string
+	templatesExportForce       bool
+)
+
+// templatesExportCmd represents the templates export command
+var templatesExportCmd = &cobra.Command{
+	Use:   "export <dir>",
+	Short: "Export the current project as a template pack",
+	Long: `Export the project in the current directory, a golden service, as a template pack in
+dir, so that the services generated with --template-pack start as copies of it.
+
+The files of the project generated from the service configuration (main.go, go.mod, the
+configurations, deployments, base handlers, services and repositories, tests and docs) become
+the templates of the pack, as they are now, with:
+
+  the service name     {{.ServiceName}}, and {{.ServiceName | upper}} in upper case
+  the module path      its last element {{.ServiceName}}, as in github.com/acme/{{.ServiceName}}
+  the ports            {{port "<name>"}}, the ports of configs/config.yaml being listed in
+                       the ports of pack.yaml, where they are changed for every service
+
+The files generated from entities, tables, specs and imported projects, and those written by
+hand, are not exported: they belong to the service, not to the scaffold. The templates of the
+pack the project does not have are the built-in ones, and the options of new still decide
+which of the templates a service is generated with.
+
+Examples:
+  microframework templates export ../acme-templates --name acme --version 1.0.0
+  microframework templates add ../acme-templates
+  microframework new payments --template-pack acme`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplatesExport,
+}
+
+func init() {
+	templatesExportCmd.Flags().StringVar(&templatesExportName, "name", "", "Name of the pack (default the service name)")
+	templatesExportCmd.Flags().StringVar(&templatesExportVersion, "version", "1.0.0", "Version of the pack")
+	templatesExportCmd.Flags().StringVar(&templatesExportDescription, "description", "", "Description of the pack")
+	templatesExportCmd.Flags().BoolVar(&templatesExportForce, "force", false, "Replace the pack in a directory that is not empty")
+
+	templatesCmd.AddCommand(templatesExportCmd)
+}
+
+// exportedPort is a port of the configuration of an exported project
+type exportedPort struct {
+	// keys are the keys of the port in configs/config.yaml, up to its own
+	keys []string
+	port int
+}
+
+func runTemplatesExport(cmd *cobra.Command, args []string) error {
+	manifest, err := loadImportManifest()
+	if err != nil {
+		return err
+	}
+	serviceName := manifest.Config.ServiceName
+	spec := &templatePackSpec{
+		Name:        templatesExportName,
+		Version:     templatesExportVersion,
+		Description: templatesExportDescription,
+		Templates:   templatePackTemplatesDir,
+	}
+	if spec.Name == "" {
+		spec.Name = serviceName
+	}
+	if spec.Description == "" {
+		spec.Description = "Scaffold exported from " + serviceName
+	}
+	if !templatePackNamePattern.MatchString(spec.Name) || !templatePackNamePattern.MatchString(spec.Version) {
+		return &UserError{fmt.Errorf("--name and --version must be letters, digits, dots, dashes and underscores")}
+	}
+
+	dir := args[0]
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		if !templatesExportForce {
+			return &UserError{fmt.Errorf("%s is not empty; use --force to replace the pack in it", dir)}
+		}
+		if err := os.RemoveAll(filepath.Join(dir, spec.Templates)); err != nil {
+			return fmt.Errorf("failed to replace the templates of %s: %w", dir, err)
+		}
+	}
+
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return err
+	}
+	modulePath := modfile.ModulePath(content)
+	spec.Ports, err = exportedPorts()
+	if err != nil {
+		return err
+	}
+	templatize := exportTemplatizer(serviceName, modulePath, spec.Ports)
+
+	var exported []string
+	files := generator.ProjectTemplates(&manifest.Config)
+	for _, file := range sortedKeys(files) {
+		content, err := os.ReadFile(filepath.FromSlash(file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, spec.Templates, filepath.FromSlash(files[file])+".tmpl")
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, []byte(templatize(string(content))), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		exported = append(exported, files[file])
+	}
+	if len(exported) == 0 {
+		return &UserError{fmt.Errorf("the project has none of the files generated from the service configuration")}
+	}
+
+	var document yaml.Node
+	if err := document.Encode(spec); err != nil {
+		return err
+	}
+	packYAML, err := encodeConfigDocument(&document)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, templatePackFile), packYAML, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", templatePackFile, err)
+	}
+	// The pack is checked as templates add checks it, so that what export writes is added
+	if _, _, err := readTemplatePack(dir); err != nil {
+		return err
+	}
+	digest, err := templatePackDigest(dir, spec)
+	if err != nil {
+		return err
+	}
+
+	var skipped []string
+	for _, file := range manifest.Paths() {
+		if _, ok := files[file]; !ok {
+			skipped = append(skipped, file)
+		}
+	}
+	reportFiles(dir)
+	reportResult(map[string]interface{}{"pack": spec.Name, "version": spec.Version, "digest": digest, "templates": exported, "ports": spec.Ports})
+
+	fmt.Printf("✓ Exported %s as template pack %s %s in %s: %d templates, %s\n", serviceName, spec.Name, spec.Version, dir, len(exported), digest)
+	for _, name := range sortedKeys(spec.Ports) {
+		fmt.Printf("  port %s: %d\n", name, spec.Ports[name])
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Not exported, generated from entities, tables, specs or imports: %s\n", strings.Join(skipped, ", "))
+	}
+	fmt.Println("\nNext steps:")
+	fmt.Printf("1. Review the templates in %s, and the ports of its %s\n", filepath.Join(dir, spec.Templates), templatePackFile)
+	fmt.Printf("2. Publish the pack, or add it: microframework templates add %s\n", dir)
+	fmt.Printf("3. Generate services from it: microframework new <service> --template-pack %s\n", spec.Name)
+	return nil
+}
+
+// exportedPorts returns the ports of configs/config.yaml by name: the port of the service is
+// http, those of its communication providers are named after them, and the others after the
+// key they are under. The ports under 1024 are left as they are.
+func exportedPorts() (map[string]int, error) {
+	content, err := os.ReadFile(filepath.Join("configs", "config.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, &UserError{fmt.Errorf("invalid configs/config.yaml: %w", err)}
+	}
+	var found []exportedPort
+	var walk func(node *yaml.Node, keys []string)
+	walk = func(node *yaml.Node, keys []string) {
+		if node.Kind == yaml.DocumentNode {
+			for _, child := range node.Content {
+				walk(child, keys)
+			}
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			path := append(append([]string{}, keys...), key)
+			if key == "port" && value.Kind == yaml.ScalarNode {
+				if port, err := strconv.Atoi(value.Value); err == nil && port >= 1024 && port <= 65535 {
+					found = append(found, exportedPort{keys: path, port: port})
+				}
+				continue
+			}
+			walk(value, path)
+		}
+	}
+	walk(&document, nil)
+
+	// The port of the service and those of its communication providers are named first, as
+	// the other sections use the same ports
+	rank := func(keys []string) int {
+		switch {
+		case len(keys) == 2 && keys[0] == "service":
+			return 0
+		case keys[0] == "communication":
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(found, func(i, j int) bool { return rank(found[i].keys) < rank(found[j].keys) })
+
+	ports := make(map[string]int)
+	named := make(map[int]bool)
+	for _, port := range found {
+		if named[port.port] {
+			continue
+		}
+		name := "http"
+		if rank(port.keys) != 0 {
+			name = port.keys[len(port.keys)-2]
+		}
+		for n, base := 2, name; ports[name] != 0; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		ports[name] = port.port
+		named[port.port] = true
+	}
+	return ports, nil
+}
+
+// exportTemplatizer returns the function making a file of a project the template of the pack:
+// its module path, service name and ports become template actions, and the {{ it has are
+// escaped
+func exportTemplatizer(serviceName, modulePath string, ports map[string]int) func(string) string {
+	replacements := map[string]string{
+		"{{":        `{{"{{"}}`,
+		serviceName: "{{.ServiceName}}",
+	}
+	literals := []string{serviceName}
+	if upper := strings.ToUpper(serviceName); upper != serviceName {
+		replacements[upper] = "{{.ServiceName | upper}}"
+		literals = append(literals, upper)
+	}
+	if modulePath != "" && modulePath != serviceName {
+		// The module path ends with the service name in the services generated from the pack
+		module := strings.ReplaceAll(modulePath, serviceName, "{{.ServiceName}}")
+		if module == modulePath {
+			module = strings.TrimPrefix(path.Dir(modulePath)+"/{{.ServiceName}}", "./")
+		}
+		replacements[modulePath] = module
+		literals = append(literals, modulePath)
+	}
+	// The longest literals come first, the alternatives of a regexp matching in order
+	sort.SliceStable(literals, func(i, j int) bool { return len(literals[i]) > len(literals[j]) })
+
+	alternatives := make([]string, 0, len(literals)+2)
+	for _, literal := range literals {
+		alternatives = append(alternatives, regexp.QuoteMeta(literal))
+	}
+	alternatives = append(alternatives, `\{\{`)
+	if len(ports) > 0 {
+		var numbers []string
+		for _, name := range sortedKeys(ports) {
+			number := strconv.Itoa(ports[name])
+			replacements[number] = fmt.Sprintf(`{{port %q}}`, name)
+			numbers = append(numbers, number)
+		}
+		alternatives = append(alternatives, `\b(?:`+strings.Join(numbers, "|")+`)\b`)
+	}
+	pattern := regexp.MustCompile(strings.Join(alternatives, "|"))
+	return func(content string) string {
+		return pattern.ReplaceAllStringFunc(content, func(match string) string {
+			return replacements[match]
+		})
+	}
+}
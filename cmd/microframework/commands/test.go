@@ -0,0 +1,650 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	testUnit          bool
+	testIntegration   bool
+	testE2E           bool
+	testAll           bool
+	testDeps          bool
+	testComposeFile   string
+	testEnvFile       string
+	testTags          string
+	testRun           string
+	testRace          bool
+	testTimeout       time.Duration
+	testCoverProfile  string
+	testCoverageFloor float64
+	testJUnit         string
+	// testVerbose is the root --verbose flag
+	testVerbose bool
+)
+
+// testSuite is a set of packages run together
+type testSuite struct {
+	name string
+	// dir holds the packages of the suite, relative to the module; "" is every package
+	// that is in no other suite's dir
+	dir string
+	// tag is the build tag the suite's files may be guarded by
+	tag string
+}
+
+// testSuites are the suites of a generated service, in the order they run
+var testSuites = []testSuite{
+	{name: "unit"},
+	{name: "integration", dir: "tests/integration", tag: "integration"},
+	{name: "e2e", dir: "tests/e2e", tag: "e2e"},
+}
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [flags]",
+	Short: "Run the unit, integration and e2e tests of the service",
+	Long: `Run the test suites of the microservice and report their coverage.
+
+The unit suite is every package outside tests/integration and tests/e2e. The integration
+and e2e suites are the packages of those directories, built with the integration or e2e
+build tag and given the variables of .env. With --deps the docker-compose dependencies
+are started before them; suites using testcontainers start their own.
+
+Coverage is collected across every package of the module, so the code an integration test
+exercises counts too, and the profiles of the suites are merged into --coverprofile. The
+run fails when a test fails or the total coverage is below --coverage-threshold.
+--junit writes a JUnit XML report for CI.
+
+Without a suite flag only the unit suite runs. With --verbose the output of every test is
+printed, not only of the failed ones.
+
+Examples:
+  microframework test
+  microframework test --integration --deps
+  microframework test --all --coverage-threshold 70 --junit report.xml
+  microframework test --unit --race --run TestUser
+
+Exit codes:
+  0  every test passed and the coverage reached the threshold
+  1  go test could not run
+  2  invalid flags, or not run from a microservice directory
+  6  a test failed, a package did not build or the coverage is below the threshold`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTest(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	testCmd.Flags().BoolVar(&testUnit, "unit", false, "Run the unit suite")
+	testCmd.Flags().BoolVar(&testIntegration, "integration", false, "Run the integration suite")
+	testCmd.Flags().BoolVar(&testE2E, "e2e", false, "Run the e2e suite")
+	testCmd.Flags().BoolVar(&testAll, "all", false, "Run every suite")
+	testCmd.Flags().BoolVar(&testDeps, "deps", false, "Start the docker-compose dependencies before the integration and e2e suites")
+	testCmd.Flags().StringVar(&testComposeFile, "compose-file", "deployments/docker/docker-compose.yml", "docker-compose file of the dependencies")
+	testCmd.Flags().StringVar(&testEnvFile, "env-file", ".env", "Environment file loaded for the integration and e2e suites")
+	testCmd.Flags().StringVar(&testTags, "tags", "", "Additional build tags, comma separated")
+	testCmd.Flags().StringVar(&testRun, "run", "", "Run only the tests matching the regular expression")
+	testCmd.Flags().BoolVar(&testRace, "race", false, "Enable the race detector")
+	testCmd.Flags().DurationVar(&testTimeout, "timeout", 10*time.Minute, "Timeout of each suite")
+	testCmd.Flags().StringVar(&testCoverProfile, "coverprofile", "coverage.out", "File the merged coverage profile is written to (empty to skip)")
+	testCmd.Flags().Float64Var(&testCoverageFloor, "coverage-threshold", 0, "Minimum total coverage, in percent")
+	testCmd.Flags().StringVar(&testJUnit, "junit", "", "File a JUnit XML report is written to")
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	testVerbose, _ = cmd.Flags().GetBool("verbose")
+	if err := checkMicroserviceDirectory(); err != nil {
+		return &UserError{err}
+	}
+	if testCoverageFloor < 0 || testCoverageFloor > 100 {
+		return &UserError{fmt.Errorf("--coverage-threshold must be between 0 and 100")}
+	}
+	selected := map[string]bool{
+		"unit":        testUnit || testAll,
+		"integration": testIntegration || testAll,
+		"e2e":         testE2E || testAll,
+	}
+	if !testUnit && !testIntegration && !testE2E && !testAll {
+		selected["unit"] = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	module := currentModulePath()
+	tmpDir, err := os.MkdirTemp("", "microframework-test-")
+	if err != nil {
+		return fmt.Errorf("failed to create the coverage directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	report := &testReport{}
+	coverage := newCoverageProfile()
+	depsStarted := false
+	for _, suite := range testSuites {
+		if !selected[suite.name] {
+			continue
+		}
+		packages, err := suitePackages(ctx, module, suite)
+		if err != nil {
+			return err
+		}
+		if len(packages) == 0 {
+			fmt.Printf("=== %s: no packages", suite.name)
+			if suite.dir != "" {
+				fmt.Printf(" in %s", suite.dir)
+			}
+			fmt.Println(", skipped")
+			continue
+		}
+
+		var env []string
+		if suite.dir != "" {
+			if testDeps && !depsStarted {
+				if err := startComposeDependencies(ctx, testComposeFile, path.Base(module)); err != nil {
+					return err
+				}
+				depsStarted = true
+			}
+			if env, err = loadEnvFile(testEnvFile); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("=== %s (%d packages)\n", suite.name, len(packages))
+		profile := filepath.Join(tmpDir, suite.name+".out")
+		started := time.Now()
+		if err := runTestSuite(ctx, module, suite, packages, env, profile, report); err != nil {
+			return err
+		}
+		if err := coverage.merge(profile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to read the coverage of %s: %w", suite.name, err)
+		}
+		report.suites = append(report.suites, suite.name)
+		fmt.Printf("=== %s finished in %s\n", suite.name, time.Since(started).Round(time.Millisecond))
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted")
+	}
+
+	if testJUnit != "" {
+		if err := report.writeJUnit(testJUnit); err != nil {
+			return fmt.Errorf("failed to write %s: %w", testJUnit, err)
+		}
+	}
+	if testCoverProfile != "" && coverage.blocks() > 0 {
+		if err := coverage.write(testCoverProfile); err != nil {
+			return fmt.Errorf("failed to write %s: %w", testCoverProfile, err)
+		}
+	}
+
+	passed, failed, skipped := report.counts()
+	fmt.Printf("\nTests: %d passed, %d failed, %d skipped (%s)\n", passed, failed, skipped, strings.Join(report.suites, ", "))
+	total := coverage.percent()
+	if coverage.blocks() > 0 {
+		fmt.Printf("Coverage: %.1f%% of statements", total)
+		if testCoverageFloor > 0 {
+			fmt.Printf(" (threshold %.1f%%)", testCoverageFloor)
+		}
+		fmt.Println()
+	}
+
+	var problems []string
+	if failedPackages := report.failedPackages(); len(failedPackages) > 0 {
+		problems = append(problems, fmt.Sprintf("failed packages: %s", strings.Join(failedPackages, ", ")))
+	}
+	if testCoverageFloor > 0 && total < testCoverageFloor {
+		problems = append(problems, fmt.Sprintf("coverage %.1f%% is below the threshold of %.1f%%", total, testCoverageFloor))
+	}
+	if len(problems) > 0 {
+		return &ValidationFailure{errors.New(strings.Join(problems, "; "))}
+	}
+	return nil
+}
+
+// suitePackages returns the import paths of the packages of a suite
+func suitePackages(ctx context.Context, module string, suite testSuite) ([]string, error) {
+	args := []string{"list", "-e"}
+	if tags := suiteTags(suite); tags != "" {
+		args = append(args, "-tags", tags)
+	}
+	pattern := "./..."
+	if suite.dir != "" {
+		if _, err := os.Stat(suite.dir); err != nil {
+			return nil, nil
+		}
+		pattern = "./" + suite.dir + "/..."
+	}
+	output, err := exec.CommandContext(ctx, "go", append(args, pattern)...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to list the packages of %s: %s", suite.name, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to list the packages of %s: %w", suite.name, err)
+	}
+
+	var packages []string
+	for _, pkg := range strings.Fields(string(output)) {
+		if suite.dir == "" && inOtherSuite(module, pkg) {
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// inOtherSuite reports whether a package belongs to a suite with a directory of its own
+func inOtherSuite(module, pkg string) bool {
+	for _, suite := range testSuites {
+		if suite.dir == "" {
+			continue
+		}
+		dir := module + "/" + suite.dir
+		if pkg == dir || strings.HasPrefix(pkg, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func suiteTags(suite testSuite) string {
+	var tags []string
+	if suite.tag != "" {
+		tags = append(tags, suite.tag)
+	}
+	if testTags != "" {
+		tags = append(tags, testTags)
+	}
+	return strings.Join(tags, ",")
+}
+
+// runTestSuite runs go test on the packages of a suite, printing its progress and adding its
+// results to report. A failing test is not an error: it is in the report.
+func runTestSuite(ctx context.Context, module string, suite testSuite, packages, env []string, profile string, report *testReport) error {
+	args := []string{"test", "-json", "-covermode=atomic", "-coverpkg=" + module + "/...", "-coverprofile=" + profile, "-timeout", testTimeout.String()}
+	if tags := suiteTags(suite); tags != "" {
+		args = append(args, "-tags", tags)
+	}
+	if testRace {
+		args = append(args, "-race")
+	}
+	if testRun != "" {
+		args = append(args, "-run", testRun)
+	}
+	if suite.dir != "" {
+		// Integration and e2e results depend on the dependencies, not only on the code
+		args = append(args, "-count=1")
+	}
+
+	test := exec.CommandContext(ctx, "go", append(args, packages...)...)
+	test.Env = append(append(env, "MICROFRAMEWORK_TEST_SUITE="+suite.name), os.Environ()...)
+	test.Stderr = os.Stderr
+	stdout, err := test.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := test.Start(); err != nil {
+		return fmt.Errorf("failed to run go test: %w", err)
+	}
+	report.read(stdout, suite.name)
+	// go test exits 1 when a test fails, which the report already holds
+	if err := test.Wait(); err != nil && ctx.Err() == nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return fmt.Errorf("go test failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// testEvent is an event of go test -json
+type testEvent struct {
+	Action     string
+	Package    string
+	Test       string
+	Elapsed    float64
+	Output     string
+	ImportPath string
+}
+
+// testCase is the result of a test
+type testCase struct {
+	name    string
+	status  string
+	elapsed float64
+	output  strings.Builder
+}
+
+// testPackage is the result of a package
+type testPackage struct {
+	name    string
+	suite   string
+	status  string
+	elapsed float64
+	output  strings.Builder
+	tests   []*testCase
+	byName  map[string]*testCase
+}
+
+// testReport holds the results of the suites
+type testReport struct {
+	suites   []string
+	packages []*testPackage
+	byName   map[string]*testPackage
+	// builds holds the build output of packages that failed to build, by import path
+	builds map[string]*strings.Builder
+}
+
+// read adds the events of go test -json to the report, printing the summary of each package
+// and the output of the failed tests
+func (r *testReport) read(events io.Reader, suite string) {
+	if r.byName == nil {
+		r.byName = make(map[string]*testPackage)
+		r.builds = make(map[string]*strings.Builder)
+	}
+	scanner := bufio.NewScanner(events)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			fmt.Println(scanner.Text())
+			continue
+		}
+		if event.Action == "build-output" || event.Action == "build-fail" {
+			if r.builds[event.ImportPath] == nil {
+				r.builds[event.ImportPath] = &strings.Builder{}
+			}
+			r.builds[event.ImportPath].WriteString(event.Output)
+			fmt.Print(event.Output)
+			continue
+		}
+		if event.Package == "" {
+			continue
+		}
+
+		key := suite + " " + event.Package
+		pkg := r.byName[key]
+		if pkg == nil {
+			pkg = &testPackage{name: event.Package, suite: suite, byName: make(map[string]*testCase)}
+			r.byName[key] = pkg
+			r.packages = append(r.packages, pkg)
+		}
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				pkg.output.WriteString(event.Output)
+				if testVerbose || (event.Output != "PASS\n" && event.Output != "FAIL\n" && !strings.HasPrefix(event.Output, "coverage:")) {
+					fmt.Print(event.Output)
+				}
+			case "pass", "fail", "skip":
+				pkg.status, pkg.elapsed = event.Action, event.Elapsed
+			}
+			continue
+		}
+
+		test := pkg.byName[event.Test]
+		if test == nil {
+			test = &testCase{name: event.Test}
+			pkg.byName[event.Test] = test
+			pkg.tests = append(pkg.tests, test)
+		}
+		switch event.Action {
+		case "output":
+			test.output.WriteString(event.Output)
+			if testVerbose {
+				fmt.Print(event.Output)
+			}
+		case "pass", "fail", "skip":
+			test.status, test.elapsed = event.Action, event.Elapsed
+			if event.Action == "fail" && !testVerbose {
+				fmt.Print(test.output.String())
+			}
+		}
+	}
+}
+
+// counts returns the number of passed, failed and skipped tests
+func (r *testReport) counts() (passed, failed, skipped int) {
+	for _, pkg := range r.packages {
+		for _, test := range pkg.tests {
+			switch test.status {
+			case "pass":
+				passed++
+			case "fail":
+				failed++
+			case "skip":
+				skipped++
+			}
+		}
+	}
+	return passed, failed, skipped
+}
+
+// failedPackages returns the packages with a failed test, or that failed to build or finish
+func (r *testReport) failedPackages() []string {
+	var failed []string
+	for _, pkg := range r.packages {
+		if pkg.status == "fail" || pkg.status == "" {
+			failed = append(failed, pkg.name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func junitSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// writeJUnit writes the report as JUnit XML, with a test suite per package
+func (r *testReport) writeJUnit(file string) error {
+	suites := junitTestSuites{}
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	var total float64
+	for _, pkg := range r.packages {
+		suite := junitTestSuite{
+			Name:       pkg.name,
+			Time:       junitSeconds(pkg.elapsed),
+			Timestamp:  timestamp,
+			Properties: []junitProperty{{Name: "suite", Value: pkg.suite}},
+		}
+		for _, test := range pkg.tests {
+			testCase := junitTestCase{Name: test.name, Classname: pkg.name, Time: junitSeconds(test.elapsed)}
+			switch test.status {
+			case "fail":
+				testCase.Failure = &junitMessage{Message: "Failed", Body: test.output.String()}
+				suite.Failures++
+			case "skip":
+				testCase.Skipped = &junitMessage{Message: "Skipped", Body: test.output.String()}
+				suite.Skipped++
+			case "":
+				// The package stopped before the test finished: a panic or a timeout
+				testCase.Failure = &junitMessage{Message: "Did not finish", Body: test.output.String()}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		if (pkg.status == "fail" || pkg.status == "") && suite.Failures == 0 {
+			// A package failing without a failed test did not build, or failed in TestMain
+			output := pkg.output.String()
+			if build := r.builds[pkg.name]; build != nil {
+				output = build.String() + output
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "[package failed]",
+				Classname: pkg.name,
+				Time:      junitSeconds(pkg.elapsed),
+				Failure:   &junitMessage{Message: "Package failed", Body: output},
+			})
+			suite.Failures++
+		}
+		suite.Tests = len(suite.Cases)
+		if testVerbose {
+			suite.SystemOut = pkg.output.String()
+		}
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		total += pkg.elapsed
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = junitSeconds(total)
+
+	content, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(file, append([]byte(xml.Header), append(content, '\n')...), 0644)
+}
+
+// coverageBlock is a block of a coverage profile
+type coverageBlock struct {
+	statements int
+	count      int
+}
+
+// coverageProfile is the merged coverage of several runs, by block (file:start,end)
+type coverageProfile struct {
+	byBlock map[string]*coverageBlock
+	order   []string
+}
+
+func newCoverageProfile() *coverageProfile {
+	return &coverageProfile{byBlock: make(map[string]*coverageBlock)}
+}
+
+// merge adds the counts of a profile written by go test -coverprofile
+func (p *coverageProfile) merge(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "mode:") || line == "" {
+			continue
+		}
+		// name.go:line.column,line.column statements count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid coverage line %q", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid coverage line %q", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("invalid coverage line %q", line)
+		}
+		block := p.byBlock[fields[0]]
+		if block == nil {
+			block = &coverageBlock{statements: statements}
+			p.byBlock[fields[0]] = block
+			p.order = append(p.order, fields[0])
+		}
+		block.count += count
+	}
+	return scanner.Err()
+}
+
+func (p *coverageProfile) blocks() int {
+	return len(p.order)
+}
+
+// percent returns the percentage of the statements that ran
+func (p *coverageProfile) percent() float64 {
+	var statements, covered int
+	for _, block := range p.byBlock {
+		statements += block.statements
+		if block.count > 0 {
+			covered += block.statements
+		}
+	}
+	if statements == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(statements)
+}
+
+// write writes the merged profile, which go tool cover reads like any other
+func (p *coverageProfile) write(file string) error {
+	var content strings.Builder
+	content.WriteString("mode: atomic\n")
+	for _, key := range p.order {
+		block := p.byBlock[key]
+		fmt.Fprintf(&content, "%s %d %d\n", key, block.statements, block.count)
+	}
+	return os.WriteFile(file, []byte(content.String()), 0644)
+}
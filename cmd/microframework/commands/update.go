@@ -1,12 +1,21 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/anasamu/go-micro-framework/pkg/progress"
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
 )
 
 var (
@@ -14,6 +23,15 @@ var (
 	updateVersion string
 	updateCheck   bool
 	updateForce   bool
+	updatePin     bool
+	updateLocked  bool
+	updateOnly    []string
+	updateExclude []string
+	updateVerify  []string
+	updateRevert  bool
+
+	// updateProgress reports the steps of the running update with --progress
+	updateProgress *progress.Reporter
 )
 
 // updateCmd represents the update command
@@ -28,46 +46,94 @@ This command allows you to:
 - Update framework CLI tool
 - Check for available updates
 - Update deployment configurations
+- Merge newer scaffold templates into generated files
 
 Examples:
   microframework update
   microframework update --type dependencies
   microframework update --type framework
+  microframework update --type templates
   microframework update --type all
   microframework update --check
-  microframework update --version v1.2.0`,
+  microframework update --version v1.2.0
+  microframework update --type framework --pin
+  microframework update --type templates --locked
+  microframework update --type dependencies --only 'golang.org/x/...' --exclude golang.org/x/tools
+  microframework update --verify build --revert
+  microframework update --check --offline
+
+The versions update looks up are cached in ~/.microframework/cache (or
+$MICROFRAMEWORK_CACHE_DIR) for --cache-ttl. With --offline, only the cached
+versions and the module cache are used.
+
+The versions in .microframework.lock, written by microframework new, are kept
+unless --pin moves them.
+
+Run at the root of a go.work workspace, update applies to every service the
+workspace uses and ends with a report of the result and changes of each.
+
+After updating, the service is built and its tests are run (see --verify). If
+that fails, update offers to restore the files it changed; --revert restores
+them without asking, for CI.`,
 	RunE: runUpdate,
 }
 
 func init() {
-	updateCmd.Flags().StringVarP(&updateType, "type", "t", "all", "Type of update (all, dependencies, framework, cli, config)")
+	updateCmd.Flags().StringVarP(&updateType, "type", "t", "all", "Type of update (all, dependencies, framework, cli, config, templates)")
 	updateCmd.Flags().StringVarP(&updateVersion, "version", "V", "", "Specific version to update to")
 	updateCmd.Flags().BoolVar(&updateCheck, "check", false, "Check for available updates without installing")
 	updateCmd.Flags().BoolVar(&updateForce, "force", false, "Force update even if there are breaking changes")
+	updateCmd.Flags().BoolVar(&updatePin, "pin", false, "Move the versions in "+projectLockFile+" to the versions updated to")
+	updateCmd.Flags().BoolVar(&updateLocked, "locked", false, "Use exactly the versions in "+projectLockFile+" and fail if that is not possible")
+	updateCmd.MarkFlagsMutuallyExclusive("pin", "locked")
+	updateCmd.Flags().StringSliceVar(&updateOnly, "only", nil, "Update only the dependencies matching these module patterns (e.g. golang.org/x/...)")
+	updateCmd.Flags().StringSliceVar(&updateExclude, "exclude", nil, "Skip the dependencies matching these module patterns")
+	updateCmd.Flags().StringSliceVar(&updateVerify, "verify", []string{VerifyBuild, VerifyTest}, "Checks to run after updating (build, test, or none)")
+	updateCmd.Flags().BoolVar(&updateRevert, "revert", false, "Revert the update without asking when verification fails")
+	updateCmd.Flags().BoolVar(&updateOffline, "offline", false, "Look versions up in the version cache only, and download modules from the module cache only")
+	updateCmd.Flags().DurationVar(&updateCacheTTL, "cache-ttl", time.Hour, "How long looked up versions are cached (0 looks them up again)")
+}
+
+// updateResult is the result of update in the JSON report
+type updateResult struct {
+	Type    string `json:"type"`
+	Version string `json:"version,omitempty"`
+	Check   bool   `json:"check"`
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
-	// Check if Go is available
-	if err := checkGoInstallation(); err != nil {
-		return fmt.Errorf("Go installation check failed: %w", err)
+	// Check if Go is available; the CLI updates itself from release binaries without it
+	if updateType != "cli" {
+		if err := checkGoInstallation(); err != nil {
+			return &EnvironmentError{fmt.Errorf("Go installation check failed: %w", err)}
+		}
 	}
 
-	// Check if we're in a microservice directory (except for CLI updates)
+	// Check if we're in a microservice directory (except for CLI updates), or at the root of
+	// a workspace of services
+	workspace := false
 	if updateType != "cli" {
 		if err := checkMicroserviceDirectory(); err != nil {
-			return err
+			if _, statErr := os.Stat("go.work"); statErr != nil {
+				return err
+			}
+			workspace = true
 		}
 	}
 
 	// Validate update type
 	if err := validateUpdateType(updateType); err != nil {
-		return fmt.Errorf("invalid update type: %w", err)
+		return &UserError{fmt.Errorf("invalid update type: %w", err)}
+	}
+
+	if err := validateVerifySteps(updateVerify); err != nil {
+		return &UserError{fmt.Errorf("invalid --verify: %w", err)}
 	}
 
 	// Validate version format if provided
 	if updateVersion != "" {
 		if err := validateVersionFormat(updateVersion); err != nil {
-			return fmt.Errorf("invalid version format: %w", err)
+			return &UserError{fmt.Errorf("invalid version format: %w", err)}
 		}
 	}
 
@@ -85,26 +151,58 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		fmt.Println("FORCE MODE - Updates will be installed even with breaking changes")
 	}
 
-	// Perform update based on type
+	reportResult(updateResult{Type: updateType, Version: updateVersion, Check: updateCheck})
+
+	if updateOffline {
+		// The go commands of the update resolve modules from the module cache only
+		os.Setenv("GOPROXY", "off")
+	}
+	if updateType == "all" {
+		// Look the framework modules up together, ahead of the framework and CLI updates
+		// of every service; the updates report the lookups that fail
+		latestModuleVersions(sortedKeys(frameworkRepositories)...)
+	}
+
+	if workspace {
+		return updateWorkspace(updateType, updateVersion, updateCheck, updateForce)
+	}
+	steps := 1
+	if updateType == "all" {
+		steps = len(serviceUpdates) + 1
+	}
+	updateProgress = progress.NewReporter(progressEvents, "update", steps)
+	return verifiedUpdate(updateCheck, func() error {
+		return runUpdateType(updateType, updateVersion, updateCheck, updateForce)
+	})
+}
+
+// runUpdateType performs an update of the given type in the current directory
+func runUpdateType(updateType, version string, check, force bool) error {
+	var update func(version string, check, force bool) error
 	switch updateType {
 	case "all":
-		return updateAll(updateVersion, updateCheck, updateForce)
+		return updateAll(version, check, force)
 	case "dependencies":
-		return updateDependencies(updateVersion, updateCheck, updateForce)
+		update = updateDependencies
 	case "framework":
-		return updateFramework(updateVersion, updateCheck, updateForce)
+		update = updateFramework
 	case "cli":
-		return updateCLI(updateVersion, updateCheck, updateForce)
+		update = updateCLI
 	case "config":
-		return updateConfig(updateVersion, updateCheck, updateForce)
+		update = updateConfig
+	case "templates":
+		update = updateTemplates
 	default:
-		return fmt.Errorf("unknown update type: %s", updateType)
+		return &UserError{fmt.Errorf("unknown update type: %s", updateType)}
 	}
+	return updateProgress.Step(updateType, func() error {
+		return update(version, check, force)
+	})
 }
 
 // validateUpdateType validates the update type
 func validateUpdateType(updateType string) error {
-	validTypes := []string{"all", "dependencies", "framework", "cli", "config"}
+	validTypes := []string{"all", "dependencies", "framework", "cli", "config", "templates"}
 
 	for _, valid := range validTypes {
 		if updateType == valid {
@@ -157,33 +255,28 @@ func checkGoInstallation() error {
 	return nil
 }
 
+// serviceUpdates are the updates of update --type all that change the service itself, in order
+var serviceUpdates = []struct {
+	Name string
+	Run  func(version string, check, force bool) error
+}{
+	{"dependencies", updateDependencies},
+	{"framework", updateFramework},
+	{"configuration", updateConfig},
+}
+
 // Update functions
 func updateAll(version string, check, force bool) error {
 	fmt.Println("Performing comprehensive update...")
 
-	var errors []error
-
-	// Update dependencies
-	fmt.Println("Updating dependencies...")
-	if err := updateDependencies(version, check, force); err != nil {
-		errors = append(errors, err)
-	}
-
-	// Update framework
-	fmt.Println("Updating framework...")
-	if err := updateFramework(version, check, force); err != nil {
-		errors = append(errors, err)
-	}
+	errors := updateService(version, check, force)
 
 	// Update CLI
 	fmt.Println("Updating CLI tool...")
-	if err := updateCLI(version, check, force); err != nil {
-		errors = append(errors, err)
-	}
-
-	// Update configuration
-	fmt.Println("Updating configuration...")
-	if err := updateConfig(version, check, force); err != nil {
+	err := updateProgress.Step("cli", func() error {
+		return updateCLI(version, check, force)
+	})
+	if err != nil {
 		errors = append(errors, err)
 	}
 
@@ -200,30 +293,42 @@ func updateAll(version string, check, force bool) error {
 	return nil
 }
 
+// updateService runs the service updates of update --type all and returns their errors
+func updateService(version string, check, force bool) []error {
+	var errors []error
+	for _, update := range serviceUpdates {
+		fmt.Printf("Updating %s...\n", update.Name)
+		err := updateProgress.Step(update.Name, func() error {
+			return update.Run(version, check, force)
+		})
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
 func updateDependencies(version string, check, force bool) error {
 	fmt.Println("Updating Go dependencies...")
 
-	if check {
-		return checkDependencyUpdates()
-	}
-
 	// Check for updates first
-	if err := checkDependencyUpdates(); err != nil {
+	updates, err := checkDependencyUpdates()
+	if err != nil {
 		return fmt.Errorf("failed to check for dependency updates: %w", err)
 	}
+	if check || len(updates) == 0 {
+		return nil
+	}
 
-	// Get current dependencies
-	dependencies, err := getCurrentDependencies()
+	selected, err := selectDependencyUpdates(updates)
 	if err != nil {
-		return fmt.Errorf("failed to get current dependencies: %w", err)
+		return err
 	}
 
 	// Perform updates
-	if err := performDependencyUpdates([]DependencyUpdate{}, force); err != nil {
+	if err := performDependencyUpdates(selected, force); err != nil {
 		return fmt.Errorf("failed to update dependencies: %w", err)
 	}
-
-	fmt.Printf("✓ Dependencies updated successfully (%d packages)\n", len(dependencies))
 	return nil
 }
 
@@ -231,7 +336,7 @@ func updateFramework(version string, check, force bool) error {
 	fmt.Println("Updating go-micro-libs framework...")
 
 	if check {
-		return checkFrameworkUpdates()
+		return checkFrameworkUpdates(version)
 	}
 
 	// Check current framework version
@@ -240,27 +345,31 @@ func updateFramework(version string, check, force bool) error {
 		return fmt.Errorf("failed to get current framework version: %w", err)
 	}
 
-	// Check for updates
-	latestVersion, err := getLatestFrameworkVersion()
+	// Resolve the version to update to, honoring the lock file
+	lock, err := loadProjectLock(".")
 	if err != nil {
-		return fmt.Errorf("failed to get latest framework version: %w", err)
+		return err
+	}
+	targetVersion, err := lockedTarget(lock, LockGoMicroLibs, version, getLatestFrameworkVersion)
+	if err != nil {
+		return fmt.Errorf("failed to resolve framework version: %w", err)
 	}
 
-	if currentVersion == latestVersion {
+	if currentVersion == targetVersion {
 		fmt.Println("✓ Framework is up to date")
-		return nil
+		return pinVersion(lock, LockGoMicroLibs, targetVersion)
 	}
 
-	fmt.Printf("Framework update available: %s -> %s\n", currentVersion, latestVersion)
+	fmt.Printf("Framework update available: %s -> %s\n", currentVersion, targetVersion)
 
 	// Check for breaking changes
-	breakingChanges, err := checkBreakingChanges(currentVersion, latestVersion)
+	breakingChanges, err := checkBreakingChanges(currentVersion, targetVersion)
 	if err != nil {
 		return fmt.Errorf("failed to check for breaking changes: %w", err)
 	}
 
 	if len(breakingChanges) > 0 && !force {
-		fmt.Println("Breaking changes detected:")
+		fmt.Println("Breaking changes affecting this project:")
 		for _, change := range breakingChanges {
 			fmt.Printf("  - %s\n", change)
 		}
@@ -268,19 +377,19 @@ func updateFramework(version string, check, force bool) error {
 	}
 
 	// Update framework
-	if err := performFrameworkUpdate(latestVersion); err != nil {
+	if err := performFrameworkUpdate(targetVersion); err != nil {
 		return fmt.Errorf("failed to update framework: %w", err)
 	}
 
 	fmt.Println("✓ Framework updated successfully")
-	return nil
+	return pinVersion(lock, LockGoMicroLibs, targetVersion)
 }
 
 func updateCLI(version string, check, force bool) error {
 	fmt.Println("Updating CLI tool...")
 
 	if check {
-		return checkCLIUpdates()
+		return checkCLIUpdates(version)
 	}
 
 	// Check current CLI version
@@ -289,124 +398,159 @@ func updateCLI(version string, check, force bool) error {
 		return fmt.Errorf("failed to get current CLI version: %w", err)
 	}
 
-	// Check for updates
-	latestVersion, err := getLatestCLIVersion()
+	// Resolve the version to update to, honoring the lock file
+	lock, err := loadProjectLock(".")
 	if err != nil {
-		return fmt.Errorf("failed to get latest CLI version: %w", err)
+		return err
+	}
+	targetVersion, err := lockedTarget(lock, LockCLI, version, getLatestCLIVersion)
+	if err != nil {
+		return fmt.Errorf("failed to resolve CLI version: %w", err)
 	}
 
-	if currentVersion == latestVersion {
+	if sameVersion(currentVersion, targetVersion) {
 		fmt.Println("✓ CLI tool is up to date")
-		return nil
+		return pinVersion(lock, LockCLI, targetVersion)
 	}
 
-	fmt.Printf("CLI update available: %s -> %s\n", currentVersion, latestVersion)
+	fmt.Printf("CLI update available: %s -> %s\n", currentVersion, targetVersion)
 
 	// Update CLI
-	if err := performCLIUpdate(latestVersion); err != nil {
+	if err := performCLIUpdate(targetVersion); err != nil {
 		return fmt.Errorf("failed to update CLI: %w", err)
 	}
 
 	fmt.Println("✓ CLI tool updated successfully")
-	return nil
+	return pinVersion(lock, LockCLI, targetVersion)
 }
 
 func updateConfig(version string, check, force bool) error {
 	fmt.Println("Updating configuration...")
 
-	if check {
-		_, err := checkConfigUpdates()
-		return err
-	}
-
 	// Check for configuration updates
-	updates, err := checkConfigUpdates()
+	updates, err := checkConfigUpdates(version)
 	if err != nil {
 		return fmt.Errorf("failed to check for configuration updates: %w", err)
 	}
 
-	if len(updates) == 0 {
+	var changes []ConfigUpdate
+	for _, update := range updates {
+		if update.Action == ConfigUpdateDeprecated {
+			warnf("%s is deprecated since %s: %s", update.Key, update.Version, update.Description)
+			continue
+		}
+		changes = append(changes, update)
+	}
+
+	if len(changes) == 0 {
 		fmt.Println("✓ Configuration is up to date")
 		return nil
 	}
 
 	// Show available updates
-	fmt.Printf("Found %d configuration updates:\n", len(updates))
-	for _, update := range updates {
-		fmt.Printf("  - %s: %s\n", update.Key, update.Description)
+	fmt.Printf("Found %d configuration updates:\n", len(changes))
+	for _, update := range changes {
+		if update.Action == ConfigUpdateRename {
+			fmt.Printf("  - %s -> %s: %s\n", update.From, update.Key, update.Description)
+		} else {
+			fmt.Printf("  - %s: %s\n", update.Key, update.Description)
+		}
 	}
 
 	// Update configuration
-	if err := performConfigUpdates(updates, force); err != nil {
+	if err := performConfigUpdates(changes, check); err != nil {
 		return fmt.Errorf("failed to update configuration: %w", err)
 	}
-
-	fmt.Println("✓ Configuration updated successfully")
 	return nil
 }
 
 // Helper functions for updates
-func getCurrentDependencies() ([]Dependency, error) {
-	fmt.Println("Getting current dependencies...")
 
-	// Read go.mod file
-	goModPath := "go.mod"
-	if _, err := os.Stat(goModPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("go.mod file not found")
-	}
+// checkDependencyUpdates lists the modules of the build that have a newer version. The
+// framework modules are left to update --type framework.
+func checkDependencyUpdates() ([]DependencyUpdate, error) {
+	fmt.Println("Checking for dependency updates...")
 
-	content, err := os.ReadFile(goModPath)
+	// List the modules of the build, which needs no lookup, then look up the latest versions
+	// of all of them at once
+	cmd := exec.Command("go", "list", "-m", "-json", "all")
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read go.mod: %w", err)
-	}
-
-	var dependencies []Dependency
-	lines := strings.Split(string(content), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "require ") && !strings.Contains(line, "// indirect") {
-			// Parse require line
-			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				dependencies = append(dependencies, Dependency{
-					Name:    parts[1],
-					Version: parts[2],
-				})
+		return nil, fmt.Errorf("failed to check for dependency updates: %w", err)
+	}
+
+	type buildModule struct {
+		Path     string
+		Version  string
+		Main     bool
+		Indirect bool
+	}
+	var modules []buildModule
+	var paths []string
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var module buildModule
+		if err := decoder.Decode(&module); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
 			}
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
 		}
+		if module.Main || module.Version == "" {
+			continue
+		}
+		if _, ok := frameworkRepositories[module.Path]; ok {
+			continue
+		}
+		modules = append(modules, module)
+		paths = append(paths, module.Path)
 	}
 
-	return dependencies, nil
-}
-
-func checkDependencyUpdates() error {
-	fmt.Println("Checking for dependency updates...")
-
-	// Run go list -u -m all to check for updates
-	cmd := exec.Command("go", "list", "-u", "-m", "all")
-	output, err := cmd.Output()
+	latest, err := latestModuleVersions(paths...)
 	if err != nil {
-		return fmt.Errorf("failed to check for dependency updates: %w", err)
+		return nil, fmt.Errorf("failed to check for dependency updates: %w", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	updateCount := 0
-
-	for _, line := range lines {
-		if strings.Contains(line, "[") && strings.Contains(line, "]") {
-			updateCount++
-			fmt.Printf("  Update available: %s\n", line)
+	var updates []DependencyUpdate
+	var unknown int
+	for _, module := range modules {
+		version, ok := latest[module.Path]
+		if !ok {
+			unknown++
+			continue
+		}
+		if semver.Compare(version, module.Version) <= 0 {
+			continue
 		}
+		updates = append(updates, DependencyUpdate{
+			Name:     module.Path,
+			Current:  module.Version,
+			Latest:   version,
+			Indirect: module.Indirect,
+		})
+	}
+	if unknown > 0 && updateOffline {
+		warnf("%d modules have no cached version and were not checked offline", unknown)
 	}
 
-	if updateCount == 0 {
+	if len(updates) == 0 {
 		fmt.Println("✓ All dependencies are up to date")
-	} else {
-		fmt.Printf("Found %d dependency updates\n", updateCount)
+		return nil, nil
 	}
 
-	return nil
+	fmt.Printf("Found %d dependency updates:\n", len(updates))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  #\tMODULE\tCURRENT\tLATEST\tTYPE")
+	for i, update := range updates {
+		kind := "direct"
+		if update.Indirect {
+			kind = "indirect"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\n", i+1, update.Name, update.Current, update.Latest, kind)
+	}
+	w.Flush()
+
+	return updates, nil
 }
 
 func performDependencyUpdates(updates []DependencyUpdate, force bool) error {
@@ -417,11 +561,15 @@ func performDependencyUpdates(updates []DependencyUpdate, force bool) error {
 		return nil
 	}
 
-	// Run go get -u to update all dependencies
-	cmd := exec.Command("go", "get", "-u", "./...")
+	// Update exactly the selected modules
+	args := []string{"get"}
+	for _, update := range updates {
+		args = append(args, update.Name+"@"+update.Latest)
+	}
+	cmd := exec.Command("go", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to update dependencies: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("go get failed: %w\nOutput: %s", err, string(output))
 	}
 
 	// Run go mod tidy to clean up
@@ -465,46 +613,60 @@ func getCurrentFrameworkVersion() (string, error) {
 func getLatestFrameworkVersion() (string, error) {
 	fmt.Println("Getting latest framework version...")
 
-	// Check GitHub releases for go-micro-libs
-	cmd := exec.Command("go", "list", "-m", "-versions", "github.com/anasamu/go-micro-libs")
-	output, err := cmd.Output()
+	latest, err := latestModuleVersion("github.com/anasamu/go-micro-libs")
 	if err != nil {
 		return "", fmt.Errorf("failed to get latest framework version: %w", err)
 	}
-
-	// Parse the output to get the latest version
-	line := strings.TrimSpace(string(output))
-	parts := strings.Fields(line)
-	if len(parts) > 0 {
-		// The last part should be the latest version
-		versions := strings.Split(parts[len(parts)-1], " ")
-		if len(versions) > 0 {
-			return versions[len(versions)-1], nil
-		}
-	}
-
-	return "v1.0.0", nil // Default fallback
+	return latest, nil
 }
 
+// checkBreakingChanges lists the breaking changes, from the release metadata of the framework
+// modules, between current and latest that affect APIs the project uses
 func checkBreakingChanges(current, latest string) ([]string, error) {
 	fmt.Println("Checking for breaking changes...")
 
-	// For now, return empty list - in a real implementation, this would:
-	// 1. Check the CHANGELOG.md or release notes
-	// 2. Compare API changes between versions
-	// 3. Check for deprecated features
+	required, err := requiredFrameworkModules()
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]string, 0, len(required))
+	for module := range required {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	usages, err := projectAPIUsages(modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan the project: %w", err)
+	}
 
 	var breakingChanges []string
+	unaffected := 0
+	for _, module := range modules {
+		from := required[module]
+		if module == "github.com/anasamu/go-micro-libs" {
+			from = current
+		}
 
-	// Simple version comparison for major version changes
-	if strings.HasPrefix(current, "v0.") && strings.HasPrefix(latest, "v1.") {
-		breakingChanges = append(breakingChanges, "Major version upgrade from v0.x to v1.x may contain breaking changes")
+		changes, err := breakingChangesBetween(module, from, latest)
+		if err != nil {
+			breakingChanges = append(breakingChanges,
+				fmt.Sprintf("could not read the release metadata of %s (%v); review its release notes before updating", module, err))
+			continue
+		}
+		for _, change := range changes {
+			used := affectedUsages(change, usages)
+			if len(used) == 0 {
+				unaffected++
+				continue
+			}
+			breakingChanges = append(breakingChanges, describeBreakingChange(change, used))
+		}
 	}
 
-	if strings.HasPrefix(current, "v1.") && strings.HasPrefix(latest, "v2.") {
-		breakingChanges = append(breakingChanges, "Major version upgrade from v1.x to v2.x may contain breaking changes")
+	if unaffected > 0 {
+		fmt.Printf("%d breaking changes do not affect APIs this project uses\n", unaffected)
 	}
-
 	return breakingChanges, nil
 }
 
@@ -522,7 +684,7 @@ func performFrameworkUpdate(version string) error {
 	cmd = exec.Command("go", "get", fmt.Sprintf("github.com/anasamu/go-micro-framework@%s", version))
 	output, err = cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("Warning: Failed to update CLI tool: %s\n", string(output))
+		warnf("Failed to update CLI tool: %s", string(output))
 	}
 
 	// Run go mod tidy to clean up
@@ -534,7 +696,7 @@ func performFrameworkUpdate(version string) error {
 	// Verify the update was successful
 	updatedVersion, err := getCurrentFrameworkVersion()
 	if err != nil {
-		return fmt.Errorf("failed to verify framework update: %w", err)
+		return &ValidationFailure{fmt.Errorf("failed to verify framework update: %w", err)}
 	}
 
 	fmt.Printf("✓ Framework updated to version %s\n", updatedVersion)
@@ -542,7 +704,7 @@ func performFrameworkUpdate(version string) error {
 	// Show integration status with go-micro-libs
 	fmt.Println("Checking go-micro-libs integration...")
 	if err := checkGoMicroLibsIntegration(); err != nil {
-		fmt.Printf("Warning: go-micro-libs integration check failed: %v\n", err)
+		warnf("go-micro-libs integration check failed: %v", err)
 	} else {
 		fmt.Println("✓ go-micro-libs integration verified")
 	}
@@ -576,28 +738,19 @@ func getCurrentCLIVersion() (string, error) {
 func getLatestCLIVersion() (string, error) {
 	fmt.Println("Getting latest CLI version...")
 
-	// Check GitHub releases for go-micro-framework
-	cmd := exec.Command("go", "list", "-m", "-versions", "github.com/anasamu/go-micro-framework")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get latest CLI version: %w", err)
+	if tag, err := latestReleaseTag(cliRepository); err == nil {
+		return tag, nil
 	}
 
-	// Parse the output to get the latest version
-	line := strings.TrimSpace(string(output))
-	parts := strings.Fields(line)
-	if len(parts) > 0 {
-		// The last part should be the latest version
-		versions := strings.Split(parts[len(parts)-1], " ")
-		if len(versions) > 0 {
-			return versions[len(versions)-1], nil
-		}
+	// Fall back to the versions of go-micro-framework on the module proxy
+	latest, err := latestModuleVersion("github.com/anasamu/go-micro-framework")
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest CLI version: %w", err)
 	}
-
-	return "1.0.0", nil // Default fallback
+	return latest, nil
 }
 
-func checkCLIUpdates() error {
+func checkCLIUpdates(version string) error {
 	fmt.Println("Checking for CLI updates...")
 
 	currentVersion, err := getCurrentCLIVersion()
@@ -610,20 +763,35 @@ func checkCLIUpdates() error {
 		return fmt.Errorf("failed to get latest CLI version: %w", err)
 	}
 
-	if currentVersion == latestVersion {
+	if sameVersion(currentVersion, latestVersion) {
 		fmt.Println("✓ CLI tool is up to date")
 	} else {
 		fmt.Printf("CLI update available: %s -> %s\n", currentVersion, latestVersion)
 	}
 
-	return nil
+	return reportLockedVersion(LockCLI, version, latestVersion)
 }
 
 func performCLIUpdate(version string) error {
 	fmt.Printf("Updating CLI to version: %s\n", version)
 
-	// Install the latest version of the CLI tool
-	cmd := exec.Command("go", "install", fmt.Sprintf("github.com/anasamu/go-micro-framework/cmd/microframework@%s", version))
+	// Install the release binary for this platform
+	err := selfUpdate(version)
+	if err == nil {
+		fmt.Printf("✓ CLI tool updated to version %s\n", version)
+		return nil
+	}
+	if !errors.Is(err, errNoReleaseBinary) && !errors.Is(err, errReleaseUnavailable) && !errors.Is(err, errNoReleaseKey) {
+		return err
+	}
+
+	// Build it from source when there is no binary to download, or none this CLI can verify:
+	// go install checks the module against the Go checksum database
+	if _, lookErr := exec.LookPath("go"); lookErr != nil {
+		return fmt.Errorf("%v, and Go is not installed to build it", err)
+	}
+	fmt.Printf("%v; installing with go install\n", err)
+	cmd := exec.Command("go", "install", fmt.Sprintf("github.com/anasamu/go-micro-framework/cmd/microframework@v%s", strings.TrimPrefix(version, "v")))
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to update CLI: %w\nOutput: %s", err, string(output))
@@ -633,55 +801,26 @@ func performCLIUpdate(version string) error {
 	return nil
 }
 
-func getCurrentConfiguration() (interface{}, error) {
-	fmt.Println("Getting current configuration...")
-
-	// Look for configuration files in the current directory
-	configFiles := []string{"config.yaml", "config.yml", "config.json", "configs/config.yaml", "configs/config.yml"}
-
-	for _, configFile := range configFiles {
-		if _, err := os.Stat(configFile); err == nil {
-			content, err := os.ReadFile(configFile)
-			if err != nil {
-				continue
-			}
-			return string(content), nil
-		}
-	}
-
-	return map[string]interface{}{}, nil
-}
-
-func checkConfigUpdates() ([]ConfigUpdate, error) {
+// checkConfigUpdates lists the changes of the upgrade manifest up to version that the
+// service configuration still needs
+func checkConfigUpdates(version string) ([]ConfigUpdate, error) {
 	fmt.Println("Checking for configuration updates...")
 
-	var updates []ConfigUpdate
-
-	// Check for common configuration updates
-	// This is a simplified implementation - in a real scenario, this would:
-	// 1. Compare current config with latest template
-	// 2. Check for deprecated configuration options
-	// 3. Suggest new configuration options
-
-	// Example: Check if new configuration options are available
-	updates = append(updates, ConfigUpdate{
-		Key:         "monitoring.prometheus.enabled",
-		Description: "Enable Prometheus metrics collection",
-		OldValue:    nil,
-		NewValue:    true,
-	})
-
-	updates = append(updates, ConfigUpdate{
-		Key:         "auth.jwt.expiration",
-		Description: "Update JWT token expiration to recommended value",
-		OldValue:    "1h",
-		NewValue:    "24h",
-	})
+	configFile, err := findConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	document, _, err := loadConfigDocument(configFile)
+	if err != nil {
+		return nil, err
+	}
 
-	return updates, nil
+	return configUpdatesFor(document.Content[0], version), nil
 }
 
-func performConfigUpdates(updates []ConfigUpdate, force bool) error {
+// performConfigUpdates applies the updates to the configuration, keeping its comments, and
+// shows the resulting diff. In check mode the diff is shown without writing the file.
+func performConfigUpdates(updates []ConfigUpdate, check bool) error {
 	fmt.Println("Performing configuration updates...")
 
 	if len(updates) == 0 {
@@ -689,18 +828,36 @@ func performConfigUpdates(updates []ConfigUpdate, force bool) error {
 		return nil
 	}
 
-	// Read current configuration
-	configFile := "configs/config.yaml"
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		configFile = "config.yaml"
-		if _, err := os.Stat(configFile); os.IsNotExist(err) {
-			return fmt.Errorf("no configuration file found")
-		}
+	configFile, err := findConfigFile()
+	if err != nil {
+		return err
+	}
+	document, content, err := loadConfigDocument(configFile)
+	if err != nil {
+		return err
 	}
 
-	content, err := os.ReadFile(configFile)
+	if err := applyConfigUpdates(document.Content[0], updates); err != nil {
+		return err
+	}
+	updatedContent, err := encodeConfigDocument(document)
 	if err != nil {
-		return fmt.Errorf("failed to read configuration file: %w", err)
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+
+	updatedContent = restoreSectionSpacing(content, updatedContent)
+
+	diff := unifiedDiff(string(content), string(updatedContent), configFile, configFile)
+	if diff == "" {
+		fmt.Println("No configuration changes to write")
+		return nil
+	}
+	fmt.Println()
+	fmt.Print(diff)
+	fmt.Println()
+
+	if check {
+		return nil
 	}
 
 	// Create backup
@@ -709,23 +866,17 @@ func performConfigUpdates(updates []ConfigUpdate, force bool) error {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 
-	// Apply updates (simplified - in real implementation, would use YAML parser)
-	updatedContent := string(content)
-	for _, update := range updates {
-		fmt.Printf("  Updating %s: %s\n", update.Key, update.Description)
-		// Here you would implement actual configuration update logic
-	}
-
 	// Write updated configuration
-	if err := os.WriteFile(configFile, []byte(updatedContent), 0644); err != nil {
+	if err := os.WriteFile(configFile, updatedContent, 0644); err != nil {
 		return fmt.Errorf("failed to write updated configuration: %w", err)
 	}
 
+	reportFiles(configFile, backupFile)
 	fmt.Printf("✓ Configuration updated successfully (backup created: %s)\n", backupFile)
 	return nil
 }
 
-func checkFrameworkUpdates() error {
+func checkFrameworkUpdates(version string) error {
 	fmt.Println("Checking for framework updates...")
 
 	currentVersion, err := getCurrentFrameworkVersion()
@@ -740,10 +891,38 @@ func checkFrameworkUpdates() error {
 
 	if currentVersion == latestVersion {
 		fmt.Println("✓ Framework is up to date")
-	} else {
-		fmt.Printf("Framework update available: %s -> %s\n", currentVersion, latestVersion)
+		return reportLockedVersion(LockGoMicroLibs, version, latestVersion)
 	}
+	fmt.Printf("Framework update available: %s -> %s\n", currentVersion, latestVersion)
 
+	breakingChanges, err := checkBreakingChanges(currentVersion, latestVersion)
+	if err != nil {
+		return fmt.Errorf("failed to check for breaking changes: %w", err)
+	}
+	if len(breakingChanges) > 0 {
+		fmt.Println("Breaking changes affecting this project:")
+		for _, change := range breakingChanges {
+			fmt.Printf("  - %s\n", change)
+		}
+	}
+
+	return reportLockedVersion(LockGoMicroLibs, version, latestVersion)
+}
+
+// reportLockedVersion tells, in check mode, which version update would move component to
+// when the lock file holds it back from the latest one
+func reportLockedVersion(component, requested, latestVersion string) error {
+	lock, err := loadProjectLock(".")
+	if err != nil {
+		return err
+	}
+	targetVersion, err := lockedTarget(lock, component, requested, func() (string, error) { return latestVersion, nil })
+	if err != nil {
+		return err
+	}
+	if !sameVersion(targetVersion, latestVersion) {
+		fmt.Printf("%s is locked to %s in %s; update --pin moves it to %s\n", component, targetVersion, projectLockFile, latestVersion)
+	}
 	return nil
 }
 
@@ -754,9 +933,10 @@ type Dependency struct {
 }
 
 type DependencyUpdate struct {
-	Name    string
-	Current string
-	Latest  string
+	Name     string
+	Current  string
+	Latest   string
+	Indirect bool
 }
 
 type ConfigUpdate struct {
@@ -764,6 +944,12 @@ type ConfigUpdate struct {
 	Description string
 	OldValue    interface{}
 	NewValue    interface{}
+	// Action is add, rename or deprecated
+	Action string
+	// From is the previous key of a rename
+	From string
+	// Version is the framework version that introduced the change
+	Version string
 }
 
 // checkGoMicroLibsIntegration checks if go-micro-libs is properly integrated
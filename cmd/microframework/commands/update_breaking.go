@@ -0,0 +1,331 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// breakingChangesFence is the info string of the fenced YAML block in release notes and
+// CHANGELOG sections that lists the breaking changes of a release:
+//
+//	```breaking-changes
+//	- symbol: github.com/anasamu/go-micro-libs/database/types.Provider.Query
+//	  description: Query takes a QueryOptions argument
+//	  migration: pass types.QueryOptions{} to keep the previous behaviour
+//	```
+const breakingChangesFence = "breaking-changes"
+
+// frameworkRepositories maps the framework modules to their GitHub repositories
+var frameworkRepositories = map[string]string{
+	"github.com/anasamu/go-micro-libs":      "anasamu/go-micro-libs",
+	"github.com/anasamu/go-micro-framework": "anasamu/go-micro-framework",
+}
+
+// breakingChange is an entry of the breaking-changes block of a release. Symbol is an
+// import path, optionally followed by an identifier and a member: path.Name.Member.
+type breakingChange struct {
+	Symbol      string `yaml:"symbol"`
+	Description string `yaml:"description"`
+	Migration   string `yaml:"migration"`
+	Module      string `yaml:"-"`
+	Version     string `yaml:"-"`
+}
+
+// releaseNotes is the description of one release of a module
+type releaseNotes struct {
+	Version string `json:"version"`
+	Body    string `json:"body"`
+}
+
+// breakingChangesBetween returns the breaking changes of the releases of module after current
+// up to and including latest
+func breakingChangesBetween(module, current, latest string) ([]breakingChange, error) {
+	notes, err := cachedReleaseNotes(module)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []breakingChange
+	for _, release := range notes {
+		if semver.Compare(release.Version, current) <= 0 || semver.Compare(release.Version, latest) > 0 {
+			continue
+		}
+		releaseChanges, err := parseBreakingChanges(release.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s block in the notes of %s %s: %w", breakingChangesFence, module, release.Version, err)
+		}
+		for _, change := range releaseChanges {
+			change.Module, change.Version = module, release.Version
+			changes = append(changes, change)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return semver.Compare(changes[i].Version, changes[j].Version) < 0 })
+	return changes, nil
+}
+
+// fetchReleaseNotes reads the release notes of module from the GitHub Releases API, falling
+// back to the CHANGELOG.md of its latest version when GitHub cannot be reached
+func fetchReleaseNotes(module string) ([]releaseNotes, error) {
+	notes, err := githubReleaseNotes(frameworkRepositories[module])
+	if err == nil {
+		return notes, nil
+	}
+
+	notes, changelogErr := changelogReleaseNotes(module)
+	if changelogErr != nil {
+		return nil, fmt.Errorf("%v; CHANGELOG.md: %v", err, changelogErr)
+	}
+	return notes, nil
+}
+
+// githubReleaseNotes lists the published releases of a GitHub repository
+func githubReleaseNotes(repository string) ([]releaseNotes, error) {
+	response, err := githubGet("https://api.github.com/repos/"+repository+"/releases?per_page=100", 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub releases of %s: %w", repository, err)
+	}
+	defer response.Body.Close()
+
+	var releases []struct {
+		TagName string `json:"tag_name"`
+		Body    string `json:"body"`
+		Draft   bool   `json:"draft"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("GitHub releases of %s: %w", repository, err)
+	}
+
+	var notes []releaseNotes
+	for _, release := range releases {
+		if release.Draft || !semver.IsValid(release.TagName) {
+			continue
+		}
+		notes = append(notes, releaseNotes{Version: release.TagName, Body: release.Body})
+	}
+	return notes, nil
+}
+
+// changelogReleaseNotes reads the sections of the CHANGELOG.md of the latest version of module,
+// downloaded into the module cache
+func changelogReleaseNotes(module string) ([]releaseNotes, error) {
+	output, err := exec.Command("go", "mod", "download", "-json", module+"@latest").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", module, err)
+	}
+	var download struct {
+		Dir string
+	}
+	if err := json.Unmarshal(output, &download); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", module, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(download.Dir, "CHANGELOG.md"))
+	if err != nil {
+		return nil, err
+	}
+	return parseChangelog(string(content)), nil
+}
+
+// parseChangelog splits a Keep a Changelog file into the notes of each released version
+func parseChangelog(content string) []releaseNotes {
+	var notes []releaseNotes
+	var current *releaseNotes
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			current = nil
+			heading := strings.TrimPrefix(line, "## ")
+			start, end := strings.Index(heading, "["), strings.Index(heading, "]")
+			if start < 0 || end < start {
+				continue
+			}
+			version := "v" + strings.TrimPrefix(heading[start+1:end], "v")
+			if !semver.IsValid(version) {
+				continue
+			}
+			notes = append(notes, releaseNotes{Version: version})
+			current = &notes[len(notes)-1]
+			continue
+		}
+		if current != nil {
+			current.Body += line + "\n"
+		}
+	}
+	return notes
+}
+
+// parseBreakingChanges reads the breaking-changes blocks of release notes
+func parseBreakingChanges(body string) ([]breakingChange, error) {
+	var changes []breakingChange
+	var block []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inBlock && trimmed == "```"+breakingChangesFence:
+			inBlock, block = true, nil
+		case inBlock && trimmed == "```":
+			inBlock = false
+			var entries []breakingChange
+			if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &entries); err != nil {
+				return nil, err
+			}
+			changes = append(changes, entries...)
+		case inBlock:
+			block = append(block, line)
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// splitSymbol separates the import path of a symbol from its identifiers
+func splitSymbol(symbol string) (string, []string) {
+	lastSlash := strings.LastIndex(symbol, "/")
+	dot := strings.Index(symbol[lastSlash+1:], ".")
+	if dot < 0 {
+		return symbol, nil
+	}
+	return symbol[:lastSlash+1+dot], strings.Split(symbol[lastSlash+1+dot+1:], ".")
+}
+
+// projectAPIUsages maps the packages of modules that the project imports, and the
+// package-level identifiers it uses from them (path.Name), to the positions of those uses
+func projectAPIUsages(modules []string) (map[string][]string, error) {
+	usages := make(map[string][]string)
+	fset := token.NewFileSet()
+
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != "." && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			// Files that do not parse cannot be checked; the build reports them
+			return nil
+		}
+
+		imports := make(map[string]string)
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			if !inModules(importPath, modules) {
+				continue
+			}
+			name := importPath[strings.LastIndex(importPath, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = importPath
+			usages[importPath] = append(usages[importPath], fset.Position(spec.Pos()).String())
+		}
+		if len(imports) == 0 {
+			return nil
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				if importPath, ok := imports[ident.Name]; ok {
+					symbol := importPath + "." + selector.Sel.Name
+					usages[symbol] = append(usages[symbol], fset.Position(selector.Pos()).String())
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	return usages, err
+}
+
+func inModules(importPath string, modules []string) bool {
+	for _, module := range modules {
+		if importPath == module || strings.HasPrefix(importPath, module+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// affectedUsages returns where the project uses the symbol of a breaking change. Members of
+// a type are matched by the uses of the type, since selections on values are not resolved.
+func affectedUsages(change breakingChange, usages map[string][]string) []string {
+	importPath, names := splitSymbol(change.Symbol)
+	if len(names) == 0 {
+		return usages[importPath]
+	}
+	return usages[importPath+"."+names[0]]
+}
+
+// requiredFrameworkModules returns the framework modules that go.mod requires, with their versions
+func requiredFrameworkModules() (map[string]string, error) {
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	file, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	required := make(map[string]string)
+	for _, require := range file.Require {
+		if _, ok := frameworkRepositories[require.Mod.Path]; ok {
+			required[require.Mod.Path] = require.Mod.Version
+		}
+	}
+	return required, nil
+}
+
+// describeBreakingChange formats an affecting change with the places to fix
+func describeBreakingChange(change breakingChange, used []string) string {
+	const maxPositions = 3
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s: %s", change.Module, change.Version, change.Symbol)
+	if change.Description != "" {
+		fmt.Fprintf(&text, " (%s)", change.Description)
+	}
+	positions := used
+	if len(positions) > maxPositions {
+		positions = positions[:maxPositions]
+	}
+	fmt.Fprintf(&text, "\n      used at %s", strings.Join(positions, ", "))
+	if len(used) > maxPositions {
+		fmt.Fprintf(&text, " and %d more", len(used)-maxPositions)
+	}
+	if change.Migration != "" {
+		fmt.Fprintf(&text, "\n      migration: %s", change.Migration)
+	}
+	return text.String()
+}
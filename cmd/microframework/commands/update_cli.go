@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// cliRepository publishes the CLI release binaries
+	cliRepository = "anasamu/go-micro-framework"
+	// releaseChecksumsAsset lists the SHA-256 of every binary of a release, in sha256sum format
+	releaseChecksumsAsset = "checksums.txt"
+	// releaseSignatureAsset is the base64 Ed25519 signature of the checksums
+	releaseSignatureAsset = "checksums.txt.sig"
+)
+
+// releasePublicKey is the base64 Ed25519 key that signs release checksums. make release sets
+// it with -ldflags "-X github.com/anasamu/go-micro-framework/cmd/microframework/commands.releasePublicKey=...";
+// a CLI built without it does not install release binaries, since it cannot verify them.
+var releasePublicKey = ""
+
+var (
+	// errNoReleaseBinary means the release has no binary for this platform
+	errNoReleaseBinary = errors.New("no release binary for this platform")
+	// errReleaseUnavailable means the release could not be fetched from GitHub
+	errReleaseUnavailable = errors.New("release unavailable")
+	// errNoReleaseKey means this CLI was built without releasePublicKey
+	errNoReleaseKey = errors.New("this CLI was built without a release key to verify release binaries with")
+)
+
+// githubReleaseAsset is a file attached to a GitHub release
+type githubReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// githubRelease is a GitHub release with its assets
+type githubRelease struct {
+	TagName string               `json:"tag_name"`
+	Assets  []githubReleaseAsset `json:"assets"`
+}
+
+// asset returns the URL of the asset called name, or ""
+func (r *githubRelease) asset(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL
+		}
+	}
+	return ""
+}
+
+// githubGet requests a GitHub API or download URL. GITHUB_TOKEN is used when set, to avoid the
+// rate limit of anonymous requests.
+func githubGet(url string, timeout time.Duration) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(url, "https://api.github.com/") {
+		request.Header.Set("Accept", "application/vnd.github+json")
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := (&http.Client{Timeout: timeout}).Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return response, nil
+}
+
+// fetchGitHubRelease returns the release of repository tagged tag, or the latest release when
+// tag is empty
+func fetchGitHubRelease(repository, tag string) (*githubRelease, error) {
+	url := "https://api.github.com/repos/" + repository + "/releases/latest"
+	if tag != "" {
+		url = "https://api.github.com/repos/" + repository + "/releases/tags/" + tag
+	}
+
+	response, err := githubGet(url, 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var release githubRelease
+	if err := json.NewDecoder(response.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("invalid release of %s: %w", repository, err)
+	}
+	return &release, nil
+}
+
+// downloadReleaseAsset downloads an asset of a release
+func downloadReleaseAsset(url string) ([]byte, error) {
+	response, err := githubGet(url, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return io.ReadAll(response.Body)
+}
+
+// releaseBinaryName is the name of the release binary for this platform, as built by make release
+func releaseBinaryName() string {
+	name := fmt.Sprintf("microframework-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// verifyReleaseChecksum checks binary against its entry in the checksums of the release
+func verifyReleaseChecksum(checksums []byte, name string, binary []byte) error {
+	sum := sha256.Sum256(binary)
+	actual := hex.EncodeToString(sum[:])
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if !strings.EqualFold(fields[0], actual) {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, downloaded %s", name, fields[0], actual)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no checksum for %s", releaseChecksumsAsset, name)
+}
+
+// verifyReleaseSignature checks the signature of the release checksums with releasePublicKey
+func verifyReleaseSignature(checksums, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(releasePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release public key built into this CLI")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", releaseSignatureAsset, err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, decoded) {
+		return fmt.Errorf("%s does not match %s; the release may have been tampered with", releaseSignatureAsset, releaseChecksumsAsset)
+	}
+	return nil
+}
+
+// replaceExecutable swaps the running binary for binary. The old binary is moved aside first,
+// which also works on Windows where a running executable cannot be overwritten.
+func replaceExecutable(binary []byte) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	dir := filepath.Dir(executable)
+	next, err := os.CreateTemp(dir, ".microframework-update-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to %s (try again with permission to replace %s): %w", dir, executable, err)
+	}
+	defer os.Remove(next.Name())
+	if _, err := next.Write(binary); err != nil {
+		next.Close()
+		return "", fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := next.Close(); err != nil {
+		return "", fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(next.Name(), 0755); err != nil {
+		return "", fmt.Errorf("failed to make the new binary executable: %w", err)
+	}
+
+	previous := executable + ".old"
+	os.Remove(previous)
+	if err := os.Rename(executable, previous); err != nil {
+		return "", fmt.Errorf("failed to move %s aside: %w", executable, err)
+	}
+	if err := os.Rename(next.Name(), executable); err != nil {
+		// Put the running binary back
+		os.Rename(previous, executable)
+		return "", fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	// Windows keeps the running binary locked; it is removed by the next update
+	os.Remove(previous)
+	return executable, nil
+}
+
+// selfUpdate replaces the running CLI with the release binary of version for this platform,
+// after verifying it against the release checksums and their signature
+func selfUpdate(version string) error {
+	if releasePublicKey == "" {
+		return errNoReleaseKey
+	}
+	tag := "v" + strings.TrimPrefix(version, "v")
+	release, err := fetchGitHubRelease(cliRepository, tag)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", errReleaseUnavailable, tag, err)
+	}
+
+	name := releaseBinaryName()
+	binaryURL := release.asset(name)
+	if binaryURL == "" {
+		return fmt.Errorf("%w (%s in release %s)", errNoReleaseBinary, name, tag)
+	}
+	checksumsURL := release.asset(releaseChecksumsAsset)
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no %s; refusing to install an unverified binary", tag, releaseChecksumsAsset)
+	}
+
+	checksums, err := downloadReleaseAsset(checksumsURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", releaseChecksumsAsset, err)
+	}
+	signatureURL := release.asset(releaseSignatureAsset)
+	if signatureURL == "" {
+		return fmt.Errorf("release %s is not signed (%s missing); refusing to install an unverified binary", tag, releaseSignatureAsset)
+	}
+	signature, err := downloadReleaseAsset(signatureURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", releaseSignatureAsset, err)
+	}
+	if err := verifyReleaseSignature(checksums, signature); err != nil {
+		return err
+	}
+	fmt.Println("✓ Release signature verified")
+
+	fmt.Printf("Downloading %s...\n", name)
+	binary, err := downloadReleaseAsset(binaryURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err := verifyReleaseChecksum(checksums, name, binary); err != nil {
+		return err
+	}
+	fmt.Println("✓ Checksum verified")
+
+	path, err := replaceExecutable(binary)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Installed %s at %s\n", tag, path)
+	return nil
+}
@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration update actions
+const (
+	ConfigUpdateAdd        = "add"
+	ConfigUpdateRename     = "rename"
+	ConfigUpdateDeprecated = "deprecated"
+)
+
+// configUpgrade lists the configuration changes introduced by a framework version. Keys are
+// dot-separated paths into the service configuration.
+type configUpgrade struct {
+	Version      string
+	Additions    []configAddition
+	Renames      []configRename
+	Deprecations []configDeprecation
+}
+
+// configAddition adds a key with its default value when it is missing. With Under set, the
+// key is only added to configurations that have that section.
+type configAddition struct {
+	Key         string
+	Value       interface{}
+	Under       string
+	Description string
+}
+
+// configRename moves a key, with its value and comments, to a new path
+type configRename struct {
+	From        string
+	To          string
+	Description string
+}
+
+// configDeprecation warns about a key that is no longer used
+type configDeprecation struct {
+	Key     string
+	Message string
+}
+
+// configUpgradeManifest holds the configuration changes of every framework release, oldest
+// first. Each change is idempotent, so it can be applied to a configuration of any age.
+var configUpgradeManifest = []configUpgrade{
+	{
+		Version: "v1.1.0",
+		Additions: []configAddition{
+			{Key: "server.idle_timeout", Value: "120s", Under: "server", Description: "Close idle keep-alive connections after two minutes"},
+		},
+		Renames: []configRename{
+			{From: "auth.jwt", To: "auth.providers.jwt", Description: "JWT settings moved under auth.providers"},
+		},
+		Deprecations: []configDeprecation{
+			{Key: "logging.level", Message: "set the level of each provider under logging.providers.<name>.level"},
+		},
+	},
+}
+
+// findConfigFile returns the service configuration file to update
+func findConfigFile() (string, error) {
+	for _, path := range []string{"configs/config.yaml", "config.yaml", "configs/config.yml", "config.yml"} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no configuration file found")
+}
+
+// loadConfigDocument parses a YAML file keeping its comments and layout
+func loadConfigDocument(path string) (*yaml.Node, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%s is not a YAML mapping", path)
+	}
+	return &document, content, nil
+}
+
+// encodeConfigDocument renders a YAML document with the two-space indentation of the templates
+func encodeConfigDocument(document *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// restoreSectionSpacing puts back the blank lines between top-level sections that the YAML
+// encoder drops, when the original configuration separated its sections that way
+func restoreSectionSpacing(original, updated []byte) []byte {
+	if !bytes.Contains(original, []byte("\n\n")) {
+		return updated
+	}
+
+	lines := strings.Split(string(updated), "\n")
+	var out []string
+	sectionStart := 0
+	for _, line := range lines {
+		topLevel := line != "" && line[0] != ' ' && line[0] != '#' && line[0] != '-'
+		if topLevel && sectionStart > 0 {
+			// Keep the comments of the section together with its key
+			at := len(out)
+			for at > sectionStart && strings.HasPrefix(out[at-1], "#") {
+				at--
+			}
+			if at > 0 && out[at-1] != "" {
+				out = append(out[:at], append([]string{""}, out[at:]...)...)
+			}
+		}
+		out = append(out, line)
+		if topLevel {
+			sectionStart = len(out)
+		}
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// configUpdatesFor returns the changes of the manifest that the configuration still needs,
+// up to targetVersion when it is a release version
+func configUpdatesFor(root *yaml.Node, targetVersion string) []ConfigUpdate {
+	target := "v" + strings.TrimPrefix(targetVersion, "v")
+
+	var updates []ConfigUpdate
+	for _, upgrade := range configUpgradeManifest {
+		if semver.IsValid(target) && semver.Compare(upgrade.Version, target) > 0 {
+			continue
+		}
+
+		for _, rename := range upgrade.Renames {
+			if yamlPath(root, rename.From) == nil {
+				continue
+			}
+			if yamlPath(root, rename.To) != nil {
+				warnf("both %s and %s are set; remove %s (%s)", rename.From, rename.To, rename.From, rename.Description)
+				continue
+			}
+			updates = append(updates, ConfigUpdate{
+				Key: rename.To, From: rename.From, Action: ConfigUpdateRename, Version: upgrade.Version,
+				Description: rename.Description,
+			})
+		}
+		for _, addition := range upgrade.Additions {
+			if yamlPath(root, addition.Key) != nil || (addition.Under != "" && yamlPath(root, addition.Under) == nil) {
+				continue
+			}
+			updates = append(updates, ConfigUpdate{
+				Key: addition.Key, NewValue: addition.Value, Action: ConfigUpdateAdd, Version: upgrade.Version,
+				Description: addition.Description,
+			})
+		}
+		for _, deprecation := range upgrade.Deprecations {
+			if node := yamlPath(root, deprecation.Key); node != nil {
+				updates = append(updates, ConfigUpdate{
+					Key: deprecation.Key, OldValue: yamlScalar(node), Action: ConfigUpdateDeprecated, Version: upgrade.Version,
+					Description: deprecation.Message,
+				})
+			}
+		}
+	}
+	return updates
+}
+
+// applyConfigUpdates edits the document in place; deprecations are only reported
+func applyConfigUpdates(root *yaml.Node, updates []ConfigUpdate) error {
+	for _, update := range updates {
+		switch update.Action {
+		case ConfigUpdateAdd:
+			var value yaml.Node
+			if err := value.Encode(update.NewValue); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", update.Key, err)
+			}
+			parent, key := yamlEnsureParent(root, update.Key)
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &value)
+		case ConfigUpdateRename:
+			// Create the destination first so that moving a key deeper into its own section
+			// does not remove that section for being empty
+			parent, key := yamlEnsureParent(root, update.Key)
+			keyNode, valueNode := yamlRemoveKey(root, update.From)
+			if keyNode == nil {
+				continue
+			}
+			keyNode.Value = key
+			parent.Content = append(parent.Content, keyNode, valueNode)
+		}
+	}
+	return nil
+}
+
+// yamlPath returns the value at a dot-separated path of mapping keys, or nil
+func yamlPath(node *yaml.Node, path string) *yaml.Node {
+	for _, key := range strings.Split(path, ".") {
+		node = yamlMappingValue(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// yamlEnsureParent creates the mappings leading to path and returns the innermost one with
+// the last key of path
+func yamlEnsureParent(root *yaml.Node, path string) (*yaml.Node, string) {
+	keys := strings.Split(path, ".")
+	node := root
+	for _, key := range keys[:len(keys)-1] {
+		child := yamlMappingValue(node, key)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		node = child
+	}
+	return node, keys[len(keys)-1]
+}
+
+// yamlRemoveKey removes the entry at path and returns its key and value nodes. A mapping
+// left empty by the removal is removed as well.
+func yamlRemoveKey(root *yaml.Node, path string) (*yaml.Node, *yaml.Node) {
+	keys := strings.Split(path, ".")
+	parents := []*yaml.Node{root}
+	for _, key := range keys[:len(keys)-1] {
+		next := yamlMappingValue(parents[len(parents)-1], key)
+		if next == nil || next.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		parents = append(parents, next)
+	}
+
+	parent := parents[len(parents)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value != keys[len(keys)-1] {
+			continue
+		}
+		keyNode, valueNode := parent.Content[i], parent.Content[i+1]
+		parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+		if len(parent.Content) == 0 && len(keys) > 1 {
+			yamlRemoveKey(root, strings.Join(keys[:len(keys)-1], "."))
+		}
+		return keyNode, valueNode
+	}
+	return nil, nil
+}
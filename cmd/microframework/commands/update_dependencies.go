@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// matchModulePattern reports whether a module path matches a pattern: a path.Match glob, or
+// a path ending in /... that matches the path and everything below it
+func matchModulePattern(pattern, module string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return module == prefix || strings.HasPrefix(module, prefix+"/")
+	}
+	matched, err := path.Match(pattern, module)
+	return err == nil && matched
+}
+
+func matchAnyModulePattern(patterns []string, module string) bool {
+	for _, pattern := range patterns {
+		if matchModulePattern(pattern, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInteractive reports whether the standard input is a terminal
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// selectDependencyUpdates chooses the updates to apply. --only and --exclude select by module
+// pattern; otherwise the user picks them on a terminal, and only direct dependencies are
+// updated when nobody can be asked.
+func selectDependencyUpdates(updates []DependencyUpdate) ([]DependencyUpdate, error) {
+	if len(updateOnly) > 0 || len(updateExclude) > 0 {
+		var selected []DependencyUpdate
+		for _, update := range updates {
+			if len(updateOnly) > 0 && !matchAnyModulePattern(updateOnly, update.Name) {
+				continue
+			}
+			if matchAnyModulePattern(updateExclude, update.Name) {
+				continue
+			}
+			selected = append(selected, update)
+		}
+		return selected, nil
+	}
+
+	if !isInteractive() {
+		fmt.Println("Not running on a terminal; updating direct dependencies only (use --only/--exclude to choose)")
+		return directDependencyUpdates(updates), nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Updates to apply (e.g. 1,3-5; all; none) [direct]: ")
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			// The input closed without an answer (e.g. /dev/null, which is a character device too)
+			fmt.Println()
+			return directDependencyUpdates(updates), nil
+		}
+
+		selected, err := parseUpdateSelection(strings.TrimSpace(answer), updates)
+		if err == nil {
+			return selected, nil
+		}
+		fmt.Println(err)
+	}
+}
+
+func directDependencyUpdates(updates []DependencyUpdate) []DependencyUpdate {
+	var direct []DependencyUpdate
+	for _, update := range updates {
+		if !update.Indirect {
+			direct = append(direct, update)
+		}
+	}
+	return direct
+}
+
+// parseUpdateSelection reads the answer to the update prompt: numbers and ranges of the
+// listed updates, all, none, or nothing for the direct dependencies
+func parseUpdateSelection(answer string, updates []DependencyUpdate) ([]DependencyUpdate, error) {
+	switch strings.ToLower(answer) {
+	case "", "direct":
+		return directDependencyUpdates(updates), nil
+	case "all":
+		return updates, nil
+	case "none":
+		return nil, nil
+	}
+
+	chosen := make([]bool, len(updates))
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(first))
+		to := from
+		if err == nil && isRange {
+			to, err = strconv.Atoi(strings.TrimSpace(last))
+		}
+		if err != nil || from < 1 || to > len(updates) || from > to {
+			return nil, fmt.Errorf("invalid selection %q: use numbers from 1 to %d", part, len(updates))
+		}
+		for i := from; i <= to; i++ {
+			chosen[i-1] = true
+		}
+	}
+
+	var selected []DependencyUpdate
+	for i, update := range updates {
+		if chosen[i] {
+			selected = append(selected, update)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+)
+
+// Template update actions
+const (
+	TemplateUpdateCreate   = "create"
+	TemplateUpdateUpdate   = "update"
+	TemplateUpdateMerge    = "merge"
+	TemplateUpdateConflict = "conflict"
+	TemplateUpdateKeep     = "keep"
+	TemplateUpdateSkip     = "skip"
+)
+
+// templateUpdate is the change to one generated file
+type templateUpdate struct {
+	Path   string
+	Action string
+	Reason string
+	// Content is what the file is written with
+	Content []byte
+	// Generated is the new rendering of the template, the next merge base
+	Generated []byte
+	Conflicts int
+}
+
+// updateTemplates re-renders the scaffold of the project with the templates of this CLI, using
+// the flags recorded in its generation manifest. Files nobody touched are replaced; files
+// changed locally are merged with the template changes, leaving conflict markers where both
+// changed the same lines.
+func updateTemplates(targetVersion string, check, force bool) error {
+	fmt.Println("Updating project templates...")
+
+	if targetVersion != "" && strings.TrimPrefix(targetVersion, "v") != strings.TrimPrefix(version, "v") {
+		return fmt.Errorf("templates come with the CLI, which is version %s; run update --type cli --version %s first", version, targetVersion)
+	}
+
+	lock, err := loadProjectLock(".")
+	if err != nil {
+		return err
+	}
+	if _, err := lockedTarget(lock, LockTemplates, templates.Version, func() (string, error) { return templates.Version, nil }); err != nil {
+		return fmt.Errorf("%w; templates %s come with CLI %s", err, templates.Version, version)
+	}
+
+	manifest, err := generator.LoadManifest(".")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UserError{fmt.Errorf("no generation manifest (%s); only projects generated by microframework new %s or later can update their templates",
+				generator.ManifestFile, version)}
+		}
+		return err
+	}
+
+	config := manifest.Config
+	config.OutputDir = ""
+	config.FrameworkVersion = version
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	if config.TemplatePack != "" {
+		// The project moves to the current version of its pack
+		name, _, _ := strings.Cut(config.TemplatePack, "@")
+		packOpts, resolved, err := templatePackOptions(name)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, packOpts...)
+		config.TemplatePack = resolved
+	}
+	gen := generator.NewServiceGenerator(&config, opts...)
+	rendered, err := gen.RenderService()
+	if err != nil {
+		return &GenerationError{fmt.Errorf("failed to render templates: %w", err)}
+	}
+	if config.Adopted {
+		// Only the files microframework init scaffolded belong to the templates of an adopted project
+		for path := range rendered {
+			if _, ok := manifest.Files[path]; !ok {
+				delete(rendered, path)
+			}
+		}
+	}
+
+	fmt.Printf("Project generated with framework %s, templates of %s\n", manifest.FrameworkVersion, version)
+	updates, err := planTemplateUpdates(manifest, rendered, force)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range manifest.Paths() {
+		if _, ok := rendered[path]; !ok {
+			fmt.Printf("  %-9s %s (no longer generated)\n", TemplateUpdateKeep, path)
+		}
+	}
+
+	changed, conflicted := 0, 0
+	for _, update := range updates {
+		if update.Action == TemplateUpdateKeep && update.Reason == "" {
+			continue
+		}
+		if update.Reason != "" {
+			fmt.Printf("  %-9s %s (%s)\n", update.Action, update.Path, update.Reason)
+		} else {
+			fmt.Printf("  %-9s %s\n", update.Action, update.Path)
+		}
+		if update.Content != nil {
+			changed++
+		}
+		if update.Conflicts > 0 {
+			conflicted++
+		}
+	}
+
+	if changed == 0 {
+		fmt.Println("✓ Templates are up to date")
+	}
+	if check {
+		return nil
+	}
+
+	for _, update := range updates {
+		if update.Content != nil {
+			if err := os.MkdirAll(filepath.Dir(update.Path), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(update.Path), err)
+			}
+			if err := os.WriteFile(update.Path, update.Content, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", update.Path, err)
+			}
+			reportFiles(update.Path)
+		}
+		if err := generator.WriteBase(".", update.Path, update.Generated); err != nil {
+			return fmt.Errorf("failed to record %s: %w", update.Path, err)
+		}
+	}
+
+	manifest.FrameworkVersion = version
+	manifest.Config.TemplatePack = config.TemplatePack
+	manifest.Files = make(map[string]string)
+	manifest.Inputs = make(map[string]string)
+	for _, update := range updates {
+		manifest.Files[update.Path] = generator.Checksum(update.Generated)
+		if inputs := gen.Inputs()[update.Path]; inputs != "" {
+			manifest.Inputs[update.Path] = inputs
+		}
+	}
+	if err := manifest.Save("."); err != nil {
+		return fmt.Errorf("failed to update generation manifest: %w", err)
+	}
+
+	if err := pinVersion(lock, LockTemplates, templates.Version); err != nil {
+		return err
+	}
+
+	if conflicted > 0 {
+		return fmt.Errorf("%d files have merge conflicts; resolve the conflict markers, then build and test the project", conflicted)
+	}
+	if changed > 0 {
+		fmt.Printf("✓ Updated %d files to the templates of %s\n", changed, version)
+	}
+	return nil
+}
+
+// planTemplateUpdates decides what happens to every file of the new rendering. With force,
+// local changes are replaced instead of merged.
+func planTemplateUpdates(manifest *generator.Manifest, rendered map[string][]byte, force bool) ([]templateUpdate, error) {
+	paths := make([]string, 0, len(rendered))
+	for path := range rendered {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var updates []templateUpdate
+	for _, path := range paths {
+		generated := rendered[path]
+		update := templateUpdate{Path: path, Action: TemplateUpdateKeep, Generated: generated}
+		recorded, tracked := manifest.Files[path]
+
+		current, err := os.ReadFile(path)
+		switch {
+		case err != nil && !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		case err != nil && tracked:
+			update.Action, update.Reason = TemplateUpdateSkip, "deleted locally"
+		case err != nil:
+			update.Action, update.Content = TemplateUpdateCreate, generated
+		case generator.Checksum(current) == generator.Checksum(generated):
+			// Already matches the new templates
+		case generator.Checksum(current) == recorded || force:
+			update.Action, update.Content = TemplateUpdateUpdate, generated
+			if generator.Checksum(current) != recorded {
+				update.Reason = "local changes overwritten"
+			}
+		case generator.Checksum(generated) == recorded:
+			update.Reason = "local changes, template unchanged"
+		default:
+			// A file that was not generated, or whose generated contents are gone, is merged
+			// without a base, so that every difference is a conflict
+			var base []byte
+			if tracked {
+				base, _ = generator.ReadBase(".", path)
+			}
+			merged, conflicts := mergeLines(splitLines(string(base)), splitLines(string(current)), splitLines(string(generated)),
+				"local", "framework "+version)
+			update.Content, update.Conflicts = withLineEndings([]byte(joinLines(merged)), current), conflicts
+			if conflicts > 0 {
+				update.Action, update.Reason = TemplateUpdateConflict, fmt.Sprintf("%d conflicting changes", conflicts)
+			} else {
+				update.Action, update.Reason = TemplateUpdateMerge, "local changes kept"
+			}
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// joinLines is the inverse of splitLines
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Verification steps run after an update
+const (
+	VerifyBuild = "build"
+	VerifyTest  = "test"
+	VerifyNone  = "none"
+)
+
+// verifyCommands are the commands of each verification step. The build output is discarded,
+// which go build ./... would otherwise write for a single main package.
+var verifyCommands = map[string][]string{
+	VerifyBuild: {"go", "build", "-o", os.DevNull, "./..."},
+	VerifyTest:  {"go", "test", "./..."},
+}
+
+// validateVerifySteps checks the steps of --verify
+func validateVerifySteps(steps []string) error {
+	for _, step := range steps {
+		if _, ok := verifyCommands[step]; !ok && step != VerifyNone {
+			return fmt.Errorf("unknown verification step %q. Available steps: %s, %s, %s", step, VerifyBuild, VerifyTest, VerifyNone)
+		}
+	}
+	return nil
+}
+
+// verificationEnabled reports whether --verify asks for any step
+func verificationEnabled(steps []string) bool {
+	for _, step := range steps {
+		if step != VerifyNone {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedUpdate runs update in the current directory, then checks that the service still
+// builds and passes its tests. When verification fails, the files the update changed are put
+// back as they were, with --revert or once the user agrees.
+func verifiedUpdate(check bool, update func() error) error {
+	if check || !verificationEnabled(updateVerify) {
+		return update()
+	}
+
+	before := snapshotServiceFiles(".")
+	updateErr := update()
+	changed := changedPaths(before, snapshotServiceFiles("."))
+	if len(changed) == 0 {
+		return updateErr
+	}
+
+	fmt.Println("\nVerifying the update...")
+	verifyErr := runVerification(updateVerify)
+	if verifyErr == nil {
+		fmt.Println("✓ Update verified")
+		return updateErr
+	}
+	fmt.Printf("✗ Verification failed: %v\n", verifyErr)
+
+	fmt.Println("Files changed by the update:")
+	for _, path := range changed {
+		fmt.Printf("  %s\n", path)
+	}
+	if !updateRevert && !(isInteractive() && confirm("Revert the update?")) {
+		return &ValidationFailure{fmt.Errorf("update verification failed (rerun with --revert to restore the previous files): %w", verifyErr)}
+	}
+
+	if err := restoreServiceFiles(before, changed); err != nil {
+		return fmt.Errorf("update verification failed and the revert failed: %w", err)
+	}
+	fmt.Printf("✓ Reverted %d files\n", len(changed))
+	return &ValidationFailure{fmt.Errorf("update reverted, verification failed: %w", verifyErr)}
+}
+
+// runVerification runs the verification steps in order and stops at the first failure
+func runVerification(steps []string) error {
+	for _, step := range steps {
+		command, ok := verifyCommands[step]
+		if !ok {
+			continue
+		}
+		fmt.Printf("Running %s\n", strings.Join(command, " "))
+		output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+		if err != nil {
+			if len(output) > 0 {
+				fmt.Print(string(output))
+			}
+			return fmt.Errorf("%s: %w", strings.Join(command, " "), err)
+		}
+		fmt.Printf("✓ %s passed\n", step)
+	}
+	return nil
+}
+
+// restoreServiceFiles puts the paths of a snapshot back as they were, removing those that did
+// not exist
+func restoreServiceFiles(snapshot map[string]string, paths []string) error {
+	for _, path := range paths {
+		content, existed := snapshot[path]
+		if !existed {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+	return nil
+}
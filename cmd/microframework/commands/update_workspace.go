@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+	"golang.org/x/mod/modfile"
+)
+
+// serviceUpdateResult is the outcome of updating one service of a workspace
+type serviceUpdateResult struct {
+	Service string
+	Err     error
+	Diffs   []string
+}
+
+// workspaceServices returns the directories of the go.work in the current directory that hold
+// a service
+func workspaceServices() ([]string, error) {
+	content, err := os.ReadFile("go.work")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.work: %w", err)
+	}
+	work, err := modfile.ParseWork("go.work", content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.work: %w", err)
+	}
+
+	var services []string
+	for _, use := range work.Use {
+		dir := filepath.Clean(use.Path)
+		if isMicroserviceDirectory(dir) {
+			services = append(services, dir)
+		}
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// isMicroserviceDirectory reports whether dir has the layout checkMicroserviceDirectory expects
+func isMicroserviceDirectory(dir string) bool {
+	for _, name := range []string{"go.mod", "cmd", "internal", "configs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// updateWorkspace applies an update to every service of the workspace and reports, per
+// service, whether it succeeded and what it changed. The CLI, shared by all services, is
+// updated once.
+func updateWorkspace(updateType, version string, check, force bool) error {
+	services, err := workspaceServices()
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("go.work lists no services")
+	}
+	fmt.Printf("Updating %d services of the workspace\n", len(services))
+
+	var results []serviceUpdateResult
+	for _, service := range services {
+		fmt.Printf("\n=== %s ===\n", service)
+
+		before := snapshotServiceFiles(service)
+		err := inDirectory(service, func() error {
+			return verifiedUpdate(check, func() error {
+				if updateType == "all" {
+					return errors.Join(updateService(version, check, force)...)
+				}
+				return runUpdateType(updateType, version, check, force)
+			})
+		})
+		after := snapshotServiceFiles(service)
+
+		result := serviceUpdateResult{Service: service, Err: err}
+		for _, path := range changedPaths(before, after) {
+			if diff := unifiedDiff(before[path], after[path], path, path); diff != "" {
+				result.Diffs = append(result.Diffs, diff)
+			}
+		}
+		results = append(results, result)
+	}
+
+	var cliErr error
+	if updateType == "all" {
+		fmt.Println("\n=== CLI ===")
+		cliErr = updateCLI(version, check, force)
+	}
+
+	return reportWorkspaceUpdate(results, cliErr)
+}
+
+// inDirectory runs fn with dir as the working directory
+func inDirectory(dir string, fn func() error) error {
+	previous, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(previous)
+	return fn()
+}
+
+// snapshotServiceFiles reads the files of a service that updates change: go.mod, go.sum, the
+// configuration, the lock file and the generated files
+func snapshotServiceFiles(service string) map[string]string {
+	paths := []string{"go.mod", "go.sum", projectLockFile}
+	configs, _ := filepath.Glob(filepath.Join(service, "configs", "*.y*ml"))
+	for _, config := range configs {
+		rel, _ := filepath.Rel(service, config)
+		paths = append(paths, rel)
+	}
+	if manifest, err := generator.LoadManifest(service); err == nil {
+		paths = append(paths, manifest.Paths()...)
+	}
+
+	snapshot := make(map[string]string)
+	for _, path := range paths {
+		full := filepath.Join(service, filepath.FromSlash(path))
+		if content, err := os.ReadFile(full); err == nil {
+			snapshot[filepath.ToSlash(full)] = string(content)
+		}
+	}
+	return snapshot
+}
+
+// changedPaths returns the paths whose contents differ between two snapshots, sorted
+func changedPaths(before, after map[string]string) []string {
+	var paths []string
+	for path, content := range after {
+		if previous, ok := before[path]; !ok || previous != content {
+			paths = append(paths, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// reportWorkspaceUpdate prints the consolidated report of a workspace update
+func reportWorkspaceUpdate(results []serviceUpdateResult, cliErr error) error {
+	fmt.Println("\nWorkspace update report:")
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("  ✗ %s: %v\n", result.Service, result.Err)
+		case len(result.Diffs) == 0:
+			fmt.Printf("  ✓ %s (no changes)\n", result.Service)
+		default:
+			fmt.Printf("  ✓ %s (%d files changed)\n", result.Service, len(result.Diffs))
+		}
+	}
+	if cliErr != nil {
+		fmt.Printf("  ✗ CLI: %v\n", cliErr)
+	}
+
+	for _, result := range results {
+		if len(result.Diffs) == 0 {
+			continue
+		}
+		fmt.Printf("\nChanges in %s:\n", result.Service)
+		for _, diff := range result.Diffs {
+			fmt.Print(diff)
+		}
+	}
+
+	if failed > 0 || cliErr != nil {
+		return fmt.Errorf("update failed for %d of %d services", failed, len(results))
+	}
+	fmt.Printf("\n✓ Updated %d services\n", len(results))
+	return nil
+}
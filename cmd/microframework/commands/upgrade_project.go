@@ -0,0 +1,545 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator"
+)
+
+var (
+	upgradeProjectTo     string
+	upgradeProjectDryRun bool
+	upgradeProjectForce  bool
+)
+
+// upgradeProjectCmd represents the upgrade-project command
+var upgradeProjectCmd = &cobra.Command{
+	Use:   "upgrade-project",
+	Short: "Migrate the project to the layout of a newer framework major",
+	Long: `Migrate the structure of the project to the layout and APIs of a newer framework major,
+with the codemods of each major between the project's layout and --to:
+
+  imports  import paths of moved or renamed modules and packages, in the Go files and go.mod
+  calls    functions of the framework and go-micro-libs that were renamed
+  moves    files the layout moved, such as the main package
+
+The changes are shown as a diff with --dry-run. What the codemods cannot do is listed as
+manual steps at the end, with the files still mentioning moved paths. The layout the project
+follows is recorded in .microframework.lock.
+
+The working tree must have no uncommitted changes, so that the upgrade can be reviewed and
+reverted with git; --force skips the check.
+
+Examples:
+  microframework upgrade-project --dry-run
+  microframework upgrade-project --to v2`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUpgradeProject(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	upgradeProjectCmd.Flags().StringVar(&upgradeProjectTo, "to", "", "Framework major to upgrade to (default: the latest this CLI knows)")
+	upgradeProjectCmd.Flags().BoolVar(&upgradeProjectDryRun, "dry-run", false, "Show the changes without making them")
+	upgradeProjectCmd.Flags().BoolVar(&upgradeProjectForce, "force", false, "Upgrade a working tree with uncommitted changes")
+}
+
+// projectUpgrade lists the structural changes of a framework major to the projects built on it
+type projectUpgrade struct {
+	Version     string
+	Description string
+	Imports     []importRewrite
+	Calls       []callRewrite
+	Moves       []fileMove
+	// Manual lists the steps left to do by hand
+	Manual []string
+}
+
+// importRewrite moves the import paths under From to To. A module path also moves the
+// requirement of go.mod, to the version of the upgrade.
+type importRewrite struct {
+	From string
+	To   string
+}
+
+// callRewrite renames the function Name of the package Package to To
+type callRewrite struct {
+	Package string
+	Name    string
+	To      string
+}
+
+// fileMove moves a file of the project; {service} in To is the service name
+type fileMove struct {
+	From        string
+	To          string
+	Description string
+}
+
+// projectUpgradeManifest holds the structural changes of every framework major, oldest first
+var projectUpgradeManifest = []projectUpgrade{
+	{
+		Version:     "v2.0.0",
+		Description: "go-micro-libs and the framework are v2 modules; each service has its main under cmd/<service>",
+		Imports: []importRewrite{
+			{From: "github.com/anasamu/go-micro-libs", To: "github.com/anasamu/go-micro-libs/v2"},
+			{From: "github.com/anasamu/go-micro-framework", To: "github.com/anasamu/go-micro-framework/v2"},
+		},
+		Calls: []callRewrite{
+			{Package: "github.com/anasamu/go-micro-libs/logging", Name: "NewLoggingManager", To: "NewManager"},
+			{Package: "github.com/anasamu/go-micro-libs/monitoring", Name: "NewMonitoringManager", To: "NewManager"},
+		},
+		Moves: []fileMove{
+			{From: "cmd/main.go", To: "cmd/{service}/main.go", Description: "so that the services of a module can share cmd/"},
+		},
+		Manual: []string{
+			"Run go mod tidy to download the v2 modules",
+			"Run microframework update --type config to apply the configuration changes of v2",
+		},
+	},
+}
+
+// defaultProjectLayout is the layout of projects that upgrade-project never upgraded
+const defaultProjectLayout = "v1.0.0"
+
+// projectUpgradePlan is the result of the codemods, applied to the project when it is written
+type projectUpgradePlan struct {
+	// files holds the new content of the changed files, by their path after the moves
+	files map[string][]byte
+	// original holds the content of the changed files before the upgrade
+	original map[string][]byte
+	moves    []fileMove
+	moved    map[string]bool
+	manual   []string
+}
+
+func runUpgradeProject(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat("go.mod"); err != nil {
+		return fmt.Errorf("no go.mod; run microframework upgrade-project from the root of the project")
+	}
+	lock, err := loadProjectLock(".")
+	if err != nil {
+		return err
+	}
+	current := defaultProjectLayout
+	if lock != nil && lock.Layout != "" {
+		current = lock.Layout
+	}
+
+	target := projectUpgradeManifest[len(projectUpgradeManifest)-1].Version
+	if upgradeProjectTo != "" {
+		target = upgradeProjectTo
+		if !strings.HasPrefix(target, "v") {
+			target = "v" + target
+		}
+		if !semver.IsValid(target) {
+			return &UserError{fmt.Errorf("invalid --to %q", upgradeProjectTo)}
+		}
+	}
+
+	var upgrades []projectUpgrade
+	for _, upgrade := range projectUpgradeManifest {
+		if semver.Compare(upgrade.Version, current) > 0 && semver.Compare(semver.Major(upgrade.Version), semver.Major(target)) <= 0 {
+			upgrades = append(upgrades, upgrade)
+		}
+	}
+	if len(upgrades) == 0 {
+		fmt.Printf("✓ The project follows the %s layout already\n", semver.Major(current))
+		return nil
+	}
+
+	if !upgradeProjectDryRun && !upgradeProjectForce {
+		if status, err := exec.Command("git", "status", "--porcelain").Output(); err == nil && len(status) > 0 {
+			return fmt.Errorf("the working tree has uncommitted changes; commit them first, or use --force")
+		}
+	}
+
+	manifest, err := generator.LoadManifest(".")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	serviceName := path.Base(currentModulePath())
+	if manifest != nil {
+		serviceName = manifest.Config.ServiceName
+	}
+
+	fmt.Printf("Upgrading the project from the %s layout to %s\n", semver.Major(current), semver.Major(upgrades[len(upgrades)-1].Version))
+	plan := &projectUpgradePlan{files: make(map[string][]byte), original: make(map[string][]byte), moved: make(map[string]bool)}
+	for _, upgrade := range upgrades {
+		fmt.Printf("\n%s: %s\n", upgrade.Version, upgrade.Description)
+		if err := plan.apply(upgrade, serviceName); err != nil {
+			return err
+		}
+	}
+
+	if upgradeProjectDryRun {
+		for _, path := range sortedKeys(plan.files) {
+			from := path
+			for _, move := range plan.moves {
+				if move.To == path {
+					from = move.From
+				}
+			}
+			fmt.Printf("\n%s", unifiedDiff(string(plan.original[path]), string(plan.files[path]), from, path))
+		}
+	} else {
+		if err := plan.write(); err != nil {
+			return err
+		}
+		if err := recordUpgrade(lock, manifest, plan, upgrades[len(upgrades)-1].Version); err != nil {
+			return err
+		}
+	}
+
+	plan.manual = append(plan.manual, movedPathMentions(plan)...)
+	if len(plan.manual) > 0 {
+		fmt.Println("\nManual steps remaining:")
+		for i, step := range plan.manual {
+			fmt.Printf("  %d. %s\n", i+1, step)
+		}
+	}
+
+	if upgradeProjectDryRun {
+		fmt.Println("\nDry run: nothing was changed")
+		return nil
+	}
+	fmt.Printf("\n✓ Upgraded the project to the %s layout; review the changes with git diff\n", semver.Major(upgrades[len(upgrades)-1].Version))
+	return nil
+}
+
+// read returns the content of a file as the upgrade left it so far
+func (p *projectUpgradePlan) read(path string) ([]byte, error) {
+	if content, ok := p.files[path]; ok {
+		return content, nil
+	}
+	if p.moved[path] {
+		return nil, fs.ErrNotExist
+	}
+	return os.ReadFile(filepath.FromSlash(path))
+}
+
+// change records the new content of a file
+func (p *projectUpgradePlan) change(path string, content []byte) {
+	if _, ok := p.original[path]; !ok {
+		p.original[path], _ = os.ReadFile(filepath.FromSlash(path))
+	}
+	p.files[path] = content
+}
+
+// goFiles returns the Go files of the project as the upgrade left them so far
+func (p *projectUpgradePlan) goFiles() ([]string, error) {
+	files, err := goSourceFiles("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files: %w", err)
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if !p.moved[file] {
+			seen[file] = true
+			paths = append(paths, file)
+		}
+	}
+	for path := range p.files {
+		if strings.HasSuffix(path, ".go") && !seen[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// apply runs the codemods of an upgrade: the moves first, so that the rewrites see the files
+// where they end up, then the calls and imports of the Go files, then go.mod
+func (p *projectUpgradePlan) apply(upgrade projectUpgrade, serviceName string) error {
+	for _, move := range upgrade.Moves {
+		to := strings.ReplaceAll(move.To, "{service}", serviceName)
+		content, err := p.read(move.From)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", move.From, err)
+		}
+		if _, err := p.read(to); err == nil {
+			p.manual = append(p.manual, fmt.Sprintf("Move %s to %s %s; %s exists already", move.From, to, move.Description, to))
+			continue
+		}
+		fmt.Printf("  %-8s %s -> %s\n", "move", move.From, to)
+
+		from := move.From
+		for i, previous := range p.moves {
+			// A file moved by an earlier upgrade moves again from where it was
+			if previous.To == move.From {
+				from = previous.From
+				p.moves = append(p.moves[:i], p.moves[i+1:]...)
+				break
+			}
+		}
+		p.moves = append(p.moves, fileMove{From: from, To: to, Description: move.Description})
+		p.moved[move.From] = true
+		delete(p.files, move.From)
+		if original, ok := p.original[move.From]; ok {
+			p.original[to] = original
+			delete(p.original, move.From)
+		} else {
+			p.original[to], _ = os.ReadFile(filepath.FromSlash(from))
+		}
+		p.files[to] = content
+	}
+
+	files, err := p.goFiles()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		src, err := p.read(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		out, imports, calls, err := rewriteGoFile(file, src, upgrade)
+		if err != nil {
+			// Files that do not parse cannot be rewritten; the build reports them
+			p.manual = append(p.manual, fmt.Sprintf("Apply the %s codemods to %s, which does not parse: %v", semver.Major(upgrade.Version), file, err))
+			continue
+		}
+		if imports+calls == 0 {
+			continue
+		}
+		fmt.Printf("  %-8s %s (%d imports, %d calls)\n", "rewrite", file, imports, calls)
+		p.change(file, out)
+	}
+
+	if err := p.rewriteGoMod(upgrade); err != nil {
+		return err
+	}
+	p.manual = append(p.manual, upgrade.Manual...)
+	return nil
+}
+
+// rewriteGoFile applies the import and call rewrites of an upgrade to a Go file, editing the
+// source in place so that its formatting and comments are kept
+func rewriteGoFile(filename string, src []byte, upgrade projectUpgrade) ([]byte, int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	imports, calls := 0, 0
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = importPath
+
+		for _, rewrite := range upgrade.Imports {
+			if !inModules(importPath, []string{rewrite.From}) || inModules(importPath, []string{rewrite.To}) {
+				continue
+			}
+			edits = append(edits, edit{
+				start: fset.Position(spec.Path.Pos()).Offset,
+				end:   fset.Position(spec.Path.End()).Offset,
+				text:  strconv.Quote(rewrite.To + strings.TrimPrefix(importPath, rewrite.From)),
+			})
+			imports++
+			break
+		}
+	}
+
+	if len(upgrade.Calls) > 0 {
+		ast.Inspect(file, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			ident, ok := selector.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			for _, rewrite := range upgrade.Calls {
+				if names[ident.Name] == rewrite.Package && selector.Sel.Name == rewrite.Name {
+					edits = append(edits, edit{
+						start: fset.Position(selector.Sel.Pos()).Offset,
+						end:   fset.Position(selector.Sel.End()).Offset,
+						text:  rewrite.To,
+					})
+					calls++
+				}
+			}
+			return true
+		})
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out, imports, calls, nil
+}
+
+// rewriteGoMod moves the requirements of the modules an upgrade renames to their new path, at
+// the version of the upgrade. The lines are edited in place, keeping the comments of go.mod.
+func (p *projectUpgradePlan) rewriteGoMod(upgrade projectUpgrade) error {
+	content, err := p.read("go.mod")
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	file, err := modfile.Parse("go.mod", content, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	out := append([]byte(nil), content...)
+	// The requirements are visited last first, so that the offsets of the others stay valid
+	for i := len(file.Require) - 1; i >= 0; i-- {
+		require := file.Require[i]
+		for _, rewrite := range upgrade.Imports {
+			if require.Mod.Path != rewrite.From {
+				continue
+			}
+			line := rewrite.To + " " + upgrade.Version
+			if require.Syntax.Token[0] == "require" {
+				line = "require " + line
+			}
+			start, end := require.Syntax.Start.Byte, require.Syntax.End.Byte
+			out = append(out[:start], append([]byte(line), out[end:]...)...)
+			fmt.Printf("  %-8s go.mod (%s -> %s %s)\n", "require", rewrite.From, rewrite.To, upgrade.Version)
+			break
+		}
+	}
+	if string(out) != string(content) {
+		p.change("go.mod", out)
+	}
+	return nil
+}
+
+// write makes the moves and writes the changed files
+func (p *projectUpgradePlan) write() error {
+	for _, move := range p.moves {
+		if err := os.MkdirAll(filepath.Dir(filepath.FromSlash(move.To)), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(move.To), err)
+		}
+		if err := os.Rename(filepath.FromSlash(move.From), filepath.FromSlash(move.To)); err != nil {
+			return fmt.Errorf("failed to move %s: %w", move.From, err)
+		}
+	}
+	for _, path := range sortedKeys(p.files) {
+		if err := os.WriteFile(filepath.FromSlash(path), p.files[path], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// recordUpgrade records the layout in the lock file, and the moves in the generation manifest:
+// its files are tracked where they moved and the main package follows the main
+func recordUpgrade(lock *projectLock, manifest *generator.Manifest, plan *projectUpgradePlan, layout string) error {
+	if manifest != nil && len(plan.moves) > 0 {
+		mainPackage := manifest.Config.MainPackage
+		if mainPackage == "" {
+			mainPackage = "./cmd"
+		}
+		for _, move := range plan.moves {
+			if checksum, ok := manifest.Files[move.From]; ok {
+				base, err := generator.ReadBase(".", move.From)
+				if err != nil {
+					return fmt.Errorf("failed to read the generated %s: %w", move.From, err)
+				}
+				if err := generator.WriteBase(".", move.To, base); err != nil {
+					return err
+				}
+				os.Remove(filepath.Join(generator.BaseDir, filepath.FromSlash(move.From)))
+				delete(manifest.Files, move.From)
+				// The inputs are those of the file where it was generated
+				delete(manifest.Inputs, move.From)
+				manifest.Files[move.To] = checksum
+			}
+			if path.Ext(move.From) == ".go" && packagePath(path.Dir(move.From)) == mainPackage {
+				manifest.Config.MainPackage = packagePath(path.Dir(move.To))
+			}
+		}
+		if err := manifest.Save("."); err != nil {
+			return fmt.Errorf("failed to write %s: %w", generator.ManifestFile, err)
+		}
+	}
+
+	if lock == nil {
+		lock = &projectLock{CLI: version}
+	}
+	lock.Layout = layout
+	if content, ok := plan.files["go.mod"]; ok {
+		if file, err := modfile.ParseLax("go.mod", content, nil); err == nil {
+			for _, require := range file.Require {
+				if inModules(require.Mod.Path, []string{"github.com/anasamu/go-micro-libs"}) {
+					lock.GoMicroLibs = require.Mod.Version
+				}
+			}
+		}
+	}
+	return lock.save(".")
+}
+
+// movedPathMentions returns a manual step for each file that still mentions a moved path
+func movedPathMentions(plan *projectUpgradePlan) []string {
+	if len(plan.moves) == 0 {
+		return nil
+	}
+
+	var steps []string
+	filepath.WalkDir(".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if file != "." && (name == "vendor" || name == "node_modules" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		file = filepath.ToSlash(file)
+		content, err := plan.read(file)
+		if err != nil {
+			return nil
+		}
+		for _, move := range plan.moves {
+			if file != move.To && strings.Contains(string(content), move.From) {
+				steps = append(steps, fmt.Sprintf("%s mentions %s, which moved to %s", file, move.From, move.To))
+			}
+		}
+		return nil
+	})
+	if len(steps) > 0 {
+		steps = append(steps, "microframework update --type templates regenerates the scaffolded files among them")
+	}
+	return steps
+}
@@ -1,15 +1,27 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/imports"
 )
 
 var (
-	validateType string
-	validateFile string
-	validateFix  bool
+	validateType          string
+	validateFile          string
+	validateFix           bool
+	validateDenyLicenses  []string
+	validateLicenseReport string
+	validateWriteBaseline bool
+	validateBaselineFile  string
+	validateFailOn        string
 )
 
 // validateCmd represents the validate command
@@ -25,32 +37,65 @@ This command performs various validation checks:
 - Security validation
 - Performance validation
 - Best practices validation
+- Migration SQL validation against the configured database dialect
+- Deployment asset validation (Dockerfile, Kubernetes manifests)
+- Dependency license audit (run explicitly with --type licenses)
 
 Examples:
   microframework validate
   microframework validate --type config
   microframework validate --type code
   microframework validate --type security
-  microframework validate --fix`,
-	RunE: runValidate,
+  microframework validate --type architecture
+  microframework validate --type migrations
+  microframework validate --type deployment
+  microframework validate --type licenses --report licenses.json
+  microframework validate --write-baseline
+  microframework validate --fail-on warn
+  microframework validate --fix
+
+Findings have a severity of info, warning, error or critical. Only findings at or
+above --fail-on fail the run; the others are still reported.
+
+Exit codes:
+  0  no finding reached the --fail-on threshold
+  2  invalid flags, or not run from a microservice directory
+  3  a tool a check needs is not installed
+  6  findings reached the threshold, or a check failed`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runValidate(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
 }
 
 func init() {
-	validateCmd.Flags().StringVarP(&validateType, "type", "t", "all", "Type of validation (all, config, code, security, performance, best-practices)")
+	validateCmd.Flags().StringVarP(&validateType, "type", "t", "all", "Type of validation (all, config, code, security, performance, best-practices, architecture, migrations, deployment, licenses)")
 	validateCmd.Flags().StringVarP(&validateFile, "file", "f", "", "Specific file to validate")
 	validateCmd.Flags().BoolVar(&validateFix, "fix", false, "Attempt to fix issues automatically where possible")
+	validateCmd.Flags().StringSliceVar(&validateDenyLicenses, "deny-licenses", []string{}, "Licenses to reject, overriding "+licensePolicyFile+" (comma-separated SPDX identifiers)")
+	validateCmd.Flags().StringVar(&validateLicenseReport, "report", "", "Write the license report to this file (.json or .csv)")
+	validateCmd.Flags().BoolVar(&validateWriteBaseline, "write-baseline", false, "Record the current findings in the baseline file instead of reporting them")
+	validateCmd.Flags().StringVar(&validateBaselineFile, "baseline", defaultBaselineFile, "Baseline file of accepted findings")
+	validateCmd.Flags().StringVar(&validateFailOn, "fail-on", SeverityError, "Lowest severity that fails validation (info, warn, error, critical, none)")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
 	// Check if we're in a microservice directory
 	if err := checkMicroserviceDirectory(); err != nil {
-		return err
+		return &UserError{err}
 	}
 
 	// Validate the validation type
 	if err := validateValidationType(validateType); err != nil {
-		return fmt.Errorf("invalid validation type: %w", err)
+		return &UserError{fmt.Errorf("invalid validation type: %w", err)}
+	}
+
+	failOn, err := parseFailOn(validateFailOn)
+	if err != nil {
+		return &UserError{err}
 	}
+	validateFailOn = failOn
 
 	fmt.Printf("Validating microservice (type: %s)\n", validateType)
 
@@ -62,28 +107,84 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		fmt.Println("Auto-fix mode enabled")
 	}
 
-	// Perform validation based on type
-	switch validateType {
+	err = validateByType(validateType, validateFile, validateFix)
+	reportResult(newValidateResult())
+
+	if validateWriteBaseline {
+		if writeErr := writeBaseline(validateBaselineFile); writeErr != nil {
+			return fmt.Errorf("failed to write baseline: %w", writeErr)
+		}
+		reportFiles(validateBaselineFile)
+		fmt.Printf("Baseline written to %s with %d findings\n", validateBaselineFile, len(baselineRecorded))
+		return err
+	}
+	if baselineMatched > 0 {
+		fmt.Printf("%d known findings hidden by baseline %s\n", baselineMatched, validateBaselineFile)
+	}
+	printFindingSummary()
+
+	return err
+}
+
+// validateByType performs the validation of a type in the current directory
+func validateByType(validationType, file string, fix bool) error {
+	var err error
+	switch validationType {
 	case "all":
-		return validateAll(validateFile, validateFix)
+		err = validateAll(file, fix)
 	case "config":
-		return validateConfig(validateFile, validateFix)
+		err = validateConfig(file, fix)
 	case "code":
-		return validateCode(validateFile, validateFix)
+		err = validateCode(file, fix)
 	case "security":
-		return validateSecurity(validateFile, validateFix)
+		err = validateSecurity(file, fix)
 	case "performance":
-		return validatePerformance(validateFile, validateFix)
+		err = validatePerformance(file, fix)
 	case "best-practices":
-		return validateBestPractices(validateFile, validateFix)
+		err = validateBestPractices(file, fix)
+	case "architecture":
+		err = validateArchitecture(file, fix)
+	case "migrations":
+		err = validateMigrations(file, fix)
+	case "deployment":
+		err = validateDeployment(file, fix)
+	case "licenses":
+		err = validateLicenses(file, fix)
 	default:
-		return fmt.Errorf("unknown validation type: %s", validateType)
+		err = &UserError{fmt.Errorf("unknown validation type: %s", validationType)}
+	}
+	if category, _ := errorCategory(err); err != nil && category == ErrorCategoryInternal {
+		// A check that could not run fails validation as its findings would
+		err = &ValidationFailure{err}
+	}
+	return err
+}
+
+// newValidateResult returns the result of the validation run so far
+func newValidateResult() validateResult {
+	return validateResult{
+		Type:           validateType,
+		FailOn:         validateFailOn,
+		Findings:       append([]ValidationIssue{}, reportedFindings...),
+		Counts:         findingCounts,
+		BaselineHidden: baselineMatched,
 	}
 }
 
+// resetValidation forgets the findings and baseline matches of a previous validation run, for
+// validations run one after the other in the same process
+func resetValidation() {
+	findingCounts = map[string]int{}
+	reportedFindings = nil
+	baselineRemaining = nil
+	baselineRecorded = nil
+	baselineMatched = 0
+	sourceLines = map[string][]string{}
+}
+
 // validateValidationType validates the validation type
 func validateValidationType(validationType string) error {
-	validTypes := []string{"all", "config", "code", "security", "performance", "best-practices"}
+	validTypes := []string{"all", "config", "code", "security", "performance", "best-practices", "architecture", "migrations", "deployment", "licenses"}
 
 	for _, valid := range validTypes {
 		if validationType == valid {
@@ -130,6 +231,24 @@ func validateAll(file string, fix bool) error {
 		errors = append(errors, err)
 	}
 
+	// Validate architecture layering
+	fmt.Println("Validating architecture...")
+	if err := validateArchitecture(file, fix); err != nil {
+		errors = append(errors, err)
+	}
+
+	// Validate migrations against the configured database dialect
+	fmt.Println("Validating migrations...")
+	if err := validateMigrations(file, fix); err != nil {
+		errors = append(errors, err)
+	}
+
+	// Validate deployment assets
+	fmt.Println("Validating deployment assets...")
+	if err := validateDeployment(file, fix); err != nil {
+		errors = append(errors, err)
+	}
+
 	// Report results
 	if len(errors) > 0 {
 		fmt.Printf("\nValidation completed with %d errors:\n", len(errors))
@@ -161,14 +280,13 @@ func validateConfig(file string, fix bool) error {
 		}
 	}
 
-	// Validate configuration syntax
-	if err := validateConfigSyntax(); err != nil {
-		return fmt.Errorf("configuration syntax validation failed: %w", err)
+	// Validate the configuration files against their schemas
+	issues, err := validateConfigSchemas(file, fix)
+	if err != nil {
+		return fmt.Errorf("failed to validate configuration files: %w", err)
 	}
-
-	// Validate configuration values
-	if err := validateConfigValues(); err != nil {
-		return fmt.Errorf("configuration values validation failed: %w", err)
+	if err := reportIssues("configuration issues", issues); err != nil {
+		return err
 	}
 
 	fmt.Println("✓ Configuration validation passed")
@@ -207,10 +325,10 @@ func validateCode(file string, fix bool) error {
 	}
 
 	// Validate code formatting
-	if err := validateCodeFormatting(); err != nil {
+	if err := validateCodeFormatting(file); err != nil {
 		if fix {
 			fmt.Println("Fixing code formatting...")
-			if err := fixCodeFormatting(); err != nil {
+			if err := fixCodeFormatting(file); err != nil {
 				return fmt.Errorf("failed to fix code formatting: %w", err)
 			}
 		} else {
@@ -219,10 +337,10 @@ func validateCode(file string, fix bool) error {
 	}
 
 	// Validate imports
-	if err := validateImports(); err != nil {
+	if err := validateImports(file); err != nil {
 		if fix {
 			fmt.Println("Fixing imports...")
-			if err := fixImports(); err != nil {
+			if err := fixImports(file); err != nil {
 				return fmt.Errorf("failed to fix imports: %w", err)
 			}
 		} else {
@@ -278,38 +396,13 @@ func validateSecurity(file string, fix bool) error {
 func validatePerformance(file string, fix bool) error {
 	fmt.Println("Validating performance...")
 
-	// Check for performance issues
-	if err := validatePerformanceIssues(); err != nil {
-		return fmt.Errorf("performance issues found: %w", err)
-	}
-
-	// Check for inefficient database queries
-	if err := validateDatabaseQueries(); err != nil {
-		if fix {
-			fmt.Println("Optimizing database queries...")
-			if err := optimizeDatabaseQueries(); err != nil {
-				return fmt.Errorf("failed to optimize database queries: %w", err)
-			}
-		} else {
-			return fmt.Errorf("inefficient database queries found: %w", err)
-		}
-	}
-
-	// Check for memory leaks
-	if err := validateMemoryUsage(); err != nil {
-		return fmt.Errorf("memory usage issues found: %w", err)
+	// Run the configured performance heuristics (see .microframework-rules.yaml)
+	issues, err := runRules(RuleCategoryPerformance, file)
+	if err != nil {
+		return fmt.Errorf("failed to run performance rules: %w", err)
 	}
-
-	// Check for connection pooling
-	if err := validateConnectionPooling(); err != nil {
-		if fix {
-			fmt.Println("Adding connection pooling...")
-			if err := addConnectionPooling(); err != nil {
-				return fmt.Errorf("failed to add connection pooling: %w", err)
-			}
-		} else {
-			return fmt.Errorf("missing connection pooling: %w", err)
-		}
+	if err := reportIssues("performance issues", issues); err != nil {
+		return err
 	}
 
 	fmt.Println("✓ Performance validation passed")
@@ -319,58 +412,52 @@ func validatePerformance(file string, fix bool) error {
 func validateBestPractices(file string, fix bool) error {
 	fmt.Println("Validating best practices...")
 
-	// Check for proper error handling
-	if err := validateErrorHandling(); err != nil {
-		if fix {
-			fmt.Println("Fixing error handling...")
-			if err := fixErrorHandling(); err != nil {
-				return fmt.Errorf("failed to fix error handling: %w", err)
-			}
-		} else {
-			return fmt.Errorf("error handling issues found: %w", err)
-		}
+	// Run the configured best-practice rules (see .microframework-rules.yaml)
+	issues, err := runRules(RuleCategoryBestPractices, file)
+	if err != nil {
+		return fmt.Errorf("failed to run best-practice rules: %w", err)
 	}
-
-	// Check for proper logging
-	if err := validateLogging(); err != nil {
-		if fix {
-			fmt.Println("Adding proper logging...")
-			if err := addLogging(); err != nil {
-				return fmt.Errorf("failed to add logging: %w", err)
-			}
-		} else {
-			return fmt.Errorf("logging issues found: %w", err)
-		}
+	if err := reportIssues("best-practice issues", issues); err != nil {
+		return err
 	}
 
-	// Check for proper testing
-	if err := validateTesting(); err != nil {
-		if fix {
-			fmt.Println("Adding tests...")
-			if err := addTests(); err != nil {
-				return fmt.Errorf("failed to add tests: %w", err)
-			}
-		} else {
-			return fmt.Errorf("testing issues found: %w", err)
-		}
-	}
+	fmt.Println("✓ Best practices validation passed")
+	return nil
+}
 
-	// Check for proper documentation
-	if err := validateDocumentation(); err != nil {
-		if fix {
-			fmt.Println("Adding documentation...")
-			if err := addDocumentation(); err != nil {
-				return fmt.Errorf("failed to add documentation: %w", err)
-			}
-		} else {
-			return fmt.Errorf("documentation issues found: %w", err)
-		}
+func validateArchitecture(file string, fix bool) error {
+	fmt.Println("Validating architecture layering...")
+
+	issues, err := runRules(RuleCategoryArchitecture, file)
+	if err != nil {
+		return fmt.Errorf("failed to run architecture rules: %w", err)
+	}
+	if err := reportIssues("layering violations", issues); err != nil {
+		return err
 	}
 
-	fmt.Println("✓ Best practices validation passed")
+	fmt.Println("✓ Architecture validation passed")
 	return nil
 }
 
+// printValidationIssues prints each issue with its location and rule
+func printValidationIssues(issues []ValidationIssue) {
+	for _, issue := range issues {
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+		}
+		fixable := ""
+		if issue.Fixable {
+			fixable = " (fixable with --fix)"
+		}
+		fmt.Printf("  [%s] %s: %s (%s)%s\n", issue.Severity, location, issue.Message, issue.Rule, fixable)
+		if issue.Suggestion != "" {
+			fmt.Printf("      suggestion: %s\n", issue.Suggestion)
+		}
+	}
+}
+
 // Helper functions for validation
 func fileExists(filename string) bool {
 	// Implementation would check if file exists
@@ -394,45 +481,142 @@ func createDefaultConfigFile(filename string) error {
 	return nil
 }
 
-func validateConfigSyntax() error {
-	fmt.Println("Validating configuration syntax...")
-	// Implementation would validate YAML/JSON syntax
+func validateGoModule() error {
+	fmt.Println("Validating Go module...")
+	// Implementation would validate go.mod and go.sum
 	return nil
 }
 
-func validateConfigValues() error {
-	fmt.Println("Validating configuration values...")
-	// Implementation would validate config values
-	return nil
+// goSourceFiles returns the Go files to check, limited to file when it is given
+func goSourceFiles(file string) ([]string, error) {
+	if file != "" {
+		if filepath.Ext(file) != ".go" {
+			return nil, nil
+		}
+		return []string{file}, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(".", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != "." && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".go" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
 }
 
-func validateGoModule() error {
-	fmt.Println("Validating Go module...")
-	// Implementation would validate go.mod and go.sum
-	return nil
+// rewriteGoFiles runs transform over each file and returns the files whose content would change;
+// when write is true the changed files are rewritten in place
+func rewriteGoFiles(file string, write bool, transform func(path string, src []byte) ([]byte, error)) ([]string, error) {
+	files, err := goSourceFiles(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files: %w", err)
+	}
+
+	var changed []string
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		out, err := transform(path, src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process %s: %w", path, err)
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+
+		changed = append(changed, path)
+		if write {
+			if err := os.WriteFile(path, out, 0644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+	}
+
+	return changed, nil
 }
 
-func validateCodeFormatting() error {
+func gofmtSource(path string, src []byte) ([]byte, error) {
+	return format.Source(src)
+}
+
+func goimportsSource(path string, src []byte) ([]byte, error) {
+	if imports.LocalPrefix == "" {
+		imports.LocalPrefix = currentModulePath()
+	}
+	return imports.Process(path, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+}
+
+// currentModulePath reads the module path from go.mod
+func currentModulePath() string {
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(content)
+}
+
+func validateCodeFormatting(file string) error {
 	fmt.Println("Validating code formatting...")
-	// Implementation would check code formatting
+
+	unformatted, err := rewriteGoFiles(file, false, gofmtSource)
+	if err != nil {
+		return err
+	}
+	if len(unformatted) > 0 {
+		return fmt.Errorf("%d files are not gofmt-formatted: %s", len(unformatted), strings.Join(unformatted, ", "))
+	}
 	return nil
 }
 
-func fixCodeFormatting() error {
-	fmt.Println("Fixing code formatting...")
-	// Implementation would fix code formatting
+func fixCodeFormatting(file string) error {
+	rewritten, err := rewriteGoFiles(file, true, gofmtSource)
+	if err != nil {
+		return err
+	}
+	for _, path := range rewritten {
+		fmt.Printf("  Formatted %s\n", path)
+	}
+	fmt.Printf("✓ %d files rewritten\n", len(rewritten))
 	return nil
 }
 
-func validateImports() error {
+func validateImports(file string) error {
 	fmt.Println("Validating imports...")
-	// Implementation would validate imports
+
+	unsorted, err := rewriteGoFiles(file, false, goimportsSource)
+	if err != nil {
+		return err
+	}
+	if len(unsorted) > 0 {
+		return fmt.Errorf("%d files have missing, unused or unsorted imports: %s", len(unsorted), strings.Join(unsorted, ", "))
+	}
 	return nil
 }
 
-func fixImports() error {
-	fmt.Println("Fixing imports...")
-	// Implementation would fix imports
+func fixImports(file string) error {
+	rewritten, err := rewriteGoFiles(file, true, goimportsSource)
+	if err != nil {
+		return err
+	}
+	for _, path := range rewritten {
+		fmt.Printf("  Fixed imports in %s\n", path)
+	}
+	fmt.Printf("✓ %d files rewritten\n", len(rewritten))
 	return nil
 }
 
@@ -472,86 +656,33 @@ func addSecurityHeaders() error {
 	return nil
 }
 
-func validatePerformanceIssues() error {
-	fmt.Println("Validating performance issues...")
-	// Implementation would check for performance issues
-	return nil
-}
-
-func validateDatabaseQueries() error {
-	fmt.Println("Validating database queries...")
-	// Implementation would check database queries
-	return nil
-}
-
-func optimizeDatabaseQueries() error {
-	fmt.Println("Optimizing database queries...")
-	// Implementation would optimize database queries
-	return nil
-}
-
-func validateMemoryUsage() error {
-	fmt.Println("Validating memory usage...")
-	// Implementation would check memory usage
-	return nil
-}
-
-func validateConnectionPooling() error {
-	fmt.Println("Validating connection pooling...")
-	// Implementation would check connection pooling
-	return nil
-}
-
-func addConnectionPooling() error {
-	fmt.Println("Adding connection pooling...")
-	// Implementation would add connection pooling
-	return nil
-}
-
-func validateErrorHandling() error {
-	fmt.Println("Validating error handling...")
-	// Implementation would check error handling
-	return nil
-}
-
-func fixErrorHandling() error {
-	fmt.Println("Fixing error handling...")
-	// Implementation would fix error handling
-	return nil
-}
-
-func validateLogging() error {
-	fmt.Println("Validating logging...")
-	// Implementation would check logging
-	return nil
-}
-
-func addLogging() error {
-	fmt.Println("Adding logging...")
-	// Implementation would add logging
-	return nil
-}
-
-func validateTesting() error {
-	fmt.Println("Validating testing...")
-	// Implementation would check testing
-	return nil
-}
-
-func addTests() error {
-	fmt.Println("Adding tests...")
-	// Implementation would add tests
-	return nil
-}
-
-func validateDocumentation() error {
-	fmt.Println("Validating documentation...")
-	// Implementation would check documentation
-	return nil
-}
+// Data structures for validation
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
 
-func addDocumentation() error {
-	fmt.Println("Adding documentation...")
-	// Implementation would add documentation
-	return nil
+// ValidationIssue describes a single problem found during validation
+type ValidationIssue struct {
+	Rule       string `json:"rule"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Fixable    bool   `json:"fixable,omitempty"`
+}
+
+// validateResult is the result of validate in the JSON report
+type validateResult struct {
+	Type   string `json:"type"`
+	FailOn string `json:"fail_on"`
+	// Findings are the findings reported, after the baseline
+	Findings []ValidationIssue `json:"findings"`
+	// Counts are the numbers of findings by severity
+	Counts map[string]int `json:"counts"`
+	// BaselineHidden is the number of known findings the baseline hides
+	BaselineHidden int `json:"baseline_hidden,omitempty"`
 }
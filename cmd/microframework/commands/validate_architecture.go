@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported project layouts for the layering rule
+const (
+	LayoutStandard  = "standard"
+	LayoutHexagonal = "hexagonal"
+)
+
+// layerConstraint forbids packages under From from depending on packages under Forbid.
+// Dependencies are followed transitively, except through packages under Via, which are
+// the layers allowed to depend on Forbid on the caller's behalf.
+type layerConstraint struct {
+	From   []string
+	Forbid []string
+	Via    []string
+	Reason string
+}
+
+// layoutConstraints are the built-in layering constraints of each project layout.
+// Paths are relative to the module root; entries without a "/" prefix are external import paths.
+var layoutConstraints = map[string][]layerConstraint{
+	LayoutStandard: {
+		{
+			From:   []string{"internal/handlers"},
+			Forbid: []string{"internal/repositories"},
+			Via:    []string{"internal/services"},
+			Reason: "handlers must go through the service layer",
+		},
+		{
+			From:   []string{"internal/models"},
+			Forbid: []string{"github.com/gin-gonic/gin"},
+			Reason: "models must not depend on the HTTP framework",
+		},
+		{
+			From:   []string{"pkg"},
+			Forbid: []string{"internal"},
+			Reason: "pkg is importable by other modules and cannot depend on internal packages",
+		},
+	},
+	LayoutHexagonal: {
+		{
+			From:   []string{"internal/core/domain"},
+			Forbid: []string{"internal/core/ports", "internal/core/services", "internal/adapters", "github.com/gin-gonic/gin", "gorm.io/gorm"},
+			Reason: "the domain must not depend on ports, services, adapters or frameworks",
+		},
+		{
+			From:   []string{"internal/core"},
+			Forbid: []string{"internal/adapters"},
+			Reason: "the core must only depend on ports, never on adapters",
+		},
+		{
+			From:   []string{"internal/adapters/handlers", "internal/adapters/http", "internal/adapters/grpc"},
+			Forbid: []string{"internal/adapters/repositories", "internal/adapters/storage"},
+			Via:    []string{"internal/core"},
+			Reason: "driving adapters must reach driven adapters through the core",
+		},
+		{
+			From:   []string{"pkg"},
+			Forbid: []string{"internal"},
+			Reason: "pkg is importable by other modules and cannot depend on internal packages",
+		},
+	},
+}
+
+// importEdge is a single import of a project package, with the position of its import spec
+type importEdge struct {
+	To   string
+	File *goSourceFile
+	Spec *ast.ImportSpec
+}
+
+// checkLayering reports import chains that break the layering of the configured layout
+func checkLayering(project *goProject, rule *validationRule) []ValidationIssue {
+	layout := LayoutStandard
+	if layouts := rule.stringListOption("layout"); len(layouts) > 0 {
+		layout = layouts[0]
+	}
+
+	constraints, ok := layoutConstraints[layout]
+	if !ok {
+		return []ValidationIssue{{
+			Rule:     rule.Name,
+			File:     rulesConfigFile,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown layout %q (available: %s, %s)", layout, LayoutStandard, LayoutHexagonal),
+		}}
+	}
+	constraints = append(constraints, rule.constraintsOption("constraints")...)
+
+	graph := buildImportGraph(project, currentModulePath())
+
+	var packages []string
+	for pkg := range graph {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	var issues []ValidationIssue
+	for _, constraint := range constraints {
+		for _, pkg := range packages {
+			if !pathMatchesAny(pkg, constraint.From) || pathMatchesAny(pkg, constraint.Forbid) {
+				continue
+			}
+			chain := forbiddenImportChain(graph, pkg, constraint)
+			if chain == nil {
+				continue
+			}
+
+			names := []string{pkg}
+			for _, edge := range chain {
+				names = append(names, edge.To)
+			}
+			issue := rule.issue(project, chain[0].Spec.Pos(), "%s depends on %s: %s (%s)",
+				pkg, chain[len(chain)-1].To, strings.Join(names, " -> "), constraint.Reason)
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// buildImportGraph maps each project package directory to the imports of its non-test files.
+// Imports of the module itself are rewritten to directories relative to the module root.
+func buildImportGraph(project *goProject, modulePath string) map[string][]importEdge {
+	graph := map[string][]importEdge{}
+	seen := map[string]bool{}
+
+	for _, file := range project.filesUnder(nil) {
+		if _, ok := graph[file.Dir]; !ok {
+			graph[file.Dir] = nil
+		}
+		for _, spec := range file.AST.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if modulePath != "" && strings.HasPrefix(path, modulePath+"/") {
+				path = strings.TrimPrefix(path, modulePath+"/")
+			}
+
+			key := file.Dir + " " + path
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			graph[file.Dir] = append(graph[file.Dir], importEdge{To: path, File: file, Spec: spec})
+		}
+	}
+
+	return graph
+}
+
+// forbiddenImportChain returns the shortest import chain from pkg to a package forbidden by
+// constraint, or nil when there is none
+func forbiddenImportChain(graph map[string][]importEdge, pkg string, constraint layerConstraint) []importEdge {
+	type step struct {
+		pkg   string
+		chain []importEdge
+	}
+
+	visited := map[string]bool{pkg: true}
+	queue := []step{{pkg: pkg}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range graph[current.pkg] {
+			if visited[edge.To] {
+				continue
+			}
+			visited[edge.To] = true
+
+			chain := append(append([]importEdge{}, current.chain...), edge)
+			if pathMatchesAny(edge.To, constraint.Forbid) {
+				return chain
+			}
+			// Only project packages have known imports; stop at the layers allowed to cross
+			if _, ok := graph[edge.To]; ok && !pathMatchesAny(edge.To, constraint.Via) {
+				queue = append(queue, step{pkg: edge.To, chain: chain})
+			}
+		}
+	}
+
+	return nil
+}
+
+// constraintsOption returns the project-defined layering constraints of a rule
+func (r *validationRule) constraintsOption(key string) []layerConstraint {
+	entries, ok := r.Options[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var constraints []layerConstraint
+	for _, entry := range entries {
+		values, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		option := &validationRule{Options: values}
+		constraint := layerConstraint{
+			From:   option.stringListOption("from"),
+			Forbid: option.stringListOption("forbid"),
+			Via:    option.stringListOption("via"),
+			Reason: fmt.Sprint(values["reason"]),
+		}
+		if values["reason"] == nil {
+			constraint.Reason = "forbidden by " + rulesConfigFile
+		}
+		if len(constraint.From) > 0 && len(constraint.Forbid) > 0 {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints
+}
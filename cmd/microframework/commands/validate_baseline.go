@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultBaselineFile = ".microframework-baseline.json"
+
+// suppressionPattern matches inline suppressions such as
+//
+//	// microframework:ignore error-wrapping -- legacy API returns opaque errors
+//
+// in any comment syntax. The suppression applies to its own line and the line below it.
+var suppressionPattern = regexp.MustCompile(`microframework:ignore\s+([A-Za-z0-9_,\-]+)(?:\s+--\s*(.*))?`)
+
+// Baseline records accepted findings so that only new findings are reported
+type Baseline struct {
+	Findings []BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding identifies an accepted finding; line numbers are left out so that
+// unrelated edits do not invalidate the baseline
+type BaselineFinding struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+var (
+	// baselineRemaining counts the baseline entries not yet matched during this run
+	baselineRemaining map[BaselineFinding]int
+	// baselineRecorded collects the findings written by --write-baseline
+	baselineRecorded []BaselineFinding
+	// baselineMatched is the number of findings hidden by the baseline during this run
+	baselineMatched int
+	// sourceLines caches the lines of files checked for inline suppressions
+	sourceLines = map[string][]string{}
+)
+
+// filterIssues drops findings suppressed inline or accepted in the baseline. With
+// --write-baseline the findings are recorded instead and nothing is reported.
+func filterIssues(issues []ValidationIssue) []ValidationIssue {
+	var remaining []ValidationIssue
+	for _, issue := range issues {
+		suppressed, problem := isSuppressed(issue)
+		if problem != nil {
+			remaining = append(remaining, *problem)
+		}
+		if !suppressed {
+			remaining = append(remaining, issue)
+		}
+	}
+
+	if validateWriteBaseline {
+		for _, issue := range remaining {
+			baselineRecorded = append(baselineRecorded, baselineKey(issue))
+		}
+		return nil
+	}
+
+	if baselineRemaining == nil {
+		baselineRemaining = map[BaselineFinding]int{}
+		baseline, err := loadBaseline(validateBaselineFile)
+		if err != nil {
+			warnf("ignoring baseline %s: %v", validateBaselineFile, err)
+		} else {
+			for _, finding := range baseline.Findings {
+				baselineRemaining[finding]++
+			}
+		}
+	}
+
+	var fresh []ValidationIssue
+	for _, issue := range remaining {
+		key := baselineKey(issue)
+		if baselineRemaining[key] > 0 {
+			baselineRemaining[key]--
+			baselineMatched++
+			continue
+		}
+		fresh = append(fresh, issue)
+	}
+	return fresh
+}
+
+// isSuppressed reports whether an inline comment suppresses issue. A suppression without a
+// justification is not honored and is reported as a finding of its own.
+func isSuppressed(issue ValidationIssue) (bool, *ValidationIssue) {
+	if issue.Line <= 0 || issue.File == "" {
+		return false, nil
+	}
+
+	lines, ok := sourceLines[issue.File]
+	if !ok {
+		content, err := os.ReadFile(issue.File)
+		if err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		sourceLines[issue.File] = lines
+	}
+
+	for _, line := range []int{issue.Line, issue.Line - 1} {
+		if line < 1 || line > len(lines) {
+			continue
+		}
+		match := suppressionPattern.FindStringSubmatch(lines[line-1])
+		if match == nil || !containsString(strings.Split(match[1], ","), issue.Rule) {
+			continue
+		}
+		if strings.TrimSpace(match[2]) == "" {
+			return false, &ValidationIssue{
+				Rule:       "suppression-justification",
+				File:       issue.File,
+				Line:       line,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("suppression of %s has no justification and is ignored", issue.Rule),
+				Suggestion: fmt.Sprintf("explain why, e.g. microframework:ignore %s -- <reason>", issue.Rule),
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func baselineKey(issue ValidationIssue) BaselineFinding {
+	return BaselineFinding{Rule: issue.Rule, File: issue.File, Message: issue.Message}
+}
+
+// loadBaseline reads a baseline file; a missing file is an empty baseline
+func loadBaseline(path string) (*Baseline, error) {
+	baseline := &Baseline{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return baseline, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, baseline); err != nil {
+		return nil, fmt.Errorf("invalid baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// writeBaseline writes the findings recorded during this run to path
+func writeBaseline(path string) error {
+	findings := append([]BaselineFinding{}, baselineRecorded...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Baseline{Findings: findings}); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
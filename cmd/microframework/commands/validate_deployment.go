@@ -0,0 +1,649 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	dockerfileDir     = "deployments/docker"
+	kubernetesDir     = "deployments/kubernetes"
+	defaultHealthPath = "/health"
+	defaultHealthPort = 8080
+)
+
+// workloadKinds lists the Kubernetes kinds that carry a pod template
+var workloadKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"ReplicaSet":  true,
+}
+
+func validateDeployment(file string, fix bool) error {
+	fmt.Println("Validating deployment assets...")
+
+	var issues []ValidationIssue
+
+	// Validate Dockerfiles
+	dockerfiles, err := findDockerfiles(file)
+	if err != nil {
+		return fmt.Errorf("failed to locate Dockerfiles: %w", err)
+	}
+	for _, path := range dockerfiles {
+		found, err := validateDockerfile(path, fix)
+		if err != nil {
+			return fmt.Errorf("failed to validate %s: %w", path, err)
+		}
+		issues = append(issues, found...)
+	}
+
+	// Validate Kubernetes manifests
+	manifests, err := findKubernetesManifests(file)
+	if err != nil {
+		return fmt.Errorf("failed to locate Kubernetes manifests: %w", err)
+	}
+	for _, path := range manifests {
+		found, err := validateKubernetesManifest(path, fix)
+		if err != nil {
+			return fmt.Errorf("failed to validate %s: %w", path, err)
+		}
+		issues = append(issues, found...)
+	}
+
+	if err := reportIssues("deployment issues", issues); err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Deployment validation passed")
+	return nil
+}
+
+// findDockerfiles returns the Dockerfiles to validate
+func findDockerfiles(file string) ([]string, error) {
+	if file != "" {
+		if isDockerfile(file) {
+			return []string{file}, nil
+		}
+		return nil, nil
+	}
+
+	var paths []string
+	for _, candidate := range []string{"Dockerfile", filepath.Join(dockerfileDir, "Dockerfile")} {
+		if _, err := os.Stat(candidate); err == nil {
+			paths = append(paths, candidate)
+		}
+	}
+
+	entries, err := os.ReadDir(dockerfileDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return paths, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() != "Dockerfile" && isDockerfile(entry.Name()) {
+			paths = append(paths, filepath.Join(dockerfileDir, entry.Name()))
+		}
+	}
+
+	return paths, nil
+}
+
+// findKubernetesManifests returns the Kubernetes manifests to validate
+func findKubernetesManifests(file string) ([]string, error) {
+	if file != "" {
+		if isYAMLFile(file) && strings.HasPrefix(filepath.ToSlash(filepath.Clean(file)), kubernetesDir) {
+			return []string{file}, nil
+		}
+		return nil, nil
+	}
+
+	var paths []string
+	err := filepath.WalkDir(kubernetesDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isYAMLFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+func isDockerfile(path string) bool {
+	name := filepath.Base(path)
+	return name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile")
+}
+
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// dockerInstruction is a single logical Dockerfile instruction
+type dockerInstruction struct {
+	Command string
+	Args    string
+	Line    int
+}
+
+// parseDockerfile splits a Dockerfile into instructions, joining continuation lines
+func parseDockerfile(content []byte) []dockerInstruction {
+	var instructions []dockerInstruction
+	var current strings.Builder
+	startLine := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if current.Len() == 0 && (line == "" || strings.HasPrefix(line, "#")) {
+			continue
+		}
+		if current.Len() == 0 {
+			startLine = lineNo
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(line)
+
+		fields := strings.SplitN(strings.TrimSpace(current.String()), " ", 2)
+		instruction := dockerInstruction{Command: strings.ToUpper(fields[0]), Line: startLine}
+		if len(fields) > 1 {
+			instruction.Args = strings.TrimSpace(fields[1])
+		}
+		instructions = append(instructions, instruction)
+		current.Reset()
+	}
+
+	return instructions
+}
+
+// validateDockerfile checks a Dockerfile for non-root user, pinned base images and a healthcheck
+func validateDockerfile(path string, fix bool) ([]ValidationIssue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := parseDockerfile(content)
+	var issues []ValidationIssue
+
+	stages := map[string]bool{}
+	finalStage := 0
+	for i, instruction := range instructions {
+		if instruction.Command != "FROM" {
+			continue
+		}
+		finalStage = i
+
+		image, alias := parseFromArgs(instruction.Args)
+		if alias != "" {
+			stages[strings.ToLower(alias)] = true
+		}
+		if image == "scratch" || stages[strings.ToLower(image)] {
+			continue
+		}
+		if !isPinnedImage(image) {
+			issues = append(issues, ValidationIssue{
+				Rule:     "docker-pinned-base",
+				File:     path,
+				Line:     instruction.Line,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("base image %q is not pinned to a specific tag or digest", image),
+			})
+		}
+	}
+
+	// Only the final stage determines the runtime user and healthcheck
+	user := ""
+	userLine := 0
+	hasHealthcheck := false
+	exposedPort := 0
+	for _, instruction := range instructions[finalStage:] {
+		switch instruction.Command {
+		case "USER":
+			user = strings.Fields(instruction.Args + " ")[0]
+			userLine = instruction.Line
+		case "HEALTHCHECK":
+			hasHealthcheck = !strings.EqualFold(strings.TrimSpace(instruction.Args), "NONE")
+		case "EXPOSE":
+			if exposedPort == 0 {
+				port := strings.SplitN(strings.Fields(instruction.Args + " ")[0], "/", 2)[0]
+				exposedPort, _ = strconv.Atoi(port)
+			}
+		}
+	}
+
+	if user == "" || isRootUser(user) {
+		message := "container runs as root; add a USER instruction with a non-root user"
+		if user != "" {
+			message = fmt.Sprintf("container runs as root user %q", user)
+		}
+		issues = append(issues, ValidationIssue{
+			Rule:     "docker-non-root",
+			File:     path,
+			Line:     userLine,
+			Severity: SeverityError,
+			Message:  message,
+		})
+	}
+
+	if !hasHealthcheck {
+		if fix && exposedPort != 0 {
+			if err := addDockerHealthcheck(path, content, exposedPort); err != nil {
+				return nil, fmt.Errorf("failed to add HEALTHCHECK: %w", err)
+			}
+			fmt.Printf("Added HEALTHCHECK to %s\n", path)
+		} else {
+			issues = append(issues, ValidationIssue{
+				Rule:     "docker-healthcheck",
+				File:     path,
+				Severity: SeverityWarning,
+				Message:  "final stage does not define a HEALTHCHECK",
+				Fixable:  exposedPort != 0,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// parseFromArgs extracts the image reference and optional stage alias from FROM arguments
+func parseFromArgs(args string) (string, string) {
+	var fields []string
+	for _, field := range strings.Fields(args) {
+		if strings.HasPrefix(field, "--") {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return "", ""
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+		return fields[0], fields[2]
+	}
+	return fields[0], ""
+}
+
+// isPinnedImage reports whether an image reference has an explicit, non-latest tag or a digest
+func isPinnedImage(image string) bool {
+	if strings.Contains(image, "@sha256:") {
+		return true
+	}
+	tag := imageTag(image)
+	return tag != "" && tag != "latest" && !strings.Contains(tag, "$")
+}
+
+// imageTag returns the tag of an image reference, or an empty string if it has none
+func imageTag(image string) string {
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+func isRootUser(user string) bool {
+	name := strings.SplitN(user, ":", 2)[0]
+	return name == "root" || name == "0"
+}
+
+// addDockerHealthcheck inserts an HTTP HEALTHCHECK before the final CMD/ENTRYPOINT
+func addDockerHealthcheck(path string, content []byte, port int) error {
+	healthcheck := fmt.Sprintf("# Health check\nHEALTHCHECK --interval=30s --timeout=3s --start-period=5s --retries=3 \\\n  CMD wget --no-verbose --tries=1 --spider http://localhost:%d%s || exit 1\n", port, defaultHealthPath)
+
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	insertAt := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		command := strings.ToUpper(strings.SplitN(strings.TrimSpace(lines[i])+" ", " ", 2)[0])
+		if command == "CMD" || command == "ENTRYPOINT" {
+			insertAt = i
+			break
+		}
+	}
+
+	updated := append([]string{}, lines[:insertAt]...)
+	updated = append(updated, strings.Split(healthcheck, "\n")...)
+	updated = append(updated, lines[insertAt:]...)
+
+	return os.WriteFile(path, withLineEndings([]byte(strings.Join(updated, "\n")), content), 0644)
+}
+
+// validateKubernetesManifest checks every document in a manifest file for schema and policy violations
+func validateKubernetesManifest(path string, fix bool) ([]ValidationIssue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generated manifests may still contain template markers; they cannot be parsed yet
+	if bytes.Contains(content, []byte("{{")) {
+		return nil, nil
+	}
+
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return []ValidationIssue{{
+				Rule:     "k8s-schema",
+				File:     path,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("invalid YAML: %v", err),
+			}}, nil
+		}
+		documents = append(documents, &document)
+	}
+
+	var issues []ValidationIssue
+	modified := false
+	for _, document := range documents {
+		if len(document.Content) == 0 {
+			continue
+		}
+		root := document.Content[0]
+		if root.Kind != yaml.MappingNode {
+			issues = append(issues, ValidationIssue{
+				Rule:     "k8s-schema",
+				File:     path,
+				Line:     root.Line,
+				Severity: SeverityError,
+				Message:  "manifest document is not a mapping",
+			})
+			continue
+		}
+
+		found, changed := validateKubernetesObject(path, root, fix)
+		issues = append(issues, found...)
+		modified = modified || changed
+	}
+
+	if modified {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		for _, document := range documents {
+			if err := encoder.Encode(document); err != nil {
+				return nil, fmt.Errorf("failed to encode manifest: %w", err)
+			}
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write manifest: %w", err)
+		}
+		fmt.Printf("Updated Kubernetes manifest: %s\n", path)
+	}
+
+	return issues, nil
+}
+
+// validateKubernetesObject validates a single Kubernetes object and applies safe fixes
+func validateKubernetesObject(path string, object *yaml.Node, fix bool) ([]ValidationIssue, bool) {
+	var issues []ValidationIssue
+	schemaIssue := func(node *yaml.Node, format string, args ...interface{}) {
+		line := object.Line
+		if node != nil {
+			line = node.Line
+		}
+		issues = append(issues, ValidationIssue{
+			Rule:     "k8s-schema",
+			File:     path,
+			Line:     line,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, field := range []string{"apiVersion", "kind"} {
+		if value := yamlMappingValue(object, field); value == nil || value.Kind != yaml.ScalarNode || value.Value == "" {
+			schemaIssue(value, "missing required field %q", field)
+		}
+	}
+	metadata := yamlMappingValue(object, "metadata")
+	if metadata == nil || yamlScalar(yamlMappingValue(metadata, "name")) == "" {
+		schemaIssue(metadata, "missing required field \"metadata.name\"")
+	}
+
+	kind := yamlScalar(yamlMappingValue(object, "kind"))
+	name := yamlScalar(yamlMappingValue(metadata, "name"))
+	spec := yamlMappingValue(object, "spec")
+
+	switch {
+	case workloadKinds[kind]:
+		if spec == nil {
+			schemaIssue(nil, "%s %q is missing \"spec\"", kind, name)
+			return issues, false
+		}
+		if replicas := yamlMappingValue(spec, "replicas"); replicas != nil && replicas.Tag != "!!int" {
+			schemaIssue(replicas, "%s %q has non-integer \"spec.replicas\"", kind, name)
+		}
+		if kind != "DaemonSet" && yamlMappingValue(spec, "selector") == nil {
+			schemaIssue(spec, "%s %q is missing \"spec.selector\"", kind, name)
+		}
+		podSpec := yamlMappingValue(yamlMappingValue(spec, "template"), "spec")
+		containers := yamlMappingValue(podSpec, "containers")
+		if containers == nil || containers.Kind != yaml.SequenceNode || len(containers.Content) == 0 {
+			schemaIssue(spec, "%s %q must define at least one container", kind, name)
+			return issues, false
+		}
+
+		changed := false
+		for _, container := range containers.Content {
+			found, fixed := validateKubernetesContainer(path, kind, name, container, fix)
+			issues = append(issues, found...)
+			changed = changed || fixed
+		}
+		return issues, changed
+
+	case kind == "Service":
+		ports := yamlMappingValue(spec, "ports")
+		if ports == nil || ports.Kind != yaml.SequenceNode || len(ports.Content) == 0 {
+			schemaIssue(spec, "Service %q must define at least one port", name)
+		}
+	}
+
+	return issues, false
+}
+
+// validateKubernetesContainer enforces resource limits, probes and pinned images on a container
+func validateKubernetesContainer(path, kind, name string, container *yaml.Node, fix bool) ([]ValidationIssue, bool) {
+	var issues []ValidationIssue
+	changed := false
+
+	containerName := yamlScalar(yamlMappingValue(container, "name"))
+	subject := fmt.Sprintf("%s %q container %q", kind, name, containerName)
+	if containerName == "" {
+		issues = append(issues, ValidationIssue{
+			Rule:     "k8s-schema",
+			File:     path,
+			Line:     container.Line,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s %q has a container without a name", kind, name),
+		})
+	}
+
+	image := yamlMappingValue(container, "image")
+	if yamlScalar(image) == "" {
+		issues = append(issues, ValidationIssue{
+			Rule:     "k8s-schema",
+			File:     path,
+			Line:     container.Line,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s has no image", subject),
+		})
+	} else if !isPinnedImage(image.Value) {
+		issues = append(issues, ValidationIssue{
+			Rule:     "k8s-no-latest",
+			File:     path,
+			Line:     image.Line,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s uses unpinned image %q", subject, image.Value),
+		})
+	}
+
+	// Resource limits
+	limits := yamlMappingValue(yamlMappingValue(container, "resources"), "limits")
+	if yamlMappingValue(limits, "cpu") == nil || yamlMappingValue(limits, "memory") == nil {
+		if fix {
+			addKubernetesResources(container)
+			changed = true
+		} else {
+			issues = append(issues, ValidationIssue{
+				Rule:     "k8s-resource-limits",
+				File:     path,
+				Line:     container.Line,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s does not set cpu and memory limits", subject),
+				Fixable:  true,
+			})
+		}
+	}
+
+	// Probes
+	port := containerPort(container)
+	for _, probe := range []string{"livenessProbe", "readinessProbe"} {
+		if yamlMappingValue(container, probe) != nil {
+			continue
+		}
+		if fix {
+			addKubernetesProbe(container, probe, port)
+			changed = true
+			continue
+		}
+		issues = append(issues, ValidationIssue{
+			Rule:     "k8s-probes",
+			File:     path,
+			Line:     container.Line,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s does not define a %s", subject, probe),
+			Fixable:  true,
+		})
+	}
+
+	return issues, changed
+}
+
+// containerPort returns the first declared container port, defaulting to the service port
+func containerPort(container *yaml.Node) int {
+	ports := yamlMappingValue(container, "ports")
+	if ports != nil && ports.Kind == yaml.SequenceNode {
+		for _, port := range ports.Content {
+			if value, err := strconv.Atoi(yamlScalar(yamlMappingValue(port, "containerPort"))); err == nil {
+				return value
+			}
+		}
+	}
+	return defaultHealthPort
+}
+
+// addKubernetesResources fills in missing cpu/memory requests and limits with conservative defaults
+func addKubernetesResources(container *yaml.Node) {
+	resources := yamlEnsureMapping(container, "resources")
+	requests := yamlEnsureMapping(resources, "requests")
+	limits := yamlEnsureMapping(resources, "limits")
+
+	defaults := []struct {
+		node       *yaml.Node
+		key, value string
+	}{
+		{requests, "cpu", "100m"},
+		{requests, "memory", "128Mi"},
+		{limits, "cpu", "200m"},
+		{limits, "memory", "256Mi"},
+	}
+	for _, d := range defaults {
+		if yamlMappingValue(d.node, d.key) == nil {
+			yamlSetScalar(d.node, d.key, d.value, "!!str")
+		}
+	}
+}
+
+// addKubernetesProbe adds an HTTP probe against the health endpoint
+func addKubernetesProbe(container *yaml.Node, probe string, port int) {
+	node := yamlEnsureMapping(container, probe)
+	httpGet := yamlEnsureMapping(node, "httpGet")
+	yamlSetScalar(httpGet, "path", defaultHealthPath, "!!str")
+	yamlSetScalar(httpGet, "port", strconv.Itoa(port), "!!int")
+
+	initialDelay, period := "5", "5"
+	if probe == "livenessProbe" {
+		initialDelay, period = "30", "10"
+	}
+	yamlSetScalar(node, "initialDelaySeconds", initialDelay, "!!int")
+	yamlSetScalar(node, "periodSeconds", period, "!!int")
+}
+
+// YAML node helpers
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlScalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+func yamlEnsureMapping(node *yaml.Node, key string) *yaml.Node {
+	if value := yamlMappingValue(node, key); value != nil && value.Kind == yaml.MappingNode {
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	yamlSetNode(node, key, value)
+	return value
+}
+
+func yamlSetScalar(node *yaml.Node, key, value, tag string) {
+	yamlSetNode(node, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+}
+
+func yamlSetNode(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
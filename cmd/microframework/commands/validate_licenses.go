@@ -0,0 +1,306 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const licensePolicyFile = ".microframework-licenses.yaml"
+
+// defaultDeniedLicenses are rejected unless the project policy says otherwise
+var defaultDeniedLicenses = []string{"AGPL-3.0"}
+
+// licenseFileNames are the file names searched for license text, in order
+var licenseFileNames = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md",
+	"COPYING", "COPYING.md", "COPYING.txt", "LICENSE-MIT", "LICENSE-APACHE",
+}
+
+var spdxIdentifierPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.\-+]+)`)
+
+// LicensePolicy holds the project license policy
+type LicensePolicy struct {
+	Deny          []string `yaml:"deny"`
+	Ignore        []string `yaml:"ignore"`
+	FailOnUnknown bool     `yaml:"fail_on_unknown"`
+}
+
+// DependencyLicense is a single entry of the license report
+type DependencyLicense struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	File    string `json:"file,omitempty"`
+	Status  string `json:"status"`
+}
+
+// goModuleDownload mirrors the JSON emitted by `go mod download -json`
+type goModuleDownload struct {
+	Path    string
+	Version string
+	Dir     string
+	Error   string
+}
+
+func validateLicenses(file string, fix bool) error {
+	fmt.Println("Validating dependency licenses...")
+
+	policy, err := loadLicensePolicy()
+	if err != nil {
+		return fmt.Errorf("failed to load license policy: %w", err)
+	}
+	if len(validateDenyLicenses) > 0 {
+		policy.Deny = validateDenyLicenses
+	}
+
+	modules, err := resolveModuleGraph()
+	if err != nil {
+		return fmt.Errorf("failed to resolve module graph: %w", err)
+	}
+
+	report, issues := auditLicenses(modules, policy)
+
+	if validateLicenseReport != "" {
+		if err := writeLicenseReport(validateLicenseReport, report); err != nil {
+			return fmt.Errorf("failed to write license report: %w", err)
+		}
+		reportFiles(validateLicenseReport)
+		fmt.Printf("License report written to %s\n", validateLicenseReport)
+	}
+
+	if err := reportIssues("license issues", issues); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ License validation passed (%d modules audited)\n", len(report))
+	return nil
+}
+
+// loadLicensePolicy reads the project license policy, falling back to the defaults
+func loadLicensePolicy() (*LicensePolicy, error) {
+	policy := &LicensePolicy{Deny: defaultDeniedLicenses}
+
+	content, err := os.ReadFile(licensePolicyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policy, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", licensePolicyFile, err)
+	}
+
+	return policy, nil
+}
+
+// resolveModuleGraph downloads every module in the build list and returns its location
+func resolveModuleGraph() ([]goModuleDownload, error) {
+	cmd := exec.Command("go", "mod", "download", "-json", "all")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("go mod download failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	var modules []goModuleDownload
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var module goModuleDownload
+		if err := decoder.Decode(&module); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse go mod download output: %w", err)
+		}
+		modules = append(modules, module)
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	return modules, nil
+}
+
+// auditLicenses detects the license of each module and checks it against the policy
+func auditLicenses(modules []goModuleDownload, policy *LicensePolicy) ([]DependencyLicense, []ValidationIssue) {
+	var report []DependencyLicense
+	var issues []ValidationIssue
+
+	for _, module := range modules {
+		entry := DependencyLicense{
+			Module:  module.Path,
+			Version: module.Version,
+			License: "Unknown",
+			Status:  "allowed",
+		}
+
+		if module.Dir != "" {
+			if license, file := detectModuleLicense(module.Dir); license != "" {
+				entry.License = license
+				entry.File = file
+			}
+		}
+
+		switch {
+		case containsString(policy.Ignore, module.Path):
+			entry.Status = "ignored"
+		case isDeniedLicense(entry.License, policy.Deny):
+			entry.Status = "denied"
+			issues = append(issues, ValidationIssue{
+				Rule:     "license-denied",
+				File:     "go.mod",
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("%s@%s is licensed under %s, which is on the deny-list", module.Path, module.Version, entry.License),
+			})
+		case entry.License == "Unknown":
+			entry.Status = "unknown"
+			severity := SeverityWarning
+			if policy.FailOnUnknown {
+				severity = SeverityError
+			}
+			message := fmt.Sprintf("could not detect the license of %s@%s", module.Path, module.Version)
+			if module.Error != "" {
+				message = fmt.Sprintf("%s: %s", message, module.Error)
+			}
+			issues = append(issues, ValidationIssue{
+				Rule:     "license-unknown",
+				File:     "go.mod",
+				Severity: severity,
+				Message:  message,
+			})
+		}
+
+		report = append(report, entry)
+	}
+
+	return report, issues
+}
+
+// detectModuleLicense finds the license file of a module and classifies it
+func detectModuleLicense(dir string) (string, string) {
+	for _, name := range licenseFileNames {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if license := classifyLicense(string(content)); license != "" {
+			return license, name
+		}
+		return "Unknown", name
+	}
+	return "", ""
+}
+
+// classifyLicense maps license text to an SPDX identifier
+func classifyLicense(text string) string {
+	if match := spdxIdentifierPattern.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	has := func(s string) bool { return strings.Contains(normalized, s) }
+
+	// Other licenses mention the GPL family in their body, so only match GPL titles in the header
+	header := normalized
+	if len(header) > 300 {
+		header = header[:300]
+	}
+	hasTitle := func(s string) bool { return strings.Contains(header, s) }
+
+	switch {
+	case has("mozilla public license") && has("2.0"):
+		return "MPL-2.0"
+	case hasTitle("gnu affero general public license"):
+		return "AGPL-3.0"
+	case hasTitle("gnu lesser general public license"):
+		if hasTitle("version 2.1") {
+			return "LGPL-2.1"
+		}
+		return "LGPL-3.0"
+	case hasTitle("gnu general public license"):
+		if hasTitle("version 2,") || hasTitle("version 2 ") {
+			return "GPL-2.0"
+		}
+		return "GPL-3.0"
+	case has("apache license") && has("version 2.0"):
+		return "Apache-2.0"
+	case has("eclipse public license"):
+		return "EPL-2.0"
+	case has("permission is hereby granted, free of charge"):
+		return "MIT"
+	case has("redistribution and use in source and binary forms"):
+		if has("neither the name") || has("names of its contributors") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case has("permission to use, copy, modify, and/or distribute this software for any purpose"),
+		has("permission to use, copy, modify, and distribute this software for any purpose"):
+		return "ISC"
+	case has("this is free and unencumbered software released into the public domain"):
+		return "Unlicense"
+	case has("creative commons") && has("cc0"):
+		return "CC0-1.0"
+	}
+
+	return ""
+}
+
+// isDeniedLicense reports whether a license matches any deny-list entry; entries match by prefix
+// so that "GPL-3.0" also covers "GPL-3.0-only" and "GPL-3.0-or-later"
+func isDeniedLicense(license string, deny []string) bool {
+	for _, denied := range deny {
+		if strings.EqualFold(license, denied) || strings.HasPrefix(strings.ToLower(license), strings.ToLower(denied)+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLicenseReport writes the report as JSON or CSV depending on the file extension
+func writeLicenseReport(path string, report []DependencyLicense) error {
+	var buf bytes.Buffer
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		writer := csv.NewWriter(&buf)
+		writer.Write([]string{"module", "version", "license", "file", "status"})
+		for _, entry := range report {
+			writer.Write([]string{entry.Module, entry.Version, entry.License, entry.File, entry.Status})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	default:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
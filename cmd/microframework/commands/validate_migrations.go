@@ -0,0 +1,579 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const projectConfigFile = "configs/config.yaml"
+
+// SQL dialects that migrations can be checked against
+const (
+	DialectPostgres  = "postgres"
+	DialectMySQL     = "mysql"
+	DialectCockroach = "cockroach"
+	DialectSQLite    = "sqlite"
+)
+
+// providerDialects maps database provider names to the SQL dialect they speak
+var providerDialects = map[string]string{
+	"postgresql":  DialectPostgres,
+	"postgres":    DialectPostgres,
+	"mysql":       DialectMySQL,
+	"mariadb":     DialectMySQL,
+	"cockroachdb": DialectCockroach,
+	"cockroach":   DialectCockroach,
+	"sqlite":      DialectSQLite,
+	"sqlite3":     DialectSQLite,
+}
+
+// sqlStatementKeywords are the keywords a migration statement may start with
+var sqlStatementKeywords = []string{
+	"ALTER", "ANALYZE", "BEGIN", "CALL", "COMMENT", "COMMIT", "CREATE", "DELETE", "DO", "DROP",
+	"GRANT", "INSERT", "LOCK", "PRAGMA", "REFRESH", "REINDEX", "RENAME", "REPLACE", "REVOKE",
+	"ROLLBACK", "SELECT", "SET", "START", "TRUNCATE", "UPDATE", "UPSERT", "USE", "VACUUM", "WITH",
+}
+
+// dialectRule flags a construct that the listed dialects do not accept
+type dialectRule struct {
+	Dialects []string
+	Pattern  *regexp.Regexp
+	Severity string
+	Message  string
+}
+
+var dialectRules = []dialectRule{
+	// MySQL-isms in PostgreSQL-compatible databases
+	{[]string{DialectPostgres, DialectCockroach}, regexp.MustCompile("`"), SeverityError, "backtick-quoted identifiers are MySQL syntax; use double quotes"},
+	{[]string{DialectPostgres, DialectCockroach}, regexp.MustCompile(`(?i)\bauto_increment\b`), SeverityError, "AUTO_INCREMENT is MySQL syntax; use SERIAL or GENERATED ... AS IDENTITY"},
+	{[]string{DialectPostgres, DialectCockroach, DialectSQLite}, regexp.MustCompile(`(?i)\)\s*engine\s*=`), SeverityError, "ENGINE= table options are MySQL syntax"},
+	{[]string{DialectPostgres, DialectCockroach}, regexp.MustCompile(`(?i)\bunsigned\b`), SeverityError, "UNSIGNED integer types are MySQL syntax; use a CHECK constraint"},
+	{[]string{DialectPostgres, DialectCockroach}, regexp.MustCompile(`(?i)\w\s+(tinyint|mediumint|tinytext|mediumtext|longtext|datetime)\b`), SeverityError, "column type is MySQL syntax; use SMALLINT, TEXT or TIMESTAMP"},
+	{[]string{DialectPostgres, DialectCockroach, DialectSQLite}, regexp.MustCompile(`(?i)\bon\s+update\s+current_timestamp\b`), SeverityError, "ON UPDATE CURRENT_TIMESTAMP is MySQL syntax; use a trigger or set the column in the application"},
+	{[]string{DialectPostgres, DialectCockroach, DialectSQLite}, regexp.MustCompile(`(?i)\balter\s+table\s+\S+\s+modify\b`), SeverityError, "MODIFY COLUMN is MySQL syntax; use ALTER COLUMN ... TYPE"},
+
+	// PostgreSQL-isms in MySQL
+	{[]string{DialectMySQL, DialectSQLite}, regexp.MustCompile(`\w::\s*[A-Za-z]`), SeverityError, ":: casts are PostgreSQL syntax; use CAST(... AS ...)"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\breturning\b`), SeverityError, "RETURNING is not supported by MySQL"},
+	{[]string{DialectMySQL, DialectSQLite}, regexp.MustCompile(`(?i)\bcreate\s+(?:unique\s+)?index\s+concurrently\b`), SeverityError, "CREATE INDEX CONCURRENTLY is PostgreSQL syntax"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\bcreate\s+(?:unique\s+)?index\s+if\s+not\s+exists\b`), SeverityError, "MySQL does not support IF NOT EXISTS on CREATE INDEX"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\w\s+(jsonb|timestamptz|bytea|uuid|bigserial|smallserial|inet|cidr|citext)\b`), SeverityError, "column type is PostgreSQL syntax; use JSON, TIMESTAMP, BLOB or CHAR(36)"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\bilike\b`), SeverityError, "ILIKE is PostgreSQL syntax; use LIKE with a case-insensitive collation"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`"[A-Za-z_][A-Za-z0-9_]*"`), SeverityWarning, "double-quoted identifiers only work with the ANSI_QUOTES SQL mode; use backticks"},
+	{[]string{DialectMySQL, DialectSQLite}, regexp.MustCompile(`(?i)\bcreate\s+extension\b`), SeverityError, "CREATE EXTENSION is PostgreSQL syntax"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\bcreate\s+type\s+\S+\s+as\s+enum\b`), SeverityError, "CREATE TYPE ... AS ENUM is PostgreSQL syntax; declare ENUM(...) on the column"},
+	{[]string{DialectMySQL}, regexp.MustCompile(`(?i)\balter\s+column\s+\S+\s+(?:set\s+data\s+)?type\b`), SeverityError, "ALTER COLUMN ... TYPE is PostgreSQL syntax; use MODIFY COLUMN"},
+
+	// CockroachDB limitations
+	{[]string{DialectCockroach}, regexp.MustCompile(`(?i)\bcreate\s+extension\b`), SeverityWarning, "most PostgreSQL extensions are not available in CockroachDB"},
+	{[]string{DialectCockroach}, regexp.MustCompile(`(?i)\bcreate\s+(?:or\s+replace\s+)?rule\b`), SeverityError, "CREATE RULE is not supported by CockroachDB"},
+	{[]string{DialectCockroach}, regexp.MustCompile(`(?i)\bexclude\s+using\b`), SeverityError, "exclusion constraints are not supported by CockroachDB"},
+	{[]string{DialectCockroach}, regexp.MustCompile(`(?i)\b(?:listen|notify)\s+\w`), SeverityError, "LISTEN/NOTIFY are not supported by CockroachDB"},
+	{[]string{DialectCockroach}, regexp.MustCompile(`(?i)\w\s+(?:big|small)?serial\b`), SeverityInfo, "SERIAL columns use unique_rowid() in CockroachDB; values are unique but not sequential"},
+
+	// SQLite limitations
+	{[]string{DialectSQLite}, regexp.MustCompile(`(?i)\bauto_increment\b`), SeverityError, "AUTO_INCREMENT is MySQL syntax; use INTEGER PRIMARY KEY AUTOINCREMENT"},
+	{[]string{DialectSQLite}, regexp.MustCompile(`(?i)\balter\s+table\s+\S+\s+alter\s+column\b`), SeverityError, "SQLite cannot alter columns; recreate the table instead"},
+	{[]string{DialectSQLite}, regexp.MustCompile(`(?i)\balter\s+table\s+\S+\s+(?:add|drop)\s+constraint\b`), SeverityError, "SQLite cannot add or drop constraints on existing tables; recreate the table instead"},
+	{[]string{DialectSQLite}, regexp.MustCompile(`(?i)\w\s+(?:big|small)?serial\b`), SeverityWarning, "SERIAL is not an auto-increment type in SQLite; use INTEGER PRIMARY KEY"},
+}
+
+var (
+	// createdObjectPattern captures tables and indexes created by a statement
+	createdObjectPattern = regexp.MustCompile(`(?is)^create\s+(?:(?:unique\s+)?index\s+(?:concurrently\s+)?|(?:temporary\s+|temp\s+)?table\s+)(?:if\s+not\s+exists\s+)?([^\s(]+)(?:\s+on\s+([^\s(]+))?`)
+	// droppedObjectPattern captures the objects dropped by a statement
+	droppedObjectPattern = regexp.MustCompile(`(?is)^drop\s+(?:table|index)\s+(?:concurrently\s+)?(?:if\s+exists\s+)?(.+)$`)
+)
+
+// sqlStatement is a single statement of a migration script
+type sqlStatement struct {
+	// Text is the statement as written, without comments
+	Text string
+	// Code is the statement without comments and with string literals blanked
+	Code string
+	// Lint is Code with the names of quoted identifiers blanked too, which the dialect rules
+	// match so that neither a literal nor a quoted name looks like a construct of the dialect
+	Lint string
+	// Line is the line of the script the statement starts on
+	Line int
+}
+
+func validateMigrations(file string, fix bool) error {
+	fmt.Println("Validating migrations...")
+
+	if _, err := os.Stat("migrations"); os.IsNotExist(err) {
+		fmt.Println("✓ No migrations directory, skipping")
+		return nil
+	}
+
+	provider := configuredDatabaseProvider()
+	issues, err := checkMigrations("migrations", provider)
+	if err != nil {
+		return err
+	}
+
+	if err := reportIssues("migration issues", issues); err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Migration validation passed")
+	return nil
+}
+
+// checkMigrations validates the migrations in dir against the dialect of provider
+func checkMigrations(dir, provider string) ([]ValidationIssue, error) {
+	files, err := loadMigrationFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	dialect, ok := providerDialects[provider]
+	if !ok {
+		fmt.Printf("Provider %q has no SQL dialect; skipping migration SQL checks\n", provider)
+		return nil, nil
+	}
+	fmt.Printf("Checking %d migrations in %s against the %s dialect\n", len(files), dir, dialect)
+
+	var issues []ValidationIssue
+	versions := map[string]string{}
+	for _, migration := range files {
+		issue := func(rule, severity, format string, args ...interface{}) {
+			issues = append(issues, ValidationIssue{
+				Rule:     rule,
+				File:     migration.Path,
+				Severity: severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if previous, ok := versions[migration.Version]; ok {
+			issue("migration-duplicate-version", SeverityError, "version %s is also used by %s", migration.Version, previous)
+		}
+		versions[migration.Version] = migration.Path
+
+		up, upIssues := checkMigrationSQL(migration.Path, "up_sql", migration.UpSQL, dialect)
+		issues = append(issues, upIssues...)
+		if strings.TrimSpace(migration.UpSQL) == "" {
+			issue("migration-syntax", SeverityError, "up_sql is empty")
+		}
+
+		if strings.TrimSpace(migration.DownSQL) == "" {
+			issue("migration-down-missing", SeverityError, "migration has no down_sql and cannot be rolled back")
+			continue
+		}
+		down, downIssues := checkMigrationSQL(migration.Path, "down_sql", migration.DownSQL, dialect)
+		issues = append(issues, downIssues...)
+
+		for _, object := range undroppedObjects(up, down) {
+			issue("migration-down-incomplete", SeverityWarning, "%s is created by up_sql but not dropped by down_sql", object)
+		}
+	}
+
+	return issues, nil
+}
+
+// checkMigrationSQL parses one migration script and applies the dialect rules to each statement
+func checkMigrationSQL(path, field, sql, dialect string) ([]sqlStatement, []ValidationIssue) {
+	var issues []ValidationIssue
+	add := func(rule, severity string, statement sqlStatement, message string) {
+		issues = append(issues, ValidationIssue{
+			Rule:     rule,
+			File:     path,
+			Severity: severity,
+			Message:  fmt.Sprintf("%s line %d: %s", field, statement.Line, message),
+		})
+	}
+
+	statements, err := splitSQLStatements(sql, dialect)
+	if err != nil {
+		add("migration-syntax", SeverityCritical, sqlStatement{Line: err.Line}, err.Message)
+		return statements, issues
+	}
+
+	for _, statement := range statements {
+		keyword := strings.ToUpper(leadingWord(statement.Lint))
+		if !containsString(sqlStatementKeywords, keyword) {
+			add("migration-syntax", SeverityCritical, statement, fmt.Sprintf("unexpected statement keyword %q", keyword))
+			continue
+		}
+
+		for _, rule := range dialectRules {
+			if containsString(rule.Dialects, dialect) && rule.Pattern.MatchString(statement.Lint) {
+				add("migration-dialect", rule.Severity, statement, rule.Message)
+			}
+		}
+	}
+
+	return statements, issues
+}
+
+// sqlSyntaxError is a lexical error in a migration script
+type sqlSyntaxError struct {
+	Line    int
+	Message string
+}
+
+// Kinds of the tokens of a migration script
+const (
+	// sqlSpace is whitespace or a comment
+	sqlSpace = iota
+	// sqlWord is a keyword, an unquoted identifier or a number
+	sqlWord
+	// sqlString is a string literal, dollar-quoted bodies included
+	sqlString
+	// sqlIdentifier is a double-quoted or backtick-quoted identifier
+	sqlIdentifier
+	// sqlSymbol is an operator or punctuation, one rune per token
+	sqlSymbol
+)
+
+// sqlToken is a token of a migration script
+type sqlToken struct {
+	Kind int
+	Text string
+	Line int
+}
+
+// tokenizeSQL splits a script into tokens following the quoting and comment rules of the
+// dialect: escape strings (E'...') and dollar-quoted bodies ($tag$...$tag$) in PostgreSQL and
+// CockroachDB, nested block comments in PostgreSQL, backslash escapes and # comments in MySQL.
+// It reports unterminated literals and comments.
+func tokenizeSQL(sql, dialect string) ([]sqlToken, *sqlSyntaxError) {
+	postgres := dialect == DialectPostgres || dialect == DialectCockroach
+	var tokens []sqlToken
+	runes := []rune(sql)
+	line := 1
+	emit := func(kind int, text string) {
+		tokens = append(tokens, sqlToken{Kind: kind, Text: text, Line: line})
+		line += strings.Count(text, "\n")
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			j := i
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			emit(sqlSpace, string(runes[i:j]))
+			i = j
+
+		case (r == '-' && next == '-') || (r == '#' && dialect == DialectMySQL):
+			j := i
+			for j < len(runes) && runes[j] != '\n' {
+				j++
+			}
+			emit(sqlSpace, " ")
+			i = j
+
+		case r == '/' && next == '*':
+			j, depth := i+2, 1
+			for j < len(runes) && depth > 0 {
+				switch {
+				case j+1 < len(runes) && runes[j] == '*' && runes[j+1] == '/':
+					depth--
+					j += 2
+				case dialect == DialectPostgres && j+1 < len(runes) && runes[j] == '/' && runes[j+1] == '*':
+					depth++
+					j += 2
+				default:
+					j++
+				}
+			}
+			if depth > 0 {
+				return nil, &sqlSyntaxError{Line: line, Message: "unterminated block comment"}
+			}
+			// The comment counts as whitespace, keeping its lines
+			emit(sqlSpace, " "+strings.Repeat("\n", strings.Count(string(runes[i:j]), "\n")))
+			i = j
+
+		case r == '\'' || r == '"' || r == '`':
+			// E'...' escape strings take backslash escapes in PostgreSQL, as every string does
+			// in MySQL
+			escapes := dialect == DialectMySQL && r != '`'
+			if r == '\'' && postgres && len(tokens) > 0 {
+				last := tokens[len(tokens)-1]
+				escapes = last.Kind == sqlWord && strings.EqualFold(last.Text, "e")
+			}
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if escapes && runes[j] == '\\' {
+					j++
+					continue
+				}
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(runes) {
+				return nil, &sqlSyntaxError{Line: line, Message: fmt.Sprintf("unterminated %c quoted literal", r)}
+			}
+			kind := sqlIdentifier
+			if r == '\'' {
+				kind = sqlString
+			}
+			emit(kind, string(runes[i:j+1]))
+			i = j + 1
+
+		case r == '$' && postgres && dollarQuoteTag(runes[i:]) != "":
+			tag := dollarQuoteTag(runes[i:])
+			body := string(runes[i+len([]rune(tag)):])
+			end := strings.Index(body, tag)
+			if end < 0 {
+				return nil, &sqlSyntaxError{Line: line, Message: fmt.Sprintf("unterminated %s quoted body", tag)}
+			}
+			literal := tag + body[:end] + tag
+			emit(sqlString, literal)
+			i += len([]rune(literal))
+
+		case isSQLWordRune(r):
+			// $ continues a word, as in PostgreSQL and MySQL identifiers, but does not start one
+			j := i
+			for j < len(runes) && (isSQLWordRune(runes[j]) || runes[j] == '$') {
+				j++
+			}
+			emit(sqlWord, string(runes[i:j]))
+			i = j
+
+		default:
+			emit(sqlSymbol, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isSQLWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// splitSQLStatements splits a script into statements at the semicolons outside literals,
+// parentheses and the BEGIN ... END bodies of triggers and routines, and reports unbalanced
+// parentheses
+func splitSQLStatements(sql, dialect string) ([]sqlStatement, *sqlSyntaxError) {
+	tokens, err := tokenizeSQL(sql, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []sqlStatement
+	var text, code, lint strings.Builder
+	var words []string
+	start, depth, blocks := 0, 0, 0
+
+	flush := func() *sqlSyntaxError {
+		if depth != 0 {
+			return &sqlSyntaxError{Line: start, Message: "unbalanced parentheses"}
+		}
+		if strings.TrimSpace(code.String()) != "" {
+			statements = append(statements, sqlStatement{
+				Text: strings.TrimSpace(text.String()),
+				Code: strings.TrimSpace(code.String()),
+				Lint: strings.TrimSpace(lint.String()),
+				Line: start,
+			})
+		}
+		text.Reset()
+		code.Reset()
+		lint.Reset()
+		words = words[:0]
+		start, blocks = 0, 0
+		return nil
+	}
+
+	for i, token := range tokens {
+		if start == 0 && token.Kind != sqlSpace {
+			start = token.Line
+		}
+
+		switch token.Kind {
+		case sqlSymbol:
+			switch token.Text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+				if depth < 0 {
+					return nil, &sqlSyntaxError{Line: token.Line, Message: "unexpected closing parenthesis"}
+				}
+			case ";":
+				if depth == 0 && blocks == 0 {
+					if err := flush(); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+
+		case sqlWord:
+			words = append(words, strings.ToUpper(token.Text))
+			if definesRoutine(words) {
+				switch words[len(words)-1] {
+				case "BEGIN", "CASE":
+					blocks++
+				case "END":
+					// END IF, END LOOP, END WHILE and END REPEAT close blocks not counted
+					if blocks > 0 && !closesUncountedBlock(tokens[i+1:]) {
+						blocks--
+					}
+				}
+			}
+		}
+
+		text.WriteString(token.Text)
+		switch token.Kind {
+		case sqlString:
+			code.WriteString("''")
+			lint.WriteString("''")
+		case sqlIdentifier:
+			quote := token.Text[:1]
+			code.WriteString(token.Text)
+			lint.WriteString(quote + "_" + quote)
+		default:
+			code.WriteString(token.Text)
+			lint.WriteString(token.Text)
+		}
+	}
+
+	if blocks > 0 {
+		return nil, &sqlSyntaxError{Line: start, Message: "unterminated BEGIN ... END block"}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// definesRoutine reports whether the words of a statement so far create a trigger, function,
+// procedure or event, whose body may hold statements of its own
+func definesRoutine(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	for _, word := range words[1:] {
+		switch word {
+		case "TRIGGER", "FUNCTION", "PROCEDURE", "EVENT":
+			return true
+		case "TABLE", "INDEX", "VIEW", "TYPE", "SEQUENCE", "SCHEMA", "EXTENSION":
+			return false
+		}
+	}
+	return false
+}
+
+// closesUncountedBlock reports whether the END before tokens ends an IF, LOOP, WHILE or REPEAT
+// block rather than a BEGIN or CASE one
+func closesUncountedBlock(tokens []sqlToken) bool {
+	for _, token := range tokens {
+		switch token.Kind {
+		case sqlSpace:
+			continue
+		case sqlWord:
+			switch strings.ToUpper(token.Text) {
+			case "IF", "LOOP", "WHILE", "REPEAT":
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// dollarQuoteTag returns the $tag$ opening a PostgreSQL dollar-quoted string, if any
+func dollarQuoteTag(runes []rune) string {
+	for j := 1; j < len(runes); j++ {
+		switch {
+		case runes[j] == '$':
+			return string(runes[:j+1])
+		case runes[j] == '_' || unicode.IsLetter(runes[j]) || (j > 1 && unicode.IsDigit(runes[j])):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func leadingWord(s string) string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' })
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// undroppedObjects returns the tables and indexes created by up that down does not remove;
+// indexes of a dropped table are removed with it
+func undroppedObjects(up, down []sqlStatement) []string {
+	dropped := map[string]bool{}
+	for _, statement := range down {
+		match := droppedObjectPattern.FindStringSubmatch(statement.Code)
+		if match == nil {
+			continue
+		}
+		for _, name := range strings.Split(match[1], ",") {
+			if fields := strings.Fields(name); len(fields) > 0 {
+				dropped[sqlObjectName(fields[0])] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, statement := range up {
+		match := createdObjectPattern.FindStringSubmatch(statement.Code)
+		if match == nil {
+			continue
+		}
+		name := sqlObjectName(match[1])
+		if dropped[name] || (match[2] != "" && dropped[sqlObjectName(match[2])]) {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// sqlObjectName normalizes a possibly quoted and schema-qualified identifier
+func sqlObjectName(name string) string {
+	name = strings.ToLower(strings.Trim(name, "\"`;"))
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = strings.Trim(name[i+1:], "\"`")
+	}
+	return name
+}
+
+// configuredDatabaseProvider returns the first SQL provider declared under database.providers
+// in configs/config.yaml, defaulting to postgresql
+func configuredDatabaseProvider() string {
+	config, err := loadProjectDatabaseConfig([]string{projectConfigFile})
+	if err != nil {
+		return "postgresql"
+	}
+	if provider := config.defaultProvider(); provider != "" {
+		return provider
+	}
+	return "postgresql"
+}
+
+// countIssuesAtLeast returns the number of issues with the given severity or a more severe one
+func countIssuesAtLeast(issues []ValidationIssue, severity string) int {
+	count := 0
+	for _, issue := range issues {
+		if severityRanks[issue.Severity] >= severityRanks[severity] {
+			count++
+		}
+	}
+	return count
+}
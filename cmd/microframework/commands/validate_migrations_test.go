@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		sql     string
+		// wantCode is the Code of each statement, wantLines the line it starts on
+		wantCode  []string
+		wantLines []int
+		wantErr   string
+	}{
+		{
+			name:      "statements and comments",
+			dialect:   DialectPostgres,
+			sql:       "-- orders\nCREATE TABLE orders (id INT);\n/* index; */\nCREATE INDEX idx ON orders (id);",
+			wantCode:  []string{"CREATE TABLE orders (id INT)", "CREATE INDEX idx ON orders (id)"},
+			wantLines: []int{2, 4},
+		},
+		{
+			name:      "semicolons in literals",
+			dialect:   DialectPostgres,
+			sql:       "INSERT INTO notes VALUES ('a; b', 'it''s');\nSELECT \"odd;name\" FROM notes;",
+			wantCode:  []string{"INSERT INTO notes VALUES ('', '')", `SELECT "odd;name" FROM notes`},
+			wantLines: []int{1, 2},
+		},
+		{
+			name:      "escape string",
+			dialect:   DialectPostgres,
+			sql:       "INSERT INTO notes VALUES (E'it\\'s; done');\nSELECT 1;",
+			wantCode:  []string{"INSERT INTO notes VALUES (E'')", "SELECT 1"},
+			wantLines: []int{1, 2},
+		},
+		{
+			name:    "dollar-quoted body",
+			dialect: DialectPostgres,
+			sql: "CREATE FUNCTION touch() RETURNS trigger AS $body$\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql;\n" +
+				"DO $$ BEGIN PERFORM 1; END $$;",
+			wantCode:  []string{"CREATE FUNCTION touch() RETURNS trigger AS '' LANGUAGE plpgsql", "DO ''"},
+			wantLines: []int{1, 7},
+		},
+		{
+			name:      "positional parameters and $ in identifiers",
+			dialect:   DialectPostgres,
+			sql:       "UPDATE a$b SET c = $1;",
+			wantCode:  []string{"UPDATE a$b SET c = $1"},
+			wantLines: []int{1},
+		},
+		{
+			name:      "nested block comment",
+			dialect:   DialectPostgres,
+			sql:       "/* outer /* inner; */ still; */ SELECT 1;",
+			wantCode:  []string{"SELECT 1"},
+			wantLines: []int{1},
+		},
+		{
+			name:      "backslash escapes and hash comments",
+			dialect:   DialectMySQL,
+			sql:       "# seed\nINSERT INTO notes VALUES ('it\\'s; done', \"a\\\"; b\");",
+			wantCode:  []string{`INSERT INTO notes VALUES ('', "a\"; b")`},
+			wantLines: []int{2},
+		},
+		{
+			name:    "trigger body",
+			dialect: DialectSQLite,
+			sql: "CREATE TRIGGER touch AFTER UPDATE ON orders BEGIN\n  UPDATE orders SET n = CASE WHEN n > 0 THEN 1 ELSE 0 END;\n  SELECT 1;\nEND;\n" +
+				"DROP TABLE old;",
+			wantLines: []int{1, 5},
+		},
+		{
+			name:    "procedure with IF",
+			dialect: DialectMySQL,
+			sql: "CREATE PROCEDURE tidy() BEGIN\n  IF 1 THEN DELETE FROM a; END IF;\n  DELETE FROM b;\nEND;\n" +
+				"SELECT 1;",
+			wantLines: []int{1, 5},
+		},
+		{name: "unterminated string", dialect: DialectPostgres, sql: "SELECT 'open;", wantErr: "unterminated ' quoted literal"},
+		{name: "unterminated dollar quote", dialect: DialectPostgres, sql: "DO $$ BEGIN;", wantErr: "unterminated $$ quoted body"},
+		{name: "unterminated comment", dialect: DialectMySQL, sql: "SELECT 1; /* open", wantErr: "unterminated block comment"},
+		{name: "unbalanced parentheses", dialect: DialectPostgres, sql: "CREATE TABLE a (id INT;", wantErr: "unbalanced parentheses"},
+		{name: "unterminated block", dialect: DialectSQLite, sql: "CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1;", wantErr: "unterminated BEGIN ... END block"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := splitSQLStatements(tt.sql, tt.dialect)
+			if tt.wantErr != "" {
+				if err == nil || err.Message != tt.wantErr {
+					t.Fatalf("splitSQLStatements() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitSQLStatements() error = %s", err.Message)
+			}
+			if len(statements) != len(tt.wantLines) {
+				t.Fatalf("got %d statements, want %d: %+v", len(statements), len(tt.wantLines), statements)
+			}
+			for i, statement := range statements {
+				if statement.Line != tt.wantLines[i] {
+					t.Errorf("statement %d starts on line %d, want %d", i, statement.Line, tt.wantLines[i])
+				}
+				if tt.wantCode != nil && statement.Code != tt.wantCode[i] {
+					t.Errorf("statement %d = %q, want %q", i, statement.Code, tt.wantCode[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMigrationSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		sql     string
+		// want are substrings of the messages of the issues, in order
+		want []string
+	}{
+		{name: "portable", dialect: DialectPostgres, sql: "CREATE TABLE orders (id BIGINT PRIMARY KEY, total NUMERIC);"},
+		{
+			name:    "MySQL syntax in PostgreSQL",
+			dialect: DialectPostgres,
+			sql:     "CREATE TABLE `orders` (id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY) ENGINE=InnoDB;",
+			want:    []string{"backtick-quoted", "AUTO_INCREMENT", "ENGINE=", "UNSIGNED"},
+		},
+		{
+			name:    "keywords in literals and comments",
+			dialect: DialectPostgres,
+			sql:     "-- AUTO_INCREMENT `ids`\nINSERT INTO notes (body) VALUES ('id INT UNSIGNED AUTO_INCREMENT ENGINE=x');",
+		},
+		{
+			name:    "keywords as quoted names",
+			dialect: DialectPostgres,
+			sql:     `CREATE TABLE "auto_increment" ("unsigned" INT, "engine" TEXT, note "datetime");`,
+		},
+		{
+			name:    "PostgreSQL syntax in MySQL",
+			dialect: DialectMySQL,
+			sql:     "CREATE INDEX IF NOT EXISTS idx ON orders (id);\nSELECT id::text FROM orders WHERE name ILIKE 'a%' RETURNING id;",
+			want:    []string{"IF NOT EXISTS", ":: casts", "RETURNING", "ILIKE"},
+		},
+		{
+			name:    "dollar-quoted function body",
+			dialect: DialectPostgres,
+			sql:     "CREATE FUNCTION f() RETURNS int AS $$ SELECT `x` FROM t AUTO_INCREMENT $$ LANGUAGE sql;",
+		},
+		{name: "unknown keyword", dialect: DialectPostgres, sql: "CREAT TABLE orders (id INT);", want: []string{`unexpected statement keyword "CREAT"`}},
+		{name: "syntax error", dialect: DialectPostgres, sql: "SELECT 'open", want: []string{"unterminated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := checkMigrationSQL("0001_orders.yaml", "up_sql", tt.sql, tt.dialect)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), len(tt.want), issues)
+			}
+			for i, issue := range issues {
+				if !strings.Contains(issue.Message, tt.want[i]) {
+					t.Errorf("issue %d = %q, want %q", i, issue.Message, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUndroppedObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		up, down string
+		want     []string
+	}{
+		{name: "dropped", up: "CREATE TABLE orders (id INT);", down: "DROP TABLE orders;"},
+		{name: "index of a dropped table", up: "CREATE TABLE orders (id INT); CREATE INDEX idx ON orders (id);", down: "DROP TABLE IF EXISTS orders;"},
+		{name: "quoted and qualified", up: `CREATE TABLE public."Orders" (id INT);`, down: `DROP TABLE "Orders";`},
+		{name: "not dropped", up: "CREATE TABLE orders (id INT); CREATE UNIQUE INDEX idx ON items (id);", down: "DROP TABLE orders;", want: []string{"idx"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, err := splitSQLStatements(tt.up, DialectPostgres)
+			if err != nil {
+				t.Fatal(err.Message)
+			}
+			down, err := splitSQLStatements(tt.down, DialectPostgres)
+			if err != nil {
+				t.Fatal(err.Message)
+			}
+			got := undroppedObjects(up, down)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("undroppedObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anasamu/go-micro-libs/database/migrations"
+)
+
+// gormQueryMethods are GORM finisher methods that execute a statement
+var gormQueryMethods = []string{
+	"Find", "First", "Last", "Take", "Scan", "Pluck", "Count",
+	"Create", "CreateInBatches", "Save", "Update", "Updates", "UpdateColumn", "UpdateColumns",
+	"Delete", "FirstOrCreate", "FirstOrInit", "Exec", "Row", "Rows",
+}
+
+// sqlQueryMethods are database/sql methods that execute a statement
+var sqlQueryMethods = []string{
+	"Query", "QueryRow", "QueryContext", "QueryRowContext", "Exec", "ExecContext",
+}
+
+var (
+	// whereColumnPattern extracts the column compared in a Where clause condition
+	whereColumnPattern = regexp.MustCompile(`(?i)([a-z_][a-z0-9_."]*)\s*(?:=|<>|!=|<=|>=|<|>|\bin\b|\blike\b|\bilike\b|\bbetween\b|\bis\b)`)
+	// createIndexPattern matches CREATE INDEX statements and captures the indexed columns
+	createIndexPattern = regexp.MustCompile(`(?is)create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?[^\s(]*\s*on\s+[^\s(]+\s*(?:using\s+\w+\s*)?\(([^)]*)\)`)
+	// inlineKeyPattern matches column definitions declared PRIMARY KEY or UNIQUE
+	inlineKeyPattern = regexp.MustCompile(`(?im)(?:^|[(,])\s*"?([a-z_][a-z0-9_]*)"?\s+[^,\n]*?\b(?:primary\s+key|unique)\b`)
+	// tableKeyPattern matches table-level PRIMARY KEY / UNIQUE / INDEX constraints
+	tableKeyPattern = regexp.MustCompile(`(?i)(?:primary\s+key|unique|index|key)\s*(?:\w+\s*)?\(([^)]*)\)`)
+)
+
+// Performance rule implementations
+
+func checkQueriesInLoops(project *goProject, rule *validationRule) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			var body *ast.BlockStmt
+			switch loop := n.(type) {
+			case *ast.ForStmt:
+				body = loop.Body
+			case *ast.RangeStmt:
+				body = loop.Body
+			default:
+				return true
+			}
+
+			ast.Inspect(body, func(inner ast.Node) bool {
+				// Closures declared in the loop run later, not once per iteration
+				if _, ok := inner.(*ast.FuncLit); ok {
+					return false
+				}
+				call, ok := inner.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				method, root, ok := queryCall(call)
+				if !ok {
+					return true
+				}
+				issue := rule.issue(project, call.Pos(), "%s.%s runs a query on every loop iteration (N+1 queries)", root, method)
+				issue.Suggestion = "collect the keys first and load all rows with a single query, e.g. Where(\"id IN ?\", ids) or Preload for associations"
+				issues = append(issues, issue)
+				// Report each statement once, not every call in its chain
+				return false
+			})
+			// Nested loops are visited from the outer loop body already
+			return false
+		})
+	}
+	return issues
+}
+
+func checkGormWithContext(project *goProject, rule *validationRule) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		if !importsPackage(file.AST, "gorm.io/gorm") {
+			continue
+		}
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !containsString(gormQueryMethods, selector.Sel.Name) {
+				return true
+			}
+			root, methods := callChain(selector.X)
+			if !looksLikeDatabaseHandle(root) || containsString(methods, "WithContext") {
+				return true
+			}
+			issue := rule.issue(project, call.Pos(), "GORM call %s.%s is not bound to a context; cancellation and deadlines are ignored", root, selector.Sel.Name)
+			issue.Suggestion = fmt.Sprintf("use %s.WithContext(ctx)...%s(...)", root, selector.Sel.Name)
+			issues = append(issues, issue)
+			// The rest of the chain belongs to this call
+			return false
+		})
+	}
+	return issues
+}
+
+func checkMissingIndexes(project *goProject, rule *validationRule) []ValidationIssue {
+	dir := "migrations"
+	if dirs := rule.stringListOption("migrations_dir"); len(dirs) > 0 {
+		dir = dirs[0]
+	}
+
+	migrationFiles, err := loadMigrationFiles(dir)
+	if err != nil || len(migrationFiles) == 0 {
+		// Without migrations there is nothing to cross-reference
+		return nil
+	}
+
+	indexed := map[string]bool{"id": true}
+	for _, migration := range migrationFiles {
+		for _, column := range indexedColumns(migration.UpSQL) {
+			indexed[column] = true
+		}
+	}
+
+	var issues []ValidationIssue
+	reported := map[string]bool{}
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || (selector.Sel.Name != "Where" && selector.Sel.Name != "Or") {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			condition, err := strconv.Unquote(literal.Value)
+			if err != nil {
+				return true
+			}
+
+			for _, column := range whereColumns(condition) {
+				key := file.Path + ":" + column
+				if indexed[column] || reported[key] {
+					continue
+				}
+				reported[key] = true
+				issue := rule.issue(project, call.Pos(), "column %q is filtered in a Where clause but no migration in %s creates an index on it", column, dir)
+				issue.Suggestion = fmt.Sprintf("add a migration with CREATE INDEX idx_<table>_%s ON <table> (%s)", column, column)
+				issues = append(issues, issue)
+			}
+			return true
+		})
+	}
+	return issues
+}
+
+// queryCall reports whether call executes a database statement and returns the method and handle
+func queryCall(call *ast.CallExpr) (string, string, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	method := selector.Sel.Name
+	if !containsString(gormQueryMethods, method) && !containsString(sqlQueryMethods, method) {
+		return "", "", false
+	}
+	root, _ := callChain(selector.X)
+	if !looksLikeDatabaseHandle(root) {
+		return "", "", false
+	}
+	return method, root, true
+}
+
+// callChain walks a method chain such as r.db.WithContext(ctx).Where(...) and returns
+// the receiver it starts from ("r.db") and the methods called along the way
+func callChain(expr ast.Expr) (string, []string) {
+	var methods []string
+	for {
+		switch e := expr.(type) {
+		case *ast.CallExpr:
+			selector, ok := e.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return "", methods
+			}
+			methods = append(methods, selector.Sel.Name)
+			expr = selector.X
+		case *ast.SelectorExpr:
+			prefix, _ := callChain(e.X)
+			if prefix == "" {
+				return e.Sel.Name, methods
+			}
+			return prefix + "." + e.Sel.Name, methods
+		case *ast.Ident:
+			return e.Name, methods
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return "", methods
+		}
+	}
+}
+
+// looksLikeDatabaseHandle guesses from its name whether an expression is a DB or transaction handle
+func looksLikeDatabaseHandle(root string) bool {
+	if root == "" {
+		return false
+	}
+	name := strings.ToLower(root[strings.LastIndex(root, ".")+1:])
+	return strings.Contains(name, "db") || name == "tx" || name == "txn"
+}
+
+func importsPackage(file *ast.File, path string) bool {
+	for _, spec := range file.Imports {
+		if strings.Trim(spec.Path.Value, `"`) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// whereColumns extracts the column names compared in a SQL condition
+func whereColumns(condition string) []string {
+	var columns []string
+	for _, match := range whereColumnPattern.FindAllStringSubmatch(condition, -1) {
+		column := strings.ToLower(strings.Trim(match[1], `"`))
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			column = column[i+1:]
+		}
+		switch column {
+		case "and", "or", "not", "null", "true", "false":
+			continue
+		}
+		if !containsString(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// indexedColumns returns the columns that lead an index, primary key or unique constraint in SQL
+func indexedColumns(sql string) []string {
+	var columns []string
+	add := func(list string) {
+		// Only the leading column of a composite index can serve a single-column filter
+		first := strings.TrimSpace(strings.SplitN(list, ",", 2)[0])
+		first = strings.ToLower(strings.Trim(strings.Fields(first + " ")[0], "\"`"))
+		if first != "" {
+			columns = append(columns, first)
+		}
+	}
+
+	for _, match := range createIndexPattern.FindAllStringSubmatch(sql, -1) {
+		add(match[1])
+	}
+	for _, match := range tableKeyPattern.FindAllStringSubmatch(sql, -1) {
+		add(match[1])
+	}
+	for _, match := range inlineKeyPattern.FindAllStringSubmatch(sql, -1) {
+		columns = append(columns, strings.ToLower(match[1]))
+	}
+
+	return columns
+}
+
+// migrationFile is a migration loaded from disk together with its path
+type migrationFile struct {
+	migrations.Migration
+	Path string
+}
+
+// loadMigrationFiles reads the JSON migrations in dir, sorted by version
+func loadMigrationFiles(dir string) ([]migrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []migrationFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var migration migrations.Migration
+		if err := json.Unmarshal(content, &migration); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		files = append(files, migrationFile{Migration: migration, Path: path})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
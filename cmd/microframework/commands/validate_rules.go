@@ -0,0 +1,441 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const rulesConfigFile = ".microframework-rules.yaml"
+
+// Rule categories
+const (
+	RuleCategoryBestPractices = "best-practices"
+	RuleCategoryPerformance   = "performance"
+	RuleCategoryArchitecture  = "architecture"
+)
+
+// validationRule is a single static check over the project's Go sources
+type validationRule struct {
+	Name        string
+	Category    string
+	Description string
+	Severity    string
+	Options     map[string]interface{}
+	Check       func(project *goProject, rule *validationRule) []ValidationIssue
+}
+
+// RulesConfig is the content of .microframework-rules.yaml
+type RulesConfig struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig enables, disables or parameterizes a single rule
+type RuleConfig struct {
+	Enabled  *bool                  `yaml:"enabled"`
+	Severity string                 `yaml:"severity"`
+	Options  map[string]interface{} `yaml:"options"`
+}
+
+// builtinRules returns the rules shipped with the CLI, with their default options
+func builtinRules() []*validationRule {
+	return []*validationRule{
+		{
+			Name:        "error-wrapping",
+			Category:    RuleCategoryBestPractices,
+			Description: "fmt.Errorf calls that include an error must wrap it with %w",
+			Severity:    SeverityWarning,
+			Check:       checkErrorWrapping,
+		},
+		{
+			Name:        "repository-context",
+			Category:    RuleCategoryBestPractices,
+			Description: "exported repository methods must take context.Context as their first parameter",
+			Severity:    SeverityError,
+			Options:     map[string]interface{}{"paths": []interface{}{"internal/repositories"}},
+			Check:       checkRepositoryContext,
+		},
+		{
+			Name:        "no-println-handlers",
+			Category:    RuleCategoryBestPractices,
+			Description: "handlers must use the structured logger instead of fmt.Print*",
+			Severity:    SeverityWarning,
+			Options:     map[string]interface{}{"paths": []interface{}{"internal/handlers"}},
+			Check:       checkNoPrintlnInHandlers,
+		},
+		{
+			Name:        "test-presence",
+			Category:    RuleCategoryBestPractices,
+			Description: "every package must have at least one _test.go file",
+			Severity:    SeverityWarning,
+			Options:     map[string]interface{}{"exclude": []interface{}{"cmd"}},
+			Check:       checkTestPresence,
+		},
+		{
+			Name:        "query-in-loop",
+			Category:    RuleCategoryPerformance,
+			Description: "database queries must not be issued inside loops (N+1 queries)",
+			Severity:    SeverityWarning,
+			Options:     map[string]interface{}{"paths": []interface{}{"internal/repositories", "internal/services"}},
+			Check:       checkQueriesInLoops,
+		},
+		{
+			Name:        "gorm-with-context",
+			Category:    RuleCategoryPerformance,
+			Description: "GORM calls must be bound to the request context with WithContext",
+			Severity:    SeverityWarning,
+			Options:     map[string]interface{}{"paths": []interface{}{"internal"}},
+			Check:       checkGormWithContext,
+		},
+		{
+			Name:        "missing-index",
+			Category:    RuleCategoryPerformance,
+			Description: "columns used in Where clauses must be indexed by a migration",
+			Severity:    SeverityInfo,
+			Options:     map[string]interface{}{"paths": []interface{}{"internal"}, "migrations_dir": "migrations"},
+			Check:       checkMissingIndexes,
+		},
+		{
+			Name:        "layering",
+			Category:    RuleCategoryArchitecture,
+			Description: "packages must respect the layering of the project layout (standard or hexagonal)",
+			Severity:    SeverityError,
+			Options:     map[string]interface{}{"layout": LayoutStandard},
+			Check:       checkLayering,
+		},
+	}
+}
+
+// loadRulesConfig reads .microframework-rules.yaml if present
+func loadRulesConfig() (*RulesConfig, error) {
+	config := &RulesConfig{}
+
+	content, err := os.ReadFile(rulesConfigFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", rulesConfigFile, err)
+	}
+
+	return config, nil
+}
+
+// configuredRules returns the enabled rules of a category with project overrides applied
+func configuredRules(category string) ([]*validationRule, error) {
+	config, err := loadRulesConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	var rules []*validationRule
+	for _, rule := range builtinRules() {
+		known[rule.Name] = true
+		if rule.Category != category {
+			continue
+		}
+
+		override, ok := config.Rules[rule.Name]
+		if ok {
+			if override.Enabled != nil && !*override.Enabled {
+				continue
+			}
+			if override.Severity != "" {
+				severity := normalizeSeverity(override.Severity)
+				if severity == "" {
+					return nil, fmt.Errorf("invalid severity %q for rule %q in %s", override.Severity, rule.Name, rulesConfigFile)
+				}
+				rule.Severity = severity
+			}
+			if rule.Options == nil {
+				rule.Options = map[string]interface{}{}
+			}
+			for key, value := range override.Options {
+				rule.Options[key] = value
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	for name := range config.Rules {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q in %s", name, rulesConfigFile)
+		}
+	}
+
+	return rules, nil
+}
+
+// runRules parses the project once and runs every enabled rule of a category
+func runRules(category, file string) ([]ValidationIssue, error) {
+	rules, err := configuredRules(category)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	project, err := loadGoProject(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ValidationIssue
+	for _, rule := range rules {
+		issues = append(issues, rule.Check(project, rule)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// stringListOption returns a list option of a rule
+func (r *validationRule) stringListOption(key string) []string {
+	var values []string
+	switch value := r.Options[key].(type) {
+	case []interface{}:
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+	case []string:
+		values = value
+	case string:
+		values = []string{value}
+	}
+	return values
+}
+
+// issue creates a finding for this rule at the given position
+func (r *validationRule) issue(project *goProject, pos token.Pos, format string, args ...interface{}) ValidationIssue {
+	position := project.Fset.Position(pos)
+	return ValidationIssue{
+		Rule:     r.Name,
+		File:     position.Filename,
+		Line:     position.Line,
+		Severity: r.Severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// goProject holds the parsed Go sources of the project
+type goProject struct {
+	Fset  *token.FileSet
+	Files []*goSourceFile
+}
+
+// goSourceFile is a single parsed Go file
+type goSourceFile struct {
+	Path string
+	Dir  string
+	AST  *ast.File
+}
+
+// loadGoProject parses the Go files of the project, limited to file when it is given
+func loadGoProject(file string) (*goProject, error) {
+	paths, err := goSourceFiles(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files: %w", err)
+	}
+
+	project := &goProject{Fset: token.NewFileSet()}
+	for _, path := range paths {
+		parsed, err := parser.ParseFile(project.Fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		project.Files = append(project.Files, &goSourceFile{
+			Path: filepath.ToSlash(path),
+			Dir:  filepath.ToSlash(filepath.Dir(path)),
+			AST:  parsed,
+		})
+	}
+
+	return project, nil
+}
+
+// filesUnder returns the non-test files located under any of the given paths
+func (p *goProject) filesUnder(paths []string) []*goSourceFile {
+	var files []*goSourceFile
+	for _, file := range p.Files {
+		if strings.HasSuffix(file.Path, "_test.go") {
+			continue
+		}
+		if len(paths) == 0 || pathMatchesAny(file.Path, paths) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// pathMatchesAny reports whether path is equal to or nested under any of the prefixes
+func pathMatchesAny(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(filepath.ToSlash(prefix), "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isPackageCall reports whether call is pkg.<one of names>(...)
+func isPackageCall(call *ast.CallExpr, pkg string, names ...string) bool {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok || ident.Name != pkg {
+		return false
+	}
+	for _, name := range names {
+		if selector.Sel.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Built-in rule implementations
+
+func checkErrorWrapping(project *goProject, rule *validationRule) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isPackageCall(call, "fmt", "Errorf") || len(call.Args) < 2 {
+				return true
+			}
+			format, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || format.Kind != token.STRING || strings.Contains(format.Value, "%w") {
+				return true
+			}
+			for _, arg := range call.Args[1:] {
+				if ident, ok := arg.(*ast.Ident); ok && isErrorName(ident.Name) {
+					issues = append(issues, rule.issue(project, call.Pos(), "error %q is formatted without %%w and cannot be unwrapped", ident.Name))
+					break
+				}
+			}
+			return true
+		})
+	}
+	return issues
+}
+
+// isErrorName guesses from a variable name whether it holds an error
+func isErrorName(name string) bool {
+	return name == "err" || strings.HasSuffix(name, "Err") || strings.HasSuffix(name, "Error")
+}
+
+func checkRepositoryContext(project *goProject, rule *validationRule) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		for _, decl := range file.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+				continue
+			}
+			if !firstParamIsContext(fn.Type) {
+				issues = append(issues, rule.issue(project, fn.Pos(), "repository method %s does not take context.Context as its first parameter", fn.Name.Name))
+			}
+		}
+	}
+	return issues
+}
+
+func firstParamIsContext(fn *ast.FuncType) bool {
+	if fn.Params == nil || len(fn.Params.List) == 0 {
+		return false
+	}
+	selector, ok := fn.Params.List[0].Type.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == "context" && selector.Sel.Name == "Context"
+}
+
+func checkNoPrintlnInHandlers(project *goProject, rule *validationRule) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, file := range project.filesUnder(rule.stringListOption("paths")) {
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if isPackageCall(call, "fmt", "Print", "Println", "Printf") {
+				issues = append(issues, rule.issue(project, call.Pos(), "handler writes to stdout with fmt; use the service logger"))
+			} else if ident, ok := call.Fun.(*ast.Ident); ok && (ident.Name == "println" || ident.Name == "print") {
+				issues = append(issues, rule.issue(project, call.Pos(), "handler writes to stderr with %s; use the service logger", ident.Name))
+			}
+			return true
+		})
+	}
+	return issues
+}
+
+func checkTestPresence(project *goProject, rule *validationRule) []ValidationIssue {
+	exclude := rule.stringListOption("exclude")
+	packages := map[string]*goSourceFile{}
+	tested := map[string]bool{}
+
+	for _, file := range project.Files {
+		if pathMatchesAny(file.Dir, exclude) {
+			continue
+		}
+		if strings.HasSuffix(file.Path, "_test.go") {
+			tested[file.Dir] = true
+			continue
+		}
+		if _, ok := packages[file.Dir]; !ok {
+			packages[file.Dir] = file
+		}
+	}
+
+	// A single file may have been requested; look for tests next to it on disk
+	for dir := range packages {
+		if tested[dir] {
+			continue
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*_test.go")); len(matches) > 0 {
+			tested[dir] = true
+		}
+	}
+
+	var dirs []string
+	for dir := range packages {
+		if !tested[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	var issues []ValidationIssue
+	for _, dir := range dirs {
+		issues = append(issues, ValidationIssue{
+			Rule:     rule.Name,
+			File:     dir,
+			Severity: rule.Severity,
+			Message:  fmt.Sprintf("package %s has no tests", packages[dir].AST.Name.Name),
+		})
+	}
+	return issues
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anasamu/go-micro-framework/pkg/schema"
+)
+
+// schemaHeaderPrefix starts the comment pointing YAML editors at the schema of a file
+const schemaHeaderPrefix = "# yaml-language-server: $schema="
+
+// validateConfigSchemas validates the project files with a schema (.microframework.yaml,
+// configs/config*.yaml, deployments/environments.yaml) against it. With fix, the files
+// that do not point editors at their schema get the header comment doing so.
+func validateConfigSchemas(file string, fix bool) ([]ValidationIssue, error) {
+	paths, err := findSchemaFiles(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ValidationIssue
+	for _, path := range paths {
+		name, _ := schema.ForFile(path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		problems, err := schema.Validate(name, content)
+		if err != nil {
+			issues = append(issues, ValidationIssue{
+				Rule:     "config-syntax",
+				File:     path,
+				Severity: SeverityError,
+				Message:  err.Error(),
+			})
+			continue
+		}
+		for _, problem := range problems {
+			message := problem.Message
+			if problem.Path != "" {
+				message = problem.Path + ": " + message
+			}
+			issues = append(issues, ValidationIssue{
+				Rule:       "config-schema",
+				File:       path,
+				Line:       problem.Line,
+				Severity:   SeverityError,
+				Message:    message,
+				Suggestion: "see " + schema.URL(name),
+			})
+		}
+
+		if bytes.HasPrefix(content, []byte(schemaHeaderPrefix)) {
+			continue
+		}
+		if fix {
+			header := []byte(schema.Header(name) + "\n")
+			if err := os.WriteFile(path, append(header, content...), 0644); err != nil {
+				return nil, fmt.Errorf("failed to add the schema header to %s: %w", path, err)
+			}
+			fmt.Printf("Added the schema header to %s\n", path)
+			continue
+		}
+		issues = append(issues, ValidationIssue{
+			Rule:       "config-schema-header",
+			File:       path,
+			Line:       1,
+			Severity:   SeverityInfo,
+			Message:    "does not point editors at its schema",
+			Suggestion: "start the file with " + schema.Header(name),
+			Fixable:    true,
+		})
+	}
+	return issues, nil
+}
+
+// findSchemaFiles returns the project files with a schema to validate
+func findSchemaFiles(file string) ([]string, error) {
+	if file != "" {
+		if _, ok := schema.ForFile(file); ok {
+			return []string{file}, nil
+		}
+		return nil, nil
+	}
+
+	var paths []string
+	for _, name := range schema.Names() {
+		for _, pattern := range schema.Files(name) {
+			matches, err := filepath.Glob(filepath.FromSlash(pattern))
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, matches...)
+		}
+	}
+	return uniqueSorted(paths), nil
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severityRanks orders severities from least to most severe
+var severityRanks = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityError:    2,
+	SeverityCritical: 3,
+}
+
+// severityOrder lists the severities from most to least severe, for summaries
+var severityOrder = []string{SeverityCritical, SeverityError, SeverityWarning, SeverityInfo}
+
+// findingCounts tallies the reported findings of this run by severity
+var findingCounts = map[string]int{}
+
+// reportedFindings are the findings reported in this run, for the JSON report
+var reportedFindings []ValidationIssue
+
+// normalizeSeverity maps accepted spellings to a severity, returning "" when it is unknown
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if severity == "warn" {
+		return SeverityWarning
+	}
+	if _, ok := severityRanks[severity]; ok {
+		return severity
+	}
+	return ""
+}
+
+// parseFailOn validates the --fail-on threshold; "none" never fails on findings
+func parseFailOn(threshold string) (string, error) {
+	if strings.EqualFold(threshold, "none") {
+		return "none", nil
+	}
+	if severity := normalizeSeverity(threshold); severity != "" {
+		return severity, nil
+	}
+	return "", fmt.Errorf("invalid --fail-on %q. Available thresholds: info, warn, error, critical, none", threshold)
+}
+
+// reachesThreshold reports whether a finding of severity fails the run
+func reachesThreshold(severity string) bool {
+	if validateFailOn == "none" {
+		return false
+	}
+	return severityRanks[severity] >= severityRanks[validateFailOn]
+}
+
+// reportIssues filters, prints and tallies findings, and returns an error naming what was
+// found when any finding reaches the --fail-on threshold
+func reportIssues(label string, issues []ValidationIssue) error {
+	issues = filterIssues(issues)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	printValidationIssues(issues)
+	reportedFindings = append(reportedFindings, issues...)
+
+	failing := 0
+	for _, issue := range issues {
+		findingCounts[issue.Severity]++
+		if reachesThreshold(issue.Severity) {
+			failing++
+		}
+	}
+
+	if failing > 0 {
+		return &ValidationFailure{fmt.Errorf("%d %s found", failing, label)}
+	}
+	fmt.Printf("  %d findings below the --fail-on threshold (%s)\n", len(issues), validateFailOn)
+	return nil
+}
+
+// printFindingSummary prints the number of findings per severity
+func printFindingSummary() {
+	var parts []string
+	total := 0
+	for _, severity := range severityOrder {
+		parts = append(parts, fmt.Sprintf("%d %s", findingCounts[severity], severity))
+		total += findingCounts[severity]
+	}
+	if total > 0 {
+		fmt.Printf("\nFindings: %s (failing on %s)\n", strings.Join(parts, ", "), validateFailOn)
+	}
+}
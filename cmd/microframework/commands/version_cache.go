@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// updateOffline makes update answer version lookups from the version cache only
+	updateOffline bool
+	// updateCacheTTL is how long update trusts the versions of the version cache
+	updateCacheTTL time.Duration
+)
+
+// versionCacheFile is the file of the cache directory holding the versions looked up
+const versionCacheFile = "versions.json"
+
+// versionCache holds the remote version metadata update looked up, so that repeated checks
+// do not query the module proxy and GitHub again
+type versionCache struct {
+	// Modules are the latest versions of modules, by module path
+	Modules map[string]cachedVersion `json:"modules,omitempty"`
+	// Releases are the tags of the latest GitHub releases, by repository
+	Releases map[string]cachedVersion `json:"releases,omitempty"`
+	// Notes are the release notes of the framework modules, by module path
+	Notes map[string]cachedNotes `json:"notes,omitempty"`
+}
+
+// cachedVersion is a version of the version cache and when it was looked up
+type cachedVersion struct {
+	Version string    `json:"version"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// cachedNotes are release notes of the version cache and when they were looked up
+type cachedNotes struct {
+	Notes   []releaseNotes `json:"notes"`
+	Fetched time.Time      `json:"fetched"`
+}
+
+// cacheDir returns the directory the CLI caches remote metadata in
+func cacheDir() (string, error) {
+	if dir := os.Getenv("MICROFRAMEWORK_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the home directory: %w", err)
+	}
+	return filepath.Join(home, ".microframework", "cache"), nil
+}
+
+// loadVersionCache reads the version cache; a missing or unreadable cache is empty
+func loadVersionCache() *versionCache {
+	cache := &versionCache{}
+	if dir, err := cacheDir(); err == nil {
+		if content, err := os.ReadFile(filepath.Join(dir, versionCacheFile)); err == nil {
+			if err := json.Unmarshal(content, cache); err != nil {
+				warnf("ignoring the unreadable version cache: %v", err)
+				cache = &versionCache{}
+			}
+		}
+	}
+	if cache.Modules == nil {
+		cache.Modules = make(map[string]cachedVersion)
+	}
+	if cache.Releases == nil {
+		cache.Releases = make(map[string]cachedVersion)
+	}
+	if cache.Notes == nil {
+		cache.Notes = make(map[string]cachedNotes)
+	}
+	return cache
+}
+
+// save writes the version cache; failing to is not an error of the lookup, only a warning
+func (c *versionCache) save() {
+	dir, err := cacheDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		var content []byte
+		if content, err = json.MarshalIndent(c, "", "  "); err == nil {
+			err = os.WriteFile(filepath.Join(dir, versionCacheFile), append(content, '\n'), 0644)
+		}
+	}
+	if err != nil {
+		warnf("failed to write the version cache: %v", err)
+	}
+}
+
+// usable tells whether an entry looked up at fetched can answer a lookup: always offline,
+// else while it is younger than the TTL
+func usable(fetched time.Time) bool {
+	return updateOffline || time.Since(fetched) < updateCacheTTL
+}
+
+// latestModuleVersions returns the latest versions of modules, by module path. The modules
+// the cache does not answer are looked up together, with a single go list; offline, they are
+// left out of the result.
+func latestModuleVersions(modules ...string) (map[string]string, error) {
+	cache := loadVersionCache()
+	latest := make(map[string]string)
+	var queries []string
+	for _, module := range uniqueSorted(modules) {
+		if entry, ok := cache.Modules[module]; ok && usable(entry.Fetched) {
+			latest[module] = entry.Version
+		} else if !updateOffline {
+			queries = append(queries, module+"@latest")
+		}
+	}
+	if len(queries) == 0 {
+		return latest, nil
+	}
+
+	output, err := exec.Command("go", append([]string{"list", "-m", "-e", "-json"}, queries...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the latest module versions: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var module struct {
+			Path    string
+			Version string
+			Error   *struct{ Err string }
+		}
+		if err := decoder.Decode(&module); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if module.Error != nil || module.Version == "" {
+			continue
+		}
+		latest[module.Path] = module.Version
+		cache.Modules[module.Path] = cachedVersion{Version: module.Version, Fetched: time.Now()}
+	}
+	cache.save()
+	return latest, nil
+}
+
+// latestModuleVersion returns the latest version of a module, as latestModuleVersions does
+func latestModuleVersion(module string) (string, error) {
+	latest, err := latestModuleVersions(module)
+	if err != nil {
+		return "", err
+	}
+	version, ok := latest[module]
+	switch {
+	case ok:
+		return version, nil
+	case updateOffline:
+		return "", fmt.Errorf("no cached version of %s; run update without --offline to look it up", module)
+	}
+	return "", fmt.Errorf("no version of %s found", module)
+}
+
+// latestReleaseTag returns the tag of the latest GitHub release of repository, from the cache
+// while it is usable
+func latestReleaseTag(repository string) (string, error) {
+	cache := loadVersionCache()
+	if entry, ok := cache.Releases[repository]; ok && usable(entry.Fetched) {
+		return entry.Version, nil
+	}
+	if updateOffline {
+		return "", fmt.Errorf("no cached release of %s", repository)
+	}
+
+	release, err := fetchGitHubRelease(repository, "")
+	if err != nil {
+		return "", err
+	}
+	cache.Releases[repository] = cachedVersion{Version: release.TagName, Fetched: time.Now()}
+	cache.save()
+	return release.TagName, nil
+}
+
+// cachedReleaseNotes returns the release notes of module, from the cache while they are usable
+func cachedReleaseNotes(module string) ([]releaseNotes, error) {
+	cache := loadVersionCache()
+	if entry, ok := cache.Notes[module]; ok && usable(entry.Fetched) {
+		return entry.Notes, nil
+	}
+	if updateOffline {
+		return nil, fmt.Errorf("no cached release notes of %s; run update without --offline to look them up", module)
+	}
+
+	notes, err := fetchReleaseNotes(module)
+	if err != nil {
+		return nil, err
+	}
+	cache.Notes[module] = cachedNotes{Notes: notes, Fetched: time.Now()}
+	cache.save()
+	return notes, nil
+}
@@ -2,62 +2,48 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/open-feature/go-sdk/openfeature"
 	"github.com/sirupsen/logrus"
 
-	// Use the new go-micro-libs library
-	microservices "github.com/anasamu/go-micro-libs"
-	"github.com/anasamu/go-micro-libs/api"
+	"github.com/anasamu/go-micro-framework/pkg/featureflags"
+	"github.com/anasamu/go-micro-framework/pkg/leader"
+	"github.com/anasamu/go-micro-framework/pkg/secrets"
+
 	"github.com/anasamu/go-micro-libs/auth"
-	"github.com/anasamu/go-micro-libs/cache"
-	"github.com/anasamu/go-micro-libs/circuitbreaker"
 	"github.com/anasamu/go-micro-libs/communication"
+	"github.com/anasamu/go-micro-libs/config"
 	"github.com/anasamu/go-micro-libs/database"
 	"github.com/anasamu/go-micro-libs/database/migrations"
-	"github.com/anasamu/go-micro-libs/discovery"
-	"github.com/anasamu/go-micro-libs/email"
-	"github.com/anasamu/go-micro-libs/event"
-	"github.com/anasamu/go-micro-libs/failover"
-	"github.com/anasamu/go-micro-libs/filegen"
 	"github.com/anasamu/go-micro-libs/logging"
 	"github.com/anasamu/go-micro-libs/messaging"
 	"github.com/anasamu/go-micro-libs/middleware"
 	"github.com/anasamu/go-micro-libs/monitoring"
-	"github.com/anasamu/go-micro-libs/payment"
-	"github.com/anasamu/go-micro-libs/ratelimit"
 	"github.com/anasamu/go-micro-libs/scheduling"
-	"github.com/anasamu/go-micro-libs/storage"
 )
 
 // Use types from go-micro-libs
 type (
-	APIManager            = microservices.APIManager
-	ConfigManager         = microservices.ConfigManager
-	LoggingManager        = microservices.LoggingManager
-	MonitoringManager     = microservices.MonitoringManager
-	DatabaseManager       = microservices.DatabaseManager
-	MigrationManager      = migrations.MigrationManager
-	AuthManager           = microservices.AuthManager
-	MiddlewareManager     = microservices.MiddlewareManager
-	CommunicationManager  = microservices.CommunicationManager
-	AIManager             = microservices.AIManager
-	StorageManager        = microservices.StorageManager
-	MessagingManager      = microservices.MessagingManager
-	SchedulingManager     = microservices.SchedulingManager
-	BackupManager         = microservices.BackupManager
-	ChaosManager          = microservices.ChaosManager
-	FailoverManager       = microservices.FailoverManager
-	EventManager          = microservices.EventManager
-	DiscoveryManager      = microservices.DiscoveryManager
-	CacheManager          = microservices.CacheManager
-	RateLimitManager      = microservices.RateLimitManager
-	CircuitBreakerManager = microservices.CircuitBreakerManager
-	FileGenManager        = microservices.FileGenManager
-	PaymentManager        = microservices.PaymentManager
-	EmailManager          = microservices.EmailManager
+	ConfigManager        = config.Manager
+	LoggingManager       = logging.LoggingManager
+	MonitoringManager    = monitoring.MonitoringManager
+	DatabaseManager      = database.DatabaseManager
+	MigrationManager     = migrations.MigrationManager
+	AuthManager          = auth.AuthManager
+	MiddlewareManager    = middleware.MiddlewareManager
+	CommunicationManager = communication.CommunicationManager
+	MessagingManager     = messaging.MessagingManager
+	SchedulingManager    = scheduling.SchedulingManager
+	FeatureFlagManager   = featureflags.Manager
+	LeaderElector        = leader.Elector
+	SecretProvider       = secrets.Provider
 )
 
 // Bootstrap manages the initialization and lifecycle of all microservices components
@@ -89,22 +75,64 @@ type Bootstrap struct {
 	filegenManager        *FileGenManager
 	paymentManager        *PaymentManager
 	emailManager          *EmailManager
+	featureFlagManager    *FeatureFlagManager
+	openFeatureProvider   string
+	leaderElector         *LeaderElector
+
+	// User-defined components, in registration order
+	registered  []Component
+	initialized bool
+
+	// Secret backends registered on top of the built-in ones
+	secretProviders []SecretProvider
 
 	// Framework configuration
 	config *FrameworkConfig
 	logger *logrus.Logger
 	mu     sync.RWMutex
+
+	// Lifecycle state of each component, and the non-critical components that exceeded
+	// their startup timeout
+	states          map[string]ComponentHealth
+	startupTimeouts map[string]error
+	stopping        bool
+	stateMu         sync.RWMutex
+
+	// Configuration reloads, one at a time
+	reloads  []ReloadEvent
+	reloadMu sync.Mutex
+
+	// Framework metrics
+	timings       map[string]componentTimings
+	restarts      map[string]int
+	recoveries    map[string]map[string]int
+	reloadResults map[string]int
+	stopMetrics   context.CancelFunc
+
+	// HTTP server of WithHTTPServer; nil without it
+	httpServer *http.Server
+	httpMux    *http.ServeMux
+
+	// Startup report
+	initStarted       time.Time
+	migrationsApplied int
+	report            *StartupReport
 }
 
 // FrameworkConfig holds framework configuration
 type FrameworkConfig struct {
 	Service    ServiceConfig    `yaml:"service"`
 	Server     ServerConfig     `yaml:"server"`
+	Logging    LoggingConfig    `yaml:"logging"`
 	Database   *DatabaseConfig  `yaml:"database,omitempty"`
 	Auth       *AuthConfig      `yaml:"auth,omitempty"`
 	Messaging  *MessagingConfig `yaml:"messaging,omitempty"`
 	Monitoring MonitoringConfig `yaml:"monitoring"`
 	Optional   OptionalConfig   `yaml:"optional"`
+	Startup    StartupConfig    `yaml:"startup"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown"`
+	Secrets    SecretsConfig    `yaml:"secrets"`
+	Watchdog   WatchdogConfig   `yaml:"watchdog"`
 }
 
 // ServiceConfig holds service configuration
@@ -124,24 +152,134 @@ type ServerConfig struct {
 	IdleTimeout  time.Duration `yaml:"idle_timeout"`
 }
 
-// DatabaseConfig holds database configuration
-type DatabaseConfig struct {
+// LoggingConfig holds logging configuration: the providers, each with its level, and the
+// manager settings next to them
+type LoggingConfig struct {
 	Providers map[string]interface{} `yaml:"providers"`
+	Settings  map[string]interface{} `yaml:",inline"`
+}
+
+// DatabaseConfig holds database configuration: the providers, and the manager settings
+// (default_provider, timeout, ...) next to them
+type DatabaseConfig struct {
+	Providers  map[string]interface{} `yaml:"providers"`
+	Migrations MigrationsConfig       `yaml:"migrations"`
+	Settings   map[string]interface{} `yaml:",inline"`
+}
+
+// What the startup does with pending migrations
+const (
+	MigrationsWarn  = "warn"
+	MigrationsApply = "apply"
+	MigrationsFail  = "fail"
+)
+
+// MigrationsConfig holds the handling of pending migrations at startup. By default they are
+// only reported, so that a deployment with several replicas applies them once, with
+// `microframework migrate up` or a single replica configured to apply them.
+type MigrationsConfig struct {
+	// Auto applies pending migrations at startup; it is the same as on_start: apply
+	Auto bool `yaml:"auto"`
+	// Dir is the migrations directory (default ./migrations)
+	Dir string `yaml:"dir"`
+	// OnStart is warn (the default), apply or fail
+	OnStart string `yaml:"on_start"`
+	// LockTimeout bounds the wait for the migration lock held by another replica (default 1m)
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+}
+
+func (c MigrationsConfig) onStart() string {
+	if c.OnStart == "" && c.Auto {
+		return MigrationsApply
+	}
+	if c.OnStart == "" {
+		return MigrationsWarn
+	}
+	return c.OnStart
+}
+
+func (c MigrationsConfig) dir() string {
+	if c.Dir == "" {
+		return "./migrations"
+	}
+	return c.Dir
+}
+
+func (c MigrationsConfig) lockTimeout() time.Duration {
+	if c.LockTimeout <= 0 {
+		return time.Minute
+	}
+	return c.LockTimeout
 }
 
-// AuthConfig holds authentication configuration
+// AuthConfig holds authentication configuration: the providers, and the manager settings
+// (default_provider, timeout, ...) next to them
 type AuthConfig struct {
 	Providers map[string]interface{} `yaml:"providers"`
+	Settings  map[string]interface{} `yaml:",inline"`
 }
 
-// MessagingConfig holds messaging configuration
+// MessagingConfig holds messaging configuration: the providers, and the manager settings
+// (default_provider, timeout, ...) next to them
 type MessagingConfig struct {
 	Providers map[string]interface{} `yaml:"providers"`
+	Settings  map[string]interface{} `yaml:",inline"`
 }
 
-// MonitoringConfig holds monitoring configuration
+// MonitoringConfig holds monitoring configuration: the providers, and the manager settings
+// (default_provider, timeout, ...) next to them
 type MonitoringConfig struct {
 	Providers map[string]interface{} `yaml:"providers"`
+	Settings  map[string]interface{} `yaml:",inline"`
+}
+
+// StartupConfig holds startup configuration
+type StartupConfig struct {
+	// Retry is the policy for connecting to databases, brokers, caches and monitoring
+	Retry RetryConfig `yaml:"retry"`
+	// ComponentTimeout bounds the initialization and the start of each component (default 60s)
+	ComponentTimeout time.Duration `yaml:"component_timeout"`
+	// Components overrides ComponentTimeout per component, by GetManager name
+	Components map[string]time.Duration `yaml:"components,omitempty"`
+	// NonCritical lists the components the service can start without: one that exceeds its
+	// timeout is left degraded instead of failing the startup
+	NonCritical []string `yaml:"non_critical,omitempty"`
+}
+
+// RetryConfig is a retry policy with exponential backoff
+type RetryConfig struct {
+	// MaxAttempts is the number of connection attempts (default 5)
+	MaxAttempts int `yaml:"max_attempts"`
+	// InitialBackoff is the wait after the first failure (default 1s)
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff caps the wait between attempts (default 30s)
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// Multiplier grows the wait after each failure (default 2)
+	Multiplier float64 `yaml:"multiplier"`
+	// Jitter varies each wait randomly by up to this fraction (default 0.2)
+	Jitter *float64 `yaml:"jitter,omitempty"`
+	// FailFast gives up at the first failure
+	FailFast bool `yaml:"fail_fast"`
+}
+
+// ShutdownConfig holds graceful shutdown configuration
+type ShutdownConfig struct {
+	// Timeout bounds the whole shutdown (default 30s)
+	Timeout time.Duration `yaml:"timeout"`
+	// ComponentTimeout bounds the shutdown of each component (default 10s)
+	ComponentTimeout time.Duration `yaml:"component_timeout"`
+	// Components overrides ComponentTimeout per component, by GetManager name
+	Components map[string]time.Duration `yaml:"components,omitempty"`
+}
+
+// SecretsConfig holds the secret backends that resolve the vault://, ssm:// and gsm://
+// references in the rest of the configuration
+type SecretsConfig struct {
+	// Timeout bounds the resolution of all the references (default 30s)
+	Timeout time.Duration `yaml:"timeout"`
+	// Providers holds the settings of the vault, ssm and gsm backends; a backend without
+	// settings reads them from its usual environment variables
+	Providers map[string]interface{} `yaml:"providers,omitempty"`
 }
 
 // OptionalConfig holds optional features configuration
@@ -161,131 +299,63 @@ type OptionalConfig struct {
 	FileGen        map[string]interface{} `yaml:"filegen,omitempty"`
 	Payment        map[string]interface{} `yaml:"payment,omitempty"`
 	Email          map[string]interface{} `yaml:"email,omitempty"`
+	FeatureFlags   map[string]interface{} `yaml:"featureflags,omitempty"`
+	LeaderElection map[string]interface{} `yaml:"leaderelection,omitempty"`
 }
 
 // Constructor functions using go-micro-libs
-func NewAPIManager(config interface{}, logger *logrus.Logger) *APIManager {
-	if cfg, ok := config.(*api.ManagerConfig); ok {
-		return microservices.NewAPIManager(cfg, logger)
-	}
-	return microservices.NewAPIManager(nil, logger)
-}
-func NewConfigManager() *ConfigManager { return microservices.NewConfigManager() }
+func NewConfigManager() *ConfigManager { return config.NewManager() }
 func NewLoggingManager(config interface{}, logger *logrus.Logger) *LoggingManager {
 	if cfg, ok := config.(*logging.ManagerConfig); ok {
-		return microservices.NewLoggingManager(cfg, logger)
+		return logging.NewLoggingManager(cfg, logger)
 	}
-	return microservices.NewLoggingManager(nil, logger)
+	return logging.NewLoggingManager(nil, logger)
 }
 func NewMonitoringManager(config interface{}, logger *logrus.Logger) *MonitoringManager {
 	if cfg, ok := config.(*monitoring.ManagerConfig); ok {
-		return microservices.NewMonitoringManager(cfg, logger)
+		return monitoring.NewMonitoringManager(cfg, logger)
 	}
-	return microservices.NewMonitoringManager(nil, logger)
+	return monitoring.NewMonitoringManager(nil, logger)
 }
 func NewDatabaseManager(config interface{}, logger *logrus.Logger) *DatabaseManager {
 	if cfg, ok := config.(*database.ManagerConfig); ok {
-		return microservices.NewDatabaseManager(cfg, logger)
+		return database.NewDatabaseManager(cfg, logger)
 	}
-	return microservices.NewDatabaseManager(nil, logger)
+	return database.NewDatabaseManager(nil, logger)
 }
 func NewAuthManager(config interface{}, logger *logrus.Logger) *AuthManager {
 	if cfg, ok := config.(*auth.ManagerConfig); ok {
-		return microservices.NewAuthManager(cfg, logger)
+		return auth.NewAuthManager(cfg, logger)
 	}
-	return microservices.NewAuthManager(nil, logger)
+	return auth.NewAuthManager(nil, logger)
 }
 func NewMiddlewareManager(config interface{}, logger *logrus.Logger) *MiddlewareManager {
 	if cfg, ok := config.(*middleware.ManagerConfig); ok {
-		return microservices.NewMiddlewareManager(cfg, logger)
+		return middleware.NewMiddlewareManager(cfg, logger)
 	}
-	return microservices.NewMiddlewareManager(nil, logger)
+	return middleware.NewMiddlewareManager(nil, logger)
 }
 func NewCommunicationManager(config interface{}, logger *logrus.Logger) *CommunicationManager {
 	if cfg, ok := config.(*communication.ManagerConfig); ok {
-		return microservices.NewCommunicationManager(cfg, logger)
-	}
-	return microservices.NewCommunicationManager(nil, logger)
-}
-func NewAIManager() *AIManager { return microservices.NewAIManager() }
-func NewStorageManager(config interface{}, logger *logrus.Logger) *StorageManager {
-	if cfg, ok := config.(*storage.ManagerConfig); ok {
-		return microservices.NewStorageManager(cfg, logger)
+		return communication.NewCommunicationManager(cfg, logger)
 	}
-	return microservices.NewStorageManager(nil, logger)
+	return communication.NewCommunicationManager(nil, logger)
 }
 func NewMessagingManager(config interface{}, logger *logrus.Logger) *MessagingManager {
 	if cfg, ok := config.(*messaging.ManagerConfig); ok {
-		return microservices.NewMessagingManager(cfg, logger)
+		return messaging.NewMessagingManager(cfg, logger)
 	}
-	return microservices.NewMessagingManager(nil, logger)
+	return messaging.NewMessagingManager(nil, logger)
 }
 func NewSchedulingManager(config interface{}, logger *logrus.Logger) *SchedulingManager {
 	if cfg, ok := config.(*scheduling.ManagerConfig); ok {
-		return microservices.NewSchedulingManager(cfg, logger)
+		return scheduling.NewSchedulingManager(cfg, logger)
 	}
-	return microservices.NewSchedulingManager(nil, logger)
-}
-func NewBackupManager() *BackupManager { return microservices.NewBackupManager() }
-func NewChaosManager() *ChaosManager   { return microservices.NewChaosManager() }
-func NewFailoverManager(config interface{}, logger *logrus.Logger) *FailoverManager {
-	if cfg, ok := config.(*failover.ManagerConfig); ok {
-		return microservices.NewFailoverManager(cfg, logger)
-	}
-	return microservices.NewFailoverManager(nil, logger)
-}
-func NewEventManager(config interface{}, logger *logrus.Logger) *EventManager {
-	if cfg, ok := config.(*event.ManagerConfig); ok {
-		return microservices.NewEventManager(cfg, logger)
-	}
-	return microservices.NewEventManager(nil, logger)
-}
-func NewDiscoveryManager(config interface{}, logger *logrus.Logger) *DiscoveryManager {
-	if cfg, ok := config.(*discovery.ManagerConfig); ok {
-		return microservices.NewDiscoveryManager(cfg, logger)
-	}
-	return microservices.NewDiscoveryManager(nil, logger)
-}
-func NewCacheManager(config interface{}, logger *logrus.Logger) *CacheManager {
-	if cfg, ok := config.(*cache.ManagerConfig); ok {
-		return microservices.NewCacheManager(cfg, logger)
-	}
-	return microservices.NewCacheManager(nil, logger)
-}
-func NewRateLimitManager(config interface{}, logger *logrus.Logger) *RateLimitManager {
-	if cfg, ok := config.(*ratelimit.ManagerConfig); ok {
-		return microservices.NewRateLimitManager(cfg, logger)
-	}
-	return microservices.NewRateLimitManager(nil, logger)
-}
-func NewCircuitBreakerManager(config interface{}, logger *logrus.Logger) *CircuitBreakerManager {
-	if cfg, ok := config.(*circuitbreaker.ManagerConfig); ok {
-		return microservices.NewCircuitBreakerManager(cfg, logger)
-	}
-	return microservices.NewCircuitBreakerManager(nil, logger)
-}
-func NewFilegenManager(config interface{}) (*FileGenManager, error) {
-	if cfg, ok := config.(*filegen.ManagerConfig); ok {
-		return microservices.NewFileGenManager(cfg)
-	}
-	return microservices.NewFileGenManager(nil)
-}
-func NewPaymentManager(config interface{}, logger *logrus.Logger) *PaymentManager {
-	if cfg, ok := config.(*payment.ManagerConfig); ok {
-		return microservices.NewPaymentManager(cfg, logger)
-	}
-	return microservices.NewPaymentManager(nil, logger)
-}
-func NewEmailManager(config interface{}, logger *logrus.Logger) *EmailManager {
-	if cfg, ok := config.(*email.ManagerConfig); ok {
-		return microservices.NewEmailManager(cfg, logger)
-	}
-	return microservices.NewEmailManager(nil, logger)
+	return scheduling.NewSchedulingManager(nil, logger)
 }
 
 // Config functions using go-micro-libs
-func DefaultAPIManagerConfig() interface{} { return api.DefaultManagerConfig() }
-func DefaultManagerConfig() interface{}    { return map[string]interface{}{} }
+func DefaultManagerConfig() interface{} { return map[string]interface{}{} }
 func DefaultMonitoringManagerConfig() interface{} {
 	return monitoring.DefaultManagerConfig()
 }
@@ -293,20 +363,12 @@ func DefaultDatabaseManagerConfig() interface{}      { return database.DefaultMa
 func DefaultAuthManagerConfig() interface{}          { return auth.DefaultManagerConfig() }
 func DefaultMiddlewareManagerConfig() interface{}    { return middleware.DefaultManagerConfig() }
 func DefaultCommunicationManagerConfig() interface{} { return communication.DefaultManagerConfig() }
-func DefaultStorageManagerConfig() interface{}       { return storage.DefaultManagerConfig() }
 func DefaultMessagingManagerConfig() interface{} {
 	return messaging.DefaultManagerConfig()
 }
-func DefaultSchedulingManagerConfig() interface{}     { return map[string]interface{}{} }
-func DefaultFailoverManagerConfig() interface{}       { return map[string]interface{}{} }
-func DefaultEventManagerConfig() interface{}          { return map[string]interface{}{} }
-func DefaultDiscoveryManagerConfig() interface{}      { return map[string]interface{}{} }
-func DefaultCacheManagerConfig() interface{}          { return map[string]interface{}{} }
-func DefaultRateLimitManagerConfig() interface{}      { return map[string]interface{}{} }
-func DefaultCircuitBreakerManagerConfig() interface{} { return map[string]interface{}{} }
-func DefaultFilegenManagerConfig() interface{}        { return map[string]interface{}{} }
-func DefaultPaymentManagerConfig() interface{}        { return payment.DefaultManagerConfig() }
-func DefaultEmailManagerConfig() interface{}          { return email.DefaultManagerConfig() }
+func DefaultSchedulingManagerConfig() interface{} {
+	return &scheduling.ManagerConfig{DefaultProvider: "default", RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
 
 // NewBootstrap creates a new bootstrap instance
 func NewBootstrap(config *FrameworkConfig, logger *logrus.Logger) *Bootstrap {
@@ -323,6 +385,12 @@ func NewBootstrap(config *FrameworkConfig, logger *logrus.Logger) *Bootstrap {
 // Initialize initializes all configured components
 func (b *Bootstrap) Initialize(ctx context.Context) error {
 	b.logger.Info("Initializing microservices framework...")
+	b.initStarted = time.Now()
+
+	// Resolve the secret references before anything reads the configuration
+	if err := b.resolveSecrets(ctx, b.config); err != nil {
+		return fmt.Errorf("failed to resolve secrets: %w", err)
+	}
 
 	// Initialize core components
 	if err := b.initializeCoreComponents(ctx); err != nil {
@@ -334,208 +402,217 @@ func (b *Bootstrap) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize optional components: %w", err)
 	}
 
+	// Initialize registered components
+	if err := b.initializeRegisteredComponents(ctx); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.initialized = true
+	b.mu.Unlock()
+	b.setAllComponentStates(StateStarting)
+	b.markTimedOutComponents()
 	b.logger.Info("Microservices framework initialized successfully")
 	return nil
 }
 
+// Core and optional components, in initialization order
+var (
+	coreComponents     = []string{"config", "logging", "monitoring", "database", "auth", "middleware", "communication"}
+	optionalComponents = []string{"api", "ai", "storage", "messaging", "leaderelection", "scheduling", "backup", "chaos", "failover", "event", "discovery", "cache", "ratelimit", "circuitbreaker", "filegen", "payment", "email", "featureflags"}
+)
+
 // initializeCoreComponents initializes core components
 func (b *Bootstrap) initializeCoreComponents(ctx context.Context) error {
-	// Initialize configuration manager
-	b.configManager = NewConfigManager()
-	b.logger.Info("Configuration manager initialized")
-
-	// Initialize logging manager
-	b.loggingManager = NewLoggingManager(
-		DefaultManagerConfig(),
-		b.logger,
-	)
-	b.logger.Info("Logging manager initialized")
-
-	// Initialize monitoring manager
-	b.monitoringManager = NewMonitoringManager(
-		DefaultMonitoringManagerConfig(),
-		b.logger,
-	)
-	b.logger.Info("Monitoring manager initialized")
-
-	// Initialize database manager if configured
-	if b.config.Database != nil {
-		b.databaseManager = NewDatabaseManager(
-			DefaultDatabaseManagerConfig(),
-			b.logger,
-		)
-		b.logger.Info("Database manager initialized")
-		
-		// Initialize migration manager for database
-		b.migrationManager = migrations.NewMigrationManager(
-			nil, // Will be set when database provider is available
-			b.logger,
-		)
-		b.logger.Info("Migration manager initialized")
-	}
-
-	// Initialize auth manager if configured
-	if b.config.Auth != nil {
-		b.authManager = NewAuthManager(
-			DefaultAuthManagerConfig(),
-			b.logger,
-		)
-		b.logger.Info("Auth manager initialized")
+	for _, name := range coreComponents {
+		if err := b.runStartupStep(ctx, name, "init", func(ctx context.Context) error { return b.initializeComponent(ctx, name) }); err != nil {
+			return err
+		}
 	}
-
-	// Initialize middleware manager
-	b.middlewareManager = NewMiddlewareManager(
-		DefaultMiddlewareManagerConfig(),
-		b.logger,
-	)
-	b.logger.Info("Middleware manager initialized")
-
-	// Initialize communication manager
-	b.communicationManager = NewCommunicationManager(
-		DefaultCommunicationManagerConfig(),
-		b.logger,
-	)
-	b.logger.Info("Communication manager initialized")
-
 	return nil
 }
 
 // initializeOptionalComponents initializes optional components
 func (b *Bootstrap) initializeOptionalComponents(ctx context.Context) error {
-	// Initialize API manager if configured
-	if b.config.Optional.API != nil {
-		b.apiManager = NewAPIManager(
-			DefaultAPIManagerConfig(),
-			b.logger,
-		)
-		b.logger.Info("API manager initialized")
-	}
-
-	// Initialize AI manager if configured
-	if b.config.Optional.AI != nil {
-		b.aiManager = NewAIManager()
-		b.logger.Info("AI manager initialized")
+	for _, name := range optionalComponents {
+		if err := b.runStartupStep(ctx, name, "init", func(ctx context.Context) error { return b.initializeComponent(ctx, name) }); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Initialize storage manager if configured
-	if b.config.Optional.Storage != nil {
-		b.storageManager = NewStorageManager(
-			DefaultStorageManagerConfig(),
-			b.logger,
-		)
-		b.logger.Info("Storage manager initialized")
-	}
+// initializeComponent creates the manager of a component from the configuration; optional
+// components are only created when configured
+func (b *Bootstrap) initializeComponent(ctx context.Context, name string) error {
+	switch name {
+	case "config":
+		// Initialize configuration manager
+		b.configManager = NewConfigManager()
+		b.logger.Info("Configuration manager initialized")
 
-	// Initialize messaging manager if configured
-	if b.config.Messaging != nil {
-		b.messagingManager = NewMessagingManager(
-			DefaultMessagingManagerConfig(),
+	case "logging":
+		// Initialize logging manager
+		if err := b.applyLogLevel(); err != nil {
+			return err
+		}
+		b.loggingManager = NewLoggingManager(
+			DefaultManagerConfig(),
 			b.logger,
 		)
-		b.logger.Info("Messaging manager initialized")
-	}
+		b.logger.Info("Logging manager initialized")
 
-	// Initialize scheduling manager if configured
-	if b.config.Optional.Scheduling != nil {
-		b.schedulingManager = NewSchedulingManager(
-			DefaultSchedulingManagerConfig(),
+	case "monitoring":
+		// Initialize monitoring manager
+		monitoringConfig, err := managerConfig(DefaultMonitoringManagerConfig(), "monitoring", b.config.Monitoring.Settings, b.config.Monitoring.Providers)
+		if err != nil {
+			return fmt.Errorf("failed to configure monitoring manager: %w", err)
+		}
+		b.monitoringManager = NewMonitoringManager(
+			monitoringConfig,
 			b.logger,
 		)
-		b.logger.Info("Scheduling manager initialized")
-	}
+		b.logger.Info("Monitoring manager initialized")
 
-	// Initialize backup manager if configured
-	if b.config.Optional.Backup != nil {
-		b.backupManager = NewBackupManager()
-		b.logger.Info("Backup manager initialized")
-	}
-
-	// Initialize chaos manager if configured
-	if b.config.Optional.Chaos != nil {
-		b.chaosManager = NewChaosManager()
-		b.logger.Info("Chaos manager initialized")
-	}
-
-	// Initialize failover manager if configured
-	if b.config.Optional.Failover != nil {
-		b.failoverManager = NewFailoverManager(
-			DefaultFailoverManagerConfig(),
+	case "database":
+		// Initialize database manager if configured
+		if b.config.Database == nil {
+			return nil
+		}
+		databaseConfig, err := managerConfig(DefaultDatabaseManagerConfig(), "database", b.config.Database.Settings, b.config.Database.Providers)
+		if err != nil {
+			return fmt.Errorf("failed to configure database manager: %w", err)
+		}
+		b.databaseManager = NewDatabaseManager(
+			databaseConfig,
 			b.logger,
 		)
-		b.logger.Info("Failover manager initialized")
-	}
+		b.logger.Info("Database manager initialized")
 
-	// Initialize event manager if configured
-	if b.config.Optional.Event != nil {
-		b.eventManager = NewEventManager(
-			DefaultEventManagerConfig(),
+		// Initialize migration manager for database
+		b.migrationManager = migrations.NewMigrationManager(
+			nil, // Will be set when database provider is available
 			b.logger,
 		)
-		b.logger.Info("Event manager initialized")
-	}
+		b.logger.Info("Migration manager initialized")
 
-	// Initialize discovery manager if configured
-	if b.config.Optional.Discovery != nil {
-		b.discoveryManager = NewDiscoveryManager(
-			DefaultDiscoveryManagerConfig(),
+	case "auth":
+		// Initialize auth manager if configured
+		if b.config.Auth == nil {
+			return nil
+		}
+		authConfig, err := managerConfig(DefaultAuthManagerConfig(), "auth", b.config.Auth.Settings, b.config.Auth.Providers)
+		if err != nil {
+			return fmt.Errorf("failed to configure auth manager: %w", err)
+		}
+		b.authManager = NewAuthManager(
+			authConfig,
 			b.logger,
 		)
-		b.logger.Info("Discovery manager initialized")
-	}
+		b.logger.Info("Auth manager initialized")
 
-	// Initialize cache manager if configured
-	if b.config.Optional.Cache != nil {
-		b.cacheManager = NewCacheManager(
-			DefaultCacheManagerConfig(),
+	case "middleware":
+		// Initialize middleware manager
+		b.middlewareManager = NewMiddlewareManager(
+			DefaultMiddlewareManagerConfig(),
 			b.logger,
 		)
-		b.logger.Info("Cache manager initialized")
-	}
+		b.logger.Info("Middleware manager initialized")
 
-	// Initialize rate limit manager if configured
-	if b.config.Optional.RateLimit != nil {
-		b.rateLimitManager = NewRateLimitManager(
-			DefaultRateLimitManagerConfig(),
+	case "communication":
+		// Initialize communication manager
+		b.communicationManager = NewCommunicationManager(
+			DefaultCommunicationManagerConfig(),
 			b.logger,
 		)
-		b.logger.Info("Rate limit manager initialized")
-	}
+		b.logger.Info("Communication manager initialized")
 
-	// Initialize circuit breaker manager if configured
-	if b.config.Optional.CircuitBreaker != nil {
-		b.circuitBreakerManager = NewCircuitBreakerManager(
-			DefaultCircuitBreakerManagerConfig(),
+	case "api":
+		return b.initializeAPI()
+	case "ai":
+		return b.initializeAI()
+	case "storage":
+		return b.initializeStorage()
+	case "messaging":
+		// Initialize messaging manager if configured
+		if b.config.Messaging == nil {
+			return nil
+		}
+		messagingConfig, err := managerConfig(DefaultMessagingManagerConfig(), "messaging", b.config.Messaging.Settings, b.config.Messaging.Providers)
+		if err != nil {
+			return fmt.Errorf("failed to configure messaging manager: %w", err)
+		}
+		b.messagingManager = NewMessagingManager(
+			messagingConfig,
 			b.logger,
 		)
-		b.logger.Info("Circuit breaker manager initialized")
-	}
+		b.logger.Info("Messaging manager initialized")
 
-	// Initialize file generation manager if configured
-	if b.config.Optional.FileGen != nil {
-		var err error
-		b.filegenManager, err = NewFilegenManager(DefaultFilegenManagerConfig())
+	case "scheduling":
+		// Initialize scheduling manager if configured
+		if b.config.Optional.Scheduling == nil {
+			return nil
+		}
+		schedulingConfig, err := optionalManagerConfig(DefaultSchedulingManagerConfig(), "optional.scheduling", b.config.Optional.Scheduling)
 		if err != nil {
-			return fmt.Errorf("failed to initialize file generation manager: %w", err)
+			return fmt.Errorf("failed to configure scheduling manager: %w", err)
 		}
-		b.logger.Info("File generation manager initialized")
-	}
-
-	// Initialize payment manager if configured
-	if b.config.Optional.Payment != nil {
-		b.paymentManager = NewPaymentManager(
-			DefaultPaymentManagerConfig(),
+		b.schedulingManager = NewSchedulingManager(
+			schedulingConfig,
 			b.logger,
 		)
-		b.logger.Info("Payment manager initialized")
-	}
+		b.logger.Info("Scheduling manager initialized")
 
-	// Initialize email manager if configured
-	if b.config.Optional.Email != nil {
-		b.emailManager = NewEmailManager(
-			DefaultEmailManagerConfig(),
-			b.logger,
-		)
-		b.logger.Info("Email manager initialized")
+	case "backup":
+		return b.initializeBackup()
+	case "chaos":
+		return b.initializeChaos()
+	case "failover":
+		return b.initializeFailover()
+	case "event":
+		return b.initializeEvent()
+	case "discovery":
+		return b.initializeDiscovery()
+	case "cache":
+		return b.initializeCache()
+	case "ratelimit":
+		return b.initializeRateLimit()
+	case "circuitbreaker":
+		return b.initializeCircuitBreaker()
+	case "filegen":
+		return b.initializeFileGen()
+	case "payment":
+		return b.initializePayment()
+	case "email":
+		return b.initializeEmail()
+	case "featureflags":
+		// Initialize feature flag manager if configured
+		if b.config.Optional.FeatureFlags == nil {
+			return nil
+		}
+		manager, err := newFeatureFlagManager(b.config.Optional.FeatureFlags)
+		if err != nil {
+			return fmt.Errorf("failed to configure feature flag manager: %w", err)
+		}
+		provider, err := openFeatureProvider(b.config.Optional.FeatureFlags)
+		if err != nil {
+			return fmt.Errorf("failed to configure feature flag manager: %w", err)
+		}
+		b.featureFlagManager = manager
+		b.openFeatureProvider = provider
+		b.logger.Info("Feature flag manager initialized")
+
+	case "leaderelection":
+		// Initialize leader election if configured
+		if b.config.Optional.LeaderElection == nil {
+			return nil
+		}
+		elector, err := newLeaderElector(b.config.Optional.LeaderElection, b.config.Service.Name)
+		if err != nil {
+			return fmt.Errorf("failed to configure leader election: %w", err)
+		}
+		b.leaderElector = elector
+		b.logger.WithField("identity", elector.Identity()).Info("Leader election initialized")
 	}
 
 	return nil
@@ -545,6 +622,10 @@ func (b *Bootstrap) initializeOptionalComponents(ctx context.Context) error {
 func (b *Bootstrap) Start(ctx context.Context) error {
 	b.logger.Info("Starting microservices framework...")
 
+	if err := b.startHTTPServer(); err != nil {
+		return err
+	}
+
 	// Start core components
 	if err := b.startCoreComponents(ctx); err != nil {
 		return fmt.Errorf("failed to start core components: %w", err)
@@ -555,141 +636,229 @@ func (b *Bootstrap) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start optional components: %w", err)
 	}
 
+	// Start registered components
+	if err := b.startRegisteredComponents(ctx); err != nil {
+		return err
+	}
+
+	b.setAllComponentStates(StateReady)
+	b.markTimedOutComponents()
+
+	// Report the framework metrics, and supervise the components, until Stop
+	metricsCtx, stopMetrics := context.WithCancel(context.WithoutCancel(ctx))
+	b.stopMetrics = stopMetrics
+	b.submitMetrics(metricsCtx)
+	go b.reportMetrics(metricsCtx)
+	go b.superviseComponents(metricsCtx)
+
+	b.logStartupReport()
+
 	b.logger.Info("Microservices framework started successfully")
 	return nil
 }
 
 // startCoreComponents starts core components
 func (b *Bootstrap) startCoreComponents(ctx context.Context) error {
-	// Start monitoring
-	if b.monitoringManager != nil {
-		// Connect to default monitoring provider
-		if err := b.monitoringManager.Connect(ctx, "prometheus"); err != nil {
-			return fmt.Errorf("failed to start monitoring: %w", err)
+	for _, name := range coreComponents {
+		if err := b.runStartupStep(ctx, name, "start", func(ctx context.Context) error { return b.startComponent(ctx, name) }); err != nil {
+			return err
 		}
 	}
-
-	// Start database connections
-	if b.databaseManager != nil {
-		// Connect to configured databases
-		for provider := range b.config.Database.Providers {
-			if err := b.databaseManager.Connect(ctx, provider); err != nil {
-				return fmt.Errorf("failed to connect to database %s: %w", provider, err)
-			}
-		}
-		
-		// Run database migrations if migration manager is available
-		if b.migrationManager != nil {
-			if err := b.runDatabaseMigrations(ctx); err != nil {
-				return fmt.Errorf("failed to run database migrations: %w", err)
-			}
-		}
-	}
-
-	// Start communication server
-	if b.communicationManager != nil {
-		if err := b.communicationManager.Start(ctx, "http", map[string]interface{}{}); err != nil {
-			return fmt.Errorf("failed to start communication: %w", err)
-		}
-	}
-
 	return nil
 }
 
 // startOptionalComponents starts optional components
 func (b *Bootstrap) startOptionalComponents(ctx context.Context) error {
-	// API manager is ready to use (no explicit start needed)
-
-	// Email manager is ready to use (no explicit start needed)
-
-	// Start messaging
-	if b.messagingManager != nil {
-		for provider := range b.config.Messaging.Providers {
-			if err := b.messagingManager.Connect(ctx, provider); err != nil {
-				return fmt.Errorf("failed to connect to messaging %s: %w", provider, err)
-			}
+	for _, name := range optionalComponents {
+		if err := b.runStartupStep(ctx, name, "start", func(ctx context.Context) error { return b.startComponent(ctx, name) }); err != nil {
+			return err
 		}
 	}
-
-	// Discovery manager is ready to use (no explicit start needed)
-
 	return nil
 }
 
-// Stop stops all components gracefully
-func (b *Bootstrap) Stop(ctx context.Context) error {
-	b.logger.Info("Stopping microservices framework...")
+// startComponent starts an initialized component; the other managers are ready to use
+// without an explicit start
+func (b *Bootstrap) startComponent(ctx context.Context, name string) error {
+	switch name {
+	case "monitoring":
+		// Start monitoring
+		if b.monitoringManager != nil {
+			// Connect to default monitoring provider
+			if err := b.connectWithRetry(ctx, "monitoring prometheus", func(ctx context.Context) error {
+				return b.monitoringManager.Connect(ctx, "prometheus")
+			}); err != nil {
+				return fmt.Errorf("failed to start monitoring: %w", err)
+			}
+		}
 
-	// Stop components in reverse order
-	if b.communicationManager != nil {
-		b.communicationManager.Stop(ctx, "http")
-	}
+	case "database":
+		// Start database connections
+		if b.databaseManager != nil {
+			// Connect to configured databases
+			for provider := range b.config.Database.Providers {
+				if err := b.connectWithRetry(ctx, "database "+provider, func(ctx context.Context) error {
+					return b.databaseManager.Connect(ctx, provider)
+				}); err != nil {
+					return fmt.Errorf("failed to connect to database %s: %w", provider, err)
+				}
+			}
 
-	if b.apiManager != nil {
-		b.apiManager.Close()
-	}
+			// Run database migrations if migration manager is available
+			if b.migrationManager != nil {
+				if err := b.runDatabaseMigrations(ctx); err != nil {
+					return fmt.Errorf("failed to run database migrations: %w", err)
+				}
+			}
+		}
 
-	if b.emailManager != nil {
-		b.emailManager.Close()
-	}
+	case "communication":
+		// Start communication server
+		if b.communicationManager != nil {
+			if err := b.communicationManager.Start(ctx, "http", b.serverSettings()); err != nil {
+				return fmt.Errorf("failed to start communication: %w", err)
+			}
+		}
 
-	if b.messagingManager != nil {
-		b.messagingManager.Close()
-	}
+	case "messaging":
+		// Start messaging
+		if b.messagingManager != nil {
+			for provider := range b.config.Messaging.Providers {
+				if err := b.connectWithRetry(ctx, "messaging "+provider, func(ctx context.Context) error {
+					return b.messagingManager.Connect(ctx, provider)
+				}); err != nil {
+					return fmt.Errorf("failed to connect to messaging %s: %w", provider, err)
+				}
+			}
+		}
 
-	if b.databaseManager != nil {
-		b.databaseManager.Close()
-	}
+	case "cache":
+		// Connect the registered cache providers
+		return b.startCache(ctx)
+
+	case "featureflags":
+		// Load the flags
+		if b.featureFlagManager != nil {
+			if err := b.connectWithRetry(ctx, "feature flags", b.featureFlagManager.Start); err != nil {
+				return fmt.Errorf("failed to load feature flags: %w", err)
+			}
+			// Flags read with the OpenFeature SDK are evaluated by the manager too
+			if b.openFeatureProvider == "env" || b.openFeatureProvider == "file" {
+				if err := openfeature.SetProviderAndWait(featureflags.NewOpenFeatureProvider(b.featureFlagManager)); err != nil {
+					return fmt.Errorf("failed to register the OpenFeature provider: %w", err)
+				}
+			}
+		}
+
+	case "leaderelection":
+		// Join the election
+		if elector := b.leaderElector; elector != nil {
+			if err := b.connectWithRetry(ctx, "leader election "+elector.Backend(), elector.Start); err != nil {
+				return fmt.Errorf("failed to start leader election: %w", err)
+			}
+			elector.OnChange(func(leader bool) {
+				b.logger.WithField("identity", elector.Identity()).Infof("Leadership changed: leader=%t", leader)
+			})
+		}
 
-	if b.monitoringManager != nil {
-		b.monitoringManager.Close()
+	case "scheduling":
+		// Run the scheduled tasks on the leader only
+		if b.schedulingManager != nil {
+			b.gateScheduling()
+		}
 	}
 
-	b.logger.Info("Microservices framework stopped successfully")
 	return nil
 }
 
-// HealthCheck performs health check on all components
-func (b *Bootstrap) HealthCheck(ctx context.Context) map[string]interface{} {
-	health := make(map[string]interface{})
-
-	// Check core components
-	if b.monitoringManager != nil {
-		health["monitoring"] = b.monitoringManager.HealthCheckAll(ctx)
+// serverSettings returns the settings the HTTP server of the communication manager starts
+// with: those of the server section, when it sets a port, over the provider's own
+func (b *Bootstrap) serverSettings() map[string]interface{} {
+	settings := map[string]interface{}{}
+	server := b.config.Server
+	if server.Port == 0 {
+		return settings
+	}
+	settings["host"] = server.Host
+	settings["port"] = server.Port
+	for key, timeout := range map[string]time.Duration{
+		"read_timeout":  server.ReadTimeout,
+		"write_timeout": server.WriteTimeout,
+		"idle_timeout":  server.IdleTimeout,
+	} {
+		if timeout > 0 {
+			settings[key] = timeout
+		}
 	}
+	return settings
+}
 
-	if b.databaseManager != nil {
-		health["database"] = b.databaseManager.HealthCheck(ctx)
-	}
+// Stop stops every initialized component in reverse dependency order, each within its
+// shutdown timeout and all within the overall shutdown deadline. Failures do not stop the
+// shutdown; they are returned together.
+func (b *Bootstrap) Stop(ctx context.Context) error {
+	b.logger.Info("Stopping microservices framework...")
 
-	if b.authManager != nil {
-		health["auth"] = b.authManager.HealthCheck(ctx)
+	b.stateMu.Lock()
+	b.stopping = true
+	b.stateMu.Unlock()
+	b.setAllComponentStates(StateStopping)
+	if b.stopMetrics != nil {
+		b.stopMetrics()
 	}
 
-	if b.apiManager != nil {
-		health["api"] = b.apiManager.HealthCheck(ctx)
-	}
+	ctx, cancel := context.WithTimeout(ctx, b.shutdownTimeout())
+	defer cancel()
 
-	if b.emailManager != nil {
-		health["email"] = b.emailManager.HealthCheck(ctx)
+	// Stop taking requests before stopping what they use
+	var errs []error
+	if err := b.stopHTTPServer(ctx); err != nil {
+		b.logger.WithError(err).Warn("Failed to stop the HTTP server")
+		errs = append(errs, fmt.Errorf("http server: %w", err))
 	}
-
-	if b.messagingManager != nil {
-		health["messaging"] = b.messagingManager.HealthCheck(ctx)
+	components := b.snapshotComponents()
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		if component.stop == nil {
+			continue
+		}
+		if err := b.stopComponent(ctx, component); err != nil {
+			b.logger.WithError(err).Warnf("Failed to stop %s", component.name)
+			errs = append(errs, fmt.Errorf("%s: %w", component.name, err))
+			continue
+		}
+		b.logger.Debugf("Stopped %s", component.name)
 	}
 
-	if b.discoveryManager != nil {
-		health["discovery"] = "ready"
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to stop %d components: %w", len(errs), errors.Join(errs...))
 	}
+	b.logger.Info("Microservices framework stopped successfully")
+	return nil
+}
 
-	if b.cacheManager != nil {
-		health["cache"] = "ready"
+// HealthCheck runs the health checks of the started components, marks those that fail as
+// degraded and those that pass as ready, and returns the state of every component. The checks
+// run on the managers of the moment HealthCheck started, so a reload or a restart replacing
+// them meanwhile does not affect it.
+func (b *Bootstrap) HealthCheck(ctx context.Context) map[string]ComponentHealth {
+	current := b.ComponentStates()
+	for _, component := range b.snapshotComponents() {
+		state := current[component.name].State
+		if component.check == nil || (state != StateReady && state != StateDegraded) {
+			continue
+		}
+		if err := component.check(ctx); err != nil {
+			b.setComponentState(component.name, StateDegraded, err)
+		} else {
+			b.setComponentState(component.name, StateReady, nil)
+		}
 	}
-
-	return health
+	return b.ComponentStates()
 }
 
-// GetManager returns a specific manager by name
+// GetManager returns a specific manager, or a registered Component, by name. The typed
+// getters, such as GetDatabaseManager, avoid the type assertion.
 func (b *Bootstrap) GetManager(name string) interface{} {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -741,37 +910,118 @@ func (b *Bootstrap) GetManager(name string) interface{} {
 		return b.paymentManager
 	case "email":
 		return b.emailManager
+	case "featureflags":
+		return b.featureFlagManager
+	case "leaderelection":
+		return b.leaderElector
 	case "migration":
 		return b.migrationManager
 	default:
+		for _, component := range b.registered {
+			if component.Name() == name {
+				return component
+			}
+		}
 		return nil
 	}
 }
 
-// runDatabaseMigrations runs database migrations using the migration manager
+// runDatabaseMigrations checks the migrations pending on the default database provider and,
+// following database.migrations.on_start, warns about them, applies them under the migration
+// lock or fails the startup
 func (b *Bootstrap) runDatabaseMigrations(ctx context.Context) error {
+	settings := b.config.Database.Migrations
+	onStart := settings.onStart()
+
 	// Get default database provider
 	provider, err := b.databaseManager.GetDefaultProvider()
 	if err != nil {
 		return fmt.Errorf("failed to get default database provider: %w", err)
 	}
-	
-	// Set the provider in migration manager
-	b.migrationManager = migrations.NewMigrationManager(provider, b.logger)
-	
+
+	// Set the provider in migration manager; the managers are read under b.mu while a reload
+	// or the watchdog restarts the database
+	manager := migrations.NewMigrationManager(provider, b.logger)
+	b.mu.Lock()
+	b.migrationManager = manager
+	b.mu.Unlock()
+
 	// Initialize migration table
-	if err := b.migrationManager.Initialize(ctx); err != nil {
+	if err := manager.Initialize(ctx); err != nil {
 		return fmt.Errorf("failed to initialize migration table: %w", err)
 	}
-	
+
 	// Create CLI manager for migrations
-	cliManager := migrations.NewCLIManager(provider, "./migrations", b.logger)
-	
-	// Apply pending migrations
+	cliManager := migrations.NewCLIManager(provider, settings.dir(), b.logger)
+
+	pending, err := b.pendingMigrations(ctx, manager, cliManager)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	switch onStart {
+	case MigrationsWarn:
+		b.logger.WithField("pending", pending).Warnf("%d database migrations are pending; apply them with `microframework migrate up`", len(pending))
+		return nil
+	case MigrationsFail:
+		return fmt.Errorf("%d database migrations are pending: %s", len(pending), strings.Join(pending, ", "))
+	}
+
+	// Apply pending migrations under the lock, so that one replica applies them
+	release, err := lockMigrations(ctx, provider, settings.lockTimeout(), b.logger)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Count the migrations applied for the startup report; another replica may have applied
+	// them while this one waited for the lock
+	applied, err := manager.GetAppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
 	if err := cliManager.Up(ctx); err != nil {
 		return fmt.Errorf("failed to apply database migrations: %w", err)
 	}
-	
-	b.logger.Info("Database migrations applied successfully")
+	after, err := manager.GetAppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	b.mu.Lock()
+	b.migrationsApplied = len(after) - len(applied)
+	b.mu.Unlock()
+
+	b.logger.WithField("count", len(after)-len(applied)).Info("Database migrations applied successfully")
 	return nil
 }
+
+// pendingMigrations returns the versions of the migrations of the migrations directory that
+// are not applied yet
+func (b *Bootstrap) pendingMigrations(ctx context.Context, manager *MigrationManager, cliManager *migrations.CLIManager) ([]string, error) {
+	if _, err := os.Stat(b.config.Database.Migrations.dir()); os.IsNotExist(err) {
+		return nil, nil
+	}
+	available, err := cliManager.LoadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	applied, err := manager.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+	var pending []string
+	for _, migration := range available {
+		if !done[migration.Version] {
+			pending = append(pending, migration.Version)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Component is a user-defined subsystem that takes part in the lifecycle of the framework.
+// Registered components are initialized and started after the built-in managers, in
+// registration order, stopped before the communication server closes the managers they use,
+// health checked with them, and returned by GetManager under their name.
+type Component interface {
+	// Name identifies the component in GetManager, health reports and logs
+	Name() string
+	// Init prepares the component; the built-in managers are initialized by then
+	Init(ctx context.Context) error
+	// Start starts the component; the built-in managers are started by then
+	Start(ctx context.Context) error
+	// Stop releases what the component holds, within the shutdown timeout of ctx
+	Stop(ctx context.Context) error
+	// Health returns an error while the component is not healthy
+	Health(ctx context.Context) error
+}
+
+// Register adds a component to the lifecycle. It must be called before Initialize, with a
+// name that no built-in or registered component uses.
+func (b *Bootstrap) Register(component Component) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	name := component.Name()
+	if name == "" {
+		return fmt.Errorf("component name is required")
+	}
+	if b.initialized {
+		return fmt.Errorf("component %s registered after Initialize", name)
+	}
+	if builtinComponent(name) {
+		return fmt.Errorf("component %s: the name is used by a built-in manager", name)
+	}
+	for _, registered := range b.registered {
+		if registered.Name() == name {
+			return fmt.Errorf("component %s is already registered", name)
+		}
+	}
+
+	b.registered = append(b.registered, component)
+	return nil
+}
+
+// GetComponent returns the registered component named name
+func (b *Bootstrap) GetComponent(name string) (Component, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, component := range b.registered {
+		if component.Name() == name {
+			return component, true
+		}
+	}
+	return nil, false
+}
+
+// initializeRegisteredComponents initializes the registered components
+func (b *Bootstrap) initializeRegisteredComponents(ctx context.Context) error {
+	for _, component := range b.registered {
+		if err := b.runStartupStep(ctx, component.Name(), "init", component.Init); err != nil {
+			return fmt.Errorf("failed to initialize component %s: %w", component.Name(), err)
+		}
+		b.logger.Infof("Component %s initialized", component.Name())
+	}
+	return nil
+}
+
+// startRegisteredComponents starts the registered components
+func (b *Bootstrap) startRegisteredComponents(ctx context.Context) error {
+	for _, component := range b.registered {
+		if err := b.runStartupStep(ctx, component.Name(), "start", component.Start); err != nil {
+			return fmt.Errorf("failed to start component %s: %w", component.Name(), err)
+		}
+		b.logger.Infof("Component %s started", component.Name())
+	}
+	return nil
+}
+
+// builtinComponent reports whether name is the name of a built-in manager
+func builtinComponent(name string) bool {
+	if name == "migration" {
+		return true
+	}
+	for _, builtin := range append(coreComponents, optionalComponents...) {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
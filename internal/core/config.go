@@ -0,0 +1,322 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override configuration values.
+// The rest of the name is the path of the value, with sections separated by a double
+// underscore: MICROFRAMEWORK_SERVER__READ_TIMEOUT=5s sets server.read_timeout.
+const EnvPrefix = "MICROFRAMEWORK_"
+
+// ConfigFileError means the configuration file could not be read or parsed
+type ConfigFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigFileError) Error() string {
+	return fmt.Sprintf("config file %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigFileError) Unwrap() error { return e.Err }
+
+// InterpolationError means a ${VAR:?message} reference names an unset variable
+type InterpolationError struct {
+	Field    string
+	Variable string
+	Message  string
+}
+
+func (e *InterpolationError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = "is not set"
+	}
+	return fmt.Sprintf("%s: environment variable %s %s", e.Field, e.Variable, message)
+}
+
+// FieldError is a problem with one configuration value
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError lists the problems found in a configuration
+type ValidationError struct {
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.Error()
+	}
+	return "invalid configuration: " + strings.Join(problems, "; ")
+}
+
+// LoadConfig reads the FrameworkConfig in the YAML file at path. ${VAR} and ${VAR:-default}
+// references in values are replaced from the environment, ${VAR:?message} fails when VAR is
+// unset, and EnvPrefix variables override values. The result is validated.
+func LoadConfig(path string) (*FrameworkConfig, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readConfig reads the FrameworkConfig in the YAML file at path as LoadConfig does, without
+// validating it
+func readConfig(path string) (*FrameworkConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigFileError{Path: path, Err: err}
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, &ConfigFileError{Path: path, Err: err}
+	}
+	if len(document.Content) == 0 {
+		document.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &ConfigFileError{Path: path, Err: fmt.Errorf("the top level must be a mapping")}
+	}
+
+	if err := interpolateNode(root, ""); err != nil {
+		return nil, err
+	}
+	applyEnvOverrides(root, os.Environ())
+
+	var config FrameworkConfig
+	if err := root.Decode(&config); err != nil {
+		return nil, &ConfigFileError{Path: path, Err: err}
+	}
+	return &config, nil
+}
+
+// interpolationPattern matches ${VAR}, ${VAR:-default} and ${VAR:?message}
+var interpolationPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// interpolateNode replaces the environment references in the scalars under node
+func interpolateNode(node *yaml.Node, field string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := interpolateNode(node.Content[i+1], joinField(field, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if err := interpolateNode(item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		value, err := interpolate(node.Value, field)
+		if err != nil {
+			return err
+		}
+		if value != node.Value {
+			node.Value = value
+			// Let the target type decide how to read the replaced value
+			node.Tag = ""
+			node.Style = 0
+		}
+	}
+	return nil
+}
+
+// interpolate replaces the environment references in a value
+func interpolate(value, field string) (string, error) {
+	var failure error
+	result := interpolationPattern.ReplaceAllStringFunc(value, func(reference string) string {
+		match := interpolationPattern.FindStringSubmatch(reference)
+		name, operator, argument := match[1], match[2], match[3]
+		if variable, ok := os.LookupEnv(name); ok && variable != "" {
+			return variable
+		}
+		switch operator {
+		case ":-":
+			return argument
+		case ":?":
+			if failure == nil {
+				failure = &InterpolationError{Field: field, Variable: name, Message: argument}
+			}
+		}
+		return ""
+	})
+	return result, failure
+}
+
+// applyEnvOverrides sets the values named by EnvPrefix variables, creating sections as needed.
+// Variables are applied in name order, so a section is overridden before its values.
+func applyEnvOverrides(root *yaml.Node, environ []string) {
+	sort.Strings(environ)
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) || len(name) == len(EnvPrefix) {
+			continue
+		}
+		keys := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		setNodeValue(root, keys, value)
+	}
+}
+
+// setNodeValue sets the scalar at the path keys under mapping
+func setNodeValue(mapping *yaml.Node, keys []string, value string) {
+	for i, key := range keys {
+		var child *yaml.Node
+		for j := 0; j+1 < len(mapping.Content); j += 2 {
+			if mapping.Content[j].Value == key {
+				child = mapping.Content[j+1]
+				break
+			}
+		}
+
+		last := i == len(keys)-1
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		if last {
+			*child = yaml.Node{Kind: yaml.ScalarNode, Value: value}
+			return
+		}
+		if child.Kind != yaml.MappingNode {
+			*child = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		mapping = child
+	}
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// Validate checks that the configuration has what each enabled feature needs
+func (c *FrameworkConfig) Validate() error {
+	var problems []FieldError
+	problem := func(field, format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Service.Name == "" {
+		problem("service.name", "is required")
+	}
+	for field, port := range map[string]int{"service.port": c.Service.Port, "server.port": c.Server.Port} {
+		if port < 0 || port > 65535 {
+			problem(field, "must be between 0 and 65535, not %d", port)
+		}
+	}
+	for field, timeout := range map[string]time.Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"shutdown.timeout":           c.Shutdown.Timeout,
+		"shutdown.component_timeout": c.Shutdown.ComponentTimeout,
+		"startup.component_timeout":  c.Startup.ComponentTimeout,
+		"secrets.timeout":            c.Secrets.Timeout,
+		"watchdog.interval":          c.Watchdog.Interval,
+		"watchdog.check_timeout":     c.Watchdog.CheckTimeout,
+		"watchdog.restart_backoff":   c.Watchdog.RestartBackoff,
+	} {
+		if timeout < 0 {
+			problem(field, "must not be negative")
+		}
+	}
+	for name, timeout := range c.Startup.Components {
+		if timeout < 0 {
+			problem("startup.components."+name, "must not be negative")
+		}
+	}
+	if c.Watchdog.FailureThreshold < 0 {
+		problem("watchdog.failure_threshold", "must not be negative")
+	}
+	if c.Watchdog.MaxRestarts < 0 {
+		problem("watchdog.max_restarts", "must not be negative")
+	}
+	retry := c.Startup.Retry
+	if retry.MaxAttempts < 0 {
+		problem("startup.retry.max_attempts", "must not be negative")
+	}
+	if retry.InitialBackoff < 0 || retry.MaxBackoff < 0 {
+		problem("startup.retry", "backoffs must not be negative")
+	}
+	if retry.Multiplier != 0 && retry.Multiplier < 1 {
+		problem("startup.retry.multiplier", "must be at least 1, not %v", retry.Multiplier)
+	}
+	if retry.Jitter != nil && (*retry.Jitter < 0 || *retry.Jitter > 1) {
+		problem("startup.retry.jitter", "must be between 0 and 1, not %v", *retry.Jitter)
+	}
+
+	if field, level := c.Logging.levelSetting(); level != "" {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			problem(field, "is not a log level: %q", level)
+		}
+	}
+
+	if c.Database != nil && len(c.Database.Providers) == 0 {
+		problem("database.providers", "must configure at least one provider when database is enabled")
+	}
+	if c.Database != nil {
+		migrations := c.Database.Migrations
+		switch migrations.OnStart {
+		case "", MigrationsWarn, MigrationsApply, MigrationsFail:
+		default:
+			problem("database.migrations.on_start", "must be warn, apply or fail, not %q", migrations.OnStart)
+		}
+		if migrations.Auto && migrations.onStart() != MigrationsApply {
+			problem("database.migrations.auto", "conflicts with on_start: %s", migrations.OnStart)
+		}
+		if migrations.LockTimeout < 0 {
+			problem("database.migrations.lock_timeout", "must not be negative")
+		}
+	}
+	if c.Auth != nil {
+		if len(c.Auth.Providers) == 0 {
+			problem("auth.providers", "must configure at least one provider when auth is enabled")
+		}
+		if jwt, ok := c.Auth.Providers["jwt"].(map[string]interface{}); ok {
+			if secret, _ := jwt["secret"].(string); secret == "" {
+				problem("auth.providers.jwt.secret", "is required")
+			}
+		}
+	}
+	if c.Messaging != nil && len(c.Messaging.Providers) == 0 {
+		problem("messaging.providers", "must configure at least one provider when messaging is enabled")
+	}
+	if _, err := rateLimits(c.Optional.RateLimit); err != nil {
+		if fieldErr, ok := err.(FieldError); ok {
+			problems = append(problems, fieldErr)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return &ValidationError{Problems: problems}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/featureflags"
+)
+
+// featureFlagProviders are the feature flag providers in the order they are asked, so that
+// environment variables override the flags file and the file overrides the flag service
+var featureFlagProviders = []string{"env", "file", "remote"}
+
+// openFeatureProviders are the providers the services evaluate their flags with through
+// OpenFeature: the env and file providers of the manager, the flagd daemon or LaunchDarkly
+var openFeatureProviders = []string{"env", "file", "flagd", "launchdarkly"}
+
+// newFeatureFlagManager creates the feature flag manager of the optional.featureflags section:
+//
+//	featureflags:
+//	  providers:
+//	    env: {prefix: FEATURE_}
+//	    file: {path: configs/flags.yaml, refresh_interval: 10s}
+//	    remote: {url: https://flags.example.com/sdk/flags, sdk_key: ${FLAGS_SDK_KEY}, poll_interval: 30s}
+//	  openfeature:
+//	    provider: file
+func newFeatureFlagManager(section map[string]interface{}) (*FeatureFlagManager, error) {
+	providers, _ := section["providers"].(map[string]interface{})
+	for name := range providers {
+		if !contains(featureFlagProviders, name) {
+			return nil, FieldError{Field: "optional.featureflags.providers." + name, Message: "unknown provider (env, file or remote)"}
+		}
+	}
+
+	manager := featureflags.NewManager()
+	for _, name := range featureFlagProviders {
+		value, ok := providers[name]
+		if !ok {
+			continue
+		}
+		settings, _ := value.(map[string]interface{})
+		field := "optional.featureflags.providers." + name
+
+		switch name {
+		case "env":
+			var options struct {
+				Prefix string `json:"prefix"`
+			}
+			options.Prefix = "FEATURE_"
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			manager.AddProvider(featureflags.NewEnvProvider(options.Prefix))
+
+		case "file":
+			var options struct {
+				Path            string        `json:"path"`
+				RefreshInterval time.Duration `json:"refresh_interval"`
+			}
+			options.Path = "configs/flags.yaml"
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			manager.AddProvider(featureflags.NewFileProvider(options.Path, options.RefreshInterval))
+
+		case "remote":
+			var options struct {
+				URL          string        `json:"url"`
+				SDKKey       string        `json:"sdk_key"`
+				PollInterval time.Duration `json:"poll_interval"`
+			}
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			if options.URL == "" {
+				return nil, FieldError{Field: field + ".url", Message: "is required"}
+			}
+			manager.AddProvider(featureflags.NewRemoteProvider(options.URL, options.SDKKey, options.PollInterval))
+		}
+	}
+	return manager, nil
+}
+
+// openFeatureProvider returns the OpenFeature provider of the optional.featureflags section,
+// empty when the flags are not evaluated through OpenFeature. The env and file providers
+// evaluate the flags of the manager, which must be configured with the provider; flagd and
+// LaunchDarkly are set up by the service itself.
+func openFeatureProvider(section map[string]interface{}) (string, error) {
+	value, ok := section["openfeature"]
+	if !ok {
+		return "", nil
+	}
+	var options struct {
+		Provider string `json:"provider"`
+	}
+	settings, _ := value.(map[string]interface{})
+	// The flagd and launchdarkly settings are left to the service
+	if _, err := managerConfig(&options, "optional.featureflags.openfeature", settings, nil); err != nil {
+		return "", err
+	}
+	if !contains(openFeatureProviders, options.Provider) {
+		return "", FieldError{Field: "optional.featureflags.openfeature.provider", Message: "unknown provider (env, file, flagd or launchdarkly)"}
+	}
+	if options.Provider == "env" || options.Provider == "file" {
+		providers, _ := section["providers"].(map[string]interface{})
+		if _, ok := providers[options.Provider]; !ok {
+			return "", FieldError{Field: "optional.featureflags.providers." + options.Provider, Message: "is required by the OpenFeature provider " + options.Provider}
+		}
+	}
+	return options.Provider, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
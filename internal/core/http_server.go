@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// serveHTTP sets up the HTTP server of WithHTTPServer on addr, serving the health handlers;
+// the service adds its own to HTTPMux
+func (b *Bootstrap) serveHTTP(addr string) {
+	b.httpMux = http.NewServeMux()
+	b.RegisterHealthHandlers(b.httpMux)
+	b.httpServer = &http.Server{
+		Addr:         addr,
+		Handler:      b.httpMux,
+		ReadTimeout:  b.config.Server.ReadTimeout,
+		WriteTimeout: b.config.Server.WriteTimeout,
+		IdleTimeout:  b.config.Server.IdleTimeout,
+	}
+}
+
+// HTTPMux returns the mux of the HTTP server started with WithHTTPServer, which serves
+// /healthz, /readyz and /debug/startup, for the service to register its handlers on. It is
+// nil without WithHTTPServer.
+func (b *Bootstrap) HTTPMux() *http.ServeMux {
+	return b.httpMux
+}
+
+// startHTTPServer listens on the address of WithHTTPServer and serves in the background. It
+// listens before the components start, so that the probes tell a starting service from a
+// dead one.
+func (b *Bootstrap) startHTTPServer() error {
+	if b.httpServer == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", b.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", b.httpServer.Addr, err)
+	}
+	go func() {
+		if err := b.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.logger.WithError(err).Error("HTTP server failed")
+		}
+	}()
+	b.logger.Infof("HTTP server listening on %s", listener.Addr())
+	return nil
+}
+
+// stopHTTPServer stops the HTTP server of WithHTTPServer, waiting for the requests in flight
+func (b *Bootstrap) stopHTTPServer(ctx context.Context) error {
+	if b.httpServer == nil {
+		return nil
+	}
+	return b.httpServer.Shutdown(ctx)
+}
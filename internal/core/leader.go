@@ -0,0 +1,120 @@
+package core
+
+import (
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/leader"
+	"github.com/redis/go-redis/v9"
+)
+
+// leaderElectionProviders are the lock backends of leader election
+var leaderElectionProviders = []string{"kubernetes", "redis", "consul"}
+
+// newLeaderElector creates the leader elector of the optional.leaderelection section, with
+// one provider:
+//
+//	leaderelection:
+//	  name: orders-scheduler   # the lock, shared by the replicas (default the service name)
+//	  lease_duration: 15s
+//	  renew_interval: 5s
+//	  providers:
+//	    kubernetes: {namespace: orders}   # a Lease, in the pod namespace by default
+//	    redis: {url: redis://localhost:6379/0, key: leader:orders}
+//	    consul: {address: http://localhost:8500, token: ${CONSUL_TOKEN}, key: service/orders/leader}
+func newLeaderElector(section map[string]interface{}, serviceName string) (*LeaderElector, error) {
+	var options struct {
+		Name          string        `json:"name"`
+		Identity      string        `json:"identity"`
+		LeaseDuration time.Duration `json:"lease_duration"`
+		RenewInterval time.Duration `json:"renew_interval"`
+	}
+	options.Name = serviceName
+	if _, err := managerConfig(&options, "optional.leaderelection", withoutKey(section, "providers"), nil); err != nil {
+		return nil, err
+	}
+
+	providers, _ := section["providers"].(map[string]interface{})
+	if len(providers) != 1 {
+		return nil, FieldError{Field: "optional.leaderelection.providers", Message: "must configure one provider (kubernetes, redis or consul)"}
+	}
+	var lock leader.Lock
+	for name, value := range providers {
+		settings, _ := value.(map[string]interface{})
+		field := "optional.leaderelection.providers." + name
+
+		switch name {
+		case "kubernetes":
+			var kubernetes struct {
+				Namespace string `json:"namespace"`
+			}
+			if _, err := managerConfig(&kubernetes, field, settings, nil); err != nil {
+				return nil, err
+			}
+			kubernetesLock, err := leader.NewKubernetesLock(kubernetes.Namespace, options.Name)
+			if err != nil {
+				return nil, FieldError{Field: field, Message: err.Error()}
+			}
+			lock = kubernetesLock
+
+		case "redis":
+			var redisOptions struct {
+				URL string `json:"url"`
+				Key string `json:"key"`
+			}
+			redisOptions.Key = "leader:" + options.Name
+			if _, err := managerConfig(&redisOptions, field, settings, nil); err != nil {
+				return nil, err
+			}
+			if redisOptions.URL == "" {
+				return nil, FieldError{Field: field + ".url", Message: "is required"}
+			}
+			clientOptions, err := redis.ParseURL(redisOptions.URL)
+			if err != nil {
+				return nil, FieldError{Field: field + ".url", Message: err.Error()}
+			}
+			lock = leader.NewRedisLock(redis.NewClient(clientOptions), redisOptions.Key)
+
+		case "consul":
+			var consul struct {
+				Address string `json:"address"`
+				Token   string `json:"token"`
+				Key     string `json:"key"`
+			}
+			consul.Address = "http://127.0.0.1:8500"
+			consul.Key = "service/" + options.Name + "/leader"
+			if _, err := managerConfig(&consul, field, settings, nil); err != nil {
+				return nil, err
+			}
+			lock = leader.NewConsulLock(consul.Address, consul.Token, consul.Key)
+
+		default:
+			return nil, FieldError{Field: field, Message: "unknown provider (kubernetes, redis or consul)"}
+		}
+	}
+
+	return leader.NewElector(lock, leader.Options{
+		Identity:      options.Identity,
+		LeaseDuration: options.LeaseDuration,
+		RenewInterval: options.RenewInterval,
+	}), nil
+}
+
+// gateScheduling puts the scheduling providers behind the leader election, so that the tasks
+// scheduled through them run on the leader only, or takes them out of it when leader
+// election is not configured. Tasks scheduled on a provider before it is gated, that is
+// before Start, run on every replica.
+func (b *Bootstrap) gateScheduling() {
+	for _, name := range b.schedulingManager.ListProviders() {
+		provider, err := b.schedulingManager.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		if gated, ok := provider.(*leader.Scheduler); ok {
+			gated.SetElector(b.leaderElector)
+			continue
+		}
+		if b.leaderElector != nil {
+			b.schedulingManager.RegisterProvider(name, leader.NewScheduler(b.leaderElector, provider, b.logger))
+		}
+	}
+}
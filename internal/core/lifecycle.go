@@ -0,0 +1,321 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	monitoringtypes "github.com/anasamu/go-micro-libs/monitoring/types"
+)
+
+// LifecycleState is the state of a component, or of the framework as a whole
+type LifecycleState string
+
+const (
+	// StateStarting means the component is initialized but not started yet
+	StateStarting LifecycleState = "starting"
+	// StateReady means the component is started and passes its health check
+	StateReady LifecycleState = "ready"
+	// StateDegraded means the component is started but fails its health check
+	StateDegraded LifecycleState = "degraded"
+	// StateStopping means the framework is shutting down
+	StateStopping LifecycleState = "stopping"
+)
+
+// ComponentHealth is the lifecycle state of a component, with the error of its last failed
+// health check
+type ComponentHealth struct {
+	State LifecycleState `json:"state"`
+	Error string         `json:"error,omitempty"`
+}
+
+// HealthReport is the body of the /healthz and /readyz responses
+type HealthReport struct {
+	Status     LifecycleState             `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// component is an initialized manager with its health check and shutdown; check and stop are
+// nil for managers without them
+type component struct {
+	name  string
+	check func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+// components returns the initialized components in dependency order: every component comes
+// after the components it uses, registered components come after the built-in managers, and
+// the communication server, which serves requests using all of them, comes last. It is called
+// with b.mu held; the checks and stops capture the managers of that moment, so that they keep
+// working on them while a reload or a restart replaces the fields.
+func (b *Bootstrap) components() []component {
+	var components []component
+	add := func(initialized bool, name string, check, stop func(ctx context.Context) error) {
+		if initialized {
+			components = append(components, component{name: name, check: check, stop: stop})
+		}
+	}
+	// addOptional adds an optional manager, which may be left out of minimal builds
+	addOptional := func(initialized bool, c component) {
+		if initialized {
+			components = append(components, c)
+		}
+	}
+
+	configManager, loggingManager := b.configManager, b.loggingManager
+	add(configManager != nil, "config", nil, closer(func() error { return configManager.Close() }))
+	add(loggingManager != nil, "logging", nil, closer(func() error { return loggingManager.Close() }))
+	monitoringManager := b.monitoringManager
+	add(monitoringManager != nil, "monitoring", func(ctx context.Context) error {
+		return monitoringHealthError(monitoringManager.HealthCheckAll(ctx))
+	}, closer(func() error { return monitoringManager.Close() }))
+	databaseManager := b.databaseManager
+	add(databaseManager != nil, "database", func(ctx context.Context) error {
+		return providerErrors(databaseManager.HealthCheck(ctx))
+	}, closer(func() error { return databaseManager.Close() }))
+	add(b.migrationManager != nil, "migration", nil, nil)
+	authManager := b.authManager
+	add(authManager != nil, "auth", func(ctx context.Context) error {
+		return providerErrors(authManager.HealthCheck(ctx))
+	}, closer(func() error { return authManager.Close() }))
+	middlewareManager := b.middlewareManager
+	add(middlewareManager != nil, "middleware", func(ctx context.Context) error {
+		return providerErrors(middlewareManager.HealthCheck(ctx))
+	}, closer(func() error { return middlewareManager.Close() }))
+	addOptional(b.apiManager != nil, b.apiComponent())
+	addOptional(b.aiManager != nil, b.aiComponent())
+	addOptional(b.storageManager != nil, b.storageComponent())
+	messagingManager := b.messagingManager
+	add(messagingManager != nil, "messaging", func(ctx context.Context) error {
+		return providerErrors(messagingManager.HealthCheck(ctx))
+	}, closer(func() error { return messagingManager.Close() }))
+	leaderElector := b.leaderElector
+	add(leaderElector != nil, "leaderelection", func(ctx context.Context) error {
+		return leaderElector.Err()
+	}, func(ctx context.Context) error { return leaderElector.Stop(ctx) })
+	schedulingManager := b.schedulingManager
+	add(schedulingManager != nil, "scheduling", nil, func(ctx context.Context) error {
+		return schedulingManager.DisconnectAll(ctx)
+	})
+	addOptional(b.backupManager != nil, b.backupComponent())
+	addOptional(b.chaosManager != nil, b.chaosComponent())
+	addOptional(b.failoverManager != nil, b.failoverComponent())
+	addOptional(b.eventManager != nil, b.eventComponent())
+	addOptional(b.discoveryManager != nil, b.discoveryComponent())
+	addOptional(b.cacheManager != nil, b.cacheComponent())
+	addOptional(b.rateLimitManager != nil, b.ratelimitComponent())
+	addOptional(b.circuitBreakerManager != nil, b.circuitbreakerComponent())
+	addOptional(b.filegenManager != nil, b.filegenComponent())
+	addOptional(b.paymentManager != nil, b.paymentComponent())
+	addOptional(b.emailManager != nil, b.emailComponent())
+	featureFlagManager := b.featureFlagManager
+	add(featureFlagManager != nil, "featureflags", nil, closer(func() error { return featureFlagManager.Close() }))
+	if b.initialized {
+		for _, registered := range b.registered {
+			add(true, registered.Name(), registered.Health, registered.Stop)
+		}
+	}
+	communicationManager := b.communicationManager
+	add(communicationManager != nil, "communication", func(ctx context.Context) error {
+		return providerErrors(communicationManager.HealthCheck(ctx))
+	}, func(ctx context.Context) error {
+		// Stop accepting requests before closing the connections
+		if communicationManager.IsProviderRunning("http") {
+			if err := communicationManager.Stop(ctx, "http"); err != nil {
+				return err
+			}
+		}
+		return communicationManager.Close()
+	})
+	return components
+}
+
+// snapshotComponents returns the components, read under b.mu, for the callers that do not
+// hold it
+func (b *Bootstrap) snapshotComponents() []component {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.components()
+}
+
+// Default shutdown timeouts
+const (
+	DefaultShutdownTimeout          = 30 * time.Second
+	DefaultComponentShutdownTimeout = 10 * time.Second
+)
+
+// shutdownTimeout returns the overall shutdown deadline
+func (b *Bootstrap) shutdownTimeout() time.Duration {
+	if b.config != nil && b.config.Shutdown.Timeout > 0 {
+		return b.config.Shutdown.Timeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// componentShutdownTimeout returns the shutdown timeout of a component
+func (b *Bootstrap) componentShutdownTimeout(name string) time.Duration {
+	if b.config != nil {
+		if timeout := b.config.Shutdown.Components[name]; timeout > 0 {
+			return timeout
+		}
+		if b.config.Shutdown.ComponentTimeout > 0 {
+			return b.config.Shutdown.ComponentTimeout
+		}
+	}
+	return DefaultComponentShutdownTimeout
+}
+
+// stopComponent stops a component and gives up when it exceeds its shutdown timeout. Managers
+// whose Close takes no context keep closing in the background after that.
+func (b *Bootstrap) stopComponent(ctx context.Context, component component) error {
+	ctx, cancel := context.WithTimeout(ctx, b.componentShutdownTimeout(component.name))
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- component.stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("not stopped in time: %w", ctx.Err())
+	}
+}
+
+// closer adapts a Close method to a component shutdown
+func closer(close func() error) func(ctx context.Context) error {
+	return func(ctx context.Context) error { return close() }
+}
+
+// providerErrors combines the failed providers of a manager health check into one error
+func providerErrors(results map[string]error) error {
+	var failed []string
+	for provider, err := range results {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", provider, err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("%s", strings.Join(failed, "; "))
+}
+
+// monitoringHealthError reports the monitoring providers that are not healthy
+func monitoringHealthError(responses map[string]*monitoringtypes.HealthCheckResponse) error {
+	errors := make(map[string]error)
+	for provider, response := range responses {
+		if response == nil || response.Status == monitoringtypes.HealthStatusHealthy {
+			continue
+		}
+		errors[provider] = fmt.Errorf("%s", response.Status)
+	}
+	return providerErrors(errors)
+}
+
+// setComponentState records the state of a component
+func (b *Bootstrap) setComponentState(name string, state LifecycleState, err error) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	if b.states == nil {
+		b.states = make(map[string]ComponentHealth)
+	}
+	health := ComponentHealth{State: state}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	b.states[name] = health
+}
+
+// setAllComponentStates records the same state for every initialized component
+func (b *Bootstrap) setAllComponentStates(state LifecycleState) {
+	for _, component := range b.snapshotComponents() {
+		b.setComponentState(component.name, state, nil)
+	}
+}
+
+// State returns the overall lifecycle state: stopping once Stop is called, starting until every
+// component is started, degraded while any component fails its health check, and ready otherwise
+func (b *Bootstrap) State() LifecycleState {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+
+	if b.stopping {
+		return StateStopping
+	}
+	if len(b.states) == 0 {
+		return StateStarting
+	}
+	overall := StateReady
+	for _, health := range b.states {
+		switch health.State {
+		case StateStarting, StateStopping:
+			return health.State
+		case StateDegraded:
+			overall = StateDegraded
+		}
+	}
+	return overall
+}
+
+// ComponentStates returns the lifecycle state of every initialized component
+func (b *Bootstrap) ComponentStates() map[string]ComponentHealth {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+
+	states := make(map[string]ComponentHealth, len(b.states))
+	for name, health := range b.states {
+		states[name] = health
+	}
+	return states
+}
+
+// Ready reports whether the service can take traffic: every component is started and healthy
+func (b *Bootstrap) Ready() bool {
+	return b.State() == StateReady
+}
+
+// Live reports whether the service is running, even if degraded; it is false once Stop is called
+func (b *Bootstrap) Live() bool {
+	return b.State() != StateStopping
+}
+
+// LivenessHandler serves /healthz: 200 while the service is live, 503 otherwise
+func (b *Bootstrap) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.writeHealthReport(w, b.Live())
+	})
+}
+
+// ReadinessHandler serves /readyz: it runs the component health checks and answers 200 when
+// the service is ready, 503 otherwise
+func (b *Bootstrap) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.HealthCheck(r.Context())
+		b.writeHealthReport(w, b.Ready())
+	})
+}
+
+// RegisterHealthHandlers serves /healthz and /readyz on mux, and the startup report on
+// /debug/startup
+func (b *Bootstrap) RegisterHealthHandlers(mux *http.ServeMux) {
+	mux.Handle("/healthz", b.LivenessHandler())
+	mux.Handle("/readyz", b.ReadinessHandler())
+	mux.Handle("/debug/startup", b.StartupReportHandler())
+}
+
+func (b *Bootstrap) writeHealthReport(w http.ResponseWriter, ok bool) {
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(HealthReport{Status: b.State(), Components: b.ComponentStates()})
+}
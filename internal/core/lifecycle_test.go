@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	httpprovider "github.com/anasamu/go-micro-libs/communication/providers/http"
+	"github.com/anasamu/go-micro-libs/monitoring/providers/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// testConfig returns a configuration whose components run without external services, with
+// the env feature flag provider reading the variables with prefix
+func testConfig(prefix string) *FrameworkConfig {
+	return &FrameworkConfig{
+		Service: ServiceConfig{Name: "orders"},
+		Optional: OptionalConfig{
+			FeatureFlags: map[string]interface{}{
+				"providers": map[string]interface{}{"env": map[string]interface{}{"prefix": prefix}},
+			},
+		},
+	}
+}
+
+// initialize initializes a Bootstrap and registers the monitoring provider and the HTTP
+// server Start starts, as the services do; the server listens on a free port of localhost
+func initialize(ctx context.Context, b *Bootstrap) error {
+	if err := b.Initialize(ctx); err != nil {
+		return err
+	}
+	if err := b.GetMonitoringManager().RegisterProvider(prometheus.NewPrometheusProvider(nil, b.logger)); err != nil {
+		return err
+	}
+	server := httpprovider.NewProvider(b.logger)
+	if err := server.Configure(map[string]interface{}{"host": "127.0.0.1", "port": 0}); err != nil {
+		return err
+	}
+	return b.GetCommunicationManager().RegisterProvider(server)
+}
+
+// startBootstrap initializes and starts a Bootstrap, and stops it at the end of the test
+func startBootstrap(t *testing.T, config *FrameworkConfig) *Bootstrap {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	b := NewBootstrap(config, logger)
+	ctx := context.Background()
+	if err := initialize(ctx, b); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := b.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { b.Stop(context.Background()) })
+	return b
+}
+
+func TestLifecycleStates(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	b := NewBootstrap(testConfig("FEATURE_"), logger)
+	ctx := context.Background()
+
+	steps := []struct {
+		name  string
+		run   func() error
+		want  LifecycleState
+		ready bool
+		live  bool
+	}{
+		{name: "initialize", run: func() error { return initialize(ctx, b) }, want: StateStarting, live: true},
+		{name: "start", run: func() error { return b.Start(ctx) }, want: StateReady, ready: true, live: true},
+		{name: "stop", run: func() error { return b.Stop(ctx) }, want: StateStopping},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if state := b.State(); state != step.want {
+			t.Errorf("%s: State() = %s, want %s", step.name, state, step.want)
+		}
+		if b.Ready() != step.ready || b.Live() != step.live {
+			t.Errorf("%s: Ready() = %v and Live() = %v, want %v and %v", step.name, b.Ready(), b.Live(), step.ready, step.live)
+		}
+		for name, health := range b.ComponentStates() {
+			if health.State != step.want {
+				t.Errorf("%s: component %s is %s, want %s", step.name, name, health.State, step.want)
+			}
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *FrameworkConfig
+		wantRestarted []string
+		wantStopped   []string
+		wantErr       bool
+	}{
+		{name: "unchanged", config: testConfig("FEATURE_")},
+		{name: "restarted component", config: testConfig("FLAG_"), wantRestarted: []string{"featureflags"}},
+		{name: "removed component", config: &FrameworkConfig{Service: ServiceConfig{Name: "orders"}}, wantStopped: []string{"featureflags"}},
+		{name: "invalid", config: &FrameworkConfig{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := startBootstrap(t, testConfig("FEATURE_"))
+			before := b.GetFeatureFlagManager()
+
+			err := b.Reload(context.Background(), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			reloads := b.Reloads()
+			if len(reloads) != 1 {
+				t.Fatalf("Reloads() has %d events, want 1", len(reloads))
+			}
+			event := reloads[0]
+			if !equalNames(event.Restarted, tt.wantRestarted) || !equalNames(event.Stopped, tt.wantStopped) {
+				t.Errorf("restarted %v and stopped %v, want %v and %v", event.Restarted, event.Stopped, tt.wantRestarted, tt.wantStopped)
+			}
+
+			after := b.GetFeatureFlagManager()
+			switch {
+			case len(tt.wantStopped) > 0 && after != nil:
+				t.Errorf("the feature flag manager of the removed section is still there")
+			case len(tt.wantRestarted) > 0 && after == before:
+				t.Errorf("the feature flag manager was not replaced")
+			case len(tt.wantRestarted) == 0 && len(tt.wantStopped) == 0 && after != before:
+				t.Errorf("the feature flag manager was replaced")
+			}
+		})
+	}
+}
+
+// TestHealthCheckDuringReload runs the readers of the managers while reloads replace them,
+// for the race detector
+func TestHealthCheckDuringReload(t *testing.T) {
+	b := startBootstrap(t, testConfig("FEATURE_"))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	readers := map[string]func(){
+		"HealthCheck":        func() { b.HealthCheck(ctx) },
+		"buildStartupReport": func() { b.buildStartupReport() },
+		"GetManager":         func() { b.GetManager("featureflags") },
+		"ComponentStates":    func() { b.ComponentStates() },
+	}
+	for _, read := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					read()
+				}
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		prefix := "FEATURE_"
+		if i%2 == 0 {
+			prefix = "FLAG_"
+		}
+		if err := b.Reload(ctx, testConfig(prefix)); err != nil {
+			t.Errorf("Reload() error = %v", err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	for name, health := range b.HealthCheck(ctx) {
+		if health.State != StateReady {
+			t.Errorf("component %s is %s after the reloads, want %s", name, health.State, StateReady)
+		}
+	}
+}
+
+// equalNames reports whether two lists of component names are equal, nil being empty
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// managerConfig overlays the manager settings of a configuration section onto config, a
+// go-micro-libs ManagerConfig holding its defaults. Settings are matched to the fields by
+// their JSON names (default_provider, retry_attempts, timeout, ...); other keys are left to
+// the providers. Without a default_provider setting, the default provider is the one marked
+// default: true, or the only one configured.
+func managerConfig(config interface{}, section string, settings, providers map[string]interface{}) (interface{}, error) {
+	target := reflect.ValueOf(config).Elem()
+
+	for key, value := range settings {
+		field, ok := managerConfigField(target, key)
+		if !ok || value == nil {
+			continue
+		}
+		if err := setManagerConfigField(field, value); err != nil {
+			return nil, FieldError{Field: section + "." + key, Message: err.Error()}
+		}
+	}
+
+	if _, ok := settings["default_provider"]; !ok {
+		if field, ok := managerConfigField(target, "default_provider"); ok {
+			if provider := defaultProvider(providers); provider != "" {
+				field.SetString(provider)
+			}
+		}
+	}
+	return config, nil
+}
+
+// optionalManagerConfig is managerConfig for an optional feature section, whose providers are
+// under its providers key
+func optionalManagerConfig(config interface{}, section string, values map[string]interface{}) (interface{}, error) {
+	settings := make(map[string]interface{}, len(values))
+	var providers map[string]interface{}
+	for key, value := range values {
+		if key == "providers" {
+			providers, _ = value.(map[string]interface{})
+			continue
+		}
+		settings[key] = value
+	}
+	return managerConfig(config, section, settings, providers)
+}
+
+// defaultProvider picks the default provider of a providers map
+func defaultProvider(providers map[string]interface{}) string {
+	names := make([]string, 0, len(providers))
+	for name, provider := range providers {
+		if settings, ok := provider.(map[string]interface{}); ok {
+			if isDefault, _ := settings["default"].(bool); isDefault {
+				return name
+			}
+		}
+		names = append(names, name)
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return ""
+}
+
+// managerConfigField finds the field of a ManagerConfig for a setting, by JSON name or, for
+// untagged fields, by the snake_case form of the field name
+func managerConfigField(target reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+		if name == key && field.IsExported() {
+			return target.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setManagerConfigField converts a YAML value to the type of field. Durations are strings
+// such as 30s, or numbers of seconds.
+func setManagerConfigField(field reflect.Value, value interface{}) error {
+	if field.Type() == durationType {
+		switch v := value.(type) {
+		case string:
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(duration))
+		case int:
+			field.SetInt(int64(time.Duration(v) * time.Second))
+		case float64:
+			field.SetInt(int64(v * float64(time.Second)))
+		default:
+			return fmt.Errorf("expected a duration, not %v", value)
+		}
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(fmt.Sprint(value))
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("expected true or false, not %q", v)
+			}
+			field.SetBool(parsed)
+		default:
+			return fmt.Errorf("expected true or false, not %v", value)
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil || number != float64(int64(number)) {
+			return fmt.Errorf("expected an integer, not %v", value)
+		}
+		field.SetInt(int64(number))
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, not %v", value)
+		}
+		field.SetFloat(number)
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok || field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("expected a mapping, not %v", value)
+		}
+		result := reflect.MakeMapWithSize(field.Type(), len(entries))
+		for key, entry := range entries {
+			result.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), reflect.ValueOf(fmt.Sprint(entry)).Convert(field.Type().Elem()))
+		}
+		field.Set(result)
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("expected a list, not %v", value)
+		}
+		result := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			result.Index(i).Set(reflect.ValueOf(fmt.Sprint(item)).Convert(field.Type().Elem()))
+		}
+		field.Set(result)
+	default:
+		return fmt.Errorf("unsupported setting")
+	}
+	return nil
+}
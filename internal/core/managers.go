@@ -0,0 +1,187 @@
+package core
+
+// Typed accessors of the managers. They return nil until Initialize has run.
+
+// GetConfigManager returns the configuration manager
+func (b *Bootstrap) GetConfigManager() *ConfigManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.configManager
+}
+
+// GetLoggingManager returns the logging manager
+func (b *Bootstrap) GetLoggingManager() *LoggingManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.loggingManager
+}
+
+// GetMonitoringManager returns the monitoring manager
+func (b *Bootstrap) GetMonitoringManager() *MonitoringManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.monitoringManager
+}
+
+// GetDatabaseManager returns the database manager, or nil when database is not configured
+func (b *Bootstrap) GetDatabaseManager() *DatabaseManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.databaseManager
+}
+
+// GetMigrationManager returns the migration manager, or nil when database is not configured
+func (b *Bootstrap) GetMigrationManager() *MigrationManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.migrationManager
+}
+
+// GetAuthManager returns the auth manager, or nil when auth is not configured
+func (b *Bootstrap) GetAuthManager() *AuthManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.authManager
+}
+
+// GetMiddlewareManager returns the middleware manager
+func (b *Bootstrap) GetMiddlewareManager() *MiddlewareManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.middlewareManager
+}
+
+// GetCommunicationManager returns the communication manager
+func (b *Bootstrap) GetCommunicationManager() *CommunicationManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.communicationManager
+}
+
+// GetAPIManager returns the API manager, or nil when optional.api is not configured
+func (b *Bootstrap) GetAPIManager() *APIManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.apiManager
+}
+
+// GetAIManager returns the AI manager, or nil when optional.ai is not configured
+func (b *Bootstrap) GetAIManager() *AIManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.aiManager
+}
+
+// GetStorageManager returns the storage manager, or nil when optional.storage is not configured
+func (b *Bootstrap) GetStorageManager() *StorageManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.storageManager
+}
+
+// GetMessagingManager returns the messaging manager, or nil when messaging is not configured
+func (b *Bootstrap) GetMessagingManager() *MessagingManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.messagingManager
+}
+
+// GetSchedulingManager returns the scheduling manager, or nil when optional.scheduling is not configured
+func (b *Bootstrap) GetSchedulingManager() *SchedulingManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.schedulingManager
+}
+
+// GetBackupManager returns the backup manager, or nil when optional.backup is not configured
+func (b *Bootstrap) GetBackupManager() *BackupManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.backupManager
+}
+
+// GetChaosManager returns the chaos manager, or nil when optional.chaos is not configured
+func (b *Bootstrap) GetChaosManager() *ChaosManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.chaosManager
+}
+
+// GetFailoverManager returns the failover manager, or nil when optional.failover is not configured
+func (b *Bootstrap) GetFailoverManager() *FailoverManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.failoverManager
+}
+
+// GetEventManager returns the event manager, or nil when optional.event is not configured
+func (b *Bootstrap) GetEventManager() *EventManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.eventManager
+}
+
+// GetDiscoveryManager returns the discovery manager, or nil when optional.discovery is not configured
+func (b *Bootstrap) GetDiscoveryManager() *DiscoveryManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.discoveryManager
+}
+
+// GetCacheManager returns the cache manager, or nil when optional.cache is not configured
+func (b *Bootstrap) GetCacheManager() *CacheManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.cacheManager
+}
+
+// GetRateLimitManager returns the rate limit manager, or nil when optional.ratelimit is not configured
+func (b *Bootstrap) GetRateLimitManager() *RateLimitManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.rateLimitManager
+}
+
+// GetCircuitBreakerManager returns the circuit breaker manager, or nil when optional.circuitbreaker is not configured
+func (b *Bootstrap) GetCircuitBreakerManager() *CircuitBreakerManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.circuitBreakerManager
+}
+
+// GetFileGenManager returns the file generation manager, or nil when optional.filegen is not configured
+func (b *Bootstrap) GetFileGenManager() *FileGenManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.filegenManager
+}
+
+// GetPaymentManager returns the payment manager, or nil when optional.payment is not configured
+func (b *Bootstrap) GetPaymentManager() *PaymentManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.paymentManager
+}
+
+// GetEmailManager returns the email manager, or nil when optional.email is not configured
+func (b *Bootstrap) GetEmailManager() *EmailManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.emailManager
+}
+
+// GetFeatureFlagManager returns the feature flag manager, or nil when optional.featureflags is
+// not configured
+func (b *Bootstrap) GetFeatureFlagManager() *FeatureFlagManager {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.featureFlagManager
+}
+
+// GetLeaderElector returns the leader elector, or nil when optional.leaderelection is not
+// configured
+func (b *Bootstrap) GetLeaderElector() *LeaderElector {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.leaderElector
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	monitoringtypes "github.com/anasamu/go-micro-libs/monitoring/types"
+)
+
+// DefaultMetricsInterval is how often the framework metrics are submitted while running
+const DefaultMetricsInterval = 15 * time.Second
+
+// Names of the framework metrics
+const (
+	MetricComponentInitSeconds  = "microframework_component_init_seconds"
+	MetricComponentStartSeconds = "microframework_component_start_seconds"
+	MetricComponentState        = "microframework_component_state"
+	MetricComponentRestarts     = "microframework_component_restarts_total"
+	MetricComponentRecoveries   = "microframework_component_recoveries_total"
+	MetricConfigReloads         = "microframework_config_reloads_total"
+)
+
+// metricsProvider is the monitoring provider the framework metrics are submitted to
+const metricsProvider = "prometheus"
+
+// componentTimings are the durations of the initialization and start of a component
+type componentTimings struct {
+	Init  time.Duration
+	Start time.Duration
+}
+
+// timeComponent runs a lifecycle step of a component and records how long it took, unless the
+// step left a built-in manager unconfigured
+func (b *Bootstrap) timeComponent(name, step string, run func() error) error {
+	began := time.Now()
+	err := run()
+	elapsed := time.Since(began)
+	if err != nil || (builtinComponent(name) && !b.hasComponent(name)) {
+		return err
+	}
+
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if b.timings == nil {
+		b.timings = make(map[string]componentTimings)
+	}
+	timings := b.timings[name]
+	if step == "init" {
+		timings.Init = elapsed
+	} else {
+		timings.Start = elapsed
+	}
+	b.timings[name] = timings
+	return nil
+}
+
+// countRestart records a restart of a component by a reload or the watchdog
+func (b *Bootstrap) countRestart(name string) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if b.restarts == nil {
+		b.restarts = make(map[string]int)
+	}
+	b.restarts[name]++
+}
+
+// countReload records the result of a reload
+func (b *Bootstrap) countReload(result string) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if b.reloadResults == nil {
+		b.reloadResults = make(map[string]int)
+	}
+	b.reloadResults[result]++
+}
+
+// Metrics returns the current framework metrics: how long each component took to initialize
+// and start, its lifecycle state (1 for the current state, 0 for the others), how often
+// reloads and the watchdog restarted it, the watchdog restarts by result, and the number of
+// reloads by result. Alerting on
+// microframework_component_state{state="degraded"} == 1 catches failing components.
+func (b *Bootstrap) Metrics() []monitoringtypes.Metric {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+
+	now := time.Now()
+	var metrics []monitoringtypes.Metric
+	add := func(name string, metricType monitoringtypes.MetricType, value float64, labels map[string]string) {
+		metrics = append(metrics, monitoringtypes.Metric{Name: name, Type: metricType, Value: value, Labels: labels, Timestamp: now})
+	}
+
+	names := make([]string, 0, len(b.states))
+	for name := range b.states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		timings := b.timings[name]
+		add(MetricComponentInitSeconds, monitoringtypes.MetricTypeGauge, timings.Init.Seconds(), map[string]string{"component": name})
+		add(MetricComponentStartSeconds, monitoringtypes.MetricTypeGauge, timings.Start.Seconds(), map[string]string{"component": name})
+		for _, state := range []LifecycleState{StateStarting, StateReady, StateDegraded, StateStopping} {
+			value := 0.0
+			if b.states[name].State == state {
+				value = 1
+			}
+			add(MetricComponentState, monitoringtypes.MetricTypeGauge, value, map[string]string{"component": name, "state": string(state)})
+		}
+		add(MetricComponentRestarts, monitoringtypes.MetricTypeCounter, float64(b.restarts[name]), map[string]string{"component": name})
+		for _, result := range []string{"success", "failure"} {
+			add(MetricComponentRecoveries, monitoringtypes.MetricTypeCounter, float64(b.recoveries[name][result]), map[string]string{"component": name, "result": result})
+		}
+	}
+	for _, result := range []string{"success", "failure"} {
+		add(MetricConfigReloads, monitoringtypes.MetricTypeCounter, float64(b.reloadResults[result]), map[string]string{"result": result})
+	}
+	return metrics
+}
+
+// submitMetrics submits the framework metrics to monitoring, when it is connected
+func (b *Bootstrap) submitMetrics(ctx context.Context) {
+	b.mu.RLock()
+	monitoring := b.monitoringManager
+	b.mu.RUnlock()
+	if monitoring == nil || !monitoring.IsProviderConnected(metricsProvider) {
+		return
+	}
+
+	err := monitoring.SubmitMetrics(ctx, metricsProvider, &monitoringtypes.MetricRequest{Metrics: b.Metrics()})
+	if err != nil {
+		b.logger.WithError(err).Debug("Failed to submit the framework metrics")
+	}
+}
+
+// reportMetrics submits the framework metrics every DefaultMetricsInterval until ctx is done
+func (b *Bootstrap) reportMetrics(ctx context.Context) {
+	ticker := time.NewTicker(DefaultMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.submitMetrics(ctx)
+		}
+	}
+}
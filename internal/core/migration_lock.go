@@ -0,0 +1,30 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/migrate"
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/sirupsen/logrus"
+)
+
+// lockMigrations takes the migration lock of the database, the one `microframework migrate`
+// takes, waiting up to timeout for another replica or migrate run to release it, and returns
+// the function that releases it
+func lockMigrations(ctx context.Context, provider database.DatabaseProvider, timeout time.Duration, logger *logrus.Logger) (func(), error) {
+	if !migrate.Lockable(provider.GetName()) {
+		logger.Warnf("Database %s has no migration lock; apply migrations from a single replica", provider.GetName())
+		return func() {}, nil
+	}
+	return migrate.Lock(ctx, provider, migrate.LockOptions{
+		Timeout: timeout,
+		Waiting: func(holder string) {
+			if holder == "" {
+				logger.Info("Waiting for another migration to finish...")
+				return
+			}
+			logger.Infof("Waiting for the migration lock held by %s...", holder)
+		},
+	})
+}
@@ -0,0 +1,23 @@
+//go:build microframework_minimal
+
+package core
+
+import "fmt"
+
+// A minimal build leaves out the optional go-micro-libs managers, and the providers they
+// import, except the ones named by a build tag of their own:
+//
+//	go build -tags microframework_minimal,microframework_cache,microframework_email ./...
+//
+// The managers that can be left out are api, ai, storage, backup, chaos, failover, event,
+// discovery, cache, ratelimit, circuitbreaker, filegen, payment and email. The core
+// components, messaging, scheduling, leader election and feature flags are always built.
+
+// notCompiled fails the initialization of a manager this build leaves out when its section
+// is configured, instead of starting without it
+func notCompiled(name string, configured bool) error {
+	if !configured {
+		return nil
+	}
+	return fmt.Errorf("optional.%s is configured but the %s manager is not compiled into this build (add the microframework_%s build tag)", name, name, name)
+}
@@ -0,0 +1,46 @@
+//go:build !microframework_minimal || microframework_ai
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anasamu/go-micro-libs/ai"
+)
+
+// AIManager is the AI manager of go-micro-libs
+type AIManager = ai.AIManager
+
+func NewAIManager() *AIManager { return ai.NewAIManager() }
+
+// initializeAI creates the AI manager when optional.ai is configured
+func (b *Bootstrap) initializeAI() error {
+	if b.config.Optional.AI == nil {
+		return nil
+	}
+	b.aiManager = NewAIManager()
+	b.logger.Info("AI manager initialized")
+	return nil
+}
+
+// aiComponent returns the AI manager as a component
+func (b *Bootstrap) aiComponent() component {
+	manager := b.aiManager
+	return component{
+		name: "ai",
+		check: func(ctx context.Context) error {
+			statuses, err := manager.HealthCheck(ctx)
+			if err != nil {
+				return err
+			}
+			errors := make(map[string]error)
+			for name, status := range statuses {
+				if status != nil && !status.Healthy {
+					errors[name] = fmt.Errorf("%s", status.Message)
+				}
+			}
+			return providerErrors(errors)
+		},
+	}
+}
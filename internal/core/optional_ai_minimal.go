@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_ai
+
+package core
+
+// AIManager stands in for the AI manager, which this build leaves out
+type AIManager struct{}
+
+func (b *Bootstrap) initializeAI() error {
+	return notCompiled("ai", b.config.Optional.AI != nil)
+}
+
+func (b *Bootstrap) aiComponent() component { return component{name: "ai"} }
@@ -0,0 +1,52 @@
+//go:build !microframework_minimal || microframework_api
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/api"
+)
+
+// APIManager is the API manager of go-micro-libs
+type APIManager = api.APIManager
+
+func NewAPIManager(config interface{}, logger *logrus.Logger) *APIManager {
+	if cfg, ok := config.(*api.ManagerConfig); ok {
+		return api.NewAPIManager(cfg, logger)
+	}
+	return api.NewAPIManager(nil, logger)
+}
+func DefaultAPIManagerConfig() interface{} { return api.DefaultManagerConfig() }
+
+// initializeAPI creates the API manager when optional.api is configured
+func (b *Bootstrap) initializeAPI() error {
+	if b.config.Optional.API == nil {
+		return nil
+	}
+	apiConfig, err := optionalManagerConfig(DefaultAPIManagerConfig(), "optional.api", b.config.Optional.API)
+	if err != nil {
+		return fmt.Errorf("failed to configure API manager: %w", err)
+	}
+	b.apiManager = NewAPIManager(
+		apiConfig,
+		b.logger,
+	)
+	b.logger.Info("API manager initialized")
+	return nil
+}
+
+// apiComponent returns the API manager as a component
+func (b *Bootstrap) apiComponent() component {
+	manager := b.apiManager
+	return component{
+		name: "api",
+		check: func(ctx context.Context) error {
+			return providerErrors(manager.HealthCheck(ctx))
+		},
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_api
+
+package core
+
+// APIManager stands in for the API manager, which this build leaves out
+type APIManager struct{}
+
+func (b *Bootstrap) initializeAPI() error {
+	return notCompiled("api", b.config.Optional.API != nil)
+}
+
+func (b *Bootstrap) apiComponent() component { return component{name: "api"} }
@@ -0,0 +1,29 @@
+//go:build !microframework_minimal || microframework_backup
+
+package core
+
+import (
+	"github.com/anasamu/go-micro-libs/backup"
+)
+
+// BackupManager is the backup manager of go-micro-libs
+type BackupManager = backup.BackupManager
+
+func NewBackupManager() *BackupManager { return backup.NewBackupManager() }
+
+// initializeBackup creates the backup manager when optional.backup is configured
+func (b *Bootstrap) initializeBackup() error {
+	if b.config.Optional.Backup == nil {
+		return nil
+	}
+	b.backupManager = NewBackupManager()
+	b.logger.Info("Backup manager initialized")
+	return nil
+}
+
+// backupComponent returns the backup manager as a component
+func (b *Bootstrap) backupComponent() component {
+	return component{
+		name: "backup",
+	}
+}
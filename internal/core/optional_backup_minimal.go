@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_backup
+
+package core
+
+// BackupManager stands in for the backup manager, which this build leaves out
+type BackupManager struct{}
+
+func (b *Bootstrap) initializeBackup() error {
+	return notCompiled("backup", b.config.Optional.Backup != nil)
+}
+
+func (b *Bootstrap) backupComponent() component { return component{name: "backup"} }
@@ -0,0 +1,69 @@
+//go:build !microframework_minimal || microframework_cache
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/cache"
+)
+
+// CacheManager is the cache manager of go-micro-libs
+type CacheManager = cache.CacheManager
+
+func NewCacheManager(config interface{}, logger *logrus.Logger) *CacheManager {
+	if cfg, ok := config.(*cache.ManagerConfig); ok {
+		return cache.NewCacheManager(cfg, logger)
+	}
+	return cache.NewCacheManager(nil, logger)
+}
+func DefaultCacheManagerConfig() interface{} {
+	return &cache.ManagerConfig{RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
+
+// initializeCache creates the cache manager when optional.cache is configured
+func (b *Bootstrap) initializeCache() error {
+	if b.config.Optional.Cache == nil {
+		return nil
+	}
+	cacheConfig, err := optionalManagerConfig(DefaultCacheManagerConfig(), "optional.cache", b.config.Optional.Cache)
+	if err != nil {
+		return fmt.Errorf("failed to configure cache manager: %w", err)
+	}
+	b.cacheManager = NewCacheManager(
+		cacheConfig,
+		b.logger,
+	)
+	b.logger.Info("Cache manager initialized")
+	return nil
+}
+
+// cacheComponent returns the cache manager as a component
+func (b *Bootstrap) cacheComponent() component {
+	manager := b.cacheManager
+	return component{
+		name: "cache",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
+
+// startCache connects the registered cache providers
+func (b *Bootstrap) startCache(ctx context.Context) error {
+	if b.cacheManager == nil {
+		return nil
+	}
+	for _, name := range b.cacheManager.ListProviders() {
+		provider, err := b.cacheManager.GetProvider(name)
+		if err != nil || provider.IsConnected() {
+			continue
+		}
+		if err := b.connectWithRetry(ctx, "cache "+name, provider.Connect); err != nil {
+			return fmt.Errorf("failed to connect to cache %s: %w", name, err)
+		}
+	}
+	return nil
+}
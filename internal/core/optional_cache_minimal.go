@@ -0,0 +1,16 @@
+//go:build microframework_minimal && !microframework_cache
+
+package core
+
+import "context"
+
+// CacheManager stands in for the cache manager, which this build leaves out
+type CacheManager struct{}
+
+func (b *Bootstrap) initializeCache() error {
+	return notCompiled("cache", b.config.Optional.Cache != nil)
+}
+
+func (b *Bootstrap) cacheComponent() component { return component{name: "cache"} }
+
+func (b *Bootstrap) startCache(ctx context.Context) error { return nil }
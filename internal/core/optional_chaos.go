@@ -0,0 +1,29 @@
+//go:build !microframework_minimal || microframework_chaos
+
+package core
+
+import (
+	"github.com/anasamu/go-micro-libs/chaos"
+)
+
+// ChaosManager is the chaos manager of go-micro-libs
+type ChaosManager = chaos.Manager
+
+func NewChaosManager() *ChaosManager { return chaos.NewManager() }
+
+// initializeChaos creates the chaos manager when optional.chaos is configured
+func (b *Bootstrap) initializeChaos() error {
+	if b.config.Optional.Chaos == nil {
+		return nil
+	}
+	b.chaosManager = NewChaosManager()
+	b.logger.Info("Chaos manager initialized")
+	return nil
+}
+
+// chaosComponent returns the chaos manager as a component
+func (b *Bootstrap) chaosComponent() component {
+	return component{
+		name: "chaos",
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_chaos
+
+package core
+
+// ChaosManager stands in for the chaos manager, which this build leaves out
+type ChaosManager struct{}
+
+func (b *Bootstrap) initializeChaos() error {
+	return notCompiled("chaos", b.config.Optional.Chaos != nil)
+}
+
+func (b *Bootstrap) chaosComponent() component { return component{name: "chaos"} }
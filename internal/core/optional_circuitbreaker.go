@@ -0,0 +1,51 @@
+//go:build !microframework_minimal || microframework_circuitbreaker
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/circuitbreaker"
+)
+
+// CircuitBreakerManager is the circuit breaker manager of go-micro-libs
+type CircuitBreakerManager = circuitbreaker.CircuitBreakerManager
+
+func NewCircuitBreakerManager(config interface{}, logger *logrus.Logger) *CircuitBreakerManager {
+	if cfg, ok := config.(*circuitbreaker.ManagerConfig); ok {
+		return circuitbreaker.NewCircuitBreakerManager(cfg, logger)
+	}
+	return circuitbreaker.NewCircuitBreakerManager(nil, logger)
+}
+func DefaultCircuitBreakerManagerConfig() interface{} {
+	return &circuitbreaker.ManagerConfig{RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
+
+// initializeCircuitBreaker creates the circuit breaker manager when optional.circuitbreaker is configured
+func (b *Bootstrap) initializeCircuitBreaker() error {
+	if b.config.Optional.CircuitBreaker == nil {
+		return nil
+	}
+	circuitBreakerConfig, err := optionalManagerConfig(DefaultCircuitBreakerManagerConfig(), "optional.circuitbreaker", b.config.Optional.CircuitBreaker)
+	if err != nil {
+		return fmt.Errorf("failed to configure circuit breaker manager: %w", err)
+	}
+	b.circuitBreakerManager = NewCircuitBreakerManager(
+		circuitBreakerConfig,
+		b.logger,
+	)
+	b.logger.Info("Circuit breaker manager initialized")
+	return nil
+}
+
+// circuitbreakerComponent returns the circuit breaker manager as a component
+func (b *Bootstrap) circuitbreakerComponent() component {
+	manager := b.circuitBreakerManager
+	return component{
+		name: "circuitbreaker",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_circuitbreaker
+
+package core
+
+// CircuitBreakerManager stands in for the circuit breaker manager, which this build leaves out
+type CircuitBreakerManager struct{}
+
+func (b *Bootstrap) initializeCircuitBreaker() error {
+	return notCompiled("circuitbreaker", b.config.Optional.CircuitBreaker != nil)
+}
+
+func (b *Bootstrap) circuitbreakerComponent() component { return component{name: "circuitbreaker"} }
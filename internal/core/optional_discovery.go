@@ -0,0 +1,51 @@
+//go:build !microframework_minimal || microframework_discovery
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/discovery"
+)
+
+// DiscoveryManager is the discovery manager of go-micro-libs
+type DiscoveryManager = discovery.DiscoveryManager
+
+func NewDiscoveryManager(config interface{}, logger *logrus.Logger) *DiscoveryManager {
+	if cfg, ok := config.(*discovery.ManagerConfig); ok {
+		return discovery.NewDiscoveryManager(cfg, logger)
+	}
+	return discovery.NewDiscoveryManager(nil, logger)
+}
+func DefaultDiscoveryManagerConfig() interface{} {
+	return &discovery.ManagerConfig{RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
+
+// initializeDiscovery creates the discovery manager when optional.discovery is configured
+func (b *Bootstrap) initializeDiscovery() error {
+	if b.config.Optional.Discovery == nil {
+		return nil
+	}
+	discoveryConfig, err := optionalManagerConfig(DefaultDiscoveryManagerConfig(), "optional.discovery", b.config.Optional.Discovery)
+	if err != nil {
+		return fmt.Errorf("failed to configure discovery manager: %w", err)
+	}
+	b.discoveryManager = NewDiscoveryManager(
+		discoveryConfig,
+		b.logger,
+	)
+	b.logger.Info("Discovery manager initialized")
+	return nil
+}
+
+// discoveryComponent returns the discovery manager as a component
+func (b *Bootstrap) discoveryComponent() component {
+	manager := b.discoveryManager
+	return component{
+		name: "discovery",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_discovery
+
+package core
+
+// DiscoveryManager stands in for the discovery manager, which this build leaves out
+type DiscoveryManager struct{}
+
+func (b *Bootstrap) initializeDiscovery() error {
+	return notCompiled("discovery", b.config.Optional.Discovery != nil)
+}
+
+func (b *Bootstrap) discoveryComponent() component { return component{name: "discovery"} }
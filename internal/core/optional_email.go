@@ -0,0 +1,52 @@
+//go:build !microframework_minimal || microframework_email
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/email"
+)
+
+// EmailManager is the email manager of go-micro-libs
+type EmailManager = email.EmailManager
+
+func NewEmailManager(config interface{}, logger *logrus.Logger) *EmailManager {
+	if cfg, ok := config.(*email.ManagerConfig); ok {
+		return email.NewEmailManager(cfg, logger)
+	}
+	return email.NewEmailManager(nil, logger)
+}
+func DefaultEmailManagerConfig() interface{} { return email.DefaultManagerConfig() }
+
+// initializeEmail creates the email manager when optional.email is configured
+func (b *Bootstrap) initializeEmail() error {
+	if b.config.Optional.Email == nil {
+		return nil
+	}
+	emailConfig, err := optionalManagerConfig(DefaultEmailManagerConfig(), "optional.email", b.config.Optional.Email)
+	if err != nil {
+		return fmt.Errorf("failed to configure email manager: %w", err)
+	}
+	b.emailManager = NewEmailManager(
+		emailConfig,
+		b.logger,
+	)
+	b.logger.Info("Email manager initialized")
+	return nil
+}
+
+// emailComponent returns the email manager as a component
+func (b *Bootstrap) emailComponent() component {
+	manager := b.emailManager
+	return component{
+		name: "email",
+		check: func(ctx context.Context) error {
+			return providerErrors(manager.HealthCheck(ctx))
+		},
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_email
+
+package core
+
+// EmailManager stands in for the email manager, which this build leaves out
+type EmailManager struct{}
+
+func (b *Bootstrap) initializeEmail() error {
+	return notCompiled("email", b.config.Optional.Email != nil)
+}
+
+func (b *Bootstrap) emailComponent() component { return component{name: "email"} }
@@ -0,0 +1,55 @@
+//go:build !microframework_minimal || microframework_event
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/event"
+)
+
+// EventManager is the event sourcing manager of go-micro-libs
+type EventManager = event.EventSourcingManager
+
+func NewEventManager(config interface{}, logger *logrus.Logger) *EventManager {
+	if cfg, ok := config.(*event.ManagerConfig); ok {
+		return event.NewEventSourcingManager(cfg, logger)
+	}
+	return event.NewEventSourcingManager(nil, logger)
+}
+func DefaultEventManagerConfig() interface{} {
+	return &event.ManagerConfig{DefaultProvider: "postgresql", RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, MaxEventSize: 1024 * 1024, MaxBatchSize: 100, SnapshotThreshold: 1000, RetentionPeriod: 365 * 24 * time.Hour, Metadata: map[string]string{}}
+}
+
+// initializeEvent creates the event sourcing manager when optional.event is configured
+func (b *Bootstrap) initializeEvent() error {
+	if b.config.Optional.Event == nil {
+		return nil
+	}
+	eventConfig, err := optionalManagerConfig(DefaultEventManagerConfig(), "optional.event", b.config.Optional.Event)
+	if err != nil {
+		return fmt.Errorf("failed to configure event manager: %w", err)
+	}
+	b.eventManager = NewEventManager(
+		eventConfig,
+		b.logger,
+	)
+	b.logger.Info("Event manager initialized")
+	return nil
+}
+
+// eventComponent returns the event sourcing manager as a component
+func (b *Bootstrap) eventComponent() component {
+	manager := b.eventManager
+	return component{
+		name: "event",
+		check: func(ctx context.Context) error {
+			return providerErrors(manager.HealthCheck(ctx))
+		},
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
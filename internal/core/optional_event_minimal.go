@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_event
+
+package core
+
+// EventManager stands in for the event sourcing manager, which this build leaves out
+type EventManager struct{}
+
+func (b *Bootstrap) initializeEvent() error {
+	return notCompiled("event", b.config.Optional.Event != nil)
+}
+
+func (b *Bootstrap) eventComponent() component { return component{name: "event"} }
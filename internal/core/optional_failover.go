@@ -0,0 +1,51 @@
+//go:build !microframework_minimal || microframework_failover
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/failover"
+)
+
+// FailoverManager is the failover manager of go-micro-libs
+type FailoverManager = failover.FailoverManager
+
+func NewFailoverManager(config interface{}, logger *logrus.Logger) *FailoverManager {
+	if cfg, ok := config.(*failover.ManagerConfig); ok {
+		return failover.NewFailoverManager(cfg, logger)
+	}
+	return failover.NewFailoverManager(nil, logger)
+}
+func DefaultFailoverManagerConfig() interface{} {
+	return &failover.ManagerConfig{RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
+
+// initializeFailover creates the failover manager when optional.failover is configured
+func (b *Bootstrap) initializeFailover() error {
+	if b.config.Optional.Failover == nil {
+		return nil
+	}
+	failoverConfig, err := optionalManagerConfig(DefaultFailoverManagerConfig(), "optional.failover", b.config.Optional.Failover)
+	if err != nil {
+		return fmt.Errorf("failed to configure failover manager: %w", err)
+	}
+	b.failoverManager = NewFailoverManager(
+		failoverConfig,
+		b.logger,
+	)
+	b.logger.Info("Failover manager initialized")
+	return nil
+}
+
+// failoverComponent returns the failover manager as a component
+func (b *Bootstrap) failoverComponent() component {
+	manager := b.failoverManager
+	return component{
+		name: "failover",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_failover
+
+package core
+
+// FailoverManager stands in for the failover manager, which this build leaves out
+type FailoverManager struct{}
+
+func (b *Bootstrap) initializeFailover() error {
+	return notCompiled("failover", b.config.Optional.Failover != nil)
+}
+
+func (b *Bootstrap) failoverComponent() component { return component{name: "failover"} }
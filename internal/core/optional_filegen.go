@@ -0,0 +1,54 @@
+//go:build !microframework_minimal || microframework_filegen
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/anasamu/go-micro-libs/filegen"
+	filegentypes "github.com/anasamu/go-micro-libs/filegen/types"
+)
+
+// FileGenManager is the file generation manager of go-micro-libs
+type FileGenManager = filegen.Manager
+
+func NewFilegenManager(config interface{}) (*FileGenManager, error) {
+	if cfg, ok := config.(*filegen.ManagerConfig); ok {
+		return filegen.NewManager(cfg)
+	}
+	return filegen.NewManager(nil)
+}
+func DefaultFilegenManagerConfig() interface{} {
+	return &filegen.ManagerConfig{
+		TemplatePath: "./templates",
+		OutputPath:   "./output",
+		MaxFileSize:  100 * 1024 * 1024,
+		AllowedTypes: []filegentypes.FileType{filegentypes.FileTypeDOCX, filegentypes.FileTypeExcel, filegentypes.FileTypeCSV, filegentypes.FileTypePDF, filegentypes.FileTypeCustom},
+	}
+}
+
+// initializeFileGen creates the file generation manager when optional.filegen is configured
+func (b *Bootstrap) initializeFileGen() error {
+	if b.config.Optional.FileGen == nil {
+		return nil
+	}
+	filegenConfig, err := optionalManagerConfig(DefaultFilegenManagerConfig(), "optional.filegen", b.config.Optional.FileGen)
+	if err != nil {
+		return fmt.Errorf("failed to configure file generation manager: %w", err)
+	}
+	b.filegenManager, err = NewFilegenManager(filegenConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize file generation manager: %w", err)
+	}
+	b.logger.Info("File generation manager initialized")
+	return nil
+}
+
+// filegenComponent returns the file generation manager as a component
+func (b *Bootstrap) filegenComponent() component {
+	manager := b.filegenManager
+	return component{
+		name: "filegen",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
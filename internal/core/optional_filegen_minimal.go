@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_filegen
+
+package core
+
+// FileGenManager stands in for the file generation manager, which this build leaves out
+type FileGenManager struct{}
+
+func (b *Bootstrap) initializeFileGen() error {
+	return notCompiled("filegen", b.config.Optional.FileGen != nil)
+}
+
+func (b *Bootstrap) filegenComponent() component { return component{name: "filegen"} }
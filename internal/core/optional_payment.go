@@ -0,0 +1,46 @@
+//go:build !microframework_minimal || microframework_payment
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/payment"
+)
+
+// PaymentManager is the payment manager of go-micro-libs
+type PaymentManager = payment.PaymentManager
+
+func NewPaymentManager(config interface{}, logger *logrus.Logger) *PaymentManager {
+	if cfg, ok := config.(*payment.ManagerConfig); ok {
+		return payment.NewPaymentManager(cfg, logger)
+	}
+	return payment.NewPaymentManager(nil, logger)
+}
+func DefaultPaymentManagerConfig() interface{} { return payment.DefaultManagerConfig() }
+
+// initializePayment creates the payment manager when optional.payment is configured
+func (b *Bootstrap) initializePayment() error {
+	if b.config.Optional.Payment == nil {
+		return nil
+	}
+	paymentConfig, err := optionalManagerConfig(DefaultPaymentManagerConfig(), "optional.payment", b.config.Optional.Payment)
+	if err != nil {
+		return fmt.Errorf("failed to configure payment manager: %w", err)
+	}
+	b.paymentManager = NewPaymentManager(
+		paymentConfig,
+		b.logger,
+	)
+	b.logger.Info("Payment manager initialized")
+	return nil
+}
+
+// paymentComponent returns the payment manager as a component
+func (b *Bootstrap) paymentComponent() component {
+	return component{
+		name: "payment",
+	}
+}
@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_payment
+
+package core
+
+// PaymentManager stands in for the payment manager, which this build leaves out
+type PaymentManager struct{}
+
+func (b *Bootstrap) initializePayment() error {
+	return notCompiled("payment", b.config.Optional.Payment != nil)
+}
+
+func (b *Bootstrap) paymentComponent() component { return component{name: "payment"} }
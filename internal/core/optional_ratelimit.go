@@ -0,0 +1,51 @@
+//go:build !microframework_minimal || microframework_ratelimit
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/ratelimit"
+)
+
+// RateLimitManager is the rate limit manager of go-micro-libs
+type RateLimitManager = ratelimit.RateLimitManager
+
+func NewRateLimitManager(config interface{}, logger *logrus.Logger) *RateLimitManager {
+	if cfg, ok := config.(*ratelimit.ManagerConfig); ok {
+		return ratelimit.NewRateLimitManager(cfg, logger)
+	}
+	return ratelimit.NewRateLimitManager(nil, logger)
+}
+func DefaultRateLimitManagerConfig() interface{} {
+	return &ratelimit.ManagerConfig{RetryAttempts: 3, RetryDelay: time.Second, Timeout: 30 * time.Second, FallbackEnabled: true, Metadata: map[string]string{}}
+}
+
+// initializeRateLimit creates the rate limit manager when optional.ratelimit is configured
+func (b *Bootstrap) initializeRateLimit() error {
+	if b.config.Optional.RateLimit == nil {
+		return nil
+	}
+	rateLimitConfig, err := optionalManagerConfig(DefaultRateLimitManagerConfig(), "optional.ratelimit", b.config.Optional.RateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to configure rate limit manager: %w", err)
+	}
+	b.rateLimitManager = NewRateLimitManager(
+		rateLimitConfig,
+		b.logger,
+	)
+	b.logger.Info("Rate limit manager initialized")
+	return nil
+}
+
+// ratelimitComponent returns the rate limit manager as a component
+func (b *Bootstrap) ratelimitComponent() component {
+	manager := b.rateLimitManager
+	return component{
+		name: "ratelimit",
+		stop: closer(func() error { return manager.Close() }),
+	}
+}
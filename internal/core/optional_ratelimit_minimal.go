@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_ratelimit
+
+package core
+
+// RateLimitManager stands in for the rate limit manager, which this build leaves out
+type RateLimitManager struct{}
+
+func (b *Bootstrap) initializeRateLimit() error {
+	return notCompiled("ratelimit", b.config.Optional.RateLimit != nil)
+}
+
+func (b *Bootstrap) ratelimitComponent() component { return component{name: "ratelimit"} }
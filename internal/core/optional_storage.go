@@ -0,0 +1,51 @@
+//go:build !microframework_minimal || microframework_storage
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anasamu/go-micro-libs/storage"
+)
+
+// StorageManager is the storage manager of go-micro-libs
+type StorageManager = storage.StorageManager
+
+func NewStorageManager(config interface{}, logger *logrus.Logger) *StorageManager {
+	if cfg, ok := config.(*storage.ManagerConfig); ok {
+		return storage.NewStorageManager(cfg, logger)
+	}
+	return storage.NewStorageManager(nil, logger)
+}
+func DefaultStorageManagerConfig() interface{} { return storage.DefaultManagerConfig() }
+
+// initializeStorage creates the storage manager when optional.storage is configured
+func (b *Bootstrap) initializeStorage() error {
+	if b.config.Optional.Storage == nil {
+		return nil
+	}
+	storageConfig, err := optionalManagerConfig(DefaultStorageManagerConfig(), "optional.storage", b.config.Optional.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to configure storage manager: %w", err)
+	}
+	b.storageManager = NewStorageManager(
+		storageConfig,
+		b.logger,
+	)
+	b.logger.Info("Storage manager initialized")
+	return nil
+}
+
+// storageComponent returns the storage manager as a component
+func (b *Bootstrap) storageComponent() component {
+	manager := b.storageManager
+	return component{
+		name: "storage",
+		check: func(ctx context.Context) error {
+			return providerErrors(manager.HealthCheck(ctx))
+		},
+	}
+}
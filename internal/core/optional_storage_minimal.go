@@ -0,0 +1,12 @@
+//go:build microframework_minimal && !microframework_storage
+
+package core
+
+// StorageManager stands in for the storage manager, which this build leaves out
+type StorageManager struct{}
+
+func (b *Bootstrap) initializeStorage() error {
+	return notCompiled("storage", b.config.Optional.Storage != nil)
+}
+
+func (b *Bootstrap) storageComponent() component { return component{name: "storage"} }
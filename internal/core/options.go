@@ -0,0 +1,192 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures the Bootstrap built by New
+type Option func(*options)
+
+// options are what the options of New set
+type options struct {
+	configFile      string
+	config          *FrameworkConfig
+	service         *ServiceConfig
+	logger          *logrus.Logger
+	components      []Component
+	secretProviders []SecretProvider
+	// httpAddr is the address of the HTTP server of WithHTTPServer
+	httpAddr string
+	// edits change the configuration once it is read, in the order of the options
+	edits []func(*FrameworkConfig) error
+}
+
+// New builds a Bootstrap from options, composing the runtime of a service in a few lines:
+//
+//	bootstrap, err := core.New(
+//		core.WithConfigFile("configs/config.yaml"),
+//		core.WithDatabase("postgresql"),
+//		core.WithComponent(orders.NewConsumer()),
+//		core.WithHTTPServer(":8080"),
+//	)
+//
+// The configuration is that of WithConfigFile or WithConfig, or an empty one, edited by the
+// other options and validated. Without WithLogger, the Bootstrap logs with a new logrus
+// logger.
+func New(opts ...Option) (*Bootstrap, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	config := o.config
+	switch {
+	case o.configFile != "" && config != nil:
+		return nil, fmt.Errorf("WithConfigFile and WithConfig cannot be used together")
+	case o.configFile != "":
+		read, err := readConfig(o.configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = read
+	case config == nil:
+		config = &FrameworkConfig{}
+	}
+
+	if o.service != nil {
+		if o.service.Name != "" {
+			config.Service.Name = o.service.Name
+		}
+		if o.service.Version != "" {
+			config.Service.Version = o.service.Version
+		}
+	}
+	for _, edit := range o.edits {
+		if err := edit(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	bootstrap := NewBootstrap(config, o.logger)
+	if o.httpAddr != "" {
+		_, port, err := net.SplitHostPort(o.httpAddr)
+		if err != nil {
+			return nil, fmt.Errorf("WithHTTPServer: invalid address %q: %w", o.httpAddr, err)
+		}
+		if number, err := strconv.Atoi(port); err != nil || number < 0 {
+			return nil, fmt.Errorf("WithHTTPServer: invalid port in %q", o.httpAddr)
+		}
+		bootstrap.serveHTTP(o.httpAddr)
+	}
+	for _, provider := range o.secretProviders {
+		if err := bootstrap.RegisterSecretProvider(provider); err != nil {
+			return nil, err
+		}
+	}
+	for _, component := range o.components {
+		if err := bootstrap.Register(component); err != nil {
+			return nil, err
+		}
+	}
+	return bootstrap, nil
+}
+
+// WithConfigFile reads the configuration from the YAML file at path, as LoadConfig does
+func WithConfigFile(path string) Option {
+	return func(o *options) {
+		o.configFile = path
+	}
+}
+
+// WithConfig uses a configuration built or read already; the other options edit it
+func WithConfig(config *FrameworkConfig) Option {
+	return func(o *options) {
+		o.config = config
+	}
+}
+
+// WithService sets the name and the version of the service, over those of the configuration;
+// an empty value keeps the configured one
+func WithService(name, version string) Option {
+	return func(o *options) {
+		o.service = &ServiceConfig{Name: name, Version: version}
+	}
+}
+
+// WithLogger logs with logger
+func WithLogger(logger *logrus.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithComponent registers a component, as Register does, in the order of the options
+func WithComponent(component Component) Option {
+	return func(o *options) {
+		o.components = append(o.components, component)
+	}
+}
+
+// WithSecretProvider registers a secret backend, as RegisterSecretProvider does
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(o *options) {
+		o.secretProviders = append(o.secretProviders, provider)
+	}
+}
+
+// WithDatabase enables the database manager with providers. The providers the configuration
+// does not have are added without settings, which they then read from their defaults.
+func WithDatabase(providers ...string) Option {
+	return func(o *options) {
+		o.edits = append(o.edits, func(config *FrameworkConfig) error {
+			if config.Database == nil {
+				config.Database = &DatabaseConfig{}
+			}
+			config.Database.Providers = withProviders(config.Database.Providers, providers)
+			return nil
+		})
+	}
+}
+
+// WithMessaging enables the messaging manager with providers, as WithDatabase does
+func WithMessaging(providers ...string) Option {
+	return func(o *options) {
+		o.edits = append(o.edits, func(config *FrameworkConfig) error {
+			if config.Messaging == nil {
+				config.Messaging = &MessagingConfig{}
+			}
+			config.Messaging.Providers = withProviders(config.Messaging.Providers, providers)
+			return nil
+		})
+	}
+}
+
+// WithHTTPServer makes Start serve HTTP on addr, host:port (":8080" listens on every
+// interface): /healthz, /readyz and /debug/startup, and the handlers the service registers
+// on HTTPMux. Stop shuts the server down before the components. The server takes its
+// timeouts from the server section of the configuration.
+func WithHTTPServer(addr string) Option {
+	return func(o *options) {
+		o.httpAddr = addr
+	}
+}
+
+// withProviders adds the providers a providers map does not have, without settings
+func withProviders(configured map[string]interface{}, providers []string) map[string]interface{} {
+	if configured == nil {
+		configured = make(map[string]interface{})
+	}
+	for _, provider := range providers {
+		if _, ok := configured[provider]; !ok {
+			configured[provider] = map[string]interface{}{}
+		}
+	}
+	return configured
+}
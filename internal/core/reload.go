@@ -0,0 +1,541 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	configtypes "github.com/anasamu/go-micro-libs/config/types"
+	ratelimittypes "github.com/anasamu/go-micro-libs/ratelimit/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultConfigWatchInterval is how often WatchConfig checks the configuration file
+const DefaultConfigWatchInterval = 5 * time.Second
+
+// maxReloadEvents is the number of reloads Reloads keeps
+const maxReloadEvents = 20
+
+// ReloadEvent records a configuration reload: the sections that changed, the components
+// reconfigured in place, restarted, or stopped because their section was removed, or why the
+// reload failed
+type ReloadEvent struct {
+	Time      time.Time `json:"time"`
+	Changed   []string  `json:"changed"`
+	Applied   []string  `json:"applied,omitempty"`
+	Restarted []string  `json:"restarted,omitempty"`
+	Stopped   []string  `json:"stopped,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// configSection is a section of the configuration and the component built from it; sections
+// without a component are read when used and take effect as soon as they are reloaded
+type configSection struct {
+	name      string
+	component string
+	value     func(c *FrameworkConfig) interface{}
+}
+
+var configSections = []configSection{
+	{"service", "", func(c *FrameworkConfig) interface{} { return c.Service }},
+	{"server", "communication", func(c *FrameworkConfig) interface{} { return c.Server }},
+	{"logging", "logging", func(c *FrameworkConfig) interface{} { return c.Logging }},
+	{"monitoring", "monitoring", func(c *FrameworkConfig) interface{} { return c.Monitoring }},
+	{"database", "database", func(c *FrameworkConfig) interface{} { return c.Database }},
+	{"auth", "auth", func(c *FrameworkConfig) interface{} { return c.Auth }},
+	{"messaging", "messaging", func(c *FrameworkConfig) interface{} { return c.Messaging }},
+	{"startup", "", func(c *FrameworkConfig) interface{} { return c.Startup }},
+	{"shutdown", "", func(c *FrameworkConfig) interface{} { return c.Shutdown }},
+	{"secrets", "", func(c *FrameworkConfig) interface{} { return c.Secrets }},
+	{"watchdog", "", func(c *FrameworkConfig) interface{} { return c.Watchdog }},
+	{"optional.api", "api", func(c *FrameworkConfig) interface{} { return c.Optional.API }},
+	{"optional.ai", "ai", func(c *FrameworkConfig) interface{} { return c.Optional.AI }},
+	{"optional.storage", "storage", func(c *FrameworkConfig) interface{} { return c.Optional.Storage }},
+	{"optional.scheduling", "scheduling", func(c *FrameworkConfig) interface{} { return c.Optional.Scheduling }},
+	{"optional.backup", "backup", func(c *FrameworkConfig) interface{} { return c.Optional.Backup }},
+	{"optional.chaos", "chaos", func(c *FrameworkConfig) interface{} { return c.Optional.Chaos }},
+	{"optional.failover", "failover", func(c *FrameworkConfig) interface{} { return c.Optional.Failover }},
+	{"optional.event", "event", func(c *FrameworkConfig) interface{} { return c.Optional.Event }},
+	{"optional.discovery", "discovery", func(c *FrameworkConfig) interface{} { return c.Optional.Discovery }},
+	{"optional.cache", "cache", func(c *FrameworkConfig) interface{} { return c.Optional.Cache }},
+	{"optional.ratelimit", "ratelimit", func(c *FrameworkConfig) interface{} { return c.Optional.RateLimit }},
+	{"optional.circuitbreaker", "circuitbreaker", func(c *FrameworkConfig) interface{} { return c.Optional.CircuitBreaker }},
+	{"optional.filegen", "filegen", func(c *FrameworkConfig) interface{} { return c.Optional.FileGen }},
+	{"optional.payment", "payment", func(c *FrameworkConfig) interface{} { return c.Optional.Payment }},
+	{"optional.email", "email", func(c *FrameworkConfig) interface{} { return c.Optional.Email }},
+	{"optional.featureflags", "featureflags", func(c *FrameworkConfig) interface{} { return c.Optional.FeatureFlags }},
+	{"optional.leaderelection", "leaderelection", func(c *FrameworkConfig) interface{} { return c.Optional.LeaderElection }},
+}
+
+// WatchConfig reloads the configuration from the file at path whenever it changes, until ctx
+// is done. The file is checked every interval (DefaultConfigWatchInterval when zero), and
+// right away when the provider of the config manager reports a change. A file that fails to
+// load is rejected and the running configuration kept.
+func (b *Bootstrap) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultConfigWatchInterval
+	}
+	last, err := fileDigest(path)
+	if err != nil {
+		return &ConfigFileError{Path: path, Err: err}
+	}
+
+	notified := make(chan struct{}, 1)
+	if b.configManager != nil {
+		// Without a current provider there is nothing to be notified of
+		_ = b.configManager.Watch(func(*configtypes.Config) {
+			select {
+			case notified <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-notified:
+			}
+
+			digest, err := fileDigest(path)
+			if err != nil {
+				b.logger.WithError(err).Warn("Failed to read the configuration file")
+				continue
+			}
+			if bytes.Equal(digest, last) {
+				continue
+			}
+			last = digest
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				b.recordReload(ctx, ReloadEvent{Time: time.Now(), Error: err.Error()})
+				continue
+			}
+			b.Reload(ctx, config)
+		}
+	}()
+	return nil
+}
+
+// fileDigest returns the SHA-256 digest of a file
+func fileDigest(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// Reload switches to a new configuration. The log level and the rate limits are applied in
+// place; every other component whose section changed is stopped, rebuilt from the new
+// configuration and started again, in dependency order. The new manager configurations are
+// checked before anything is stopped, so a configuration that cannot be applied leaves the
+// running components untouched. Every reload is logged, counted in monitoring and kept in
+// Reloads.
+func (b *Bootstrap) Reload(ctx context.Context, config *FrameworkConfig) error {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	event := ReloadEvent{Time: time.Now()}
+	err := b.reload(ctx, config, &event)
+	if err != nil {
+		event.Error = err.Error()
+	}
+	b.recordReload(ctx, event)
+	return err
+}
+
+func (b *Bootstrap) reload(ctx context.Context, config *FrameworkConfig, event *ReloadEvent) error {
+	if b.State() == StateStopping {
+		return fmt.Errorf("the framework is stopping")
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := b.resolveSecrets(ctx, config); err != nil {
+		return fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	b.mu.RLock()
+	current := b.config
+	b.mu.RUnlock()
+
+	restart := make(map[string]bool)
+	for _, section := range configSections {
+		if reflect.DeepEqual(section.value(current), section.value(config)) {
+			continue
+		}
+		event.Changed = append(event.Changed, section.name)
+
+		switch {
+		case section.component == "":
+		case section.component == "logging":
+			event.Applied = append(event.Applied, "logging")
+		case section.component == "ratelimit" && current.Optional.RateLimit != nil && config.Optional.RateLimit != nil &&
+			reflect.DeepEqual(withoutKey(current.Optional.RateLimit, "limits"), withoutKey(config.Optional.RateLimit, "limits")):
+			event.Applied = append(event.Applied, "ratelimit")
+		default:
+			restart[section.component] = true
+		}
+	}
+	if len(event.Changed) == 0 {
+		return nil
+	}
+
+	// Build the new managers aside first, to find configuration errors
+	check := &Bootstrap{config: config, logger: logrus.New()}
+	check.logger.SetOutput(io.Discard)
+	if err := check.applyLogLevel(); err != nil {
+		return err
+	}
+	for _, name := range append(coreComponents, optionalComponents...) {
+		if restart[name] {
+			if err := check.initializeComponent(ctx, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Stop the components to restart, the users before what they use
+	if restart["database"] {
+		restart["migration"] = true
+	}
+	components := b.components()
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		if !restart[component.name] {
+			continue
+		}
+		b.setComponentState(component.name, StateStopping, nil)
+		if component.stop != nil {
+			if err := b.stopComponent(ctx, component); err != nil {
+				b.logger.WithError(err).Warnf("Failed to stop %s for the reload", component.name)
+			}
+		}
+	}
+
+	b.config = config
+	if err := b.applyLogLevel(); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, name := range append(coreComponents, optionalComponents...) {
+		if !restart[name] {
+			continue
+		}
+		running := b.hasComponent(name)
+		b.clearComponent(name)
+		b.deleteComponentState(name)
+		if err := b.timeComponent(name, "init", func() error { return b.initializeComponent(ctx, name) }); err != nil {
+			failed = append(failed, name)
+			b.logger.WithError(err).Errorf("Failed to restart %s", name)
+			continue
+		}
+		if !b.hasComponent(name) {
+			event.Stopped = append(event.Stopped, name)
+			continue
+		}
+		if err := b.timeComponent(name, "start", func() error { return b.startComponent(ctx, name) }); err != nil {
+			failed = append(failed, name)
+			b.logger.WithError(err).Errorf("Failed to restart %s", name)
+			b.setComponentState(name, StateDegraded, err)
+			continue
+		}
+		event.Restarted = append(event.Restarted, name)
+		if running {
+			b.countRestart(name)
+		}
+		b.setComponentState(name, StateReady, nil)
+		if name == "database" && b.migrationManager != nil {
+			b.setComponentState("migration", StateReady, nil)
+		}
+	}
+
+	// The scheduled tasks follow the restarted election, or run everywhere without one
+	if restart["leaderelection"] && !restart["scheduling"] && b.schedulingManager != nil {
+		b.gateScheduling()
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restart %v", failed)
+	}
+	return nil
+}
+
+// hasComponent reports whether a component is initialized; it is called with b.mu held, or
+// during Initialize
+func (b *Bootstrap) hasComponent(name string) bool {
+	for _, component := range b.components() {
+		if component.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clearComponent drops the manager of a component
+func (b *Bootstrap) clearComponent(name string) {
+	switch name {
+	case "config":
+		b.configManager = nil
+	case "logging":
+		b.loggingManager = nil
+	case "monitoring":
+		b.monitoringManager = nil
+	case "database":
+		b.databaseManager = nil
+		b.migrationManager = nil
+	case "auth":
+		b.authManager = nil
+	case "middleware":
+		b.middlewareManager = nil
+	case "communication":
+		b.communicationManager = nil
+	case "api":
+		b.apiManager = nil
+	case "ai":
+		b.aiManager = nil
+	case "storage":
+		b.storageManager = nil
+	case "messaging":
+		b.messagingManager = nil
+	case "scheduling":
+		b.schedulingManager = nil
+	case "backup":
+		b.backupManager = nil
+	case "chaos":
+		b.chaosManager = nil
+	case "failover":
+		b.failoverManager = nil
+	case "event":
+		b.eventManager = nil
+	case "discovery":
+		b.discoveryManager = nil
+	case "cache":
+		b.cacheManager = nil
+	case "ratelimit":
+		b.rateLimitManager = nil
+	case "circuitbreaker":
+		b.circuitBreakerManager = nil
+	case "filegen":
+		b.filegenManager = nil
+	case "payment":
+		b.paymentManager = nil
+	case "email":
+		b.emailManager = nil
+	case "featureflags":
+		b.featureFlagManager = nil
+	case "leaderelection":
+		b.leaderElector = nil
+	}
+}
+
+// adoptComponent takes the manager of a component from another Bootstrap, which built it
+func (b *Bootstrap) adoptComponent(from *Bootstrap, name string) {
+	switch name {
+	case "config":
+		b.configManager = from.configManager
+	case "logging":
+		b.loggingManager = from.loggingManager
+	case "monitoring":
+		b.monitoringManager = from.monitoringManager
+	case "database":
+		b.databaseManager = from.databaseManager
+		b.migrationManager = from.migrationManager
+	case "auth":
+		b.authManager = from.authManager
+	case "middleware":
+		b.middlewareManager = from.middlewareManager
+	case "communication":
+		b.communicationManager = from.communicationManager
+	case "api":
+		b.apiManager = from.apiManager
+	case "ai":
+		b.aiManager = from.aiManager
+	case "storage":
+		b.storageManager = from.storageManager
+	case "messaging":
+		b.messagingManager = from.messagingManager
+	case "scheduling":
+		b.schedulingManager = from.schedulingManager
+	case "backup":
+		b.backupManager = from.backupManager
+	case "chaos":
+		b.chaosManager = from.chaosManager
+	case "failover":
+		b.failoverManager = from.failoverManager
+	case "event":
+		b.eventManager = from.eventManager
+	case "discovery":
+		b.discoveryManager = from.discoveryManager
+	case "cache":
+		b.cacheManager = from.cacheManager
+	case "ratelimit":
+		b.rateLimitManager = from.rateLimitManager
+	case "circuitbreaker":
+		b.circuitBreakerManager = from.circuitBreakerManager
+	case "filegen":
+		b.filegenManager = from.filegenManager
+	case "payment":
+		b.paymentManager = from.paymentManager
+	case "email":
+		b.emailManager = from.emailManager
+	case "featureflags":
+		b.featureFlagManager = from.featureFlagManager
+		b.openFeatureProvider = from.openFeatureProvider
+	case "leaderelection":
+		b.leaderElector = from.leaderElector
+	}
+}
+
+// deleteComponentState forgets the state of a component, and of the migrations with the
+// database, including a startup timeout
+func (b *Bootstrap) deleteComponentState(name string) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	delete(b.states, name)
+	delete(b.startupTimeouts, name)
+	if name == "database" {
+		delete(b.states, "migration")
+	}
+}
+
+// recordReload logs a reload, counts it in the framework metrics and keeps it for Reloads
+func (b *Bootstrap) recordReload(ctx context.Context, event ReloadEvent) {
+	b.stateMu.Lock()
+	b.reloads = append(b.reloads, event)
+	if len(b.reloads) > maxReloadEvents {
+		b.reloads = b.reloads[len(b.reloads)-maxReloadEvents:]
+	}
+	b.stateMu.Unlock()
+
+	entry := b.logger.WithFields(logrus.Fields{
+		"changed":   event.Changed,
+		"applied":   event.Applied,
+		"restarted": event.Restarted,
+		"stopped":   event.Stopped,
+	})
+	result := "success"
+	switch {
+	case event.Error != "":
+		result = "failure"
+		entry.WithField("error", event.Error).Error("Configuration reload failed")
+	case len(event.Changed) == 0:
+		entry.Info("Configuration reloaded without changes")
+	default:
+		entry.Info("Configuration reloaded")
+	}
+
+	b.countReload(result)
+	b.submitMetrics(ctx)
+}
+
+// Reloads returns the most recent configuration reloads, oldest first
+func (b *Bootstrap) Reloads() []ReloadEvent {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+
+	return append([]ReloadEvent(nil), b.reloads...)
+}
+
+// Level returns the log level of the default logging provider, or of the console provider
+func (c LoggingConfig) Level() string {
+	_, level := c.levelSetting()
+	return level
+}
+
+// levelSetting returns the log level with the field it is set in
+func (c LoggingConfig) levelSetting() (string, string) {
+	provider := defaultProvider(c.Providers)
+	if provider == "" {
+		provider = "console"
+	}
+	settings, _ := c.Providers[provider].(map[string]interface{})
+	level, _ := settings["level"].(string)
+	return "logging.providers." + provider + ".level", level
+}
+
+// applyLogLevel sets the level of the framework logger from the logging configuration
+func (b *Bootstrap) applyLogLevel() error {
+	field, level := b.config.Logging.levelSetting()
+	if level == "" {
+		return nil
+	}
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return FieldError{Field: field, Message: err.Error()}
+	}
+	b.logger.SetLevel(parsed)
+	return nil
+}
+
+// RateLimit returns the rate limit configured under optional.ratelimit.limits.<name>. Limits
+// are read from the current configuration, so reloads change them without restarting the
+// rate limit manager.
+func (b *Bootstrap) RateLimit(name string) (*ratelimittypes.RateLimit, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	limits, err := rateLimits(b.config.Optional.RateLimit)
+	if err != nil {
+		return nil, false
+	}
+	limit, ok := limits[name]
+	return limit, ok
+}
+
+// rateLimits reads the limits of the rate limit section; algorithm defaults to token_bucket
+func rateLimits(section map[string]interface{}) (map[string]*ratelimittypes.RateLimit, error) {
+	entries, _ := section["limits"].(map[string]interface{})
+	limits := make(map[string]*ratelimittypes.RateLimit, len(entries))
+	for name, entry := range entries {
+		field := "optional.ratelimit.limits." + name
+		settings, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, FieldError{Field: field, Message: "expected a mapping"}
+		}
+		limit := &ratelimittypes.RateLimit{Algorithm: ratelimittypes.AlgorithmTokenBucket}
+		if _, err := managerConfig(limit, field, settings, nil); err != nil {
+			return nil, err
+		}
+		if limit.Limit <= 0 || limit.Window <= 0 {
+			return nil, FieldError{Field: field, Message: "limit and window must be positive"}
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// withoutKey returns a copy of a section without one of its keys
+func withoutKey(section map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(section))
+	for k, v := range section {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartupReport summarizes a start of the framework: what runs, with which providers, where
+// it listens and how long each part took. It answers why a feature is not active: the
+// component is missing from Components and listed in Disabled.
+type StartupReport struct {
+	Service           string            `json:"service"`
+	Version           string            `json:"version,omitempty"`
+	Environment       string            `json:"environment,omitempty"`
+	StartedAt         time.Time         `json:"started_at"`
+	StartupSeconds    float64           `json:"startup_seconds"`
+	Listen            []string          `json:"listen,omitempty"`
+	MigrationsApplied int               `json:"migrations_applied"`
+	Components        []ComponentReport `json:"components"`
+	Disabled          []string          `json:"disabled,omitempty"`
+}
+
+// ComponentReport is the part of a StartupReport about one component
+type ComponentReport struct {
+	Name            string         `json:"name"`
+	State           LifecycleState `json:"state"`
+	Providers       []string       `json:"providers,omitempty"`
+	DefaultProvider string         `json:"default_provider,omitempty"`
+	InitSeconds     float64        `json:"init_seconds"`
+	StartSeconds    float64        `json:"start_seconds"`
+}
+
+// StartupReport returns the report of the last Start, or nil before Start has succeeded
+func (b *Bootstrap) StartupReport() *StartupReport {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.report
+}
+
+// buildStartupReport summarizes the components as they are now, with the configuration and
+// the migrations count a reload may replace meanwhile read under b.mu
+func (b *Bootstrap) buildStartupReport() *StartupReport {
+	b.mu.RLock()
+	config, migrationsApplied := b.config, b.migrationsApplied
+	b.mu.RUnlock()
+
+	report := &StartupReport{
+		Service:           config.Service.Name,
+		Version:           config.Service.Version,
+		Environment:       config.Service.Environment,
+		StartedAt:         time.Now(),
+		Listen:            b.listenAddresses(config),
+		MigrationsApplied: migrationsApplied,
+	}
+	if !b.initStarted.IsZero() {
+		report.StartupSeconds = time.Since(b.initStarted).Seconds()
+	}
+
+	states := b.ComponentStates()
+	running := make(map[string]bool)
+	components := b.snapshotComponents()
+	b.stateMu.RLock()
+	for _, component := range components {
+		running[component.name] = true
+		providers, settings := config.sectionProviders(component.name)
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		defaultName, _ := settings["default_provider"].(string)
+		if defaultName == "" {
+			defaultName = defaultProvider(providers)
+		}
+		timings := b.timings[component.name]
+		report.Components = append(report.Components, ComponentReport{
+			Name:            component.name,
+			State:           states[component.name].State,
+			Providers:       names,
+			DefaultProvider: defaultName,
+			InitSeconds:     timings.Init.Seconds(),
+			StartSeconds:    timings.Start.Seconds(),
+		})
+	}
+	b.stateMu.RUnlock()
+
+	for _, name := range append(coreComponents, optionalComponents...) {
+		if !running[name] {
+			report.Disabled = append(report.Disabled, name)
+		}
+	}
+	return report
+}
+
+// listenAddresses returns the addresses the HTTP server of WithHTTPServer, the server and
+// the service are configured on
+func (b *Bootstrap) listenAddresses(config *FrameworkConfig) []string {
+	var addresses []string
+	if b.httpServer != nil {
+		addresses = append(addresses, b.httpServer.Addr)
+	}
+	if config.Server.Port > 0 {
+		addresses = append(addresses, net.JoinHostPort(config.Server.Host, strconv.Itoa(config.Server.Port)))
+	}
+	if config.Service.Port > 0 && config.Service.Port != config.Server.Port {
+		addresses = append(addresses, net.JoinHostPort("", strconv.Itoa(config.Service.Port)))
+	}
+	return addresses
+}
+
+// sectionProviders returns the providers and manager settings of a component's section
+func (c *FrameworkConfig) sectionProviders(name string) (map[string]interface{}, map[string]interface{}) {
+	switch name {
+	case "logging":
+		return c.Logging.Providers, c.Logging.Settings
+	case "monitoring":
+		return c.Monitoring.Providers, c.Monitoring.Settings
+	case "database":
+		if c.Database != nil {
+			return c.Database.Providers, c.Database.Settings
+		}
+	case "auth":
+		if c.Auth != nil {
+			return c.Auth.Providers, c.Auth.Settings
+		}
+	case "messaging":
+		if c.Messaging != nil {
+			return c.Messaging.Providers, c.Messaging.Settings
+		}
+	}
+	for _, section := range configSections {
+		if section.name != "optional."+name {
+			continue
+		}
+		values, _ := section.value(c).(map[string]interface{})
+		providers, _ := values["providers"].(map[string]interface{})
+		return providers, values
+	}
+	return nil, nil
+}
+
+// logStartupReport builds the startup report, keeps it for StartupReport and logs it
+func (b *Bootstrap) logStartupReport() {
+	report := b.buildStartupReport()
+	b.stateMu.Lock()
+	b.report = report
+	b.stateMu.Unlock()
+
+	for _, component := range report.Components {
+		b.logger.WithFields(logrus.Fields{
+			"state":            component.State,
+			"providers":        component.Providers,
+			"default_provider": component.DefaultProvider,
+			"init":             time.Duration(component.InitSeconds * float64(time.Second)).Round(time.Microsecond),
+			"start":            time.Duration(component.StartSeconds * float64(time.Second)).Round(time.Microsecond),
+		}).Infof("Component %s", component.Name)
+	}
+	b.logger.WithFields(logrus.Fields{
+		"service":            report.Service,
+		"version":            report.Version,
+		"environment":        report.Environment,
+		"listen":             report.Listen,
+		"migrations_applied": report.MigrationsApplied,
+		"disabled":           report.Disabled,
+		"startup":            fmt.Sprintf("%.3fs", report.StartupSeconds),
+	}).Info("Startup report")
+}
+
+// StartupReportHandler serves the startup report as JSON, or 503 before Start has succeeded
+func (b *Bootstrap) StartupReportHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := b.StartupReport()
+		w.Header().Set("Content-Type", "application/json")
+		if report == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": "the framework has not started"})
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Default startup retry policy
+const (
+	DefaultRetryMaxAttempts    = 5
+	DefaultRetryInitialBackoff = time.Second
+	DefaultRetryMaxBackoff     = 30 * time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.2
+)
+
+// withDefaults fills in the unset values of a retry policy
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if r.FailFast {
+		r.MaxAttempts = 1
+	}
+	if r.InitialBackoff <= 0 {
+		r.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if r.Multiplier <= 0 {
+		r.Multiplier = DefaultRetryMultiplier
+	}
+	if r.Jitter == nil {
+		jitter := DefaultRetryJitter
+		r.Jitter = &jitter
+	}
+	return r
+}
+
+// backoff returns the wait after the given failed attempt, counting from 1
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	wait := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-1))
+	wait *= 1 + *r.Jitter*(2*rand.Float64()-1)
+	if wait > float64(r.MaxBackoff) {
+		wait = float64(r.MaxBackoff)
+	}
+	return time.Duration(wait)
+}
+
+// connectWithRetry runs connect until it succeeds, following the startup retry policy, so a
+// service started before its dependencies waits for them instead of exiting. target names the
+// connection in logs.
+func (b *Bootstrap) connectWithRetry(ctx context.Context, target string, connect func(ctx context.Context) error) error {
+	policy := b.config.Startup.Retry.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				b.logger.WithField("attempts", attempt).Infof("Connected to %s", target)
+			}
+			return nil
+		}
+		if policy.MaxAttempts == 1 {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := policy.backoff(attempt)
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"attempt":      attempt,
+			"max_attempts": policy.MaxAttempts,
+		}).Warnf("Failed to connect to %s, retrying in %s", target, wait.Round(time.Millisecond))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/secrets"
+)
+
+// DefaultSecretsTimeout bounds the resolution of the secret references of a configuration
+const DefaultSecretsTimeout = 30 * time.Second
+
+// secretBackends are the built-in secret backends
+var secretBackends = []string{"vault", "ssm", "gsm"}
+
+// RegisterSecretProvider adds a secret backend, resolving the references with its scheme. It
+// replaces the built-in backend of the same scheme, and must be called before Initialize.
+func (b *Bootstrap) RegisterSecretProvider(provider SecretProvider) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.initialized {
+		return fmt.Errorf("secret provider %s registered after Initialize", provider.Scheme())
+	}
+	b.secretProviders = append(b.secretProviders, provider)
+	return nil
+}
+
+// newSecretResolver creates the resolver of the secrets section, with the built-in backends
+// and then the registered ones:
+//
+//	secrets:
+//	  timeout: 30s
+//	  providers:
+//	    vault: {address: https://vault:8200, role: orders}   # or token; default VAULT_ADDR, VAULT_TOKEN
+//	    ssm: {region: eu-west-1}                             # default AWS_REGION
+//	    gsm: {project: my-project}                           # default GOOGLE_CLOUD_PROJECT
+func newSecretResolver(config SecretsConfig, registered []SecretProvider) (*secrets.Resolver, error) {
+	for name := range config.Providers {
+		if !contains(secretBackends, name) {
+			return nil, FieldError{Field: "secrets.providers." + name, Message: "unknown provider (vault, ssm or gsm)"}
+		}
+	}
+
+	resolver := secrets.NewResolver()
+	for _, name := range secretBackends {
+		settings, _ := config.Providers[name].(map[string]interface{})
+		field := "secrets.providers." + name
+
+		switch name {
+		case "vault":
+			var options struct {
+				Address   string `json:"address"`
+				Token     string `json:"token"`
+				Namespace string `json:"namespace"`
+				Role      string `json:"role"`
+				AuthPath  string `json:"auth_path"`
+				KVVersion int    `json:"kv_version"`
+			}
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			resolver.Register(secrets.NewVaultProvider(secrets.VaultOptions(options)))
+
+		case "ssm":
+			var options struct {
+				Region   string `json:"region"`
+				Endpoint string `json:"endpoint"`
+			}
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			resolver.Register(secrets.NewSSMProvider(secrets.SSMOptions(options)))
+
+		case "gsm":
+			var options struct {
+				Project         string `json:"project"`
+				CredentialsFile string `json:"credentials_file"`
+			}
+			if _, err := managerConfig(&options, field, settings, nil); err != nil {
+				return nil, err
+			}
+			resolver.Register(secrets.NewGSMProvider(secrets.GSMOptions(options)))
+		}
+	}
+	for _, provider := range registered {
+		resolver.Register(provider)
+	}
+	return resolver, nil
+}
+
+// resolveSecrets replaces the secret references in the values of config with the secrets
+// they reference. The secrets section itself is left as it is, and a reference read twice is
+// fetched once.
+func (b *Bootstrap) resolveSecrets(ctx context.Context, config *FrameworkConfig) error {
+	if config == nil {
+		return nil
+	}
+	b.mu.RLock()
+	registered := append([]SecretProvider(nil), b.secretProviders...)
+	b.mu.RUnlock()
+
+	resolver, err := newSecretResolver(config.Secrets, registered)
+	if err != nil {
+		return err
+	}
+	timeout := config.Secrets.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSecretsTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	walker := &secretWalker{ctx: ctx, resolver: resolver, cache: make(map[string]string)}
+	if _, _, err := walker.walk(reflect.ValueOf(config).Elem(), ""); err != nil {
+		return err
+	}
+	if len(walker.cache) > 0 {
+		b.logger.WithField("references", len(walker.cache)).Info("Resolved the secret references of the configuration")
+	}
+	return nil
+}
+
+// secretWalker resolves the secret references in the strings of a configuration
+type secretWalker struct {
+	ctx      context.Context
+	resolver *secrets.Resolver
+	cache    map[string]string
+}
+
+// walk resolves the references under value. A string that is a reference is not set in
+// place, since map values cannot be; its replacement is returned for the caller to set.
+func (w *secretWalker) walk(value reflect.Value, field string) (reflect.Value, bool, error) {
+	switch value.Kind() {
+	case reflect.String:
+		reference := value.String()
+		if !w.resolver.IsReference(reference) {
+			return value, false, nil
+		}
+		secret, ok := w.cache[reference]
+		if !ok {
+			var err error
+			if secret, err = w.resolver.Resolve(w.ctx, reference); err != nil {
+				return value, false, FieldError{Field: field, Message: err.Error()}
+			}
+			w.cache[reference] = secret
+		}
+		return reflect.ValueOf(secret).Convert(value.Type()), true, nil
+
+	case reflect.Interface, reflect.Pointer:
+		if value.IsNil() {
+			return value, false, nil
+		}
+		replacement, replaced, err := w.walk(value.Elem(), field)
+		if replaced && value.Kind() == reflect.Pointer && value.Elem().CanSet() {
+			value.Elem().Set(replacement)
+			replaced = false
+		}
+		return replacement, replaced, err
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			structField := value.Type().Field(i)
+			name, options, _ := strings.Cut(structField.Tag.Get("yaml"), ",")
+			if !structField.IsExported() || name == "-" || structField.Type == reflect.TypeOf(SecretsConfig{}) {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(structField.Name)
+			}
+			path := joinField(field, name)
+			if options == "inline" {
+				path = field
+			}
+			if err := w.set(value.Field(i), path); err != nil {
+				return value, false, err
+			}
+		}
+
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			replacement, replaced, err := w.walk(value.MapIndex(key), joinField(field, fmt.Sprint(key.Interface())))
+			if err != nil {
+				return value, false, err
+			}
+			if replaced {
+				value.SetMapIndex(key, replacement)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := w.set(value.Index(i), fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return value, false, err
+			}
+		}
+	}
+	return value, false, nil
+}
+
+// set resolves the references under an addressable value, setting it when it is one
+func (w *secretWalker) set(value reflect.Value, field string) error {
+	replacement, replaced, err := w.walk(value, field)
+	if err != nil {
+		return err
+	}
+	if replaced && value.CanSet() {
+		value.Set(replacement)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/anasamu/go-micro-framework/pkg/secrets"
+	"github.com/sirupsen/logrus"
+)
+
+// countingProvider resolves test://<name> to the value of name, counting the lookups
+type countingProvider struct {
+	values  map[string]string
+	lookups map[string]int
+}
+
+func (p *countingProvider) Scheme() string { return "test" }
+
+func (p *countingProvider) Resolve(ctx context.Context, reference secrets.Reference) (string, error) {
+	p.lookups[reference.Path]++
+	value, ok := p.values[reference.Path]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *FrameworkConfig
+		// check inspects the resolved configuration
+		check func(t *testing.T, config *FrameworkConfig)
+		// wantErr is the field of the error, "" when the references resolve
+		wantErr string
+	}{
+		{
+			name: "struct and map values",
+			config: &FrameworkConfig{
+				Service: ServiceConfig{Name: "test://name"},
+				Database: &DatabaseConfig{Providers: map[string]interface{}{
+					"postgresql": map[string]interface{}{"password": "test://password", "host": "localhost"},
+				}},
+			},
+			check: func(t *testing.T, config *FrameworkConfig) {
+				if config.Service.Name != "orders" {
+					t.Errorf("service.name = %q, want orders", config.Service.Name)
+				}
+				postgres := config.Database.Providers["postgresql"].(map[string]interface{})
+				if postgres["password"] != "s3cret" || postgres["host"] != "localhost" {
+					t.Errorf("database.providers.postgresql = %v", postgres)
+				}
+			},
+		},
+		{
+			name: "lists",
+			config: &FrameworkConfig{Optional: OptionalConfig{Cache: map[string]interface{}{
+				"nodes": []interface{}{"test://password", "plain"},
+			}}},
+			check: func(t *testing.T, config *FrameworkConfig) {
+				nodes := config.Optional.Cache["nodes"].([]interface{})
+				if nodes[0] != "s3cret" || nodes[1] != "plain" {
+					t.Errorf("optional.cache.nodes = %v", nodes)
+				}
+			},
+		},
+		{
+			name:    "missing secret",
+			config:  &FrameworkConfig{Service: ServiceConfig{Name: "test://missing"}},
+			wantErr: "service.name",
+		},
+		{
+			name:    "unknown backend",
+			config:  &FrameworkConfig{Secrets: SecretsConfig{Providers: map[string]interface{}{"keychain": nil}}},
+			wantErr: "secrets.providers.keychain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			b := NewBootstrap(tt.config, logger)
+			provider := &countingProvider{values: map[string]string{"name": "orders", "password": "s3cret"}, lookups: make(map[string]int)}
+			if err := b.RegisterSecretProvider(provider); err != nil {
+				t.Fatal(err)
+			}
+
+			err := b.resolveSecrets(context.Background(), tt.config)
+			if tt.wantErr != "" {
+				var fieldErr FieldError
+				if !errors.As(err, &fieldErr) || !strings.HasPrefix(fieldErr.Field, tt.wantErr) {
+					t.Fatalf("resolveSecrets() error = %v, want an error of %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveSecrets() error = %v", err)
+			}
+			tt.check(t, tt.config)
+			for name, lookups := range provider.lookups {
+				if lookups > 1 {
+					t.Errorf("%s was looked up %d times, want once", name, lookups)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterSecretProviderAfterInitialize(t *testing.T) {
+	b := startBootstrap(t, testConfig("FEATURE_"))
+	if err := b.RegisterSecretProvider(&countingProvider{}); err == nil {
+		t.Errorf("RegisterSecretProvider() after Initialize succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultComponentStartupTimeout bounds the initialization and the start of each component
+const DefaultComponentStartupTimeout = 60 * time.Second
+
+// componentStartupTimeout returns the startup timeout of a component
+func (b *Bootstrap) componentStartupTimeout(name string) time.Duration {
+	if b.config != nil {
+		if timeout := b.config.Startup.Components[name]; timeout > 0 {
+			return timeout
+		}
+		if b.config.Startup.ComponentTimeout > 0 {
+			return b.config.Startup.ComponentTimeout
+		}
+	}
+	return DefaultComponentStartupTimeout
+}
+
+// critical reports whether the startup fails when a component exceeds its startup timeout
+func (b *Bootstrap) critical(name string) bool {
+	return b.config == nil || !contains(b.config.Startup.NonCritical, name)
+}
+
+// runStartupStep runs the init or start step of a component with a context cancelled when
+// the component exceeds its startup timeout. A critical component that exceeds it fails the
+// startup; any other component is left degraded, its step keeps running in the background
+// with the cancelled context, and the startup goes on without it.
+func (b *Bootstrap) runStartupStep(ctx context.Context, name, step string, run func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("startup cancelled before the %s of %s: %w", step, name, err)
+	}
+	if b.timedOut(name) {
+		return nil
+	}
+
+	timeout := b.componentStartupTimeout(name)
+	stepCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(timeout, func() {
+		cancel(fmt.Errorf("%s of %s exceeded its startup timeout of %s", step, name, timeout))
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.timeComponent(name, step, func() error { return run(stepCtx) })
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+		timer.Stop()
+		if err == nil || context.Cause(stepCtx) == nil || ctx.Err() != nil {
+			// The context of a successful step stays alive for the work it started
+			return err
+		}
+	case <-stepCtx.Done():
+		if ctx.Err() != nil {
+			return fmt.Errorf("startup cancelled during the %s of %s: %w", step, name, ctx.Err())
+		}
+	}
+
+	// The step exceeded its timeout
+	err = context.Cause(stepCtx)
+	if b.critical(name) {
+		return err
+	}
+	b.logger.WithError(err).Warnf("Continuing the startup without %s, which is not critical", name)
+	b.stateMu.Lock()
+	if b.startupTimeouts == nil {
+		b.startupTimeouts = make(map[string]error)
+	}
+	b.startupTimeouts[name] = err
+	b.stateMu.Unlock()
+	return nil
+}
+
+// timedOut reports whether a non-critical component exceeded its startup timeout
+func (b *Bootstrap) timedOut(name string) bool {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	_, ok := b.startupTimeouts[name]
+	return ok
+}
+
+// markTimedOutComponents records the components that exceeded their startup timeout as
+// degraded, until their health check passes
+func (b *Bootstrap) markTimedOutComponents() {
+	for _, component := range b.snapshotComponents() {
+		b.stateMu.RLock()
+		err, ok := b.startupTimeouts[component.name]
+		b.stateMu.RUnlock()
+		if ok {
+			b.setComponentState(component.name, StateDegraded, err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Default watchdog settings
+const (
+	DefaultWatchdogInterval         = 15 * time.Second
+	DefaultWatchdogCheckTimeout     = 5 * time.Second
+	DefaultWatchdogFailureThreshold = 3
+	DefaultWatchdogMaxRestarts      = 5
+	DefaultWatchdogRestartBackoff   = 30 * time.Second
+	maxWatchdogRestartBackoff       = 10 * time.Minute
+)
+
+// WatchdogConfig holds the supervision of the components after startup
+type WatchdogConfig struct {
+	// Enabled runs the health checks periodically and restarts the failing components
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often the health checks run (default 15s)
+	Interval time.Duration `yaml:"interval"`
+	// CheckTimeout bounds each round of health checks (default 5s)
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+	// FailureThreshold is the number of failed checks in a row before a component is
+	// restarted (default 3)
+	FailureThreshold int `yaml:"failure_threshold"`
+	// MaxRestarts bounds the restarts of a component until it is healthy again (default 5);
+	// after that it is left degraded
+	MaxRestarts int `yaml:"max_restarts"`
+	// RestartBackoff is the wait after a restart before the next one, doubled after each
+	// restart that does not make the component healthy (default 30s)
+	RestartBackoff time.Duration `yaml:"restart_backoff"`
+	// Exclude lists the components that are checked but never restarted
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+func (c WatchdogConfig) withDefaults() WatchdogConfig {
+	if c.Interval <= 0 {
+		c.Interval = DefaultWatchdogInterval
+	}
+	if c.CheckTimeout <= 0 {
+		c.CheckTimeout = DefaultWatchdogCheckTimeout
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultWatchdogFailureThreshold
+	}
+	if c.MaxRestarts <= 0 {
+		c.MaxRestarts = DefaultWatchdogMaxRestarts
+	}
+	if c.RestartBackoff <= 0 {
+		c.RestartBackoff = DefaultWatchdogRestartBackoff
+	}
+	return c
+}
+
+// supervision is what the watchdog knows of a failing component
+type supervision struct {
+	failures    int
+	restarts    int
+	nextRestart time.Time
+	gaveUp      bool
+}
+
+// superviseComponents runs the health checks every watchdog interval until ctx is done, and
+// restarts the components that keep failing them. The watchdog section is read again before
+// each round, so reloads enable, disable and tune it.
+func (b *Bootstrap) superviseComponents(ctx context.Context) {
+	config := b.watchdogConfig()
+	if config.Enabled {
+		b.logger.WithFields(logrus.Fields{
+			"interval":          config.Interval,
+			"failure_threshold": config.FailureThreshold,
+			"max_restarts":      config.MaxRestarts,
+		}).Info("Component watchdog started")
+	}
+
+	supervised := make(map[string]*supervision)
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if b.State() == StateStopping {
+			return
+		}
+
+		current := b.watchdogConfig()
+		if current.Interval != config.Interval {
+			ticker.Reset(current.Interval)
+		}
+		config = current
+		if !config.Enabled {
+			clear(supervised)
+			continue
+		}
+		b.superviseOnce(ctx, config, supervised)
+	}
+}
+
+// watchdogConfig returns the current watchdog settings
+func (b *Bootstrap) watchdogConfig() WatchdogConfig {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.config.Watchdog.withDefaults()
+}
+
+// superviseOnce runs one round of health checks and restarts the components that failed
+// too many in a row
+func (b *Bootstrap) superviseOnce(ctx context.Context, config WatchdogConfig, supervised map[string]*supervision) {
+	checkCtx, cancel := context.WithTimeout(ctx, config.CheckTimeout)
+	states := b.HealthCheck(checkCtx)
+	cancel()
+
+	for name, health := range states {
+		current := supervised[name]
+		if health.State != StateDegraded {
+			if current != nil && current.restarts > 0 {
+				b.logger.WithField("restarts", current.restarts).Infof("Component %s recovered", name)
+			}
+			delete(supervised, name)
+			continue
+		}
+		if current == nil {
+			current = &supervision{}
+			supervised[name] = current
+		}
+		current.failures++
+
+		switch {
+		case current.failures < config.FailureThreshold, time.Now().Before(current.nextRestart):
+		case !b.restartable(name, config):
+		case current.restarts >= config.MaxRestarts:
+			if !current.gaveUp {
+				current.gaveUp = true
+				b.logger.WithField("restarts", current.restarts).Errorf("Component %s is still failing, leaving it degraded", name)
+			}
+		default:
+			current.restarts++
+			backoff := config.RestartBackoff << (current.restarts - 1)
+			if backoff <= 0 || backoff > maxWatchdogRestartBackoff {
+				backoff = maxWatchdogRestartBackoff
+			}
+			current.nextRestart = time.Now().Add(backoff)
+
+			entry := b.logger.WithFields(logrus.Fields{
+				"failures": current.failures,
+				"attempt":  current.restarts,
+				"error":    health.Error,
+			})
+			entry.Warnf("Component %s keeps failing its health check, restarting it", name)
+			if err := b.recoverComponent(ctx, name); err != nil {
+				b.countRecovery(name, "failure")
+				entry.WithError(err).Errorf("Failed to restart %s", name)
+				continue
+			}
+			b.countRecovery(name, "success")
+			current.failures = 0
+		}
+	}
+	b.submitMetrics(ctx)
+}
+
+// restartable reports whether the watchdog may restart a component: not the communication
+// server, which serves the requests, nor the migrations, which follow the database, nor the
+// excluded ones
+func (b *Bootstrap) restartable(name string, config WatchdogConfig) bool {
+	return name != "communication" && name != "migration" && !contains(config.Exclude, name)
+}
+
+// recoverComponent stops a component and starts it again: a built-in manager is rebuilt from
+// the configuration, a registered component is stopped, initialized and started. It runs
+// one at a time with configuration reloads.
+func (b *Bootstrap) recoverComponent(ctx context.Context, name string) error {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	if b.State() == StateStopping {
+		return fmt.Errorf("the framework is stopping")
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.componentStartupTimeout(name))
+	defer cancel()
+
+	var err error
+	if registered, ok := b.GetComponent(name); ok {
+		err = b.restartRegistered(ctx, registered)
+	} else {
+		err = b.restartManager(ctx, name)
+	}
+	if err != nil {
+		b.setComponentState(name, StateDegraded, err)
+		return err
+	}
+
+	b.countRestart(name)
+	b.setComponentState(name, StateReady, nil)
+	b.mu.RLock()
+	migrations := name == "database" && b.migrationManager != nil
+	b.mu.RUnlock()
+	if migrations {
+		b.setComponentState("migration", StateReady, nil)
+	}
+	return nil
+}
+
+// restartRegistered stops, initializes and starts a registered component. It runs without
+// b.mu, so that the component can get the managers while it starts.
+func (b *Bootstrap) restartRegistered(ctx context.Context, registered Component) error {
+	if err := b.stopComponent(ctx, component{name: registered.Name(), stop: registered.Stop}); err != nil {
+		b.logger.WithError(err).Warnf("Failed to stop %s for the restart", registered.Name())
+	}
+	b.deleteComponentState(registered.Name())
+	if err := registered.Init(ctx); err != nil {
+		return err
+	}
+	return registered.Start(ctx)
+}
+
+// restartManager rebuilds a built-in manager from the configuration. The new manager is
+// built and connected on a scratch Bootstrap, without b.mu, so that the getters and the
+// metrics keep answering while it retries its connections; b.mu is only held to swap it in.
+func (b *Bootstrap) restartManager(ctx context.Context, name string) error {
+	b.mu.RLock()
+	var stop func(ctx context.Context) error
+	for _, component := range b.components() {
+		if component.name == name {
+			stop = component.stop
+		}
+	}
+	fresh := &Bootstrap{config: b.config, logger: b.logger, leaderElector: b.leaderElector}
+	b.mu.RUnlock()
+
+	if stop != nil {
+		if err := b.stopComponent(ctx, component{name: name, stop: stop}); err != nil {
+			b.logger.WithError(err).Warnf("Failed to stop %s for the restart", name)
+		}
+	}
+	b.deleteComponentState(name)
+	if err := fresh.initializeComponent(ctx, name); err != nil {
+		return err
+	}
+	if err := fresh.startComponent(ctx, name); err != nil {
+		for _, component := range fresh.components() {
+			if component.name == name && component.stop != nil {
+				component.stop(context.WithoutCancel(ctx))
+			}
+		}
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.adoptComponent(fresh, name)
+	if name == "leaderelection" && b.schedulingManager != nil {
+		b.gateScheduling()
+	}
+	return nil
+}
+
+// countRecovery records the result of a restart by the watchdog
+func (b *Bootstrap) countRecovery(name, result string) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if b.recoveries == nil {
+		b.recoveries = make(map[string]map[string]int)
+	}
+	if b.recoveries[name] == nil {
+		b.recoveries[name] = make(map[string]int)
+	}
+	b.recoveries[name][result]++
+}
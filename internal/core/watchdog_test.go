@@ -0,0 +1,167 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeComponent is a registered component that fails its health check while failed is set;
+// restarting it clears failed when heals is set
+type fakeComponent struct {
+	name   string
+	heals  bool
+	starts atomic.Int32
+	failed atomic.Bool
+}
+
+func (c *fakeComponent) Name() string                   { return c.name }
+func (c *fakeComponent) Init(ctx context.Context) error { return nil }
+func (c *fakeComponent) Stop(ctx context.Context) error { return nil }
+
+func (c *fakeComponent) Start(ctx context.Context) error {
+	if c.starts.Add(1) > 1 && c.heals {
+		c.failed.Store(false)
+	}
+	return nil
+}
+
+func (c *fakeComponent) Health(ctx context.Context) error {
+	if c.failed.Load() {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func TestWatchdogConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config WatchdogConfig
+		want   WatchdogConfig
+	}{
+		{
+			name:   "unset",
+			config: WatchdogConfig{Enabled: true},
+			want: WatchdogConfig{
+				Enabled: true, Interval: DefaultWatchdogInterval, CheckTimeout: DefaultWatchdogCheckTimeout,
+				FailureThreshold: DefaultWatchdogFailureThreshold, MaxRestarts: DefaultWatchdogMaxRestarts,
+				RestartBackoff: DefaultWatchdogRestartBackoff,
+			},
+		},
+		{
+			name:   "set",
+			config: WatchdogConfig{Interval: time.Second, CheckTimeout: time.Second, FailureThreshold: 1, MaxRestarts: 1, RestartBackoff: time.Second},
+			want:   WatchdogConfig{Interval: time.Second, CheckTimeout: time.Second, FailureThreshold: 1, MaxRestarts: 1, RestartBackoff: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.withDefaults()
+			if got.Enabled != tt.want.Enabled || got.Interval != tt.want.Interval || got.CheckTimeout != tt.want.CheckTimeout ||
+				got.FailureThreshold != tt.want.FailureThreshold || got.MaxRestarts != tt.want.MaxRestarts || got.RestartBackoff != tt.want.RestartBackoff {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuperviseOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		component *fakeComponent
+		failing   bool
+		exclude   []string
+		// wantRestarts are the restarts after the rounds, wantState the state of the component
+		wantRestarts int32
+		wantState    LifecycleState
+	}{
+		{name: "healthy", component: &fakeComponent{name: "worker"}, wantState: StateReady},
+		{name: "recovers", component: &fakeComponent{name: "worker", heals: true}, failing: true, wantRestarts: 1, wantState: StateReady},
+		{name: "keeps failing", component: &fakeComponent{name: "worker"}, failing: true, wantRestarts: 2, wantState: StateDegraded},
+		{name: "excluded", component: &fakeComponent{name: "worker"}, failing: true, exclude: []string{"worker"}, wantState: StateDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			b := NewBootstrap(testConfig("FEATURE_"), logger)
+			if err := b.Register(tt.component); err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if err := initialize(ctx, b); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { b.Stop(context.Background()) })
+			tt.component.failed.Store(tt.failing)
+
+			config := WatchdogConfig{Enabled: true, FailureThreshold: 2, MaxRestarts: 2, RestartBackoff: time.Nanosecond, Exclude: tt.exclude}.withDefaults()
+			supervised := make(map[string]*supervision)
+			for round := 0; round < 8; round++ {
+				b.superviseOnce(ctx, config, supervised)
+			}
+
+			if restarts := tt.component.starts.Load() - 1; restarts != tt.wantRestarts {
+				t.Errorf("restarted %d times, want %d", restarts, tt.wantRestarts)
+			}
+			if state := b.ComponentStates()["worker"].State; state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+// TestRestartManagerDuringHealthCheck restarts a built-in manager, as the watchdog does, while
+// the health checks and the getters read the managers, for the race detector
+func TestRestartManagerDuringHealthCheck(t *testing.T) {
+	b := startBootstrap(t, testConfig("FEATURE_"))
+	ctx := context.Background()
+	before := b.GetFeatureFlagManager()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for _, read := range []func(){
+		func() { b.HealthCheck(ctx) },
+		func() { b.GetFeatureFlagManager() },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					read()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := b.recoverComponent(ctx, "featureflags"); err != nil {
+			t.Errorf("recoverComponent() error = %v", err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if b.GetFeatureFlagManager() == before {
+		t.Errorf("the feature flag manager was not replaced")
+	}
+	if state := b.ComponentStates()["featureflags"].State; state != StateReady {
+		t.Errorf("featureflags is %s after the restarts, want %s", state, StateReady)
+	}
+}
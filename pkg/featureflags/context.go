@@ -0,0 +1,55 @@
+package featureflags
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// evaluation is what a request context carries: the manager and who the request is for
+type evaluation struct {
+	manager *Manager
+	evalCtx EvaluationContext
+}
+
+// NewContext returns a context carrying the manager and the evaluation context of a request
+func NewContext(ctx context.Context, manager *Manager, evalCtx EvaluationContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, evaluation{manager: manager, evalCtx: evalCtx})
+}
+
+// FromContext returns the manager and evaluation context carried by ctx
+func FromContext(ctx context.Context) (*Manager, EvaluationContext, bool) {
+	carried, ok := ctx.Value(contextKey{}).(evaluation)
+	return carried.manager, carried.evalCtx, ok
+}
+
+// Enabled evaluates a boolean flag for the request of ctx; it is false without a manager
+func Enabled(ctx context.Context, flag string) bool {
+	manager, evalCtx, ok := FromContext(ctx)
+	if !ok || manager == nil {
+		return false
+	}
+	return manager.Boolean(ctx, flag, false, evalCtx)
+}
+
+// ContextFunc builds the evaluation context of a request, typically from the authenticated
+// user
+type ContextFunc func(r *http.Request) EvaluationContext
+
+// Middleware puts the manager and the evaluation context of each request in the request
+// context, for Enabled and FromContext in handlers. Without contextFunc, the targeting key is
+// the X-User-ID header.
+func Middleware(manager *Manager, contextFunc ContextFunc) func(http.Handler) http.Handler {
+	if contextFunc == nil {
+		contextFunc = func(r *http.Request) EvaluationContext {
+			return EvaluationContext{TargetingKey: r.Header.Get("X-User-ID")}
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewContext(r.Context(), manager, contextFunc(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
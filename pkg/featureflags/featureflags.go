@@ -0,0 +1,203 @@
+// Package featureflags evaluates feature flags from a chain of providers. Its vocabulary
+// follows OpenFeature: a flag is resolved for an evaluation context (a targeting key and
+// attributes) to a value, with the variant, the reason and, on failure, an error code.
+//
+//	manager := featureflags.NewManager(
+//		featureflags.NewEnvProvider("FEATURE_"),
+//		featureflags.NewFileProvider("configs/flags.yaml"),
+//	)
+//	if manager.Boolean(ctx, "new-checkout", false, featureflags.EvaluationContext{TargetingKey: userID}) {
+//		...
+//	}
+//
+// Providers are asked in order and the first one that knows the flag answers, so environment
+// variables can override the flags file.
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Reasons of a resolution
+const (
+	// ReasonStatic means the flag has one value for everybody
+	ReasonStatic = "STATIC"
+	// ReasonTargetingMatch means a targeting rule chose the value
+	ReasonTargetingMatch = "TARGETING_MATCH"
+	// ReasonSplit means a percentage rollout chose the value
+	ReasonSplit = "SPLIT"
+	// ReasonDefault means the flag has no value for the context and the default was used
+	ReasonDefault = "DEFAULT"
+	// ReasonError means the flag could not be resolved and the default was used
+	ReasonError = "ERROR"
+)
+
+// Error codes of a failed resolution
+const (
+	ErrorFlagNotFound = "FLAG_NOT_FOUND"
+	ErrorGeneral      = "GENERAL"
+)
+
+// ErrFlagNotFound is returned by a provider that does not know a flag
+var ErrFlagNotFound = errors.New("flag not found")
+
+// EvaluationContext is who or what a flag is evaluated for
+type EvaluationContext struct {
+	// TargetingKey identifies the subject, such as a user ID; percentage rollouts hash it
+	TargetingKey string
+	// Attributes are matched by targeting rules
+	Attributes map[string]interface{}
+}
+
+// Resolution is the result of evaluating a flag
+type Resolution struct {
+	Flag      string
+	Value     interface{}
+	Variant   string
+	Reason    string
+	ErrorCode string
+	Error     error
+}
+
+// Provider resolves flags from one source
+type Provider interface {
+	// Name identifies the provider in resolutions and logs
+	Name() string
+	// Resolve evaluates a flag, returning ErrFlagNotFound for flags it does not know
+	Resolve(ctx context.Context, flag string, evalCtx EvaluationContext) (Resolution, error)
+}
+
+// Starter is implemented by providers that load their flags before use
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Closer is implemented by providers that hold resources
+type Closer interface {
+	Close() error
+}
+
+// Manager evaluates flags with a chain of providers
+type Manager struct {
+	mu        sync.RWMutex
+	providers []Provider
+}
+
+// NewManager creates a manager asking the providers in order
+func NewManager(providers ...Provider) *Manager {
+	return &Manager{providers: providers}
+}
+
+// AddProvider appends a provider to the chain
+func (m *Manager) AddProvider(provider Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.providers = append(m.providers, provider)
+}
+
+// Providers returns the names of the providers, in order
+func (m *Manager) Providers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, len(m.providers))
+	for i, provider := range m.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// Start loads the flags of the providers that need it
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, provider := range m.providers {
+		if starter, ok := provider.(Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				return fmt.Errorf("feature flag provider %s: %w", provider.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close releases the resources of the providers
+func (m *Manager) Close() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var errs []error
+	for _, provider := range m.providers {
+		if closer, ok := provider.(Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("feature flag provider %s: %w", provider.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resolve evaluates a flag with the first provider that knows it. Without a value, the
+// resolution carries defaultValue with the reason DEFAULT or ERROR.
+func (m *Manager) Resolve(ctx context.Context, flag string, defaultValue interface{}, evalCtx EvaluationContext) Resolution {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, provider := range m.providers {
+		resolution, err := provider.Resolve(ctx, flag, evalCtx)
+		if errors.Is(err, ErrFlagNotFound) {
+			continue
+		}
+		if err != nil {
+			return Resolution{Flag: flag, Value: defaultValue, Reason: ReasonError, ErrorCode: ErrorGeneral, Error: err}
+		}
+		resolution.Flag = flag
+		if resolution.Value == nil {
+			resolution.Value = defaultValue
+			resolution.Reason = ReasonDefault
+		}
+		return resolution
+	}
+	return Resolution{Flag: flag, Value: defaultValue, Reason: ReasonError, ErrorCode: ErrorFlagNotFound, Error: ErrFlagNotFound}
+}
+
+// Boolean evaluates a boolean flag
+func (m *Manager) Boolean(ctx context.Context, flag string, defaultValue bool, evalCtx EvaluationContext) bool {
+	value, ok := m.Resolve(ctx, flag, defaultValue, evalCtx).Value.(bool)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// String evaluates a string flag
+func (m *Manager) String(ctx context.Context, flag string, defaultValue string, evalCtx EvaluationContext) string {
+	value, ok := m.Resolve(ctx, flag, defaultValue, evalCtx).Value.(string)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// Number evaluates a numeric flag
+func (m *Manager) Number(ctx context.Context, flag string, defaultValue float64, evalCtx EvaluationContext) float64 {
+	switch value := m.Resolve(ctx, flag, defaultValue, evalCtx).Value.(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	default:
+		return defaultValue
+	}
+}
+
+// Object evaluates a flag whose value is structured
+func (m *Manager) Object(ctx context.Context, flag string, defaultValue interface{}, evalCtx EvaluationContext) interface{} {
+	return m.Resolve(ctx, flag, defaultValue, evalCtx).Value
+}
@@ -0,0 +1,170 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// Flag is the definition of a flag, as written in a flags file or served by a flag service.
+// A flag without variants is static and has Value for everybody:
+//
+//	max-items: 50
+//	new-checkout:
+//	  variants: {on: true, off: false}
+//	  default: off
+//	  rules:
+//	    - attribute: country
+//	      in: [ID, SG]
+//	      variant: on
+//	    - rollout: {on: 20, off: 80}
+//
+// Rules are tried in order. A rule with an attribute applies to the contexts whose attribute
+// is one of In (the attribute "key" is the targeting key); a rule with a rollout splits the
+// targeting keys between variants by percentage.
+type Flag struct {
+	Value          interface{}            `json:"value,omitempty"`
+	Variants       map[string]interface{} `json:"variants,omitempty"`
+	DefaultVariant string                 `json:"default,omitempty"`
+	Rules          []Rule                 `json:"rules,omitempty"`
+}
+
+// Rule is a targeting rule of a flag
+type Rule struct {
+	Attribute string             `json:"attribute,omitempty"`
+	In        []string           `json:"in,omitempty"`
+	Variant   string             `json:"variant,omitempty"`
+	Rollout   map[string]float64 `json:"rollout,omitempty"`
+}
+
+// ParseFlags reads flag definitions decoded from YAML or JSON. A value that is a mapping with
+// variants is a full definition; any other value is a static flag.
+func ParseFlags(values map[string]interface{}) (map[string]Flag, error) {
+	flags := make(map[string]Flag, len(values))
+	for name, value := range values {
+		definition, ok := value.(map[string]interface{})
+		if _, hasVariants := definition["variants"]; !ok || !hasVariants {
+			flags[name] = Flag{Value: value}
+			continue
+		}
+
+		content, err := json.Marshal(definition)
+		if err != nil {
+			return nil, fmt.Errorf("flag %s: %w", name, err)
+		}
+		var flag Flag
+		if err := json.Unmarshal(content, &flag); err != nil {
+			return nil, fmt.Errorf("flag %s: %w", name, err)
+		}
+		if err := flag.validate(); err != nil {
+			return nil, fmt.Errorf("flag %s: %w", name, err)
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// validate checks that the rules of a flag name existing variants
+func (f Flag) validate() error {
+	if _, ok := f.Variants[f.DefaultVariant]; f.DefaultVariant != "" && !ok {
+		return fmt.Errorf("unknown default variant %q", f.DefaultVariant)
+	}
+	for i, rule := range f.Rules {
+		if len(rule.Rollout) == 0 {
+			if _, ok := f.Variants[rule.Variant]; !ok {
+				return fmt.Errorf("rule %d: unknown variant %q", i+1, rule.Variant)
+			}
+			continue
+		}
+		total := 0.0
+		for variant, percentage := range rule.Rollout {
+			if _, ok := f.Variants[variant]; !ok {
+				return fmt.Errorf("rule %d: unknown variant %q", i+1, variant)
+			}
+			total += percentage
+		}
+		if total > 100 {
+			return fmt.Errorf("rule %d: rollout adds up to %v%%", i+1, total)
+		}
+	}
+	return nil
+}
+
+// Evaluate resolves the flag named name for a context
+func (f Flag) Evaluate(name string, evalCtx EvaluationContext) Resolution {
+	if f.Variants == nil {
+		return Resolution{Value: f.Value, Reason: ReasonStatic}
+	}
+
+	for _, rule := range f.Rules {
+		if rule.Attribute != "" && !rule.matches(evalCtx) {
+			continue
+		}
+		if len(rule.Rollout) > 0 {
+			variant, ok := rollout(name, evalCtx.TargetingKey, rule.Rollout)
+			if !ok {
+				// The rollout leaves this context out; try the next rules
+				continue
+			}
+			return Resolution{Value: f.Variants[variant], Variant: variant, Reason: ReasonSplit}
+		}
+		return Resolution{Value: f.Variants[rule.Variant], Variant: rule.Variant, Reason: ReasonTargetingMatch}
+	}
+	return Resolution{Value: f.Variants[f.DefaultVariant], Variant: f.DefaultVariant, Reason: ReasonDefault}
+}
+
+// matches reports whether the attribute of a context is one of the rule values; an attribute
+// that is a list, such as the roles of a user, matches when one of its values does
+func (r Rule) matches(evalCtx EvaluationContext) bool {
+	var values []string
+	if r.Attribute == "key" {
+		values = []string{evalCtx.TargetingKey}
+	} else {
+		attribute, ok := evalCtx.Attributes[r.Attribute]
+		if !ok {
+			return false
+		}
+		switch list := attribute.(type) {
+		case []string:
+			values = list
+		case []interface{}:
+			for _, value := range list {
+				values = append(values, fmt.Sprint(value))
+			}
+		default:
+			values = []string{fmt.Sprint(attribute)}
+		}
+	}
+	for _, candidate := range r.In {
+		for _, value := range values {
+			if candidate == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rollout puts a targeting key in a bucket from 0 to 100, the same for every evaluation of
+// the flag, and returns the variant whose share covers the bucket
+func rollout(flag, key string, shares map[string]float64) (string, bool) {
+	hash := fnv.New32a()
+	hash.Write([]byte(flag + "/" + key))
+	bucket := float64(hash.Sum32()%10000) / 100
+
+	variants := make([]string, 0, len(shares))
+	for variant := range shares {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+
+	limit := 0.0
+	for _, variant := range variants {
+		limit += shares[variant]
+		if bucket < limit {
+			return variant, true
+		}
+	}
+	return "", false
+}
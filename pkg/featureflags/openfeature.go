@@ -0,0 +1,135 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// OpenFeatureProvider is an OpenFeature provider evaluating the flags of a manager, so that
+// the services reading their flags with the OpenFeature SDK keep the env, file and remote
+// providers of the manager:
+//
+//	openfeature.SetProviderAndWait(featureflags.NewOpenFeatureProvider(manager))
+//	client := openfeature.NewDefaultClient()
+//	enabled, _ := client.BooleanValue(ctx, "new-checkout", false, openfeature.TransactionContext(ctx))
+//
+// The targeting key of the OpenFeature evaluation context is that of the manager, and its
+// other attributes are the attributes the rules match.
+type OpenFeatureProvider struct {
+	manager *Manager
+}
+
+// NewOpenFeatureProvider creates the OpenFeature provider of a manager. The manager is started
+// and closed by its owner, as the Bootstrap does with its feature flag component, rather than
+// by OpenFeature.
+func NewOpenFeatureProvider(manager *Manager) *OpenFeatureProvider {
+	return &OpenFeatureProvider{manager: manager}
+}
+
+// Metadata names the provider after the providers of the manager
+func (p *OpenFeatureProvider) Metadata() openfeature.Metadata {
+	return openfeature.Metadata{Name: "go-micro-framework"}
+}
+
+// Hooks returns no hooks
+func (p *OpenFeatureProvider) Hooks() []openfeature.Hook {
+	return nil
+}
+
+// BooleanEvaluation evaluates a boolean flag
+func (p *OpenFeatureProvider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, flatCtx openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
+	value, detail := p.resolve(ctx, flag, defaultValue, flatCtx)
+	resolved, ok := value.(bool)
+	if !ok {
+		return openfeature.BoolResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch(flag, "boolean", value)}
+	}
+	return openfeature.BoolResolutionDetail{Value: resolved, ProviderResolutionDetail: detail}
+}
+
+// StringEvaluation evaluates a string flag
+func (p *OpenFeatureProvider) StringEvaluation(ctx context.Context, flag string, defaultValue string, flatCtx openfeature.FlattenedContext) openfeature.StringResolutionDetail {
+	value, detail := p.resolve(ctx, flag, defaultValue, flatCtx)
+	resolved, ok := value.(string)
+	if !ok {
+		return openfeature.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch(flag, "string", value)}
+	}
+	return openfeature.StringResolutionDetail{Value: resolved, ProviderResolutionDetail: detail}
+}
+
+// FloatEvaluation evaluates a numeric flag
+func (p *OpenFeatureProvider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, flatCtx openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
+	value, detail := p.resolve(ctx, flag, defaultValue, flatCtx)
+	var resolved float64
+	switch number := value.(type) {
+	case float64:
+		resolved = number
+	case int:
+		resolved = float64(number)
+	case int64:
+		resolved = float64(number)
+	default:
+		return openfeature.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch(flag, "number", value)}
+	}
+	return openfeature.FloatResolutionDetail{Value: resolved, ProviderResolutionDetail: detail}
+}
+
+// IntEvaluation evaluates an integer flag; the numbers of the env provider, which are
+// floats, are integers when they have no fraction
+func (p *OpenFeatureProvider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, flatCtx openfeature.FlattenedContext) openfeature.IntResolutionDetail {
+	value, detail := p.resolve(ctx, flag, defaultValue, flatCtx)
+	var resolved int64
+	switch number := value.(type) {
+	case int:
+		resolved = int64(number)
+	case int64:
+		resolved = number
+	case float64:
+		if number != math.Trunc(number) {
+			return openfeature.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch(flag, "integer", value)}
+		}
+		resolved = int64(number)
+	default:
+		return openfeature.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch(flag, "integer", value)}
+	}
+	return openfeature.IntResolutionDetail{Value: resolved, ProviderResolutionDetail: detail}
+}
+
+// ObjectEvaluation evaluates a flag whose value is structured
+func (p *OpenFeatureProvider) ObjectEvaluation(ctx context.Context, flag string, defaultValue any, flatCtx openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	value, detail := p.resolve(ctx, flag, defaultValue, flatCtx)
+	return openfeature.InterfaceResolutionDetail{Value: value, ProviderResolutionDetail: detail}
+}
+
+// resolve evaluates a flag with the manager for the flattened OpenFeature context
+func (p *OpenFeatureProvider) resolve(ctx context.Context, flag string, defaultValue interface{}, flatCtx openfeature.FlattenedContext) (interface{}, openfeature.ProviderResolutionDetail) {
+	evalCtx := EvaluationContext{Attributes: make(map[string]interface{}, len(flatCtx))}
+	for key, value := range flatCtx {
+		if key == openfeature.TargetingKey {
+			evalCtx.TargetingKey, _ = value.(string)
+			continue
+		}
+		evalCtx.Attributes[key] = value
+	}
+
+	resolution := p.manager.Resolve(ctx, flag, defaultValue, evalCtx)
+	detail := openfeature.ProviderResolutionDetail{Reason: openfeature.Reason(resolution.Reason), Variant: resolution.Variant}
+	switch {
+	case errors.Is(resolution.Error, ErrFlagNotFound):
+		detail.ResolutionError = openfeature.NewFlagNotFoundResolutionError(fmt.Sprintf("flag %s not found", flag))
+	case resolution.Error != nil:
+		detail.ResolutionError = openfeature.NewGeneralResolutionError(resolution.Error.Error())
+	}
+	return resolution.Value, detail
+}
+
+// typeMismatch is the resolution of a flag whose value is not of the type evaluated
+func typeMismatch(flag, expected string, value interface{}) openfeature.ProviderResolutionDetail {
+	return openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewTypeMismatchResolutionError(fmt.Sprintf("flag %s is %T, not a %s", flag, value, expected)),
+	}
+}
@@ -0,0 +1,271 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// flagSet holds the flags loaded by a provider
+type flagSet struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+func (s *flagSet) set(flags map[string]Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = flags
+}
+
+func (s *flagSet) resolve(flag string, evalCtx EvaluationContext) (Resolution, error) {
+	s.mu.RLock()
+	definition, ok := s.flags[flag]
+	s.mu.RUnlock()
+	if !ok {
+		return Resolution{}, ErrFlagNotFound
+	}
+	return definition.Evaluate(flag, evalCtx), nil
+}
+
+// EnvProvider reads static flags from environment variables: the flag new-checkout is the
+// variable <prefix>NEW_CHECKOUT. true and false are booleans, numbers are numbers and
+// anything else is a string.
+type EnvProvider struct {
+	prefix string
+}
+
+// NewEnvProvider creates a provider for the variables starting with prefix
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+// Name returns env
+func (p *EnvProvider) Name() string { return "env" }
+
+// Resolve reads the variable of a flag
+func (p *EnvProvider) Resolve(ctx context.Context, flag string, evalCtx EvaluationContext) (Resolution, error) {
+	value, ok := os.LookupEnv(p.Variable(flag))
+	if !ok {
+		return Resolution{}, ErrFlagNotFound
+	}
+	return Resolution{Value: parseValue(value), Reason: ReasonStatic}, nil
+}
+
+// Variable returns the environment variable of a flag
+func (p *EnvProvider) Variable(flag string) string {
+	return p.prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flag))
+}
+
+func parseValue(value string) interface{} {
+	if parsed, err := strconv.ParseBool(value); err == nil {
+		return parsed
+	}
+	if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+		return parsed
+	}
+	return value
+}
+
+// FileProvider reads flags from a YAML or JSON file, and reads it again when it changes if
+// started with a refresh interval
+type FileProvider struct {
+	flagSet
+	path     string
+	interval time.Duration
+	modified time.Time
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewFileProvider creates a provider for the flags file at path. With a positive interval,
+// the file is checked for changes that often once started.
+func NewFileProvider(path string, interval ...time.Duration) *FileProvider {
+	provider := &FileProvider{path: path, stop: make(chan struct{})}
+	if len(interval) > 0 {
+		provider.interval = interval[0]
+	}
+	return provider
+}
+
+// Name returns file
+func (p *FileProvider) Name() string { return "file" }
+
+// Start loads the file and starts watching it
+func (p *FileProvider) Start(ctx context.Context) error {
+	if err := p.Load(); err != nil {
+		return err
+	}
+	if p.interval > 0 {
+		go p.watch()
+	}
+	return nil
+}
+
+// Load reads the flags file
+func (p *FileProvider) Load() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	flags, err := ParseFlags(values)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	p.set(flags)
+	p.modified = info.ModTime()
+	return nil
+}
+
+func (p *FileProvider) watch() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(p.path)
+			if err != nil || !info.ModTime().After(p.modified) {
+				continue
+			}
+			// A file that fails to load keeps the flags loaded before
+			p.Load()
+		}
+	}
+}
+
+// Resolve evaluates a flag of the file
+func (p *FileProvider) Resolve(ctx context.Context, flag string, evalCtx EvaluationContext) (Resolution, error) {
+	return p.resolve(flag, evalCtx)
+}
+
+// Close stops watching the file
+func (p *FileProvider) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	return nil
+}
+
+// RemoteProvider polls a flag service in the style of LaunchDarkly: a GET of URL with the SDK
+// key in the Authorization header returns the flag definitions as a JSON object, either
+// directly or under "flags". Evaluation happens locally, so a failed poll keeps the last flags.
+type RemoteProvider struct {
+	flagSet
+	url      string
+	sdkKey   string
+	interval time.Duration
+	client   *http.Client
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewRemoteProvider creates a provider polling url every interval (30s when zero)
+func NewRemoteProvider(url, sdkKey string, interval time.Duration) *RemoteProvider {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &RemoteProvider{
+		url:      url,
+		sdkKey:   sdkKey,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		stop:     make(chan struct{}),
+	}
+}
+
+// Name returns remote
+func (p *RemoteProvider) Name() string { return "remote" }
+
+// Start fetches the flags and starts polling
+func (p *RemoteProvider) Start(ctx context.Context) error {
+	if err := p.Fetch(ctx); err != nil {
+		return err
+	}
+	go p.poll()
+	return nil
+}
+
+// Fetch gets the flags from the service
+func (p *RemoteProvider) Fetch(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+	if p.sdkKey != "" {
+		request.Header.Set("Authorization", p.sdkKey)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", p.url, response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("%s: %w", p.url, err)
+	}
+	if nested, ok := values["flags"].(map[string]interface{}); ok {
+		values = nested
+	}
+	flags, err := ParseFlags(values)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.url, err)
+	}
+	p.set(flags)
+	return nil
+}
+
+func (p *RemoteProvider) poll() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+			p.Fetch(ctx)
+			cancel()
+		}
+	}
+}
+
+// Resolve evaluates a flag fetched from the service
+func (p *RemoteProvider) Resolve(ctx context.Context, flag string, evalCtx EvaluationContext) (Resolution, error) {
+	return p.resolve(flag, evalCtx)
+}
+
+// Close stops polling
+func (p *RemoteProvider) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	return nil
+}
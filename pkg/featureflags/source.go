@@ -0,0 +1,10 @@
+package featureflags
+
+import "embed"
+
+// Source holds the source of the package, which the generator copies into the services
+// generated with feature flags as their internal/featureflags package, so that they do not
+// depend on a release of the framework
+//
+//go:embed context.go featureflags.go flag.go openfeature.go providers.go
+var Source embed.FS
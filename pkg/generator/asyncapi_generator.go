@@ -0,0 +1,233 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// AsyncAPIConfig holds configuration for the generation of the messaging code of an AsyncAPI
+// document
+type AsyncAPIConfig struct {
+	ServiceName string
+	// Spec is what was read from the AsyncAPI document
+	Spec *AsyncAPISpec
+	// Serialization is how the messages are encoded: json, or avro or protobuf with their
+	// schemas in api/events and a schema registry
+	Serialization string
+	OutputPath    string
+	ForceGenerate bool
+}
+
+// AsyncAPISpec is what generate from-asyncapi derives from an AsyncAPI document: the messages
+// of the channels, those the service sends and those it receives
+type AsyncAPISpec struct {
+	// Source is the AsyncAPI document, for the header of the generated files
+	Source string
+	// Types are the structs of the payloads of the messages and of the schemas they reference
+	Types []MessageType
+	// Producers are the messages the service sends
+	Producers []AsyncAPIOperation
+	// Channels are the channels the service receives messages from
+	Channels []AsyncAPIChannel
+	// Topics are the Kafka topics of the channels without parameters, when the document is
+	// that of Kafka channels
+	Topics []KafkaTopic
+}
+
+// MessageType is a struct of a payload of an AsyncAPI document
+type MessageType struct {
+	Name        string
+	Description string
+	Fields      []MessageField
+}
+
+// MessageField is a property of the schema of a MessageType
+type MessageField struct {
+	// Name is the name of the property, GoName that of the field
+	Name, GoName string
+	GoType       string
+	Description  string
+	Required     bool
+}
+
+// AsyncAPIOperation is a message the service sends or receives on a channel
+type AsyncAPIOperation struct {
+	// Method is the Go name of the producer or handler method
+	Method      string
+	Description string
+	// Address is the address of the channel, with its {parameters}
+	Address string
+	// Message is the Go type of the message, MessageName the type its messages are sent with
+	Message, MessageName string
+	// Parameters are the parameters of the address, as Go parameters of the producer
+	Parameters []AsyncAPIParameter
+}
+
+// AsyncAPIParameter is a parameter of the address of a channel
+type AsyncAPIParameter struct {
+	Name, GoName string
+}
+
+// AsyncAPIChannel is a channel the service subscribes to, and the handlers of its messages
+type AsyncAPIChannel struct {
+	Address  string
+	Handlers []AsyncAPIOperation
+}
+
+// Topic returns the Go expression of the topic of a producer: the address with its parameters
+func (o AsyncAPIOperation) Topic() string {
+	topic := fmt.Sprintf("%q", o.Address)
+	for _, parameter := range o.Parameters {
+		topic = strings.ReplaceAll(topic, "{"+parameter.Name+"}", `" + `+parameter.GoName+` + "`)
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(topic, ` + ""`), `"" + `)
+}
+
+// AsyncAPIGenerator generates the messaging code of an AsyncAPI document: the message types,
+// the producers sending them with a MessagingManager, and the subscriptions of handlers
+type AsyncAPIGenerator struct {
+	options
+	config *AsyncAPIConfig
+	// hook is the hook context of the running generation
+	hook *HookContext
+}
+
+// NewAsyncAPIGenerator creates a new AsyncAPI generator
+func NewAsyncAPIGenerator(config *AsyncAPIConfig, opts ...Option) *AsyncAPIGenerator {
+	return &AsyncAPIGenerator{
+		options: newOptions(opts),
+		config:  config,
+	}
+}
+
+// Validate reports the first field of the configuration that cannot be generated, as a
+// *ConfigError
+func (c *AsyncAPIConfig) Validate() error {
+	if err := validateName("ServiceName", c.ServiceName); err != nil {
+		return err
+	}
+	if c.Spec == nil || len(c.Spec.Producers)+len(c.Spec.Channels) == 0 {
+		return &ConfigError{Field: "Spec", Reason: "the document sends and receives no messages"}
+	}
+	if c.Serialization != "" && !containsName(Serializations, c.Serialization) {
+		return &ConfigError{Field: "Serialization", Reason: fmt.Sprintf("%q is not one of %s", c.Serialization, strings.Join(Serializations, ", "))}
+	}
+	return nil
+}
+
+// asyncAPIData is what the AsyncAPI templates are rendered with
+type asyncAPIData struct {
+	ServiceName string
+	*AsyncAPISpec
+	// Time is set when a message field is a time.Time
+	Time bool
+	// Serialization is json, avro or protobuf, and Schemas the schemas of the last two
+	Serialization string
+	Schemas       *eventSchemas
+}
+
+// GenerateAsyncAPI generates the messaging code of the document of the configuration into
+// internal/events, with the Avro or Protobuf serialization the schemas of its messages into
+// api/events, and the Kafka topics of its channels into deployments/kafka. The handler stubs
+// are written once, to be implemented, and only replaced with ForceGenerate; the other files
+// are generated again from the document every time.
+func (ag *AsyncAPIGenerator) GenerateAsyncAPI() error {
+	if err := ag.config.Validate(); err != nil {
+		return err
+	}
+
+	ag.hook = &HookContext{Generator: "asyncapi", Name: ag.config.ServiceName, Dir: ag.config.OutputPath}
+	if err := ag.runBeforeHooks(ag.hook); err != nil {
+		return err
+	}
+
+	data := &asyncAPIData{ServiceName: ag.config.ServiceName, AsyncAPISpec: ag.config.Spec, Serialization: ag.config.Serialization}
+	if data.Serialization == "" {
+		data.Serialization = "json"
+	}
+	for _, messageType := range data.Types {
+		for _, field := range messageType.Fields {
+			if strings.HasSuffix(field.GoType, "time.Time") {
+				data.Time = true
+			}
+		}
+	}
+
+	eventsDir := filepath.Join("internal", "events")
+	schemasDir := filepath.Join("api", "events")
+	if data.Serialization != "json" {
+		previous, err := os.ReadFile(filepath.Join(ag.config.OutputPath, schemasDir, "events.proto"))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if data.Schemas, err = newEventSchemas(data.AsyncAPISpec, data.ServiceName, data.Serialization, string(previous)); err != nil {
+			return &ConfigError{Field: "Spec", Reason: err.Error()}
+		}
+		// The codec registers the schema as it is written
+		if data.Schemas.Proto != nil {
+			source, err := ag.pipeline.Render("asyncapi/events.proto", filepath.Join(ag.config.OutputPath, schemasDir, "events.proto"), data)
+			if err != nil {
+				return fmt.Errorf("failed to generate %s: %w", filepath.ToSlash(filepath.Join(schemasDir, "events.proto")), err)
+			}
+			data.Schemas.ProtoSource = string(source)
+		}
+	}
+
+	// The producers are only generated for a document whose service sends messages, the
+	// consumers and handlers for one whose service receives them
+	type eventsFile struct {
+		step, template, file string
+		data                 interface{}
+	}
+	files := []eventsFile{
+		{"message types", "asyncapi/messages.go", filepath.Join(eventsDir, "messages.go"), data},
+		{"codec", "asyncapi/codec.go", filepath.Join(eventsDir, "codec.go"), data},
+	}
+	if len(data.Producers) > 0 {
+		files = append(files, eventsFile{"producers", "asyncapi/producers.go", filepath.Join(eventsDir, "producers.go"), data})
+	}
+	if len(data.Channels) > 0 {
+		files = append(files,
+			eventsFile{"consumers", "asyncapi/consumers.go", filepath.Join(eventsDir, "consumers.go"), data},
+			eventsFile{"handlers", "asyncapi/handlers.go", filepath.Join(eventsDir, "handlers.go"), data})
+	}
+	// The schemas are registered from api/events, and the topics created from
+	// deployments/kafka
+	if data.Schemas != nil {
+		for _, schema := range data.Schemas.Avro {
+			files = append(files, eventsFile{"schema " + schema.Type, "asyncapi/schema.avsc", filepath.Join(schemasDir, schema.File), schema})
+		}
+		if data.Schemas.Proto != nil {
+			files = append(files, eventsFile{"protobuf schema", "asyncapi/events.proto", filepath.Join(schemasDir, "events.proto"), data})
+		}
+		files = append(files, eventsFile{"registry subjects", "asyncapi/registry.yaml", filepath.Join(schemasDir, "registry.yaml"), data})
+	}
+	if len(data.Topics) > 0 {
+		files = append(files, eventsFile{"topics", "asyncapi/topics.yaml", filepath.Join("deployments", "kafka", "topics.yaml"), data})
+	}
+
+	ag.progress = progress.NewReporter(ag.events, "generate", len(files))
+	defer func() { ag.progress = nil }()
+
+	for _, file := range files {
+		path := filepath.Join(ag.config.OutputPath, file.file)
+		err := ag.progress.Step(file.step, func() error {
+			if _, err := os.Stat(path); err == nil && filepath.Base(path) == "handlers.go" && !ag.config.ForceGenerate {
+				return nil
+			}
+			if filepath.Ext(path) != ".go" {
+				return ag.runHooked(ag.hook, file.template, path, file.data)
+			}
+			return ag.runHooked(ag.hook, file.template, path, file.data, GoFormat{})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filepath.ToSlash(file.file), err)
+		}
+	}
+
+	return ag.runAfterHooks(ag.hook)
+}
@@ -0,0 +1,497 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serializations are how generate from-asyncapi encodes the messages: as JSON objects, or in
+// the Avro or Protobuf wire format of a schema registry
+var Serializations = []string{"json", "avro", "protobuf"}
+
+// KafkaTopic is a topic of a channel of an AsyncAPI document, as its Kafka bindings define it
+type KafkaTopic struct {
+	// Name is the name of the topic, Resource that of its KafkaTopic resource
+	Name, Resource string
+	Partitions     int
+	Replicas       int
+	// Config is the configuration of the topic, sorted by key
+	Config []TopicSetting
+}
+
+// TopicSetting is an entry of the configuration of a topic, as retention.ms
+type TopicSetting struct {
+	Key, Value string
+}
+
+// EventSchema is the Avro schema of a message the service sends or receives, in api/events
+type EventSchema struct {
+	// Type is the Go type of the message, File the schema file under api/events
+	Type, File string
+	Schema     string
+}
+
+// RegistrySubject is a subject of the schema registry and the schema registered under it
+type RegistrySubject struct {
+	Subject string
+	// Type is the Go type of the message, and the record or message name of its schema
+	Type string
+	// File is the schema file under api/events
+	File string
+}
+
+// ProtoFile is api/events/events.proto, the Protobuf schema of the messages
+type ProtoFile struct {
+	Package  string
+	Imports  []string
+	Messages []ProtoMessage
+}
+
+// ProtoMessage is a message of ProtoFile, the Protobuf schema of a MessageType
+type ProtoMessage struct {
+	Name, Description string
+	Fields            []ProtoField
+	// Reserved are the numbers of the fields the message had and no longer has
+	Reserved []int
+}
+
+// ProtoField is a field of a ProtoMessage; Label is optional, repeated or empty
+type ProtoField struct {
+	Name, Type, Label string
+	Number            int
+	Description       string
+}
+
+// eventSchemas is what the schemas of the messages are generated from and with
+type eventSchemas struct {
+	// Namespace is the Avro namespace and Protobuf package of the schemas
+	Namespace string
+	Avro      []EventSchema
+	Proto     *ProtoFile
+	// Descriptor is the serialized FileDescriptorProto of Proto, which the codec encodes and
+	// decodes the messages with
+	Descriptor string
+	// ProtoSource is api/events/events.proto, the schema the codec registers
+	ProtoSource string
+	Subjects    []RegistrySubject
+	// SharedTopics are the topics of several message types, whose subjects are named after
+	// the records rather than the topic
+	SharedTopics []string
+}
+
+// schemaName is what the properties of the messages must be named for their Avro and Protobuf
+// schemas
+var schemaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// schemaNamespace returns the Avro namespace and Protobuf package of the messages of a service
+func schemaNamespace(serviceName string) string {
+	return strings.ReplaceAll(serviceName, "-", "_") + ".events"
+}
+
+// newEventSchemas returns the schemas of the messages of a spec in a serialization, and the
+// subjects they are registered under. previousProto is the events.proto generated before, whose
+// field numbers are kept.
+func newEventSchemas(spec *AsyncAPISpec, serviceName, serialization, previousProto string) (*eventSchemas, error) {
+	schemas := &eventSchemas{Namespace: schemaNamespace(serviceName)}
+	types := make(map[string]MessageType)
+	for _, messageType := range spec.Types {
+		types[messageType.Name] = messageType
+		for _, field := range messageType.Fields {
+			if !schemaName.MatchString(field.Name) {
+				return nil, fmt.Errorf("property %s of %s is no %s field name", field.Name, messageType.Name, serialization)
+			}
+		}
+	}
+
+	// The messages of each topic, by topic; the topics with parameters are only known when
+	// the messages are sent, and are registered then
+	topics := make(map[string][]string)
+	var addresses []string
+	addMessage := func(operation AsyncAPIOperation) {
+		if len(operation.Parameters) > 0 {
+			return
+		}
+		if _, ok := topics[operation.Address]; !ok {
+			addresses = append(addresses, operation.Address)
+		}
+		if !containsName(topics[operation.Address], operation.Message) {
+			topics[operation.Address] = append(topics[operation.Address], operation.Message)
+		}
+	}
+	for _, producer := range spec.Producers {
+		addMessage(producer)
+	}
+	for _, channel := range spec.Channels {
+		for _, handler := range channel.Handlers {
+			addMessage(handler)
+		}
+	}
+	sort.Strings(addresses)
+
+	switch serialization {
+	case "avro":
+		var messages []string
+		for _, operation := range append(append([]AsyncAPIOperation{}, spec.Producers...), channelHandlers(spec.Channels)...) {
+			if !containsName(messages, operation.Message) {
+				messages = append(messages, operation.Message)
+			}
+		}
+		sort.Strings(messages)
+		for _, name := range messages {
+			schema, err := newAvroRecord(types, name, schemas.Namespace, make(map[string]bool))
+			if err != nil {
+				return nil, err
+			}
+			encoded, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			schemas.Avro = append(schemas.Avro, EventSchema{Type: name, File: name + ".avsc", Schema: string(encoded)})
+		}
+	case "protobuf":
+		file, err := protoFile(spec.Types, schemas.Namespace, previousProto)
+		if err != nil {
+			return nil, err
+		}
+		descriptor, err := file.descriptor()
+		if err != nil {
+			return nil, err
+		}
+		schemas.Proto, schemas.Descriptor = file, descriptor
+	}
+
+	for _, address := range addresses {
+		shared := len(topics[address]) > 1
+		if shared {
+			schemas.SharedTopics = append(schemas.SharedTopics, address)
+		}
+		for _, name := range topics[address] {
+			subject := RegistrySubject{Subject: address + "-value", Type: name, File: name + ".avsc"}
+			if shared {
+				subject.Subject = schemas.Namespace + "." + name
+			}
+			if serialization == "protobuf" {
+				subject.File = "events.proto"
+			}
+			schemas.Subjects = append(schemas.Subjects, subject)
+		}
+	}
+	return schemas, nil
+}
+
+// channelHandlers returns the handlers of the channels
+func channelHandlers(channels []AsyncAPIChannel) []AsyncAPIOperation {
+	var handlers []AsyncAPIOperation
+	for _, channel := range channels {
+		handlers = append(handlers, channel.Handlers...)
+	}
+	return handlers
+}
+
+// containsName reports whether names contains name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// avroRecord is an Avro record schema, its keys in the order of the specification
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+// avroField is a field of an avroRecord
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// newAvroRecord returns the record schema of a message type, with the records of its fields
+// declared where they are first used. defined are the records declared already.
+func newAvroRecord(types map[string]MessageType, name, namespace string, defined map[string]bool) (*avroRecord, error) {
+	messageType, ok := types[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no schema", name)
+	}
+	defined[name] = true
+	record := &avroRecord{Type: "record", Name: name, Namespace: namespace, Doc: messageType.Description}
+	for _, field := range messageType.Fields {
+		goType := strings.TrimPrefix(field.GoType, "*")
+		schema, err := avroType(types, goType, namespace, defined)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, field.Name, err)
+		}
+		avro := avroField{Name: field.Name, Type: schema, Doc: field.Description}
+		switch {
+		case field.Required:
+		case strings.HasPrefix(goType, "[]"):
+			avro.Default = json.RawMessage("[]")
+		default:
+			avro.Type, avro.Default = []interface{}{"null", schema}, json.RawMessage("null")
+		}
+		record.Fields = append(record.Fields, avro)
+	}
+	return record, nil
+}
+
+// avroPrimitives are the Avro types of the Go types of the message fields
+var avroPrimitives = map[string]interface{}{
+	"string":    "string",
+	"int32":     "int",
+	"int64":     "long",
+	"float32":   "float",
+	"float64":   "double",
+	"bool":      "boolean",
+	"time.Time": avroLogicalType{Type: "long", LogicalType: "timestamp-micros"},
+}
+
+// avroLogicalType is an Avro logical type, and avroArray an array schema
+type (
+	avroLogicalType struct {
+		Type        string `json:"type"`
+		LogicalType string `json:"logicalType"`
+	}
+	avroArray struct {
+		Type  string      `json:"type"`
+		Items interface{} `json:"items"`
+	}
+)
+
+// avroType returns the Avro schema of the Go type of a message field
+func avroType(types map[string]MessageType, goType, namespace string, defined map[string]bool) (interface{}, error) {
+	if primitive, ok := avroPrimitives[goType]; ok {
+		return primitive, nil
+	}
+	if items := strings.TrimPrefix(goType, "[]"); items != goType {
+		schema, err := avroType(types, items, namespace, defined)
+		return avroArray{Type: "array", Items: schema}, err
+	}
+	if _, ok := types[goType]; !ok {
+		return nil, fmt.Errorf("%s has no Avro type; give the property a schema with a type", goType)
+	}
+	if defined[goType] {
+		return namespace + "." + goType, nil
+	}
+	record, err := newAvroRecord(types, goType, "", defined)
+	return record, err
+}
+
+// protoScalars are the Protobuf types of the Go types of the message fields, and the files
+// declaring them
+var protoScalars = map[string][2]string{
+	"string":                 {"string"},
+	"int32":                  {"int32"},
+	"int64":                  {"int64"},
+	"float32":                {"float"},
+	"float64":                {"double"},
+	"bool":                   {"bool"},
+	"time.Time":              {"google.protobuf.Timestamp", "google/protobuf/timestamp.proto"},
+	"map[string]interface{}": {"google.protobuf.Struct", "google/protobuf/struct.proto"},
+	"interface{}":            {"google.protobuf.Value", "google/protobuf/struct.proto"},
+}
+
+// protoFieldLine matches the fields of the messages of a generated events.proto, and
+// protoReserved their reserved numbers
+var (
+	protoMessageLine = regexp.MustCompile(`^message (\w+) \{`)
+	protoFieldLine   = regexp.MustCompile(`^\s+(?:optional |repeated )?[\w.]+ (\w+) = (\d+);`)
+	protoReserved    = regexp.MustCompile(`^\s+reserved ([\d, ]+);`)
+)
+
+// protoFile returns the Protobuf schema of the message types. The fields keep the numbers they
+// have in previous, the events.proto generated before; the new fields are numbered after the
+// others, and the numbers of the removed ones are reserved.
+func protoFile(types []MessageType, namespace, previous string) (*ProtoFile, error) {
+	numbers := make(map[string]map[string]int)
+	reserved := make(map[string][]int)
+	var message string
+	for _, line := range strings.Split(previous, "\n") {
+		if match := protoMessageLine.FindStringSubmatch(line); match != nil {
+			message = match[1]
+			numbers[message] = make(map[string]int)
+		} else if match := protoFieldLine.FindStringSubmatch(line); match != nil && message != "" {
+			numbers[message][match[1]], _ = strconv.Atoi(match[2])
+		} else if match := protoReserved.FindStringSubmatch(line); match != nil && message != "" {
+			for _, number := range strings.Split(match[1], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(number)); err == nil {
+					reserved[message] = append(reserved[message], n)
+				}
+			}
+		}
+	}
+
+	file := &ProtoFile{Package: namespace}
+	for _, messageType := range types {
+		message := ProtoMessage{Name: messageType.Name, Description: messageType.Description, Reserved: reserved[messageType.Name]}
+		previous := numbers[messageType.Name]
+		next := 1
+		for _, number := range previous {
+			next = max(next, number+1)
+		}
+		for _, number := range message.Reserved {
+			next = max(next, number+1)
+		}
+		kept := make(map[string]bool)
+		for _, field := range messageType.Fields {
+			goType := strings.TrimPrefix(field.GoType, "*")
+			label := ""
+			if items := strings.TrimPrefix(goType, "[]"); items != goType {
+				goType, label = strings.TrimPrefix(items, "*"), "repeated"
+				if strings.HasPrefix(goType, "[]") {
+					return nil, fmt.Errorf("%s.%s: Protobuf has no lists of lists", messageType.Name, field.Name)
+				}
+			}
+			protoType, ok := protoScalars[goType]
+			switch {
+			case ok:
+				if protoType[1] != "" && !containsName(file.Imports, protoType[1]) {
+					file.Imports = append(file.Imports, protoType[1])
+				}
+				if label == "" && !field.Required && !strings.HasPrefix(protoType[0], "google.") {
+					label = "optional"
+				}
+			case containsType(types, goType):
+				protoType[0] = goType
+			default:
+				return nil, fmt.Errorf("%s.%s: %s has no Protobuf type", messageType.Name, field.Name, goType)
+			}
+			number, ok := previous[field.Name]
+			if !ok {
+				number = next
+				next++
+			}
+			kept[field.Name] = true
+			message.Fields = append(message.Fields, ProtoField{
+				Name:        field.Name,
+				Type:        protoType[0],
+				Label:       label,
+				Number:      number,
+				Description: field.Description,
+			})
+		}
+		for name, number := range previous {
+			if !kept[name] {
+				message.Reserved = append(message.Reserved, number)
+			}
+		}
+		sort.Ints(message.Reserved)
+		file.Messages = append(file.Messages, message)
+	}
+	sort.Strings(file.Imports)
+	return file, nil
+}
+
+// containsType reports whether types has a type of a name
+func containsType(types []MessageType, name string) bool {
+	for _, messageType := range types {
+		if messageType.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// protoTypes are the descriptor types of the Protobuf scalars
+var protoTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"float":  descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"double": descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+}
+
+// descriptor returns the serialized FileDescriptorProto of the file, as protoc would compile
+// it, once checked
+func (f *ProtoFile) descriptor() (string, error) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("events.proto"),
+		Package:    proto.String(f.Package),
+		Dependency: f.Imports,
+		Syntax:     proto.String("proto3"),
+	}
+	for _, message := range f.Messages {
+		descriptor := &descriptorpb.DescriptorProto{Name: proto.String(message.Name)}
+		var oneofs []*descriptorpb.OneofDescriptorProto
+		for _, field := range message.Fields {
+			fieldDescriptor := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(field.Name),
+				Number:   proto.Int32(int32(field.Number)),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				JsonName: proto.String(protoJSONName(field.Name)),
+			}
+			if scalar, ok := protoTypes[field.Type]; ok {
+				fieldDescriptor.Type = scalar.Enum()
+			} else {
+				fieldDescriptor.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				if strings.HasPrefix(field.Type, "google.") {
+					fieldDescriptor.TypeName = proto.String("." + field.Type)
+				} else {
+					fieldDescriptor.TypeName = proto.String("." + f.Package + "." + field.Type)
+				}
+			}
+			switch field.Label {
+			case "repeated":
+				fieldDescriptor.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			case "optional":
+				// proto3 optional fields are in a synthetic oneof of their own
+				fieldDescriptor.Proto3Optional = proto.Bool(true)
+				fieldDescriptor.OneofIndex = proto.Int32(int32(len(oneofs)))
+				oneofs = append(oneofs, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + field.Name)})
+			}
+			descriptor.Field = append(descriptor.Field, fieldDescriptor)
+		}
+		descriptor.OneofDecl = oneofs
+		for _, number := range message.Reserved {
+			descriptor.ReservedRange = append(descriptor.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{
+				Start: proto.Int32(int32(number)),
+				End:   proto.Int32(int32(number) + 1),
+			})
+		}
+		file.MessageType = append(file.MessageType, descriptor)
+	}
+	if _, err := protodesc.NewFile(file, protoregistry.GlobalFiles); err != nil {
+		return "", fmt.Errorf("invalid Protobuf schema: %w", err)
+	}
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(file)
+	return string(encoded), err
+}
+
+// protoJSONName returns the JSON name protoc gives a field: its name in lowerCamelCase
+func protoJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && 'a' <= r && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
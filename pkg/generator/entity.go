@@ -0,0 +1,365 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// Entity is a domain entity of the service, designed with microframework scaffold entity. The
+// entities are recorded in the generation manifest, and its model, repository, service and
+// handler, protobuf messages and GraphQL types are generated from them.
+type Entity struct {
+	Name      string           `json:"name" yaml:"name"`
+	Fields    []EntityField    `json:"fields" yaml:"fields"`
+	Relations []EntityRelation `json:"relations,omitempty" yaml:"relations,omitempty"`
+}
+
+// EntityField is a field of an entity
+type EntityField struct {
+	// Name is the snake_case name of the field, its column and JSON name
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Required bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	Unique   bool   `json:"unique,omitempty" yaml:"unique,omitempty"`
+	// Validate holds the validation rules of the field, as the binding tags of the requests
+	// take them: email, min=3, oneof=draft published
+	Validate []string `json:"validate,omitempty" yaml:"validate,omitempty"`
+}
+
+// EntityRelation is a relation of an entity to another, or to itself
+type EntityRelation struct {
+	// Name is the snake_case name of the relation field
+	Name   string `json:"name" yaml:"name"`
+	Kind   string `json:"kind" yaml:"kind"`
+	Entity string `json:"entity" yaml:"entity"`
+}
+
+// The kinds of relation between entities
+const (
+	RelationBelongsTo  = "belongs_to"
+	RelationHasOne     = "has_one"
+	RelationHasMany    = "has_many"
+	RelationManyToMany = "many_to_many"
+)
+
+// EntityRelationKinds are the kinds of relation between entities
+var EntityRelationKinds = []string{RelationBelongsTo, RelationHasOne, RelationHasMany, RelationManyToMany}
+
+// entityFieldType is how a field type is declared in Go, protobuf and GraphQL
+type entityFieldType struct {
+	Go, Proto, GraphQL, Gorm string
+}
+
+// entityFieldTypes are the types of entity fields
+var entityFieldTypes = map[string]entityFieldType{
+	"string": {Go: "string", Proto: "string", GraphQL: "String"},
+	"text":   {Go: "string", Proto: "string", GraphQL: "String", Gorm: "type:text"},
+	"int":    {Go: "int64", Proto: "int64", GraphQL: "Int"},
+	"float":  {Go: "float64", Proto: "double", GraphQL: "Float"},
+	"bool":   {Go: "bool", Proto: "bool", GraphQL: "Boolean"},
+	"time":   {Go: "time.Time", Proto: "google.protobuf.Timestamp", GraphQL: "Time"},
+	"uuid":   {Go: "string", Proto: "string", GraphQL: "ID"},
+	"json":   {Go: "map[string]interface{}", Proto: "google.protobuf.Struct", GraphQL: "JSON", Gorm: "serializer:json"},
+}
+
+// EntityFieldTypes are the types of entity fields, sorted
+var EntityFieldTypes = []string{"bool", "float", "int", "json", "string", "text", "time", "uuid"}
+
+var (
+	entityNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// reservedEntityFields are the fields every entity has
+var reservedEntityFields = []string{"id", "created_at", "updated_at", "deleted_at"}
+
+// ValidateEntities checks the entities of a service: their names, the types of their fields,
+// and that their relations point to entities of the service
+func ValidateEntities(entities []Entity) error {
+	names := make(map[string]bool)
+	for _, entity := range entities {
+		if !entityNamePattern.MatchString(entity.Name) {
+			return fmt.Errorf("invalid entity name %q: use PascalCase, as OrderItem", entity.Name)
+		}
+		if names[entity.Name] {
+			return fmt.Errorf("entity %s is defined twice", entity.Name)
+		}
+		names[entity.Name] = true
+	}
+
+	for _, entity := range entities {
+		if len(entity.Fields) == 0 {
+			return fmt.Errorf("entity %s has no fields", entity.Name)
+		}
+		fields := make(map[string]bool)
+		for _, field := range entity.Fields {
+			if !fieldNamePattern.MatchString(field.Name) {
+				return fmt.Errorf("entity %s: invalid field name %q: use snake_case, as unit_price", entity.Name, field.Name)
+			}
+			for _, reserved := range reservedEntityFields {
+				if field.Name == reserved {
+					return fmt.Errorf("entity %s: field %s is generated for every entity", entity.Name, field.Name)
+				}
+			}
+			if _, ok := entityFieldTypes[field.Type]; !ok {
+				return fmt.Errorf("entity %s: field %s has unknown type %q (%s)", entity.Name, field.Name, field.Type, strings.Join(EntityFieldTypes, ", "))
+			}
+			for _, rule := range field.Validate {
+				if rule == "" || strings.ContainsAny(rule, ",`\"") {
+					return fmt.Errorf("entity %s: field %s has invalid validation rule %q", entity.Name, field.Name, rule)
+				}
+			}
+			if fields[field.Name] {
+				return fmt.Errorf("entity %s: field %s is defined twice", entity.Name, field.Name)
+			}
+			fields[field.Name] = true
+		}
+
+		for _, relation := range entity.Relations {
+			if !fieldNamePattern.MatchString(relation.Name) {
+				return fmt.Errorf("entity %s: invalid relation name %q: use snake_case", entity.Name, relation.Name)
+			}
+			if fields[relation.Name] || (relation.Kind == RelationBelongsTo && fields[relation.Name+"_id"]) {
+				return fmt.Errorf("entity %s: relation %s conflicts with a field", entity.Name, relation.Name)
+			}
+			fields[relation.Name] = true
+			if !containsKind(relation.Kind) {
+				return fmt.Errorf("entity %s: relation %s has unknown kind %q (%s)", entity.Name, relation.Name, relation.Kind, strings.Join(EntityRelationKinds, ", "))
+			}
+			if !names[relation.Entity] {
+				return fmt.Errorf("entity %s: relation %s points to %s, which is no entity of the service; scaffold it first", entity.Name, relation.Name, relation.Entity)
+			}
+		}
+	}
+	return nil
+}
+
+// Route returns the path the handler of the entity registers its routes under, as /order_items
+func (e Entity) Route() string {
+	return "/" + snakeCase(plural(e.Name))
+}
+
+func containsKind(kind string) bool {
+	for _, known := range EntityRelationKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// entityData is what the entity templates are rendered with
+type entityData struct {
+	ServiceName string
+	// ProtoPackage is the protobuf package of the service
+	ProtoPackage string
+	Name         string
+	// A is the article of the name: a Customer, an Order
+	A string
+	// Var is the name of a variable holding an entity, Snake names its files
+	Var, Snake  string
+	Plural      string
+	PluralVar   string
+	PluralSnake string
+	Fields      []entityFieldData
+	Relations   []entityRelationData
+	// ProtoImports are the imports of the proto file, besides empty and timestamp
+	ProtoImports []string
+}
+
+// entityFieldData is a field of entityData, with its declarations in each language
+type entityFieldData struct {
+	Name, GoName, GraphQLName string
+	GoType, ProtoType         string
+	GraphQLType               string
+	// ModelTag, CreateTag and UpdateTag are the struct tags of the field in the model and the
+	// requests
+	ModelTag, CreateTag, UpdateTag string
+	Required                       bool
+}
+
+// entityRelationData is a relation of entityData
+type entityRelationData struct {
+	Kind, GoName, GraphQLName string
+	Entity                    string
+	// GoType is the type of the relation field of the model
+	GoType   string
+	ModelTag string
+	// ForeignKey is the foreign key field of a belongs_to relation
+	ForeignKey *entityFieldData
+}
+
+// newEntityData returns the data the templates of an entity are rendered with
+func newEntityData(config *GeneratorConfig, entity Entity) *entityData {
+	data := &entityData{
+		ServiceName:  config.ServiceName,
+		ProtoPackage: strings.ReplaceAll(config.ServiceName, "-", "_"),
+		Name:         entity.Name,
+		A:            "a",
+		Var:          lowerFirst(entity.Name),
+		Snake:        snakeCase(entity.Name),
+		Plural:       plural(entity.Name),
+	}
+	if strings.ContainsRune("AEIOU", rune(entity.Name[0])) {
+		data.A = "an"
+	}
+	if token.IsKeyword(data.Var) {
+		data.Var += "Entity"
+	}
+	data.PluralVar = lowerFirst(data.Plural)
+	data.PluralSnake = snakeCase(data.Plural)
+
+	for _, field := range entity.Fields {
+		fieldType := entityFieldTypes[field.Type]
+		fieldData := entityFieldData{
+			Name:        field.Name,
+			GoName:      goName(field.Name),
+			GraphQLName: lowerFirst(goName(field.Name)),
+			GoType:      fieldType.Go,
+			ProtoType:   fieldType.Proto,
+			GraphQLType: fieldType.GraphQL,
+			Required:    field.Required,
+		}
+
+		var gorm []string
+		if field.Required {
+			gorm = append(gorm, "not null")
+		}
+		if field.Unique {
+			gorm = append(gorm, "uniqueIndex")
+		}
+		if fieldType.Gorm != "" {
+			gorm = append(gorm, fieldType.Gorm)
+		}
+		fieldData.ModelTag = structTag("json", field.Name, "gorm", strings.Join(gorm, ";"))
+
+		// Optional fields are validated when they are given, and bool ones are always given
+		create, update := "", ""
+		if len(field.Validate) > 0 {
+			update = "omitempty," + strings.Join(field.Validate, ",")
+			create = update
+		}
+		if field.Required && field.Type != "bool" {
+			create = strings.Join(append([]string{"required"}, field.Validate...), ",")
+		}
+		fieldData.CreateTag = structTag("json", field.Name, "binding", create)
+		fieldData.UpdateTag = structTag("json", field.Name+",omitempty", "binding", update)
+
+		if field.Type == "json" && len(data.ProtoImports) == 0 {
+			data.ProtoImports = append(data.ProtoImports, "google/protobuf/struct.proto")
+		}
+		data.Fields = append(data.Fields, fieldData)
+	}
+
+	for _, relation := range entity.Relations {
+		relationData := entityRelationData{
+			Kind:        relation.Kind,
+			GoName:      goName(relation.Name),
+			GraphQLName: lowerFirst(goName(relation.Name)),
+			Entity:      relation.Entity,
+		}
+		switch relation.Kind {
+		case RelationBelongsTo:
+			relationData.GoType = "*" + relation.Entity
+			relationData.ModelTag = structTag("json", relation.Name+",omitempty", "gorm", "foreignKey:"+relationData.GoName+"ID")
+			relationData.ForeignKey = &entityFieldData{
+				Name:        relation.Name + "_id",
+				GoName:      relationData.GoName + "ID",
+				GraphQLName: relationData.GraphQLName + "Id",
+				GoType:      "uint",
+				ProtoType:   "uint64",
+				GraphQLType: "ID",
+				ModelTag:    structTag("json", relation.Name+"_id", "gorm", "index"),
+				CreateTag:   structTag("json", relation.Name+"_id", "", ""),
+				UpdateTag:   structTag("json", relation.Name+"_id,omitempty", "", ""),
+			}
+		case RelationHasOne:
+			relationData.GoType = "*" + relation.Entity
+			relationData.ModelTag = structTag("json", relation.Name+",omitempty", "", "")
+		case RelationHasMany:
+			relationData.GoType = "[]" + relation.Entity
+			relationData.ModelTag = structTag("json", relation.Name+",omitempty", "", "")
+		case RelationManyToMany:
+			relationData.GoType = "[]" + relation.Entity
+			relationData.ModelTag = structTag("json", relation.Name+",omitempty", "gorm", "many2many:"+data.Snake+"_"+relation.Name)
+		}
+		data.Relations = append(data.Relations, relationData)
+	}
+	return data
+}
+
+// ForeignKeys returns the foreign key fields of the belongs_to relations
+func (d *entityData) ForeignKeys() []entityFieldData {
+	var keys []entityFieldData
+	for _, relation := range d.Relations {
+		if relation.ForeignKey != nil {
+			keys = append(keys, *relation.ForeignKey)
+		}
+	}
+	return keys
+}
+
+// structTag returns a struct tag with a json key, and another key when its value is set
+func structTag(jsonKey, jsonValue, key, value string) string {
+	tag := fmt.Sprintf(`%s:"%s"`, jsonKey, jsonValue)
+	if value != "" {
+		tag += fmt.Sprintf(` %s:"%s"`, key, value)
+	}
+	return "`" + tag + "`"
+}
+
+// goInitialisms are the words Go names keep in upper case
+var goInitialisms = map[string]bool{"id": true, "url": true, "uuid": true, "api": true, "ip": true, "http": true, "json": true, "sku": true}
+
+// goName returns the Go name of a snake_case name: unit_price is UnitPrice, customer_id CustomerID
+func goName(name string) string {
+	var builder strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if goInitialisms[word] {
+			builder.WriteString(strings.ToUpper(word))
+		} else {
+			builder.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return builder.String()
+}
+
+// snakeCase returns the snake_case name of a PascalCase name: OrderItem is order_item
+func snakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+				builder.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// lowerFirst returns name with its first letter in lower case
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// plural returns the English plural of a name
+func plural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "y") && !strings.HasSuffix(lower, "ay") && !strings.HasSuffix(lower, "ey") && !strings.HasSuffix(lower, "oy"):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConfig is matched, with errors.Is, by the errors of invalid configurations
+var ErrInvalidConfig = errors.New("invalid generator configuration")
+
+// ConfigError reports a field of a configuration that cannot be generated
+type ConfigError struct {
+	// Field is the name of the configuration field, as ServiceName
+	Field  string
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidConfig, e.Field, e.Reason)
+}
+
+// Unwrap makes the error match ErrInvalidConfig
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// TemplateError reports a template that is missing from the registry, does not parse, or
+// fails to render
+type TemplateError struct {
+	// Template is the name of the template in the registry
+	Template string
+	Err      error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("template %s: %v", e.Template, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// ErrFileTooLarge is matched, with errors.Is, by the errors of files larger than the
+// MaxFileSize of the pipeline
+var ErrFileTooLarge = errors.New("generated file too large")
+
+// FileSizeError reports a file that grew past the MaxFileSize of the pipeline as it rendered
+type FileSizeError struct {
+	// Path is where the file was generated
+	Path  string
+	Limit int64
+}
+
+func (e *FileSizeError) Error() string {
+	return fmt.Sprintf("%s: %v (limit %d bytes)", e.Path, ErrFileTooLarge, e.Limit)
+}
+
+// Unwrap makes the error match ErrFileTooLarge
+func (e *FileSizeError) Unwrap() error {
+	return ErrFileTooLarge
+}
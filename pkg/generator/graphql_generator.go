@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// GraphQLConfig holds configuration for GraphQL generation
+type GraphQLConfig struct {
+	ServiceName   string
+	SchemaName    string
+	Types         []string
+	Queries       []string
+	Mutations     []string
+	Subscriptions []string
+	OutputPath    string
+	ForceGenerate bool
+	// SDL is the schema the files are generated from instead of the lists of names, when
+	// imported from SDL
+	SDL *GraphQLSchema
+}
+
+// GraphQLGenerator handles the generation of GraphQL schema files
+type GraphQLGenerator struct {
+	options
+	config *GraphQLConfig
+	// hook is the hook context of the running generation
+	hook *HookContext
+}
+
+// NewGraphQLGenerator creates a new GraphQL generator
+func NewGraphQLGenerator(config *GraphQLConfig, opts ...Option) *GraphQLGenerator {
+	return &GraphQLGenerator{
+		options: newOptions(opts),
+		config:  config,
+	}
+}
+
+// Validate reports the first field of the configuration that cannot be generated, as a
+// *ConfigError
+func (c *GraphQLConfig) Validate() error {
+	if err := validateName("ServiceName", c.ServiceName); err != nil {
+		return err
+	}
+	if c.SchemaName != "" {
+		return validateName("SchemaName", c.SchemaName)
+	}
+	return nil
+}
+
+// GenerateGraphQL generates GraphQL schema files, or the Go types, server and resolver stubs
+// of the SDL of the configuration. The schema is named after the service when SchemaName is
+// empty.
+func (gg *GraphQLGenerator) GenerateGraphQL() error {
+	if err := gg.config.Validate(); err != nil {
+		return err
+	}
+	if gg.config.SchemaName == "" {
+		gg.config.SchemaName = strings.ReplaceAll(gg.config.ServiceName, "-", "_")
+	}
+
+	gg.hook = &HookContext{Generator: "graphql", Name: gg.config.ServiceName, Dir: gg.config.OutputPath}
+	if err := gg.runBeforeHooks(gg.hook); err != nil {
+		return err
+	}
+
+	// Create GraphQL directory
+	graphqlDir := filepath.Join(gg.config.OutputPath, "graphql")
+	if err := os.MkdirAll(graphqlDir, 0755); err != nil {
+		return fmt.Errorf("failed to create GraphQL directory: %w", err)
+	}
+
+	if gg.config.SDL != nil {
+		gg.progress = progress.NewReporter(gg.events, "generate", 4)
+		defer func() { gg.progress = nil }()
+		if err := gg.generateFromSDL(graphqlDir); err != nil {
+			return err
+		}
+		return gg.runAfterHooks(gg.hook)
+	}
+
+	gg.progress = progress.NewReporter(gg.events, "generate", 2)
+	defer func() { gg.progress = nil }()
+
+	// Generate GraphQL schema file
+	err := gg.progress.Step("GraphQL schema", func() error {
+		return gg.generateGraphQLSchema(graphqlDir)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate GraphQL schema: %w", err)
+	}
+
+	// Generate Go schema file
+	err = gg.progress.Step("Go schema", func() error {
+		return gg.generateGoSchema(graphqlDir)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate Go schema: %w", err)
+	}
+
+	return gg.runAfterHooks(gg.hook)
+}
+
+// generateGraphQLSchema generates the GraphQL schema file
+func (gg *GraphQLGenerator) generateGraphQLSchema(graphqlDir string) error {
+	// Create GraphQL schema file
+	fileName := gg.config.SchemaName + ".graphql"
+	filePath := filepath.Join(graphqlDir, fileName)
+
+	// Check if file exists and force is not set
+	if !gg.config.ForceGenerate {
+		if _, err := os.Stat(filePath); err == nil {
+			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
+		}
+	}
+
+	// Create template data
+	data := map[string]interface{}{
+		"ServiceName":   gg.config.ServiceName,
+		"SchemaName":    gg.config.SchemaName,
+		"Types":         gg.config.Types,
+		"Queries":       gg.config.Queries,
+		"Mutations":     gg.config.Mutations,
+		"Subscriptions": gg.config.Subscriptions,
+	}
+
+	// Render and write file
+	return gg.runHooked(gg.hook, "graphql/schema.graphql", filePath, data)
+}
+
+// generateGoSchema generates the Go schema file
+func (gg *GraphQLGenerator) generateGoSchema(graphqlDir string) error {
+	// Create Go schema file
+	fileName := gg.config.SchemaName + "_schema.go"
+	filePath := filepath.Join(graphqlDir, fileName)
+
+	// Check if file exists and force is not set
+	if !gg.config.ForceGenerate {
+		if _, err := os.Stat(filePath); err == nil {
+			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
+		}
+	}
+
+	// Create template data
+	data := map[string]interface{}{
+		"ServiceName":   gg.config.ServiceName,
+		"SchemaName":    gg.config.SchemaName,
+		"Types":         gg.config.Types,
+		"Queries":       gg.config.Queries,
+		"Mutations":     gg.config.Mutations,
+		"Subscriptions": gg.config.Subscriptions,
+	}
+
+	// Render and write file
+	return gg.runHooked(gg.hook, "graphql/schema.go", filePath, data)
+}
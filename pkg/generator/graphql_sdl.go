@@ -0,0 +1,462 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GraphQLSchema is a GraphQL schema imported from SDL with generate graphql --from-sdl, which
+// the Go types, the server and the resolver stubs of the service are generated from
+type GraphQLSchema struct {
+	// Source is the SDL file the schema was read from
+	Source string
+	// SDL is the schema as written, copied to graphql/<schema>.graphql
+	SDL        []byte
+	Scalars    []GraphQLType
+	Enums      []GraphQLType
+	Interfaces []GraphQLType
+	Unions     []GraphQLType
+	Objects    []GraphQLType
+	Inputs     []GraphQLType
+	// Query and Mutation are the names of the root types; Mutation is empty without one
+	Query, Mutation string
+}
+
+// GraphQLType is a named type of a GraphQL schema
+type GraphQLType struct {
+	Name        string
+	Description string
+	// Fields are the fields of an object, interface or input object
+	Fields []GraphQLField
+	// Values are the values of an enum
+	Values []string
+	// Types are the interfaces an object implements, or the members of a union
+	Types []string
+}
+
+// GraphQLField is a field of a GraphQL type, or an argument of a field
+type GraphQLField struct {
+	Name        string
+	Description string
+	Type        GraphQLTypeRef
+	Args        []GraphQLField
+	// Default is the Go expression of the default value of an argument or input field
+	Default string
+	// Deprecated is the reason of the @deprecated directive of the field
+	Deprecated string
+}
+
+// GraphQLTypeRef is the type of a field: a named type, or a list of a type, non-null or not
+type GraphQLTypeRef struct {
+	Name    string
+	Of      *GraphQLTypeRef
+	NonNull bool
+}
+
+// String returns the type as written in SDL: [User!]!
+func (t GraphQLTypeRef) String() string {
+	name := t.Name
+	if t.Of != nil {
+		name = "[" + t.Of.String() + "]"
+	}
+	if t.NonNull {
+		name += "!"
+	}
+	return name
+}
+
+// graphQLBuiltins are the Go types and graphql-go types of the built-in scalars
+var graphQLBuiltins = map[string][2]string{
+	"String":  {"string", "graphql.String"},
+	"ID":      {"string", "graphql.ID"},
+	"Int":     {"int", "graphql.Int"},
+	"Float":   {"float64", "graphql.Float"},
+	"Boolean": {"bool", "graphql.Boolean"},
+}
+
+// graphQLTimeScalars are the custom scalars generated as time.Time, in RFC 3339
+var graphQLTimeScalars = map[string]bool{"Time": true, "DateTime": true, "Timestamp": true}
+
+// sdlData is what the SDL templates are rendered with
+type sdlData struct {
+	ServiceName string
+	// Source is the name of the SDL file, for the header of the generated files
+	Source     string
+	Scalars    []*sdlType
+	Enums      []*sdlType
+	Interfaces []*sdlType
+	Unions     []*sdlType
+	Objects    []*sdlType
+	Inputs     []*sdlType
+	// Resolvers are the types with fields resolved by a resolver, the root types first
+	Resolvers []*sdlType
+	// Types are the object types of the schema, but the root types
+	Types           []*sdlType
+	Query, Mutation *sdlType
+	// Time is set when a scalar is a time.Time, Literal when a scalar is of any value
+	Time, Literal bool
+	// TypesTime and ResolversTime are set when the types or the resolver stubs use time.Time
+	TypesTime, ResolversTime bool
+}
+
+// sdlType is a named type of sdlData
+type sdlType struct {
+	GraphQLType
+	// Var is the variable of the graphql-go type in the server; Lower is the name with its
+	// first letter in lower case, the other identifiers of the type start with
+	Var, Lower string
+	Doc        []string
+	Root       bool
+	// Fields are the fields of the type, Struct those of its Go struct and Resolved those
+	// resolved by its resolver
+	Fields, Struct, Resolved []*sdlField
+	Values                   []sdlEnumValue
+	// Implements are the interfaces and unions of an object, and Interfaces its interfaces;
+	// Members are the objects of an interface or union
+	Implements []string
+	Interfaces []*sdlType
+	Members    []*sdlType
+	// GoType is the Go type of a scalar
+	GoType string
+	Time   bool
+}
+
+// sdlField is a field of an sdlType
+type sdlField struct {
+	GraphQLField
+	GoName, GoType string
+	// GraphQLType is the graphql-go type expression of the field
+	GraphQLType string
+	// Zero is the zero value of GoType, returned by the resolver stubs
+	Zero string
+	Args []*sdlField
+	// ArgsType is the struct the arguments are decoded into
+	ArgsType string
+}
+
+// sdlEnumValue is a value of an enum and its Go constant
+type sdlEnumValue struct {
+	Name, Const string
+}
+
+// newSDLData returns the data the SDL templates of a schema are rendered with, failing when
+// two Go declarations would have the same name
+func newSDLData(config *GraphQLConfig) (*sdlData, error) {
+	schema := config.SDL
+	data := &sdlData{ServiceName: config.ServiceName, Source: filepath.Base(schema.Source)}
+	types := make(map[string]*sdlType)
+	add := func(list *[]*sdlType, graphQLTypes []GraphQLType) {
+		for _, graphQLType := range graphQLTypes {
+			t := &sdlType{GraphQLType: graphQLType, Var: lowerFirst(graphQLType.Name) + "Type", Lower: lowerFirst(graphQLType.Name)}
+			if graphQLType.Description != "" {
+				t.Doc = strings.Split(strings.TrimSpace(graphQLType.Description), "\n")
+			}
+			types[t.Name] = t
+			*list = append(*list, t)
+		}
+	}
+	add(&data.Scalars, schema.Scalars)
+	add(&data.Enums, schema.Enums)
+	add(&data.Interfaces, schema.Interfaces)
+	add(&data.Unions, schema.Unions)
+	add(&data.Objects, schema.Objects)
+	add(&data.Inputs, schema.Inputs)
+
+	for _, scalar := range data.Scalars {
+		scalar.GoType, scalar.Time = scalar.Name, graphQLTimeScalars[scalar.Name]
+		if scalar.Time {
+			scalar.GoType, data.Time = "time.Time", true
+		} else {
+			data.Literal = true
+		}
+	}
+	for _, enum := range data.Enums {
+		for _, value := range enum.GraphQLType.Values {
+			enum.Values = append(enum.Values, sdlEnumValue{Name: value, Const: enum.Name + goName(strings.ToLower(value))})
+		}
+	}
+
+	var goType func(ref GraphQLTypeRef) string
+	goType = func(ref GraphQLTypeRef) string {
+		if ref.Of != nil {
+			return "[]" + goType(*ref.Of)
+		}
+		if builtin, ok := graphQLBuiltins[ref.Name]; ok {
+			if ref.NonNull {
+				return builtin[0]
+			}
+			return "*" + builtin[0]
+		}
+		t := types[ref.Name]
+		switch {
+		case t.GoType != "" && !t.Time:
+			// Scalars of any value are nil when null
+			return t.GoType
+		case t.GoType != "":
+			if ref.NonNull {
+				return t.GoType
+			}
+			return "*" + t.GoType
+		case t.Values != nil && ref.NonNull:
+			return t.Name
+		case containsSDLType(data.Interfaces, t), containsSDLType(data.Unions, t):
+			return t.Name
+		}
+		return "*" + t.Name
+	}
+	var graphQLType func(ref GraphQLTypeRef) string
+	graphQLType = func(ref GraphQLTypeRef) string {
+		var expression string
+		if ref.Of != nil {
+			expression = "graphql.NewList(" + graphQLType(*ref.Of) + ")"
+		} else if builtin, ok := graphQLBuiltins[ref.Name]; ok {
+			expression = builtin[1]
+		} else {
+			expression = types[ref.Name].Var
+		}
+		if ref.NonNull {
+			return "graphql.NewNonNull(" + expression + ")"
+		}
+		return expression
+	}
+	zero := func(goType string) string {
+		switch {
+		case strings.HasPrefix(goType, "*"), strings.HasPrefix(goType, "[]"):
+			return "nil"
+		case goType == "string":
+			return `""`
+		case goType == "int", goType == "float64":
+			return "0"
+		case goType == "bool":
+			return "false"
+		case goType == "time.Time":
+			return "time.Time{}"
+		}
+		if t := types[goType]; t != nil && t.Values != nil {
+			return `""`
+		}
+		return "nil"
+	}
+	newField := func(field GraphQLField) *sdlField {
+		f := &sdlField{GraphQLField: field, GoName: goName(field.Name), GraphQLType: graphQLType(field.Type)}
+		f.GoType = goType(field.Type)
+		f.Zero = zero(f.GoType)
+		return f
+	}
+
+	// Objects implement the marker methods of their interfaces and unions
+	for _, object := range data.Objects {
+		object.Implements = append(object.Implements, object.GraphQLType.Types...)
+	}
+	for _, union := range data.Unions {
+		for _, member := range union.GraphQLType.Types {
+			types[member].Implements = append(types[member].Implements, union.Name)
+			union.Members = append(union.Members, types[member])
+		}
+	}
+	for _, object := range data.Objects {
+		for _, name := range object.GraphQLType.Types {
+			types[name].Members = append(types[name].Members, object)
+			object.Interfaces = append(object.Interfaces, types[name])
+		}
+	}
+
+	declared := make(map[string]string)
+	declare := func(name, what string) error {
+		if other, ok := declared[name]; ok {
+			return fmt.Errorf("%s and %s are both declared as %s in Go", other, what, name)
+		}
+		declared[name] = what
+		return nil
+	}
+	for _, name := range []string{"NewSchema", "NewHandler", "ResolverRoot", "Resolver"} {
+		declared[name] = "the generated " + name
+	}
+
+	for _, t := range append(append(append([]*sdlType{}, data.Scalars...), data.Enums...), append(data.Interfaces, data.Unions...)...) {
+		if err := declare(t.Name, "type "+t.Name); err != nil {
+			return nil, err
+		}
+		for _, value := range t.Values {
+			if err := declare(value.Const, t.Name+"."+value.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, t := range append(append([]*sdlType{}, data.Objects...), data.Inputs...) {
+		t.Root = t.Name == schema.Query || t.Name == schema.Mutation
+		switch t.Name {
+		case schema.Query:
+			data.Query = t
+		case schema.Mutation:
+			data.Mutation = t
+		}
+		if !t.Root {
+			if err := declare(t.Name, "type "+t.Name); err != nil {
+				return nil, err
+			}
+		}
+		if t.Root && t.Name != "Query" && t.Name != "Mutation" {
+			// The resolvers of the root types are named after them
+			if err := declare(t.Name+"Resolver", "the resolver of "+t.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		goNames := make(map[string]string)
+		for _, field := range t.GraphQLType.Fields {
+			f := newField(field)
+			if other, ok := goNames[f.GoName]; ok {
+				return nil, fmt.Errorf("fields %s.%s and %s.%s are both named %s in Go", t.Name, other, t.Name, field.Name, f.GoName)
+			}
+			goNames[f.GoName] = field.Name
+			t.Fields = append(t.Fields, f)
+			if len(field.Args) > 0 {
+				f.ArgsType = t.Name + f.GoName + "Args"
+				if err := declare(f.ArgsType, "the arguments of "+t.Name+"."+field.Name); err != nil {
+					return nil, err
+				}
+				for _, arg := range field.Args {
+					f.Args = append(f.Args, newField(arg))
+				}
+			}
+			if t.Root || len(field.Args) > 0 {
+				t.Resolved = append(t.Resolved, f)
+			} else {
+				t.Struct = append(t.Struct, f)
+			}
+		}
+		if len(t.Resolved) > 0 && !containsSDLType(data.Inputs, t) {
+			if err := declare(t.Name+"Resolver", "the resolver of "+t.Name); err != nil {
+				return nil, err
+			}
+			data.Resolvers = append(data.Resolvers, t)
+		}
+		if !t.Root && !containsSDLType(data.Inputs, t) {
+			data.Types = append(data.Types, t)
+		}
+	}
+	for _, t := range data.Interfaces {
+		for _, field := range t.GraphQLType.Fields {
+			f := newField(field)
+			for _, arg := range field.Args {
+				f.Args = append(f.Args, newField(arg))
+			}
+			t.Fields = append(t.Fields, f)
+		}
+	}
+	// The root types are resolved first
+	sortRoots := func(i int) int {
+		if data.Resolvers[i].Root {
+			return 0
+		}
+		return 1
+	}
+	for i := 1; i < len(data.Resolvers); i++ {
+		for j := i; j > 0 && sortRoots(j) < sortRoots(j-1); j-- {
+			data.Resolvers[j], data.Resolvers[j-1] = data.Resolvers[j-1], data.Resolvers[j]
+		}
+	}
+
+	// The types declare the fields of the structs and the arguments of the fields
+	for _, t := range append(append([]*sdlType{}, data.Objects...), data.Inputs...) {
+		for _, field := range t.Fields {
+			declared := field.Args
+			if !t.Root && len(field.Args) == 0 {
+				declared = append(declared, field)
+			}
+			for _, f := range declared {
+				if strings.HasSuffix(f.GoType, "time.Time") {
+					data.TypesTime = true
+				}
+			}
+		}
+		for _, field := range t.Resolved {
+			if field.Zero == "time.Time{}" {
+				data.ResolversTime = true
+			}
+		}
+	}
+	return data, nil
+}
+
+// containsSDLType reports whether a type is one of a list
+func containsSDLType(list []*sdlType, t *sdlType) bool {
+	for _, other := range list {
+		if other == t {
+			return true
+		}
+	}
+	return false
+}
+
+// generateFromSDL generates the Go types, server and resolver stubs of the SDL of the
+// configuration into graphqlDir, with a copy of the SDL. The resolver stubs are written once,
+// to be implemented, and only replaced with ForceGenerate; the other files are generated
+// again from the SDL every time.
+func (gg *GraphQLGenerator) generateFromSDL(graphqlDir string) error {
+	data, err := newSDLData(gg.config)
+	if err != nil {
+		return err
+	}
+	schemaPath := filepath.Join(graphqlDir, gg.config.SchemaName+".graphql")
+	err = gg.progress.Step("GraphQL schema", func() error {
+		if source, err := filepath.Abs(gg.config.SDL.Source); err == nil {
+			if target, err := filepath.Abs(schemaPath); err == nil && source == target {
+				return nil
+			}
+		}
+		return gg.writeSDL(schemaPath)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy GraphQL schema: %w", err)
+	}
+
+	files := []struct{ step, template, suffix string }{
+		{"Go types", "graphql/types.go", "_types.go"},
+		{"GraphQL server", "graphql/server.go", "_server.go"},
+	}
+	for _, file := range files {
+		path := filepath.Join(graphqlDir, gg.config.SchemaName+file.suffix)
+		err := gg.progress.Step(file.step, func() error {
+			return gg.runHooked(gg.hook, file.template, path, data, GoFormat{})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	path := filepath.Join(graphqlDir, gg.config.SchemaName+"_resolvers.go")
+	return gg.progress.Step("resolvers", func() error {
+		if _, err := os.Stat(path); err == nil && !gg.config.ForceGenerate {
+			return nil
+		}
+		if err := gg.runHooked(gg.hook, "graphql/resolvers.go", path, data, GoFormat{}); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filepath.Base(path), err)
+		}
+		return nil
+	})
+}
+
+// writeSDL writes the SDL of the configuration to path, through the file hooks
+func (gg *GraphQLGenerator) writeSDL(path string) error {
+	relPath, err := filepath.Rel(gg.hook.Dir, path)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+	content, err := gg.runFileHooks(gg.hook, relPath, gg.config.SDL.SDL)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := gg.pipeline.Writer.Write(path, content); err != nil {
+		return err
+	}
+	gg.progress.File(relPath, int64(len(content)), time.Since(start))
+	gg.hook.Files = append(gg.hook.Files, relPath)
+	return nil
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// GRPCService is a gRPC service of the .proto files a service was imported from with
+// microframework new --from-proto, which the service implements a server of
+type GRPCService struct {
+	// Name is the Go name of the service: UserService
+	Name string `json:"name"`
+	// Proto is the .proto file declaring the service, relative to the project: api/proto/user.proto
+	Proto string `json:"proto"`
+	// Package is the alias of the Go package of the compiled service in the handlers
+	Package string `json:"package"`
+	// Imports are the Go packages of the compiled service and of the messages of its methods
+	Imports []GoImport   `json:"imports"`
+	Methods []GRPCMethod `json:"methods"`
+}
+
+// GoImport is an import of a generated Go file
+type GoImport struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path"`
+}
+
+// GRPCMethod is a method of a gRPC service
+type GRPCMethod struct {
+	Name string `json:"name"`
+	// Input and Output are the Go types of the request and response messages, qualified with
+	// the alias of their package: userv1.GetUserRequest
+	Input           string `json:"input"`
+	Output          string `json:"output"`
+	ClientStreaming bool   `json:"client_streaming,omitempty"`
+	ServerStreaming bool   `json:"server_streaming,omitempty"`
+}
+
+// Unary reports whether neither the requests nor the responses of the method are streamed
+func (m GRPCMethod) Unary() bool {
+	return !m.ClientStreaming && !m.ServerStreaming
+}
+
+// File returns the name of the file of the server of the service: user_service_server.go
+func (s GRPCService) File() string {
+	return snakeCase(s.Name) + "_server.go"
+}
+
+// HasUnary reports whether a method of the service is unary, for which the server takes a context
+func (s GRPCService) HasUnary() bool {
+	for _, method := range s.Methods {
+		if method.Unary() {
+			return true
+		}
+	}
+	return false
+}
+
+// grpcRegistration is the data of the file registering the servers of the gRPC services
+type grpcRegistration struct {
+	Services []GRPCService
+	Imports  []GoImport
+}
+
+// generateGRPCServers generates the server of each gRPC service and the function registering them
+func (sg *ServiceGenerator) generateGRPCServers() error {
+	handlersDir := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "handlers")
+	registration := grpcRegistration{Services: sg.config.GRPCServices}
+	seen := make(map[string]bool)
+	for _, service := range sg.config.GRPCServices {
+		if err := sg.writeGoTemplate("grpc/server.go", filepath.Join(handlersDir, service.File()), service); err != nil {
+			return err
+		}
+		for _, imported := range service.Imports {
+			if imported.Alias == service.Package && !seen[imported.Path] {
+				seen[imported.Path] = true
+				registration.Imports = append(registration.Imports, imported)
+			}
+		}
+	}
+	sort.Slice(registration.Imports, func(i, j int) bool { return registration.Imports[i].Path < registration.Imports[j].Path })
+	return sg.writeGoTemplate("internal/handlers/grpc.go", filepath.Join(handlersDir, "grpc.go"), registration)
+}
+
+// generateAddedFiles writes the files of WithFiles
+func (sg *ServiceGenerator) generateAddedFiles() error {
+	paths := make([]string, 0, len(sg.added))
+	for path := range sg.added {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(path))
+		if err := sg.writeFile(renderedFile{path: outputPath, content: sg.added[path]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// HookContext describes a generation to its hooks
+type HookContext struct {
+	// Generator is the generator running the hooks: service, protobuf or graphql
+	Generator string
+	// Name is the name of the service generated
+	Name string
+	// Dir is the directory the files are generated in, which their paths are relative to
+	Dir string
+	// Files are the paths of the generated files, sorted; set for the After hooks
+	Files []string
+}
+
+// Hooks are callbacks run around a generation, for the steps an organization adds to it,
+// as stamping headers or registering the service in a catalog:
+//
+//	gen := generator.NewServiceGenerator(config, generator.WithHooks(generator.Hooks{
+//		After: func(ctx *generator.HookContext) error { return catalog.Register(ctx.Name, ctx.Dir) },
+//	}))
+//
+// Before runs before any file is generated, and an error aborts the generation. File runs on
+// every file once it is post-processed, with its path relative to the context's Dir, and
+// returns the content to write; it also runs when the files are only rendered, so that they
+// match what would be written. After runs once every file is written. Any of them may be nil.
+type Hooks struct {
+	Before func(ctx *HookContext) error
+	File   func(ctx *HookContext, path string, content []byte) ([]byte, error)
+	After  func(ctx *HookContext) error
+}
+
+// WithHooks adds hooks to the generation. The hooks of several WithHooks run in the order
+// the options are given.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks)
+	}
+}
+
+// runBeforeHooks runs the Before hooks of a generation
+func (o *options) runBeforeHooks(ctx *HookContext) error {
+	for _, hooks := range o.hooks {
+		if hooks.Before == nil {
+			continue
+		}
+		if err := hooks.Before(ctx); err != nil {
+			return fmt.Errorf("before generate hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// runFileHooks runs the File hooks on a file at path, relative to the context's Dir
+func (o *options) runFileHooks(ctx *HookContext, path string, content []byte) ([]byte, error) {
+	for _, hooks := range o.hooks {
+		if hooks.File == nil {
+			continue
+		}
+		var err error
+		if content, err = hooks.File(ctx, path, content); err != nil {
+			return nil, fmt.Errorf("file hook on %s: %w", path, err)
+		}
+	}
+	return content, nil
+}
+
+// runAfterHooks runs the After hooks of a generation, once its files are in the context
+func (o *options) runAfterHooks(ctx *HookContext) error {
+	sort.Strings(ctx.Files)
+	for _, hooks := range o.hooks {
+		if hooks.After == nil {
+			continue
+		}
+		if err := hooks.After(ctx); err != nil {
+			return fmt.Errorf("after generate hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// runHooked renders the template of a name for the file at path, under the context's Dir,
+// post-processes it with the processors given, runs the file hooks on it, writes it, reports it
+// and adds it to the files of the context. Without file hooks, the file is streamed when the
+// pipeline can stream it.
+func (o *options) runHooked(ctx *HookContext, name, path string, data interface{}, processors ...PostProcessor) error {
+	relPath, err := filepath.Rel(ctx.Dir, path)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	start := time.Now()
+	if !o.hasFileHooks() && o.pipeline.canStream(processors) {
+		streamed, err := o.pipeline.stream(name, path, path, data)
+		if err != nil {
+			return err
+		}
+		o.progress.File(relPath, streamed.size, time.Since(start))
+		ctx.Files = append(ctx.Files, relPath)
+		return nil
+	}
+
+	content, err := o.pipeline.Render(name, path, data, processors...)
+	if err != nil {
+		return err
+	}
+	if content, err = o.runFileHooks(ctx, relPath, content); err != nil {
+		return err
+	}
+	start = time.Now()
+	if err := o.pipeline.Writer.Write(path, content); err != nil {
+		return err
+	}
+	o.progress.File(relPath, int64(len(content)), time.Since(start))
+	ctx.Files = append(ctx.Files, relPath)
+	return nil
+}
+
+// hasFileHooks tells whether a File hook runs on the generated files
+func (o *options) hasFileHooks() bool {
+	for _, hooks := range o.hooks {
+		if hooks.File != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// What an incremental generation did to a file of the project
+const (
+	// FileCreated is a file that did not exist
+	FileCreated = "created"
+	// FileUpdated is a generated file, unchanged since, written with its new contents
+	FileUpdated = "updated"
+	// FileUnchanged is a file that already has the generated contents, left as it is
+	FileUnchanged = "unchanged"
+	// FileModified is a file changed since it was generated, or not generated, kept as it is
+	FileModified = "modified"
+	// FileDeleted is a generated file deleted since, not created again
+	FileDeleted = "deleted"
+	// FileOverwritten is a file changed since it was generated, or not generated, replaced
+	FileOverwritten = "overwritten"
+)
+
+// FileChange is what an incremental generation did to a file of the project
+type FileChange struct {
+	// Path is the path of the file, relative to the project root
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+// WithIncremental makes GenerateService regenerate the project in place, from the generation
+// manifest of a previous generation. The files whose template and data did not change since
+// are neither rendered nor written again, nor are the files whose contents are the same; the
+// files changed since they were generated are kept, unless force is set, and reported by
+// Changes. The file hooks only run on the files rendered again.
+func WithIncremental(force bool) Option {
+	return func(o *options) {
+		o.incremental = true
+		o.force = force
+	}
+}
+
+// Changes returns what the last GenerateService with WithIncremental did to each file of the
+// project, sorted by path
+func (sg *ServiceGenerator) Changes() []FileChange {
+	changes := make([]FileChange, 0, len(sg.changes))
+	for path, action := range sg.changes {
+		changes = append(changes, FileChange{Path: path, Action: action})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Inputs returns the checksums of what each file of the last generation was rendered from, by
+// path relative to the project root, as recorded in the generation manifest
+func (sg *ServiceGenerator) Inputs() map[string]string {
+	return sg.inputs
+}
+
+// loadPrevious reads the generation manifest an incremental generation starts from; a project
+// without one is generated as if none of its files was
+func (sg *ServiceGenerator) loadPrevious() error {
+	sg.changes = make(map[string]string)
+	manifest, err := LoadManifest(sg.hook.Dir)
+	switch {
+	case os.IsNotExist(err):
+		manifest = &Manifest{Files: map[string]string{}}
+	case err != nil:
+		return err
+	}
+	sg.previous = manifest
+	return nil
+}
+
+// inputsChecksum returns the checksum of what a file is rendered from: the template, its data,
+// and the renderer and post-processors of the pipeline. It is "" when the data does not encode.
+func (sg *ServiceGenerator) inputsChecksum(name string, data interface{}, processors []PostProcessor) string {
+	text, ok := sg.pipeline.Source.Lookup(name)
+	if !ok {
+		return ""
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	var inputs bytes.Buffer
+	fmt.Fprintf(&inputs, "%s\x00%s\x00%s\x00%T", name, text, encoded, sg.pipeline.Renderer)
+	for _, processor := range append(processors[:len(processors):len(processors)], sg.pipeline.PostProcessors...) {
+		fmt.Fprintf(&inputs, "\x00%#v", processor)
+	}
+	return Checksum(inputs.Bytes())
+}
+
+// unchangedFile returns the contents of a file whose inputs are those of the previous
+// generation and which was not changed since, so that it need not be rendered again
+func (sg *ServiceGenerator) unchangedFile(file renderedFile) ([]byte, bool) {
+	if sg.previous == nil || file.inputs == "" {
+		return nil, false
+	}
+	relPath, err := filepath.Rel(sg.hook.Dir, file.path)
+	if err != nil {
+		return nil, false
+	}
+	relPath = filepath.ToSlash(relPath)
+	recorded, tracked := sg.previous.Files[relPath]
+	if !tracked || sg.previous.Inputs[relPath] != file.inputs {
+		return nil, false
+	}
+	current, err := os.ReadFile(file.path)
+	if err != nil || Checksum(current) != recorded {
+		return nil, false
+	}
+	return current, true
+}
+
+// incrementalAction decides what an incremental generation does to the file at path, relative
+// to the project root, generated with content; the file is written for FileCreated,
+// FileUpdated and FileOverwritten only
+func (sg *ServiceGenerator) incrementalAction(relPath, path string, content []byte) (string, error) {
+	recorded, tracked := sg.previous.Files[relPath]
+	current, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && tracked && !sg.force:
+		return FileDeleted, nil
+	case os.IsNotExist(err):
+		return FileCreated, nil
+	case err != nil:
+		return "", err
+	case bytes.Equal(current, content) || Checksum(current) == Checksum(content):
+		return FileUnchanged, nil
+	case tracked && Checksum(current) == recorded:
+		return FileUpdated, nil
+	case sg.force:
+		return FileOverwritten, nil
+	}
+	return FileModified, nil
+}
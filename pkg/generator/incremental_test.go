@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIncrementalGeneration(t *testing.T) {
+	tests := []struct {
+		name  string
+		force bool
+		// edit changes the generated project before it is generated again
+		edit       func(t *testing.T, dir string)
+		wantAction map[string]string
+		// wantReadme is the README.md after the generation, "" for the generated one
+		wantReadme string
+	}{
+		{
+			name:       "unchanged",
+			edit:       func(t *testing.T, dir string) {},
+			wantAction: map[string]string{"README.md": FileUnchanged, "go.mod": FileUnchanged},
+		},
+		{
+			name:       "modified file is kept",
+			edit:       func(t *testing.T, dir string) { writeTree(t, dir, map[string]string{"README.md": "# edited\n"}) },
+			wantAction: map[string]string{"README.md": FileModified},
+			wantReadme: "# edited\n",
+		},
+		{
+			name:       "modified file is overwritten with force",
+			force:      true,
+			edit:       func(t *testing.T, dir string) { writeTree(t, dir, map[string]string{"README.md": "# edited\n"}) },
+			wantAction: map[string]string{"README.md": FileOverwritten},
+		},
+		{
+			name: "deleted file is not created again",
+			edit: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "README.md")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAction: map[string]string{"README.md": FileDeleted},
+			wantReadme: "-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "orders")
+			config := func() *GeneratorConfig {
+				return &GeneratorConfig{ServiceName: "orders", ServiceType: "rest", OutputDir: root}
+			}
+			if err := NewServiceGenerator(config()).GenerateService(); err != nil {
+				t.Fatalf("GenerateService() error = %v", err)
+			}
+			generated, err := os.ReadFile(filepath.Join(dir, "README.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(t, dir)
+
+			sg := NewServiceGenerator(config(), WithIncremental(tt.force))
+			if err := sg.GenerateService(); err != nil {
+				t.Fatalf("incremental GenerateService() error = %v", err)
+			}
+			actions := make(map[string]string)
+			for _, change := range sg.Changes() {
+				actions[change.Path] = change.Action
+			}
+			for path, want := range tt.wantAction {
+				if actions[path] != want {
+					t.Errorf("%s was %q, want %q", path, actions[path], want)
+				}
+			}
+
+			readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+			switch {
+			case tt.wantReadme == "-":
+				if !os.IsNotExist(err) {
+					t.Errorf("the deleted README.md was created again")
+				}
+			case tt.wantReadme == "" && string(readme) != string(generated):
+				t.Errorf("README.md = %q, want the generated one", readme)
+			case tt.wantReadme != "" && string(readme) != tt.wantReadme:
+				t.Errorf("README.md = %q, want %q", readme, tt.wantReadme)
+			}
+		})
+	}
+}
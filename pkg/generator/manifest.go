@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// ManifestFile records how a project was generated, relative to the project root
+	ManifestFile = ".microframework/manifest.json"
+	// BaseDir keeps the generated contents of each file, the common ancestor when merging
+	// a newer version of the templates with local changes
+	BaseDir = ".microframework/base"
+)
+
+// Manifest records the framework version and flags a project was generated with, and the
+// checksum of every generated file as it was written
+type Manifest struct {
+	FrameworkVersion string            `json:"framework_version"`
+	Config           GeneratorConfig   `json:"config"`
+	Files            map[string]string `json:"files"`
+	// Inputs are the checksums of what the files were rendered from, their template and its
+	// data, by which incremental generations skip the files whose inputs did not change
+	Inputs map[string]string `json:"inputs,omitempty"`
+}
+
+// Checksum returns the checksum recorded in manifests for content. Line endings do not count,
+// so that a generated file checked out with \r\n line endings, as Git does on Windows, is not
+// taken for a modified one.
+func Checksum(content []byte) string {
+	if bytes.Contains(content, []byte("\r\n")) {
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Paths returns the generated files in the manifest, sorted
+func (m *Manifest) Paths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for path := range m.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// LoadManifest reads the generation manifest of the project in dir
+func LoadManifest(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]string{}
+	}
+	if manifest.Inputs == nil {
+		manifest.Inputs = map[string]string{}
+	}
+	return &manifest, nil
+}
+
+// Save writes the manifest to the project in dir
+func (m *Manifest) Save(dir string) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, ManifestFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// ReadBase returns the generated contents of a file of the project in dir
+func ReadBase(dir, path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dir, BaseDir, filepath.FromSlash(path)))
+}
+
+// WriteBase records the generated contents of a file of the project in dir
+func WriteBase(dir, path string, content []byte) error {
+	basePath := filepath.Join(dir, BaseDir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(basePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(basePath), err)
+	}
+	return os.WriteFile(basePath, content, 0644)
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OIDCClient is the client of a service authenticating its users with OpenID Connect
+// (--with-auth oidc), which the Keycloak realm and the client registration of the project are
+// generated for
+type OIDCClient struct {
+	// RedirectURLs are the URLs the provider redirects back to after the login
+	RedirectURLs []string
+	// Scopes are the scopes the service requests, openid first
+	Scopes []string
+	// Roles are the realm roles the service authorizes its users with
+	Roles []string
+}
+
+// DefaultOIDCRedirectURL is the callback of a service running locally
+const DefaultOIDCRedirectURL = "http://localhost:8080/auth/callback"
+
+// standardOIDCScopes are the scopes of OpenID Connect and Keycloak every realm has; the others
+// are scopes of the service, which its realm declares
+var standardOIDCScopes = map[string]bool{"openid": true, "profile": true, "email": true, "roles": true}
+
+// NewOIDCClient returns the client of a service with the redirect URLs, scopes and roles, by
+// default the local callback, openid, profile and email, and user and admin. openid is always
+// requested.
+func NewOIDCClient(redirectURLs, scopes, roles []string) *OIDCClient {
+	client := &OIDCClient{RedirectURLs: uniqueStrings(redirectURLs), Roles: uniqueStrings(roles)}
+	if len(client.RedirectURLs) == 0 {
+		client.RedirectURLs = []string{DefaultOIDCRedirectURL}
+	}
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	client.Scopes = uniqueStrings(append([]string{"openid"}, scopes...))
+	if len(client.Roles) == 0 {
+		client.Roles = []string{"user", "admin"}
+	}
+	return client
+}
+
+// Validate reports the first redirect URL, scope or role the realm cannot be generated with
+func (c *OIDCClient) Validate() error {
+	for _, redirect := range c.RedirectURLs {
+		if u, err := url.Parse(redirect); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid OIDC redirect URL %q: an absolute URL", redirect)
+		}
+	}
+	for _, scope := range c.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\"\\") {
+			return fmt.Errorf("invalid OIDC scope %q", scope)
+		}
+	}
+	for _, role := range c.Roles {
+		if role == "" || strings.ContainsAny(role, "\"\\/") {
+			return fmt.Errorf("invalid OIDC role %q", role)
+		}
+	}
+	return nil
+}
+
+// ServiceScopes returns the scopes of the service, which are not standard ones
+func (c *OIDCClient) ServiceScopes() []string {
+	var scopes []string
+	for _, scope := range c.Scopes {
+		if !standardOIDCScopes[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// uniqueStrings returns the values that are not empty, without duplicates, in their order
+func uniqueStrings(values []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,27 @@
+package generator
+
+import "path/filepath"
+
+// Operation is an operation of the OpenAPI spec a service was imported from with
+// microframework new --from-openapi, which the API handler of the service serves. The
+// operations the CRUD routes of the entities serve are not among them.
+type Operation struct {
+	// Name is the name of the handler method, from the operationId: ListPets
+	Name string `json:"name"`
+	// Method is the HTTP method, in upper case
+	Method string `json:"method"`
+	// Path is the path template of the spec, as /pets/{petId}
+	Path string `json:"path"`
+	// Route is the gin route of the path, as /pets/:id, its wildcards named as those of the
+	// other routes at the same place
+	Route   string `json:"route"`
+	Summary string `json:"summary,omitempty"`
+	// Security are the security schemes of the operation
+	Security []string `json:"security,omitempty"`
+}
+
+// generateAPIHandlers generates the handler of the operations of the OpenAPI spec
+func (sg *ServiceGenerator) generateAPIHandlers() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "handlers", "api.go")
+	return sg.writeGoTemplate("internal/handlers/api.go", outputPath, sg.config)
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"runtime"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// Option configures a generator
+type Option func(*options)
+
+// options are the settings the generators share
+type options struct {
+	pipeline Pipeline
+	hooks    []Hooks
+	events   chan<- progress.Event
+	// progress reports the steps and files of the running generation; nil without WithProgress
+	progress *progress.Reporter
+	// overrides are the templates set with WithTemplate, which take precedence over the source
+	overrides map[string]string
+	// workers is how many steps of a generation render their files at once; 0 for GOMAXPROCS
+	workers int
+	// incremental regenerates the project from its generation manifest, keeping the files
+	// changed since they were generated unless force is set
+	incremental bool
+	force       bool
+	// validators check a staged project before it is moved into place, after GoParse
+	validators []Validator
+	// added are the files of WithFiles, by path relative to the project root
+	added map[string][]byte
+}
+
+// WithTemplates renders the templates of a registry instead of the built-in ones
+func WithTemplates(registry *templates.Registry) Option {
+	return WithSource(registry)
+}
+
+// WithSource renders the templates of a source instead of the built-in ones
+func WithSource(source Source) Option {
+	return func(o *options) {
+		o.pipeline.Source = source
+	}
+}
+
+// WithTemplate replaces the template of a name, one of templates.NewRegistry().Names()
+func WithTemplate(name, text string) Option {
+	return func(o *options) {
+		if o.overrides == nil {
+			o.overrides = make(map[string]string)
+		}
+		o.overrides[name] = text
+	}
+}
+
+// WithRenderer renders the templates with a renderer instead of a TemplateRenderer
+func WithRenderer(renderer Renderer) Option {
+	return func(o *options) {
+		o.pipeline.Renderer = renderer
+	}
+}
+
+// WithPostProcessors adds post-processors to the pipeline, which run in order on every
+// rendered file
+func WithPostProcessors(processors ...PostProcessor) Option {
+	return func(o *options) {
+		o.pipeline.PostProcessors = append(o.pipeline.PostProcessors, processors...)
+	}
+}
+
+// WithWriter writes the generated files with a writer instead of a FileWriter
+func WithWriter(writer Writer) Option {
+	return func(o *options) {
+		o.pipeline.Writer = writer
+	}
+}
+
+// WithProgress sends the progress of GenerateService, GenerateProtobuf and GenerateGraphQL
+// over events: each step started and completed, and each file written. The generation waits
+// for every event to be received.
+func WithProgress(events chan<- progress.Event) Option {
+	return func(o *options) {
+		o.events = events
+	}
+}
+
+// WithWorkers renders the files of up to n steps of a generation at once; 1 renders them one
+// step after the other. By default, as many steps render at once as GOMAXPROCS. The files are
+// written in the same order whatever n is, but the sources, renderers and post-processors of
+// the pipeline must be safe for concurrent use when n is not 1.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// WithFiles adds files that are not rendered from a template to the generated project, by
+// path relative to the project root with forward slashes, such as code compiled from the
+// sources the project is imported from. They are staged, validated and recorded in the
+// generation manifest like the rendered files.
+func WithFiles(files map[string][]byte) Option {
+	return func(o *options) {
+		if o.added == nil {
+			o.added = make(map[string][]byte)
+		}
+		for path, content := range files {
+			o.added[path] = content
+		}
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.pipeline.Source == nil {
+		o.pipeline.Source = templates.NewRegistry()
+	}
+	if len(o.overrides) > 0 {
+		o.pipeline.Source = overlaySource{overrides: o.overrides, base: o.pipeline.Source}
+	}
+	if o.pipeline.Renderer == nil {
+		o.pipeline.Renderer = defaultRenderer
+	}
+	if o.pipeline.Writer == nil {
+		o.pipeline.Writer = FileWriter{}
+	}
+	return o
+}
+
+// workerCount returns how many steps of a generation render their files at once
+func (o *options) workerCount() int {
+	if o.workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.workers
+}
+
+// overlaySource serves the templates set with WithTemplate over those of another source
+type overlaySource struct {
+	overrides map[string]string
+	base      Source
+}
+
+func (s overlaySource) Lookup(name string) (string, bool) {
+	if text, ok := s.overrides[name]; ok {
+		return text, true
+	}
+	return s.base.Lookup(name)
+}
@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// The generators produce each file through a pipeline of stages, each behind an interface
+// so that it can be replaced, chained or tested on its own:
+//
+//	Source → Renderer → PostProcessor... → Writer
+//
+// The Source provides the text of a template by name, the Renderer executes it with the
+// data of the file, the PostProcessors transform the result in order (formatting,
+// goimports, license headers, ...), and the Writer stores it.
+
+// Source provides the text of the templates by name. *templates.Registry is a Source.
+type Source interface {
+	Lookup(name string) (string, bool)
+}
+
+// Renderer executes the text of a template with the data of a file
+type Renderer interface {
+	Render(name, text string, data interface{}) ([]byte, error)
+}
+
+// StreamRenderer is a Renderer that executes a template straight to a writer, so that a large
+// file is not held in memory. TemplateRenderer is a StreamRenderer.
+type StreamRenderer interface {
+	Renderer
+	RenderTo(w io.Writer, name, text string, data interface{}) error
+}
+
+// PostProcessor transforms a rendered file. path is where the file is written, which
+// post-processors tell the kind of file by.
+type PostProcessor interface {
+	Process(path string, content []byte) ([]byte, error)
+}
+
+// PostProcessorFunc is a PostProcessor function
+type PostProcessorFunc func(path string, content []byte) ([]byte, error)
+
+// Process calls f
+func (f PostProcessorFunc) Process(path string, content []byte) ([]byte, error) {
+	return f(path, content)
+}
+
+// Writer stores a generated file
+type Writer interface {
+	Write(path string, content []byte) error
+}
+
+// StreamWriter is a Writer that creates the files for their contents to be streamed to. The
+// file is complete once the returned writer is closed. FileWriter is a StreamWriter.
+type StreamWriter interface {
+	Writer
+	Create(path string) (io.WriteCloser, error)
+}
+
+// WriterFunc is a Writer function
+type WriterFunc func(path string, content []byte) error
+
+// Write calls f
+func (f WriterFunc) Write(path string, content []byte) error {
+	return f(path, content)
+}
+
+// Pipeline is the stages a generator produces its files through
+type Pipeline struct {
+	Source         Source
+	Renderer       Renderer
+	PostProcessors []PostProcessor
+	Writer         Writer
+	// MaxFileSize is the largest file, in bytes, the pipeline renders, failing with a
+	// *FileSizeError beyond it; 0 for no limit
+	MaxFileSize int64
+}
+
+// Render renders the template of a name for the file at path, and post-processes it with
+// the processors given, then with those of the pipeline
+func (p *Pipeline) Render(name, path string, data interface{}, processors ...PostProcessor) ([]byte, error) {
+	text, ok := p.Source.Lookup(name)
+	if !ok {
+		return nil, &TemplateError{Template: name, Err: errors.New("not in the registry")}
+	}
+	var content []byte
+	if renderer, ok := p.Renderer.(StreamRenderer); ok && p.MaxFileSize > 0 {
+		// Stop rendering at the limit rather than once the whole file is in memory
+		var buf bytes.Buffer
+		if err := renderer.RenderTo(&limitWriter{w: &buf, path: path, limit: p.MaxFileSize}, name, text, data); err != nil {
+			return nil, err
+		}
+		content = buf.Bytes()
+	} else {
+		var err error
+		if content, err = p.Renderer.Render(name, text, data); err != nil {
+			return nil, err
+		}
+		if p.MaxFileSize > 0 && int64(len(content)) > p.MaxFileSize {
+			return nil, &FileSizeError{Path: path, Limit: p.MaxFileSize}
+		}
+	}
+	var err error
+	for _, processor := range append(processors[:len(processors):len(processors)], p.PostProcessors...) {
+		if content, err = processor.Process(path, content); err != nil {
+			return nil, fmt.Errorf("failed to post-process %s: %w", filepath.Base(path), err)
+		}
+	}
+	return content, nil
+}
+
+// Run renders the template of a name for the file at path and writes the file
+func (p *Pipeline) Run(name, path string, data interface{}, processors ...PostProcessor) error {
+	content, err := p.Render(name, path, data, processors...)
+	if err != nil {
+		return err
+	}
+	return p.Writer.Write(path, content)
+}
+
+// templateFuncs are the functions every template is parsed with, built-in or not
+var templateFuncs = template.FuncMap{
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"plural":    plural,
+	"secretRef": secretRef,
+	"add":       func(a, b int) int { return a + b },
+}
+
+// TemplateRenderer renders text/template templates, with the functions of the built-in
+// templates (upper, lower, plural, secretRef, add) and those of Funcs. A renderer made with
+// NewTemplateRenderer parses each template once and caches it, so that a template rendered
+// for many files, entities or services is not parsed again; the functions of Funcs must not
+// change once it renders. The zero TemplateRenderer parses the templates every time.
+type TemplateRenderer struct {
+	Funcs template.FuncMap
+	// parsed holds the parsed templates by templateKey; nil for no cache
+	parsed *sync.Map
+}
+
+// templateKey identifies a parsed template of a renderer
+type templateKey struct {
+	name, text string
+}
+
+// NewTemplateRenderer returns a renderer with the functions of funcs that caches the
+// templates it parses
+func NewTemplateRenderer(funcs template.FuncMap) TemplateRenderer {
+	return TemplateRenderer{Funcs: funcs, parsed: &sync.Map{}}
+}
+
+// defaultRenderer renders the templates of the generators without a renderer; the built-in
+// templates are parsed once per process
+var defaultRenderer = NewTemplateRenderer(nil)
+
+// Render parses and executes a template, failing with a *TemplateError
+func (r TemplateRenderer) Render(name, text string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.RenderTo(&buf, name, text, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderTo parses a template and executes it to w, failing with a *TemplateError
+func (r TemplateRenderer) RenderTo(w io.Writer, name, text string, data interface{}) error {
+	tmpl, err := r.parse(name, text)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return &TemplateError{Template: name, Err: err}
+	}
+	return nil
+}
+
+// Parse checks that a template parses, without executing it, failing with a *TemplateError.
+// Tools shipping templates check them with it before they are rendered.
+func (r TemplateRenderer) Parse(name, text string) error {
+	_, err := r.parse(name, text)
+	return err
+}
+
+// parse parses a template, or returns it from the cache of the renderer. The templates are
+// only executed once parsed, which is safe for concurrent use.
+func (r TemplateRenderer) parse(name, text string) (*template.Template, error) {
+	key := templateKey{name: name, text: text}
+	if r.parsed != nil {
+		if tmpl, ok := r.parsed.Load(key); ok {
+			return tmpl.(*template.Template), nil
+		}
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Funcs(r.Funcs).Parse(text)
+	if err != nil {
+		return nil, &TemplateError{Template: name, Err: err}
+	}
+	if r.parsed == nil {
+		return tmpl, nil
+	}
+	cached, _ := r.parsed.LoadOrStore(key, tmpl)
+	return cached.(*template.Template), nil
+}
+
+// FileWriter writes the files to disk, creating their directories
+type FileWriter struct{}
+
+// Write writes a file
+func (FileWriter) Write(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Create creates a file, buffering what is written to it until it is closed
+func (FileWriter) Create(path string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	return &bufferedFile{Writer: bufio.NewWriterSize(file, 64<<10), file: file}, nil
+}
+
+// bufferedFile is a file created by FileWriter, written through a buffer
+type bufferedFile struct {
+	*bufio.Writer
+	file *os.File
+}
+
+// Close flushes the buffer and closes the file
+func (f *bufferedFile) Close() error {
+	err := f.Flush()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PortedProject is a project of another framework (echo, go-kit, kratos) the service was
+// ported from with microframework import project: the endpoints its routes serve, which the
+// ported handler has stubs of, and its service interfaces, which the ported services
+// implement as stubs
+type PortedProject struct {
+	Framework string `json:"framework"`
+	// Source is the directory of the project
+	Source    string           `json:"source"`
+	Endpoints []PortedEndpoint `json:"endpoints,omitempty"`
+	Services  []PortedService  `json:"services,omitempty"`
+	// Imports are the packages the types of the service methods are from
+	Imports []string `json:"imports,omitempty"`
+}
+
+// PortedEndpoint is a route of a ported project, as the gin route of the ported handler. Its
+// Method is Any for a route of every method.
+type PortedEndpoint struct {
+	Operation
+	// Origin is where the route is registered, as file:line, and Handler the handler it
+	// registers there
+	Origin  string `json:"origin"`
+	Handler string `json:"handler,omitempty"`
+}
+
+// PortedService is a service interface of a ported project
+type PortedService struct {
+	Name    string         `json:"name"`
+	Origin  string         `json:"origin"`
+	Methods []PortedMethod `json:"methods"`
+}
+
+// PortedMethod is a method of a PortedService, with the Go types of its parameters and
+// results; those of the project are interface{} in the stubs
+type PortedMethod struct {
+	Name    string        `json:"name"`
+	Params  []PortedParam `json:"params,omitempty"`
+	Results []PortedParam `json:"results,omitempty"`
+}
+
+// PortedParam is a parameter or result of a PortedMethod
+type PortedParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ValidatePorted checks a ported project: that its endpoints and services have distinct Go
+// names and its routes a method gin registers
+func ValidatePorted(ported *PortedProject) error {
+	names := map[string]bool{"RegisterRoutes": true}
+	for _, endpoint := range ported.Endpoints {
+		if !entityNamePattern.MatchString(endpoint.Name) || names[endpoint.Name] {
+			return fmt.Errorf("endpoint %s %s: invalid or duplicate handler name %q", endpoint.Method, endpoint.Path, endpoint.Name)
+		}
+		names[endpoint.Name] = true
+		switch endpoint.Method {
+		case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "Any":
+		default:
+			return fmt.Errorf("endpoint %s %s: gin has no route method for %s", endpoint.Method, endpoint.Path, endpoint.Method)
+		}
+		if !strings.HasPrefix(endpoint.Route, "/") || strings.ContainsAny(endpoint.Route, "\"\\`") {
+			return fmt.Errorf("endpoint %s %s: invalid route %q", endpoint.Method, endpoint.Path, endpoint.Route)
+		}
+	}
+	services := make(map[string]bool)
+	for _, service := range ported.Services {
+		if !entityNamePattern.MatchString(service.Name) || services[service.Name] {
+			return fmt.Errorf("service %s: invalid or duplicate name", service.Name)
+		}
+		services[service.Name] = true
+		if len(service.Methods) == 0 {
+			return fmt.Errorf("service %s has no methods", service.Name)
+		}
+	}
+	return nil
+}
+
+// portedData is what the ported templates are rendered with
+type portedData struct {
+	*PortedProject
+	// Imports are the packages the services import, the standard library first
+	Imports []string
+}
+
+// portedServiceData returns the data of the ported services, which import errors when a
+// method returns an error
+func portedServiceData(ported *PortedProject) *portedData {
+	imports := append([]string{}, ported.Imports...)
+	for _, service := range ported.Services {
+		for _, method := range service.Methods {
+			for _, result := range method.Results {
+				if result.Type == "error" {
+					imports = append(imports, "errors")
+				}
+			}
+		}
+	}
+	sort.Strings(imports)
+	data := &portedData{PortedProject: ported}
+	for i, path := range imports {
+		if i == 0 || path != imports[i-1] {
+			data.Imports = append(data.Imports, path)
+		}
+	}
+	return data
+}
+
+// generatePorted generates the handler of the endpoints and the stubs of the services of
+// the ported project
+func (sg *ServiceGenerator) generatePorted() error {
+	ported := sg.config.Ported
+	projectDir := filepath.Join(sg.config.OutputDir, sg.config.ServiceName)
+	if len(ported.Endpoints) > 0 {
+		if err := sg.writeGoTemplate("ported/handler.go", filepath.Join(projectDir, "internal", "handlers", "ported.go"), ported); err != nil {
+			return err
+		}
+	}
+	if len(ported.Services) > 0 {
+		return sg.writeGoTemplate("ported/services.go", filepath.Join(projectDir, "internal", "services", "ported.go"), portedServiceData(ported))
+	}
+	return nil
+}
+
+// RenderPorted generates the files of the ported project of the configuration in memory and
+// returns them by path relative to the project root
+func (sg *ServiceGenerator) RenderPorted() (map[string][]byte, error) {
+	if sg.config.Ported == nil {
+		return nil, fmt.Errorf("no ported project in the configuration")
+	}
+	sg.render = true
+	defer func() { sg.render = false }()
+	sg.hook = sg.newHookContext()
+	if err := sg.generatePorted(); err != nil {
+		return nil, err
+	}
+	return sg.files, nil
+}
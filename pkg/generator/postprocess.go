@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+// GoFormat formats the Go files with gofmt, leaving the other files as they are
+type GoFormat struct{}
+
+// Process formats a Go file
+func (GoFormat) Process(path string, content []byte) ([]byte, error) {
+	if filepath.Ext(path) != ".go" {
+		return content, nil
+	}
+	return format.Source(content)
+}
+
+// GoImports adds the missing imports of the Go files and removes the unused ones, then
+// formats them, as goimports does
+type GoImports struct {
+	// LocalPrefix groups the imports starting with it after the third-party ones
+	LocalPrefix string
+}
+
+// Process runs goimports on a Go file
+func (g GoImports) Process(path string, content []byte) ([]byte, error) {
+	if filepath.Ext(path) != ".go" {
+		return content, nil
+	}
+	if g.LocalPrefix != "" {
+		// goimports reads the local prefix from a package variable
+		previous := imports.LocalPrefix
+		imports.LocalPrefix = g.LocalPrefix
+		defer func() { imports.LocalPrefix = previous }()
+	}
+	return imports.Process(path, content, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+}
+
+// licenseCommentPrefixes are the line comment prefixes of the kinds of file, by extension or
+// by base name for the files without one
+var licenseCommentPrefixes = map[string]string{
+	".go":        "//",
+	".proto":     "//",
+	".graphql":   "#",
+	".graphqls":  "#",
+	".yaml":      "#",
+	".yml":       "#",
+	".sh":        "#",
+	".example":   "#",
+	"Dockerfile": "#",
+	"Makefile":   "#",
+}
+
+// LicenseHeader adds a license header to the files that take comments, as line comments
+// above their content. Files with the header already, and kinds of file without line
+// comments (JSON, Markdown, go.mod), are left as they are.
+type LicenseHeader struct {
+	// Text is the header, without comment markers
+	Text string
+}
+
+// Process adds the header to a file
+func (h LicenseHeader) Process(path string, content []byte) ([]byte, error) {
+	prefix, ok := licenseCommentPrefixes[filepath.Ext(path)]
+	if !ok {
+		prefix, ok = licenseCommentPrefixes[filepath.Base(path)]
+	}
+	if !ok || strings.TrimSpace(h.Text) == "" {
+		return content, nil
+	}
+
+	var header bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(h.Text, "\n"), "\n") {
+		header.WriteString(strings.TrimRight(prefix+" "+line, " ") + "\n")
+	}
+	if bytes.Contains(content, header.Bytes()) {
+		return content, nil
+	}
+	header.WriteString("\n")
+
+	// A shebang stays the first line of a script
+	if bytes.HasPrefix(content, []byte("#!")) {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+		return append(append(append([]byte{}, content[:end]...), header.Bytes()...), content[end:]...), nil
+	}
+	return append(header.Bytes(), content...), nil
+}
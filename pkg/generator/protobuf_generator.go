@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// ProtobufConfig holds configuration for protobuf generation
+type ProtobufConfig struct {
+	ServiceName   string
+	PackageName   string
+	GRPCServices  []string
+	OutputPath    string
+	ForceGenerate bool
+}
+
+// ProtobufGenerator handles the generation of protobuf files
+type ProtobufGenerator struct {
+	options
+	config *ProtobufConfig
+	// hook is the hook context of the running generation
+	hook *HookContext
+}
+
+// NewProtobufGenerator creates a new protobuf generator
+func NewProtobufGenerator(config *ProtobufConfig, opts ...Option) *ProtobufGenerator {
+	return &ProtobufGenerator{
+		options: newOptions(opts),
+		config:  config,
+	}
+}
+
+// Validate reports the first field of the configuration that cannot be generated, as a
+// *ConfigError
+func (c *ProtobufConfig) Validate() error {
+	if err := validateName("ServiceName", c.ServiceName); err != nil {
+		return err
+	}
+	for _, service := range c.GRPCServices {
+		if err := validateName("GRPCServices", service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateProtobuf generates protobuf files for gRPC services. The package is named after
+// the service when PackageName is empty.
+func (pg *ProtobufGenerator) GenerateProtobuf() error {
+	if err := pg.config.Validate(); err != nil {
+		return err
+	}
+	if pg.config.PackageName == "" {
+		pg.config.PackageName = strings.ReplaceAll(pg.config.ServiceName, "-", "_")
+	}
+
+	pg.hook = &HookContext{Generator: "protobuf", Name: pg.config.ServiceName, Dir: pg.config.OutputPath}
+	if err := pg.runBeforeHooks(pg.hook); err != nil {
+		return err
+	}
+
+	// Create protobuf directory
+	protobufDir := filepath.Join(pg.config.OutputPath, "protobuf")
+	if err := os.MkdirAll(protobufDir, 0755); err != nil {
+		return fmt.Errorf("failed to create protobuf directory: %w", err)
+	}
+	pg.progress = progress.NewReporter(pg.events, "generate", len(pg.config.GRPCServices)+1)
+	defer func() { pg.progress = nil }()
+
+	// Generate protobuf files for each service
+	for _, serviceName := range pg.config.GRPCServices {
+		err := pg.progress.Step("protobuf "+serviceName, func() error {
+			return pg.generateServiceProtobuf(serviceName, protobufDir)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate protobuf for service %s: %w", serviceName, err)
+		}
+	}
+
+	// Generate main protobuf file
+	err := pg.progress.Step("main protobuf", func() error {
+		return pg.generateMainProtobuf(protobufDir)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate main protobuf file: %w", err)
+	}
+
+	return pg.runAfterHooks(pg.hook)
+}
+
+// generateServiceProtobuf generates a protobuf file for a specific service
+func (pg *ProtobufGenerator) generateServiceProtobuf(serviceName, protobufDir string) error {
+	// Create service-specific protobuf file
+	fileName := strings.ToLower(serviceName) + ".proto"
+	filePath := filepath.Join(protobufDir, fileName)
+
+	// Check if file exists and force is not set
+	if !pg.config.ForceGenerate {
+		if _, err := os.Stat(filePath); err == nil {
+			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
+		}
+	}
+
+	// Create template data
+	data := map[string]interface{}{
+		"ServiceName":      serviceName,
+		"PackageName":      pg.config.PackageName,
+		"ServiceNameLower": strings.ToLower(serviceName),
+	}
+
+	// Render and write file
+	return pg.runHooked(pg.hook, "protobuf/service.proto", filePath, data)
+}
+
+// generateMainProtobuf generates the main protobuf file
+func (pg *ProtobufGenerator) generateMainProtobuf(protobufDir string) error {
+	// Create main protobuf file
+	fileName := pg.config.ServiceName + ".proto"
+	filePath := filepath.Join(protobufDir, fileName)
+
+	// Check if file exists and force is not set
+	if !pg.config.ForceGenerate {
+		if _, err := os.Stat(filePath); err == nil {
+			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
+		}
+	}
+
+	// Create template data
+	data := map[string]interface{}{
+		"ServiceName":  pg.config.ServiceName,
+		"PackageName":  pg.config.PackageName,
+		"GRPCServices": pg.config.GRPCServices,
+	}
+
+	// Render and write file
+	return pg.runHooked(pg.hook, "protobuf/main.proto", filePath, data)
+}
@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// PulumiReleases are the Helm charts the Pulumi program installs for the providers of the
+// configuration, by provider
+var PulumiReleases = map[string]string{
+	"postgresql": "oci://registry-1.docker.io/bitnamicharts/postgresql",
+	"mysql":      "oci://registry-1.docker.io/bitnamicharts/mysql",
+	"mongodb":    "oci://registry-1.docker.io/bitnamicharts/mongodb",
+	"redis":      "oci://registry-1.docker.io/bitnamicharts/redis",
+	"kafka":      "oci://registry-1.docker.io/bitnamicharts/kafka",
+	"rabbitmq":   "oci://registry-1.docker.io/bitnamicharts/rabbitmq",
+	"nats":       "oci://registry-1.docker.io/bitnamicharts/nats",
+}
+
+// PulumiConfig holds configuration for the generation of the Pulumi program of a service
+type PulumiConfig struct {
+	ServiceName string
+	// Language is the language of the program; go is the one generated
+	Language string
+	// Database is the database of the service, postgresql, mysql or mongodb, empty when it
+	// has none
+	Database string
+	// Redis is set when the service uses a redis database, Cache when it caches in redis
+	Redis, Cache bool
+	// Messaging are the brokers of the queues of the service: kafka, rabbitmq or nats
+	Messaging []string
+	// Stacks are the stacks of the program, one per environment the service is deployed to
+	Stacks        []PulumiStack
+	OutputPath    string
+	ForceGenerate bool
+}
+
+// PulumiStack is a stack of the Pulumi program: the configuration of an environment
+type PulumiStack struct {
+	Name string
+	// Context and Namespace are where the resources of the stack are created; the current
+	// context and the default namespace when empty
+	Context, Namespace string
+	Image              string
+	Replicas, Port     int
+}
+
+// PulumiGenerator generates the Pulumi program provisioning the infrastructure of a service:
+// its deployment and service in the cluster, and the database, cache and queues it uses
+type PulumiGenerator struct {
+	options
+	config *PulumiConfig
+	// hook is the hook context of the running generation
+	hook *HookContext
+}
+
+// NewPulumiGenerator creates a new Pulumi generator
+func NewPulumiGenerator(config *PulumiConfig, opts ...Option) *PulumiGenerator {
+	return &PulumiGenerator{
+		options: newOptions(opts),
+		config:  config,
+	}
+}
+
+// Validate reports the first field of the configuration that cannot be generated, as a
+// *ConfigError
+func (c *PulumiConfig) Validate() error {
+	if err := validateName("ServiceName", c.ServiceName); err != nil {
+		return err
+	}
+	if c.Language != "go" {
+		return &ConfigError{Field: "Language", Reason: fmt.Sprintf("%q is not supported; the program is generated in go", c.Language)}
+	}
+	switch c.Database {
+	case "", "postgresql", "mysql", "mongodb":
+	default:
+		return &ConfigError{Field: "Database", Reason: fmt.Sprintf("%q is not postgresql, mysql or mongodb", c.Database)}
+	}
+	for _, provider := range c.Messaging {
+		if provider != "kafka" && provider != "rabbitmq" && provider != "nats" {
+			return &ConfigError{Field: "Messaging", Reason: fmt.Sprintf("%q is not kafka, rabbitmq or nats", provider)}
+		}
+	}
+	if len(c.Stacks) == 0 {
+		return &ConfigError{Field: "Stacks", Reason: "the program has no stack"}
+	}
+	for _, stack := range c.Stacks {
+		if err := validateName("Stacks", stack.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Releases returns the providers the program installs a release of, the cache being in the
+// redis of the database
+func (c *PulumiConfig) Releases() []string {
+	var releases []string
+	if c.Database != "" {
+		releases = append(releases, c.Database)
+	}
+	if c.Redis || c.Cache {
+		releases = append(releases, "redis")
+	}
+	return append(releases, c.Messaging...)
+}
+
+// Secrets returns the secret configuration of the stacks: the passwords of the releases,
+// set with pulumi config set --secret
+func (c *PulumiConfig) Secrets() []string {
+	var secrets []string
+	for _, release := range c.Releases() {
+		switch release {
+		case "postgresql", "mysql", "mongodb":
+			secrets = append(secrets, "databasePassword")
+		case "redis":
+			secrets = append(secrets, "redisPassword")
+		case "rabbitmq":
+			secrets = append(secrets, "rabbitmqPassword")
+		}
+	}
+	return secrets
+}
+
+// pulumiData is what the Pulumi templates are rendered with
+type pulumiData struct {
+	*PulumiConfig
+	// Stack is the stack of a stack file
+	Stack PulumiStack
+	// DatabaseName is the name and user of the database, the service name in snake case
+	DatabaseName string
+}
+
+// Chart returns the chart of the release of provider
+func (d *pulumiData) Chart(provider string) string {
+	return PulumiReleases[provider]
+}
+
+// Has reports whether the program installs the release of provider
+func (d *pulumiData) Has(provider string) bool {
+	for _, release := range d.Releases() {
+		if release == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// GeneratePulumi generates the Pulumi program of the configuration into deployments/pulumi.
+// The stack files are written once, as they hold the secrets of the stacks, and only replaced
+// with ForceGenerate; the program is generated again from the configuration every time.
+func (pg *PulumiGenerator) GeneratePulumi() error {
+	if err := pg.config.Validate(); err != nil {
+		return err
+	}
+
+	pg.hook = &HookContext{Generator: "pulumi", Name: pg.config.ServiceName, Dir: pg.config.OutputPath}
+	if err := pg.runBeforeHooks(pg.hook); err != nil {
+		return err
+	}
+
+	pulumiDir := filepath.Join(pg.config.OutputPath, "deployments", "pulumi")
+	if err := os.MkdirAll(pulumiDir, 0755); err != nil {
+		return fmt.Errorf("failed to create pulumi directory: %w", err)
+	}
+
+	data := &pulumiData{
+		PulumiConfig: pg.config,
+		DatabaseName: strings.ReplaceAll(pg.config.ServiceName, "-", "_"),
+	}
+	type pulumiFile struct{ step, template, file string }
+	files := []pulumiFile{
+		{"project", "pulumi/Pulumi.yaml", "Pulumi.yaml"},
+		{"module", "pulumi/go.mod", "go.mod"},
+		{"program", "pulumi/main.go", "main.go"},
+	}
+
+	pg.progress = progress.NewReporter(pg.events, "generate", len(files)+len(pg.config.Stacks))
+	defer func() { pg.progress = nil }()
+
+	for _, file := range files {
+		err := pg.progress.Step(file.step, func() error {
+			return pg.runHooked(pg.hook, file.template, filepath.Join(pulumiDir, file.file), data, GoFormat{})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", file.file, err)
+		}
+	}
+	for _, stack := range pg.config.Stacks {
+		file := "Pulumi." + stack.Name + ".yaml"
+		path := filepath.Join(pulumiDir, file)
+		err := pg.progress.Step("stack "+stack.Name, func() error {
+			if _, err := os.Stat(path); err == nil && !pg.config.ForceGenerate {
+				return nil
+			}
+			stackData := *data
+			stackData.Stack = stack
+			return pg.runHooked(pg.hook, "pulumi/stack.yaml", path, &stackData)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", file, err)
+		}
+	}
+
+	return pg.runAfterHooks(pg.hook)
+}
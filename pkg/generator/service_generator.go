@@ -0,0 +1,1067 @@
+// Package generator generates microservice projects, protobuf files and GraphQL schemas
+// from the templates microframework new and generate use, so that other tools and internal
+// platforms can embed the generation. A ServiceGenerator writes a project, with the
+// manifest microframework update and upgrade-project rely on, or renders its files in
+// memory:
+//
+//	config := &generator.GeneratorConfig{ServiceName: "orders", ServiceType: "rest", WithDatabase: true, DatabaseProvider: "postgres"}
+//	files, err := generator.NewServiceGenerator(config).RenderService()
+//
+// The templates come from a templates.Registry; options replace them:
+//
+//	gen := generator.NewServiceGenerator(config, generator.WithTemplate("README.md", readme))
+//
+// Each file goes through a Pipeline, Source → Renderer → PostProcessor... → Writer, whose
+// stages options replace or extend:
+//
+//	gen := generator.NewServiceGenerator(config, generator.WithPostProcessors(generator.GoImports{}, generator.LicenseHeader{Text: header}))
+//
+// Hooks set with WithHooks run before and after a generation and on every file, and
+// WithProgress streams its steps and files as progress.Events.
+//
+// Invalid configurations fail with a *ConfigError, matching ErrInvalidConfig, and templates
+// that do not parse or render with a *TemplateError.
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anasamu/go-micro-framework/pkg/generator/templates"
+	"github.com/anasamu/go-micro-framework/pkg/progress"
+)
+
+// secretRef returns the reference to the secret key of a service in a secrets backend
+func secretRef(provider, service, key string) string {
+	switch provider {
+	case "ssm":
+		return fmt.Sprintf("ssm:///%s/%s", service, key)
+	case "gsm":
+		return fmt.Sprintf("gsm://%s-%s", service, key)
+	default:
+		return fmt.Sprintf("vault://secret/%s#%s", service, key)
+	}
+}
+
+// DefaultVersion is the version of the services generated without one
+const DefaultVersion = "1.0.0"
+
+// ServiceGenerator handles the generation of microservice projects
+type ServiceGenerator struct {
+	options
+	config *GeneratorConfig
+	// files holds the generated contents by path relative to the project root; nil for the
+	// streamed files
+	files map[string][]byte
+	// streamed holds the checksums of the files streamed to disk, by path
+	streamed map[string]string
+	// render keeps the generated files in memory instead of writing them
+	render bool
+	// hook is the hook context of the running generation
+	hook *HookContext
+	// pending collects the files of a step rendered concurrently with the others, which
+	// generateFiles writes once every step is rendered; nil when the files are written as
+	// they are rendered
+	pending *[]renderedFile
+	// inputs holds the checksums of what the generated files were rendered from, by path
+	inputs map[string]string
+	// previous is the manifest an incremental generation starts from; nil otherwise
+	previous *Manifest
+	// changes holds what an incremental generation did to each file, by path
+	changes map[string]string
+	// staging is the directory the project is written to until it is moved into place; ""
+	// when the files are written in place
+	staging string
+	// copied holds the files copied into the staging directory from the project, which are
+	// not moved back
+	copied map[string]bool
+}
+
+// GeneratorConfig holds configuration for service generation
+type GeneratorConfig struct {
+	ServiceName string
+	ServiceType string
+	// Version is the version of the service in its configuration, and the tag its container
+	// image is built and deployed with; DefaultVersion when unset
+	Version            string `json:",omitempty"`
+	WithAuth           bool
+	WithDatabase       bool
+	WithMessaging      bool
+	WithMonitoring     bool
+	WithAI             bool
+	WithStorage        bool
+	WithCache          bool
+	WithDiscovery      bool
+	WithCircuitBreaker bool
+	WithRateLimit      bool
+	WithChaos          bool
+	WithFailover       bool
+	WithEvent          bool
+	WithScheduling     bool
+	WithBackup         bool
+	WithPayment        bool
+	WithFileGen        bool
+	WithAPI            bool
+	WithEmail          bool
+	WithFeatureFlags   bool
+	WithErrorTracking  bool
+	OutputDir          string `json:"-"`
+	// Provider specifications
+	AuthProvider         string
+	DatabaseProvider     string
+	MessagingProvider    string
+	MonitoringProvider   string
+	AIProvider           string
+	StorageProvider      string
+	CacheProvider        string
+	DiscoveryProvider    string
+	PaymentProvider      string
+	APIProvider          string
+	EmailProvider        string
+	FeatureFlagsProvider string
+	// ErrorTrackingProvider is the service the errors and panics are reported to, sentry or
+	// bugsnag
+	ErrorTrackingProvider string
+	// OpenFeatureProvider is the provider the services generated with --with-featureflags
+	// openfeature evaluate their flags with: env, file, flagd or launchdarkly
+	OpenFeatureProvider string
+	// OIDC is the client of the services authenticating their users with OpenID Connect,
+	// which the Keycloak realm and client registration of deployments/oidc are generated for
+	OIDC *OIDCClient `json:",omitempty"`
+	// SecretsProvider is the secrets backend (vault, ssm or gsm) the production
+	// configuration references its secrets in, instead of environment variables
+	SecretsProvider string
+	// MainPackage is the main package of the service, when it is not cmd/main.go
+	MainPackage string
+	// Adopted marks a project taken over by microframework init rather than generated: only
+	// the files init scaffolded belong to the templates, and its tests check the running
+	// service instead of the generated handlers
+	Adopted bool
+	// Entities are the domain entities designed with microframework scaffold entity, which the
+	// CRUD layers, protobuf messages and GraphQL types of the service are generated from
+	Entities []Entity `json:",omitempty"`
+	// Tables are the tables of an existing database imported with microframework import db,
+	// which the models and repositories of the service map
+	Tables []Table `json:",omitempty"`
+	// OpenAPISpec is the OpenAPI spec in the project, as api/openapi.yaml, the service was
+	// imported from with microframework new --from-openapi
+	OpenAPISpec string `json:",omitempty"`
+	// Operations are the operations of OpenAPISpec the API handler of the service serves
+	Operations []Operation `json:",omitempty"`
+	// GRPCServices are the gRPC services of the .proto files the service was imported from
+	// with microframework new --from-proto, whose servers main.go serves
+	GRPCServices []GRPCService `json:",omitempty"`
+	// Ported is the project of another framework the service was ported from with
+	// microframework import project, whose endpoints and services it has stubs of
+	Ported *PortedProject `json:",omitempty"`
+	// Owner and System are the owner and system of the service in the Backstage catalog, as
+	// catalog-info.yaml registers it
+	Owner  string `json:",omitempty"`
+	System string `json:",omitempty"`
+	// TemplatePack is the template pack, as name@version, the templates were taken from
+	// instead of the built-in ones; the generators render the templates of their options, it
+	// is recorded so that the project keeps being generated from the same pack
+	TemplatePack string `json:",omitempty"`
+	// FrameworkVersion is recorded in the generation manifest
+	FrameworkVersion string `json:"-"`
+}
+
+// NewServiceGenerator creates a new service generator
+func NewServiceGenerator(config *GeneratorConfig, opts ...Option) *ServiceGenerator {
+	if config.Version == "" {
+		config.Version = DefaultVersion
+	}
+	return &ServiceGenerator{
+		options:  newOptions(opts),
+		config:   config,
+		files:    make(map[string][]byte),
+		streamed: make(map[string]string),
+		inputs:   make(map[string]string),
+	}
+}
+
+// Validate reports the first field of the configuration that cannot be generated, as a
+// *ConfigError
+func (c *GeneratorConfig) Validate() error {
+	if err := validateName("ServiceName", c.ServiceName); err != nil {
+		return err
+	}
+	if err := ValidateEntities(c.Entities); err != nil {
+		return &ConfigError{Field: "Entities", Reason: err.Error()}
+	}
+	if err := ValidateTables(c.Tables, c.Entities); err != nil {
+		return &ConfigError{Field: "Tables", Reason: err.Error()}
+	}
+	if c.Ported != nil {
+		if err := ValidatePorted(c.Ported); err != nil {
+			return &ConfigError{Field: "Ported", Reason: err.Error()}
+		}
+	}
+	if c.OIDC != nil {
+		if err := c.OIDC.Validate(); err != nil {
+			return &ConfigError{Field: "OIDC", Reason: err.Error()}
+		}
+	}
+	if c.MainPackage != "" {
+		if err := validatePackagePath("MainPackage", c.MainPackage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePackagePath checks the path of a package in the project, as ./cmd/orders or
+// cmd/orders, which files are generated in: it must stay inside the project
+func validatePackagePath(field, dir string) error {
+	clean := strings.TrimPrefix(dir, "./")
+	switch {
+	case strings.ContainsAny(dir, `\:`) || path.IsAbs(dir) || filepath.IsAbs(dir) || filepath.VolumeName(dir) != "":
+		return &ConfigError{Field: field, Reason: fmt.Sprintf("%q is not a path relative to the project", dir)}
+	case clean == "" || path.Clean(clean) != clean:
+		return &ConfigError{Field: field, Reason: fmt.Sprintf("%q is not a clean path", dir)}
+	case clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, "/../") || strings.HasSuffix(clean, "/.."):
+		return &ConfigError{Field: field, Reason: fmt.Sprintf("%q is outside the project", dir)}
+	}
+	return nil
+}
+
+// validateName checks a name that files and directories are named after
+func validateName(field, name string) error {
+	switch {
+	case name == "":
+		return &ConfigError{Field: field, Reason: "is empty"}
+	case name == "." || name == ".." || strings.ContainsAny(name, `/\`):
+		return &ConfigError{Field: field, Reason: fmt.Sprintf("%q is not a file name", name)}
+	}
+	return nil
+}
+
+// RenderService generates the project files in memory and returns them by path relative to
+// the project root
+func (sg *ServiceGenerator) RenderService() (map[string][]byte, error) {
+	if err := sg.config.Validate(); err != nil {
+		return nil, err
+	}
+	sg.render = true
+	defer func() { sg.render = false }()
+	sg.hook = sg.newHookContext()
+
+	if err := sg.generateFiles(); err != nil {
+		return nil, err
+	}
+	return sg.files, nil
+}
+
+// GenerateService generates a complete microservice project. The project is written to a
+// staging directory next to it, checked with GoParse and the validators of WithValidators,
+// and only then moved into place, so that a generation that fails leaves the project
+// directory as it was.
+func (sg *ServiceGenerator) GenerateService() error {
+	if err := sg.config.Validate(); err != nil {
+		return err
+	}
+	sg.hook = sg.newHookContext()
+	if err := sg.runBeforeHooks(sg.hook); err != nil {
+		return err
+	}
+	if err := sg.startStaging(); err != nil {
+		return err
+	}
+	defer sg.stopStaging()
+	// The project structure and the manifest are steps too, and so are the validation and the
+	// move of a staged project
+	steps := len(sg.generationSteps()) + 2
+	if sg.staging != "" {
+		steps += 2
+	}
+	sg.progress = progress.NewReporter(sg.events, "generate", steps)
+	defer func() { sg.progress = nil }()
+
+	if sg.incremental {
+		if err := sg.loadPrevious(); err != nil {
+			return fmt.Errorf("failed to read the generation manifest: %w", err)
+		}
+		defer func() { sg.previous = nil }()
+	}
+
+	// Create project directory structure
+	if err := sg.progress.Step("project structure", sg.createProjectStructure); err != nil {
+		return fmt.Errorf("failed to create project structure: %w", err)
+	}
+
+	if err := sg.generateFiles(); err != nil {
+		return err
+	}
+
+	// Record how the project was generated, so that it can be updated to newer templates
+	if err := sg.progress.Step("generation manifest", sg.writeManifest); err != nil {
+		return fmt.Errorf("failed to write generation manifest: %w", err)
+	}
+
+	if sg.staging != "" {
+		if err := sg.progress.Step("validation", sg.validateStaged); err != nil {
+			return fmt.Errorf("the generated project is invalid: %w", err)
+		}
+		if err := sg.progress.Step("move into place", sg.commitStaged); err != nil {
+			return fmt.Errorf("failed to move the generated project into place: %w", err)
+		}
+	}
+
+	for path := range sg.files {
+		sg.hook.Files = append(sg.hook.Files, path)
+	}
+	return sg.runAfterHooks(sg.hook)
+}
+
+// newHookContext returns the hook context of a generation of the project
+func (sg *ServiceGenerator) newHookContext() *HookContext {
+	return &HookContext{
+		Generator: "service",
+		Name:      sg.config.ServiceName,
+		Dir:       filepath.Join(sg.config.OutputDir, sg.config.ServiceName),
+	}
+}
+
+// writeManifest writes the generation manifest and the generated contents of every file. The
+// files an incremental generation kept keep the record of the previous generation, so that
+// updating the templates merges the changes made to them.
+func (sg *ServiceGenerator) writeManifest() error {
+	projectDir := sg.target(filepath.Join(sg.config.OutputDir, sg.config.ServiceName))
+	manifest := &Manifest{
+		FrameworkVersion: sg.config.FrameworkVersion,
+		Config:           *sg.config,
+		Files:            make(map[string]string),
+		Inputs:           make(map[string]string),
+	}
+
+	for path, content := range sg.files {
+		if action := sg.changes[path]; action == FileModified || action == FileDeleted {
+			if recorded, ok := sg.previous.Files[path]; ok {
+				manifest.Files[path] = recorded
+			}
+			continue
+		}
+		if inputs := sg.inputs[path]; inputs != "" {
+			manifest.Inputs[path] = inputs
+		}
+		if checksum, ok := sg.streamed[path]; ok {
+			// The generated contents of the streamed files were written with them
+			manifest.Files[path] = checksum
+			continue
+		}
+		manifest.Files[path] = Checksum(content)
+		if err := WriteBase(projectDir, path, content); err != nil {
+			return err
+		}
+	}
+	return manifest.Save(projectDir)
+}
+
+// generationStep is a step of the generation of a project, writing some of its files. It
+// runs on the generator it is given, which may be a copy rendering the step's files.
+type generationStep struct {
+	name string
+	run  func(*ServiceGenerator) error
+}
+
+// renderedFile is a file a step rendered, waiting to be written
+type renderedFile struct {
+	path    string
+	content []byte
+	// inputs is the checksum of what the file was rendered from
+	inputs string
+	// unchanged is set for the files of an incremental generation that were not rendered
+	// again, content being that of the file
+	unchanged bool
+	// streamed is set for the files streamed to disk already, whose content is not kept
+	streamed *streamedFile
+	// elapsed is how long the file took to stream, for the streamed files
+	elapsed time.Duration
+}
+
+// generationSteps returns the steps generating the files of the project, in order
+func (sg *ServiceGenerator) generationSteps() []generationStep {
+	steps := []generationStep{
+		{"main.go", (*ServiceGenerator).generateMain},
+		{"go.mod", (*ServiceGenerator).generateGoMod},
+		{"configuration", (*ServiceGenerator).generateConfig},
+		{"handlers", (*ServiceGenerator).generateHandlers},
+		{"models", (*ServiceGenerator).generateModels},
+		{"repositories", (*ServiceGenerator).generateRepositories},
+		{"services", (*ServiceGenerator).generateServices},
+		{"middleware", (*ServiceGenerator).generateMiddleware},
+		{"utils", (*ServiceGenerator).generateUtils},
+		{".env.example", (*ServiceGenerator).generateEnvExample},
+		{".gitattributes", (*ServiceGenerator).generateGitAttributes},
+		{"Docker files", (*ServiceGenerator).generateDocker},
+		{"Kubernetes manifests", (*ServiceGenerator).generateKubernetes},
+		{"deployment environments", (*ServiceGenerator).generateEnvironments},
+		{"tests", (*ServiceGenerator).generateTests},
+		{"documentation", (*ServiceGenerator).generateDocumentation},
+		{"catalog-info.yaml", (*ServiceGenerator).generateCatalogInfo},
+	}
+
+	// The files of the designed entities
+	for _, entity := range sg.config.Entities {
+		entity := entity
+		steps = append(steps, generationStep{"entity " + entity.Name, func(g *ServiceGenerator) error { return g.generateEntity(entity) }})
+	}
+
+	// The models and repositories of the imported tables
+	for _, table := range sg.config.Tables {
+		table := table
+		steps = append(steps, generationStep{"table " + table.Name, func(g *ServiceGenerator) error { return g.generateTable(table) }})
+	}
+
+	// The handler of the operations of the OpenAPI spec the service was imported from
+	if len(sg.config.Operations) > 0 {
+		steps = append(steps, generationStep{"API handlers", (*ServiceGenerator).generateAPIHandlers})
+	}
+
+	// The stubs of the endpoints and services of the project the service was ported from
+	if sg.config.Ported != nil {
+		steps = append(steps, generationStep{"ported project", (*ServiceGenerator).generatePorted})
+	}
+
+	// The servers of the gRPC services of the .proto files the service was imported from
+	if len(sg.config.GRPCServices) > 0 {
+		steps = append(steps, generationStep{"gRPC servers", (*ServiceGenerator).generateGRPCServers})
+	}
+
+	// The files added with WithFiles
+	if len(sg.added) > 0 {
+		steps = append(steps, generationStep{"added files", (*ServiceGenerator).generateAddedFiles})
+	}
+
+	// The SDK initialization and panic reporting of the services tracking their errors
+	if sg.config.WithErrorTracking {
+		steps = append(steps, generationStep{"error tracking", (*ServiceGenerator).generateErrorTracking})
+	}
+
+	// The workflows, activities and worker of the Temporal workers
+	if sg.config.ServiceType == "temporal-worker" {
+		steps = append(steps, generationStep{"Temporal worker", (*ServiceGenerator).generateTemporalWorker})
+	}
+
+	// The featureflags package of the services evaluating their flags with it
+	if vendorsFeatureFlags(sg.config) {
+		steps = append(steps, generationStep{"featureflags package", (*ServiceGenerator).generateFeatureFlagsPackage})
+	}
+
+	// The OpenFeature provider, typed flags and targeting middleware of the services
+	// evaluating their flags with OpenFeature
+	if sg.config.OpenFeatureProvider != "" {
+		steps = append(steps, generationStep{"OpenFeature flags", (*ServiceGenerator).generateOpenFeature})
+	}
+
+	// The Keycloak realm and client registration of the services authenticating with OIDC
+	if sg.config.OIDC != nil {
+		steps = append(steps, generationStep{"OIDC client", (*ServiceGenerator).generateOIDC})
+	}
+
+	// The collector, dashboards and provisioning of the monitored services
+	if sg.config.WithMonitoring {
+		steps = append(steps, generationStep{"observability bundle", (*ServiceGenerator).generateObservability})
+	}
+
+	// Initial migration if database is enabled
+	if sg.config.WithDatabase {
+		steps = append(steps, generationStep{"initial migration", (*ServiceGenerator).generateInitialMigration})
+	}
+	return steps
+}
+
+// generateFiles generates every file of the project. The steps render their files
+// concurrently, up to the workers of WithWorkers at once. The files that stream, when the
+// generator has no file hooks and neither renders in memory nor regenerates incrementally,
+// are written by the workers as they render them; the others are held until every step is
+// rendered and then written step by step, in the order of the steps, where the file hooks
+// run. Either way the files are recorded and reported to the progress in the order of the
+// steps. A step failing does not stop the others, which still write their files, unlike the
+// sequential generation that stopped at the first failure: the failures of every step are
+// returned together.
+func (sg *ServiceGenerator) generateFiles() error {
+	steps := sg.generationSteps()
+	rendered := make([][]renderedFile, len(steps))
+	renderErrs := make([]error, len(steps))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(sg.workerCount(), len(steps)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				// The copy only collects the files of the step, it neither records nor writes them
+				step := *sg
+				step.pending = &rendered[index]
+				renderErrs[index] = steps[index].run(&step)
+			}
+		}()
+	}
+	for index := range steps {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	var errs []error
+	for index, step := range steps {
+		err := sg.progress.Step(step.name, func() error {
+			if renderErrs[index] != nil {
+				return renderErrs[index]
+			}
+			for _, file := range rendered[index] {
+				if err := sg.writeFile(file); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to generate %s: %w", step.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RenderEntity generates the files of an entity of the configuration in memory and returns
+// them by path relative to the project root
+func (sg *ServiceGenerator) RenderEntity(name string) (map[string][]byte, error) {
+	sg.render = true
+	defer func() { sg.render = false }()
+	sg.hook = sg.newHookContext()
+
+	for _, entity := range sg.config.Entities {
+		if entity.Name == name {
+			if err := sg.generateEntity(entity); err != nil {
+				return nil, err
+			}
+			return sg.files, nil
+		}
+	}
+	return nil, fmt.Errorf("no entity %s in the configuration", name)
+}
+
+// generateEntity generates the model, repository, service and handler of an entity, and its
+// protobuf messages or GraphQL types for the services of those types
+func (sg *ServiceGenerator) generateEntity(entity Entity) error {
+	data := newEntityData(sg.config, entity)
+	projectDir := filepath.Join(sg.config.OutputDir, sg.config.ServiceName)
+
+	goFiles := []struct {
+		template, dir, suffix string
+	}{
+		{"entity/model.go", "models", ""},
+		{"entity/repository.go", "repositories", "_repository"},
+		{"entity/service.go", "services", "_service"},
+		{"entity/handler.go", "handlers", "_handler"},
+	}
+	for _, file := range goFiles {
+		outputPath := filepath.Join(projectDir, "internal", file.dir, data.Snake+file.suffix+".go")
+		if err := sg.writeGoTemplate(file.template, outputPath, data); err != nil {
+			return err
+		}
+	}
+
+	var schema, dir, extension string
+	switch sg.config.ServiceType {
+	case "grpc":
+		schema, dir, extension = "entity/entity.proto", "protobuf", ".proto"
+	case "graphql":
+		schema, dir, extension = "entity/entity.graphql", "graphql", ".graphql"
+	default:
+		return nil
+	}
+	return sg.writeTemplate(schema, filepath.Join(projectDir, dir, data.Snake+extension), data)
+}
+
+// createProjectStructure creates the directory structure for the service
+func (sg *ServiceGenerator) createProjectStructure() error {
+	baseDir := sg.target(filepath.Join(sg.config.OutputDir, sg.config.ServiceName))
+
+	dirs := []string{
+		"cmd",
+		"internal/handlers",
+		"internal/models",
+		"internal/repositories",
+		"internal/services",
+		"internal/middleware",
+		"internal/utils",
+		"pkg/types",
+		"configs",
+		"migrations",
+		"deployments/docker",
+		"deployments/kubernetes",
+		"deployments/helm",
+		"tests/unit",
+		"tests/integration",
+		"tests/e2e",
+		"docs",
+		"scripts",
+	}
+
+	for _, dir := range dirs {
+		fullPath := filepath.Join(baseDir, dir)
+		if err := os.MkdirAll(fullPath, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", fullPath, err)
+		}
+	}
+
+	return nil
+}
+
+// generateMain generates the main.go file
+func (sg *ServiceGenerator) generateMain() error {
+	mainDir := "cmd"
+	if sg.config.MainPackage != "" {
+		mainDir = filepath.FromSlash(sg.config.MainPackage)
+	}
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, mainDir, "main.go")
+	return sg.writeGoTemplate("cmd/main.go", outputPath, sg.config)
+}
+
+// generateGoMod generates the go.mod file
+func (sg *ServiceGenerator) generateGoMod() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "go.mod")
+	return sg.writeTemplate("go.mod", outputPath, sg.config)
+}
+
+// generateConfig generates configuration files
+func (sg *ServiceGenerator) generateConfig() error {
+	// Generate config.yaml
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "configs", "config.yaml")
+	if err := sg.writeTemplate("configs/config.yaml", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate config.dev.yaml
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "configs", "config.dev.yaml")
+	if err := sg.writeTemplate("configs/config.dev.yaml", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate flags.yaml for the file feature flag provider
+	provider := sg.config.FeatureFlagsProvider
+	if provider == "openfeature" {
+		provider = sg.config.OpenFeatureProvider
+	}
+	if !sg.config.WithFeatureFlags || provider == "env" || provider == "remote" || provider == "flagd" || provider == "launchdarkly" {
+		return nil
+	}
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "configs", "flags.yaml")
+	return sg.writeTemplate("configs/flags.yaml", outputPath, sg.config)
+}
+
+// generateErrorTracking generates the errortracking package, which initializes the SDK of
+// the error tracking provider and reports the panics of the requests
+func (sg *ServiceGenerator) generateErrorTracking() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "errortracking", "errortracking.go")
+	return sg.writeGoTemplate("internal/errortracking/errortracking.go", outputPath, sg.config)
+}
+
+// generateHandlers generates HTTP handlers
+func (sg *ServiceGenerator) generateHandlers() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "handlers", "handlers.go")
+	return sg.writeGoTemplate("internal/handlers/handlers.go", outputPath, sg.config)
+}
+
+// generateModels generates data models
+func (sg *ServiceGenerator) generateModels() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "models", "models.go")
+	return sg.writeGoTemplate("internal/models/models.go", outputPath, sg.config)
+}
+
+// generateRepositories generates data repositories
+func (sg *ServiceGenerator) generateRepositories() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "repositories", "repositories.go")
+	return sg.writeGoTemplate("internal/repositories/repositories.go", outputPath, sg.config)
+}
+
+// generateServices generates business logic services
+func (sg *ServiceGenerator) generateServices() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "services", "services.go")
+	return sg.writeGoTemplate("internal/services/services.go", outputPath, sg.config)
+}
+
+// generateMiddleware generates middleware components
+func (sg *ServiceGenerator) generateMiddleware() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "middleware", "middleware.go")
+	return sg.writeGoTemplate("internal/middleware/middleware.go", outputPath, sg.config)
+}
+
+// generateUtils generates utility components
+func (sg *ServiceGenerator) generateUtils() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "internal", "utils", "utils.go")
+	return sg.writeGoTemplate("internal/utils/utils.go", outputPath, sg.config)
+}
+
+// generateEnvExample generates .env.example file
+func (sg *ServiceGenerator) generateEnvExample() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, ".env.example")
+	return sg.writeTemplate(".env.example", outputPath, sg.config)
+}
+
+// generateGitAttributes generates the .gitattributes file keeping the line endings of the
+// project \n on every platform
+func (sg *ServiceGenerator) generateGitAttributes() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, ".gitattributes")
+	return sg.writeTemplate(".gitattributes", outputPath, sg.config)
+}
+
+// generateDocker generates Docker-related files
+func (sg *ServiceGenerator) generateDocker() error {
+	// Generate Dockerfile
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "docker", "Dockerfile")
+	if err := sg.writeTemplate("deployments/docker/Dockerfile", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate docker-compose.yml
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "docker", "docker-compose.yml")
+	return sg.writeTemplate("deployments/docker/docker-compose.yml", outputPath, sg.config)
+}
+
+// generateKubernetes generates Kubernetes manifests
+func (sg *ServiceGenerator) generateKubernetes() error {
+	// Generate deployment.yaml
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "kubernetes", "deployment.yaml")
+	if err := sg.writeTemplate("deployments/kubernetes/deployment.yaml", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate service.yaml
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "kubernetes", "service.yaml")
+	if err := sg.writeTemplate("deployments/kubernetes/service.yaml", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate configmap.yaml
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "kubernetes", "configmap.yaml")
+	return sg.writeTemplate("deployments/kubernetes/configmap.yaml", outputPath, sg.config)
+}
+
+// generateEnvironments generates the map of the environments the service is deployed to
+func (sg *ServiceGenerator) generateEnvironments() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "deployments", "environments.yaml")
+	return sg.writeTemplate("deployments/environments.yaml", outputPath, sg.config)
+}
+
+// generateObservability generates the observability bundle of deployments/observability: the
+// OpenTelemetry Collector configuration, the Grafana dashboard of the service and the
+// provisioning of Grafana and Prometheus
+func (sg *ServiceGenerator) generateObservability() error {
+	for _, file := range observabilityFiles(sg.config.ServiceName) {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(file.path))
+		if err := sg.writeTemplate(file.template, outputPath, sg.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// temporalFiles are the files of the workflows, activities, worker and workflow tests of a
+// Temporal worker
+var temporalFiles = []string{
+	"internal/workflows/workflows.go", "internal/activities/activities.go",
+	"internal/temporal/worker.go", "tests/unit/workflows_test.go",
+}
+
+// generateTemporalWorker generates the workflow and activity stubs of a Temporal worker, the
+// worker registering them, and their tests with the Temporal test suite
+func (sg *ServiceGenerator) generateTemporalWorker() error {
+	for _, name := range temporalFiles {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(name))
+		if err := sg.writeGoTemplate(name, outputPath, sg.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vendorsFeatureFlags reports whether a service evaluates its flags with the featureflags
+// package of the framework, which it gets a copy of: all but the OpenFeature services whose
+// flags flagd or LaunchDarkly evaluate
+func vendorsFeatureFlags(config *GeneratorConfig) bool {
+	return config.WithFeatureFlags && config.OpenFeatureProvider != "flagd" && config.OpenFeatureProvider != "launchdarkly"
+}
+
+// generateFeatureFlagsPackage generates internal/featureflags, the copy of the featureflags
+// package of the framework
+func (sg *ServiceGenerator) generateFeatureFlagsPackage() error {
+	for _, name := range templates.FeatureFlagsFiles {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(name))
+		if err := sg.writeGoTemplate(name, outputPath, sg.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openFeatureFiles returns the files of the flags package of a service evaluating its flags
+// with OpenFeature: the adapter of LaunchDarkly, and the flags flagd serves, only with them
+func openFeatureFiles(config *GeneratorConfig) []string {
+	files := []string{"internal/flags/provider.go", "internal/flags/flags.go", "internal/flags/middleware.go"}
+	switch config.OpenFeatureProvider {
+	case "launchdarkly":
+		files = append(files, "internal/flags/launchdarkly.go")
+	case "flagd":
+		files = append(files, "configs/flags.flagd.json")
+	}
+	return files
+}
+
+// generateOpenFeature generates the flags package, which registers the OpenFeature provider
+// and reads the flags with the targeting context of the requests
+func (sg *ServiceGenerator) generateOpenFeature() error {
+	for _, name := range openFeatureFiles(sg.config) {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(name))
+		var err error
+		if strings.HasSuffix(name, ".go") {
+			err = sg.writeGoTemplate(name, outputPath, sg.config)
+		} else {
+			err = sg.writeTemplate(name, outputPath, sg.config)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// oidcFiles are the files of the Keycloak realm and client registration of a service
+var oidcFiles = []string{"deployments/oidc/realm.json", "deployments/oidc/client.json"}
+
+// generateOIDC generates the Keycloak realm export the local Keycloak imports, and the
+// client registration of the service for the other OpenID Connect providers
+func (sg *ServiceGenerator) generateOIDC() error {
+	for _, name := range oidcFiles {
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, filepath.FromSlash(name))
+		if err := sg.writeTemplate(name, outputPath, sg.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observabilityFile is a file of the observability bundle and the template it is rendered from
+type observabilityFile struct{ path, template string }
+
+// observabilityFiles returns the files of the observability bundle of a service
+func observabilityFiles(serviceName string) []observabilityFile {
+	files := []observabilityFile{{"deployments/observability/grafana/dashboards/" + serviceName + ".json", "deployments/observability/grafana/dashboards/service.json"}}
+	for _, name := range []string{
+		"deployments/observability/otel-collector.yaml", "deployments/observability/prometheus.yml",
+		"deployments/observability/docker-compose.yml",
+		"deployments/observability/grafana/provisioning/datasources/datasources.yaml",
+		"deployments/observability/grafana/provisioning/dashboards/dashboards.yaml",
+	} {
+		files = append(files, observabilityFile{name, name})
+	}
+	return files
+}
+
+// generateTests generates test files
+func (sg *ServiceGenerator) generateTests() error {
+	if sg.config.Adopted {
+		return sg.generateHealthTests()
+	}
+
+	// Generate unit tests
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "tests", "unit", "service_test.go")
+	if err := sg.writeGoTemplate("tests/unit/service_test.go", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate integration tests
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "tests", "integration", "integration_test.go")
+	return sg.writeGoTemplate("tests/integration/integration_test.go", outputPath, sg.config)
+}
+
+// generateHealthTests generates the integration and end-to-end tests of an adopted project,
+// which check the health endpoint of the running service
+func (sg *ServiceGenerator) generateHealthTests() error {
+	for _, suite := range []string{"integration", "e2e"} {
+		data := map[string]string{"ServiceName": sg.config.ServiceName, "Package": suite, "Tag": suite}
+		outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "tests", suite, "health_test.go")
+		if err := sg.writeGoTemplate("tests/health_test.go", outputPath, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateDocumentation generates documentation files
+func (sg *ServiceGenerator) generateDocumentation() error {
+	// Generate README.md
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "README.md")
+	if err := sg.writeTemplate("README.md", outputPath, sg.config); err != nil {
+		return err
+	}
+
+	// Generate API documentation
+	outputPath = filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "docs", "API.md")
+	return sg.writeTemplate("docs/API.md", outputPath, sg.config)
+}
+
+// generateCatalogInfo generates the catalog-info.yaml registering the service in the
+// Backstage software catalog
+func (sg *ServiceGenerator) generateCatalogInfo() error {
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "catalog-info.yaml")
+	return sg.writeTemplate("catalog-info.yaml", outputPath, sg.config)
+}
+
+// ProjectTemplates returns the templates the files of a project of the configuration are
+// rendered from with the configuration, or with nothing else of it than the service name, by
+// path relative to the project root. The files generated from entities, tables, specs and
+// imported projects are not among them.
+func ProjectTemplates(config *GeneratorConfig) map[string]string {
+	mainDir := "cmd"
+	if config.MainPackage != "" {
+		mainDir = config.MainPackage
+	}
+	files := map[string]string{
+		mainDir + "/main.go":                            "cmd/main.go",
+		"migrations/20240101000000_initial_schema.json": "migrations/initial_schema.json",
+	}
+	for _, name := range []string{
+		"go.mod", "configs/config.yaml", "configs/config.dev.yaml", "configs/flags.yaml",
+		"internal/handlers/handlers.go", "internal/models/models.go", "internal/repositories/repositories.go",
+		"internal/services/services.go", "internal/middleware/middleware.go", "internal/utils/utils.go",
+		".env.example", ".gitattributes", "deployments/docker/Dockerfile", "deployments/docker/docker-compose.yml",
+		"deployments/kubernetes/deployment.yaml", "deployments/kubernetes/service.yaml",
+		"deployments/kubernetes/configmap.yaml", "deployments/environments.yaml",
+		"tests/unit/service_test.go", "tests/integration/integration_test.go", "README.md", "docs/API.md",
+	} {
+		files[name] = name
+	}
+	if config.WithErrorTracking {
+		files["internal/errortracking/errortracking.go"] = "internal/errortracking/errortracking.go"
+	}
+	if config.ServiceType == "temporal-worker" {
+		for _, name := range temporalFiles {
+			files[name] = name
+		}
+	}
+	if vendorsFeatureFlags(config) {
+		for _, name := range templates.FeatureFlagsFiles {
+			files[name] = name
+		}
+	}
+	if config.OpenFeatureProvider != "" {
+		for _, name := range openFeatureFiles(config) {
+			files[name] = name
+		}
+	}
+	if config.OIDC != nil {
+		for _, name := range oidcFiles {
+			files[name] = name
+		}
+	}
+	if config.WithMonitoring {
+		for _, file := range observabilityFiles(config.ServiceName) {
+			files[file.path] = file.template
+		}
+	}
+	return files
+}
+
+// generateInitialMigration generates an initial migration file
+func (sg *ServiceGenerator) generateInitialMigration() error {
+	// Create migration data with timestamp
+	migrationData := struct {
+		ServiceName string
+		Timestamp   string
+		Description string
+		CreatedAt   string
+	}{
+		ServiceName: sg.config.ServiceName,
+		Timestamp:   "20240101000000", // Initial timestamp
+		Description: "Initial database schema",
+		CreatedAt:   "2024-01-01T00:00:00Z",
+	}
+
+	outputPath := filepath.Join(sg.config.OutputDir, sg.config.ServiceName, "migrations", "20240101000000_initial_schema.json")
+	return sg.writeTemplate("migrations/initial_schema.json", outputPath, migrationData)
+}
+
+// writeTemplate renders the template of a name through the pipeline and writes it to a file,
+// unless an incremental generation finds it unchanged
+func (sg *ServiceGenerator) writeTemplate(name, outputPath string, data interface{}, processors ...PostProcessor) error {
+	file := renderedFile{path: outputPath, inputs: sg.inputsChecksum(name, data, processors)}
+	if sg.streams(processors) {
+		return sg.streamTemplate(name, outputPath, data, file.inputs)
+	}
+	if file.content, file.unchanged = sg.unchangedFile(file); file.unchanged {
+		return sg.writeFile(file)
+	}
+	content, err := sg.pipeline.Render(name, outputPath, data, processors...)
+	if err != nil {
+		return err
+	}
+	file.content = content
+	return sg.writeFile(file)
+}
+
+// writeGoTemplate writes a template of Go source to a file, formatted, since the sections it
+// leaves out would otherwise leave the imports and fields misaligned
+func (sg *ServiceGenerator) writeGoTemplate(name, outputPath string, data interface{}) error {
+	return sg.writeTemplate(name, outputPath, data, GoFormat{})
+}
+
+// writeFile records a generated file and writes it unless the generator only renders, or
+// adds it to the pending files of the step the generator renders. An incremental generation
+// only writes the files that differ and were not changed since they were generated.
+func (sg *ServiceGenerator) writeFile(file renderedFile) error {
+	if sg.pending != nil {
+		*sg.pending = append(*sg.pending, file)
+		return nil
+	}
+	relPath, err := filepath.Rel(sg.hook.Dir, file.path)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+	if file.streamed != nil {
+		sg.files[relPath] = nil
+		sg.streamed[relPath] = file.streamed.checksum
+		sg.inputs[relPath] = file.inputs
+		sg.progress.File(relPath, file.streamed.size, file.elapsed)
+		return nil
+	}
+	content := file.content
+	if !file.unchanged {
+		if content, err = sg.runFileHooks(sg.hook, relPath, content); err != nil {
+			return err
+		}
+	}
+	sg.files[relPath] = content
+	sg.inputs[relPath] = file.inputs
+	if sg.render {
+		return nil
+	}
+	if sg.previous != nil {
+		action := FileUnchanged
+		if !file.unchanged {
+			if action, err = sg.incrementalAction(relPath, file.path, content); err != nil {
+				return err
+			}
+		}
+		sg.changes[relPath] = action
+		if action != FileCreated && action != FileUpdated && action != FileOverwritten {
+			return nil
+		}
+	}
+	start := time.Now()
+	if err := sg.pipeline.Writer.Write(sg.target(file.path), content); err != nil {
+		return err
+	}
+	sg.progress.File(relPath, int64(len(content)), time.Since(start))
+	return nil
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorConfigValidate(t *testing.T) {
+	order := Entity{Name: "Order", Fields: []EntityField{{Name: "total", Type: "float"}}}
+	tests := []struct {
+		name   string
+		config GeneratorConfig
+		// wantField is the Field of the ConfigError, "" when the configuration is valid
+		wantField string
+	}{
+		{name: "minimal", config: GeneratorConfig{ServiceName: "orders"}},
+		{name: "empty name", config: GeneratorConfig{}, wantField: "ServiceName"},
+		{name: "name with a separator", config: GeneratorConfig{ServiceName: "orders/api"}, wantField: "ServiceName"},
+		{name: "name is a parent", config: GeneratorConfig{ServiceName: ".."}, wantField: "ServiceName"},
+		{name: "entity", config: GeneratorConfig{ServiceName: "orders", Entities: []Entity{order}}},
+		{
+			name:      "entity without fields",
+			config:    GeneratorConfig{ServiceName: "orders", Entities: []Entity{{Name: "Order"}}},
+			wantField: "Entities",
+		},
+		{
+			name:      "entity named in snake_case",
+			config:    GeneratorConfig{ServiceName: "orders", Entities: []Entity{{Name: "order_item", Fields: order.Fields}}},
+			wantField: "Entities",
+		},
+		{
+			name:      "table named after an entity",
+			config:    GeneratorConfig{ServiceName: "orders", Entities: []Entity{order}, Tables: []Table{{Name: "orders", Model: "Order"}}},
+			wantField: "Tables",
+		},
+		{name: "main package", config: GeneratorConfig{ServiceName: "orders", MainPackage: "cmd/orders"}},
+		{name: "main package with a dot", config: GeneratorConfig{ServiceName: "orders", MainPackage: "./cmd/orders"}},
+		{name: "absolute main package", config: GeneratorConfig{ServiceName: "orders", MainPackage: "/cmd/orders"}, wantField: "MainPackage"},
+		{name: "main package outside", config: GeneratorConfig{ServiceName: "orders", MainPackage: "../cmd"}, wantField: "MainPackage"},
+		{name: "main package climbing out", config: GeneratorConfig{ServiceName: "orders", MainPackage: "cmd/../../x"}, wantField: "MainPackage"},
+		{name: "main package ending in a parent", config: GeneratorConfig{ServiceName: "orders", MainPackage: "cmd/.."}, wantField: "MainPackage"},
+		{name: "unclean main package", config: GeneratorConfig{ServiceName: "orders", MainPackage: "cmd//orders/"}, wantField: "MainPackage"},
+		{name: "main package is the project", config: GeneratorConfig{ServiceName: "orders", MainPackage: "./"}, wantField: "MainPackage"},
+		{name: "main package with backslashes", config: GeneratorConfig{ServiceName: "orders", MainPackage: `cmd\orders`}, wantField: "MainPackage"},
+		{name: "main package on a volume", config: GeneratorConfig{ServiceName: "orders", MainPackage: "C:/cmd"}, wantField: "MainPackage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("Validate() error = %v, want a ConfigError of %s", err, tt.wantField)
+			}
+			if configErr.Field != tt.wantField {
+				t.Errorf("Validate() field = %s, want %s (%v)", configErr.Field, tt.wantField, err)
+			}
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("Validate() error = %v, want it to match ErrInvalidConfig and name %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRenderServiceGoFiles(t *testing.T) {
+	order := Entity{Name: "Order", Fields: []EntityField{{Name: "total", Type: "float"}}}
+	tests := []struct {
+		name   string
+		config GeneratorConfig
+		// wantFiles are files that must be rendered, the tests among them
+		wantFiles []string
+	}{
+		{
+			name:      "rest",
+			config:    GeneratorConfig{ServiceName: "orders", ServiceType: "rest"},
+			wantFiles: []string{"cmd/main.go", "tests/unit/service_test.go", "tests/integration/integration_test.go"},
+		},
+		{name: "grpc", config: GeneratorConfig{ServiceName: "orders", ServiceType: "grpc"}},
+		{name: "graphql", config: GeneratorConfig{ServiceName: "orders", ServiceType: "graphql"}},
+		{
+			name:   "entities",
+			config: GeneratorConfig{ServiceName: "orders", ServiceType: "rest", WithDatabase: true, DatabaseProvider: "postgresql", Entities: []Entity{order}},
+		},
+		{
+			name: "components",
+			config: GeneratorConfig{
+				ServiceName: "orders", ServiceType: "rest", WithAuth: true, WithMonitoring: true, WithCache: true,
+				WithFeatureFlags: true, WithErrorTracking: true, ErrorTrackingProvider: "sentry",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			files, err := NewServiceGenerator(&config).RenderService()
+			if err != nil {
+				t.Fatalf("RenderService() error = %v", err)
+			}
+			for _, name := range tt.wantFiles {
+				if _, ok := files[name]; !ok {
+					t.Errorf("%s was not rendered", name)
+				}
+			}
+			for name, content := range files {
+				if !strings.HasSuffix(name, ".go") {
+					continue
+				}
+				if _, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ImportsOnly); err != nil {
+					t.Errorf("%s does not parse: %v", name, err)
+					continue
+				}
+				formatted, err := format.Source(content)
+				if err != nil {
+					t.Errorf("%s does not parse: %v", name, err)
+				} else if !bytes.Equal(formatted, content) {
+					t.Errorf("%s is not gofmt-formatted", name)
+				}
+			}
+		})
+	}
+}
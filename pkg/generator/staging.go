@@ -0,0 +1,233 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Validator checks a generated project, staged in dir, before it is moved into place
+type Validator interface {
+	Validate(dir string) error
+}
+
+// ValidatorFunc adapts a function to a Validator
+type ValidatorFunc func(dir string) error
+
+// Validate calls f
+func (f ValidatorFunc) Validate(dir string) error {
+	return f(dir)
+}
+
+// WithValidators adds validators to those GenerateService checks the staged project with,
+// after GoParse, in order
+func WithValidators(validators ...Validator) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, validators...)
+	}
+}
+
+// GoParse checks that every Go file of a project parses
+type GoParse struct{}
+
+// Validate parses the Go files under dir, reporting every file that does not parse
+func (GoParse) Validate(dir string) error {
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			// .microframework keeps the generated contents of the files, not files to build
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), filepath.ToSlash(relPath), content, parser.AllErrors); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// GoVet runs go mod tidy, then go vet, on a copy of a project, leaving the project as it was
+// generated. The modules the project requires come from the module cache or the proxy.
+type GoVet struct{}
+
+// Validate vets a copy of the project in dir
+func (GoVet) Validate(dir string) error {
+	vetDir, err := os.MkdirTemp("", "microframework-vet-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(vetDir)
+	if err := os.CopyFS(vetDir, os.DirFS(dir)); err != nil {
+		return fmt.Errorf("failed to copy the project: %w", err)
+	}
+
+	for _, args := range [][]string{{"mod", "tidy"}, {"vet", "./..."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = vetDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("go %s: %w\n%s", strings.Join(args, " "), err, bytes.TrimSpace(output))
+		}
+	}
+	return nil
+}
+
+// startStaging makes GenerateService write the project to a staging directory next to it,
+// so that it is only moved into place once it is complete and valid. Projects written with
+// another Writer than a FileWriter are not staged.
+func (sg *ServiceGenerator) startStaging() error {
+	if _, ok := sg.pipeline.Writer.(FileWriter); !ok {
+		return nil
+	}
+	parent := filepath.Dir(sg.hook.Dir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", parent, err)
+	}
+	root, err := os.MkdirTemp(parent, ".microframework-staging-")
+	if err != nil {
+		return fmt.Errorf("failed to create the staging directory: %w", err)
+	}
+	sg.staging = filepath.Join(root, filepath.Base(sg.hook.Dir))
+	sg.copied = make(map[string]bool)
+	return nil
+}
+
+// stopStaging removes the staging directory, with whatever the generation left in it
+func (sg *ServiceGenerator) stopStaging() {
+	if sg.staging != "" {
+		os.RemoveAll(filepath.Dir(sg.staging))
+	}
+	sg.staging, sg.copied = "", nil
+}
+
+// target returns where the file or directory at path, under the project, is written: its
+// place in the staging directory while the project is staged
+func (sg *ServiceGenerator) target(path string) string {
+	if sg.staging == "" {
+		return path
+	}
+	relPath, err := filepath.Rel(sg.hook.Dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.Join(sg.staging, relPath)
+}
+
+// validateStaged checks the staged project with GoParse and the validators of the options.
+// The files an incremental generation did not write are copied in from the project first,
+// so that the validators see the project as it will be.
+func (sg *ServiceGenerator) validateStaged() error {
+	for path, action := range sg.changes {
+		if action == FileCreated || action == FileUpdated || action == FileOverwritten {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(sg.hook.Dir, filepath.FromSlash(path)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := (FileWriter{}).Write(filepath.Join(sg.staging, filepath.FromSlash(path)), content); err != nil {
+			return err
+		}
+		sg.copied[path] = true
+	}
+
+	for _, validator := range append([]Validator{GoParse{}}, sg.validators...) {
+		if err := validator.Validate(sg.staging); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitStaged moves the staged project into place: as a whole when the project directory
+// does not exist or is empty, else file by file, restoring the project as it was when a
+// move fails
+func (sg *ServiceGenerator) commitStaged() error {
+	dir := sg.hook.Dir
+	entries, err := os.ReadDir(dir)
+	switch {
+	case os.IsNotExist(err):
+		return os.Rename(sg.staging, dir)
+	case err != nil:
+		return err
+	case len(entries) == 0:
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+		return os.Rename(sg.staging, dir)
+	}
+
+	backup := filepath.Join(filepath.Dir(sg.staging), ".backup")
+	var undo []func()
+	err = filepath.WalkDir(sg.staging, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(sg.staging, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, relPath)
+		if entry.IsDir() {
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				if err := os.Mkdir(target, 0755); err != nil {
+					return err
+				}
+				undo = append(undo, func() { os.Remove(target) })
+			}
+			return nil
+		}
+		if sg.copied[filepath.ToSlash(relPath)] {
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			saved := filepath.Join(backup, relPath)
+			if err := os.MkdirAll(filepath.Dir(saved), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(target, saved); err != nil {
+				return err
+			}
+			undo = append(undo, func() { os.Rename(saved, target) })
+		} else {
+			undo = append(undo, func() { os.Remove(target) })
+		}
+		return os.Rename(path, target)
+	})
+	if err != nil {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree writes files, by slash-separated path, under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the files under dir by slash-separated path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCommitStaged(t *testing.T) {
+	tests := []struct {
+		name    string
+		project map[string]string
+		staged  map[string]string
+		// copied are the staged files copied from the project, which stay as they are
+		copied map[string]bool
+		// want is the project after the commit, the project as it was when wantErr
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "new project",
+			staged: map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main\n"},
+			want:   map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main\n"},
+		},
+		{
+			name:    "existing project",
+			project: map[string]string{"go.mod": "module old\n", "notes.txt": "kept\n"},
+			staged:  map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main\n"},
+			want:    map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main\n", "notes.txt": "kept\n"},
+		},
+		{
+			name:    "copied files are left in place",
+			project: map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main // edited\n"},
+			staged:  map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main // edited\n"},
+			copied:  map[string]bool{"cmd/main.go": true},
+			want:    map[string]string{"go.mod": "module orders\n", "cmd/main.go": "package main // edited\n"},
+		},
+		{
+			// internal is a file in the project and a directory in the staging directory, so
+			// moving internal/handlers.go fails after README.md, cmd and go.mod were moved
+			name:    "failed move is rolled back",
+			project: map[string]string{"go.mod": "module old\n", "internal": "not a directory\n"},
+			staged: map[string]string{
+				"README.md":            "# orders\n",
+				"go.mod":               "module orders\n",
+				"internal/handlers.go": "package internal\n",
+				"cmd/main.go":          "package main\n",
+			},
+			want:    map[string]string{"go.mod": "module old\n", "internal": "not a directory\n"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "orders")
+			if tt.project != nil {
+				writeTree(t, dir, tt.project)
+			}
+			staging := filepath.Join(root, ".microframework-staging-test", "orders")
+			writeTree(t, staging, tt.staged)
+
+			sg := &ServiceGenerator{hook: &HookContext{Dir: dir}, staging: staging, copied: tt.copied}
+			err := sg.commitStaged()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commitStaged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := readTree(t, dir)
+			if len(got) != len(tt.want) {
+				t.Errorf("project has %v, want %v", got, tt.want)
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+			if tt.wantErr {
+				if _, err := os.Stat(filepath.Join(dir, "cmd")); !os.IsNotExist(err) {
+					t.Errorf("the directory created by the failed commit was not removed")
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateServiceLeavesProjectOnFailedValidation(t *testing.T) {
+	root := t.TempDir()
+	project := map[string]string{"go.mod": "module orders\n", "notes.txt": "kept\n"}
+	writeTree(t, filepath.Join(root, "orders"), project)
+
+	failed := errors.New("rejected")
+	config := &GeneratorConfig{ServiceName: "orders", ServiceType: "rest", OutputDir: root}
+	err := NewServiceGenerator(config, WithValidators(ValidatorFunc(func(string) error { return failed }))).GenerateService()
+	if !errors.Is(err, failed) {
+		t.Fatalf("GenerateService() error = %v, want %v", err, failed)
+	}
+
+	got := readTree(t, filepath.Join(root, "orders"))
+	if len(got) != len(project) || got["go.mod"] != project["go.mod"] || got["notes.txt"] != project["notes.txt"] {
+		t.Errorf("project is %v after a failed generation, want %v", got, project)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("the generation left %d entries next to the project, want the staging directory removed", len(entries)-1)
+	}
+}
@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithMaxFileSize fails the generation of any file larger than n bytes with a
+// *FileSizeError, stopping its rendering at the limit; 0, the default, sets no limit
+func WithMaxFileSize(n int64) Option {
+	return func(o *options) {
+		o.pipeline.MaxFileSize = n
+	}
+}
+
+// streamedFile is a file the pipeline streamed to its writer
+type streamedFile struct {
+	size     int64
+	checksum string
+}
+
+// canStream tells whether the pipeline streams the files rendered with processors to its
+// writer: when its Renderer is a StreamRenderer, its Writer a StreamWriter, and neither
+// processors nor the pipeline post-process the files
+func (p *Pipeline) canStream(processors []PostProcessor) bool {
+	_, renders := p.Renderer.(StreamRenderer)
+	_, writes := p.Writer.(StreamWriter)
+	return renders && writes && len(processors) == 0 && len(p.PostProcessors) == 0
+}
+
+// stream renders the template of a name for the file at path straight to the file at target,
+// through the buffer of the writer, as to also, so that the file is never held in memory
+// whatever its size. It returns the size and the checksum of the file; a file that fails to
+// render or grows past MaxFileSize is removed. The pipeline must stream, as canStream tells.
+func (p *Pipeline) stream(name, path, target string, data interface{}, also ...io.Writer) (streamedFile, error) {
+	text, ok := p.Source.Lookup(name)
+	if !ok {
+		return streamedFile{}, &TemplateError{Template: name, Err: errors.New("not in the registry")}
+	}
+	file, err := p.Writer.(StreamWriter).Create(target)
+	if err != nil {
+		return streamedFile{}, err
+	}
+
+	hash := sha256.New()
+	checksum := &lfWriter{w: hash}
+	out := &limitWriter{w: io.MultiWriter(append([]io.Writer{file, checksum}, also...)...), path: path, limit: p.MaxFileSize}
+	err = p.Renderer.(StreamRenderer).RenderTo(out, name, text, data)
+	if err == nil {
+		err = checksum.flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return streamedFile{}, err
+	}
+	return streamedFile{size: out.n, checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// limitWriter fails the writes past limit bytes, when limit is not 0, with a *FileSizeError
+type limitWriter struct {
+	w     io.Writer
+	path  string
+	limit int64
+	// n is how many bytes were written
+	n int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.limit > 0 && l.n+int64(len(p)) > l.limit {
+		return 0, &FileSizeError{Path: l.path, Limit: l.limit}
+	}
+	n, err := l.w.Write(p)
+	l.n += int64(n)
+	return n, err
+}
+
+// lfWriter writes to w what is written to it with \n for its \r\n line endings, as Checksum
+// hashes files
+type lfWriter struct {
+	w io.Writer
+	// cr is set when the last byte written was a \r, held back until the next one
+	cr  bool
+	buf []byte
+}
+
+func (l *lfWriter) Write(p []byte) (int, error) {
+	out := l.buf[:0]
+	for _, b := range p {
+		if l.cr && b != '\n' {
+			out = append(out, '\r')
+		}
+		l.cr = b == '\r'
+		if !l.cr {
+			out = append(out, b)
+		}
+	}
+	l.buf = out
+	if _, err := l.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes the \r held back at the end of the output
+func (l *lfWriter) flush() error {
+	if !l.cr {
+		return nil
+	}
+	l.cr = false
+	_, err := l.w.Write([]byte{'\r'})
+	return err
+}
+
+// streams tells whether the generator streams the files rendered with processors: it does
+// when the pipeline streams, and it neither keeps the files in memory, nor regenerates the
+// project incrementally, nor has file hooks, which all need the contents of the files
+func (sg *ServiceGenerator) streams(processors []PostProcessor) bool {
+	return !sg.render && sg.previous == nil && !sg.hasFileHooks() && sg.pipeline.canStream(processors)
+}
+
+// streamTemplate streams the template of a name to a file, together with its generated
+// contents in the base directory of the project, and records it as writeFile does. The
+// steps rendering concurrently stream their files as they render them, each to its own file.
+func (sg *ServiceGenerator) streamTemplate(name, outputPath string, data interface{}, inputs string) error {
+	relPath, err := filepath.Rel(sg.hook.Dir, outputPath)
+	if err != nil {
+		return err
+	}
+	basePath := sg.target(filepath.Join(sg.hook.Dir, BaseDir, relPath))
+	base, err := (FileWriter{}).Create(basePath)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	streamed, err := sg.pipeline.stream(name, outputPath, sg.target(outputPath), data, base)
+	if closeErr := base.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(basePath)
+		return err
+	}
+	return sg.writeFile(renderedFile{path: outputPath, inputs: inputs, streamed: &streamed, elapsed: time.Since(start)})
+}
@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Table is a table of an existing database imported with microframework import db, which the
+// service has a model and a repository of. Unlike an entity, the model maps the table as it
+// is, column by column, rather than the table being created from the model.
+type Table struct {
+	// Name is the name of the table in the database
+	Name string `json:"name"`
+	// Model is the Go name of the model of the table: OrderItem for order_items
+	Model   string        `json:"model"`
+	Columns []TableColumn `json:"columns"`
+	Indexes []TableIndex  `json:"indexes,omitempty"`
+}
+
+// TableColumn is a column of an imported table and the field of the model mapping it
+type TableColumn struct {
+	Name string `json:"name"`
+	// Type is the type of the column as the database declares it: character varying(255)
+	Type string `json:"type"`
+	// GoType is the type of the field: *string for a nullable varchar
+	GoType     string `json:"go_type"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	NotNull    bool   `json:"not_null,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+	// Default is the SQL expression of the default value of the column
+	Default string `json:"default,omitempty"`
+}
+
+// TableIndex is an index of an imported table, besides those of its primary key and unique
+// columns
+type TableIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// ValidateTables checks the imported tables of a service: that their models are named as Go
+// types, after neither another table nor an entity, and have fields
+func ValidateTables(tables []Table, entities []Entity) error {
+	models := make(map[string]string)
+	for _, entity := range entities {
+		models[entity.Name] = "entity " + entity.Name
+	}
+	for _, table := range tables {
+		if !entityNamePattern.MatchString(table.Model) {
+			return fmt.Errorf("table %s: invalid model name %q: use PascalCase, as OrderItem", table.Name, table.Model)
+		}
+		if other, ok := models[table.Model]; ok {
+			return fmt.Errorf("table %s: model %s is already the model of %s", table.Name, table.Model, other)
+		}
+		models[table.Model] = "table " + table.Name
+		if len(table.Columns) == 0 {
+			return fmt.Errorf("table %s has no columns", table.Name)
+		}
+		for _, column := range table.Columns {
+			if column.Name == "" || column.GoType == "" || strings.ContainsAny(column.Name+column.Type+column.Default, "`;") {
+				return fmt.Errorf("table %s: column %q cannot be mapped to a field", table.Name, column.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// tableData is what the table templates are rendered with
+type tableData struct {
+	ServiceName string
+	Table
+	Fields []tableFieldData
+	// Key is the field of the primary key, when the table has a primary key of one column;
+	// the repository reads, updates and deletes rows by it
+	Key *tableFieldData
+	// Var is the name of a variable holding a model, Snake names its files
+	Var, Snake string
+	// Time is set when a field is a time.Time
+	Time bool
+}
+
+// tableFieldData is a column of tableData
+type tableFieldData struct {
+	TableColumn
+	GoName string
+	// Tag is the struct tag of the field in the model
+	Tag string
+}
+
+// newTableData returns the data the templates of a table are rendered with
+func newTableData(config *GeneratorConfig, table Table) *tableData {
+	data := &tableData{
+		ServiceName: config.ServiceName,
+		Table:       table,
+		Var:         lowerFirst(table.Model),
+		Snake:       snakeCase(table.Model),
+	}
+	var keys []tableFieldData
+	for _, column := range table.Columns {
+		field := tableFieldData{TableColumn: column, GoName: columnGoName(column.Name)}
+		settings := []string{"column:" + column.Name, "type:" + column.Type}
+		if column.PrimaryKey {
+			settings = append(settings, "primaryKey")
+		}
+		if column.NotNull && !column.PrimaryKey {
+			settings = append(settings, "not null")
+		}
+		if column.Unique {
+			settings = append(settings, "unique")
+		}
+		if column.Default != "" {
+			settings = append(settings, "default:"+column.Default)
+		}
+		for _, index := range table.Indexes {
+			for _, indexed := range index.Columns {
+				switch {
+				case indexed != column.Name:
+				case index.Unique:
+					settings = append(settings, "uniqueIndex:"+index.Name)
+				default:
+					settings = append(settings, "index:"+index.Name)
+				}
+			}
+		}
+		field.Tag = fmt.Sprintf("`json:%s gorm:%s`", strconv.Quote(column.Name), strconv.Quote(strings.Join(settings, ";")))
+
+		data.Fields = append(data.Fields, field)
+		if column.PrimaryKey {
+			keys = append(keys, field)
+		}
+		if strings.TrimPrefix(column.GoType, "*") == "time.Time" {
+			data.Time = true
+		}
+	}
+	if len(keys) == 1 {
+		data.Key = &keys[0]
+	}
+	return data
+}
+
+// columnGoName returns the Go name of a column: unit_price is UnitPrice, customerId CustomerId
+func columnGoName(column string) string {
+	name := goName(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, column))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Column" + name
+	}
+	return name
+}
+
+// generateTable generates the model and repository of an imported table
+func (sg *ServiceGenerator) generateTable(table Table) error {
+	data := newTableData(sg.config, table)
+	projectDir := filepath.Join(sg.config.OutputDir, sg.config.ServiceName)
+	if err := sg.writeGoTemplate("table/model.go", filepath.Join(projectDir, "internal", "models", data.Snake+".go"), data); err != nil {
+		return err
+	}
+	return sg.writeGoTemplate("table/repository.go", filepath.Join(projectDir, "internal", "repositories", data.Snake+"_repository.go"), data)
+}
+
+// RenderTable generates the files of an imported table of the configuration in memory and
+// returns them by path relative to the project root
+func (sg *ServiceGenerator) RenderTable(name string) (map[string][]byte, error) {
+	sg.render = true
+	defer func() { sg.render = false }()
+	sg.hook = sg.newHookContext()
+
+	for _, table := range sg.config.Tables {
+		if table.Name == name {
+			if err := sg.generateTable(table); err != nil {
+				return nil, err
+			}
+			return sg.files, nil
+		}
+	}
+	return nil, fmt.Errorf("no table %s in the configuration", name)
+}
@@ -0,0 +1,39 @@
+package templates
+
+// APIHandlerTemplate is the handler of the operations of the OpenAPI spec a service was
+// imported from with microframework new --from-openapi, one stub per operation
+const APIHandlerTemplate = `package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIHandler handles the operations of {{.OpenAPISpec}} that the entity handlers do not
+type APIHandler struct {
+	// Add service dependencies here
+}
+
+// NewAPIHandler creates a new handler
+func NewAPIHandler() *APIHandler {
+	return &APIHandler{}
+}
+
+// RegisterRoutes registers the routes of the operations of {{.OpenAPISpec}}
+func (h *APIHandler) RegisterRoutes(router gin.IRouter) {
+{{- range .Operations}}
+	router.{{.Method}}("{{.Route}}", h.{{.Name}})
+{{- end}}
+}
+{{range .Operations}}
+// {{.Name}} handles {{.Method}} {{.Path}}{{if .Summary}}: {{.Summary}}{{end}}
+{{- if .Security}}
+//
+// It requires {{range $i, $scheme := .Security}}{{if $i}}, {{end}}{{$scheme}}{{end}}.
+{{- end}}
+func (h *APIHandler) {{.Name}}(c *gin.Context) {
+	c.JSON(http.StatusNotImplemented, gin.H{"error": "{{.Name}} is not implemented"})
+}
+{{end -}}
+`
@@ -0,0 +1,732 @@
+package templates
+
+// AsyncAPIMessagesTemplate is the Go types of the payloads of the messages of generate
+// from-asyncapi
+const AsyncAPIMessagesTemplate = `// Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+
+package events
+{{if .Time}}
+import "time"
+{{end}}
+// Service is the source of the messages the service sends, and the consumer group of its
+// subscriptions
+const Service = "{{.ServiceName}}"
+{{- range .Types}}
+
+// {{.Name}} is an object of the messages of {{$.Source}}
+{{- if .Description}}
+//
+// {{.Description}}
+{{- end}}
+type {{.Name}} struct {
+{{- range .Fields}}
+{{- if .Description}}
+	// {{.Description}}
+{{- end}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.Name}}{{if not .Required}},omitempty{{end}}"{{if eq $.Serialization "avro"}} avro:"{{.Name}}"{{end}}` + "`" + `
+{{- end}}
+}
+{{- end}}
+`
+
+// AsyncAPIProducersTemplate is the producers of the messages the service sends, publishing
+// them with a MessagingManager encoded with a Codec
+const AsyncAPIProducersTemplate = `// Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anasamu/go-micro-libs/messaging"
+)
+
+// Producer publishes the messages the service sends with a provider of a MessagingManager
+type Producer struct {
+	manager  *messaging.MessagingManager
+	provider string
+	codec    Codec
+}
+{{- if eq .Serialization "json"}}
+
+// NewProducer creates a producer publishing with the provider of the manager, the messages
+// encoded as JSON objects
+func NewProducer(manager *messaging.MessagingManager, provider string) *Producer {
+	return &Producer{manager: manager, provider: provider, codec: JSONCodec{}}
+}
+{{- else}}
+
+// NewProducer creates a producer publishing with the provider of the manager, the messages
+// encoded with the codec, a RegistryCodec
+func NewProducer(manager *messaging.MessagingManager, provider string, codec Codec) *Producer {
+	return &Producer{manager: manager, provider: provider, codec: codec}
+}
+{{- end}}
+{{- range .Producers}}
+
+// {{.Method}} publishes the message to {{.Address}}, as a {{.MessageName}} message
+{{- if .Description}}
+//
+// {{.Description}}
+{{- end}}
+func (p *Producer) {{.Method}}(ctx context.Context{{range .Parameters}}, {{.GoName}} string{{end}}, message *{{.Message}}) error {
+	return p.publish(ctx, {{.Topic}}, "{{.MessageName}}", message)
+}
+{{- end}}
+
+// publish sends a message of a type to a topic, its payload encoded by the codec
+func (p *Producer) publish(ctx context.Context, topic, messageType string, message interface{}) error {
+	payload, err := p.codec.Encode(ctx, topic, message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", messageType, err)
+	}
+	_, err = p.manager.PublishMessage(ctx, p.provider, &messaging.PublishRequest{
+		Topic:   topic,
+		Message: messaging.CreateMessage(messageType, Service, "", topic, payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s to %s: %w", messageType, topic, err)
+	}
+	return nil
+}
+`
+
+// AsyncAPIConsumersTemplate is the Handlers interface of the messages the service receives,
+// and the subscriptions dispatching them to its methods, decoded with a Codec
+const AsyncAPIConsumersTemplate = `// Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anasamu/go-micro-libs/messaging"
+)
+
+// Handlers handles the messages of the channels the service subscribes to
+type Handlers interface {
+{{- range .Channels}}
+{{- range .Handlers}}
+	// {{.Method}} handles the {{.MessageName}} messages of {{.Address}}
+	{{.Method}}(ctx context.Context, message *{{.Message}}) error
+{{- end}}
+{{- end}}
+}
+
+// Subscribe subscribes the handlers to the channels with the provider of the manager, in the
+// consumer group of the service
+{{- if eq .Serialization "json"}}, the messages decoded from JSON objects
+func Subscribe(ctx context.Context, manager *messaging.MessagingManager, provider string, handlers Handlers) error {
+	codec := JSONCodec{}
+{{- else}}, the messages decoded with the codec, a RegistryCodec
+func Subscribe(ctx context.Context, manager *messaging.MessagingManager, provider string, handlers Handlers, codec Codec) error {
+{{- end}}
+	subscriptions := []struct {
+		topic   string
+		handler messaging.MessageHandler
+	}{
+{{- range .Channels}}
+		{"{{.Address}}", func(ctx context.Context, message *messaging.Message) error {
+{{- if eq (len .Handlers) 1}}
+{{- with index .Handlers 0}}
+			var payload {{.Message}}
+			if err := decode(ctx, codec, message, &payload); err != nil {
+				return err
+			}
+			return handlers.{{.Method}}(ctx, &payload)
+{{- end}}
+{{- else}}
+			switch message.Type {
+{{- range .Handlers}}
+			case "{{.MessageName}}":
+				var payload {{.Message}}
+				if err := decode(ctx, codec, message, &payload); err != nil {
+					return err
+				}
+				return handlers.{{.Method}}(ctx, &payload)
+{{- end}}
+			}
+			return fmt.Errorf("unknown message type %q on %s", message.Type, message.Topic)
+{{- end}}
+		}},
+{{- end}}
+	}
+	for _, subscription := range subscriptions {
+		request := &messaging.SubscribeRequest{Topic: subscription.topic, GroupID: Service, AutoAck: true}
+		if err := manager.SubscribeToTopic(ctx, provider, request, subscription.handler); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", subscription.topic, err)
+		}
+	}
+	return nil
+}
+
+// decode reads the payload of a message into its Go type with the codec
+func decode(ctx context.Context, codec Codec, message *messaging.Message, payload interface{}) error {
+	if err := codec.Decode(ctx, message, payload); err != nil {
+		return fmt.Errorf("failed to decode %s message %s: %w", message.Type, message.ID, err)
+	}
+	return nil
+}
+`
+
+// AsyncAPIHandlersTemplate is the stubs of the handlers of the messages the service
+// receives, to be implemented
+const AsyncAPIHandlersTemplate = `package events
+
+import (
+	"context"
+	"errors"
+)
+
+// Handler handles the messages of the channels of {{.Source}} the service subscribes to.
+// Implement its methods; when the document changes, the compiler lists the methods to add
+// or change.
+type Handler struct{}
+
+var _ Handlers = (*Handler)(nil)
+{{- range .Channels}}
+{{- range .Handlers}}
+
+func (h *Handler) {{.Method}}(ctx context.Context, message *{{.Message}}) error {
+	return errors.New("not implemented: {{.Method}}")
+}
+{{- end}}
+{{- end}}
+`
+
+// AsyncAPICodecTemplate is the codecs of the messages of generate from-asyncapi: JSONCodec,
+// and with --serialization avro or protobuf RegistryCodec, which encodes them in the wire
+// format of a schema registry with the schemas of api/events
+const AsyncAPICodecTemplate = `// Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+
+package events
+
+import (
+	{{- if ne .Serialization "json"}}
+	"bytes"
+	{{- end}}
+	"context"
+	{{- if ne .Serialization "json"}}
+	"encoding/base64"
+	"encoding/binary"
+	{{- end}}
+	"encoding/json"
+	{{- if ne .Serialization "json"}}
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	{{- end}}
+
+	"github.com/anasamu/go-micro-libs/messaging"
+	{{- if eq .Serialization "avro"}}
+	"github.com/hamba/avro/v2"
+	{{- else if eq .Serialization "protobuf"}}
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	{{- end}}
+)
+
+// Codec encodes the messages the service sends into the payloads of the messages of the
+// MessagingManager, and decodes the payloads of those it receives
+type Codec interface {
+	// Encode returns the payload of a message sent to a topic
+	Encode(ctx context.Context, topic string, message interface{}) (map[string]interface{}, error)
+	// Decode reads the payload of a message into its Go type
+	Decode(ctx context.Context, message *messaging.Message, payload interface{}) error
+}
+
+// JSONCodec encodes the messages as the JSON objects of their fields
+type JSONCodec struct{}
+
+// Encode returns the JSON object of the message
+func (JSONCodec) Encode(ctx context.Context, topic string, message interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(encoded, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Decode reads the JSON object of the message into its Go type
+func (JSONCodec) Decode(ctx context.Context, message *messaging.Message, payload interface{}) error {
+	encoded, err := json.Marshal(message.Payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, payload)
+}
+{{- if ne .Serialization "json"}}
+
+// namespace is the {{if eq .Serialization "avro"}}namespace of the Avro records{{else}}package of the Protobuf messages{{end}} of the schemas
+const namespace = "{{.Schemas.Namespace}}"
+
+// sharedTopics are the topics of several message types, whose schemas are registered under
+// the subjects of their {{if eq .Serialization "avro"}}records{{else}}messages{{end}}, as <namespace>.<name>; the schemas of the other
+// topics are registered under <topic>-value
+var sharedTopics = map[string]bool{
+	{{- range .Schemas.SharedTopics}}
+	"{{.}}": true,
+	{{- end}}
+}
+{{- if eq .Serialization "avro"}}
+
+// schemas are the Avro schemas of api/events, by message type
+var schemas = map[string]string{
+	{{- range .Schemas.Avro}}
+	"{{.Type}}": {{printf "%q" .Schema}},
+	{{- end}}
+}
+{{- else}}
+
+// schema is api/events/events.proto, and descriptor its compiled FileDescriptorProto
+const (
+	schema     = {{printf "%q" .Schemas.ProtoSource}}
+	descriptor = {{printf "%q" .Schemas.Descriptor}}
+)
+{{- end}}
+
+// RegistryConfig is the schema registry the schemas of the messages are registered in
+type RegistryConfig struct {
+	// URL is the URL of the schema registry, Confluent Schema Registry or a compatible one
+	URL string
+	// Username and Password authenticate with basic auth, when set
+	Username, Password string
+	// AutoRegister registers the schemas of the messages sent that are not registered yet;
+	// otherwise they must have been registered, with microframework events register
+	AutoRegister bool
+}
+
+// RegistryConfigFrom reads the schema_registry section of the configuration, expanding the
+// environment variables of its values
+func RegistryConfigFrom(section interface{}) RegistryConfig {
+	values, _ := section.(map[string]interface{})
+	value := func(key string) string {
+		s, _ := values[key].(string)
+		return os.ExpandEnv(s)
+	}
+	autoRegister, _ := values["auto_register"].(bool)
+	return RegistryConfig{
+		URL:          value("url"),
+		Username:     value("username"),
+		Password:     value("password"),
+		AutoRegister: autoRegister,
+	}
+}
+
+// RegistryCodec encodes the messages in the {{if eq .Serialization "avro"}}Avro{{else}}Protobuf{{end}} wire format of the schema registry: a zero
+// byte, the ID of the schema in 4 bytes{{if eq .Serialization "protobuf"}}, the indexes of the message in the schema{{end}}, then the encoded message,
+// which the payload carries in its data field
+type RegistryCodec struct {
+	config RegistryConfig
+	client *http.Client
+	{{- if eq .Serialization "avro"}}
+	// parsed are the parsed schemas, by message type
+	parsed map[string]avro.Schema
+	{{- else}}
+	// messages are the descriptors of the messages of the schema, by name
+	messages protoreflect.MessageDescriptors
+	{{- end}}
+
+	mu sync.Mutex
+	// ids are the IDs of the schemas, by subject
+	ids map[string]int
+	{{- if eq .Serialization "avro"}}
+	// writers are the schemas of the messages received, by ID
+	writers map[int]avro.Schema
+	{{- end}}
+}
+
+// NewRegistryCodec creates a codec of the schemas of the registry
+func NewRegistryCodec(config RegistryConfig) (*RegistryCodec, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("the schema registry has no URL")
+	}
+	codec := &RegistryCodec{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		ids:    make(map[string]int),
+		{{- if eq .Serialization "avro"}}
+		parsed:  make(map[string]avro.Schema),
+		writers: make(map[int]avro.Schema),
+		{{- end}}
+	}
+	{{- if eq .Serialization "avro"}}
+	for name, text := range schemas {
+		schema, err := avro.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema of %s: %w", name, err)
+		}
+		codec.parsed[name] = schema
+	}
+	{{- else}}
+	file := &descriptorpb.FileDescriptorProto{}
+	if err := proto.Unmarshal([]byte(descriptor), file); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiled, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	codec.messages = compiled.Messages()
+	{{- end}}
+	return codec, nil
+}
+
+// Encode returns the payload of a message sent to a topic, encoded with its schema, which is
+// registered with AutoRegister
+func (c *RegistryCodec) Encode(ctx context.Context, topic string, message interface{}) (map[string]interface{}, error) {
+	name := typeName(message)
+	{{- if eq .Serialization "avro"}}
+	schema, ok := c.parsed[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no schema", name)
+	}
+	id, err := c.schemaID(ctx, subject(topic, name), schemas[name])
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := avro.Marshal(schema, message)
+	if err != nil {
+		return nil, err
+	}
+	data := binary.BigEndian.AppendUint32([]byte{0}, uint32(id))
+	{{- else}}
+	descriptor := c.messages.ByName(protoreflect.Name(name))
+	if descriptor == nil {
+		return nil, fmt.Errorf("%s has no schema", name)
+	}
+	id, err := c.schemaID(ctx, subject(topic, name), schema)
+	if err != nil {
+		return nil, err
+	}
+	// The message is converted through its JSON object, whose properties are its fields
+	object, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	encoding := dynamicpb.NewMessage(descriptor)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(object, encoding); err != nil {
+		return nil, err
+	}
+	encoded, err := proto.Marshal(encoding)
+	if err != nil {
+		return nil, err
+	}
+	data := binary.BigEndian.AppendUint32([]byte{0}, uint32(id))
+	if index := descriptor.Index(); index == 0 {
+		data = append(data, 0)
+	} else {
+		data = binary.AppendVarint(binary.AppendVarint(data, 1), int64(index))
+	}
+	{{- end}}
+	return map[string]interface{}{"data": append(data, encoded...)}, nil
+}
+
+// Decode reads the payload of a message into its Go type{{if eq .Serialization "avro"}}, with the schema the message was
+// written with{{end}}
+func (c *RegistryCodec) Decode(ctx context.Context, message *messaging.Message, payload interface{}) error {
+	var data []byte
+	switch value := message.Payload["data"].(type) {
+	case []byte:
+		data = value
+	case string:
+		// The payloads sent as JSON carry the data in base64
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		data = decoded
+	}
+	if len(data) < 5 || data[0] != 0 {
+		return fmt.Errorf("the payload is not in the wire format of the schema registry")
+	}
+	{{- if eq .Serialization "avro"}}
+	writer, err := c.writerSchema(ctx, int(binary.BigEndian.Uint32(data[1:5])))
+	if err != nil {
+		return err
+	}
+	if named, ok := writer.(avro.NamedSchema); ok && named.Name() != typeName(payload) {
+		return fmt.Errorf("the payload is a %s, not a %s", named.Name(), typeName(payload))
+	}
+	return avro.Unmarshal(writer, data[5:], payload)
+	{{- else}}
+	data = data[5:]
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return fmt.Errorf("invalid message indexes")
+	}
+	data = data[n:]
+	index := int64(0)
+	for i := int64(0); i < count; i++ {
+		if index, n = binary.Varint(data); n <= 0 {
+			return fmt.Errorf("invalid message indexes")
+		}
+		data = data[n:]
+		if i > 0 {
+			return fmt.Errorf("nested messages are not messages of the schema")
+		}
+	}
+	if index < 0 || int(index) >= c.messages.Len() {
+		return fmt.Errorf("message %d is not in the schema", index)
+	}
+	descriptor := c.messages.Get(int(index))
+	if name := typeName(payload); string(descriptor.Name()) != name {
+		return fmt.Errorf("the payload is a %s, not a %s", descriptor.Name(), name)
+	}
+	decoded := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+	// The message is converted through its JSON object, whose properties are its fields
+	object, err := json.Marshal(protoObject(decoded))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(object, payload)
+	{{- end}}
+}
+
+// schemaID returns the ID of the schema of a subject, registering it with AutoRegister
+func (c *RegistryCodec) schemaID(ctx context.Context, subject, schema string) (int, error) {
+	c.mu.Lock()
+	id, ok := c.ids[subject]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	path := "/subjects/" + url.PathEscape(subject)
+	if c.config.AutoRegister {
+		path += "/versions"
+	}
+	request := map[string]string{"schema": schema{{if eq .Serialization "protobuf"}}, "schemaType": "PROTOBUF"{{end}}}
+	var response struct {
+		ID int ` + "`" + `json:"id"` + "`" + `
+	}
+	if err := c.call(ctx, http.MethodPost, path, request, &response); err != nil {
+		return 0, fmt.Errorf("schema of %s: %w", subject, err)
+	}
+	c.mu.Lock()
+	c.ids[subject] = response.ID
+	c.mu.Unlock()
+	return response.ID, nil
+}
+{{- if eq .Serialization "avro"}}
+
+// writerSchema returns the schema of an ID
+func (c *RegistryCodec) writerSchema(ctx context.Context, id int) (avro.Schema, error) {
+	c.mu.Lock()
+	schema, ok := c.writers[id]
+	c.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+	var response struct {
+		Schema string ` + "`" + `json:"schema"` + "`" + `
+	}
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	schema, err := avro.Parse(response.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %d: %w", id, err)
+	}
+	c.mu.Lock()
+	c.writers[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+{{- end}}
+
+// call sends a request to the schema registry and decodes its response
+func (c *RegistryCodec) call(ctx context.Context, method, path string, request, response interface{}) error {
+	var body bytes.Buffer
+	if request != nil {
+		if err := json.NewEncoder(&body).Encode(request); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.URL, "/")+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		var failure struct {
+			Message string ` + "`" + `json:"message"` + "`" + `
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, failure.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// subject returns the subject the schema of a message type sent to a topic is registered under
+func subject(topic, name string) string {
+	if sharedTopics[topic] {
+		return namespace + "." + name
+	}
+	return topic + "-value"
+}
+
+// typeName returns the name of the type of a message
+func typeName(message interface{}) string {
+	t := reflect.TypeOf(message)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+{{- if eq .Serialization "protobuf"}}
+
+// protoObject returns the JSON object of a message, its properties named after its fields
+// and its 64-bit integers numbers rather than the strings of protojson
+func protoObject(message protoreflect.Message) map[string]interface{} {
+	object := make(map[string]interface{})
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if field.IsList() {
+			list := value.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = protoValue(field, list.Get(i))
+			}
+			object[string(field.Name())] = items
+		} else {
+			object[string(field.Name())] = protoValue(field, value)
+		}
+		return true
+	})
+	return object
+}
+
+// protoValue returns the JSON value of a value of a field
+func protoValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	if field.Message() == nil {
+		return value.Interface()
+	}
+	if field.Message().FullName().Parent() == "google.protobuf" {
+		// The well-known types have their own JSON forms, as RFC 3339 timestamps
+		encoded, err := protojson.Marshal(value.Message().Interface())
+		if err != nil {
+			return nil
+		}
+		var decoded interface{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded
+	}
+	return protoObject(value.Message())
+}
+{{- end}}
+{{- end}}
+`
+
+// AsyncAPIAvroSchemaTemplate is the Avro schema of a message of generate from-asyncapi
+// --serialization avro, in api/events
+const AsyncAPIAvroSchemaTemplate = `{{.Schema}}
+`
+
+// AsyncAPIProtoTemplate is the Protobuf schema of the messages of generate from-asyncapi
+// --serialization protobuf, in api/events/events.proto. The fields keep their numbers when
+// the schema is generated again.
+const AsyncAPIProtoTemplate = `// Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+// The fields keep their numbers when it is generated again, and the numbers of the fields
+// removed are reserved.
+
+syntax = "proto3";
+
+package {{.Schemas.Proto.Package}};
+{{- if .Schemas.Proto.Imports}}
+{{range .Schemas.Proto.Imports}}
+import "{{.}}";
+{{- end}}
+{{- end}}
+{{- range .Schemas.Proto.Messages}}
+{{if .Description}}
+// {{.Description}}
+{{- end}}
+message {{.Name}} {
+{{- if .Reserved}}
+  reserved {{range $i, $number := .Reserved}}{{if $i}}, {{end}}{{$number}}{{end}};
+{{- end}}
+{{- range .Fields}}
+{{- if .Description}}
+  // {{.Description}}
+{{- end}}
+  {{if .Label}}{{.Label}} {{end}}{{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
+}
+{{- end}}
+`
+
+// AsyncAPIRegistryTemplate is api/events/registry.yaml, the subjects the schemas of the
+// messages are registered under by microframework events register
+const AsyncAPIRegistryTemplate = `# Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+# The subjects microframework events register registers the schemas of api/events under: the
+# schema of the messages of a topic under <topic>-value, those of a topic of several message
+# types under <namespace>.<name>. The topics with parameters are registered by the codec when
+# their messages are sent.
+format: {{.Serialization}}
+subjects:
+{{- range .Schemas.Subjects}}
+  - subject: "{{.Subject}}"
+    type: {{.Type}}
+    schema: {{.File}}
+{{- end}}
+`
+
+// AsyncAPITopicsTemplate is deployments/kafka/topics.yaml, the Kafka topics of the channels of
+// generate from-asyncapi as Strimzi KafkaTopic resources
+const AsyncAPITopicsTemplate = `# Code generated by microframework generate from-asyncapi from {{.Source}}. DO NOT EDIT.
+# The Kafka topics of the channels of {{.ServiceName}}, as Strimzi KafkaTopic resources of the
+# Kafka cluster named kafka. Their partitions, replicas and configuration are those of the
+# kafka bindings of the channels; change them there and generate again.
+{{- range .Topics}}
+---
+apiVersion: kafka.strimzi.io/v1beta2
+kind: KafkaTopic
+metadata:
+  name: {{.Resource}}
+  labels:
+    strimzi.io/cluster: kafka
+    app.kubernetes.io/part-of: {{$.ServiceName}}
+spec:
+  topicName: "{{.Name}}"
+  partitions: {{.Partitions}}
+  replicas: {{.Replicas}}
+  {{- if .Config}}
+  config:
+    {{- range .Config}}
+    {{.Key}}: "{{.Value}}"
+    {{- end}}
+  {{- end}}
+{{- end}}
+`
@@ -0,0 +1,66 @@
+package templates
+
+// CatalogInfoTemplate registers the service in the Backstage software catalog: its component,
+// and the APIs of the OpenAPI spec and .proto files it was generated from
+const CatalogInfoTemplate = `# Backstage catalog entities of {{.ServiceName}}, registered with the catalog:register
+# action or from the catalog import page
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: {{.ServiceName}}
+  description: {{.ServiceType}} microservice {{.ServiceName}}
+  tags:
+    - go
+    - microframework
+    - {{.ServiceType}}
+spec:
+  type: service
+  lifecycle: experimental
+  owner: {{if .Owner}}{{.Owner}}{{else}}unknown{{end}}
+{{- if .System}}
+  system: {{.System}}
+{{- end}}
+{{- if or .OpenAPISpec .GRPCServices}}
+  providesApis:
+{{- if .OpenAPISpec}}
+    - {{.ServiceName}}-api
+{{- end}}
+{{- range .GRPCServices}}
+    - {{$.ServiceName}}-{{.Name | lower}}
+{{- end}}
+{{- end}}
+{{- if .OpenAPISpec}}
+---
+apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: {{.ServiceName}}-api
+  description: REST API of {{.ServiceName}}
+spec:
+  type: openapi
+  lifecycle: experimental
+  owner: {{if .Owner}}{{.Owner}}{{else}}unknown{{end}}
+{{- if .System}}
+  system: {{.System}}
+{{- end}}
+  definition:
+    $text: ./{{.OpenAPISpec}}
+{{- end}}
+{{- range .GRPCServices}}
+---
+apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: {{$.ServiceName}}-{{.Name | lower}}
+  description: gRPC service {{.Name}} of {{$.ServiceName}}
+spec:
+  type: grpc
+  lifecycle: experimental
+  owner: {{if $.Owner}}{{$.Owner}}{{else}}unknown{{end}}
+{{- if $.System}}
+  system: {{$.System}}
+{{- end}}
+  definition:
+    $text: ./{{.Proto}}
+{{- end}}
+`
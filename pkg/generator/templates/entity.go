@@ -0,0 +1,494 @@
+package templates
+
+// Templates of the files generated for the entities designed with microframework scaffold
+// entity, rendered with the fields and relations of an entity
+const (
+	EntityModelTemplate = `package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// {{.Name}} is the {{.Name}} entity
+type {{.Name}} struct {
+	ID uint ` + "`json:\"id\" gorm:\"primaryKey\"`" + `
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} {{.ModelTag}}
+{{- end}}
+{{- range .Relations}}
+{{- with .ForeignKey}}
+	{{.GoName}} {{.GoType}} {{.ModelTag}}
+{{- end}}
+	{{.GoName}} {{.GoType}} {{.ModelTag}}
+{{- end}}
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`json:\"updated_at\"`" + `
+	DeletedAt gorm.DeletedAt ` + "`json:\"-\" gorm:\"index\"`" + `
+}
+
+// TableName returns the table name for the model
+func ({{.Name}}) TableName() string {
+	return "{{.PluralSnake}}"
+}
+
+// Create{{.Name}}Request represents the request to create {{.A}} {{.Name}}
+type Create{{.Name}}Request struct {
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} {{.CreateTag}}
+{{- end}}
+{{- range .ForeignKeys}}
+	{{.GoName}} {{.GoType}} {{.CreateTag}}
+{{- end}}
+}
+
+// Update{{.Name}}Request represents the request to update {{.A}} {{.Name}}; the fields left out
+// are kept
+type Update{{.Name}}Request struct {
+{{- range .Fields}}
+	{{.GoName}} *{{.GoType}} {{.UpdateTag}}
+{{- end}}
+{{- range .ForeignKeys}}
+	{{.GoName}} *{{.GoType}} {{.UpdateTag}}
+{{- end}}
+}
+`
+
+	EntityRepositoryTemplate = `package repositories
+
+import (
+	"context"
+
+	"{{.ServiceName}}/internal/models"
+	"gorm.io/gorm"
+)
+
+// {{.Name}}Repository handles the data access of {{.Name}}
+type {{.Name}}Repository struct {
+	db *gorm.DB
+}
+
+// New{{.Name}}Repository creates a new repository
+func New{{.Name}}Repository(db *gorm.DB) *{{.Name}}Repository {
+	return &{{.Name}}Repository{
+		db: db,
+	}
+}
+
+// Create creates a new {{.Name}}
+func (r *{{.Name}}Repository) Create(ctx context.Context, {{.Var}} *models.{{.Name}}) error {
+	return r.db.WithContext(ctx).Create({{.Var}}).Error
+}
+
+// GetByID retrieves {{.A}} {{.Name}} by ID, with its relations
+func (r *{{.Name}}Repository) GetByID(ctx context.Context, id uint) (*models.{{.Name}}, error) {
+	var {{.Var}} models.{{.Name}}
+	err := r.db.WithContext(ctx){{range .Relations}}.Preload("{{.GoName}}"){{end}}.First(&{{.Var}}, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Var}}, nil
+}
+
+// Update updates {{.A}} {{.Name}}
+func (r *{{.Name}}Repository) Update(ctx context.Context, {{.Var}} *models.{{.Name}}) error {
+	return r.db.WithContext(ctx).Save({{.Var}}).Error
+}
+
+// Delete soft deletes {{.A}} {{.Name}}
+func (r *{{.Name}}Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.{{.Name}}{}, id).Error
+}
+
+// List retrieves {{.Plural}} with pagination
+func (r *{{.Name}}Repository) List(ctx context.Context, offset, limit int) ([]*models.{{.Name}}, error) {
+	var {{.PluralVar}} []*models.{{.Name}}
+	err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&{{.PluralVar}}).Error
+	return {{.PluralVar}}, err
+}
+
+// Count returns the total number of {{.Plural}}
+func (r *{{.Name}}Repository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.{{.Name}}{}).Count(&count).Error
+	return count, err
+}
+`
+
+	EntityServiceTemplate = `package services
+
+import (
+	"context"
+
+	"{{.ServiceName}}/internal/models"
+	"{{.ServiceName}}/internal/repositories"
+)
+
+// {{.Name}}Service handles the business logic of {{.Name}}
+type {{.Name}}Service struct {
+	repo *repositories.{{.Name}}Repository
+}
+
+// New{{.Name}}Service creates a new service
+func New{{.Name}}Service(repo *repositories.{{.Name}}Repository) *{{.Name}}Service {
+	return &{{.Name}}Service{
+		repo: repo,
+	}
+}
+
+// Create{{.Name}} creates a new {{.Name}}
+func (s *{{.Name}}Service) Create{{.Name}}(ctx context.Context, req *models.Create{{.Name}}Request) (*models.{{.Name}}, error) {
+	{{.Var}} := &models.{{.Name}}{
+{{- range .Fields}}
+		{{.GoName}}: req.{{.GoName}},
+{{- end}}
+{{- range .ForeignKeys}}
+		{{.GoName}}: req.{{.GoName}},
+{{- end}}
+	}
+
+	if err := s.repo.Create(ctx, {{.Var}}); err != nil {
+		return nil, err
+	}
+	return {{.Var}}, nil
+}
+
+// Get{{.Name}} retrieves {{.A}} {{.Name}} by ID
+func (s *{{.Name}}Service) Get{{.Name}}(ctx context.Context, id uint) (*models.{{.Name}}, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Update{{.Name}} updates the fields of {{.A}} {{.Name}} given in the request
+func (s *{{.Name}}Service) Update{{.Name}}(ctx context.Context, id uint, req *models.Update{{.Name}}Request) (*models.{{.Name}}, error) {
+	{{.Var}}, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+{{- range .Fields}}
+	if req.{{.GoName}} != nil {
+		{{$.Var}}.{{.GoName}} = *req.{{.GoName}}
+	}
+{{- end}}
+{{- range .ForeignKeys}}
+	if req.{{.GoName}} != nil {
+		{{$.Var}}.{{.GoName}} = *req.{{.GoName}}
+	}
+{{- end}}
+
+	if err := s.repo.Update(ctx, {{.Var}}); err != nil {
+		return nil, err
+	}
+	return {{.Var}}, nil
+}
+
+// Delete{{.Name}} deletes {{.A}} {{.Name}}
+func (s *{{.Name}}Service) Delete{{.Name}}(ctx context.Context, id uint) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// List{{.Plural}} retrieves {{.Plural}} with pagination
+func (s *{{.Name}}Service) List{{.Plural}}(ctx context.Context, offset, limit int) ([]*models.{{.Name}}, int64, error) {
+	{{.PluralVar}}, err := s.repo.List(ctx, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return {{.PluralVar}}, count, nil
+}
+`
+
+	EntityHandlerTemplate = `package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"{{.ServiceName}}/internal/models"
+	"{{.ServiceName}}/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// {{.Name}}Handler handles the HTTP requests of {{.Name}}
+type {{.Name}}Handler struct {
+	service *services.{{.Name}}Service
+}
+
+// New{{.Name}}Handler creates a new handler
+func New{{.Name}}Handler(service *services.{{.Name}}Service) *{{.Name}}Handler {
+	return &{{.Name}}Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes of {{.Name}} under /{{.PluralSnake}}
+func (h *{{.Name}}Handler) RegisterRoutes(router gin.IRouter) {
+	group := router.Group("/{{.PluralSnake}}")
+	group.POST("", h.Create{{.Name}})
+	group.GET("", h.List{{.Plural}})
+	group.GET("/:id", h.Get{{.Name}})
+	group.PATCH("/:id", h.Update{{.Name}})
+	group.DELETE("/:id", h.Delete{{.Name}})
+}
+
+// Create{{.Name}} creates {{.A}} {{.Name}}
+func (h *{{.Name}}Handler) Create{{.Name}}(c *gin.Context) {
+	var request models.Create{{.Name}}Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	{{.Var}}, err := h.service.Create{{.Name}}(c.Request.Context(), &request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, {{.Var}})
+}
+
+// Get{{.Name}} returns {{.A}} {{.Name}}
+func (h *{{.Name}}Handler) Get{{.Name}}(c *gin.Context) {
+	id, ok := {{.Var}}ID(c)
+	if !ok {
+		return
+	}
+
+	{{.Var}}, err := h.service.Get{{.Name}}(c.Request.Context(), id)
+	if err != nil {
+		{{.Var}}Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, {{.Var}})
+}
+
+// Update{{.Name}} updates {{.A}} {{.Name}}
+func (h *{{.Name}}Handler) Update{{.Name}}(c *gin.Context) {
+	id, ok := {{.Var}}ID(c)
+	if !ok {
+		return
+	}
+	var request models.Update{{.Name}}Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	{{.Var}}, err := h.service.Update{{.Name}}(c.Request.Context(), id, &request)
+	if err != nil {
+		{{.Var}}Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, {{.Var}})
+}
+
+// Delete{{.Name}} deletes {{.A}} {{.Name}}
+func (h *{{.Name}}Handler) Delete{{.Name}}(c *gin.Context) {
+	id, ok := {{.Var}}ID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete{{.Name}}(c.Request.Context(), id); err != nil {
+		{{.Var}}Error(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// List{{.Plural}} returns a page of {{.Plural}}
+func (h *{{.Name}}Handler) List{{.Plural}}(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	{{.PluralVar}}, total, err := h.service.List{{.Plural}}(c.Request.Context(), (page-1)*limit, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": {{.PluralVar}},
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// {{.Var}}ID returns the ID of the request path, answering 400 when it is invalid
+func {{.Var}}ID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// {{.Var}}Error answers with the status of an error of the {{.Name}} service
+func {{.Var}}Error(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "{{.Name}} not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+`
+
+	EntityProtobufTemplate = `syntax = "proto3";
+
+package {{.ProtoPackage}};
+
+option go_package = "{{.ServiceName}}/protobuf";
+
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+{{- range .ProtoImports}}
+import "{{.}}";
+{{- end}}
+
+// {{.Name}} service definition
+service {{.Name}}Service {
+  rpc Create{{.Name}}(Create{{.Name}}Request) returns ({{.Name}});
+  rpc Get{{.Name}}(Get{{.Name}}Request) returns ({{.Name}});
+  rpc Update{{.Name}}(Update{{.Name}}Request) returns ({{.Name}});
+  rpc Delete{{.Name}}(Delete{{.Name}}Request) returns (google.protobuf.Empty);
+  rpc List{{.Plural}}(List{{.Plural}}Request) returns (List{{.Plural}}Response);
+}
+
+// {{.Name}} entity; add fields at the end of the entity design, the numbers of the fields
+// follow their order
+message {{.Name}} {
+  uint64 id = 1;
+{{- range $i, $field := .Fields}}
+  {{$field.ProtoType}} {{$field.Name}} = {{add $i 2}};
+{{- end}}
+{{- $next := add (len .Fields) 2}}
+{{- range $i, $key := .ForeignKeys}}
+  {{$key.ProtoType}} {{$key.Name}} = {{add $i $next}};
+{{- end}}
+{{- $next = add (len .ForeignKeys) $next}}
+  google.protobuf.Timestamp created_at = {{$next}};
+  google.protobuf.Timestamp updated_at = {{add $next 1}};
+}
+
+// Create {{.Name}} request
+message Create{{.Name}}Request {
+{{- range $i, $field := .Fields}}
+  {{$field.ProtoType}} {{$field.Name}} = {{add $i 1}};
+{{- end}}
+{{- $next = add (len .Fields) 1}}
+{{- range $i, $key := .ForeignKeys}}
+  {{$key.ProtoType}} {{$key.Name}} = {{add $i $next}};
+{{- end}}
+}
+
+// Get {{.Name}} request
+message Get{{.Name}}Request {
+  uint64 id = 1;
+}
+
+// Update {{.Name}} request; the fields left out are kept
+message Update{{.Name}}Request {
+  uint64 id = 1;
+{{- range $i, $field := .Fields}}
+  optional {{$field.ProtoType}} {{$field.Name}} = {{add $i 2}};
+{{- end}}
+{{- $next = add (len .Fields) 2}}
+{{- range $i, $key := .ForeignKeys}}
+  optional {{$key.ProtoType}} {{$key.Name}} = {{add $i $next}};
+{{- end}}
+}
+
+// Delete {{.Name}} request
+message Delete{{.Name}}Request {
+  uint64 id = 1;
+}
+
+// List {{.Plural}} request
+message List{{.Plural}}Request {
+  int32 page = 1;
+  int32 limit = 2;
+}
+
+// List {{.Plural}} response
+message List{{.Plural}}Response {
+  repeated {{.Name}} {{.PluralSnake}} = 1;
+  int64 total = 2;
+  int32 page = 3;
+  int32 limit = 4;
+}
+`
+
+	EntityGraphQLTemplate = `# {{.Name}} entity of {{.ServiceName}}
+# Extends the Query and Mutation types, and uses the Time, JSON and PaginationInput
+# definitions of the schema of the service
+
+type {{.Name}} {
+  id: ID!
+{{- range .Fields}}
+  {{.GraphQLName}}: {{.GraphQLType}}{{if .Required}}!{{end}}
+{{- end}}
+{{- range .Relations}}
+{{- with .ForeignKey}}
+  {{.GraphQLName}}: ID
+{{- end}}
+{{- if or (eq .Kind "has_many") (eq .Kind "many_to_many")}}
+  {{.GraphQLName}}: [{{.Entity}}!]!
+{{- else}}
+  {{.GraphQLName}}: {{.Entity}}
+{{- end}}
+{{- end}}
+  createdAt: Time!
+  updatedAt: Time!
+}
+
+type {{.Name}}ListResponse {
+  items: [{{.Name}}!]!
+  total: Int!
+  page: Int!
+  limit: Int!
+}
+
+input Create{{.Name}}Input {
+{{- range .Fields}}
+  {{.GraphQLName}}: {{.GraphQLType}}{{if .Required}}!{{end}}
+{{- end}}
+{{- range .ForeignKeys}}
+  {{.GraphQLName}}: ID
+{{- end}}
+}
+
+input Update{{.Name}}Input {
+{{- range .Fields}}
+  {{.GraphQLName}}: {{.GraphQLType}}
+{{- end}}
+{{- range .ForeignKeys}}
+  {{.GraphQLName}}: ID
+{{- end}}
+}
+
+extend type Query {
+  {{.Var}}(id: ID!): {{.Name}}
+  {{.PluralVar}}(pagination: PaginationInput): {{.Name}}ListResponse!
+}
+
+extend type Mutation {
+  create{{.Name}}(input: Create{{.Name}}Input!): {{.Name}}!
+  update{{.Name}}(id: ID!, input: Update{{.Name}}Input!): {{.Name}}!
+  delete{{.Name}}(id: ID!): Boolean!
+}
+`
+)
@@ -0,0 +1,162 @@
+package templates
+
+// ErrorTrackingTemplate is the errortracking package of the services generated with
+// --with-errortracking, which initializes the SDK of the provider and reports the panics the
+// recovery middleware catches
+const ErrorTrackingTemplate = `// Package errortracking reports the errors and panics of {{.ServiceName}} to {{if eq .ErrorTrackingProvider "bugsnag"}}Bugsnag{{else}}Sentry{{end}}.
+package errortracking
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	{{- if ne .ErrorTrackingProvider "bugsnag"}}
+	"time"
+	{{- end}}
+{{if eq .ErrorTrackingProvider "bugsnag"}}
+	"github.com/bugsnag/bugsnag-go/v2"
+	{{- else}}
+	"github.com/getsentry/sentry-go"
+	{{- end}}
+)
+
+// service is the name the releases of the service are tagged with
+const service = "{{.ServiceName}}"
+
+// Config is the errortracking section of configs/config.yaml
+type Config struct {
+	{{- if eq .ErrorTrackingProvider "bugsnag"}}
+	// APIKey is the notifier API key of the Bugsnag project; nothing is reported without it
+	APIKey string
+	{{- else}}
+	// DSN is the DSN of the Sentry project; nothing is reported without it
+	DSN string
+	// SampleRate is the fraction of the errors reported, 1 when unset
+	SampleRate float64
+	{{- end}}
+	// Environment is the environment the errors are reported in, development when unset
+	Environment string
+}
+
+// ConfigFrom reads the errortracking section of the configuration, expanding the environment
+// variables of its values
+func ConfigFrom(section interface{}) Config {
+	values, _ := section.(map[string]interface{})
+	value := func(key string) string {
+		s, _ := values[key].(string)
+		return os.ExpandEnv(s)
+	}
+	cfg := Config{
+		{{- if eq .ErrorTrackingProvider "bugsnag"}}
+		APIKey:      value("api_key"),
+		{{- else}}
+		DSN:         value("dsn"),
+		SampleRate:  1,
+		{{- end}}
+		Environment: value("environment"),
+	}
+	{{- if ne .ErrorTrackingProvider "bugsnag"}}
+	if rate, ok := values["sample_rate"].(float64); ok {
+		cfg.SampleRate = rate
+	}
+	{{- end}}
+	if cfg.Environment == "" {
+		cfg.Environment = "development"
+	}
+	return cfg
+}
+
+// Release returns the release the errors are tagged with, {{.ServiceName}}@<version>: the
+// version of the module the binary was built from, or the VCS revision of a development build
+func Release() string {
+	version := "dev"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return service + "@" + version
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return service + "@" + info.Main.Version
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" {
+		version = revision
+		if modified == "true" {
+			version += "-dirty"
+		}
+	}
+	return service + "@" + version
+}
+
+// Init initializes the {{if eq .ErrorTrackingProvider "bugsnag"}}Bugsnag{{else}}Sentry{{end}} SDK. The returned function sends the errors not sent yet, and
+// is called before the service exits.
+func Init(cfg Config) (func(), error) {
+	{{- if eq .ErrorTrackingProvider "bugsnag"}}
+	if cfg.APIKey == "" {
+		return func() {}, nil
+	}
+	bugsnag.Configure(bugsnag.Configuration{
+		APIKey:          cfg.APIKey,
+		ReleaseStage:    cfg.Environment,
+		AppVersion:      Release(),
+		ProjectPackages: []string{"main", service + "/*"},
+		// The panics of the service are reported by the recovery middleware
+		PanicHandler: func() {},
+	})
+	return func() {}, nil
+	{{- else}}
+	if cfg.DSN == "" {
+		return func() {}, nil
+	}
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Environment: cfg.Environment,
+		Release:     Release(),
+		SampleRate:  cfg.SampleRate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Sentry: %w", err)
+	}
+	return func() { sentry.Flush(5 * time.Second) }, nil
+	{{- end}}
+}
+
+// CapturePanic reports a panic recovered while serving r, with the request and tags
+// such as its route and request ID
+func CapturePanic(r *http.Request, recovered interface{}, tags map[string]string) {
+	{{- if eq .ErrorTrackingProvider "bugsnag"}}
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", recovered)
+	}
+	metadata := bugsnag.MetaData{}
+	for key, value := range tags {
+		metadata.Add("request", key, value)
+	}
+	bugsnag.Notify(err, r, metadata, bugsnag.HandledState{
+		SeverityReason:   bugsnag.SeverityReasonUnhandledPanic,
+		OriginalSeverity: bugsnag.SeverityError,
+		Unhandled:        true,
+		Framework:        "gin",
+	})
+	{{- else}}
+	hub := sentry.CurrentHub().Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetRequest(r)
+		scope.SetTags(tags)
+	})
+	hub.RecoverWithContext(r.Context(), recovered)
+	{{- end}}
+}
+`
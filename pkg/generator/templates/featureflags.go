@@ -0,0 +1,28 @@
+package templates
+
+import (
+	"io/fs"
+
+	"github.com/anasamu/go-micro-framework/pkg/featureflags"
+)
+
+// FeatureFlagsFiles are the templates of the internal/featureflags package of the services
+// generated with feature flags: the sources of pkg/featureflags, which have no actions and
+// render as they are, so that the services build without importing the framework
+var FeatureFlagsFiles []string
+
+func init() {
+	entries, err := fs.ReadDir(featureflags.Source, ".")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		source, err := fs.ReadFile(featureflags.Source, entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		name := "internal/featureflags/" + entry.Name()
+		builtin[name] = string(source)
+		FeatureFlagsFiles = append(FeatureFlagsFiles, name)
+	}
+}
@@ -0,0 +1,485 @@
+package templates
+
+// GraphQLTypesTemplate is the Go types of the SDL of generate graphql --from-sdl: structs for
+// the objects and input objects, string types for the enums and marker interfaces for the
+// interfaces and unions
+const GraphQLTypesTemplate = `// Code generated by microframework generate graphql --from-sdl from {{.Source}}. DO NOT EDIT.
+
+package graphql
+{{if .TypesTime}}
+import "time"
+{{end}}
+{{- range .Scalars}}{{if not .Time}}
+
+// {{.Name}} is the value of the {{.Name}} scalar, as sent by the clients
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} = interface{}
+{{- end}}{{end}}
+{{- range .Enums}}
+
+// {{.Name}} is the {{.Name}} enum
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} string
+{{- $enum := .}}
+
+const (
+{{- range .Values}}
+	{{.Const}} {{$enum.Name}} = "{{.Name}}"
+{{- end}}
+)
+{{- end}}
+{{- range .Interfaces}}
+
+// {{.Name}} is the {{.Name}} interface, implemented by {{range $i, $member := .Members}}{{if $i}}, {{end}}{{$member.Name}}{{end}}
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} interface {
+	Is{{.Name}}()
+}
+{{- end}}
+{{- range .Unions}}
+
+// {{.Name}} is the {{.Name}} union of {{range $i, $member := .Members}}{{if $i}}, {{end}}{{$member.Name}}{{end}}
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} interface {
+	Is{{.Name}}()
+}
+{{- end}}
+{{- range .Types}}
+
+// {{.Name}} is the {{.Name}} type
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} struct {
+{{- range .Struct}}
+{{- if .Description}}
+	// {{.Description}}
+{{- end}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{- $type := .}}
+{{- range .Implements}}
+
+func ({{$type.Name}}) Is{{.}}() {}
+{{- end}}
+{{- end}}
+{{- range .Inputs}}
+
+// {{.Name}} is the {{.Name}} input
+{{- if .Doc}}
+//
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- end}}
+type {{.Name}} struct {
+{{- range .Fields}}
+{{- if .Description}}
+	// {{.Description}}
+{{- end}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{- end}}
+{{- range .Resolvers}}{{$type := .}}{{range .Resolved}}{{if .ArgsType}}
+
+// {{.ArgsType}} are the arguments of {{$type.Name}}.{{.Name}}
+type {{.ArgsType}} struct {
+{{- range .Args}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{- end}}{{end}}{{end}}
+`
+
+// GraphQLServerTemplate is the graphql-go schema of the SDL of generate graphql --from-sdl,
+// resolving the fields of the root types, and those with arguments, with the resolvers
+const GraphQLServerTemplate = `// Code generated by microframework generate graphql --from-sdl from {{.Source}}. DO NOT EDIT.
+
+package graphql
+
+import (
+{{- if .Resolvers}}
+	"context"
+	"encoding/json"
+{{- end}}
+	"net/http"
+{{- if .Literal}}
+	"strconv"
+{{- end}}
+{{- if .Time}}
+	"time"
+{{- end}}
+
+	"github.com/graphql-go/graphql"
+{{- if or .Time .Literal}}
+	"github.com/graphql-go/graphql/language/ast"
+{{- end}}
+	"github.com/graphql-go/handler"
+)
+
+// ResolverRoot returns the resolvers of the fields of the schema that are not read from the
+// Go types: those of the root types, and those with arguments
+type ResolverRoot interface {
+{{- range .Resolvers}}
+	{{.Name}}() {{.Name}}Resolver
+{{- end}}
+}
+{{- range .Resolvers}}{{$type := .}}
+
+// {{.Name}}Resolver resolves the fields of {{.Name}}
+type {{.Name}}Resolver interface {
+{{- range .Resolved}}
+	{{.GoName}}(ctx context.Context{{if not $type.Root}}, obj *{{$type.Name}}{{end}}{{if .ArgsType}}, args {{.ArgsType}}{{end}}) ({{.GoType}}, error)
+{{- end}}
+}
+{{- end}}
+
+// NewSchema builds the schema of {{.Source}}, resolving its fields with root
+func NewSchema(root ResolverRoot) (graphql.Schema, error) {
+	var (
+{{- range .Scalars}}
+		{{.Var}} *graphql.Scalar
+{{- end}}
+{{- range .Enums}}
+		{{.Var}} *graphql.Enum
+{{- end}}
+{{- range .Interfaces}}
+		{{.Var}} *graphql.Interface
+{{- end}}
+{{- range .Unions}}
+		{{.Var}} *graphql.Union
+{{- end}}
+{{- range .Objects}}
+		{{.Var}} *graphql.Object
+{{- end}}
+{{- range .Inputs}}
+		{{.Var}} *graphql.InputObject
+{{- end}}
+	)
+{{- range .Scalars}}
+
+	{{.Var}} = graphql.NewScalar(graphql.ScalarConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+{{- if .Time}}
+		Serialize: func(value interface{}) interface{} {
+			switch value := value.(type) {
+			case time.Time:
+				return value.Format(time.RFC3339)
+			case *time.Time:
+				if value != nil {
+					return value.Format(time.RFC3339)
+				}
+			}
+			return nil
+		},
+		ParseValue: func(value interface{}) interface{} {
+			if s, ok := value.(string); ok {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					return t
+				}
+			}
+			return nil
+		},
+		ParseLiteral: func(value ast.Value) interface{} {
+			if s, ok := value.(*ast.StringValue); ok {
+				if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+					return t
+				}
+			}
+			return nil
+		},
+{{- else}}
+		Serialize:    func(value interface{}) interface{} { return value },
+		ParseValue:   func(value interface{}) interface{} { return value },
+		ParseLiteral: literalValue,
+{{- end}}
+	})
+{{- end}}
+{{- range .Enums}}
+
+	{{.Var}} = graphql.NewEnum(graphql.EnumConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+		Values: graphql.EnumValueConfigMap{
+{{- range .Values}}
+			"{{.Name}}": &graphql.EnumValueConfig{Value: {{.Const}}},
+{{- end}}
+		},
+	})
+{{- end}}
+{{- range .Interfaces}}
+
+	{{.Var}} = graphql.NewInterface(graphql.InterfaceConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+{{- range .Fields}}
+				"{{.Name}}": &graphql.Field{
+					Type: {{.GraphQLType}},
+{{- template "arguments" .}}
+				},
+{{- end}}
+			}
+		}),
+{{- template "resolveType" .}}
+	})
+{{- end}}
+{{- range .Unions}}
+
+	{{.Var}} = graphql.NewUnion(graphql.UnionConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+		Types: graphql.UnionTypesThunk(func() []*graphql.Object {
+			return []*graphql.Object{ {{- range $i, $member := .Members}}{{if $i}}, {{end}}{{$member.Var}}{{end -}} }
+		}),
+{{- template "resolveType" .}}
+	})
+{{- end}}
+{{- range .Objects}}{{$type := .}}
+
+	{{.Var}} = graphql.NewObject(graphql.ObjectConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+{{- if .Interfaces}}
+		Interfaces: graphql.InterfacesThunk(func() []*graphql.Interface {
+			return []*graphql.Interface{ {{- range $i, $interface := .Interfaces}}{{if $i}}, {{end}}{{$interface.Var}}{{end -}} }
+		}),
+{{- end}}
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+{{- range .Fields}}
+				"{{.Name}}": &graphql.Field{
+					Type: {{.GraphQLType}},
+{{- template "arguments" .}}
+{{- if or $type.Root .ArgsType}}
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+{{- if .ArgsType}}
+						var args {{.ArgsType}}
+						if err := decodeArgs(p.Args, &args); err != nil {
+							return nil, err
+						}
+{{- end}}
+						return root.{{$type.Name}}().{{.GoName}}(p.Context{{if not $type.Root}}, {{$type.Lower}}Source(p.Source){{end}}{{if .ArgsType}}, args{{end}})
+					},
+{{- end}}
+				},
+{{- end}}
+			}
+		}),
+	})
+{{- end}}
+{{- range .Inputs}}
+
+	{{.Var}} = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "{{.Name}}",
+{{- with .Description}}
+		Description: {{printf "%q" .}},
+{{- end}}
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			return graphql.InputObjectConfigFieldMap{
+{{- range .Fields}}
+				"{{.Name}}": &graphql.InputObjectFieldConfig{
+					Type: {{.GraphQLType}},
+{{- with .Default}}
+					DefaultValue: {{.}},
+{{- end}}
+{{- with .Description}}
+					Description: {{printf "%q" .}},
+{{- end}}
+				},
+{{- end}}
+			}
+		}),
+	})
+{{- end}}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: {{.Query.Var}},
+{{- with .Mutation}}
+		Mutation: {{.Var}},
+{{- end}}
+{{- if .Types}}
+		Types: []graphql.Type{ {{- range $i, $type := .Types}}{{if $i}}, {{end}}{{$type.Var}}{{end -}} },
+{{- end}}
+	})
+}
+
+// NewHandler returns the HTTP handler serving the schema, with GraphiQL for the requests of
+// browsers
+func NewHandler(root ResolverRoot) (http.Handler, error) {
+	schema, err := NewSchema(root)
+	if err != nil {
+		return nil, err
+	}
+	return handler.New(&handler.Config{
+		Schema:   &schema,
+		Pretty:   true,
+		GraphiQL: true,
+	}), nil
+}
+{{- if .Resolvers}}
+
+// decodeArgs decodes the arguments of a field into their Go struct
+func decodeArgs(args map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+{{- end}}
+{{- range .Resolvers}}{{if not .Root}}
+
+// {{.Lower}}Source returns the {{.Name}} a field of {{.Name}} is resolved for
+func {{.Lower}}Source(source interface{}) *{{.Name}} {
+	switch source := source.(type) {
+	case *{{.Name}}:
+		return source
+	case {{.Name}}:
+		return &source
+	}
+	return nil
+}
+{{- end}}{{end}}
+{{- if .Literal}}
+
+// literalValue returns the value of a literal of a scalar of any value
+func literalValue(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(value.Value); err == nil {
+			return n
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(value.Value, 64); err == nil {
+			return f
+		}
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(value.Values))
+		for _, item := range value.Values {
+			list = append(list, literalValue(item))
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = literalValue(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+{{- end}}
+{{- define "arguments"}}
+{{- with .Description}}
+					Description: {{printf "%q" .}},
+{{- end}}
+{{- with .Deprecated}}
+					DeprecationReason: {{printf "%q" .}},
+{{- end}}
+{{- if .Args}}
+					Args: graphql.FieldConfigArgument{
+{{- range .Args}}
+						"{{.Name}}": &graphql.ArgumentConfig{
+							Type: {{.GraphQLType}},
+{{- with .Default}}
+							DefaultValue: {{.}},
+{{- end}}
+{{- with .Description}}
+							Description: {{printf "%q" .}},
+{{- end}}
+						},
+{{- end}}
+					},
+{{- end}}
+{{- end}}
+{{- define "resolveType"}}
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			switch p.Value.(type) {
+{{- range .Members}}
+			case *{{.Name}}, {{.Name}}:
+				return {{.Var}}
+{{- end}}
+			}
+			return nil
+		},
+{{- end}}
+`
+
+// GraphQLResolversTemplate is the resolver stubs of the SDL of generate graphql --from-sdl,
+// written once to be implemented
+const GraphQLResolversTemplate = `package graphql
+
+import (
+	"context"
+	"errors"
+{{- if .ResolversTime}}
+	"time"
+{{- end}}
+)
+
+// Resolver resolves the fields of {{.Source}} that are not read from the Go types.
+// Implement its methods; when the schema changes, the compiler lists the methods to add or
+// change.
+type Resolver struct{}
+{{- range .Resolvers}}
+
+// {{.Name}} returns the resolver of the fields of {{.Name}}
+func (r *Resolver) {{.Name}}() {{.Name}}Resolver {
+	return &{{.Lower}}Resolver{r}
+}
+{{- end}}
+{{- range .Resolvers}}{{$type := .}}
+
+type {{.Lower}}Resolver struct{ *Resolver }
+{{- range .Resolved}}
+
+func (r *{{$type.Lower}}Resolver) {{.GoName}}(ctx context.Context{{if not $type.Root}}, obj *{{$type.Name}}{{end}}{{if .ArgsType}}, args {{.ArgsType}}{{end}}) ({{.GoType}}, error) {
+	return {{.Zero}}, errors.New("not implemented: {{$type.Name}}.{{.Name}}")
+}
+{{- end}}
+{{- end}}
+`
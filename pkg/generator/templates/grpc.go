@@ -0,0 +1,66 @@
+package templates
+
+// GRPCServerTemplate is the server of a gRPC service of the .proto files a service was
+// imported from with microframework new --from-proto, one stub per method
+const GRPCServerTemplate = `package handlers
+
+import (
+	{{- if .HasUnary}}
+	"context"
+	{{- end}}
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	{{range .Imports}}
+	{{.Alias}} "{{.Path}}"
+	{{- end}}
+)
+
+// {{.Name}}Server implements the {{.Name}} service of {{.Proto}}
+type {{.Name}}Server struct {
+	{{.Package}}.Unimplemented{{.Name}}Server
+	// Add service dependencies here
+}
+
+// New{{.Name}}Server creates a new server
+func New{{.Name}}Server() *{{.Name}}Server {
+	return &{{.Name}}Server{}
+}
+{{$service := .}}
+{{- range .Methods}}
+{{- if .Unary}}
+// {{.Name}} handles the unary {{.Name}} call
+func (s *{{$service.Name}}Server) {{.Name}}(ctx context.Context, request *{{.Input}}) (*{{.Output}}, error) {
+	return nil, status.Error(codes.Unimplemented, "{{.Name}} is not implemented")
+}
+{{- else if not .ClientStreaming}}
+// {{.Name}} handles the {{.Name}} call, streaming its responses
+func (s *{{$service.Name}}Server) {{.Name}}(request *{{.Input}}, stream {{$service.Package}}.{{$service.Name}}_{{.Name}}Server) error {
+	return status.Error(codes.Unimplemented, "{{.Name}} is not implemented")
+}
+{{- else}}
+// {{.Name}} handles the {{.Name}} call, streaming its requests{{if .ServerStreaming}} and responses{{end}}
+func (s *{{$service.Name}}Server) {{.Name}}(stream {{$service.Package}}.{{$service.Name}}_{{.Name}}Server) error {
+	return status.Error(codes.Unimplemented, "{{.Name}} is not implemented")
+}
+{{- end}}
+{{end -}}
+`
+
+// GRPCRegistrationTemplate registers the servers of the gRPC services of a service
+const GRPCRegistrationTemplate = `package handlers
+
+import (
+	"google.golang.org/grpc"
+	{{range .Imports}}
+	{{.Alias}} "{{.Path}}"
+	{{- end}}
+)
+
+// RegisterGRPCServers registers the server of each gRPC service on server
+func RegisterGRPCServers(server *grpc.Server) {
+{{- range .Services}}
+	{{.Package}}.Register{{.Name}}Server(server, New{{.Name}}Server())
+{{- end}}
+}
+`
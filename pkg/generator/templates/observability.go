@@ -0,0 +1,309 @@
+package templates
+
+// OTelCollectorTemplate is the OpenTelemetry Collector configuration of the observability
+// bundle: it receives the traces, metrics and logs of the service over OTLP, scrapes its
+// /metrics, and exports the traces to Jaeger and the metrics to Prometheus
+const OTelCollectorTemplate = `# OpenTelemetry Collector of {{.ServiceName}}: OTLP on 4317 (gRPC) and 4318 (HTTP), the
+# metrics of the service scraped from /metrics and served to Prometheus on 8889, the traces
+# sent to Jaeger
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: {{.ServiceName}}
+          scrape_interval: 15s
+          static_configs:
+            - targets: ["{{.ServiceName}}:8080"]
+{{- if and .WithMessaging (or (not .MessagingProvider) (eq .MessagingProvider "kafka"))}}
+        # The lag of the consumer group of {{.ServiceName}}, from kafka-exporter
+        - job_name: {{.ServiceName}}-kafka
+          scrape_interval: 30s
+          static_configs:
+            - targets: ["kafka-exporter:9308"]
+{{- end}}
+{{- if and .WithMessaging (or (not .MessagingProvider) (eq .MessagingProvider "rabbitmq"))}}
+        # The backlog of the queue of {{.ServiceName}}, from the prometheus plugin of RabbitMQ
+        - job_name: {{.ServiceName}}-rabbitmq
+          scrape_interval: 30s
+          static_configs:
+            - targets: ["rabbitmq:15692"]
+{{- end}}
+
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_percentage: 80
+    spike_limit_percentage: 20
+  resource:
+    attributes:
+      - key: service.name
+        value: {{.ServiceName}}
+        action: insert
+  batch: {}
+
+exporters:
+  prometheus:
+    endpoint: 0.0.0.0:8889
+    resource_to_telemetry_conversion:
+      enabled: true
+  otlp/jaeger:
+    endpoint: jaeger:4317
+    tls:
+      insecure: true
+  debug:
+    verbosity: basic
+
+extensions:
+  health_check:
+    endpoint: 0.0.0.0:13133
+
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [memory_limiter, resource, batch]
+      exporters: [otlp/jaeger]
+    metrics:
+      receivers: [otlp, prometheus]
+      processors: [memory_limiter, resource, batch]
+      exporters: [prometheus]
+    logs:
+      receivers: [otlp]
+      processors: [memory_limiter, resource, batch]
+      exporters: [debug]
+`
+
+// ObservabilityPrometheusTemplate is the Prometheus configuration of the observability bundle,
+// scraping the metrics the collector serves
+const ObservabilityPrometheusTemplate = `# Prometheus of {{.ServiceName}}, scraping the metrics of the OpenTelemetry Collector with the
+# job of the service they were scraped or received from
+global:
+  scrape_interval: 15s
+  evaluation_interval: 15s
+
+scrape_configs:
+  - job_name: otel-collector
+    honor_labels: true
+    static_configs:
+      - targets: ["otel-collector:8889"]
+`
+
+// GrafanaDatasourcesTemplate provisions the Prometheus and Jaeger data sources of the
+// dashboards of the observability bundle
+const GrafanaDatasourcesTemplate = `# Data sources of the Grafana of {{.ServiceName}}
+apiVersion: 1
+
+datasources:
+  - name: Prometheus
+    uid: prometheus
+    type: prometheus
+    access: proxy
+    url: http://prometheus:9090
+    isDefault: true
+  - name: Jaeger
+    uid: jaeger
+    type: jaeger
+    access: proxy
+    url: http://jaeger:16686
+`
+
+// GrafanaDashboardsTemplate provisions the dashboards of the observability bundle from the
+// directory they are mounted in
+const GrafanaDashboardsTemplate = `# Dashboards of the Grafana of {{.ServiceName}}, read from grafana/dashboards
+apiVersion: 1
+
+providers:
+  - name: {{.ServiceName}}
+    folder: {{.ServiceName}}
+    type: file
+    disableDeletion: false
+    options:
+      path: /var/lib/grafana/dashboards
+`
+
+// GrafanaDashboardTemplate is the Grafana dashboard of the service: its request rate, errors
+// and duration, and the connection pool of its database and the lag of its queues
+const GrafanaDashboardTemplate = `{
+  "uid": "{{.ServiceName}}",
+  "title": "{{.ServiceName}}",
+  "tags": ["microframework", "{{.ServiceName}}"],
+  "timezone": "browser",
+  "schemaVersion": 39,
+  "version": 1,
+  "refresh": "30s",
+  "time": {"from": "now-1h", "to": "now"},
+  "panels": [
+    {
+      "id": 1,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} request rate",
+      "description": "Requests per second, by status",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 0, "y": 0, "w": 8, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "reqps"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum by (status) (rate(http_requests_total{job=\"{{.ServiceName}}\"}[$__rate_interval]))", "legendFormat": "{{"{{"}}status{{"}}"}}"}
+      ]
+    },
+    {
+      "id": 2,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} error rate",
+      "description": "Share of the requests answered with a 5xx status",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 8, "y": 0, "w": 8, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "percentunit", "min": 0}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum(rate(http_requests_total{job=\"{{.ServiceName}}\", status=~\"5..\"}[$__rate_interval])) / sum(rate(http_requests_total{job=\"{{.ServiceName}}\"}[$__rate_interval]))", "legendFormat": "errors"}
+      ]
+    },
+    {
+      "id": 3,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} request duration",
+      "description": "Percentiles of the request duration",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 16, "y": 0, "w": 8, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "s"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "histogram_quantile(0.5, sum by (le) (rate(http_request_duration_seconds_bucket{job=\"{{.ServiceName}}\"}[$__rate_interval])))", "legendFormat": "p50"},
+        {"refId": "B", "expr": "histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket{job=\"{{.ServiceName}}\"}[$__rate_interval])))", "legendFormat": "p95"},
+        {"refId": "C", "expr": "histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{job=\"{{.ServiceName}}\"}[$__rate_interval])))", "legendFormat": "p99"}
+      ]
+    }
+{{- if .WithDatabase}},
+    {
+      "id": 4,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} database connections",
+      "description": "Connections of the database pool in use, idle, and at most open",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 0, "y": 8, "w": 12, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "short", "min": 0}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum(database_connections_active{job=\"{{.ServiceName}}\"})", "legendFormat": "in use"},
+        {"refId": "B", "expr": "sum(go_sql_idle_connections{job=\"{{.ServiceName}}\"})", "legendFormat": "idle"},
+        {"refId": "C", "expr": "sum(go_sql_max_open_connections{job=\"{{.ServiceName}}\"})", "legendFormat": "max open"}
+      ]
+    },
+    {
+      "id": 5,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} database connection wait",
+      "description": "Time per second the requests waited for a connection of the pool",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 12, "y": 8, "w": 12, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "s", "min": 0}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum(rate(go_sql_wait_duration_seconds_total{job=\"{{.ServiceName}}\"}[$__rate_interval]))", "legendFormat": "wait"}
+      ]
+    }
+{{- end}}
+{{- if .WithMessaging}}
+{{- if or (not .MessagingProvider) (eq .MessagingProvider "kafka")}},
+    {
+      "id": 6,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} consumer lag",
+      "description": "Messages of the Kafka topics the consumer group of {{.ServiceName}} has yet to consume",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 0, "y": 16, "w": 12, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "short", "min": 0}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum by (topic) (kafka_consumergroup_lag{consumergroup=\"{{.ServiceName}}\"})", "legendFormat": "{{"{{"}}topic{{"}}"}}"}
+      ]
+    }
+{{- end}}
+{{- if or (not .MessagingProvider) (eq .MessagingProvider "rabbitmq")}},
+    {
+      "id": 7,
+      "type": "timeseries",
+      "title": "{{.ServiceName}} queue backlog",
+      "description": "Messages of the RabbitMQ queue of {{.ServiceName}} ready to be delivered and not acknowledged",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"x": 12, "y": 16, "w": 12, "h": 8},
+      "fieldConfig": {"defaults": {"unit": "short", "min": 0}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum(rabbitmq_queue_messages_ready{queue=\"{{.ServiceName}}-queue\"})", "legendFormat": "ready"},
+        {"refId": "B", "expr": "sum(rabbitmq_queue_messages_unacked{queue=\"{{.ServiceName}}-queue\"})", "legendFormat": "unacknowledged"}
+      ]
+    }
+{{- end}}
+{{- end}}
+  ]
+}
+`
+
+// ObservabilityComposeTemplate runs the observability bundle with the services of
+// deployments/docker/docker-compose.yml, whose directory its paths are relative to
+const ObservabilityComposeTemplate = `# The OpenTelemetry Collector, Prometheus, Jaeger and Grafana of {{.ServiceName}}, run with the
+# service:
+#
+#   docker compose -f deployments/docker/docker-compose.yml -f deployments/observability/docker-compose.yml up
+#
+# Grafana is on http://localhost:3000 with the {{.ServiceName}} dashboard, Jaeger on
+# http://localhost:16686
+services:
+  {{.ServiceName}}:
+    environment:
+      - OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4317
+      - OTEL_SERVICE_NAME={{.ServiceName}}
+    depends_on:
+      - otel-collector
+
+  otel-collector:
+    image: otel/opentelemetry-collector-contrib:0.111.0
+    command: ["--config=/etc/otelcol-contrib/config.yaml"]
+    volumes:
+      - ../observability/otel-collector.yaml:/etc/otelcol-contrib/config.yaml:ro
+    ports:
+      - "4317:4317"
+      - "4318:4318"
+    depends_on:
+      - jaeger
+    networks:
+      - {{.ServiceName}}-network
+
+  prometheus:
+    image: prom/prometheus:v2.54.1
+    volumes:
+      - ../observability/prometheus.yml:/etc/prometheus/prometheus.yml:ro
+    ports:
+      - "9090:9090"
+    depends_on:
+      - otel-collector
+    networks:
+      - {{.ServiceName}}-network
+
+  jaeger:
+    image: jaegertracing/all-in-one:1.62.0
+    environment:
+      - COLLECTOR_OTLP_ENABLED=true
+    ports:
+      - "16686:16686"
+    networks:
+      - {{.ServiceName}}-network
+
+  grafana:
+    image: grafana/grafana:11.2.2
+    environment:
+      - GF_AUTH_ANONYMOUS_ENABLED=true
+      - GF_AUTH_ANONYMOUS_ORG_ROLE=Viewer
+    volumes:
+      - ../observability/grafana/provisioning:/etc/grafana/provisioning:ro
+      - ../observability/grafana/dashboards:/var/lib/grafana/dashboards:ro
+    ports:
+      - "3000:3000"
+    depends_on:
+      - prometheus
+      - jaeger
+    networks:
+      - {{.ServiceName}}-network
+`
@@ -0,0 +1,218 @@
+package templates
+
+// KeycloakRealmTemplate is the Keycloak realm export of the services authenticating with
+// --with-auth oidc, which the Keycloak of the local docker-compose imports at startup: the realm
+// of the service, its confidential client with the redirect URLs and scopes of the service, its
+// realm roles, and a development user holding them all. The client scopes are declared, the
+// standard ones with them, so that the tokens carry the scopes of the service and the roles in
+// realm_access.roles.
+const KeycloakRealmTemplate = `{
+  "realm": "{{.ServiceName}}",
+  "displayName": "{{.ServiceName}}",
+  "enabled": true,
+  "sslRequired": "external",
+  "accessTokenLifespan": 300,
+  "roles": {
+    "realm": [
+      {{- range $i, $role := .OIDC.Roles}}{{if $i}},{{end}}
+      {
+        "name": {{printf "%q" $role}},
+        "description": "{{$.ServiceName}} {{$role}}"
+      }
+      {{- end}}
+    ]
+  },
+  "clientScopes": [
+    {
+      "name": "basic",
+      "description": "The subject and authentication time of the tokens",
+      "protocol": "openid-connect",
+      "attributes": {
+        "include.in.token.scope": "false",
+        "display.on.consent.screen": "false"
+      },
+      "protocolMappers": [
+        {
+          "name": "sub",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-sub-mapper",
+          "config": {
+            "access.token.claim": "true",
+            "introspection.token.claim": "true"
+          }
+        }
+      ]
+    },
+    {
+      "name": "profile",
+      "description": "The name and username of the user",
+      "protocol": "openid-connect",
+      "attributes": {
+        "include.in.token.scope": "true",
+        "display.on.consent.screen": "true"
+      },
+      "protocolMappers": [
+        {
+          "name": "username",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-usermodel-attribute-mapper",
+          "config": {
+            "user.attribute": "username",
+            "claim.name": "preferred_username",
+            "jsonType.label": "String",
+            "id.token.claim": "true",
+            "access.token.claim": "true",
+            "userinfo.token.claim": "true"
+          }
+        },
+        {
+          "name": "full name",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-full-name-mapper",
+          "config": {
+            "id.token.claim": "true",
+            "access.token.claim": "true",
+            "userinfo.token.claim": "true"
+          }
+        }
+      ]
+    },
+    {
+      "name": "email",
+      "description": "The email address of the user",
+      "protocol": "openid-connect",
+      "attributes": {
+        "include.in.token.scope": "true",
+        "display.on.consent.screen": "true"
+      },
+      "protocolMappers": [
+        {
+          "name": "email",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-usermodel-attribute-mapper",
+          "config": {
+            "user.attribute": "email",
+            "claim.name": "email",
+            "jsonType.label": "String",
+            "id.token.claim": "true",
+            "access.token.claim": "true",
+            "userinfo.token.claim": "true"
+          }
+        },
+        {
+          "name": "email verified",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-usermodel-property-mapper",
+          "config": {
+            "user.attribute": "emailVerified",
+            "claim.name": "email_verified",
+            "jsonType.label": "boolean",
+            "id.token.claim": "true",
+            "access.token.claim": "true",
+            "userinfo.token.claim": "true"
+          }
+        }
+      ]
+    },
+    {
+      "name": "roles",
+      "description": "The realm roles of the user, in realm_access.roles",
+      "protocol": "openid-connect",
+      "attributes": {
+        "include.in.token.scope": "false",
+        "display.on.consent.screen": "false"
+      },
+      "protocolMappers": [
+        {
+          "name": "realm roles",
+          "protocol": "openid-connect",
+          "protocolMapper": "oidc-usermodel-realm-role-mapper",
+          "config": {
+            "claim.name": "realm_access.roles",
+            "jsonType.label": "String",
+            "multivalued": "true",
+            "access.token.claim": "true",
+            "introspection.token.claim": "true"
+          }
+        }
+      ]
+    }
+    {{- range .OIDC.ServiceScopes}},
+    {
+      "name": {{printf "%q" .}},
+      "description": "{{$.ServiceName}} scope {{.}}",
+      "protocol": "openid-connect",
+      "attributes": {
+        "include.in.token.scope": "true",
+        "display.on.consent.screen": "true"
+      }
+    }
+    {{- end}}
+  ],
+  "clients": [
+    {
+      "clientId": "{{.ServiceName}}",
+      "name": "{{.ServiceName}}",
+      "enabled": true,
+      "protocol": "openid-connect",
+      "publicClient": false,
+      "clientAuthenticatorType": "client-secret",
+      "secret": "{{.ServiceName}}-dev-secret",
+      "standardFlowEnabled": true,
+      "directAccessGrantsEnabled": true,
+      "serviceAccountsEnabled": false,
+      "redirectUris": [
+        {{- range $i, $url := .OIDC.RedirectURLs}}{{if $i}},{{end}}
+        {{printf "%q" $url}}
+        {{- end}}
+      ],
+      "webOrigins": ["+"],
+      "attributes": {
+        "post.logout.redirect.uris": "+"
+      },
+      "defaultClientScopes": ["basic", "profile", "email", "roles"],
+      "optionalClientScopes": [
+        {{- range $i, $scope := .OIDC.ServiceScopes}}{{if $i}}, {{end}}{{printf "%q" $scope}}{{end -}}
+      ]
+    }
+  ],
+  "users": [
+    {
+      "username": "dev",
+      "enabled": true,
+      "email": "dev@{{.ServiceName}}.local",
+      "emailVerified": true,
+      "firstName": "Dev",
+      "lastName": "User",
+      "credentials": [
+        {
+          "type": "password",
+          "value": "dev",
+          "temporary": false
+        }
+      ],
+      "realmRoles": [
+        {{- range $i, $role := .OIDC.Roles}}{{if $i}}, {{end}}{{printf "%q" $role}}{{end -}}
+      ]
+    }
+  ]
+}
+`
+
+// OIDCClientRegistrationTemplate is the client metadata of the services authenticating with
+// --with-auth oidc, as RFC 7591 dynamic client registration defines it, to register the
+// service with the OpenID Connect providers other than Keycloak
+const OIDCClientRegistrationTemplate = `{
+  "client_name": "{{.ServiceName}}",
+  "application_type": "web",
+  "redirect_uris": [
+    {{- range $i, $url := .OIDC.RedirectURLs}}{{if $i}},{{end}}
+    {{printf "%q" $url}}
+    {{- end}}
+  ],
+  "grant_types": ["authorization_code", "refresh_token"],
+  "response_types": ["code"],
+  "token_endpoint_auth_method": "client_secret_basic",
+  "scope": "{{range $i, $scope := .OIDC.Scopes}}{{if $i}} {{end}}{{$scope}}{{end}}"
+}
+`
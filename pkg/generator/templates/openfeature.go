@@ -0,0 +1,384 @@
+package templates
+
+// OpenFeatureProviderTemplate is the flags package of the services generated with
+// --with-featureflags openfeature, which registers the OpenFeature provider the flags are
+// evaluated with: the env and file providers of the framework, flagd or LaunchDarkly
+const OpenFeatureProviderTemplate = `// Package flags evaluates the feature flags of {{.ServiceName}} with OpenFeature, from
+{{- if eq .OpenFeatureProvider "flagd"}} flagd
+{{- else if eq .OpenFeatureProvider "launchdarkly"}} LaunchDarkly
+{{- else if eq .OpenFeatureProvider "file"}} configs/flags.yaml and the FEATURE_ environment variables
+{{- else}} the FEATURE_ environment variables{{end}}.
+package flags
+
+import (
+	{{- if or (eq .OpenFeatureProvider "env") (eq .OpenFeatureProvider "file")}}
+	"context"
+	{{- end}}
+	"fmt"
+	"os"
+	{{- if eq .OpenFeatureProvider "flagd"}}
+	"strconv"
+	{{- end}}
+	{{- if or (eq .OpenFeatureProvider "file") (eq .OpenFeatureProvider "launchdarkly")}}
+	"time"
+	{{- end}}
+
+	"github.com/open-feature/go-sdk/openfeature"
+	{{- if eq .OpenFeatureProvider "flagd"}}
+	flagd "github.com/open-feature/go-sdk-contrib/providers/flagd/pkg"
+	{{- end}}
+	{{- if or (eq .OpenFeatureProvider "env") (eq .OpenFeatureProvider "file")}}
+
+	"{{.ServiceName}}/internal/featureflags"
+	{{- end}}
+)
+
+// Config is the optional.featureflags section of configs/config.yaml
+type Config struct {
+	{{- if or (eq .OpenFeatureProvider "env") (eq .OpenFeatureProvider "file")}}
+	// EnvPrefix is the prefix of the environment variables overriding the flags, FEATURE_ when
+	// unset
+	EnvPrefix string
+	{{- end}}
+	{{- if eq .OpenFeatureProvider "file"}}
+	// File is the flags file, configs/flags.yaml when unset, reloaded every RefreshInterval
+	File            string
+	RefreshInterval time.Duration
+	{{- else if eq .OpenFeatureProvider "flagd"}}
+	// FlagdHost and FlagdPort are the address of the flagd daemon, localhost:8013 when unset
+	FlagdHost string
+	FlagdPort uint16
+	{{- else if eq .OpenFeatureProvider "launchdarkly"}}
+	// SDKKey is the server-side SDK key of the LaunchDarkly environment; the flags have their
+	// default values without it
+	SDKKey string
+	{{- end}}
+}
+
+// ConfigFrom reads the optional.featureflags section of the configuration, expanding the
+// environment variables of its values
+func ConfigFrom(section interface{}) Config {
+	values, _ := section.(map[string]interface{})
+	{{- if or (eq .OpenFeatureProvider "env") (eq .OpenFeatureProvider "file")}}
+	providers, _ := values["providers"].(map[string]interface{})
+	env, _ := providers["env"].(map[string]interface{})
+	cfg := Config{EnvPrefix: stringValue(env, "prefix")}
+	if cfg.EnvPrefix == "" {
+		cfg.EnvPrefix = "FEATURE_"
+	}
+	{{- if eq .OpenFeatureProvider "file"}}
+	file, _ := providers["file"].(map[string]interface{})
+	cfg.File = stringValue(file, "path")
+	if cfg.File == "" {
+		cfg.File = "configs/flags.yaml"
+	}
+	cfg.RefreshInterval, _ = time.ParseDuration(stringValue(file, "refresh_interval"))
+	{{- end}}
+	{{- else}}
+	openFeature, _ := values["openfeature"].(map[string]interface{})
+	settings, _ := openFeature["{{.OpenFeatureProvider}}"].(map[string]interface{})
+	{{- if eq .OpenFeatureProvider "flagd"}}
+	cfg := Config{FlagdHost: stringValue(settings, "host"), FlagdPort: 8013}
+	if cfg.FlagdHost == "" {
+		cfg.FlagdHost = "localhost"
+	}
+	switch port := settings["port"].(type) {
+	case int:
+		cfg.FlagdPort = uint16(port)
+	case float64:
+		cfg.FlagdPort = uint16(port)
+	case string:
+		if parsed, err := strconv.ParseUint(os.ExpandEnv(port), 10, 16); err == nil {
+			cfg.FlagdPort = uint16(parsed)
+		}
+	}
+	{{- else}}
+	cfg := Config{SDKKey: stringValue(settings, "sdk_key")}
+	{{- end}}
+	{{- end}}
+	return cfg
+}
+
+// stringValue reads a string setting, expanding its environment variables
+func stringValue(values map[string]interface{}, key string) string {
+	s, _ := values[key].(string)
+	return os.ExpandEnv(s)
+}
+
+// Init registers the OpenFeature provider of the flags. The returned function shuts the
+// provider down, and is called before the service exits.
+func Init(cfg Config) (func(), error) {
+	{{- if or (eq .OpenFeatureProvider "env") (eq .OpenFeatureProvider "file")}}
+	// Environment variables override the flags file, as with the Bootstrap
+	manager := featureflags.NewManager(featureflags.NewEnvProvider(cfg.EnvPrefix))
+	{{- if eq .OpenFeatureProvider "file"}}
+	manager.AddProvider(featureflags.NewFileProvider(cfg.File, cfg.RefreshInterval))
+	{{- end}}
+	if err := manager.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load the feature flags: %w", err)
+	}
+	if err := openfeature.SetProviderAndWait(featureflags.NewOpenFeatureProvider(manager)); err != nil {
+		manager.Close()
+		return nil, fmt.Errorf("failed to register the feature flag provider: %w", err)
+	}
+	return func() {
+		openfeature.Shutdown()
+		manager.Close()
+	}, nil
+	{{- else if eq .OpenFeatureProvider "flagd"}}
+	provider, err := flagd.NewProvider(flagd.WithHost(cfg.FlagdHost), flagd.WithPort(cfg.FlagdPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure flagd: %w", err)
+	}
+	if err := openfeature.SetProviderAndWait(provider); err != nil {
+		return nil, fmt.Errorf("failed to connect to flagd at %s:%d: %w", cfg.FlagdHost, cfg.FlagdPort, err)
+	}
+	return openfeature.Shutdown, nil
+	{{- else}}
+	if cfg.SDKKey == "" {
+		// Without an SDK key, the NoopProvider of OpenFeature returns the default values
+		return func() {}, nil
+	}
+	provider, err := newLaunchDarklyProvider(cfg.SDKKey, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LaunchDarkly: %w", err)
+	}
+	if err := openfeature.SetProviderAndWait(provider); err != nil {
+		return nil, fmt.Errorf("failed to register the feature flag provider: %w", err)
+	}
+	return openfeature.Shutdown, nil
+	{{- end}}
+}
+`
+
+// OpenFeatureFlagsTemplate is the typed accessors of the flags of the generated service,
+// which read them with the targeting context of the request
+const OpenFeatureFlagsTemplate = `package flags
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// The keys of the flags of the service
+const (
+	NewCheckoutKey = "new-checkout"
+	MaxPageSizeKey = "max-page-size"
+)
+
+// client evaluates the flags with the provider Init registers, and the targeting context
+// Middleware puts in the request context
+var client = openfeature.NewClient("{{.ServiceName}}")
+
+// NewCheckout reports whether the new checkout is enabled for the user of ctx; it is
+// disabled when the flag cannot be evaluated
+func NewCheckout(ctx context.Context) bool {
+	enabled, _ := client.BooleanValue(ctx, NewCheckoutKey, false, openfeature.EvaluationContext{})
+	return enabled
+}
+
+// MaxPageSize is the largest page the lists return to the user of ctx, 100 when the flag
+// cannot be evaluated
+func MaxPageSize(ctx context.Context) int64 {
+	size, _ := client.IntValue(ctx, MaxPageSizeKey, 100, openfeature.EvaluationContext{})
+	return size
+}
+`
+
+// OpenFeatureMiddlewareTemplate is the middleware of the flags package, which sets the
+// targeting context of the flags from the claims of the authenticated user
+const OpenFeatureMiddlewareTemplate = `package flags
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// Middleware sets the targeting context of the flags evaluated while serving a request, so
+// that flags can target users, roles and permissions. It runs after the authentication
+// middleware, whose claims it reads: the targeting key is the user ID, else the X-User-ID
+// header, and the roles and permissions of the user are the roles and permissions attributes.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetString("user_id")
+		if key == "" {
+			key = c.GetHeader("X-User-ID")
+		}
+		attributes := map[string]interface{}{}
+		if roles := c.GetStringSlice("user_roles"); len(roles) > 0 {
+			attributes["roles"] = roles
+		}
+		if permissions := c.GetStringSlice("user_permissions"); len(permissions) > 0 {
+			attributes["permissions"] = permissions
+		}
+
+		evalCtx := openfeature.NewEvaluationContext(key, attributes)
+		c.Request = c.Request.WithContext(openfeature.WithTransactionContext(c.Request.Context(), evalCtx))
+		c.Next()
+	}
+}
+`
+
+// OpenFeatureLaunchDarklyTemplate is the OpenFeature provider of the flags package over the
+// LaunchDarkly server-side SDK
+const OpenFeatureLaunchDarklyTemplate = `package flags
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// launchDarklyProvider evaluates the flags with the LaunchDarkly SDK. The targeting key of
+// the OpenFeature context is the key of the LaunchDarkly context, an anonymous one without
+// it, and the other attributes are its attributes.
+type launchDarklyProvider struct {
+	client *ld.LDClient
+}
+
+// newLaunchDarklyProvider connects to LaunchDarkly, waiting up to wait for the flags
+func newLaunchDarklyProvider(sdkKey string, wait time.Duration) (*launchDarklyProvider, error) {
+	client, err := ld.MakeClient(sdkKey, wait)
+	if err != nil {
+		return nil, err
+	}
+	return &launchDarklyProvider{client: client}, nil
+}
+
+// Metadata names the provider
+func (p *launchDarklyProvider) Metadata() openfeature.Metadata {
+	return openfeature.Metadata{Name: "launchdarkly"}
+}
+
+// Hooks returns no hooks
+func (p *launchDarklyProvider) Hooks() []openfeature.Hook {
+	return nil
+}
+
+// Init fails when the flags could not be loaded
+func (p *launchDarklyProvider) Init(evaluationContext openfeature.EvaluationContext) error {
+	if !p.client.Initialized() {
+		return fmt.Errorf("the flags of LaunchDarkly are not loaded")
+	}
+	return nil
+}
+
+// Shutdown closes the client, sending its analytics events
+func (p *launchDarklyProvider) Shutdown() {
+	p.client.Close()
+}
+
+// BooleanEvaluation evaluates a boolean flag
+func (p *launchDarklyProvider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, flatCtx openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
+	value, detail, _ := p.client.BoolVariationDetailCtx(ctx, flag, launchDarklyContext(flatCtx), defaultValue)
+	return openfeature.BoolResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail(flag, detail)}
+}
+
+// StringEvaluation evaluates a string flag
+func (p *launchDarklyProvider) StringEvaluation(ctx context.Context, flag string, defaultValue string, flatCtx openfeature.FlattenedContext) openfeature.StringResolutionDetail {
+	value, detail, _ := p.client.StringVariationDetailCtx(ctx, flag, launchDarklyContext(flatCtx), defaultValue)
+	return openfeature.StringResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail(flag, detail)}
+}
+
+// FloatEvaluation evaluates a numeric flag
+func (p *launchDarklyProvider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, flatCtx openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
+	value, detail, _ := p.client.Float64VariationDetailCtx(ctx, flag, launchDarklyContext(flatCtx), defaultValue)
+	return openfeature.FloatResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail(flag, detail)}
+}
+
+// IntEvaluation evaluates an integer flag
+func (p *launchDarklyProvider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, flatCtx openfeature.FlattenedContext) openfeature.IntResolutionDetail {
+	value, detail, _ := p.client.IntVariationDetailCtx(ctx, flag, launchDarklyContext(flatCtx), int(defaultValue))
+	return openfeature.IntResolutionDetail{Value: int64(value), ProviderResolutionDetail: resolutionDetail(flag, detail)}
+}
+
+// ObjectEvaluation evaluates a JSON flag
+func (p *launchDarklyProvider) ObjectEvaluation(ctx context.Context, flag string, defaultValue any, flatCtx openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	value, detail, _ := p.client.JSONVariationDetailCtx(ctx, flag, launchDarklyContext(flatCtx), ldvalue.CopyArbitraryValue(defaultValue))
+	return openfeature.InterfaceResolutionDetail{Value: value.AsArbitraryValue(), ProviderResolutionDetail: resolutionDetail(flag, detail)}
+}
+
+// launchDarklyContext is the LaunchDarkly context of a flattened OpenFeature context
+func launchDarklyContext(flatCtx openfeature.FlattenedContext) ldcontext.Context {
+	key, _ := flatCtx[openfeature.TargetingKey].(string)
+	builder := ldcontext.NewBuilder(key)
+	if key == "" {
+		builder.Key("anonymous").Anonymous(true)
+	}
+	for name, value := range flatCtx {
+		if name != openfeature.TargetingKey {
+			builder.SetValue(name, ldvalue.CopyArbitraryValue(value))
+		}
+	}
+	return builder.Build()
+}
+
+// resolutionDetail is the OpenFeature resolution of a LaunchDarkly evaluation
+func resolutionDetail(flag string, detail ldreason.EvaluationDetail) openfeature.ProviderResolutionDetail {
+	resolution := openfeature.ProviderResolutionDetail{}
+	if index, ok := detail.VariationIndex.Get(); ok {
+		resolution.Variant = fmt.Sprint(index)
+	}
+	switch detail.Reason.GetKind() {
+	case ldreason.EvalReasonOff:
+		resolution.Reason = openfeature.DisabledReason
+	case ldreason.EvalReasonTargetMatch, ldreason.EvalReasonRuleMatch:
+		resolution.Reason = openfeature.TargetingMatchReason
+	case ldreason.EvalReasonFallthrough:
+		resolution.Reason = openfeature.DefaultReason
+	case ldreason.EvalReasonError:
+		resolution.Reason = openfeature.ErrorReason
+		switch detail.Reason.GetErrorKind() {
+		case ldreason.EvalErrorFlagNotFound:
+			resolution.ResolutionError = openfeature.NewFlagNotFoundResolutionError(fmt.Sprintf("flag %s not found", flag))
+		case ldreason.EvalErrorWrongType:
+			resolution.ResolutionError = openfeature.NewTypeMismatchResolutionError(fmt.Sprintf("flag %s is not of the type evaluated", flag))
+		case ldreason.EvalErrorClientNotReady:
+			resolution.ResolutionError = openfeature.NewProviderNotReadyResolutionError("the flags of LaunchDarkly are not loaded")
+		default:
+			resolution.ResolutionError = openfeature.NewGeneralResolutionError(string(detail.Reason.GetErrorKind()))
+		}
+	default:
+		resolution.Reason = openfeature.Reason(detail.Reason.GetKind())
+	}
+	return resolution
+}
+`
+
+// FlagdFlagsTemplate is the flags of the generated service in the flag definition format of
+// flagd, which the flagd container of docker-compose serves
+const FlagdFlagsTemplate = `{
+  "$schema": "https://flagd.dev/schema/v0/flags.json",
+  "flags": {
+    "new-checkout": {
+      "state": "ENABLED",
+      "variants": {
+        "enabled": true,
+        "disabled": false
+      },
+      "defaultVariant": "disabled",
+      "targeting": {
+        "if": [
+          {"in": [{"var": "targetingKey"}, ["beta-tester"]]},
+          "enabled",
+          {"fractional": [["enabled", 10], ["disabled", 90]]}
+        ]
+      }
+    },
+    "max-page-size": {
+      "state": "ENABLED",
+      "variants": {
+        "default": 100
+      },
+      "defaultVariant": "default"
+    }
+  }
+}
+`
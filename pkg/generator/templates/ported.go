@@ -0,0 +1,79 @@
+package templates
+
+// PortedHandlerTemplate is the handler of the endpoints of the project a service was ported
+// from with microframework import project, one stub per route
+const PortedHandlerTemplate = `package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PortedHandler handles the endpoints of the {{.Framework}} project the service was ported
+// from, {{.Source}}. PORTING.md lists what their handlers did that is left to port.
+type PortedHandler struct {
+	// Add service dependencies here
+}
+
+// NewPortedHandler creates a new handler
+func NewPortedHandler() *PortedHandler {
+	return &PortedHandler{}
+}
+
+// RegisterRoutes registers the routes of the endpoints of {{.Source}}
+func (h *PortedHandler) RegisterRoutes(router gin.IRouter) {
+{{- range .Endpoints}}
+	router.{{.Method}}("{{.Route}}", h.{{.Name}})
+{{- end}}
+}
+{{range .Endpoints}}
+// {{.Name}} handles {{if eq .Method "Any"}}every method of{{else}}{{.Method}}{{end}} {{.Path}}, ported from {{.Origin}}
+{{- if .Handler}}
+//
+// It was served by {{.Handler}}.
+{{- end}}
+func (h *PortedHandler) {{.Name}}(c *gin.Context) {
+	c.JSON(http.StatusNotImplemented, gin.H{"error": "{{.Name}} is not implemented"})
+}
+{{end -}}
+`
+
+// PortedServicesTemplate is the stubs of the service interfaces of the project a service was
+// ported from with microframework import project
+const PortedServicesTemplate = `package services
+{{- if .Imports}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+{{- range .Services}}
+{{- $service := .}}
+
+// Ported{{.Name}} implements the {{.Name}} interface of the {{$.Framework}} project the
+// service was ported from, declared at {{.Origin}}. Port the implementation of its
+// methods; the types of the project are interface{} until they are ported too.
+type Ported{{.Name}} struct{}
+
+// NewPorted{{.Name}} creates a new service
+func NewPorted{{.Name}}() *Ported{{.Name}} {
+	return &Ported{{.Name}}{}
+}
+{{- range .Methods}}
+
+func (s *Ported{{$service.Name}}) {{.Name}}({{range $i, $param := .Params}}{{if $i}}, {{end}}{{$param.Name}} {{$param.Type}}{{end}}){{if .Results}} ({{range $i, $result := .Results}}{{if $i}}, {{end}}{{$result.Name}} {{$result.Type}}{{end}}){{end}} {
+{{- $error := ""}}
+{{- range .Results}}{{if eq .Type "error"}}{{$error = .Name}}{{end}}{{end}}
+{{- if $error}}
+	{{$error}} = errors.New("not implemented: {{$service.Name}}.{{.Name}}")
+	return
+{{- else}}
+	panic("not implemented: {{$service.Name}}.{{.Name}}")
+{{- end}}
+}
+{{- end}}
+{{- end}}
+`
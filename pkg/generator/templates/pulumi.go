@@ -0,0 +1,272 @@
+package templates
+
+// PulumiProjectTemplate is the Pulumi.yaml of the program of generate pulumi
+const PulumiProjectTemplate = `# Pulumi project of the infrastructure of {{.ServiceName}}, generated by microframework
+# generate pulumi from its configuration
+name: {{.ServiceName}}-infra
+description: "Infrastructure of {{.ServiceName}}: its deployment{{range .Releases}}, {{.}}{{end}}"
+runtime: go
+`
+
+// PulumiGoModTemplate is the go.mod of the program of generate pulumi, a module of its own
+const PulumiGoModTemplate = `module {{.ServiceName}}-infra
+
+go 1.22
+
+require (
+	github.com/pulumi/pulumi-kubernetes/sdk/v4 v4.18.0
+	github.com/pulumi/pulumi/sdk/v3 v3.134.0
+)
+`
+
+// PulumiProgramTemplate is the Pulumi program provisioning the service in the cluster, and
+// the database, cache and queues it uses as Helm releases
+const PulumiProgramTemplate = `// Code generated by microframework generate pulumi from the configuration of {{.ServiceName}}. DO NOT EDIT.
+//
+// The configuration of the stacks is in the Pulumi.<stack>.yaml files, which are kept when
+// the program is generated again.
+
+package main
+
+import (
+	"strconv"
+
+	appsv1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/apps/v1"
+	corev1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
+{{- if .Releases}}
+	helmv3 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
+{{- end}}
+	metav1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+// service is the name of the deployment, the service and the releases of the program
+const service = "{{.ServiceName}}"
+
+func main() {
+	pulumi.Run(func(ctx *pulumi.Context) error {
+		cfg := config.New(ctx, "")
+		namespace := cfg.Get("namespace")
+		port := cfg.RequireInt("port")
+		labels := pulumi.StringMap{"app": pulumi.String(service)}
+
+		// metadata is the metadata of a resource of the service, in the namespace of the stack
+		metadata := func(name string) *metav1.ObjectMetaArgs {
+			args := &metav1.ObjectMetaArgs{Name: pulumi.String(name), Labels: labels}
+			if namespace != "" {
+				args.Namespace = pulumi.String(namespace)
+			}
+			return args
+		}
+		env := corev1.EnvVarArray{
+			&corev1.EnvVarArgs{Name: pulumi.String("PORT"), Value: pulumi.String(strconv.Itoa(port))},
+		}
+{{- if .Secrets}}
+		// secretEnv is an environment variable of the service set from its secrets
+		secretEnv := func(name, key string) *corev1.EnvVarArgs {
+			return &corev1.EnvVarArgs{
+				Name: pulumi.String(name),
+				ValueFrom: &corev1.EnvVarSourceArgs{
+					SecretKeyRef: &corev1.SecretKeySelectorArgs{Name: pulumi.String(service + "-secrets"), Key: pulumi.String(key)},
+				},
+			}
+		}
+		secrets := pulumi.StringMap{}
+{{- end}}
+{{- if .Releases}}
+
+		var releases []pulumi.Resource
+		// release installs the chart of a provider of the service, named after them
+		release := func(provider, chart string, values pulumi.Map) error {
+			name := service + "-" + provider
+			values["fullnameOverride"] = pulumi.String(name)
+			args := &helmv3.ReleaseArgs{Name: pulumi.String(name), Chart: pulumi.String(chart), Values: values}
+			if namespace != "" {
+				args.Namespace = pulumi.String(namespace)
+			}
+			r, err := helmv3.NewRelease(ctx, name, args)
+			if err != nil {
+				return err
+			}
+			releases = append(releases, r)
+			return nil
+		}
+{{- end}}
+{{- if .Database}}
+
+		// The database, whose user and database are named after the service
+		databasePassword := cfg.RequireSecret("databasePassword")
+{{- if eq .Database "postgresql"}}
+		if err := release("postgresql", "{{.Chart "postgresql"}}", pulumi.Map{
+			"auth": pulumi.Map{
+				"username": pulumi.String("{{.DatabaseName}}"),
+				"password": databasePassword,
+				"database": pulumi.String("{{.DatabaseName}}"),
+			},
+		}); err != nil {
+			return err
+		}
+		secrets["database-url"] = pulumi.Sprintf("postgres://{{.DatabaseName}}:%s@%s-postgresql:5432/{{.DatabaseName}}?sslmode=disable", databasePassword, service)
+{{- else if eq .Database "mysql"}}
+		if err := release("mysql", "{{.Chart "mysql"}}", pulumi.Map{
+			"auth": pulumi.Map{
+				"username": pulumi.String("{{.DatabaseName}}"),
+				"password": databasePassword,
+				"database": pulumi.String("{{.DatabaseName}}"),
+			},
+		}); err != nil {
+			return err
+		}
+		secrets["database-url"] = pulumi.Sprintf("{{.DatabaseName}}:%s@tcp(%s-mysql:3306)/{{.DatabaseName}}?parseTime=true", databasePassword, service)
+{{- else if eq .Database "mongodb"}}
+		if err := release("mongodb", "{{.Chart "mongodb"}}", pulumi.Map{
+			"auth": pulumi.Map{
+				"usernames": pulumi.Array{pulumi.String("{{.DatabaseName}}")},
+				"passwords": pulumi.Array{databasePassword},
+				"databases": pulumi.Array{pulumi.String("{{.DatabaseName}}")},
+			},
+		}); err != nil {
+			return err
+		}
+		secrets["database-url"] = pulumi.Sprintf("mongodb://{{.DatabaseName}}:%s@%s-mongodb:27017/{{.DatabaseName}}", databasePassword, service)
+{{- end}}
+		env = append(env, secretEnv("DATABASE_URL", "database-url"))
+{{- end}}
+{{- if .Has "redis"}}
+
+		// Redis, {{if .Redis}}the database{{end}}{{if and .Redis .Cache}} and {{end}}{{if .Cache}}the cache in database 2{{end}}
+		redisPassword := cfg.RequireSecret("redisPassword")
+		if err := release("redis", "{{.Chart "redis"}}", pulumi.Map{
+			"architecture": pulumi.String("standalone"),
+			"auth":         pulumi.Map{"password": redisPassword},
+		}); err != nil {
+			return err
+		}
+{{- if .Redis}}
+		secrets["redis-url"] = pulumi.Sprintf("redis://:%s@%s-redis-master:6379/0", redisPassword, service)
+		env = append(env, secretEnv("REDIS_URL", "redis-url"))
+{{- end}}
+{{- if .Cache}}
+		secrets["cache-redis-url"] = pulumi.Sprintf("redis://:%s@%s-redis-master:6379/2", redisPassword, service)
+		env = append(env, secretEnv("CACHE_REDIS_URL", "cache-redis-url"))
+{{- end}}
+{{- end}}
+{{- range .Messaging}}
+{{- if eq . "kafka"}}
+
+		// Kafka, whose clients connect in plaintext
+		if err := release("kafka", "{{$.Chart "kafka"}}", pulumi.Map{
+			"listeners": pulumi.Map{"client": pulumi.Map{"protocol": pulumi.String("PLAINTEXT")}},
+		}); err != nil {
+			return err
+		}
+		env = append(env, &corev1.EnvVarArgs{Name: pulumi.String("KAFKA_BROKERS"), Value: pulumi.String(service + "-kafka:9092")})
+{{- else if eq . "rabbitmq"}}
+
+		// RabbitMQ, whose user is named after the service
+		rabbitmqPassword := cfg.RequireSecret("rabbitmqPassword")
+		if err := release("rabbitmq", "{{$.Chart "rabbitmq"}}", pulumi.Map{
+			"auth": pulumi.Map{"username": pulumi.String(service), "password": rabbitmqPassword},
+		}); err != nil {
+			return err
+		}
+		secrets["rabbitmq-url"] = pulumi.Sprintf("amqp://%s:%s@%s-rabbitmq:5672/", service, rabbitmqPassword, service)
+		env = append(env, secretEnv("RABBITMQ_URL", "rabbitmq-url"))
+{{- else if eq . "nats"}}
+
+		// NATS, without authentication in the cluster
+		if err := release("nats", "{{$.Chart "nats"}}", pulumi.Map{
+			"auth": pulumi.Map{"enabled": pulumi.Bool(false)},
+		}); err != nil {
+			return err
+		}
+		env = append(env, &corev1.EnvVarArgs{Name: pulumi.String("NATS_URL"), Value: pulumi.String("nats://" + service + "-nats:4222")})
+{{- end}}
+{{- end}}
+{{- if .Secrets}}
+
+		// The connection URLs of the releases, read by the service from its environment
+		if _, err := corev1.NewSecret(ctx, service+"-secrets", &corev1.SecretArgs{
+			Metadata:   metadata(service + "-secrets"),
+			StringData: secrets,
+		}); err != nil {
+			return err
+		}
+{{- end}}
+
+		probe := func(path string, delay int) *corev1.ProbeArgs {
+			return &corev1.ProbeArgs{
+				HttpGet:             &corev1.HTTPGetActionArgs{Path: pulumi.String(path), Port: pulumi.Int(port)},
+				InitialDelaySeconds: pulumi.Int(delay),
+			}
+		}
+		deployment, err := appsv1.NewDeployment(ctx, service, &appsv1.DeploymentArgs{
+			Metadata: metadata(service),
+			Spec: &appsv1.DeploymentSpecArgs{
+				Replicas: pulumi.Int(cfg.RequireInt("replicas")),
+				Selector: &metav1.LabelSelectorArgs{MatchLabels: labels},
+				Template: &corev1.PodTemplateSpecArgs{
+					Metadata: &metav1.ObjectMetaArgs{Labels: labels},
+					Spec: &corev1.PodSpecArgs{
+						Containers: corev1.ContainerArray{
+							&corev1.ContainerArgs{
+								Name:           pulumi.String(service),
+								Image:          pulumi.String(cfg.Require("image")),
+								Ports:          corev1.ContainerPortArray{&corev1.ContainerPortArgs{ContainerPort: pulumi.Int(port)}},
+								Env:            env,
+								LivenessProbe:  probe("/healthz", 30),
+								ReadinessProbe: probe("/readyz", 5),
+							},
+						},
+					},
+				},
+			},
+		}{{if .Releases}}, pulumi.DependsOn(releases){{end}})
+		if err != nil {
+			return err
+		}
+
+		svc, err := corev1.NewService(ctx, service, &corev1.ServiceArgs{
+			Metadata: metadata(service),
+			Spec: &corev1.ServiceSpecArgs{
+				Selector: labels,
+				Ports: corev1.ServicePortArray{
+					&corev1.ServicePortArgs{Name: pulumi.String("http"), Port: pulumi.Int(port), TargetPort: pulumi.Int(port)},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx.Export("deployment", deployment.Metadata.Name())
+		ctx.Export("service", svc.Metadata.Name())
+		return nil
+	})
+}
+`
+
+// PulumiStackTemplate is the Pulumi.<stack>.yaml configuration of a stack of the program of
+// generate pulumi
+const PulumiStackTemplate = `# Configuration of the {{.Stack.Name}} stack of {{.ServiceName}}-infra, mapped from
+# deployments/environments.yaml and the configuration of the environment by microframework
+# generate pulumi. It is kept when the program is generated again.
+{{- if .Secrets}}
+#
+# Set the secrets of the stack with:
+{{- range .Secrets}}
+#   pulumi config set --secret {{.}} <value> --stack {{$.Stack.Name}}
+{{- end}}
+{{- end}}
+config:
+{{- if .Stack.Context}}
+  kubernetes:context: {{printf "%q" .Stack.Context}}
+{{- end}}
+{{- if .Stack.Namespace}}
+  {{.ServiceName}}-infra:namespace: {{printf "%q" .Stack.Namespace}}
+{{- end}}
+  {{.ServiceName}}-infra:image: {{printf "%q" .Stack.Image}}
+  {{.ServiceName}}-infra:replicas: "{{.Stack.Replicas}}"
+  {{.ServiceName}}-infra:port: "{{.Stack.Port}}"
+`
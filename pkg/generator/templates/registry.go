@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"sort"
+	"strings"
+)
+
+// builtin are the templates of the pack by name. A name is the path the template renders in
+// a project, or the kind of file for the templates rendered once per entity or gRPC service.
+var builtin = map[string]string{
+	"cmd/main.go":                                   MainTemplate,
+	"go.mod":                                        GoModTemplate,
+	"configs/config.yaml":                           ConfigTemplate,
+	"configs/config.dev.yaml":                       ConfigDevTemplate,
+	"configs/flags.yaml":                            FeatureFlagsTemplate,
+	"internal/handlers/handlers.go":                 HandlersTemplate,
+	"internal/handlers/api.go":                      APIHandlerTemplate,
+	"internal/handlers/grpc.go":                     GRPCRegistrationTemplate,
+	"internal/models/models.go":                     ModelsTemplate,
+	"internal/repositories/repositories.go":         RepositoriesTemplate,
+	"internal/services/services.go":                 ServicesTemplate,
+	"internal/middleware/middleware.go":             MiddlewareTemplate,
+	"internal/errortracking/errortracking.go":       ErrorTrackingTemplate,
+	"internal/flags/provider.go":                    OpenFeatureProviderTemplate,
+	"internal/flags/flags.go":                       OpenFeatureFlagsTemplate,
+	"internal/flags/middleware.go":                  OpenFeatureMiddlewareTemplate,
+	"internal/flags/launchdarkly.go":                OpenFeatureLaunchDarklyTemplate,
+	"configs/flags.flagd.json":                      FlagdFlagsTemplate,
+	"internal/workflows/workflows.go":               TemporalWorkflowsTemplate,
+	"internal/activities/activities.go":             TemporalActivitiesTemplate,
+	"internal/temporal/worker.go":                   TemporalWorkerTemplate,
+	"tests/unit/workflows_test.go":                  TemporalWorkflowTestTemplate,
+	"internal/utils/utils.go":                       UtilsTemplate,
+	".env.example":                                  EnvExampleTemplate,
+	".gitattributes":                                GitAttributesTemplate,
+	"deployments/docker/Dockerfile":                 DockerfileTemplate,
+	"deployments/docker/docker-compose.yml":         DockerComposeTemplate,
+	"deployments/kubernetes/deployment.yaml":        KubernetesDeploymentTemplate,
+	"deployments/kubernetes/service.yaml":           KubernetesServiceTemplate,
+	"deployments/kubernetes/configmap.yaml":         KubernetesConfigMapTemplate,
+	"deployments/environments.yaml":                 EnvironmentsTemplate,
+	"deployments/oidc/realm.json":                   KeycloakRealmTemplate,
+	"deployments/oidc/client.json":                  OIDCClientRegistrationTemplate,
+	"deployments/observability/otel-collector.yaml": OTelCollectorTemplate,
+	"deployments/observability/prometheus.yml":      ObservabilityPrometheusTemplate,
+	"deployments/observability/docker-compose.yml":  ObservabilityComposeTemplate,
+	"deployments/observability/grafana/provisioning/datasources/datasources.yaml": GrafanaDatasourcesTemplate,
+	"deployments/observability/grafana/provisioning/dashboards/dashboards.yaml":   GrafanaDashboardsTemplate,
+	"deployments/observability/grafana/dashboards/service.json":                   GrafanaDashboardTemplate,
+	"tests/unit/service_test.go":                                                  UnitTestTemplate,
+	"tests/integration/integration_test.go":                                       IntegrationTestTemplate,
+	"tests/health_test.go":                                                        HealthTestTemplate,
+	"README.md":                                                                   ReadmeTemplate,
+	"docs/API.md":                                                                 APITemplate,
+	"catalog-info.yaml":                                                           CatalogInfoTemplate,
+	"migrations/initial_schema.json":                                              MigrationExampleTemplate,
+	"entity/model.go":                                                             EntityModelTemplate,
+	"entity/repository.go":                                                        EntityRepositoryTemplate,
+	"entity/service.go":                                                           EntityServiceTemplate,
+	"entity/handler.go":                                                           EntityHandlerTemplate,
+	"entity/entity.proto":                                                         EntityProtobufTemplate,
+	"entity/entity.graphql":                                                       EntityGraphQLTemplate,
+	"grpc/server.go":                                                              GRPCServerTemplate,
+	"ported/handler.go":                                                           PortedHandlerTemplate,
+	"ported/services.go":                                                          PortedServicesTemplate,
+	"table/model.go":                                                              TableModelTemplate,
+	"table/repository.go":                                                         TableRepositoryTemplate,
+	"protobuf/service.proto":                                                      ProtobufServiceTemplate,
+	"protobuf/main.proto":                                                         ProtobufMainTemplate,
+	"graphql/schema.graphql":                                                      GraphQLSchemaTemplate,
+	"graphql/schema.go":                                                           GraphQLGoSchemaTemplate,
+	"graphql/types.go":                                                            GraphQLTypesTemplate,
+	"graphql/server.go":                                                           GraphQLServerTemplate,
+	"graphql/resolvers.go":                                                        GraphQLResolversTemplate,
+	"asyncapi/messages.go":                                                        AsyncAPIMessagesTemplate,
+	"asyncapi/producers.go":                                                       AsyncAPIProducersTemplate,
+	"asyncapi/consumers.go":                                                       AsyncAPIConsumersTemplate,
+	"asyncapi/handlers.go":                                                        AsyncAPIHandlersTemplate,
+	"asyncapi/codec.go":                                                           AsyncAPICodecTemplate,
+	"asyncapi/schema.avsc":                                                        AsyncAPIAvroSchemaTemplate,
+	"asyncapi/events.proto":                                                       AsyncAPIProtoTemplate,
+	"asyncapi/registry.yaml":                                                      AsyncAPIRegistryTemplate,
+	"asyncapi/topics.yaml":                                                        AsyncAPITopicsTemplate,
+	"pulumi/Pulumi.yaml":                                                          PulumiProjectTemplate,
+	"pulumi/go.mod":                                                               PulumiGoModTemplate,
+	"pulumi/main.go":                                                              PulumiProgramTemplate,
+	"pulumi/stack.yaml":                                                           PulumiStackTemplate,
+}
+
+// Registry holds the templates the generators render, by name. The names are those of
+// the built-in templates, listed by Names; registering one of them replaces it, so that
+// a tool embedding the generators renders its own Dockerfile or README.
+//
+//	registry := templates.NewRegistry()
+//	registry.Register("deployments/docker/Dockerfile", dockerfile)
+type Registry struct {
+	templates map[string]string
+}
+
+// NewRegistry returns a registry of the built-in templates
+func NewRegistry() *Registry {
+	r := &Registry{templates: make(map[string]string, len(builtin))}
+	for name, text := range builtin {
+		r.templates[name] = text
+	}
+	return r
+}
+
+// Lookup returns the text of a template, reporting whether the registry has it
+func (r *Registry) Lookup(name string) (string, bool) {
+	text, ok := r.templates[name]
+	return text, ok
+}
+
+// Register sets the text of a template, replacing the built-in one of that name. Its line
+// endings become \n, so that a template edited on Windows renders the files the others do.
+func (r *Registry) Register(name, text string) {
+	r.templates[name] = strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// Names returns the names of the templates of the registry, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,145 +1,214 @@
-package generator
+package templates
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"text/template"
-)
+// ProtobufServiceTemplate is the protobuf file of a gRPC service of the service
+const ProtobufServiceTemplate = `syntax = "proto3";
+
+package {{.PackageName}};
+
+option go_package = "github.com/anasamu/{{.ServiceNameLower}}/protobuf";
 
-// GraphQLConfig holds configuration for GraphQL generation
-type GraphQLConfig struct {
-	ServiceName   string
-	SchemaName    string
-	Types         []string
-	Queries       []string
-	Mutations     []string
-	Subscriptions []string
-	OutputPath    string
-	ForceGenerate bool
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+
+// {{.ServiceName}} service definition
+service {{.ServiceName}} {
+  // Health check
+  rpc HealthCheck(google.protobuf.Empty) returns (HealthResponse);
+  
+  // Get service info
+  rpc GetServiceInfo(google.protobuf.Empty) returns (ServiceInfoResponse);
+  
+  // Example CRUD operations
+  rpc Create{{.ServiceName}}(Create{{.ServiceName}}Request) returns ({{.ServiceName}}Response);
+  rpc Get{{.ServiceName}}(Get{{.ServiceName}}Request) returns ({{.ServiceName}}Response);
+  rpc Update{{.ServiceName}}(Update{{.ServiceName}}Request) returns ({{.ServiceName}}Response);
+  rpc Delete{{.ServiceName}}(Delete{{.ServiceName}}Request) returns (google.protobuf.Empty);
+  rpc List{{.ServiceName}}s(List{{.ServiceName}}sRequest) returns (List{{.ServiceName}}sResponse);
 }
 
-// GraphQLGenerator handles the generation of GraphQL schema files
-type GraphQLGenerator struct {
-	config *GraphQLConfig
+// Health response
+message HealthResponse {
+  string status = 1;
+  string message = 2;
+  google.protobuf.Timestamp timestamp = 3;
 }
 
-// NewGraphQLGenerator creates a new GraphQL generator
-func NewGraphQLGenerator(config *GraphQLConfig) *GraphQLGenerator {
-	return &GraphQLGenerator{
-		config: config,
-	}
+// Service info response
+message ServiceInfoResponse {
+  string name = 1;
+  string version = 2;
+  string description = 3;
+  google.protobuf.Timestamp started_at = 4;
 }
 
-// GenerateGraphQL generates GraphQL schema files
-func (gg *GraphQLGenerator) GenerateGraphQL() error {
-	// Create GraphQL directory
-	graphqlDir := filepath.Join(gg.config.OutputPath, "graphql")
-	if err := os.MkdirAll(graphqlDir, 0755); err != nil {
-		return fmt.Errorf("failed to create GraphQL directory: %w", err)
-	}
+// {{.ServiceName}} entity
+message {{.ServiceName}} {
+  string id = 1;
+  string name = 2;
+  string description = 3;
+  google.protobuf.Timestamp created_at = 4;
+  google.protobuf.Timestamp updated_at = 5;
+}
 
-	// Generate GraphQL schema file
-	if err := gg.generateGraphQLSchema(graphqlDir); err != nil {
-		return fmt.Errorf("failed to generate GraphQL schema: %w", err)
-	}
+// Create {{.ServiceName}} request
+message Create{{.ServiceName}}Request {
+  string name = 1;
+  string description = 2;
+}
 
-	// Generate Go schema file
-	if err := gg.generateGoSchema(graphqlDir); err != nil {
-		return fmt.Errorf("failed to generate Go schema: %w", err)
-	}
+// Get {{.ServiceName}} request
+message Get{{.ServiceName}}Request {
+  string id = 1;
+}
 
-	return nil
+// Update {{.ServiceName}} request
+message Update{{.ServiceName}}Request {
+  string id = 1;
+  string name = 2;
+  string description = 3;
 }
 
-// generateGraphQLSchema generates the GraphQL schema file
-func (gg *GraphQLGenerator) generateGraphQLSchema(graphqlDir string) error {
-	// Create GraphQL schema file
-	fileName := gg.config.SchemaName + ".graphql"
-	filePath := filepath.Join(graphqlDir, fileName)
+// Delete {{.ServiceName}} request
+message Delete{{.ServiceName}}Request {
+  string id = 1;
+}
 
-	// Check if file exists and force is not set
-	if !gg.config.ForceGenerate {
-		if _, err := os.Stat(filePath); err == nil {
-			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
-		}
-	}
+// List {{.ServiceName}}s request
+message List{{.ServiceName}}sRequest {
+  int32 page = 1;
+  int32 limit = 2;
+  string search = 3;
+}
 
-	// Parse template
-	tmpl, err := template.New("schema.graphql").Parse(graphQLSchemaTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse GraphQL schema template: %w", err)
-	}
+// {{.ServiceName}} response
+message {{.ServiceName}}Response {
+  {{.ServiceName}} {{.ServiceNameLower}} = 1;
+  string message = 2;
+  bool success = 3;
+}
 
-	// Create template data
-	data := map[string]interface{}{
-		"ServiceName":   gg.config.ServiceName,
-		"SchemaName":    gg.config.SchemaName,
-		"Types":         gg.config.Types,
-		"Queries":       gg.config.Queries,
-		"Mutations":     gg.config.Mutations,
-		"Subscriptions": gg.config.Subscriptions,
-	}
+// List {{.ServiceName}}s response
+message List{{.ServiceName}}sResponse {
+  repeated {{.ServiceName}} {{.ServiceNameLower}}s = 1;
+  int32 total = 2;
+  int32 page = 3;
+  int32 limit = 4;
+  string message = 5;
+  bool success = 6;
+}
+`
 
-	// Write file
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filePath, err)
-	}
-	defer file.Close()
+// ProtobufMainTemplate is the protobuf file gathering the gRPC services of the service
+const ProtobufMainTemplate = `syntax = "proto3";
 
-	if err := tmpl.Execute(file, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
+package {{.PackageName}};
+
+option go_package = "github.com/anasamu/{{.ServiceName}}/protobuf";
 
-	return nil
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+
+// Main service definition
+service {{.ServiceName}}Service {
+  // Health check
+  rpc HealthCheck(google.protobuf.Empty) returns (HealthResponse);
+  
+  // Get service info
+  rpc GetServiceInfo(google.protobuf.Empty) returns (ServiceInfoResponse);
 }
 
-// generateGoSchema generates the Go schema file
-func (gg *GraphQLGenerator) generateGoSchema(graphqlDir string) error {
-	// Create Go schema file
-	fileName := gg.config.SchemaName + "_schema.go"
-	filePath := filepath.Join(graphqlDir, fileName)
+// Health response
+message HealthResponse {
+  string status = 1;
+  string message = 2;
+  google.protobuf.Timestamp timestamp = 3;
+}
 
-	// Check if file exists and force is not set
-	if !gg.config.ForceGenerate {
-		if _, err := os.Stat(filePath); err == nil {
-			return fmt.Errorf("file %s already exists, use --force to overwrite", fileName)
-		}
-	}
+// Service info response
+message ServiceInfoResponse {
+  string name = 1;
+  string version = 2;
+  string description = 3;
+  google.protobuf.Timestamp started_at = 4;
+}
 
-	// Parse template
-	tmpl, err := template.New("schema.go").Parse(graphQLGoSchemaTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse GraphQL Go schema template: %w", err)
-	}
+{{range .GRPCServices}}
+// {{.}} service definition
+service {{.}} {
+  // Health check
+  rpc HealthCheck(google.protobuf.Empty) returns (HealthResponse);
+  
+  // Get service info
+  rpc GetServiceInfo(google.protobuf.Empty) returns (ServiceInfoResponse);
+  
+  // Example CRUD operations
+  rpc Create{{.}}(Create{{.}}Request) returns ({{.}}Response);
+  rpc Get{{.}}(Get{{.}}Request) returns ({{.}}Response);
+  rpc Update{{.}}(Update{{.}}Request) returns ({{.}}Response);
+  rpc Delete{{.}}(Delete{{.}}Request) returns (google.protobuf.Empty);
+  rpc List{{.}}s(List{{.}}sRequest) returns (List{{.}}sResponse);
+}
 
-	// Create template data
-	data := map[string]interface{}{
-		"ServiceName":   gg.config.ServiceName,
-		"SchemaName":    gg.config.SchemaName,
-		"Types":         gg.config.Types,
-		"Queries":       gg.config.Queries,
-		"Mutations":     gg.config.Mutations,
-		"Subscriptions": gg.config.Subscriptions,
-	}
+// {{.}} entity
+message {{.}} {
+  string id = 1;
+  string name = 2;
+  string description = 3;
+  google.protobuf.Timestamp created_at = 4;
+  google.protobuf.Timestamp updated_at = 5;
+}
 
-	// Write file
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filePath, err)
-	}
-	defer file.Close()
+// Create {{.}} request
+message Create{{.}}Request {
+  string name = 1;
+  string description = 2;
+}
 
-	if err := tmpl.Execute(file, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
+// Get {{.}} request
+message Get{{.}}Request {
+  string id = 1;
+}
+
+// Update {{.}} request
+message Update{{.}}Request {
+  string id = 1;
+  string name = 2;
+  string description = 3;
+}
+
+// Delete {{.}} request
+message Delete{{.}}Request {
+  string id = 1;
+}
 
-	return nil
+// List {{.}}s request
+message List{{.}}sRequest {
+  int32 page = 1;
+  int32 limit = 2;
+  string search = 3;
 }
 
-// GraphQL schema template
-const graphQLSchemaTemplate = `# {{.ServiceName}} GraphQL Schema
+// {{.}} response
+message {{.}}Response {
+  {{.}} {{. | lower}} = 1;
+  string message = 2;
+  bool success = 3;
+}
+
+// List {{.}}s response
+message List{{.}}sResponse {
+  repeated {{.}} {{. | lower}}s = 1;
+  int32 total = 2;
+  int32 page = 3;
+  int32 limit = 4;
+  string message = 5;
+  bool success = 6;
+}
+{{end}}
+`
+
+// GraphQLSchemaTemplate is the GraphQL schema of the service
+const GraphQLSchemaTemplate = `# {{.ServiceName}} GraphQL Schema
 
 # Scalar types
 scalar Time
@@ -272,8 +341,8 @@ type Subscription {
 }
 `
 
-// GraphQL Go schema template
-const graphQLGoSchemaTemplate = `package graphql
+// GraphQLGoSchemaTemplate is the Go package building the GraphQL schema of the service
+const GraphQLGoSchemaTemplate = `package graphql
 
 import (
 	"context"
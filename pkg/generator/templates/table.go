@@ -0,0 +1,85 @@
+package templates
+
+// TableModelTemplate is the GORM model of a table imported with microframework import db,
+// mapping its columns as the database declares them
+const TableModelTemplate = `package models
+{{if .Time}}
+import "time"
+{{end}}
+// {{.Model}} maps the {{.Name}} table
+type {{.Model}} struct {
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} {{.Tag}}
+{{- end}}
+}
+
+// TableName returns the table name for the model
+func ({{.Model}}) TableName() string {
+	return "{{.Name}}"
+}
+`
+
+// TableRepositoryTemplate is the repository of a table imported with microframework import
+// db; the methods reading and writing a single row need a primary key of one column
+const TableRepositoryTemplate = `package repositories
+
+import (
+	"context"
+
+	"{{.ServiceName}}/internal/models"
+	"gorm.io/gorm"
+)
+
+// {{.Model}}Repository handles the data access of the {{.Name}} table
+type {{.Model}}Repository struct {
+	db *gorm.DB
+}
+
+// New{{.Model}}Repository creates a new repository
+func New{{.Model}}Repository(db *gorm.DB) *{{.Model}}Repository {
+	return &{{.Model}}Repository{
+		db: db,
+	}
+}
+
+// Create inserts a row
+func (r *{{.Model}}Repository) Create(ctx context.Context, {{.Var}} *models.{{.Model}}) error {
+	return r.db.WithContext(ctx).Create({{.Var}}).Error
+}
+{{- with .Key}}
+
+// GetBy{{.GoName}} retrieves the row with the given {{.Name}}
+func (r *{{$.Model}}Repository) GetBy{{.GoName}}(ctx context.Context, key {{.GoType}}) (*models.{{$.Model}}, error) {
+	var {{$.Var}} models.{{$.Model}}
+	err := r.db.WithContext(ctx).First(&{{$.Var}}, "{{.Name}} = ?", key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &{{$.Var}}, nil
+}
+
+// Update saves every column of a row
+func (r *{{$.Model}}Repository) Update(ctx context.Context, {{$.Var}} *models.{{$.Model}}) error {
+	return r.db.WithContext(ctx).Save({{$.Var}}).Error
+}
+
+// Delete deletes the row with the given {{.Name}}
+func (r *{{$.Model}}Repository) Delete(ctx context.Context, key {{.GoType}}) error {
+	return r.db.WithContext(ctx).Delete(&models.{{$.Model}}{}, "{{.Name}} = ?", key).Error
+}
+{{- end}}
+
+// List retrieves rows with pagination
+func (r *{{.Model}}Repository) List(ctx context.Context, offset, limit int) ([]*models.{{.Model}}, error) {
+	var rows []*models.{{.Model}}
+	err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// Count returns the number of rows
+func (r *{{.Model}}Repository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.{{.Model}}{}).Count(&count).Error
+	return count, err
+}
+`
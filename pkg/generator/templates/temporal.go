@@ -0,0 +1,244 @@
+package templates
+
+// TemporalWorkflowsTemplate is the workflows package of the services generated with --type
+// temporal-worker, a workflow stub running the activity stub with a retry policy
+const TemporalWorkflowsTemplate = `// Package workflows holds the Temporal workflows of {{.ServiceName}}. A workflow is
+// deterministic: it calls databases and other services through activities only, and reads
+// the time, random numbers and configuration through the workflow package.
+package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"{{.ServiceName}}/internal/activities"
+)
+
+// ProcessInput is the input of ProcessWorkflow
+type ProcessInput struct {
+	ID string
+}
+
+// ProcessResult is the result of ProcessWorkflow
+type ProcessResult struct {
+	ID      string
+	Message string
+}
+
+// ProcessWorkflow processes an item with the Process activity, retried with an exponential
+// backoff while it fails
+func ProcessWorkflow(ctx workflow.Context, input ProcessInput) (ProcessResult, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var a *activities.Activities
+	var message string
+	if err := workflow.ExecuteActivity(ctx, a.Process, input.ID).Get(ctx, &message); err != nil {
+		return ProcessResult{}, err
+	}
+	workflow.GetLogger(ctx).Info("Processed", "id", input.ID)
+	return ProcessResult{ID: input.ID, Message: message}, nil
+}
+`
+
+// TemporalActivitiesTemplate is the activities package of the services generated with --type
+// temporal-worker
+const TemporalActivitiesTemplate = `// Package activities holds the Temporal activities of {{.ServiceName}}: the steps of its
+// workflows that call databases and other services, which Temporal retries when they fail.
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// Activities are the activities of the service; the clients and managers they use are its
+// fields, set when the worker registers them
+type Activities struct{}
+
+// Process processes the item of a workflow
+func (a *Activities) Process(ctx context.Context, id string) (string, error) {
+	activity.GetLogger(ctx).Info("Processing", "id", id)
+	return fmt.Sprintf("processed %s", id), nil
+}
+`
+
+// TemporalWorkerTemplate is the temporal package of the services generated with --type
+// temporal-worker, which registers the workflows and activities with a worker polling the
+// task queue of the service
+const TemporalWorkerTemplate = `// Package temporal runs the Temporal worker of {{.ServiceName}}, which executes its workflows
+// and activities from the task queue of the service.
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"{{.ServiceName}}/internal/activities"
+	"{{.ServiceName}}/internal/workflows"
+)
+
+// Config is the temporal section of configs/config.yaml
+type Config struct {
+	// HostPort is the address of the Temporal frontend, localhost:7233 when unset
+	HostPort string
+	// Namespace is the namespace of the workflows, default when unset
+	Namespace string
+	// TaskQueue is the task queue the worker polls, {{.ServiceName}} when unset
+	TaskQueue string
+}
+
+// ConfigFrom reads the temporal section of the configuration, expanding the environment
+// variables of its values
+func ConfigFrom(section interface{}) Config {
+	values, _ := section.(map[string]interface{})
+	value := func(key, defaultValue string) string {
+		s, _ := values[key].(string)
+		if s = os.ExpandEnv(s); s == "" {
+			return defaultValue
+		}
+		return s
+	}
+	return Config{
+		HostPort:  value("host_port", client.DefaultHostPort),
+		Namespace: value("namespace", client.DefaultNamespace),
+		TaskQueue: value("task_queue", "{{.ServiceName}}"),
+	}
+}
+
+// Registry is what the workflows and activities are registered with: a worker, or the
+// environment of a test
+type Registry interface {
+	RegisterWorkflow(w interface{})
+	RegisterActivity(a interface{})
+}
+
+// Register registers the workflows and activities of the service
+func Register(registry Registry) {
+	registry.RegisterWorkflow(workflows.ProcessWorkflow)
+	registry.RegisterActivity(&activities.Activities{})
+}
+
+// Worker is the running worker of the service, and the client it polls with, which starts
+// workflows too
+type Worker struct {
+	Client    client.Client
+	taskQueue string
+	worker    worker.Worker
+}
+
+// Start connects to Temporal and starts polling the task queue
+func Start(cfg Config) (*Worker, error) {
+	c, err := client.Dial(client.Options{HostPort: cfg.HostPort, Namespace: cfg.Namespace})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Temporal at %s: %w", cfg.HostPort, err)
+	}
+	w := worker.New(c, cfg.TaskQueue, worker.Options{})
+	Register(w)
+	if err := w.Start(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to start the worker of %s: %w", cfg.TaskQueue, err)
+	}
+	return &Worker{Client: c, taskQueue: cfg.TaskQueue, worker: w}, nil
+}
+
+// StartProcess starts a ProcessWorkflow on the task queue of the service; the workflow ID
+// is derived from the item, so that an item is processed once at a time
+func (w *Worker) StartProcess(ctx context.Context, input workflows.ProcessInput) (client.WorkflowRun, error) {
+	options := client.StartWorkflowOptions{ID: "process-" + input.ID, TaskQueue: w.taskQueue}
+	return w.Client.ExecuteWorkflow(ctx, options, workflows.ProcessWorkflow, input)
+}
+
+// Stop stops polling, waiting for the running activities, and closes the client
+func (w *Worker) Stop() {
+	w.worker.Stop()
+	w.Client.Close()
+}
+`
+
+// TemporalWorkflowTestTemplate is the tests of the workflows and activities of the services
+// generated with --type temporal-worker, run with the test suite of the Temporal SDK
+const TemporalWorkflowTestTemplate = `package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"{{.ServiceName}}/internal/activities"
+	"{{.ServiceName}}/internal/temporal"
+	"{{.ServiceName}}/internal/workflows"
+)
+
+func TestProcessWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	temporal.Register(env)
+
+	env.ExecuteWorkflow(workflows.ProcessWorkflow, workflows.ProcessInput{ID: "42"})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result workflows.ProcessResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, workflows.ProcessResult{ID: "42", Message: "processed 42"}, result)
+}
+
+func TestProcessWorkflow_RetriesActivity(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var a *activities.Activities
+	env.OnActivity(a.Process, mock.Anything, "42").Return("", errors.New("unavailable")).Once()
+	env.OnActivity(a.Process, mock.Anything, "42").Return("processed 42", nil).Once()
+
+	env.ExecuteWorkflow(workflows.ProcessWorkflow, workflows.ProcessInput{ID: "42"})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
+
+func TestProcessWorkflow_FailsAfterRetries(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var a *activities.Activities
+	env.OnActivity(a.Process, mock.Anything, "42").Return("", errors.New("unavailable"))
+
+	env.ExecuteWorkflow(workflows.ProcessWorkflow, workflows.ProcessInput{ID: "42"})
+
+	require.True(t, env.IsWorkflowCompleted())
+	assert.Error(t, env.GetWorkflowError())
+}
+
+func TestProcessActivity(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	a := &activities.Activities{}
+	env.RegisterActivity(a)
+
+	value, err := env.ExecuteActivity(a.Process, "42")
+	require.NoError(t, err)
+	var message string
+	require.NoError(t, value.Get(&message))
+	assert.Equal(t, "processed 42", message)
+}
+`
@@ -0,0 +1,139 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsulLock is a lock held in a Consul key by a session whose TTL is the lease
+type ConsulLock struct {
+	address string
+	token   string
+	key     string
+	client  *http.Client
+
+	mu      sync.Mutex
+	session string
+}
+
+// NewConsulLock creates a lock held in key through the Consul agent at address, such as
+// http://127.0.0.1:8500
+func NewConsulLock(address, token, key string) *ConsulLock {
+	return &ConsulLock{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		key:     strings.TrimPrefix(key, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns consul
+func (l *ConsulLock) Name() string { return "consul" }
+
+// Acquire renews the session, creating it when it is missing or expired, and acquires the key
+// with it
+func (l *ConsulLock) Acquire(ctx context.Context, identity string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.session != "" {
+		status, err := l.put(ctx, "/v1/session/renew/"+l.session, nil, nil)
+		if err != nil {
+			return false, err
+		}
+		if status == http.StatusNotFound {
+			// The session expired, and with it the lock
+			l.session = ""
+		}
+	}
+	if l.session == "" {
+		// Consul accepts TTLs from 10s
+		seconds := max(int(ttl.Seconds()), 10)
+		request := map[string]interface{}{
+			"Name":      identity,
+			"TTL":       fmt.Sprintf("%ds", seconds),
+			"Behavior":  "release",
+			"LockDelay": "0s",
+		}
+		var created struct {
+			ID string `json:"ID"`
+		}
+		if _, err := l.put(ctx, "/v1/session/create", request, &created); err != nil {
+			return false, err
+		}
+		l.session = created.ID
+	}
+
+	var acquired bool
+	if _, err := l.put(ctx, "/v1/kv/"+l.key+"?acquire="+l.session, identity, &acquired); err != nil {
+		return false, err
+	}
+	return acquired, nil
+}
+
+// Release releases the key and destroys the session
+func (l *ConsulLock) Release(ctx context.Context, identity string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.session == "" {
+		return nil
+	}
+	if _, err := l.put(ctx, "/v1/kv/"+l.key+"?release="+l.session, identity, nil); err != nil {
+		return err
+	}
+	_, err := l.put(ctx, "/v1/session/destroy/"+l.session, nil, nil)
+	l.session = ""
+	return err
+}
+
+// put sends a PUT request to the agent and decodes the JSON response into result. A 404 is
+// returned as a status, not an error.
+func (l *ConsulLock) put(ctx context.Context, path string, body, result interface{}) (int, error) {
+	var content io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		// A key value, stored as is
+		content = strings.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		content = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, l.address+path, content)
+	if err != nil {
+		return 0, err
+	}
+	if l.token != "" {
+		request.Header.Set("X-Consul-Token", l.token)
+	}
+
+	response, err := l.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return response.StatusCode, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return response.StatusCode, fmt.Errorf("consul %s: %s: %s", path, response.Status, strings.TrimSpace(string(message)))
+	}
+	if result != nil {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			return response.StatusCode, fmt.Errorf("consul %s: %w", path, err)
+		}
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,230 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// In-cluster service account files
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	leaseTimeFormat   = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// KubernetesLock is a lock held in a coordination.k8s.io/v1 Lease, as with client-go leader
+// election. It talks to the API server of the cluster the service runs in, with the service
+// account of the pod, which needs get, create and update on leases.
+type KubernetesLock struct {
+	server    string
+	namespace string
+	name      string
+	client    *http.Client
+}
+
+// lease is the part of a Lease the lock reads and writes
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// NewKubernetesLock creates a lock held in the Lease name of namespace, the namespace of the
+// pod when empty
+func NewKubernetesLock(namespace, name string) (*KubernetesLock, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster (KUBERNETES_SERVICE_HOST is not set)")
+	}
+	if namespace == "" {
+		content, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(content))
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate in %s/ca.crt", serviceAccountDir)
+	}
+
+	return &KubernetesLock{
+		server:    "https://" + net.JoinHostPort(host, port),
+		namespace: namespace,
+		name:      name,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// Name returns kubernetes
+func (l *KubernetesLock) Name() string { return "kubernetes" }
+
+// Acquire creates the Lease, takes it over when its holder let it expire, or renews it. A
+// conflicting write by another replica means the lock is not held.
+func (l *KubernetesLock) Acquire(ctx context.Context, identity string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	seconds := max(int(ttl.Seconds()), 1)
+
+	current, found, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		created := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: l.name, Namespace: l.namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       identity,
+				LeaseDurationSeconds: seconds,
+				AcquireTime:          now.UTC().Format(leaseTimeFormat),
+				RenewTime:            now.UTC().Format(leaseTimeFormat),
+			},
+		}
+		return l.write(ctx, http.MethodPost, l.collection(), created)
+	}
+
+	spec := current.Spec
+	if spec.HolderIdentity != identity {
+		if spec.HolderIdentity != "" && !expired(spec, now) {
+			return false, nil
+		}
+		spec.HolderIdentity = identity
+		spec.AcquireTime = now.UTC().Format(leaseTimeFormat)
+		spec.LeaseTransitions++
+	}
+	spec.LeaseDurationSeconds = seconds
+	spec.RenewTime = now.UTC().Format(leaseTimeFormat)
+	current.Spec = spec
+	return l.write(ctx, http.MethodPut, l.collection()+"/"+l.name, current)
+}
+
+// Release clears the holder of the Lease if identity holds it, so another replica takes it
+// without waiting for the lease to expire
+func (l *KubernetesLock) Release(ctx context.Context, identity string) error {
+	current, found, err := l.get(ctx)
+	if err != nil || !found || current.Spec.HolderIdentity != identity {
+		return err
+	}
+	current.Spec.HolderIdentity = ""
+	current.Spec.LeaseDurationSeconds = 1
+	current.Spec.RenewTime = time.Now().UTC().Format(leaseTimeFormat)
+	_, err = l.write(ctx, http.MethodPut, l.collection()+"/"+l.name, current)
+	return err
+}
+
+// expired reports whether the holder of a Lease stopped renewing it
+func expired(spec leaseSpec, now time.Time) bool {
+	renewed, err := time.Parse(time.RFC3339Nano, spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return now.After(renewed.Add(time.Duration(spec.LeaseDurationSeconds) * time.Second))
+}
+
+func (l *KubernetesLock) collection() string {
+	return fmt.Sprintf("/apis/coordination.k8s.io/v1/namespaces/%s/leases", l.namespace)
+}
+
+// get reads the Lease
+func (l *KubernetesLock) get(ctx context.Context) (lease, bool, error) {
+	var current lease
+	response, err := l.do(ctx, http.MethodGet, l.collection()+"/"+l.name, nil)
+	if err != nil {
+		return current, false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(response.Body).Decode(&current); err != nil {
+			return current, false, fmt.Errorf("lease %s: %w", l.name, err)
+		}
+		return current, true, nil
+	case http.StatusNotFound:
+		return current, false, nil
+	default:
+		return current, false, statusError(response)
+	}
+}
+
+// write creates or updates the Lease and reports whether it succeeded; a conflict means
+// another replica wrote it first
+func (l *KubernetesLock) write(ctx context.Context, method, path string, value lease) (bool, error) {
+	response, err := l.do(ctx, method, path, value)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, statusError(response)
+	}
+}
+
+// do sends a request to the API server with the service account token, read on every
+// request since it is rotated
+func (l *KubernetesLock) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var content io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		content = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, l.server+path, content)
+	if err != nil {
+		return nil, err
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	return l.client.Do(request)
+}
+
+func statusError(response *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("kubernetes %s %s: %s: %s", response.Request.Method, response.Request.URL.Path, response.Status, strings.TrimSpace(string(message)))
+}
@@ -0,0 +1,243 @@
+// Package leader elects one replica of a service as the leader, so that scheduled jobs and
+// singleton workers run once however many replicas are running. The replicas compete for a
+// lock with a lease, held in a Kubernetes Lease, a Redis key or a Consul session; the leader
+// renews the lease and the others take over when it stops renewing.
+//
+//	elector := leader.NewElector(leader.NewRedisLock(client, "leader:orders"), leader.Options{})
+//	if err := elector.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer elector.Stop(ctx)
+//
+//	elector.RunWhileLeader(ctx, func(ctx context.Context) {
+//		// runs on the leader only, until ctx is cancelled when leadership is lost
+//	})
+package leader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Default lease timings
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewInterval = 5 * time.Second
+)
+
+// Lock is a lock with a lease, shared by the replicas
+type Lock interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Acquire takes the lock for identity for ttl, or extends the lease when identity holds
+	// it already, and reports whether identity holds the lock
+	Acquire(ctx context.Context, identity string, ttl time.Duration) (bool, error)
+	// Release gives the lock up if identity holds it
+	Release(ctx context.Context, identity string) error
+}
+
+// Options are the settings of an Elector
+type Options struct {
+	// Identity names this replica in the lock (default hostname-pid)
+	Identity string
+	// LeaseDuration is how long the lock is held without renewal (default 15s)
+	LeaseDuration time.Duration
+	// RenewInterval is how often the leader renews the lease and the others try to take the
+	// lock (default 5s); it must be shorter than LeaseDuration
+	RenewInterval time.Duration
+}
+
+// Elector runs the election of one replica
+type Elector struct {
+	lock     Lock
+	identity string
+	ttl      time.Duration
+	interval time.Duration
+
+	mu        sync.Mutex
+	leader    bool
+	renewed   time.Time
+	err       error
+	observers []func(leader bool)
+
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewElector creates an elector competing for lock
+func NewElector(lock Lock, options Options) *Elector {
+	if options.Identity == "" {
+		hostname, _ := os.Hostname()
+		options.Identity = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = DefaultLeaseDuration
+	}
+	if options.RenewInterval <= 0 || options.RenewInterval >= options.LeaseDuration {
+		options.RenewInterval = min(DefaultRenewInterval, options.LeaseDuration/3)
+	}
+	return &Elector{
+		lock:     lock,
+		identity: options.Identity,
+		ttl:      options.LeaseDuration,
+		interval: options.RenewInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Identity returns the identity of this replica
+func (e *Elector) Identity() string { return e.identity }
+
+// Backend returns the name of the lock backend
+func (e *Elector) Backend() string { return e.lock.Name() }
+
+// Start tries to take the lock once, failing when the backend cannot be reached, and keeps
+// competing for it in the background until Stop
+func (e *Elector) Start(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+	if err := e.attempt(ctx); err != nil {
+		return fmt.Errorf("leader election with %s: %w", e.lock.Name(), err)
+	}
+	e.mu.Lock()
+	e.started = true
+	e.mu.Unlock()
+	go e.run()
+	return nil
+}
+
+func (e *Elector) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+			e.attempt(ctx)
+			cancel()
+		}
+	}
+}
+
+// attempt takes or renews the lock. A leader that cannot reach the backend steps down before
+// its lease runs out, since another replica may take the lock then.
+func (e *Elector) attempt(ctx context.Context) error {
+	held, err := e.lock.Acquire(ctx, e.identity, e.ttl)
+
+	e.mu.Lock()
+	e.err = err
+	leader := e.leader
+	switch {
+	case err == nil:
+		leader = held
+		if held {
+			e.renewed = time.Now()
+		}
+	case e.leader && time.Since(e.renewed)+e.interval >= e.ttl:
+		leader = false
+	}
+	e.mu.Unlock()
+
+	e.setLeader(leader)
+	return err
+}
+
+// setLeader records the leadership and tells the observers when it changes
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	if e.leader == leader {
+		e.mu.Unlock()
+		return
+	}
+	e.leader = leader
+	observers := append([]func(bool){}, e.observers...)
+	e.mu.Unlock()
+
+	for _, observer := range observers {
+		observer(leader)
+	}
+}
+
+// Stop stops competing for the lock and releases it if this replica holds it
+func (e *Elector) Stop(ctx context.Context) error {
+	var err error
+	e.once.Do(func() {
+		close(e.stop)
+		e.mu.Lock()
+		started := e.started
+		e.mu.Unlock()
+		if started {
+			<-e.done
+		}
+
+		if e.IsLeader() {
+			err = e.lock.Release(ctx, e.identity)
+		}
+		e.setLeader(false)
+		if closer, ok := e.lock.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+// IsLeader reports whether this replica is the leader
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Err returns the error of the last attempt to take or renew the lock
+func (e *Elector) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// OnChange calls observer whenever this replica gains or loses the leadership, and right
+// away if it leads already. Observers run one at a time on the election goroutine.
+func (e *Elector) OnChange(observer func(leader bool)) {
+	e.mu.Lock()
+	e.observers = append(e.observers, observer)
+	leader := e.leader
+	e.mu.Unlock()
+
+	if leader {
+		observer(true)
+	}
+}
+
+// RunWhileLeader runs a singleton worker: run is started whenever this replica becomes the
+// leader, with a context cancelled when it loses the leadership or ctx is done
+func (e *Elector) RunWhileLeader(ctx context.Context, run func(ctx context.Context)) {
+	var mu sync.Mutex
+	var cancel context.CancelFunc
+	e.OnChange(func(leader bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+			cancel = nil
+		}
+		if leader && ctx.Err() == nil {
+			var workerCtx context.Context
+			workerCtx, cancel = context.WithCancel(ctx)
+			go run(workerCtx)
+		}
+	})
+}
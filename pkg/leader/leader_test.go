@@ -0,0 +1,202 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLock is a Lock held in memory, shared by the electors of a test; failing makes it
+// unreachable
+type memoryLock struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+	failing bool
+}
+
+func (l *memoryLock) Name() string { return "memory" }
+
+func (l *memoryLock) Acquire(ctx context.Context, identity string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failing {
+		return false, errors.New("unreachable")
+	}
+	if l.holder == "" || l.holder == identity || time.Now().After(l.expires) {
+		l.holder, l.expires = identity, time.Now().Add(ttl)
+	}
+	return l.holder == identity, nil
+}
+
+func (l *memoryLock) Release(ctx context.Context, identity string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == identity {
+		l.holder = ""
+	}
+	return nil
+}
+
+func (l *memoryLock) setFailing(failing bool) {
+	l.mu.Lock()
+	l.failing = failing
+	l.mu.Unlock()
+}
+
+// eventually waits up to a second for condition
+func eventually(t *testing.T, condition func() bool, message string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewElectorDefaults(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      Options
+		wantTTL      time.Duration
+		wantInterval time.Duration
+	}{
+		{name: "unset", wantTTL: DefaultLeaseDuration, wantInterval: DefaultRenewInterval},
+		{name: "set", options: Options{LeaseDuration: time.Minute, RenewInterval: 10 * time.Second}, wantTTL: time.Minute, wantInterval: 10 * time.Second},
+		{name: "short lease", options: Options{LeaseDuration: 3 * time.Second}, wantTTL: 3 * time.Second, wantInterval: time.Second},
+		{name: "renewal after the lease", options: Options{LeaseDuration: 6 * time.Second, RenewInterval: 6 * time.Second}, wantTTL: 6 * time.Second, wantInterval: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elector := NewElector(&memoryLock{}, tt.options)
+			if elector.ttl != tt.wantTTL || elector.interval != tt.wantInterval {
+				t.Errorf("lease %s renewed every %s, want %s every %s", elector.ttl, elector.interval, tt.wantTTL, tt.wantInterval)
+			}
+			if elector.Identity() == "" {
+				t.Errorf("Identity() is empty")
+			}
+		})
+	}
+}
+
+func TestElectionFailover(t *testing.T) {
+	lock := &memoryLock{}
+	options := Options{LeaseDuration: 200 * time.Millisecond, RenewInterval: 20 * time.Millisecond}
+	first := NewElector(lock, Options{Identity: "first", LeaseDuration: options.LeaseDuration, RenewInterval: options.RenewInterval})
+	second := NewElector(lock, Options{Identity: "second", LeaseDuration: options.LeaseDuration, RenewInterval: options.RenewInterval})
+	ctx := context.Background()
+
+	if err := first.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer second.Stop(ctx)
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("first leads %v and second %v, want the first only", first.IsLeader(), second.IsLeader())
+	}
+
+	var changes []bool
+	var mu sync.Mutex
+	second.OnChange(func(leader bool) {
+		mu.Lock()
+		changes = append(changes, leader)
+		mu.Unlock()
+	})
+
+	if err := first.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if first.IsLeader() {
+		t.Errorf("first still leads after Stop")
+	}
+	eventually(t, second.IsLeader, "second did not take over from the stopped leader")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 1 || !changes[0] {
+		t.Errorf("observer saw %v, want [true]", changes)
+	}
+}
+
+func TestAttemptUnreachableBackend(t *testing.T) {
+	tests := []struct {
+		name string
+		// sinceRenewal is how long ago the leader renewed its lease
+		sinceRenewal time.Duration
+		wantLeader   bool
+	}{
+		{name: "lease still long", sinceRenewal: 0, wantLeader: true},
+		{name: "lease about to run out", sinceRenewal: 90 * time.Second, wantLeader: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := &memoryLock{}
+			elector := NewElector(lock, Options{Identity: "leader", LeaseDuration: 100 * time.Second, RenewInterval: 20 * time.Second})
+			if err := elector.attempt(context.Background()); err != nil || !elector.IsLeader() {
+				t.Fatalf("attempt() = %v, leader %v", err, elector.IsLeader())
+			}
+
+			elector.mu.Lock()
+			elector.renewed = time.Now().Add(-tt.sinceRenewal)
+			elector.mu.Unlock()
+			lock.setFailing(true)
+			if err := elector.attempt(context.Background()); err == nil {
+				t.Fatalf("attempt() succeeded on an unreachable backend")
+			}
+			if elector.IsLeader() != tt.wantLeader {
+				t.Errorf("IsLeader() = %v, want %v", elector.IsLeader(), tt.wantLeader)
+			}
+			if elector.Err() == nil {
+				t.Errorf("Err() is nil after a failed attempt")
+			}
+		})
+	}
+}
+
+func TestStartUnreachableBackend(t *testing.T) {
+	lock := &memoryLock{failing: true}
+	elector := NewElector(lock, Options{})
+	if err := elector.Start(context.Background()); err == nil {
+		t.Fatalf("Start() succeeded on an unreachable backend")
+	}
+	if err := elector.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() of an elector that did not start = %v", err)
+	}
+}
+
+func TestRunWhileLeader(t *testing.T) {
+	lock := &memoryLock{}
+	elector := NewElector(lock, Options{Identity: "leader"})
+	ctx := context.Background()
+
+	running := make(chan context.Context, 2)
+	elector.RunWhileLeader(ctx, func(ctx context.Context) { running <- ctx })
+	if err := elector.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var worker context.Context
+	select {
+	case worker = <-running:
+	case <-time.After(time.Second):
+		t.Fatal("the worker did not start on the leader")
+	}
+	if err := elector.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-worker.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the worker was not cancelled when the leadership was lost")
+	}
+	if len(running) > 0 {
+		t.Errorf("the worker started again")
+	}
+}
@@ -0,0 +1,64 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript sets the key to the identity when it is free, and extends its expiry when the
+// identity holds it already
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if holder == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the key when the identity holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLock is a lock held in a Redis key that expires with the lease
+type RedisLock struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisLock creates a lock held in key; Close closes the client
+func NewRedisLock(client redis.UniversalClient, key string) *RedisLock {
+	return &RedisLock{client: client, key: key}
+}
+
+// Name returns redis
+func (l *RedisLock) Name() string { return "redis" }
+
+// Acquire takes or extends the key
+func (l *RedisLock) Acquire(ctx context.Context, identity string, ttl time.Duration) (bool, error) {
+	held, err := acquireScript.Run(ctx, l.client, []string{l.key}, identity, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// Release deletes the key if identity holds it
+func (l *RedisLock) Release(ctx context.Context, identity string) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, identity).Err()
+}
+
+// Close closes the Redis client
+func (l *RedisLock) Close() error {
+	return l.client.Close()
+}
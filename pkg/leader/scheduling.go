@@ -0,0 +1,154 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anasamu/go-micro-libs/scheduling"
+	"github.com/anasamu/go-micro-libs/scheduling/types"
+	"github.com/sirupsen/logrus"
+)
+
+// schedulingTimeout bounds the scheduling and cancelling of the tasks on a leadership change
+const schedulingTimeout = 30 * time.Second
+
+// Scheduler gates a scheduling provider on the leadership: the tasks scheduled through it are
+// kept, handed to the provider while this replica leads and cancelled on it when the
+// leadership is lost, so that running several replicas does not run a job several times.
+// The other provider methods go to the provider directly.
+type Scheduler struct {
+	scheduling.SchedulingProvider
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	elector *Elector
+	tasks   map[string]*types.Task
+	// running is whether the tasks are on the provider
+	running bool
+}
+
+// NewScheduler gates provider on the leadership of elector
+func NewScheduler(elector *Elector, provider scheduling.SchedulingProvider, logger *logrus.Logger) *Scheduler {
+	scheduler := &Scheduler{
+		SchedulingProvider: provider,
+		logger:             logger,
+		tasks:              make(map[string]*types.Task),
+	}
+	scheduler.SetElector(elector)
+	return scheduler
+}
+
+// Provider returns the gated provider
+func (s *Scheduler) Provider() scheduling.SchedulingProvider {
+	return s.SchedulingProvider
+}
+
+// SetElector gates the tasks on the leadership of another elector, such as one restarted by a
+// configuration reload. With a nil elector the tasks run on every replica.
+func (s *Scheduler) SetElector(elector *Elector) {
+	s.mu.Lock()
+	s.elector = elector
+	s.mu.Unlock()
+
+	if elector == nil {
+		s.leadershipChanged(nil, true)
+		return
+	}
+	elector.OnChange(func(leader bool) { s.leadershipChanged(elector, leader) })
+	if !elector.IsLeader() {
+		s.leadershipChanged(elector, false)
+	}
+}
+
+// leadershipChanged schedules the kept tasks on the provider, or cancels them, unless they
+// are there already or the scheduler follows another elector by now
+func (s *Scheduler) leadershipChanged(elector *Elector, leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.elector != elector || s.running == leader {
+		return
+	}
+	s.running = leader
+
+	ctx, cancel := context.WithTimeout(context.Background(), schedulingTimeout)
+	defer cancel()
+	for id, task := range s.tasks {
+		var err error
+		if leader {
+			_, err = s.SchedulingProvider.ScheduleTask(ctx, task)
+		} else {
+			err = s.SchedulingProvider.CancelTask(ctx, id)
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("task", id).Warn("Failed to hand over the task on a leadership change")
+		}
+	}
+	if len(s.tasks) > 0 {
+		s.logger.WithFields(logrus.Fields{"tasks": len(s.tasks), "leader": leader}).Info("Scheduled tasks handed over on a leadership change")
+	}
+}
+
+// ScheduleTask keeps the task, and schedules it on the provider while this replica leads
+func (s *Scheduler) ScheduleTask(ctx context.Context, task *types.Task) (*types.TaskResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[task.ID] = task
+	if s.running {
+		return s.SchedulingProvider.ScheduleTask(ctx, task)
+	}
+	return &types.TaskResult{
+		TaskID:    task.ID,
+		Status:    types.TaskStatusPending,
+		Message:   "held until this replica is the leader",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// ScheduleMultiple schedules each task with ScheduleTask
+func (s *Scheduler) ScheduleMultiple(ctx context.Context, tasks []*types.Task) ([]*types.TaskResult, error) {
+	results := make([]*types.TaskResult, 0, len(tasks))
+	for _, task := range tasks {
+		result, err := s.ScheduleTask(ctx, task)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// UpdateTask updates the kept task, and the task on the provider while this replica leads
+func (s *Scheduler) UpdateTask(ctx context.Context, task *types.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[task.ID] = task
+	if s.running {
+		return s.SchedulingProvider.UpdateTask(ctx, task)
+	}
+	return nil
+}
+
+// CancelTask forgets the task, and cancels it on the provider while this replica leads
+func (s *Scheduler) CancelTask(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tasks, taskID)
+	if s.running {
+		return s.SchedulingProvider.CancelTask(ctx, taskID)
+	}
+	return nil
+}
+
+// CancelMultiple cancels each task with CancelTask
+func (s *Scheduler) CancelMultiple(ctx context.Context, taskIDs []string) error {
+	for _, taskID := range taskIDs {
+		if err := s.CancelTask(ctx, taskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"time"
+
+	"github.com/anasamu/go-micro-libs/database"
+)
+
+// DefaultTable is the migration table of go-micro-libs
+const DefaultTable = "schema_migrations"
+
+// lockPollInterval is how often a held migration lock is retried
+const lockPollInterval = 500 * time.Millisecond
+
+// lockDialect is how a database holds the migration lock
+type lockDialect struct {
+	// advisory is set for the databases with advisory locks, which do not need a lock table
+	advisory bool
+	// timestamp is the column type of locked_at
+	timestamp string
+	// numbered is set for the databases whose placeholders are $1, $2... rather than ?
+	numbered bool
+}
+
+// lockDialects are the databases that can hold the migration lock, by provider name. Other
+// databases are not locked.
+var lockDialects = map[string]lockDialect{
+	"postgresql":  {advisory: true},
+	"postgres":    {advisory: true},
+	"cockroachdb": {timestamp: "TIMESTAMP", numbered: true},
+	"cockroach":   {timestamp: "TIMESTAMP", numbered: true},
+	"mysql":       {timestamp: "DATETIME"},
+	"mariadb":     {timestamp: "DATETIME"},
+	"sqlite":      {timestamp: "DATETIME"},
+	"sqlite3":     {timestamp: "DATETIME"},
+}
+
+// LockOptions configures Lock
+type LockOptions struct {
+	// Table is the migration table, which names the lock; DefaultTable when empty
+	Table string
+	// Timeout is how long Lock waits for another run to release the lock
+	Timeout time.Duration
+	// Waiting is called once when another run holds the lock, with the owner of the lock
+	// when the database records it
+	Waiting func(holder string)
+}
+
+// Lockable reports whether the database of a provider can hold the migration lock
+func Lockable(provider string) bool {
+	_, ok := lockDialects[provider]
+	return ok
+}
+
+// Lock takes the migration lock of a database, waiting up to options.Timeout for another
+// run, a replica applying the migrations at startup or a `microframework migrate`, to
+// release it, and returns the function that releases it. PostgreSQL uses a
+// transaction-scoped advisory lock; CockroachDB, MySQL, MariaDB and SQLite insert a row into
+// <table>_lock. The databases that are not Lockable are not locked.
+func Lock(ctx context.Context, provider database.DatabaseProvider, options LockOptions) (func(), error) {
+	dialect, ok := lockDialects[provider.GetName()]
+	if !ok {
+		return func() {}, nil
+	}
+	if options.Table == "" {
+		options.Table = DefaultTable
+	}
+	if options.Waiting == nil {
+		options.Waiting = func(string) {}
+	}
+
+	deadline := time.Now().Add(options.Timeout)
+	if dialect.advisory {
+		return advisoryLock(ctx, provider, options, deadline)
+	}
+	return rowLock(ctx, provider, dialect, options, deadline)
+}
+
+// advisoryLock holds pg_advisory_xact_lock in a transaction that stays open until release,
+// so the lock is dropped by the server if the process dies
+func advisoryLock(ctx context.Context, provider database.DatabaseProvider, options LockOptions, deadline time.Time) (func(), error) {
+	tx, err := provider.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	key := int64(crc32.ChecksumIEEE([]byte("microframework:" + options.Table)))
+
+	waiting := false
+	for {
+		row, err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", key)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		var locked bool
+		if err := row.Scan(&locked); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if locked {
+			return func() { tx.Rollback() }, nil
+		}
+
+		if time.Now().After(deadline) {
+			tx.Rollback()
+			return nil, fmt.Errorf("another migration holds the lock (advisory lock %d); gave up after %s", key, options.Timeout)
+		}
+		if !waiting {
+			options.Waiting("")
+			waiting = true
+		}
+		if err := sleep(ctx, lockPollInterval); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+}
+
+// rowLock inserts the single row of the lock table; the primary key makes a second insert
+// fail while the lock is held
+func rowLock(ctx context.Context, provider database.DatabaseProvider, dialect lockDialect, options LockOptions, deadline time.Time) (func(), error) {
+	table := options.Table + "_lock"
+	placeholder := func(n int) string {
+		if dialect.numbered {
+			return fmt.Sprintf("$%d", n)
+		}
+		return "?"
+	}
+
+	_, err := provider.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY,
+	owner VARCHAR(255) NOT NULL,
+	locked_at %s NOT NULL
+)`, table, dialect.timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration lock table: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	insert := fmt.Sprintf("INSERT INTO %s (id, owner, locked_at) VALUES (1, %s, %s)", table, placeholder(1), placeholder(2))
+
+	waiting := false
+	for {
+		_, insertErr := provider.Exec(ctx, insert, owner, time.Now())
+		if insertErr == nil {
+			return func() {
+				provider.Exec(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND owner = %s", table, placeholder(1)), owner)
+			}, nil
+		}
+
+		// The insert also fails for reasons other than a held lock
+		holder, lockedAt, err := lockHolder(ctx, provider, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", insertErr)
+		}
+		if holder == "" {
+			// The lock was released between the insert and the check
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("failed to acquire migration lock: %w", insertErr)
+			}
+		} else {
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("migration lock held by %s since %s; gave up after %s. If that run is gone, delete the row from %s",
+					holder, lockedAt, options.Timeout, table)
+			}
+			if !waiting {
+				options.Waiting(holder)
+				waiting = true
+			}
+		}
+		if err := sleep(ctx, lockPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lockHolder returns the owner of the lock row and when it was taken, or "" when it is free
+func lockHolder(ctx context.Context, provider database.DatabaseProvider, table string) (string, string, error) {
+	rows, err := provider.Query(ctx, fmt.Sprintf("SELECT owner, locked_at FROM %s WHERE id = 1", table))
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", "", rows.Err()
+	}
+	var owner string
+	var lockedAt interface{}
+	if err := rows.Scan(&owner, &lockedAt); err != nil {
+		return "", "", err
+	}
+	return owner, fmt.Sprint(lockedAt), nil
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,296 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anasamu/go-micro-libs/database"
+	"github.com/anasamu/go-micro-libs/database/types"
+)
+
+// fakeProvider is a database that answers the statements of Lock from a script: the results
+// of the inserts of the lock row, or of pg_try_advisory_xact_lock, in order
+type fakeProvider struct {
+	database.DatabaseProvider
+	name string
+
+	// createErr fails the creation of the lock table
+	createErr error
+	// inserts are the errors of the successive inserts of the lock row; nil once exhausted
+	inserts []error
+	// holder owns the lock row, "" when there is none; holderErr fails reading it
+	holder    string
+	holderErr error
+
+	// beginErr fails the transaction of the advisory lock
+	beginErr error
+	// locked are the successive results of pg_try_advisory_xact_lock; true once exhausted
+	locked []bool
+
+	statements []string
+	args       [][]interface{}
+	rollbacks  int
+}
+
+func (p *fakeProvider) GetName() string { return p.name }
+
+func (p *fakeProvider) Exec(ctx context.Context, query string, args ...interface{}) (types.ExecResult, error) {
+	p.statements = append(p.statements, query)
+	p.args = append(p.args, args)
+	switch {
+	case strings.HasPrefix(query, "CREATE"):
+		return nil, p.createErr
+	case strings.HasPrefix(query, "INSERT"):
+		if len(p.inserts) == 0 {
+			return nil, nil
+		}
+		err := p.inserts[0]
+		p.inserts = p.inserts[1:]
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (p *fakeProvider) Query(ctx context.Context, query string, args ...interface{}) (types.QueryResult, error) {
+	p.statements = append(p.statements, query)
+	if p.holderErr != nil {
+		return nil, p.holderErr
+	}
+	return &fakeRows{owner: p.holder}, nil
+}
+
+func (p *fakeProvider) BeginTransaction(ctx context.Context) (types.Transaction, error) {
+	if p.beginErr != nil {
+		return nil, p.beginErr
+	}
+	return &fakeTransaction{provider: p}, nil
+}
+
+// fakeTransaction is the transaction of the advisory lock
+type fakeTransaction struct {
+	types.Transaction
+	provider *fakeProvider
+}
+
+func (tx *fakeTransaction) QueryRow(ctx context.Context, query string, args ...interface{}) (types.Row, error) {
+	tx.provider.statements = append(tx.provider.statements, query)
+	locked := true
+	if len(tx.provider.locked) > 0 {
+		locked = tx.provider.locked[0]
+		tx.provider.locked = tx.provider.locked[1:]
+	}
+	return fakeRow{locked: locked}, nil
+}
+
+func (tx *fakeTransaction) Rollback() error {
+	tx.provider.rollbacks++
+	return nil
+}
+
+// fakeRow is the result of pg_try_advisory_xact_lock
+type fakeRow struct{ locked bool }
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	*dest[0].(*bool) = r.locked
+	return nil
+}
+
+func (r fakeRow) Err() error { return nil }
+
+// fakeRows is the lock row, when there is an owner
+type fakeRows struct {
+	types.QueryResult
+	owner string
+	read  bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.owner == "" || r.read {
+		return false
+	}
+	r.read = true
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	*dest[0].(*string) = r.owner
+	*dest[1].(*interface{}) = "2024-01-01 00:00:00"
+	return nil
+}
+
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Close() error { return nil }
+
+func TestLockRow(t *testing.T) {
+	duplicate := errors.New("UNIQUE constraint failed: schema_migrations_lock.id")
+	tests := []struct {
+		name     string
+		provider *fakeProvider
+		timeout  time.Duration
+		// wantErr is part of the error of Lock, "" when it takes the lock
+		wantErr string
+		// wantWaiting is the holder Waiting is called with, "" when it is not called
+		wantWaiting string
+		// wantPlaceholder is the placeholder of the owner in the insert
+		wantPlaceholder string
+	}{
+		{
+			name:            "free",
+			provider:        &fakeProvider{name: "sqlite"},
+			wantPlaceholder: "?",
+		},
+		{
+			name:            "numbered placeholders",
+			provider:        &fakeProvider{name: "cockroachdb"},
+			wantPlaceholder: "$1",
+		},
+		{
+			name:            "released while waiting",
+			provider:        &fakeProvider{name: "mysql", inserts: []error{duplicate}, holder: "other:1"},
+			timeout:         5 * time.Second,
+			wantWaiting:     "other:1",
+			wantPlaceholder: "?",
+		},
+		{
+			name:     "held past the timeout",
+			provider: &fakeProvider{name: "mysql", inserts: []error{duplicate}, holder: "other:1"},
+			wantErr:  "migration lock held by other:1",
+		},
+		{
+			name:     "insert fails without a holder",
+			provider: &fakeProvider{name: "sqlite", inserts: []error{duplicate}},
+			wantErr:  "failed to acquire migration lock",
+		},
+		{
+			name:     "holder cannot be read",
+			provider: &fakeProvider{name: "sqlite", inserts: []error{duplicate}, holderErr: errors.New("no such table")},
+			wantErr:  "failed to acquire migration lock",
+		},
+		{
+			name:     "lock table cannot be created",
+			provider: &fakeProvider{name: "mariadb", createErr: errors.New("permission denied")},
+			wantErr:  "failed to create migration lock table",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waiting []string
+			release, err := Lock(context.Background(), tt.provider, LockOptions{
+				Timeout: tt.timeout,
+				Waiting: func(holder string) { waiting = append(waiting, holder) },
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Lock() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+
+			if tt.wantWaiting == "" && len(waiting) > 0 {
+				t.Errorf("Waiting called with %q", waiting)
+			}
+			if tt.wantWaiting != "" && (len(waiting) != 1 || waiting[0] != tt.wantWaiting) {
+				t.Errorf("Waiting called with %q, want once with %q", waiting, tt.wantWaiting)
+			}
+
+			release()
+			last := tt.provider.statements[len(tt.provider.statements)-1]
+			want := "DELETE FROM schema_migrations_lock WHERE id = 1 AND owner = " + tt.wantPlaceholder
+			if last != want {
+				t.Errorf("release ran %q, want %q", last, want)
+			}
+			insert := tt.provider.args[len(tt.provider.args)-2]
+			if owner := tt.provider.args[len(tt.provider.args)-1][0]; owner != insert[0] {
+				t.Errorf("release deleted the row of %v, the lock was taken by %v", owner, insert[0])
+			}
+		})
+	}
+}
+
+func TestLockAdvisory(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    *fakeProvider
+		timeout     time.Duration
+		wantErr     string
+		wantWaiting bool
+		// wantRollbacks are the rollbacks of the transaction once Lock returns
+		wantRollbacks int
+	}{
+		{
+			name:     "free",
+			provider: &fakeProvider{name: "postgres"},
+		},
+		{
+			name:        "released while waiting",
+			provider:    &fakeProvider{name: "postgresql", locked: []bool{false}},
+			timeout:     5 * time.Second,
+			wantWaiting: true,
+		},
+		{
+			name:          "held past the timeout",
+			provider:      &fakeProvider{name: "postgres", locked: []bool{false}},
+			wantErr:       "another migration holds the lock",
+			wantRollbacks: 1,
+		},
+		{
+			name:     "transaction cannot begin",
+			provider: &fakeProvider{name: "postgres", beginErr: errors.New("connection refused")},
+			wantErr:  "failed to acquire migration lock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waiting := false
+			release, err := Lock(context.Background(), tt.provider, LockOptions{
+				Timeout: tt.timeout,
+				Waiting: func(holder string) { waiting = true },
+			})
+			if tt.provider.rollbacks != tt.wantRollbacks {
+				t.Errorf("rollbacks = %d, want %d", tt.provider.rollbacks, tt.wantRollbacks)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Lock() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+			if waiting != tt.wantWaiting {
+				t.Errorf("waiting = %v, want %v", waiting, tt.wantWaiting)
+			}
+			for _, statement := range tt.provider.statements {
+				if strings.Contains(statement, "schema_migrations_lock") {
+					t.Errorf("advisory lock ran %q", statement)
+				}
+			}
+
+			release()
+			if tt.provider.rollbacks != 1 {
+				t.Errorf("release rolled back %d times, want 1", tt.provider.rollbacks)
+			}
+		})
+	}
+}
+
+func TestLockNotLockable(t *testing.T) {
+	provider := &fakeProvider{name: "mongodb"}
+	release, err := Lock(context.Background(), provider, LockOptions{})
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	release()
+	if len(provider.statements) > 0 {
+		t.Errorf("Lock ran %q on a database that is not lockable", provider.statements)
+	}
+}
@@ -0,0 +1,80 @@
+// Package migrate registers migrations written in Go. They are applied by
+// `microframework migrate` together with the JSON migrations of the same directory,
+// ordered by version.
+//
+// A Go migration lives in the migrations directory of a service, in package migrations:
+//
+//	func init() {
+//		migrate.Register("20240105120000", "backfill user slugs", upBackfillSlugs, downBackfillSlugs)
+//	}
+//
+//	func upBackfillSlugs(ctx context.Context, tx types.Transaction) error {
+//		_, err := tx.Exec(ctx, "UPDATE users SET slug = lower(name) WHERE slug IS NULL")
+//		return err
+//	}
+//
+// Lock takes the migration lock of a database, which the migrate command and the services
+// applying their migrations at startup share, so that one run applies them at a time.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/anasamu/go-micro-libs/database/types"
+)
+
+// Func applies or reverts a migration inside the transaction that records it
+type Func func(ctx context.Context, tx types.Transaction) error
+
+// Migration is a registered Go migration
+type Migration struct {
+	Version     string
+	Description string
+	Up          Func
+	// Down may be nil when the migration cannot be reverted
+	Down Func
+}
+
+var (
+	mu         sync.Mutex
+	registered = map[string]Migration{}
+)
+
+// Register adds a Go migration. It panics when the version is registered twice, so that
+// conflicting migrations are caught when the service starts.
+func Register(version, description string, up, down Func) {
+	if up == nil {
+		panic(fmt.Sprintf("migrate: migration %s has no up function", version))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := registered[version]; exists {
+		panic(fmt.Sprintf("migrate: migration %s registered twice", version))
+	}
+	registered[version] = Migration{Version: version, Description: description, Up: up, Down: down}
+}
+
+// Lookup returns the Go migration registered for version
+func Lookup(version string) (Migration, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	migration, ok := registered[version]
+	return migration, ok
+}
+
+// Registered returns the registered Go migrations ordered by version
+func Registered() []Migration {
+	mu.Lock()
+	defer mu.Unlock()
+
+	list := make([]Migration, 0, len(registered))
+	for _, migration := range registered {
+		list = append(list, migration)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
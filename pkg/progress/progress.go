@@ -0,0 +1,126 @@
+// Package progress streams the progress of long operations, as generating a project,
+// deploying or updating it, so that IDE extensions and web UIs can show it as it happens.
+// An operation sends an Event over a channel when a step starts, completes or fails and
+// when it writes a file:
+//
+//	events := make(chan progress.Event)
+//	go func() {
+//		for event := range events {
+//			fmt.Printf("%3d%% %s %s%s\n", event.Percent, event.Type, event.Step, event.Path)
+//		}
+//	}()
+//	err := generator.NewServiceGenerator(config, generator.WithProgress(events)).GenerateService()
+//	close(events)
+package progress
+
+import (
+	"time"
+)
+
+// EventType is the kind of an event
+type EventType string
+
+// Event types
+const (
+	StepStarted   EventType = "step_started"
+	StepCompleted EventType = "step_completed"
+	StepFailed    EventType = "step_failed"
+	FileWritten   EventType = "file_written"
+)
+
+// Event is a step of an operation, or a file it wrote
+type Event struct {
+	Type EventType `json:"type"`
+	// Operation is the operation running: generate, deploy, update
+	Operation string `json:"operation"`
+	// Step is the step that started, completed or failed, or the step writing the file
+	Step string `json:"step,omitempty"`
+	// Path is the file written, relative to the directory of the operation
+	Path string `json:"path,omitempty"`
+	// Size is the size of the file written, in bytes
+	Size int64 `json:"size,omitempty"`
+	// Duration is how long the file took to write, or to render and write for the files
+	// streamed as they render; nanoseconds in JSON
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is why the step failed
+	Error string `json:"error,omitempty"`
+	// Percent is the share of the steps of the operation completed, 0 to 100
+	Percent int       `json:"percent"`
+	Time    time.Time `json:"time"`
+}
+
+// Reporter sends the events of an operation over a channel and counts its completed steps.
+// The sends block until the events are received. A nil *Reporter reports nothing, so that
+// operations report unconditionally.
+type Reporter struct {
+	events    chan<- Event
+	operation string
+	total     int
+	completed int
+	step      string
+}
+
+// NewReporter returns a reporter of an operation of total steps sending its events over
+// events, or nil when events is nil
+func NewReporter(events chan<- Event, operation string, total int) *Reporter {
+	if events == nil {
+		return nil
+	}
+	return &Reporter{events: events, operation: operation, total: total}
+}
+
+// Start reports that a step started
+func (r *Reporter) Start(step string) {
+	if r == nil {
+		return
+	}
+	r.step = step
+	r.send(Event{Type: StepStarted, Step: step})
+}
+
+// Complete reports that the running step completed
+func (r *Reporter) Complete() {
+	if r == nil {
+		return
+	}
+	if r.completed < r.total {
+		r.completed++
+	}
+	r.send(Event{Type: StepCompleted, Step: r.step})
+}
+
+// Fail reports that the running step failed with err
+func (r *Reporter) Fail(err error) {
+	if r == nil {
+		return
+	}
+	r.send(Event{Type: StepFailed, Step: r.step, Error: err.Error()})
+}
+
+// File reports that the running step wrote the file at path, of size bytes, in duration
+func (r *Reporter) File(path string, size int64, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.send(Event{Type: FileWritten, Step: r.step, Path: path, Size: size, Duration: duration})
+}
+
+// Step runs a step between its Start and its Complete, or its Fail when it returns an error
+func (r *Reporter) Step(step string, run func() error) error {
+	r.Start(step)
+	if err := run(); err != nil {
+		r.Fail(err)
+		return err
+	}
+	r.Complete()
+	return nil
+}
+
+func (r *Reporter) send(event Event) {
+	event.Operation = r.operation
+	if r.total > 0 {
+		event.Percent = r.completed * 100 / r.total
+	}
+	event.Time = time.Now().UTC()
+	r.events <- event
+}
@@ -0,0 +1,131 @@
+// Package schema ships the JSON Schemas of the files of a microframework project, and
+// validates YAML and JSON documents against them, reporting problems at their line:
+//
+//	problems, err := schema.ValidateFile("configs/config.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	for _, problem := range problems {
+//		fmt.Println(problem)
+//	}
+//
+// The schemas are published at URL(name), which the files point YAML editors at with the
+// Header(name) comment, so that editors check them as they are edited.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Schema names
+const (
+	// CLIConfig is the schema of .microframework.yaml, the configuration of the CLI
+	CLIConfig = "microframework"
+	// Config is the schema of configs/config.yaml and its overlays, the configuration of a service
+	Config = "config"
+	// Environments is the schema of deployments/environments.yaml
+	Environments = "environments"
+)
+
+// BaseURL is where the schemas are published, as <name>.schema.json
+const BaseURL = "https://raw.githubusercontent.com/anasamu/go-micro-framework/main/pkg/schema/schemas/"
+
+//go:embed schemas/*.schema.json
+var files embed.FS
+
+// configFilePattern matches the base names of the configuration files of a service
+var configFilePattern = regexp.MustCompile(`^config(\.[A-Za-z0-9_-]+)?\.ya?ml$`)
+
+// Names returns the names of the schemas, sorted
+func Names() []string {
+	entries, _ := files.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the JSON Schema of a name
+func Get(name string) ([]byte, bool) {
+	content, err := files.ReadFile("schemas/" + name + ".schema.json")
+	return content, err == nil
+}
+
+// URL returns where the schema of a name is published
+func URL(name string) string {
+	return BaseURL + name + ".schema.json"
+}
+
+// Header returns the comment pointing YAML editors (through the YAML language server) at the
+// schema of a name, the first line of the files it describes
+func Header(name string) string {
+	return "# yaml-language-server: $schema=" + URL(name)
+}
+
+// Files returns the files of a project the schema of a name describes, as patterns
+func Files(name string) []string {
+	switch name {
+	case CLIConfig:
+		return []string{".microframework.yaml"}
+	case Config:
+		return []string{"configs/config.yaml", "configs/config.*.yaml"}
+	case Environments:
+		return []string{"deployments/environments.yaml"}
+	}
+	return nil
+}
+
+// ForFile returns the name of the schema describing the file at a path, if any
+func ForFile(file string) (string, bool) {
+	file = filepath.ToSlash(filepath.Clean(file))
+	base := path.Base(file)
+	switch {
+	case base == ".microframework.yaml" || base == ".microframework.yml":
+		return CLIConfig, true
+	case configFilePattern.MatchString(base) && path.Base(path.Dir(file)) == "configs":
+		return Config, true
+	case base == "environments.yaml" || base == "environments.yml":
+		return Environments, true
+	}
+	return "", false
+}
+
+var (
+	compiled     map[string]*node
+	compiledErr  error
+	compiledOnce sync.Once
+)
+
+// load returns the parsed schema of a name
+func load(name string) (*node, error) {
+	compiledOnce.Do(func() {
+		compiled = make(map[string]*node)
+		for _, schemaName := range Names() {
+			content, _ := Get(schemaName)
+			root := &node{}
+			if err := json.Unmarshal(content, root); err != nil {
+				compiledErr = fmt.Errorf("invalid schema %s: %w", schemaName, err)
+				return
+			}
+			compiled[schemaName] = root
+		}
+	})
+	if compiledErr != nil {
+		return nil, compiledErr
+	}
+	root, ok := compiled[name]
+	if !ok {
+		return nil, fmt.Errorf("no schema %q (%s)", name, strings.Join(Names(), ", "))
+	}
+	return root, nil
+}
@@ -0,0 +1,331 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is where a document does not follow its schema
+type Problem struct {
+	// Path is the path of the value, as service.port or hooks.before[0]; "" for the document
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Path, p.Message)
+}
+
+// Validate validates a YAML or JSON document against the schema of a name. It returns an
+// error when there is no such schema or the document does not parse.
+func Validate(name string, content []byte) ([]Problem, error) {
+	root, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		value = document.Content[0]
+	}
+
+	v := &validator{root: root}
+	v.validate(root, value, "")
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Column < v.problems[j].Column
+	})
+	return v.problems, nil
+}
+
+// ValidateFile validates the file at a path against the schema describing it (ForFile)
+func ValidateFile(file string) ([]Problem, error) {
+	name, ok := ForFile(file)
+	if !ok {
+		return nil, fmt.Errorf("no schema describes %s", file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(name, content)
+}
+
+// node is a JSON Schema, of the keywords the schemas of this package use
+type node struct {
+	Ref                  string           `json:"$ref"`
+	Defs                 map[string]*node `json:"$defs"`
+	Type                 types            `json:"type"`
+	Properties           map[string]*node `json:"properties"`
+	AdditionalProperties *additional      `json:"additionalProperties"`
+	PropertyNames        *node            `json:"propertyNames"`
+	Required             []string         `json:"required"`
+	Items                *node            `json:"items"`
+	Enum                 []interface{}    `json:"enum"`
+	Pattern              string           `json:"pattern"`
+	MinLength            *int             `json:"minLength"`
+	Minimum              *float64         `json:"minimum"`
+	Maximum              *float64         `json:"maximum"`
+
+	pattern *regexp.Regexp
+}
+
+// types is the type keyword: a type or a list of types
+type types []string
+
+func (t *types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = types{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additional is the additionalProperties keyword: whether other properties are allowed, or
+// the schema they follow
+type additional struct {
+	allowed bool
+	schema  *node
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	a.schema = &node{}
+	return json.Unmarshal(data, a.schema)
+}
+
+// validator collects the problems of a document
+type validator struct {
+	root     *node
+	problems []Problem
+}
+
+func (v *validator) report(value *yaml.Node, path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Line: value.Line, Column: value.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve follows the $ref of a schema to the definition of the root schema it names
+func (v *validator) resolve(schema *node) *node {
+	for schema.Ref != "" {
+		definition := v.root.Defs[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+		if definition == nil {
+			return &node{}
+		}
+		schema = definition
+	}
+	return schema
+}
+
+func (v *validator) validate(schema *node, value *yaml.Node, path string) {
+	schema = v.resolve(schema)
+	if value.Kind == yaml.AliasNode && value.Alias != nil {
+		value = value.Alias
+	}
+
+	kind := kindOf(value)
+	if len(schema.Type) > 0 && !matchesType(schema.Type, kind) {
+		v.report(value, path, "must be %s, not %s", article(strings.Join(schema.Type, " or ")), article(kind))
+		return
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		v.report(value, path, "must be one of %s, not %q", enumList(schema.Enum), value.Value)
+		return
+	}
+
+	switch value.Kind {
+	case yaml.MappingNode:
+		v.validateObject(schema, value, path)
+	case yaml.SequenceNode:
+		if schema.Items != nil {
+			for i, item := range value.Content {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case yaml.ScalarNode:
+		v.validateScalar(schema, value, path, kind)
+	}
+}
+
+func (v *validator) validateObject(schema *node, value *yaml.Node, path string) {
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, item := value.Content[i], value.Content[i+1]
+		if key.Value == "<<" {
+			continue
+		}
+		present[key.Value] = true
+		itemPath := key.Value
+		if path != "" {
+			itemPath = path + "." + key.Value
+		}
+
+		if schema.PropertyNames != nil && len(schema.PropertyNames.Enum) > 0 && !inEnum(schema.PropertyNames.Enum, key) {
+			v.report(key, itemPath, "unknown key, expected one of %s", enumList(schema.PropertyNames.Enum))
+			continue
+		}
+		if property, ok := schema.Properties[key.Value]; ok {
+			v.validate(property, item, itemPath)
+			continue
+		}
+		switch {
+		case schema.AdditionalProperties == nil:
+		case schema.AdditionalProperties.schema != nil:
+			v.validate(schema.AdditionalProperties.schema, item, itemPath)
+		case !schema.AdditionalProperties.allowed:
+			v.report(key, itemPath, "unknown key%s", suggestion(key.Value, schema.Properties))
+		}
+	}
+	for _, required := range schema.Required {
+		if !present[required] {
+			v.report(value, path, "missing required key %q", required)
+		}
+	}
+}
+
+func (v *validator) validateScalar(schema *node, value *yaml.Node, path, kind string) {
+	switch kind {
+	case "string":
+		if schema.MinLength != nil && len([]rune(value.Value)) < *schema.MinLength {
+			v.report(value, path, "must not be empty")
+		}
+		if schema.Pattern != "" {
+			if schema.pattern == nil {
+				schema.pattern = regexp.MustCompile(schema.Pattern)
+			}
+			if !schema.pattern.MatchString(value.Value) {
+				v.report(value, path, "%q does not match %s", value.Value, schema.Pattern)
+			}
+		}
+	case "integer", "number":
+		number, err := strconv.ParseFloat(strings.ReplaceAll(value.Value, "_", ""), 64)
+		if err != nil {
+			return
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			v.report(value, path, "must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			v.report(value, path, "must be at most %v", *schema.Maximum)
+		}
+	}
+}
+
+// kindOf returns the JSON Schema type of a YAML value
+func kindOf(value *yaml.Node) string {
+	switch value.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch value.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func matchesType(allowed types, kind string) bool {
+	for _, t := range allowed {
+		if t == kind || (t == "number" && kind == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// inEnum reports whether a scalar is one of the values of an enum
+func inEnum(enum []interface{}, value *yaml.Node) bool {
+	var decoded interface{}
+	if err := value.Decode(&decoded); err != nil {
+		return false
+	}
+	if integer, ok := decoded.(int); ok {
+		decoded = float64(integer)
+	}
+	for _, allowed := range enum {
+		if allowed == decoded {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = fmt.Sprint(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func article(kind string) string {
+	switch {
+	case kind == "null":
+		return "null"
+	case strings.IndexAny(kind, "aeiou") == 0:
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// suggestion names the known key closest to an unknown one, when one is close
+func suggestion(key string, properties map[string]*node) string {
+	best, distance := "", 3
+	for name := range properties {
+		if d := editDistance(key, name); d < distance || (d == distance && best != "" && name < best) {
+			best, distance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
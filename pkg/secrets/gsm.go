@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/auth/credentials"
+	"cloud.google.com/go/auth/httptransport"
+)
+
+// GSMOptions are the settings of a GSMProvider
+type GSMOptions struct {
+	// Project is the project of references that name none (default GOOGLE_CLOUD_PROJECT, then
+	// the project of the credentials)
+	Project string
+	// CredentialsFile is a credentials file replacing the Application Default Credentials,
+	// which are found as by the Google Cloud SDKs: GOOGLE_APPLICATION_CREDENTIALS, the gcloud
+	// credentials, or the metadata server (GKE, Cloud Run, GCE)
+	CredentialsFile string
+}
+
+// GSMProvider resolves gsm:// references to Google Secret Manager secret versions:
+// gsm://project/secret/version, gsm://project/secret for the latest version, gsm://secret in
+// the default project, or the full gsm://projects/project/secrets/secret/versions/version.
+// A #key reference reads a key of a secret holding a JSON object.
+type GSMProvider struct {
+	options GSMOptions
+
+	mu     sync.Mutex
+	client *http.Client
+}
+
+// NewGSMProvider creates a Google Secret Manager provider
+func NewGSMProvider(options GSMOptions) *GSMProvider {
+	if options.Project == "" {
+		options.Project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return &GSMProvider{options: options}
+}
+
+// Scheme returns gsm
+func (p *GSMProvider) Scheme() string { return "gsm" }
+
+// Resolve accesses a secret version
+func (p *GSMProvider) Resolve(ctx context.Context, reference Reference) (string, error) {
+	client, project, err := p.httpClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	name, err := versionName(reference.Path, project)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case response.StatusCode >= 300:
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return "", fmt.Errorf("secret manager %s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	if reference.Key == "" {
+		return string(value), nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(value, &values); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object, so it has no key %s", reference.Key)
+	}
+	return selectKey(reference, values)
+}
+
+// httpClient returns the HTTP client authenticating the requests with the credentials of
+// the provider, found the first time, and the default project
+func (p *GSMProvider) httpClient(ctx context.Context) (*http.Client, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, p.options.Project, nil
+	}
+
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+		CredentialsFile: p.options.CredentialsFile,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("no Google credentials: %w", err)
+	}
+	client, err := httptransport.NewClient(&httptransport.Options{Credentials: creds})
+	if err != nil {
+		return nil, "", err
+	}
+	client.Timeout = requestTimeout
+	if p.options.Project == "" {
+		// Not every kind of credentials has a project
+		p.options.Project, _ = creds.ProjectID(ctx)
+	}
+	p.client = client
+	return p.client, p.options.Project, nil
+}
+
+// versionName returns the resource name of the secret version a reference path names, in
+// project when it names none
+func versionName(path, project string) (string, error) {
+	path = strings.Trim(path, "/")
+	if strings.HasPrefix(path, "projects/") {
+		if !strings.Contains(path, "/versions/") {
+			path += "/versions/latest"
+		}
+		return path, nil
+	}
+
+	parts := strings.Split(path, "/")
+	secret, version := "", "latest"
+	switch len(parts) {
+	case 1:
+		secret = parts[0]
+	case 2:
+		project, secret = parts[0], parts[1]
+	case 3:
+		project, secret, version = parts[0], parts[1], parts[2]
+	default:
+		return "", fmt.Errorf("the path must be project/secret/version")
+	}
+	if project == "" {
+		return "", fmt.Errorf("no project: name one in the reference or set GOOGLE_CLOUD_PROJECT")
+	}
+	if secret == "" {
+		return "", fmt.Errorf("the secret name is required")
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, secret, version), nil
+}
@@ -0,0 +1,156 @@
+// Package secrets resolves references to secrets held in a secrets backend, so that database
+// passwords, JWT secrets and API keys are read at startup instead of being written in the
+// configuration or the environment of a deployment. A reference is a URI whose scheme names
+// the backend, with an optional fragment naming a key of a structured secret:
+//
+//	vault://secret/orders/db#password   a key of a Vault KV secret
+//	ssm:///orders/prod/db-password      an AWS SSM parameter
+//	gsm://my-project/orders-db/latest   a Google Secret Manager secret version
+//
+// Backends are Providers registered with a Resolver; any other value is left as it is.
+//
+//	resolver := secrets.NewResolver(secrets.NewVaultProvider(secrets.VaultOptions{}))
+//	password, err := resolver.Resolve(ctx, "vault://secret/orders/db#password")
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound means the referenced secret, or its key, does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// requestTimeout bounds each request of the built-in providers to their backend
+const requestTimeout = 10 * time.Second
+
+// Reference is a parsed secret reference
+type Reference struct {
+	// Scheme names the backend: vault, ssm, gsm, ...
+	Scheme string
+	// Path locates the secret in the backend: everything between the scheme and the fragment
+	Path string
+	// Key names a value of a structured secret; it is empty for a plain secret
+	Key string
+}
+
+// ParseReference parses scheme://path#key, reporting whether value has that form
+func ParseReference(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok || scheme == "" || rest == "" || strings.ContainsAny(value, " \t\n") {
+		return Reference{}, false
+	}
+	for _, r := range scheme {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '+') {
+			return Reference{}, false
+		}
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// String returns the reference in its URI form
+func (r Reference) String() string {
+	if r.Key == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Key
+}
+
+// Provider reads secrets from a backend
+type Provider interface {
+	// Scheme is the URI scheme of the references the provider resolves
+	Scheme() string
+	// Resolve returns the value of the referenced secret
+	Resolve(ctx context.Context, reference Reference) (string, error)
+}
+
+// Resolver resolves references with the provider registered for their scheme
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver with providers
+func NewResolver(providers ...Provider) *Resolver {
+	resolver := &Resolver{providers: make(map[string]Provider)}
+	for _, provider := range providers {
+		resolver.Register(provider)
+	}
+	return resolver
+}
+
+// Register adds a provider, replacing the one registered for the same scheme
+func (r *Resolver) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider.Scheme()] = provider
+}
+
+// Schemes returns the schemes of the registered providers
+func (r *Resolver) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.providers))
+	for scheme := range r.providers {
+		schemes = append(schemes, scheme)
+	}
+	return schemes
+}
+
+// IsReference reports whether value is a reference to a registered provider
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.provider(value)
+	return ok
+}
+
+// Resolve returns the secret value references, or value itself when it is not a reference
+// to a registered provider
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	provider, reference, ok := r.provider(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", reference, err)
+	}
+	return secret, nil
+}
+
+// provider returns the provider of the scheme of a reference
+func (r *Resolver) provider(value string) (Provider, Reference, bool) {
+	reference, ok := ParseReference(value)
+	if !ok {
+		return nil, reference, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.providers[reference.Scheme]
+	return provider, reference, ok
+}
+
+// selectKey returns the key of a structured secret that reference names, or its only value
+// when the reference names no key
+func selectKey(reference Reference, values map[string]interface{}) (string, error) {
+	if reference.Key == "" {
+		if len(values) == 1 {
+			for _, value := range values {
+				return fmt.Sprint(value), nil
+			}
+		}
+		return "", fmt.Errorf("the secret has %d keys; name one with #key", len(values))
+	}
+	value, ok := values[reference.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s: %w", reference.Key, ErrNotFound)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	return fmt.Sprint(value), nil
+}
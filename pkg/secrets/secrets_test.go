@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   Reference
+		wantOK bool
+	}{
+		{value: "vault://secret/orders/db#password", want: Reference{Scheme: "vault", Path: "secret/orders/db", Key: "password"}, wantOK: true},
+		{value: "ssm:///orders/prod/db-password", want: Reference{Scheme: "ssm", Path: "/orders/prod/db-password"}, wantOK: true},
+		{value: "gsm://my-project/orders-db/latest", want: Reference{Scheme: "gsm", Path: "my-project/orders-db/latest"}, wantOK: true},
+		{value: "plain password"},
+		{value: "postgres"},
+		{value: "://missing-scheme"},
+		{value: "vault://"},
+		{value: "Vault://secret/orders"},
+		{value: "vault://secret/orders db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseReference(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("ParseReference(%q) = %+v, %v, want %+v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+			if ok && got.String() != tt.value {
+				t.Errorf("String() = %q, want %q", got.String(), tt.value)
+			}
+		})
+	}
+}
+
+// mapProvider resolves the references of its scheme from a map of structured secrets
+type mapProvider struct {
+	scheme  string
+	secrets map[string]map[string]interface{}
+}
+
+func (p mapProvider) Scheme() string { return p.scheme }
+
+func (p mapProvider) Resolve(ctx context.Context, reference Reference) (string, error) {
+	values, ok := p.secrets[reference.Path]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return selectKey(reference, values)
+}
+
+func TestResolverResolve(t *testing.T) {
+	resolver := NewResolver(mapProvider{scheme: "test", secrets: map[string]map[string]interface{}{
+		"orders/db":    {"password": "s3cret", "port": 5432},
+		"orders/token": {"value": "t0ken"},
+	}})
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr error
+	}{
+		{value: "test://orders/db#password", want: "s3cret"},
+		{value: "test://orders/db#port", want: "5432"},
+		{value: "test://orders/token", want: "t0ken"},
+		{value: "plain", want: "plain"},
+		{value: "other://orders/db#password", want: "other://orders/db#password"},
+		{value: "test://orders/db#user", wantErr: ErrNotFound},
+		{value: "test://orders/missing", wantErr: ErrNotFound},
+		{value: "test://orders/db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %v", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Resolve(%q) = %q, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Resolve(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+
+	if !resolver.IsReference("test://orders/db") || resolver.IsReference("other://orders/db") {
+		t.Errorf("IsReference reports the references of the unregistered schemes")
+	}
+}
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSMOptions are the settings of an SSMProvider
+type SSMOptions struct {
+	// Region is the AWS region of the parameters (default the region of the AWS configuration:
+	// AWS_REGION, then the region of the profile)
+	Region string
+	// Endpoint replaces the SSM endpoint of the region, for a local emulator
+	Endpoint string
+}
+
+// SSMProvider resolves ssm://name references to AWS Systems Manager parameters, decrypting
+// SecureString parameters: ssm:///orders/prod/db-password reads /orders/prod/db-password. A
+// #key reference reads a key of a parameter holding a JSON object. The credentials are
+// those of the default chain of the AWS SDK: environment variables, the shared
+// configuration and credentials files with AWS_PROFILE and SSO, web identity (EKS), the
+// container credentials endpoint (ECS) or the instance metadata service (EC2).
+type SSMProvider struct {
+	options SSMOptions
+
+	mu     sync.Mutex
+	client *ssm.Client
+}
+
+// NewSSMProvider creates an AWS SSM Parameter Store provider
+func NewSSMProvider(options SSMOptions) *SSMProvider {
+	return &SSMProvider{options: options}
+}
+
+// Scheme returns ssm
+func (p *SSMProvider) Scheme() string { return "ssm" }
+
+// Resolve reads a parameter
+func (p *SSMProvider) Resolve(ctx context.Context, reference Reference) (string, error) {
+	if reference.Path == "" {
+		return "", fmt.Errorf("the parameter name is required")
+	}
+	client, err := p.ssmClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(reference.Path),
+		WithDecryption: aws.Bool(true),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("ssm: %w", err)
+	}
+
+	value := aws.ToString(output.Parameter.Value)
+	if reference.Key == "" {
+		return value, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return "", fmt.Errorf("the parameter is not a JSON object, so it has no key %s", reference.Key)
+	}
+	return selectKey(reference, values)
+}
+
+// ssmClient returns the SSM client, loading the AWS configuration the first time
+func (p *SSMProvider) ssmClient(ctx context.Context) (*ssm.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	var loaders []func(*config.LoadOptions) error
+	if p.options.Region != "" {
+		loaders = append(loaders, config.WithRegion(p.options.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loaders...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region: set AWS_REGION or the ssm provider region")
+	}
+	p.client = ssm.NewFromConfig(cfg, func(o *ssm.Options) {
+		if p.options.Endpoint != "" {
+			o.BaseEndpoint = aws.String(p.options.Endpoint)
+		}
+	})
+	return p.client, nil
+}
@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// kubernetesTokenPath is the service account token a pod logs in to Vault with
+const kubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultOptions are the settings of a VaultProvider
+type VaultOptions struct {
+	// Address is the Vault server (default VAULT_ADDR, then https://127.0.0.1:8200)
+	Address string
+	// Token authenticates the requests (default VAULT_TOKEN)
+	Token string
+	// Namespace is the Vault Enterprise namespace (default VAULT_NAMESPACE)
+	Namespace string
+	// Role logs in with the Kubernetes auth method as this role, with the service account
+	// token of the pod, when no token is set
+	Role string
+	// AuthPath is the mount of the Kubernetes auth method (default kubernetes)
+	AuthPath string
+	// KVVersion is the version of the KV secrets engines (default 2)
+	KVVersion int
+}
+
+// VaultProvider resolves vault://mount/path#key references to keys of KV secrets. The first
+// segment of the path is the mount of the secrets engine: vault://secret/orders/db#password
+// reads the password key of orders/db in the secret engine. The client is the one of the
+// Vault API, configured by the VAULT_* environment variables (VAULT_CACERT, VAULT_SKIP_VERIFY,
+// ...) besides the options.
+type VaultProvider struct {
+	options VaultOptions
+	client  *vault.Client
+	// err is why the client could not be created, returned by Resolve
+	err error
+
+	mu sync.Mutex
+}
+
+// NewVaultProvider creates a Vault provider
+func NewVaultProvider(options VaultOptions) *VaultProvider {
+	if options.AuthPath == "" {
+		options.AuthPath = "kubernetes"
+	}
+	if options.KVVersion == 0 {
+		options.KVVersion = 2
+	}
+
+	config := vault.DefaultConfig()
+	if config.Error != nil {
+		return &VaultProvider{options: options, err: config.Error}
+	}
+	if options.Address != "" {
+		config.Address = strings.TrimRight(options.Address, "/")
+	}
+	config.Timeout = requestTimeout
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return &VaultProvider{options: options, err: err}
+	}
+	if options.Token != "" {
+		client.SetToken(options.Token)
+	}
+	if options.Namespace != "" {
+		client.SetNamespace(options.Namespace)
+	}
+	return &VaultProvider{options: options, client: client}
+}
+
+// Scheme returns vault
+func (p *VaultProvider) Scheme() string { return "vault" }
+
+// Resolve reads a key of a KV secret
+func (p *VaultProvider) Resolve(ctx context.Context, reference Reference) (string, error) {
+	if p.err != nil {
+		return "", fmt.Errorf("vault client: %w", p.err)
+	}
+	mount, path, ok := strings.Cut(strings.Trim(reference.Path, "/"), "/")
+	if !ok || path == "" {
+		return "", fmt.Errorf("the path must be mount/secret")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return "", err
+	}
+
+	var secret *vault.KVSecret
+	var err error
+	if p.options.KVVersion == 2 {
+		secret, err = p.client.KVv2(mount).Get(ctx, path)
+	} else {
+		secret, err = p.client.KVv1(mount).Get(ctx, path)
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", ErrNotFound
+	}
+	return selectKey(reference, secret.Data)
+}
+
+// authenticate logs in with the Kubernetes auth method the first time when the client has no
+// token and a role is set
+func (p *VaultProvider) authenticate(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client.Token() != "" {
+		return nil
+	}
+	if p.options.Role == "" {
+		return fmt.Errorf("no Vault token: set VAULT_TOKEN or a Kubernetes auth role")
+	}
+
+	jwt, err := os.ReadFile(kubernetesTokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	login := map[string]interface{}{"role": p.options.Role, "jwt": strings.TrimSpace(string(jwt))}
+	secret, err := p.client.Logical().WriteWithContext(ctx, "auth/"+p.options.AuthPath+"/login", login)
+	if err != nil {
+		return fmt.Errorf("vault login as %s: %w", p.options.Role, err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("vault login as %s: no token in the response", p.options.Role)
+	}
+	p.client.SetToken(secret.Auth.ClientToken)
+	return nil
+}